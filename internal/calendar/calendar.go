@@ -0,0 +1,271 @@
+// Package calendar reads the events of the day from local iCalendar files,
+// such as the calendars kept by Evolution Data Server, so they can be given
+// to the model as context.
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Variable is replaced with the events of the day in prompts.
+const Variable = "{{calendar}}"
+
+// Event is a calendar event. Recurring events are expanded by On.
+type Event struct {
+	UID      string
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time // Exclusive
+	AllDay   bool
+
+	rule         *rule
+	exDates      []time.Time // Occurrences removed from a recurring event
+	recurrenceID time.Time   // Occurrence of a recurring event this one replaces
+}
+
+// rule is the supported subset of an iCalendar recurrence rule.
+type rule struct {
+	freq     string // DAILY, WEEKLY, MONTHLY or YEARLY
+	interval int
+	count    int       // Number of occurrences, 0 if unlimited
+	until    time.Time // Last possible occurrence, zero if unlimited
+	byDay    []time.Weekday
+}
+
+// maxRecurrenceDays bounds the days scanned when counting occurrences.
+const maxRecurrenceDays = 100 * 366
+
+// DefaultSources returns the folder where Evolution Data Server keeps local
+// calendars.
+func DefaultSources() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".local", "share", "evolution", "calendar")}
+}
+
+// Load reads the events of every .ics file in sources. A source can be a
+// file or a folder, which is searched recursively. Sources that don't exist
+// are skipped, and a leading ~ stands for the home folder.
+func Load(sources []string) ([]Event, error) {
+	var events []Event
+	for _, source := range sources {
+		if rest, ok := strings.CutPrefix(source, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				source = filepath.Join(home, rest)
+			}
+		}
+		err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (path != source && !strings.EqualFold(filepath.Ext(path), ".ics")) {
+				return nil
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			parsed, err := Parse(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			events = append(events, parsed...)
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return events, fmt.Errorf("failed to read calendar: %w", err)
+		}
+	}
+	return events, nil
+}
+
+// On returns the events that take place on the day of t, in t's location,
+// ordered by start time with all-day events first. Occurrences of recurring
+// events are returned with their own start and end.
+func On(events []Event, t time.Time) []Event {
+	loc := t.Location()
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	// Occurrences replaced by a modified copy
+	replaced := make(map[string]bool)
+	for _, e := range events {
+		if !e.recurrenceID.IsZero() {
+			replaced[e.UID+civilDate(e.recurrenceID.In(loc))] = true
+		}
+	}
+
+	var result []Event
+	for _, e := range events {
+		if e.rule == nil {
+			if overlaps(e, dayStart, dayEnd) {
+				result = append(result, e)
+			}
+			continue
+		}
+		if replaced[e.UID+civilDate(dayStart)] || !e.occursOn(dayStart) {
+			continue
+		}
+
+		// Move the occurrence to the day, keeping its time and length
+		start := e.Start.In(loc)
+		occurrence := e
+		occurrence.Start = time.Date(t.Year(), t.Month(), t.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+		occurrence.End = occurrence.Start.Add(e.End.Sub(e.Start))
+		result = append(result, occurrence)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].AllDay != result[j].AllDay {
+			return result[i].AllDay
+		}
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+// overlaps reports whether e takes place between start and end.
+func overlaps(e Event, start, end time.Time) bool {
+	if !e.End.After(e.Start) {
+		return !e.Start.Before(start) && e.Start.Before(end)
+	}
+	return e.Start.Before(end) && e.End.After(start)
+}
+
+// occursOn reports whether a recurring event has an occurrence on day.
+func (e Event) occursOn(day time.Time) bool {
+	loc := day.Location()
+	first := dayNumber(e.Start.In(loc))
+	target := dayNumber(day)
+	if target < first {
+		return false
+	}
+	if !e.rule.until.IsZero() && target > dayNumber(e.rule.until.In(loc)) {
+		return false
+	}
+	for _, ex := range e.exDates {
+		if dayNumber(ex.In(loc)) == target {
+			return false
+		}
+	}
+	if !e.matches(day) {
+		return false
+	}
+	if e.rule.count <= 0 {
+		return true
+	}
+
+	// Count the occurrences up to the day
+	n := 0
+	d := e.Start.In(loc)
+	d = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i <= maxRecurrenceDays && dayNumber(d) <= target; i++ {
+		if e.matches(d) {
+			n++
+			if n > e.rule.count {
+				return false
+			}
+		}
+		d = d.AddDate(0, 0, 1)
+	}
+	return n <= e.rule.count
+}
+
+// matches reports whether day fits the recurrence rule, ignoring its limits.
+func (e Event) matches(day time.Time) bool {
+	start := e.Start.In(day.Location())
+	r := e.rule
+	switch r.freq {
+	case "DAILY":
+		return (dayNumber(day)-dayNumber(start))%int64(r.interval) == 0
+	case "WEEKLY":
+		days := r.byDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		for _, wd := range days {
+			if day.Weekday() == wd {
+				weeks := (mondayNumber(day) - mondayNumber(start)) / 7
+				return weeks%int64(r.interval) == 0
+			}
+		}
+		return false
+	case "MONTHLY":
+		months := (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+		return day.Day() == start.Day() && months%r.interval == 0
+	case "YEARLY":
+		return day.Month() == start.Month() && day.Day() == start.Day() &&
+			(day.Year()-start.Year())%r.interval == 0
+	default:
+		return false
+	}
+}
+
+// dayNumber returns the number of days between the Unix epoch and the
+// calendar date of t.
+func dayNumber(t time.Time) int64 {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// mondayNumber returns the day number of the Monday starting t's week.
+func mondayNumber(t time.Time) int64 {
+	offset := (int64(t.Weekday()) + 6) % 7
+	return dayNumber(t) - offset
+}
+
+// civilDate formats the calendar date of t.
+func civilDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// Format describes the events of the day of t for the model.
+func Format(events []Event, t time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Today is %s.", t.Format("Monday, 2 January 2006"))
+	if len(events) == 0 {
+		b.WriteString(" There are no calendar events today.")
+		return b.String()
+	}
+
+	b.WriteString(" Calendar events for today:")
+	for _, e := range events {
+		b.WriteString("\n- ")
+		if e.AllDay {
+			b.WriteString("All day")
+		} else {
+			fmt.Fprintf(&b, "%s–%s", e.Start.In(t.Location()).Format("15:04"), e.End.In(t.Location()).Format("15:04"))
+		}
+		b.WriteString(": ")
+		summary := strings.TrimSpace(e.Summary)
+		if summary == "" {
+			summary = "(untitled)"
+		}
+		b.WriteString(strings.Join(strings.Fields(summary), " "))
+		if location := strings.TrimSpace(e.Location); location != "" {
+			fmt.Fprintf(&b, " (%s)", strings.Join(strings.Fields(location), " "))
+		}
+	}
+	return b.String()
+}
+
+// Today loads the events in sources and describes the ones on the day of now.
+func Today(sources []string, now time.Time) (string, error) {
+	events, err := Load(sources)
+	if err != nil {
+		return "", err
+	}
+	return Format(On(events, now), now), nil
+}
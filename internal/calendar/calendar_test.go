@@ -0,0 +1,195 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"DTSTART:20240506T090000\r\n" +
+	"DTEND:20240506T091500\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\r\n" +
+	"EXDATE:20240515T090000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"SUMMARY:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"RECURRENCE-ID:20240520T090000\r\n" +
+	"SUMMARY:Standup (moved)\r\n" +
+	"DTSTART:20240520T100000\r\n" +
+	"DTEND:20240520T101500\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review\r\n" +
+	"SUMMARY:Quarterly review\\, with slides\r\n" +
+	"LOCATION:Room 4\r\n" +
+	"DESCRIPTION:A long description that is folded onto\r\n" +
+	"  a second line\r\n" +
+	"DTSTART:20240508T140000\r\n" +
+	"DURATION:PT1H30M\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Holi\r\n" +
+	" day\r\n" +
+	"DTSTART;VALUE=DATE:20240508\r\n" +
+	"DTEND;VALUE=DATE:20240509\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"SUMMARY:Cancelled lunch\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART:20240508T120000\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func day(d int) time.Time {
+	return time.Date(2024, 5, d, 8, 0, 0, 0, time.Local)
+}
+
+func summaries(events []Event) []string {
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Summary)
+	}
+	return names
+}
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(testICS))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("Parse() = %d events, want 4 (the cancelled one is skipped)", len(events))
+	}
+
+	review := events[2]
+	if review.Summary != "Quarterly review, with slides" || review.Location != "Room 4" {
+		t.Errorf("review = %q at %q, want unescaped summary and location", review.Summary, review.Location)
+	}
+	if got := review.End.Sub(review.Start); got != 90*time.Minute {
+		t.Errorf("review duration = %v, want 1h30m", got)
+	}
+
+	holiday := events[3]
+	if holiday.Summary != "Holiday" || !holiday.AllDay {
+		t.Errorf("holiday = %+v, want an all-day event with an unfolded summary", holiday)
+	}
+}
+
+func TestOn(t *testing.T) {
+	events, err := Parse(strings.NewReader(testICS))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want []string
+	}{
+		{"first occurrence", day(6), []string{"Standup"}},
+		{"no events", day(7), nil},
+		{"all-day event first", day(8), []string{"Holiday", "Standup", "Quarterly review, with slides"}},
+		{"excluded occurrence", day(15), nil},
+		{"later week", day(13), []string{"Standup"}},
+		{"replaced occurrence", day(20), []string{"Standup (moved)"}},
+		{"before the first occurrence", day(1), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summaries(On(events, tt.day))
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("On(%s) = %q, want %q", tt.day.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+
+	// Occurrences keep their time on the new day
+	got := On(events, day(13))
+	if len(got) == 1 && got[0].Start.Format("2006-01-02 15:04") != "2024-05-13 09:00" {
+		t.Errorf("occurrence start = %v, want 2024-05-13 09:00", got[0].Start)
+	}
+}
+
+func TestOnRecurrenceLimits(t *testing.T) {
+	start := time.Date(2024, 5, 1, 18, 0, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		rule rule
+		day  int
+		want bool
+	}{
+		{"daily", rule{freq: "DAILY", interval: 1}, 9, true},
+		{"every other day", rule{freq: "DAILY", interval: 2}, 4, false},
+		{"every other day match", rule{freq: "DAILY", interval: 2}, 5, true},
+		{"count reached", rule{freq: "DAILY", interval: 1, count: 3}, 4, false},
+		{"within count", rule{freq: "DAILY", interval: 1, count: 3}, 3, true},
+		{"after until", rule{freq: "DAILY", interval: 1, until: time.Date(2024, 5, 3, 0, 0, 0, 0, time.Local)}, 4, false},
+		{"monthly other day", rule{freq: "MONTHLY", interval: 1}, 2, false},
+		{"every two weeks", rule{freq: "WEEKLY", interval: 2}, 8, false},
+		{"every two weeks match", rule{freq: "WEEKLY", interval: 2}, 15, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.rule
+			e := Event{Summary: "Event", Start: start, End: start.Add(time.Hour), rule: &r}
+			got := len(On([]Event{e}, day(tt.day))) == 1
+			if got != tt.want {
+				t.Errorf("occurs on May %d = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2024, 5, 8, 8, 0, 0, 0, time.Local)
+	events := []Event{
+		{Summary: "Holiday", AllDay: true},
+		{
+			Summary:  "Review",
+			Location: "Room 4",
+			Start:    time.Date(2024, 5, 8, 14, 0, 0, 0, time.Local),
+			End:      time.Date(2024, 5, 8, 15, 30, 0, 0, time.Local),
+		},
+	}
+
+	want := "Today is Wednesday, 8 May 2024. Calendar events for today:\n" +
+		"- All day: Holiday\n" +
+		"- 14:00–15:30: Review (Room 4)"
+	if got := Format(events, now); got != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Format(nil, now); got != "Today is Wednesday, 8 May 2024. There are no calendar events today." {
+		t.Errorf("Format(nil) = %q", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "system")
+	os.MkdirAll(nested, 0755)
+	os.WriteFile(filepath.Join(nested, "calendar.ics"), []byte(testICS), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a calendar"), 0644)
+
+	events, err := Load([]string{dir, filepath.Join(dir, "missing")})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(events) != 4 {
+		t.Errorf("Load() = %d events, want 4", len(events))
+	}
+}
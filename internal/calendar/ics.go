@@ -0,0 +1,282 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icsProperty is a content line of an iCalendar file.
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Parse reads the events of an iCalendar (.ics) file. Cancelled events are
+// skipped, and properties that are not needed are ignored.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+
+	var events []Event
+	var current *Event
+	var cancelled bool
+	depth := 0 // Nesting inside the event, e.g. VALARM
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VEVENT"):
+			current = &Event{}
+			cancelled = false
+			depth = 0
+			continue
+		case current == nil:
+			continue
+		case prop.Name == "BEGIN":
+			depth++
+			continue
+		case prop.Name == "END" && strings.EqualFold(prop.Value, "VEVENT"):
+			if !cancelled && !current.Start.IsZero() {
+				if current.End.IsZero() {
+					current.End = current.Start
+					if current.AllDay {
+						current.End = current.Start.AddDate(0, 0, 1)
+					}
+				}
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		case prop.Name == "END":
+			depth--
+			continue
+		case depth > 0:
+			continue
+		}
+
+		switch prop.Name {
+		case "UID":
+			current.UID = prop.Value
+		case "SUMMARY":
+			current.Summary = unescapeText(prop.Value)
+		case "LOCATION":
+			current.Location = unescapeText(prop.Value)
+		case "STATUS":
+			cancelled = strings.EqualFold(prop.Value, "CANCELLED")
+		case "DTSTART":
+			t, allDay, err := parseTime(prop)
+			if err != nil {
+				return nil, err
+			}
+			current.Start, current.AllDay = t, allDay
+		case "DTEND":
+			t, _, err := parseTime(prop)
+			if err != nil {
+				return nil, err
+			}
+			current.End = t
+		case "DURATION":
+			if d, ok := parseDuration(prop.Value); ok && !current.Start.IsZero() {
+				current.End = current.Start.Add(d)
+			}
+		case "RRULE":
+			current.rule = parseRule(prop.Value)
+		case "EXDATE":
+			for _, value := range strings.Split(prop.Value, ",") {
+				t, _, err := parseTime(icsProperty{Params: prop.Params, Value: value})
+				if err == nil {
+					current.exDates = append(current.exDates, t)
+				}
+			}
+		case "RECURRENCE-ID":
+			t, _, err := parseTime(prop)
+			if err == nil {
+				current.recurrenceID = t
+			}
+		}
+	}
+	return events, nil
+}
+
+// unfoldLines reads the content lines of r, joining folded lines.
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line into its name, parameters and value.
+func parseProperty(line string) (icsProperty, bool) {
+	// The value starts at the first colon outside a quoted parameter
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return icsProperty{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := icsProperty{
+		Name:   strings.ToUpper(parts[0]),
+		Params: make(map[string]string),
+		Value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+// unescapeText decodes the escapes of an iCalendar text value.
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseTime parses a DATE or DATE-TIME value. Dates are returned as local
+// midnight and reported as all-day.
+func parseTime(prop icsProperty) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.Value)
+	if prop.Params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q: %w", value, err)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid time %q: %w", value, err)
+		}
+		return t, false, nil
+	}
+
+	// Times without a known zone are taken as local time
+	loc := time.Local
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+	return t, false, nil
+}
+
+// parseDuration parses the simple iCalendar durations used by events, such
+// as PT1H30M or P1D.
+func parseDuration(s string) (time.Duration, bool) {
+	s = strings.TrimPrefix(strings.ToUpper(s), "+")
+	if !strings.HasPrefix(s, "P") {
+		return 0, false
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, r := range s {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			number += string(r)
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, false
+			}
+			number = ""
+			switch {
+			case r == 'W':
+				total += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D':
+				total += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				total += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				total += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				total += time.Duration(n) * time.Second
+			default:
+				return 0, false
+			}
+		}
+	}
+	return total, number == ""
+}
+
+// parseRule parses the parts of an RRULE value that are supported.
+func parseRule(value string) *rule {
+	r := &rule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			if n, err := strconv.Atoi(val); err == nil && n > 0 {
+				r.interval = n
+			}
+		case "COUNT":
+			if n, err := strconv.Atoi(val); err == nil {
+				r.count = n
+			}
+		case "UNTIL":
+			if t, _, err := parseTime(icsProperty{Value: val}); err == nil {
+				r.until = t
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				if wd, ok := weekdays[strings.ToUpper(day)]; ok {
+					r.byDay = append(r.byDay, wd)
+				}
+			}
+		}
+	}
+	return r
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
@@ -5,16 +5,98 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // AppConfig holds the application-wide settings.
 type AppConfig struct {
-	DefaultModel       string `json:"default_model"`
-	ResponseLanguage   string `json:"response_language"` // "auto", "en", "es", etc.
-	GlobalSystemPrompt string `json:"global_system_prompt"`
-	SidebarVisible     bool   `json:"sidebar_visible"`
+	DefaultModel       string            `json:"default_model"`
+	ResponseLanguage   string            `json:"response_language"` // "auto", "en", "es", etc.
+	GlobalSystemPrompt string            `json:"global_system_prompt"`
+	SidebarVisible     bool              `json:"sidebar_visible"`
+	InterfaceLanguage  string            `json:"interface_language"`            // "auto" (system language), "en", "es", etc.
+	PromptWarnTokens   int               `json:"prompt_warn_tokens"`            // Confirm before sending larger prompts (0 = never)
+	SummarizeTokens    int               `json:"summarize_history_tokens"`      // Summarize older turns once the history is larger (0 = never)
+	MaxMessageLength   int               `json:"max_message_length"`            // Longer messages are attached as a file (0 = never)
+	MaxTableRows       int               `json:"max_table_rows"`                // Rows of each attached spreadsheet sent to the model (0 = all)
+	AutoArchiveDays    int               `json:"auto_archive_days"`             // Archive chats unused for this many days (0 = never)
+	UtilityModel       string            `json:"utility_model"`                 // Model for titles and self-review ("" = chat model)
+	SelfReview         bool              `json:"self_review"`                   // Critique and revise each response (experimental)
+	MultiAgent         bool              `json:"multi_agent"`                   // Show the button starting a conversation between two models (experimental)
+	KeepAlive          string            `json:"keep_alive"`                    // How long models stay loaded after a response, e.g. "10m" ("" = server default)
+	Endpoints          []Endpoint        `json:"endpoints"`                     // Named Ollama servers (empty = local default)
+	ActiveEndpoint     string            `json:"active_endpoint"`               // Name of the endpoint in use
+	ConfirmRemoteFiles bool              `json:"confirm_remote_files"`          // Ask before sending attachments to a server on another machine
+	Hooks              []Hook            `json:"hooks,omitempty"`               // Commands run on events, see HookCommands
+	PipeCommands       []PipeCommand     `json:"pipes,omitempty"`               // Commands responses can be sent to
+	NotesFolder        string            `json:"notes_folder"`                  // Markdown folder for "Send to notes" ("" = disabled)
+	NotesTags          []string          `json:"notes_tags"`                    // Tags in the front matter of new notes
+	ExportFileName     string            `json:"export_file_name"`              // Template of the file name of exported chats, e.g. "{{date}}-{{slug}}" ("" = title)
+	ExportFrontMatter  bool              `json:"export_front_matter"`           // Start Markdown exports of one chat with YAML front matter
+	CalendarEnabled    bool              `json:"calendar_enabled"`              // Fill {{calendar}} in prompts with today's events
+	CalendarSources    []string          `json:"calendar_sources"`              // .ics files or folders (empty = Evolution calendars)
+	BuiltinTools       bool              `json:"builtin_tools"`                 // Offer time, unit and calculator tools to models that support tools
+	ImageOCR           bool              `json:"image_ocr"`                     // Send the text of images to models without vision, read with tesseract
+	SpeechCommand      string            `json:"speech_command"`                // Transcribes the recording at {file} for the microphone button ("" = no button)
+	ReadAloud          bool              `json:"read_aloud"`                    // Read each completed response aloud, unless the chat is muted
+	NotebookOutputs    bool              `json:"notebook_outputs"`              // Attach the output of notebook code cells along with the code
+	RunSnippets        bool              `json:"run_snippets"`                  // Show a button running shell and Python code blocks in a sandbox
+	CodeWrap           bool              `json:"code_wrap"`                     // Wrap long lines of code blocks instead of scrolling them
+	EnabledPlugins     []string          `json:"enabled_plugins"`               // Names of the plugins whose readers and tools are used
+	Shortcuts          map[string]string `json:"shortcuts,omitempty"`           // Keyboard shortcuts changed from DefaultShortcuts ("" = none)
+	RunInBackground    bool              `json:"run_in_background"`             // Keep running when the last window closes
+	MutedNotifications []string          `json:"muted_notifications,omitempty"` // Events not notified, see NotifyEvents
+	QuietHours         bool              `json:"quiet_hours"`                   // No notifications from QuietHoursStart to QuietHoursEnd
+	QuietHoursStart    int               `json:"quiet_hours_start"`             // Hour quiet hours start, 0-23
+	QuietHoursEnd      int               `json:"quiet_hours_end"`               // Hour quiet hours end, 0-23
+	UpdateCheck        bool              `json:"update_check"`                  // Check GitHub weekly for new releases
+	UpdateChannel      string            `json:"update_channel"`                // "stable" or "prerelease"
+	LastUpdateCheck    time.Time         `json:"last_update_check"`             // When releases were last checked
+	SkippedUpdate      string            `json:"skipped_update"`                // Release version the user chose to skip
+	ColorScheme        string            `json:"color_scheme"`                  // "system", "light" or "dark"
+	AccentColor        string            `json:"accent_color"`                  // Hex color such as "#3584e4" ("" = system accent)
+	MessageDensity     string            `json:"message_density"`               // "compact", "comfortable" or "spacious"
+	PlainTextMessages  bool              `json:"plain_text_messages"`           // Show messages as plain text with their structure in words, for screen readers
+	SyntaxStyleLight   string            `json:"syntax_style_light"`            // Chroma style of code blocks in the light color scheme
+	SyntaxStyleDark    string            `json:"syntax_style_dark"`             // Chroma style of code blocks in the dark color scheme
+	Suggestions        []Suggestion      `json:"suggestions"`                   // Pills of the welcome screen (nil = DefaultSuggestions, empty = none)
+	WindowWidth        int               `json:"window_width"`                  // Size of the last window closed (0 = default)
+	WindowHeight       int               `json:"window_height"`
+	WindowMaximized    bool              `json:"window_maximized"`
 }
 
+// DefaultPromptWarnTokens is the default prompt size that triggers a confirmation.
+const DefaultPromptWarnTokens = 8000
+
+// DefaultMaxMessageLength is the default message length, in characters,
+// above which the text is moved to an attachment.
+const DefaultMaxMessageLength = 20000
+
+// DefaultMaxTableRows is the default number of rows sent of each sheet of
+// an attached spreadsheet.
+const DefaultMaxTableRows = 200
+
+// Color schemes for AppConfig.ColorScheme.
+const (
+	ColorSchemeSystem = "system"
+	ColorSchemeLight  = "light"
+	ColorSchemeDark   = "dark"
+)
+
+// Default Chroma styles of code blocks for AppConfig.SyntaxStyleLight and
+// AppConfig.SyntaxStyleDark.
+const (
+	DefaultSyntaxStyleLight = "github"
+	DefaultSyntaxStyleDark  = "dracula"
+)
+
+// Message densities for AppConfig.MessageDensity.
+const (
+	DensityCompact     = "compact"
+	DensityComfortable = "comfortable"
+	DensitySpacious    = "spacious"
+)
+
 // BaseFormatPrompts contains formatting instructions that are always prepended
 // to the system prompt to guide the model toward clean Markdown output.
 var BaseFormatPrompts = map[string]string{
@@ -68,8 +150,25 @@ func DefaultConfig() *AppConfig {
 	return &AppConfig{
 		DefaultModel:       "",
 		ResponseLanguage:   "auto",
+		InterfaceLanguage:  "auto",
 		GlobalSystemPrompt: "",
 		SidebarVisible:     true,
+		PromptWarnTokens:   DefaultPromptWarnTokens,
+		MaxMessageLength:   DefaultMaxMessageLength,
+		MaxTableRows:       DefaultMaxTableRows,
+		BuiltinTools:       true,
+		ConfirmRemoteFiles: true,
+		ImageOCR:           true,
+		NotebookOutputs:    true,
+		CodeWrap:           true,
+		UpdateCheck:        true,
+		UpdateChannel:      "stable",
+		ColorScheme:        ColorSchemeSystem,
+		MessageDensity:     DensityComfortable,
+		SyntaxStyleLight:   DefaultSyntaxStyleLight,
+		SyntaxStyleDark:    DefaultSyntaxStyleDark,
+		QuietHoursStart:    DefaultQuietHoursStart,
+		QuietHoursEnd:      DefaultQuietHoursEnd,
 	}
 }
 
@@ -117,7 +216,13 @@ func (c *AppConfig) Save() error {
 
 // LanguageInstruction returns the system prompt instruction for the configured language.
 func (c *AppConfig) LanguageInstruction() string {
-	switch c.ResponseLanguage {
+	return LanguageInstructionFor(c.ResponseLanguage)
+}
+
+// LanguageInstructionFor returns the system prompt instruction for the given language code.
+// Returns an empty string for "auto" or unknown languages.
+func LanguageInstructionFor(lang string) string {
+	switch lang {
 	case "en":
 		return "Always respond in English."
 	case "es":
@@ -133,11 +238,40 @@ func (c *AppConfig) LanguageInstruction() string {
 	}
 }
 
+// EffectiveUtilityModel returns the model used for background tasks such as
+// title generation and self-review, falling back to the chat model.
+func (c *AppConfig) EffectiveUtilityModel(chatModel string) string {
+	if c.UtilityModel != "" {
+		return c.UtilityModel
+	}
+	return chatModel
+}
+
+// EffectiveKeepAlive returns how long the model of a chat stays loaded
+// after a response. A non-empty chat keep-alive overrides the global one.
+func (c *AppConfig) EffectiveKeepAlive(chatKeepAlive string) string {
+	if keepAlive := strings.TrimSpace(chatKeepAlive); keepAlive != "" {
+		return keepAlive
+	}
+	return strings.TrimSpace(c.KeepAlive)
+}
+
+// EffectiveLanguage returns the response language for a chat.
+// A non-empty chat language overrides the global ResponseLanguage.
+func (c *AppConfig) EffectiveLanguage(chatLanguage string) string {
+	if chatLanguage != "" {
+		return chatLanguage
+	}
+	return c.ResponseLanguage
+}
+
 // GetEffectiveSystemPrompt returns the system prompt with base formatting
 // instructions prepended and language instruction appended.
-func (c *AppConfig) GetEffectiveSystemPrompt(chatPrompt string) string {
-	// Determine effective language
-	effectiveLang := c.ResponseLanguage
+// chatLanguage overrides the global ResponseLanguage when not empty.
+func (c *AppConfig) GetEffectiveSystemPrompt(chatPrompt, chatLanguage string) string {
+	// Determine effective language (chat-specific has priority over global)
+	lang := c.EffectiveLanguage(chatLanguage)
+	effectiveLang := lang
 	if effectiveLang == "" || effectiveLang == "auto" {
 		effectiveLang = "en"
 	}
@@ -155,7 +289,7 @@ func (c *AppConfig) GetEffectiveSystemPrompt(chatPrompt string) string {
 	}
 
 	// Add language instruction if configured
-	if langInstruction := c.LanguageInstruction(); langInstruction != "" {
+	if langInstruction := LanguageInstructionFor(lang); langInstruction != "" {
 		parts = append(parts, langInstruction)
 	}
 
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetEffectiveSystemPrompt_Language(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalLang   string
+		chatLanguage string
+		want         string
+		notWant      string
+	}{
+		{
+			name:       "global language applies",
+			globalLang: "es",
+			want:       "Siempre responde en español.",
+		},
+		{
+			name:         "chat language overrides global",
+			globalLang:   "es",
+			chatLanguage: "de",
+			want:         "Antworte immer auf Deutsch.",
+			notWant:      "Siempre responde en español.",
+		},
+		{
+			name:         "chat language overrides auto",
+			globalLang:   "auto",
+			chatLanguage: "fr",
+			want:         "Réponds toujours en français.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ResponseLanguage = tt.globalLang
+
+			got := cfg.GetEffectiveSystemPrompt("", tt.chatLanguage)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("GetEffectiveSystemPrompt() = %q, want it to contain %q", got, tt.want)
+			}
+			if tt.notWant != "" && strings.Contains(got, tt.notWant) {
+				t.Errorf("GetEffectiveSystemPrompt() = %q, should not contain %q", got, tt.notWant)
+			}
+		})
+	}
+}
+
+func TestGetEffectiveSystemPrompt_ChatPromptPriority(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GlobalSystemPrompt = "global prompt"
+
+	if got := cfg.GetEffectiveSystemPrompt("", ""); !strings.Contains(got, "global prompt") {
+		t.Errorf("expected global prompt in %q", got)
+	}
+
+	got := cfg.GetEffectiveSystemPrompt("chat prompt", "")
+	if !strings.Contains(got, "chat prompt") || strings.Contains(got, "global prompt") {
+		t.Errorf("expected only chat prompt in %q", got)
+	}
+}
+
+func TestEffectiveUtilityModel(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.EffectiveUtilityModel("llama3"); got != "llama3" {
+		t.Errorf("EffectiveUtilityModel() = %q, want chat model %q", got, "llama3")
+	}
+
+	cfg.UtilityModel = "qwen2.5:0.5b"
+	if got := cfg.EffectiveUtilityModel("llama3"); got != "qwen2.5:0.5b" {
+		t.Errorf("EffectiveUtilityModel() = %q, want %q", got, "qwen2.5:0.5b")
+	}
+}
+
+func TestEffectiveKeepAlive(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.EffectiveKeepAlive(""); got != "" {
+		t.Errorf("EffectiveKeepAlive() = %q, want server default", got)
+	}
+
+	cfg.KeepAlive = "30m"
+	if got := cfg.EffectiveKeepAlive(""); got != "30m" {
+		t.Errorf("EffectiveKeepAlive() = %q, want %q", got, "30m")
+	}
+	if got := cfg.EffectiveKeepAlive(" -1 "); got != "-1" {
+		t.Errorf("EffectiveKeepAlive() = %q, want chat keep-alive %q", got, "-1")
+	}
+}
+
+func TestLoadConfig_CodeWrap(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(GetConfigDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Settings saved before the option wrap code blocks
+	if err := os.WriteFile(GetConfigFilePath(), []byte(`{"default_model": "llama3"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.CodeWrap {
+		t.Error("CodeWrap = false, want long lines wrapped by default")
+	}
+
+	cfg.CodeWrap = false
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if cfg, _ = LoadConfig(); cfg.CodeWrap {
+		t.Error("CodeWrap = true after saving false")
+	}
+}
@@ -1,5 +1,6 @@
 // Package config provides application configuration and path management.
-// It follows XDG Base Directory Specification for Linux.
+// It follows XDG Base Directory Specification for Linux, unless running in
+// portable mode.
 package config
 
 import (
@@ -13,11 +14,17 @@ const (
 
 	// DatabaseName is the SQLite database filename
 	DatabaseName = "guanaco.db"
+
+	// UserStyleName is the optional user stylesheet filename
+	UserStyleName = "style.css"
 )
 
 // GetDataDir returns the path to the application data directory.
 // Respects XDG_DATA_HOME, defaults to ~/.local/share/guanaco
 func GetDataDir() string {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "data")
+	}
 	dataHome := os.Getenv("XDG_DATA_HOME")
 	if dataHome == "" {
 		home, _ := os.UserHomeDir()
@@ -29,6 +36,9 @@ func GetDataDir() string {
 // GetConfigDir returns the path to the application config directory.
 // Respects XDG_CONFIG_HOME, defaults to ~/.config/guanaco
 func GetConfigDir() string {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "config")
+	}
 	configHome := os.Getenv("XDG_CONFIG_HOME")
 	if configHome == "" {
 		home, _ := os.UserHomeDir()
@@ -42,6 +52,24 @@ func GetDatabasePath() string {
 	return filepath.Join(GetDataDir(), DatabaseName)
 }
 
+// GetPluginsDir returns the path to the folder holding plugins, one per
+// subfolder.
+func GetPluginsDir() string {
+	return filepath.Join(GetConfigDir(), "plugins")
+}
+
+// GetUserStylePath returns the full path to the optional user stylesheet,
+// loaded after the built-in one to customize the look of the app.
+func GetUserStylePath() string {
+	return filepath.Join(GetConfigDir(), UserStyleName)
+}
+
+// GetLocaleDir returns the path to the folder of translation catalogs that
+// take precedence over the built-in ones, as locale/<lang>/LC_MESSAGES/guanaco.mo.
+func GetLocaleDir() string {
+	return filepath.Join(GetDataDir(), "locale")
+}
+
 // EnsureDirectories creates the necessary application directories if they don't exist.
 func EnsureDirectories() error {
 	dirs := []string{
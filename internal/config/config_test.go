@@ -43,6 +43,27 @@ func TestGetDatabasePath(t *testing.T) {
 	}
 }
 
+func TestGetUserStylePath(t *testing.T) {
+	original := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", original)
+
+	os.Setenv("XDG_CONFIG_HOME", "/tmp/test-xdg-config")
+
+	want := "/tmp/test-xdg-config/guanaco/style.css"
+	if got := GetUserStylePath(); got != want {
+		t.Errorf("GetUserStylePath() = %q, want %q", got, want)
+	}
+}
+
+func TestGetLocaleDir(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/tmp/test-xdg-data")
+
+	want := "/tmp/test-xdg-data/guanaco/locale"
+	if got := GetLocaleDir(); got != want {
+		t.Errorf("GetLocaleDir() = %q, want %q", got, want)
+	}
+}
+
 func TestGetDataDir_RespectsXDGDataHome(t *testing.T) {
 	// Save original and restore after test
 	original := os.Getenv("XDG_DATA_HOME")
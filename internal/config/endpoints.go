@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// unixScheme starts the URL of a server listening on a Unix socket, as in
+// "unix:///run/ollama/ollama.sock".
+const unixScheme = "unix://"
+
+// Endpoint is a named Ollama server.
+type Endpoint struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// Headers are sent with every request to the server, for proxies that
+	// require authentication.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// SocketPath returns the path of the Unix socket of the endpoint, or an
+// empty string when it is reached over HTTP.
+func (e Endpoint) SocketPath() string {
+	if path, ok := strings.CutPrefix(e.URL, unixScheme); ok {
+		return path
+	}
+	return ""
+}
+
+// IsLocalURL reports whether the server at raw runs on this machine: it
+// listens on a Unix socket or on a loopback address. Anything sent to
+// other servers leaves the machine.
+func IsLocalURL(raw string) bool {
+	if raw == "" || strings.HasPrefix(raw, unixScheme) {
+		return true
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// IsLoopbackURL reports whether the server at raw is one "ollama serve"
+// started on this machine answers: an HTTP server on a loopback address.
+// Unix sockets are left out, as ollama serve listens on TCP.
+func IsLoopbackURL(raw string) bool {
+	return !strings.HasPrefix(raw, unixScheme) && IsLocalURL(raw)
+}
+
+// ActiveEndpointInfo returns the active endpoint. If the active name is
+// unknown, the first endpoint is used. Returns false when no endpoints are
+// configured.
+func (c *AppConfig) ActiveEndpointInfo() (Endpoint, bool) {
+	for _, e := range c.Endpoints {
+		if e.Name == c.ActiveEndpoint {
+			return e, true
+		}
+	}
+	if len(c.Endpoints) > 0 {
+		return c.Endpoints[0], true
+	}
+	return Endpoint{}, false
+}
+
+// ActiveEndpointURL returns the URL of the active endpoint. Returns an
+// empty string when no endpoints are configured.
+func (c *AppConfig) ActiveEndpointURL() string {
+	e, _ := c.ActiveEndpointInfo()
+	return e.URL
+}
+
+// NormalizeEndpointURL validates a server URL, adding "http://" when no
+// scheme is given and removing trailing slashes. A "unix:" URL names the
+// socket of a server on this machine.
+func NormalizeEndpointURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("server URL is empty")
+	}
+	if path, ok := strings.CutPrefix(raw, "unix:"); ok {
+		path = "/" + strings.TrimLeft(path, "/")
+		if path == "/" {
+			return "", fmt.Errorf("invalid server URL %q: missing socket path", raw)
+		}
+		return unixScheme + path, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid server URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q: missing host", raw)
+	}
+
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// ParseHeaders reads request headers written one per line as
+// "Name: value". Blank lines are skipped.
+func ParseHeaders(text string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q: expected \"Name: value\"", line)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	return headers, nil
+}
+
+// FormatHeaders writes headers one per line as "Name: value", sorted by
+// name, as read by ParseHeaders.
+func FormatHeaders(headers map[string]string) string {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		fmt.Fprintf(&b, "%s: %s\n", name, headers[name])
+	}
+	return b.String()
+}
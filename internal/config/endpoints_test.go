@@ -0,0 +1,130 @@
+package config
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestActiveEndpointURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []Endpoint
+		active    string
+		want      string
+	}{
+		{"none configured", nil, "", ""},
+		{"active by name", []Endpoint{{Name: "Local", URL: "http://localhost:11434"}, {Name: "GPU box", URL: "http://gpu:11434"}}, "GPU box", "http://gpu:11434"},
+		{"unknown active falls back to first", []Endpoint{{Name: "Local", URL: "http://localhost:11434"}}, "Gone", "http://localhost:11434"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Endpoints = tt.endpoints
+			cfg.ActiveEndpoint = tt.active
+			if got := cfg.ActiveEndpointURL(); got != tt.want {
+				t.Errorf("ActiveEndpointURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEndpointURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"http://localhost:11434", "http://localhost:11434", false},
+		{"  192.168.1.10:11434/ ", "http://192.168.1.10:11434", false},
+		{"https://ollama.example.com/", "https://ollama.example.com", false},
+		{"", "", true},
+		{"ftp://host", "", true},
+		{"http://", "", true},
+		{"unix:///run/ollama/ollama.sock", "unix:///run/ollama/ollama.sock", false},
+		{"unix:/run/ollama/ollama.sock", "unix:///run/ollama/ollama.sock", false},
+		{"unix://", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := NormalizeEndpointURL(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeEndpointURL(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeEndpointURL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointSocketPath(t *testing.T) {
+	if got := (Endpoint{URL: "unix:///run/ollama/ollama.sock"}).SocketPath(); got != "/run/ollama/ollama.sock" {
+		t.Errorf("SocketPath() = %q, want %q", got, "/run/ollama/ollama.sock")
+	}
+	if got := (Endpoint{URL: "http://localhost:11434"}).SocketPath(); got != "" {
+		t.Errorf("SocketPath() = %q for an HTTP endpoint, want empty", got)
+	}
+}
+
+func TestIsLocalURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"", true},
+		{"http://localhost:11434", true},
+		{"http://ollama.localhost", true},
+		{"http://127.0.0.1:11434", true},
+		{"http://127.0.1.1", true},
+		{"http://[::1]:11434", true},
+		{"unix:///run/ollama/ollama.sock", true},
+		{"http://192.168.1.10:11434", false},
+		{"https://ollama.example.com", false},
+		{"http://localhost.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := IsLocalURL(tt.raw); got != tt.want {
+			t.Errorf("IsLocalURL(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestIsLoopbackURL(t *testing.T) {
+	tests := map[string]bool{
+		"":                               true,
+		"http://localhost:11434":         true,
+		"http://[::1]:11434":             true,
+		"unix:///run/ollama/ollama.sock": false,
+		"http://192.168.1.10:11434":      false,
+	}
+	for raw, want := range tests {
+		if got := IsLoopbackURL(raw); got != want {
+			t.Errorf("IsLoopbackURL(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders("Authorization: Bearer abc:def\n\n  X-Team :  ml \n")
+	if err != nil {
+		t.Fatalf("ParseHeaders() error = %v", err)
+	}
+	want := map[string]string{"Authorization": "Bearer abc:def", "X-Team": "ml"}
+	if !maps.Equal(headers, want) {
+		t.Errorf("ParseHeaders() = %v, want %v", headers, want)
+	}
+	if got := FormatHeaders(headers); got != "Authorization: Bearer abc:def\nX-Team: ml\n" {
+		t.Errorf("FormatHeaders() = %q", got)
+	}
+
+	if headers, err := ParseHeaders("  \n"); err != nil || headers != nil {
+		t.Errorf("ParseHeaders(blank) = %v, %v, want nil, nil", headers, err)
+	}
+	for _, text := range []string{"no colon", ": value", "Bad Name: value"} {
+		if _, err := ParseHeaders(text); err == nil {
+			t.Errorf("ParseHeaders(%q) error = nil, want error", text)
+		}
+	}
+}
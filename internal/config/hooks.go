@@ -0,0 +1,21 @@
+package config
+
+import "strings"
+
+// Hook is a shell command run when an event happens, e.g.
+// {"event": "response-completed", "command": "~/bin/save-answer"}.
+type Hook struct {
+	Event   string `json:"event"`
+	Command string `json:"command"`
+}
+
+// HookCommands returns the commands of the hooks configured for event.
+func (c *AppConfig) HookCommands(event string) []string {
+	var commands []string
+	for _, h := range c.Hooks {
+		if h.Event == event && strings.TrimSpace(h.Command) != "" {
+			commands = append(commands, h.Command)
+		}
+	}
+	return commands
+}
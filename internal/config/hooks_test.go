@@ -0,0 +1,25 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppConfig_HookCommands(t *testing.T) {
+	cfg := &AppConfig{Hooks: []Hook{
+		{Event: "response-completed", Command: "save-answer"},
+		{Event: "model-pulled", Command: "notify-send pulled"},
+		{Event: "response-completed", Command: "  "},
+		{Event: "response-completed", Command: "tee -a log.jsonl"},
+	}}
+
+	got := cfg.HookCommands("response-completed")
+	want := []string{"save-answer", "tee -a log.jsonl"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HookCommands() = %v, want %v", got, want)
+	}
+
+	if got := cfg.HookCommands("chat-exported"); got != nil {
+		t.Errorf("HookCommands() = %v for an event without hooks, want nil", got)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"slices"
+	"time"
+)
+
+// Events that send a desktop notification when no Guanaco window has the
+// focus. Each can be turned off in the settings.
+const (
+	NotifyResponse = "response"
+	NotifyDownload = "download"
+	NotifyError    = "error"
+)
+
+// NotifyEvents lists the notification events, in the order they are shown
+// in the settings.
+var NotifyEvents = []string{
+	NotifyResponse,
+	NotifyDownload,
+	NotifyError,
+}
+
+// Default quiet hours, used once they are turned on.
+const (
+	DefaultQuietHoursStart = 22
+	DefaultQuietHoursEnd   = 7
+)
+
+// NotifyEnabled reports whether event sends notifications.
+func (c *AppConfig) NotifyEnabled(event string) bool {
+	return !slices.Contains(c.MutedNotifications, event)
+}
+
+// SetNotifyEnabled turns notifications for event on or off.
+func (c *AppConfig) SetNotifyEnabled(event string, enabled bool) {
+	c.MutedNotifications = slices.DeleteFunc(c.MutedNotifications, func(e string) bool {
+		return e == event
+	})
+	if !enabled {
+		c.MutedNotifications = append(c.MutedNotifications, event)
+	}
+	if len(c.MutedNotifications) == 0 {
+		c.MutedNotifications = nil
+	}
+}
+
+// InQuietHours reports whether t falls in the quiet hours. They start at
+// the hour QuietHoursStart and end at the hour QuietHoursEnd, the next day
+// when the end is earlier than the start.
+func (c *AppConfig) InQuietHours(t time.Time) bool {
+	if !c.QuietHours || c.QuietHoursStart == c.QuietHoursEnd {
+		return false
+	}
+	hour := t.Hour()
+	if c.QuietHoursStart < c.QuietHoursEnd {
+		return hour >= c.QuietHoursStart && hour < c.QuietHoursEnd
+	}
+	return hour >= c.QuietHoursStart || hour < c.QuietHoursEnd
+}
+
+// ShouldNotify reports whether event sends a notification at t.
+func (c *AppConfig) ShouldNotify(event string, t time.Time) bool {
+	return c.NotifyEnabled(event) && !c.InQuietHours(t)
+}
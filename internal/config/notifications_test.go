@@ -0,0 +1,88 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetNotifyEnabled(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, event := range NotifyEvents {
+		if !cfg.NotifyEnabled(event) {
+			t.Errorf("NotifyEnabled(%q) = false by default", event)
+		}
+	}
+
+	cfg.SetNotifyEnabled(NotifyDownload, false)
+	cfg.SetNotifyEnabled(NotifyDownload, false)
+	if cfg.NotifyEnabled(NotifyDownload) {
+		t.Error("NotifyEnabled(download) = true after turning it off")
+	}
+	if len(cfg.MutedNotifications) != 1 {
+		t.Errorf("MutedNotifications = %v, want download once", cfg.MutedNotifications)
+	}
+	if !cfg.NotifyEnabled(NotifyResponse) {
+		t.Error("NotifyEnabled(response) = false, want only download off")
+	}
+
+	cfg.SetNotifyEnabled(NotifyDownload, true)
+	if !cfg.NotifyEnabled(NotifyDownload) || cfg.MutedNotifications != nil {
+		t.Errorf("MutedNotifications = %v after turning download on again, want nil", cfg.MutedNotifications)
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 5, 1, hour, 30, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		start, end int
+		hour       int
+		want       bool
+	}{
+		{"off", false, 22, 7, 23, false},
+		{"overnight late", true, 22, 7, 23, true},
+		{"overnight early", true, 22, 7, 6, true},
+		{"overnight end", true, 22, 7, 7, false},
+		{"overnight day", true, 22, 7, 12, false},
+		{"same day inside", true, 13, 15, 14, true},
+		{"same day start", true, 13, 15, 13, true},
+		{"same day outside", true, 13, 15, 15, false},
+		{"empty range", true, 9, 9, 9, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.QuietHours = tt.enabled
+			cfg.QuietHoursStart = tt.start
+			cfg.QuietHoursEnd = tt.end
+			if got := cfg.InQuietHours(at(tt.hour)); got != tt.want {
+				t.Errorf("InQuietHours(%d:30) = %v, want %v", tt.hour, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldNotify(t *testing.T) {
+	cfg := DefaultConfig()
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	night := time.Date(2024, 5, 1, 23, 0, 0, 0, time.Local)
+
+	if !cfg.ShouldNotify(NotifyError, night) {
+		t.Error("ShouldNotify(error) = false without quiet hours")
+	}
+	cfg.QuietHours = true
+	if cfg.ShouldNotify(NotifyError, night) {
+		t.Error("ShouldNotify(error) = true during quiet hours")
+	}
+	if !cfg.ShouldNotify(NotifyError, noon) {
+		t.Error("ShouldNotify(error) = false outside quiet hours")
+	}
+	cfg.SetNotifyEnabled(NotifyError, false)
+	if cfg.ShouldNotify(NotifyError, noon) {
+		t.Error("ShouldNotify(error) = true with errors turned off")
+	}
+}
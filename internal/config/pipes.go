@@ -0,0 +1,41 @@
+package config
+
+import "strings"
+
+// PipeCommand is a command a response can be sent to from its actions, e.g.
+// {"name": "Copy to clipboard", "command": "wl-copy"}.
+type PipeCommand struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	Trusted bool   `json:"trusted,omitempty"` // Run without asking first
+}
+
+// Label returns the name shown for the command, the command itself when it
+// has no name.
+func (p PipeCommand) Label() string {
+	if name := strings.TrimSpace(p.Name); name != "" {
+		return name
+	}
+	return strings.TrimSpace(p.Command)
+}
+
+// Pipes returns the configured pipe commands that have a command.
+func (c *AppConfig) Pipes() []PipeCommand {
+	var pipes []PipeCommand
+	for _, p := range c.PipeCommands {
+		if strings.TrimSpace(p.Command) != "" {
+			pipes = append(pipes, p)
+		}
+	}
+	return pipes
+}
+
+// TrustPipeCommand marks the pipe commands running command as trusted, so
+// they run without a confirmation.
+func (c *AppConfig) TrustPipeCommand(command string) {
+	for i := range c.PipeCommands {
+		if c.PipeCommands[i].Command == command {
+			c.PipeCommands[i].Trusted = true
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppConfig_Pipes(t *testing.T) {
+	cfg := &AppConfig{PipeCommands: []PipeCommand{
+		{Name: "Clipboard", Command: "wl-copy"},
+		{Name: "Empty", Command: " "},
+		{Command: "pandoc -o ~/answer.docx"},
+	}}
+
+	got := cfg.Pipes()
+	want := []PipeCommand{
+		{Name: "Clipboard", Command: "wl-copy"},
+		{Command: "pandoc -o ~/answer.docx"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Pipes() = %v, want %v", got, want)
+	}
+
+	if got[0].Label() != "Clipboard" || got[1].Label() != "pandoc -o ~/answer.docx" {
+		t.Errorf("Label() = %q, %q", got[0].Label(), got[1].Label())
+	}
+}
+
+func TestAppConfig_TrustPipeCommand(t *testing.T) {
+	cfg := &AppConfig{PipeCommands: []PipeCommand{
+		{Name: "Clipboard", Command: "wl-copy"},
+		{Name: "Word", Command: "pandoc -o ~/answer.docx"},
+	}}
+
+	cfg.TrustPipeCommand("wl-copy")
+
+	if !cfg.PipeCommands[0].Trusted {
+		t.Error("TrustPipeCommand() did not trust the command")
+	}
+	if cfg.PipeCommands[1].Trusted {
+		t.Error("TrustPipeCommand() trusted another command")
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	// PortableFlag is the command line option that turns on portable mode.
+	PortableFlag = "--portable"
+
+	// PortableMarkerName is the file next to the executable that turns on
+	// portable mode without the command line option.
+	PortableMarkerName = "portable.flag"
+
+	// PortableDirName is the directory beside the executable that holds the
+	// settings, chats and logs in portable mode.
+	PortableDirName = "guanaco-data"
+)
+
+// portableDir is the directory used instead of the XDG directories in
+// portable mode ("" when not portable).
+var portableDir string
+
+// SetPortableDir stores settings, chats and logs in dir instead of the XDG
+// directories. An empty dir turns portable mode off.
+func SetPortableDir(dir string) {
+	portableDir = dir
+}
+
+// PortableDir returns the directory used in portable mode, or "" when the
+// XDG directories are used.
+func PortableDir() string {
+	return portableDir
+}
+
+// DetectPortable reports whether portable mode was asked for, with the
+// PortableFlag option in args or a PortableMarkerName file next to
+// executable. It returns the portable directory ("" when not portable) and
+// args without the option, which other command line parsers don't know.
+func DetectPortable(args []string, executable string) (string, []string) {
+	rest := make([]string, 0, len(args))
+	flagged := false
+	for i, arg := range args {
+		if i > 0 && arg == PortableFlag {
+			flagged = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+
+	if executable == "" {
+		return "", rest
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	exeDir := filepath.Dir(executable)
+	if !flagged {
+		if _, err := os.Stat(filepath.Join(exeDir, PortableMarkerName)); err != nil {
+			return "", rest
+		}
+	}
+	return filepath.Join(exeDir, PortableDirName), rest
+}
+
+// SetupPortable turns on portable mode when args or a marker file next to
+// the running executable ask for it, and returns args without the portable
+// option. It must run before the settings, database or log are opened.
+func SetupPortable(args []string) []string {
+	executable, _ := os.Executable()
+	dir, rest := DetectPortable(args, executable)
+	if dir != "" {
+		SetPortableDir(dir)
+	}
+	return rest
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectPortable(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "guanaco")
+	if err := os.WriteFile(executable, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, rest := DetectPortable([]string{"guanaco"}, executable)
+	if got != "" || !reflect.DeepEqual(rest, []string{"guanaco"}) {
+		t.Errorf("DetectPortable() without flag = %q, %v; want not portable", got, rest)
+	}
+
+	want := filepath.Join(dir, PortableDirName)
+	got, rest = DetectPortable([]string{"guanaco", "--portable", "--verbose"}, executable)
+	if got != want || !reflect.DeepEqual(rest, []string{"guanaco", "--verbose"}) {
+		t.Errorf("DetectPortable() with option = %q, %v; want %q without the option", got, rest, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, PortableMarkerName), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = DetectPortable([]string{"guanaco"}, executable)
+	if got != want {
+		t.Errorf("DetectPortable() with marker file = %q, want %q", got, want)
+	}
+}
+
+func TestPortableDirs(t *testing.T) {
+	defer SetPortableDir("")
+
+	dir := t.TempDir()
+	SetPortableDir(dir)
+
+	if got, want := GetDataDir(), filepath.Join(dir, "data"); got != want {
+		t.Errorf("GetDataDir() = %q, want %q", got, want)
+	}
+	if got, want := GetConfigFilePath(), filepath.Join(dir, "config", "settings.json"); got != want {
+		t.Errorf("GetConfigFilePath() = %q, want %q", got, want)
+	}
+	if got, want := GetDatabasePath(), filepath.Join(dir, "data", DatabaseName); got != want {
+		t.Errorf("GetDatabasePath() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+// Actions that can be bound to a keyboard shortcut.
+const (
+	ShortcutNewChat       = "new-chat"
+	ShortcutNewWindow     = "new-window"
+	ShortcutSearch        = "search"
+	ShortcutSettings      = "settings"
+	ShortcutCloseWindow   = "close-window"
+	ShortcutStopStreaming = "stop-streaming"
+	ShortcutQuit          = "quit"
+)
+
+// ShortcutActions lists the actions that can be bound, in the order they
+// are shown in the settings.
+var ShortcutActions = []string{
+	ShortcutNewChat,
+	ShortcutNewWindow,
+	ShortcutSearch,
+	ShortcutSettings,
+	ShortcutCloseWindow,
+	ShortcutStopStreaming,
+	ShortcutQuit,
+}
+
+// DefaultShortcuts holds the default binding of each action, written as
+// GTK accelerators such as "<Control>n".
+var DefaultShortcuts = map[string]string{
+	ShortcutNewChat:       "<Control>n",
+	ShortcutNewWindow:     "<Control><Shift>n",
+	ShortcutSearch:        "<Control>k",
+	ShortcutSettings:      "<Control>comma",
+	ShortcutCloseWindow:   "<Control>w",
+	ShortcutStopStreaming: "Escape",
+	ShortcutQuit:          "<Control>q",
+}
+
+// Shortcut returns the accelerator bound to action, or "" when the action
+// has no shortcut. Actions not in Shortcuts use their default binding.
+func (c *AppConfig) Shortcut(action string) string {
+	if accel, ok := c.Shortcuts[action]; ok {
+		return accel
+	}
+	return DefaultShortcuts[action]
+}
+
+// ShortcutBindings returns the accelerator of every action, defaults
+// included.
+func (c *AppConfig) ShortcutBindings() map[string]string {
+	bindings := make(map[string]string, len(ShortcutActions))
+	for _, action := range ShortcutActions {
+		bindings[action] = c.Shortcut(action)
+	}
+	return bindings
+}
+
+// SetShortcutBindings stores bindings, keeping only the ones that differ
+// from the defaults so that changes to the defaults still apply.
+func (c *AppConfig) SetShortcutBindings(bindings map[string]string) {
+	c.Shortcuts = nil
+	for _, action := range ShortcutActions {
+		accel, ok := bindings[action]
+		if !ok || accel == DefaultShortcuts[action] {
+			continue
+		}
+		if c.Shortcuts == nil {
+			c.Shortcuts = make(map[string]string)
+		}
+		c.Shortcuts[action] = accel
+	}
+}
+
+// ShortcutConflict returns the action other than action that is bound to
+// accel in bindings, or "" when there is none.
+func ShortcutConflict(bindings map[string]string, action, accel string) string {
+	if accel == "" {
+		return ""
+	}
+	for _, other := range ShortcutActions {
+		if other != action && bindings[other] == accel {
+			return other
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestShortcut(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.Shortcut(ShortcutNewChat); got != "<Control>n" {
+		t.Errorf("Shortcut(new-chat) = %q, want default %q", got, "<Control>n")
+	}
+
+	cfg.Shortcuts = map[string]string{
+		ShortcutNewChat:       "<Control>t",
+		ShortcutStopStreaming: "",
+	}
+	if got := cfg.Shortcut(ShortcutNewChat); got != "<Control>t" {
+		t.Errorf("Shortcut(new-chat) = %q, want %q", got, "<Control>t")
+	}
+	if got := cfg.Shortcut(ShortcutStopStreaming); got != "" {
+		t.Errorf("Shortcut(stop-streaming) = %q, want it disabled", got)
+	}
+	if got := cfg.Shortcut(ShortcutSearch); got != "<Control>k" {
+		t.Errorf("Shortcut(search) = %q, want default %q", got, "<Control>k")
+	}
+	if got := cfg.Shortcut("unknown"); got != "" {
+		t.Errorf("Shortcut(unknown) = %q, want none", got)
+	}
+}
+
+func TestDefaultShortcutsCoverActions(t *testing.T) {
+	for _, action := range ShortcutActions {
+		if DefaultShortcuts[action] == "" {
+			t.Errorf("action %q has no default shortcut", action)
+		}
+	}
+	if len(DefaultShortcuts) != len(ShortcutActions) {
+		t.Errorf("DefaultShortcuts has %d entries, want %d", len(DefaultShortcuts), len(ShortcutActions))
+	}
+}
+
+func TestSetShortcutBindings(t *testing.T) {
+	cfg := DefaultConfig()
+	bindings := cfg.ShortcutBindings()
+	bindings[ShortcutSearch] = "<Control>f"
+	bindings[ShortcutCloseWindow] = ""
+
+	cfg.SetShortcutBindings(bindings)
+
+	want := map[string]string{
+		ShortcutSearch:      "<Control>f",
+		ShortcutCloseWindow: "",
+	}
+	if len(cfg.Shortcuts) != len(want) {
+		t.Fatalf("Shortcuts = %v, want %v", cfg.Shortcuts, want)
+	}
+	for action, accel := range want {
+		if got, ok := cfg.Shortcuts[action]; !ok || got != accel {
+			t.Errorf("Shortcuts[%q] = %q, want %q", action, got, accel)
+		}
+	}
+
+	// Back to the defaults, nothing is stored
+	cfg.SetShortcutBindings(DefaultShortcuts)
+	if cfg.Shortcuts != nil {
+		t.Errorf("Shortcuts = %v, want nil", cfg.Shortcuts)
+	}
+}
+
+func TestShortcutsRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Shortcuts = map[string]string{ShortcutStopStreaming: ""}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	loaded := DefaultConfig()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got := loaded.Shortcut(ShortcutStopStreaming); got != "" {
+		t.Errorf("Shortcut(stop-streaming) = %q after loading, want it disabled", got)
+	}
+	if got := loaded.Shortcut(ShortcutNewChat); got != "<Control>n" {
+		t.Errorf("Shortcut(new-chat) = %q after loading, want default", got)
+	}
+}
+
+func TestShortcutConflict(t *testing.T) {
+	bindings := DefaultConfig().ShortcutBindings()
+
+	tests := []struct {
+		name   string
+		action string
+		accel  string
+		want   string
+	}{
+		{"free", ShortcutSearch, "<Control>f", ""},
+		{"taken", ShortcutSearch, "<Control>n", ShortcutNewChat},
+		{"own binding", ShortcutNewChat, "<Control>n", ""},
+		{"disabled", ShortcutSearch, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShortcutConflict(bindings, tt.action, tt.accel); got != tt.want {
+				t.Errorf("ShortcutConflict(%q, %q) = %q, want %q", tt.action, tt.accel, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import "strings"
+
+// Suggestion is a pill of the welcome screen inserting a prompt in the
+// input, e.g. {"icon": "💡", "label": "Explain", "prompt": "Explain "}.
+type Suggestion struct {
+	Icon   string `json:"icon,omitempty"`
+	Label  string `json:"label"`
+	Prompt string `json:"prompt"`
+}
+
+// DefaultSuggestions are the pills shown when none are configured. Their
+// labels and prompts are translated when shown.
+var DefaultSuggestions = []Suggestion{
+	{Icon: "💡", Label: "Explain", Prompt: "Explain in simple terms: "},
+	{Icon: "💻", Label: "Write", Prompt: "Write code that "},
+	{Icon: "📝", Label: "Summarize", Prompt: "Summarize the following text:\n\n"},
+	{Icon: "🌐", Label: "Translate", Prompt: "Translate the following text into English:\n\n"},
+}
+
+// WelcomeSuggestions returns the configured suggestions that have a label
+// and a prompt, or DefaultSuggestions when none are configured.
+func (c *AppConfig) WelcomeSuggestions() []Suggestion {
+	if c.Suggestions == nil {
+		return DefaultSuggestions
+	}
+	var suggestions []Suggestion
+	for _, s := range c.Suggestions {
+		if strings.TrimSpace(s.Label) != "" && s.Prompt != "" {
+			suggestions = append(suggestions, s)
+		}
+	}
+	return suggestions
+}
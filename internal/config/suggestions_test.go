@@ -0,0 +1,29 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppConfig_WelcomeSuggestions(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.WelcomeSuggestions(); !reflect.DeepEqual(got, DefaultSuggestions) {
+		t.Errorf("WelcomeSuggestions() = %v, want the defaults", got)
+	}
+
+	cfg.Suggestions = []Suggestion{
+		{Icon: "🐛", Label: "Debug", Prompt: "Find the bug in this code:\n\n"},
+		{Label: " ", Prompt: "No label"},
+		{Label: "No prompt"},
+	}
+	want := []Suggestion{{Icon: "🐛", Label: "Debug", Prompt: "Find the bug in this code:\n\n"}}
+	if got := cfg.WelcomeSuggestions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("WelcomeSuggestions() = %v, want %v", got, want)
+	}
+
+	// An empty list hides the pills
+	cfg.Suggestions = []Suggestion{}
+	if got := cfg.WelcomeSuggestions(); len(got) != 0 {
+		t.Errorf("WelcomeSuggestions() = %v, want none", got)
+	}
+}
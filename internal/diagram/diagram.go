@@ -0,0 +1,110 @@
+// Package diagram renders Mermaid and Graphviz diagrams to PNG images
+// with the mmdc and dot commands, when they are installed.
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Timeout is how long rendering one diagram may take. mmdc starts a
+// headless browser, which takes a few seconds.
+const Timeout = time.Minute
+
+// ErrUnsupported is returned for languages that have no renderer.
+var ErrUnsupported = errors.New("diagrams in this language can't be rendered")
+
+// renderer is a command converting a diagram file to a PNG image.
+type renderer struct {
+	command string
+	ext     string                        // Extension of the diagram file
+	args    func(in, out string) []string // Arguments converting in to out
+}
+
+var (
+	mermaid = &renderer{"mmdc", ".mmd", func(in, out string) []string {
+		return []string{"-i", in, "-o", out, "-b", "white"}
+	}}
+	graphviz = &renderer{"dot", ".dot", func(in, out string) []string {
+		return []string{"-Tpng", "-Gbgcolor=white", "-o", out, in}
+	}}
+)
+
+// renderers maps code block languages to their renderer.
+var renderers = map[string]*renderer{
+	"mermaid":  mermaid,
+	"mmd":      mermaid,
+	"dot":      graphviz,
+	"graphviz": graphviz,
+	"gv":       graphviz,
+}
+
+func rendererFor(language string) *renderer {
+	return renderers[strings.ToLower(strings.TrimSpace(language))]
+}
+
+// Supported reports whether diagrams in language, the language of a code
+// block, can be rendered when the command for them is installed.
+func Supported(language string) bool {
+	return rendererFor(language) != nil
+}
+
+// Available reports whether diagrams in language can be rendered: it is
+// supported and its command is installed.
+func Available(language string) bool {
+	r := rendererFor(language)
+	if r == nil {
+		return false
+	}
+	_, err := exec.LookPath(r.command)
+	return err == nil
+}
+
+// Render renders the diagram source, written in language, to a PNG image.
+func Render(ctx context.Context, language, source string) ([]byte, error) {
+	r := rendererFor(language)
+	if r == nil {
+		return nil, ErrUnsupported
+	}
+	if _, err := exec.LookPath(r.command); err != nil {
+		return nil, fmt.Errorf("%s is not installed", r.command)
+	}
+
+	dir, err := os.MkdirTemp("", "guanaco-diagram-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary folder: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "diagram"+r.ext)
+	out := filepath.Join(dir, "diagram.png")
+	if err := os.WriteFile(in, []byte(source), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write diagram: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, r.command, r.args(in, out)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", r.command, err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", r.command, err)
+	}
+
+	image, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rendered diagram: %w", err)
+	}
+	return image, nil
+}
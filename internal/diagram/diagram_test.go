@@ -0,0 +1,56 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSupported(t *testing.T) {
+	for _, language := range []string{"mermaid", "dot", "graphviz", "Mermaid", " gv "} {
+		if !Supported(language) {
+			t.Errorf("Supported(%q) = false, want true", language)
+		}
+	}
+	for _, language := range []string{"", "go", "plantuml"} {
+		if Supported(language) {
+			t.Errorf("Supported(%q) = true, want false", language)
+		}
+	}
+}
+
+func TestRender_Unsupported(t *testing.T) {
+	if _, err := Render(context.Background(), "go", "package main"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Render(go) error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestRender_NotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if Available("dot") {
+		t.Error("Available(dot) = true with an empty PATH")
+	}
+	if _, err := Render(context.Background(), "dot", "digraph { a -> b }"); err == nil {
+		t.Error("Render() succeeded without dot installed")
+	}
+}
+
+func TestRender_Graphviz(t *testing.T) {
+	if !Available("dot") {
+		t.Skip("dot is not installed")
+	}
+
+	image, err := Render(context.Background(), "dot", "digraph { a -> b }")
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !bytes.HasPrefix(image, []byte("\x89PNG")) {
+		t.Error("Render() did not return a PNG image")
+	}
+
+	if _, err := Render(context.Background(), "dot", "digraph { a -> "); err == nil {
+		t.Error("Render() succeeded with an invalid diagram")
+	}
+}
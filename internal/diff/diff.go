@@ -0,0 +1,219 @@
+// Package diff compares two texts word by word, for showing what changed
+// between two responses to the same prompt.
+//
+// Texts are split into words that keep their trailing whitespace, and the
+// longest common subsequence of words is kept as unchanged. Long texts that
+// differ throughout are compared line by line instead, which bounds the
+// memory used.
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxCells limits the size of the comparison table, in cells of two bytes.
+const maxCells = 4 << 20
+
+// Kind says whether a piece of text is in both texts or in only one.
+type Kind int
+
+const (
+	// Equal text is in both texts.
+	Equal Kind = iota
+	// Delete text is only in the old text.
+	Delete
+	// Insert text is only in the new text.
+	Insert
+)
+
+// Op is a piece of text of one kind. Consecutive ops have different kinds.
+type Op struct {
+	Kind Kind
+	Text string
+}
+
+// Words compares two texts and returns the ops turning oldText into newText.
+func Words(oldText, newText string) []Op {
+	ops, ok := compare(splitWords(oldText), splitWords(newText))
+	if !ok {
+		ops, ok = compare(splitLines(oldText), splitLines(newText))
+	}
+	if !ok {
+		ops = merge([]Op{{Delete, oldText}, {Insert, newText}})
+	}
+	return ops
+}
+
+// Changes counts the words only in the new text and only in the old one.
+func Changes(ops []Op) (inserted, deleted int) {
+	for _, op := range ops {
+		switch op.Kind {
+		case Insert:
+			inserted += len(strings.Fields(op.Text))
+		case Delete:
+			deleted += len(strings.Fields(op.Text))
+		}
+	}
+	return inserted, deleted
+}
+
+// Old returns the old text of ops.
+func Old(ops []Op) string {
+	return join(ops, Insert)
+}
+
+// New returns the new text of ops.
+func New(ops []Op) string {
+	return join(ops, Delete)
+}
+
+// join joins the text of ops, leaving out the ones of kind skip.
+func join(ops []Op, skip Kind) string {
+	var b strings.Builder
+	for _, op := range ops {
+		if op.Kind != skip {
+			b.WriteString(op.Text)
+		}
+	}
+	return b.String()
+}
+
+// splitWords splits text into words, each with the whitespace after it.
+// Whitespace at the start is a token of its own.
+func splitWords(text string) []string {
+	var tokens []string
+	start := 0
+	inSpace := true
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if !space && inSpace && i > start {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// splitLines splits text into lines, each with its newline.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// compare returns the ops turning tokens a into tokens b, or false when
+// the texts are too long to compare.
+func compare(a, b []string) ([]Op, bool) {
+	// The common start and end are left out of the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := []Op{{Equal, strings.Join(a[:prefix], "")}}
+	middle, ok := lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if !ok {
+		return nil, false
+	}
+	ops = append(ops, middle...)
+	ops = append(ops, Op{Equal, strings.Join(a[len(a)-suffix:], "")})
+	return merge(ops), true
+}
+
+// lcs compares a and b with a table of the longest common subsequences of
+// their ends.
+func lcs(a, b []string) ([]Op, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return []Op{{Delete, strings.Join(a, "")}, {Insert, strings.Join(b, "")}}, true
+	}
+	if (n+1)*(m+1) > maxCells || min(n, m) > 0xffff {
+		return nil, false
+	}
+
+	// table[i*(m+1)+j] is the length of the LCS of a[i:] and b[j:]
+	width := m + 1
+	table := make([]uint16, (n+1)*width)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*width+j] = table[(i+1)*width+j+1] + 1
+			} else {
+				table[i*width+j] = max(table[(i+1)*width+j], table[i*width+j+1])
+			}
+		}
+	}
+
+	ops := make([]Op, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{Equal, a[i]})
+			i++
+			j++
+		case table[(i+1)*width+j] >= table[i*width+j+1]:
+			ops = append(ops, Op{Delete, a[i]})
+			i++
+		default:
+			ops = append(ops, Op{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, Op{Delete, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, Op{Insert, b[j]})
+	}
+	return ops, true
+}
+
+// merge joins consecutive ops of the same kind and drops empty ones. Within
+// a change, deletions are put before insertions.
+func merge(ops []Op) []Op {
+	var merged []Op
+	var deleted, inserted strings.Builder
+	flush := func() {
+		if deleted.Len() > 0 {
+			merged = append(merged, Op{Delete, deleted.String()})
+			deleted.Reset()
+		}
+		if inserted.Len() > 0 {
+			merged = append(merged, Op{Insert, inserted.String()})
+			inserted.Reset()
+		}
+	}
+
+	for _, op := range ops {
+		if op.Text == "" {
+			continue
+		}
+		switch op.Kind {
+		case Delete:
+			deleted.WriteString(op.Text)
+		case Insert:
+			inserted.WriteString(op.Text)
+		default:
+			flush()
+			if last := len(merged) - 1; last >= 0 && merged[last].Kind == Equal {
+				merged[last].Text += op.Text
+			} else {
+				merged = append(merged, op)
+			}
+		}
+	}
+	flush()
+	return merged
+}
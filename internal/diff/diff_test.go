@@ -0,0 +1,138 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []Op
+	}{
+		{
+			name: "equal",
+			old:  "The sky is blue.",
+			new:  "The sky is blue.",
+			want: []Op{{Equal, "The sky is blue."}},
+		},
+		{
+			name: "replaced word",
+			old:  "The sky is blue today.",
+			new:  "The sky is grey today.",
+			want: []Op{{Equal, "The sky is "}, {Delete, "blue "}, {Insert, "grey "}, {Equal, "today."}},
+		},
+		{
+			name: "inserted words",
+			old:  "Use a list.",
+			new:  "Use a short list.",
+			want: []Op{{Equal, "Use a "}, {Insert, "short "}, {Equal, "list."}},
+		},
+		{
+			name: "deleted line",
+			old:  "One\nTwo\nThree",
+			new:  "One\nThree",
+			want: []Op{{Equal, "One\n"}, {Delete, "Two\n"}, {Equal, "Three"}},
+		},
+		{
+			name: "from empty",
+			old:  "",
+			new:  "Hello",
+			want: []Op{{Insert, "Hello"}},
+		},
+		{
+			name: "both empty",
+			old:  "",
+			new:  "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Words(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words() = %q, want %q", got, tt.want)
+			}
+			if Old(got) != tt.old || New(got) != tt.new {
+				t.Errorf("Words() ops give %q and %q, want the original texts", Old(got), New(got))
+			}
+		})
+	}
+}
+
+func TestWords_LongTexts(t *testing.T) {
+	// Too many words for the word table, so lines are compared
+	var oldText, newText strings.Builder
+	for i := 0; i < 3000; i++ {
+		oldText.WriteString("alpha beta gamma\n")
+		if i == 1500 {
+			newText.WriteString("delta\n")
+		}
+		newText.WriteString("alpha beta gamma\n")
+	}
+	oldText.WriteString("old ending")
+	newText.WriteString("new ending")
+
+	ops := Words(oldText.String(), newText.String())
+	if Old(ops) != oldText.String() || New(ops) != newText.String() {
+		t.Fatal("Words() ops don't give the original texts")
+	}
+	inserted, deleted := Changes(ops)
+	if inserted != 3 || deleted != 2 {
+		t.Errorf("Changes() = %d inserted, %d deleted, want 3 and 2", inserted, deleted)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	ops := []Op{
+		{Equal, "The sky is "},
+		{Delete, "blue "},
+		{Insert, "grey and cloudy "},
+		{Equal, "today."},
+	}
+
+	inserted, deleted := Changes(ops)
+	if inserted != 3 || deleted != 1 {
+		t.Errorf("Changes() = %d inserted, %d deleted, want 3 and 1", inserted, deleted)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"one", []string{"one"}},
+		{"one\ntwo\n", []string{"one\n", "two\n"}},
+		{"one\n\n", []string{"one\n", "\n"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"one", []string{"one"}},
+		{"one two\n", []string{"one ", "two\n"}},
+		{"  indented", []string{"  ", "indented"}},
+		{"café  olé", []string{"café  ", "olé"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitWords(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package hooks runs user commands when something happens in the app, such
+// as a response being completed. Each command gets a JSON description of
+// the event on stdin, so answers can be piped into other tools. Pipe sends a
+// response to a command the user picks for it.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Events hooks can be configured for.
+const (
+	ResponseCompleted = "response-completed"
+	ChatExported      = "chat-exported"
+	ModelPulled       = "model-pulled"
+)
+
+// Events lists the supported events.
+var Events = []string{ResponseCompleted, ChatExported, ModelPulled}
+
+// Timeout is how long a hook may run before it is stopped.
+const Timeout = 30 * time.Second
+
+// Response is the payload of ResponseCompleted.
+type Response struct {
+	Event     string    `json:"event"`
+	ChatID    int64     `json:"chat_id"`
+	ChatTitle string    `json:"chat_title"`
+	Model     string    `json:"model"`
+	Prompt    string    `json:"prompt"`
+	Response  string    `json:"response"`
+	Time      time.Time `json:"time"`
+}
+
+// Export is the payload of ChatExported.
+type Export struct {
+	Event   string    `json:"event"`
+	Path    string    `json:"path"`
+	Format  string    `json:"format"`
+	ChatIDs []int64   `json:"chat_ids,omitempty"` // Empty when all chats were exported
+	Time    time.Time `json:"time"`
+}
+
+// Pull is the payload of ModelPulled.
+type Pull struct {
+	Event string    `json:"event"`
+	Model string    `json:"model"`
+	Time  time.Time `json:"time"`
+}
+
+// Execute runs command with sh, writing payload as JSON to its stdin. The
+// event name is also available in the GUANACO_EVENT environment variable.
+func Execute(ctx context.Context, command, event string, payload any) error {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook payload: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), "GUANACO_EVENT="+event)
+	// Don't wait for children of the shell still holding the output open
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			return fmt.Errorf("hook failed: %w: %s", err, out)
+		}
+		return fmt.Errorf("hook failed: %w", err)
+	}
+	return nil
+}
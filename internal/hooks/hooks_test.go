@@ -0,0 +1,70 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecute_WritesPayloadToStdin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	payload := Pull{Event: ModelPulled, Model: "llama3:latest"}
+
+	err := Execute(context.Background(), "cat > "+out, ModelPulled, payload)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook output missing: %v", err)
+	}
+	var got Pull
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("hook input is not JSON: %v", err)
+	}
+	if got.Event != ModelPulled || got.Model != "llama3:latest" {
+		t.Errorf("hook input = %+v, want %+v", got, payload)
+	}
+}
+
+func TestExecute_SetsEventVariable(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "event")
+
+	err := Execute(context.Background(), `printf %s "$GUANACO_EVENT" > `+out, ChatExported, Export{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(out)
+	if string(data) != ChatExported {
+		t.Errorf("GUANACO_EVENT = %q, want %q", data, ChatExported)
+	}
+}
+
+func TestExecute_Failure(t *testing.T) {
+	err := Execute(context.Background(), "echo broken >&2; exit 3", ResponseCompleted, Response{})
+	if err == nil {
+		t.Fatal("Execute() error = nil for a failing command")
+	}
+	if !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Execute() error = %v, want the command output", err)
+	}
+}
+
+func TestExecute_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := Execute(ctx, "sleep 5", ResponseCompleted, Response{}); err == nil {
+		t.Error("Execute() error = nil for a command past its deadline")
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("Execute() did not stop the command at the deadline")
+	}
+}
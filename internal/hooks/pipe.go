@@ -0,0 +1,31 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// MaxPipeOutput is how much of the output of a piped command is kept.
+const MaxPipeOutput = 64 * 1024
+
+// Pipe runs command with sh, writing text to its stdin, and returns what it
+// printed on stdout and stderr. Longer output than MaxPipeOutput is cut.
+func Pipe(ctx context.Context, command, text string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = strings.NewReader(text)
+	// Don't wait for children of the shell still holding the output open
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+	out := strings.TrimSpace(string(output[:min(len(output), MaxPipeOutput)]))
+	if err != nil {
+		if out != "" {
+			return "", fmt.Errorf("command failed: %w: %s", err, out)
+		}
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,37 @@
+package hooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPipe_ReturnsOutput(t *testing.T) {
+	got, err := Pipe(context.Background(), "tr a-z A-Z", "## Answer\n\nhello")
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	if got != "## ANSWER\n\nHELLO" {
+		t.Errorf("Pipe() = %q, want the text in upper case", got)
+	}
+}
+
+func TestPipe_CutsLongOutput(t *testing.T) {
+	got, err := Pipe(context.Background(), "yes | head -c 200000", "")
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	if len(got) > MaxPipeOutput {
+		t.Errorf("Pipe() returned %d bytes, want at most %d", len(got), MaxPipeOutput)
+	}
+}
+
+func TestPipe_Failure(t *testing.T) {
+	_, err := Pipe(context.Background(), "echo 'pandoc: not found' >&2; exit 127", "text")
+	if err == nil {
+		t.Fatal("Pipe() error = nil for a failing command")
+	}
+	if !strings.Contains(err.Error(), "pandoc: not found") {
+		t.Errorf("Pipe() error = %v, want the command output", err)
+	}
+}
@@ -0,0 +1,59 @@
+package i18n
+
+// catalog holds the translations of one language.
+type catalog struct {
+	messages map[string][]string // Translations by msgid, one per plural form
+	nplurals int                 // Number of plural forms
+	plural   pluralFunc          // Plural form of a count
+}
+
+// newCatalog returns an empty catalog with the plural rule of English,
+// until a header sets the language's.
+func newCatalog() *catalog {
+	return &catalog{
+		messages: map[string][]string{},
+		nplurals: 2,
+		plural:   germanicPlural,
+	}
+}
+
+// add adds the translations of a message, skipping untranslated ones.
+func (c *catalog) add(msgid string, translations []string) {
+	for _, trans := range translations {
+		if trans == "" {
+			return
+		}
+	}
+	if len(translations) > 0 {
+		c.messages[msgid] = translations
+	}
+}
+
+// translate returns the translation of a message. A nil catalog has none.
+func (c *catalog) translate(msgid string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	translations, ok := c.messages[msgid]
+	if !ok {
+		return "", false
+	}
+	return translations[0], true
+}
+
+// translatePlural returns the translation of a message in the plural form
+// of n. A nil catalog has none.
+func (c *catalog) translatePlural(msgid string, n uint) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	translations, ok := c.messages[msgid]
+	if !ok {
+		return "", false
+	}
+	form := c.plural(uint64(n))
+	if form >= len(translations) {
+		return "", false
+	}
+	return translations[form], true
+}
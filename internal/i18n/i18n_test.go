@@ -0,0 +1,156 @@
+package i18n
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLanguageCode(t *testing.T) {
+	tests := map[string]string{
+		"es_ES.UTF-8":    "es",
+		"pt_BR:en":       "pt",
+		"de_DE@euro":     "de",
+		"fr":             "fr",
+		"en_US.UTF-8:es": "en",
+		"":               "",
+		"C.UTF-8":        "C",
+	}
+	for locale, want := range tests {
+		if got := languageCode(locale); got != want {
+			t.Errorf("languageCode(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLanguage("en")
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"en", "Settings"},
+		{"es", "Configuración"},
+		{"pt", "Configurações"},
+		{"fr", "Paramètres"},
+		{"de", "Einstellungen"},
+		{"xx", "Settings"},
+	}
+	for _, tt := range tests {
+		SetLanguage(tt.lang)
+		if got := T("Settings"); got != tt.want {
+			t.Errorf("T() in %q = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+	if got := T("No translation for this"); got != "No translation for this" {
+		t.Errorf("T() = %q, want the source string", got)
+	}
+}
+
+func TestN(t *testing.T) {
+	defer SetLanguage("en")
+
+	tests := []struct {
+		lang string
+		n    uint
+		want string
+	}{
+		{"en", 1, "%d line"},
+		{"en", 0, "%d lines"},
+		{"es", 1, "%d línea"},
+		{"es", 0, "%d líneas"},
+		{"es", 2, "%d líneas"},
+		// French uses the singular for 0
+		{"fr", 0, "%d ligne"},
+		{"fr", 1, "%d ligne"},
+		{"fr", 2, "%d lignes"},
+		{"de", 5, "%d Zeilen"},
+	}
+	for _, tt := range tests {
+		SetLanguage(tt.lang)
+		if got := N("%d line", "%d lines", tt.n); got != tt.want {
+			t.Errorf("N(%d) in %q = %q, want %q", tt.n, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestTLang(t *testing.T) {
+	SetLanguage("en")
+	defer SetLanguage("en")
+
+	if got := TLang("es", "Good morning"); got != "Buenos días" {
+		t.Errorf(`TLang("es") = %q, want "Buenos días"`, got)
+	}
+	if got := TLang("xx", "Good morning"); got != "Good morning" {
+		t.Errorf(`TLang("xx") = %q, want the source string`, got)
+	}
+	// The current language is not changed
+	if got := T("Good morning"); got != "Good morning" {
+		t.Errorf("T() = %q after TLang, want the source string", got)
+	}
+
+	SetLanguage("es")
+	if got := TLang("en", "Good morning"); got != "Good morning" {
+		t.Errorf(`TLang("en") = %q, want the source string`, got)
+	}
+	if got := TLang("de", "Good morning"); got != "Guten Morgen" {
+		t.Errorf(`TLang("de") = %q with Spanish current, want "Guten Morgen"`, got)
+	}
+}
+
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestEmbeddedCatalogs checks that the catalogs shipped parse, and that
+// translations keep the format verbs and surrounding whitespace of messages.
+func TestEmbeddedCatalogs(t *testing.T) {
+	files, err := fs.Glob(embeddedLocales, "locale/*.po")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no embedded catalogs: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := embeddedLocales.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := parsePO(data)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+
+		if len(c.messages) == 0 {
+			t.Errorf("%s has no translations", file)
+		}
+
+		for msgid, translations := range c.messages {
+			if len(translations) != 1 && len(translations) != c.nplurals {
+				t.Errorf("%s: %q has %d forms, want %d", file, msgid, len(translations), c.nplurals)
+			}
+			for _, trans := range translations {
+				checkTranslation(t, file, msgid, trans, len(translations) > 1)
+			}
+		}
+	}
+}
+
+func checkTranslation(t *testing.T, file, msgid, trans string, plural bool) {
+	t.Helper()
+
+	verbs := func(s string) string {
+		if plural {
+			// Forms of a plural message may leave out the count
+			s = strings.ReplaceAll(s, "%d", "")
+		}
+		return strings.Join(formatVerb.FindAllString(s, -1), " ")
+	}
+	if got, want := verbs(trans), verbs(msgid); got != want {
+		t.Errorf("%s: %q has verbs %q, want %q", file, trans, got, want)
+	}
+	if strings.HasSuffix(msgid, " ") != strings.HasSuffix(trans, " ") ||
+		strings.HasSuffix(msgid, "\n") != strings.HasSuffix(trans, "\n") {
+		t.Errorf("%s: %q and %q end differently", file, msgid, trans)
+	}
+}
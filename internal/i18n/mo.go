@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// Magic numbers of .mo files, as read in little-endian order.
+const (
+	moMagicLittleEndian = 0x950412de
+	moMagicBigEndian    = 0xde120495
+)
+
+var errInvalidMO = errors.New("invalid .mo file")
+
+// parseMO reads a gettext .mo catalog, as compiled by msgfmt.
+func parseMO(data []byte) (*catalog, error) {
+	if len(data) < 28 {
+		return nil, errInvalidMO
+	}
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(data) {
+	case moMagicLittleEndian:
+		order = binary.LittleEndian
+	case moMagicBigEndian:
+		order = binary.BigEndian
+	default:
+		return nil, errInvalidMO
+	}
+	if order.Uint32(data[4:])>>16 != 0 {
+		return nil, errors.New("unsupported .mo file revision")
+	}
+
+	count := int(order.Uint32(data[8:]))
+	originals := int(order.Uint32(data[12:]))
+	translations := int(order.Uint32(data[16:]))
+
+	// str returns the string described at offset of a table.
+	str := func(table, i int) (string, bool) {
+		pos := table + i*8
+		if pos < 0 || pos+8 > len(data) {
+			return "", false
+		}
+		length := int(order.Uint32(data[pos:]))
+		offset := int(order.Uint32(data[pos+4:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return "", false
+		}
+		return string(data[offset : offset+length]), true
+	}
+
+	c := newCatalog()
+	for i := 0; i < count; i++ {
+		original, ok := str(originals, i)
+		if !ok {
+			return nil, errInvalidMO
+		}
+		translation, ok := str(translations, i)
+		if !ok {
+			return nil, errInvalidMO
+		}
+
+		switch {
+		case original == "":
+			if err := c.readHeader([]string{translation}); err != nil {
+				return nil, err
+			}
+		case strings.Contains(original, "\x04"):
+			// Messages with a context are skipped, as in .po files
+		default:
+			// The plural msgid and the plural forms follow NUL characters
+			msgid, _, _ := strings.Cut(original, "\x00")
+			c.add(msgid, strings.Split(translation, "\x00"))
+		}
+	}
+	return c, nil
+}
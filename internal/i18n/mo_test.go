@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// buildMO compiles messages into a .mo file, as msgfmt does.
+func buildMO(order binary.ByteOrder, messages map[string]string) []byte {
+	keys := make([]string, 0, len(messages))
+	for k := range messages {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	const headerSize = 28
+	n := len(keys)
+	originals := headerSize
+	translations := originals + n*8
+	offset := translations + n*8
+
+	data := make([]byte, offset)
+	order.PutUint32(data[0:], moMagicLittleEndian)
+	order.PutUint32(data[8:], uint32(n))
+	order.PutUint32(data[12:], uint32(originals))
+	order.PutUint32(data[16:], uint32(translations))
+
+	put := func(table, i int, s string) {
+		order.PutUint32(data[table+i*8:], uint32(len(s)))
+		order.PutUint32(data[table+i*8+4:], uint32(len(data)))
+		data = append(data, s...)
+		data = append(data, 0)
+	}
+	for i, k := range keys {
+		put(originals, i, k)
+	}
+	for i, k := range keys {
+		put(translations, i, messages[k])
+	}
+	return data
+}
+
+func TestParseMO(t *testing.T) {
+	messages := map[string]string{
+		"":                    "Language: fr\nPlural-Forms: nplurals=2; plural=(n > 1);\n",
+		"Settings":            "Paramètres",
+		"%d file\x00%d files": "%d fichier\x00%d fichiers",
+		"menu\x04Open":        "Ouvrir le menu",
+	}
+	for name, order := range map[string]binary.ByteOrder{"little endian": binary.LittleEndian, "big endian": binary.BigEndian} {
+		t.Run(name, func(t *testing.T) {
+			c, err := parseMO(buildMO(order, messages))
+			if err != nil {
+				t.Fatalf("parseMO() error = %v", err)
+			}
+			want := map[string][]string{
+				"Settings": {"Paramètres"},
+				"%d file":  {"%d fichier", "%d fichiers"},
+			}
+			if !reflect.DeepEqual(c.messages, want) {
+				t.Errorf("messages = %q, want %q", c.messages, want)
+			}
+			if got, _ := c.translatePlural("%d file", 0); got != "%d fichier" {
+				t.Errorf("translatePlural(0) = %q, want the French singular", got)
+			}
+		})
+	}
+}
+
+func TestParseMO_Invalid(t *testing.T) {
+	valid := buildMO(binary.LittleEndian, map[string]string{"Settings": "Paramètres"})
+
+	truncated := valid[:len(valid)-5]
+	badMagic := append([]byte{}, valid...)
+	badMagic[0] = 0
+
+	for name, data := range map[string][]byte{"empty": nil, "truncated": truncated, "bad magic": badMagic} {
+		if _, err := parseMO(data); err == nil {
+			t.Errorf("parseMO() with %s data: no error", name)
+		}
+	}
+}
+
+func TestInit_InstalledCatalog(t *testing.T) {
+	dir := t.TempDir()
+	messagesDir := filepath.Join(dir, "es", "LC_MESSAGES")
+	if err := os.MkdirAll(messagesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mo := buildMO(binary.LittleEndian, map[string]string{"Settings": "Preferencias"})
+	if err := os.WriteFile(filepath.Join(messagesDir, TextDomain+".mo"), mo, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		Init("")
+		SetLanguage("en")
+	}()
+
+	t.Setenv("LANGUAGE", "es_ES:en")
+	Init(dir)
+	if got := T("Settings"); got != "Preferencias" {
+		t.Errorf("T() = %q, want the installed translation", got)
+	}
+	// Languages not installed still use the embedded catalogs
+	if got := TLang("fr", "Settings"); got != "Paramètres" {
+		t.Errorf(`TLang("fr") = %q, want the embedded translation`, got)
+	}
+}
@@ -0,0 +1,271 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pluralFunc returns the index of the plural form of a count.
+type pluralFunc func(n uint64) int
+
+// germanicPlural is the plural rule of English, one form for 1 and another
+// for the rest.
+func germanicPlural(n uint64) int {
+	if n != 1 {
+		return 1
+	}
+	return 0
+}
+
+// parsePluralForms parses the Plural-Forms header of a catalog, such as
+// "nplurals=2; plural=(n != 1);".
+func parsePluralForms(header string) (int, pluralFunc, error) {
+	nplurals := 0
+	expr := ""
+	for _, field := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(name) {
+		case "nplurals":
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 1 {
+				return 0, nil, fmt.Errorf("invalid nplurals %q", value)
+			}
+			nplurals = n
+		case "plural":
+			expr = value
+		}
+	}
+	if nplurals == 0 || strings.TrimSpace(expr) == "" {
+		return 0, nil, fmt.Errorf("invalid plural forms %q", header)
+	}
+
+	eval, err := compilePlural(expr)
+	if err != nil {
+		return 0, nil, err
+	}
+	return nplurals, func(n uint64) int { return int(eval(n)) }, nil
+}
+
+// pluralExpr evaluates a plural expression for a count.
+type pluralExpr func(n uint64) uint64
+
+// compilePlural compiles the C expression of a plural rule, made of n,
+// numbers, arithmetic, comparison and logical operators, parentheses and
+// the conditional operator.
+func compilePlural(expr string) (pluralExpr, error) {
+	p := &pluralParser{input: expr}
+	e, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q in plural expression %q", p.input[p.pos:], expr)
+	}
+	return e, nil
+}
+
+// pluralParser is a recursive descent parser of plural expressions, with a
+// method per precedence level of C.
+type pluralParser struct {
+	input string
+	pos   int
+}
+
+func (p *pluralParser) skipSpace() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\r\n", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes op if the input continues with it.
+func (p *pluralParser) accept(op string) bool {
+	p.skipSpace()
+	if !strings.HasPrefix(p.input[p.pos:], op) {
+		return false
+	}
+	// "<" is not the start of "<=", nor "!" of "!="
+	next := p.pos + len(op)
+	if len(op) == 1 && strings.Contains("<>!=", op) && next < len(p.input) && p.input[next] == '=' {
+		return false
+	}
+	p.pos = next
+	return true
+}
+
+func (p *pluralParser) conditional() (pluralExpr, error) {
+	cond, err := p.or()
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	yes, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, fmt.Errorf("missing : in plural expression %q", p.input)
+	}
+	no, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	return func(n uint64) uint64 {
+		if cond(n) != 0 {
+			return yes(n)
+		}
+		return no(n)
+	}, nil
+}
+
+func (p *pluralParser) or() (pluralExpr, error) {
+	left, err := p.and()
+	for err == nil && p.accept("||") {
+		var right pluralExpr
+		if right, err = p.and(); err == nil {
+			l := left
+			left = func(n uint64) uint64 { return boolValue(l(n) != 0 || right(n) != 0) }
+		}
+	}
+	return left, err
+}
+
+func (p *pluralParser) and() (pluralExpr, error) {
+	left, err := p.equality()
+	for err == nil && p.accept("&&") {
+		var right pluralExpr
+		if right, err = p.equality(); err == nil {
+			l := left
+			left = func(n uint64) uint64 { return boolValue(l(n) != 0 && right(n) != 0) }
+		}
+	}
+	return left, err
+}
+
+func (p *pluralParser) equality() (pluralExpr, error) {
+	return p.binary(p.relational, "==", "!=")
+}
+
+func (p *pluralParser) relational() (pluralExpr, error) {
+	return p.binary(p.additive, "<=", ">=", "<", ">")
+}
+
+func (p *pluralParser) additive() (pluralExpr, error) {
+	return p.binary(p.multiplicative, "+", "-")
+}
+
+func (p *pluralParser) multiplicative() (pluralExpr, error) {
+	return p.binary(p.unary, "*", "/", "%")
+}
+
+// binary parses operands of next joined by the left-associative operators
+// ops.
+func (p *pluralParser) binary(next func() (pluralExpr, error), ops ...string) (pluralExpr, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range ops {
+			if p.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryOp(op, left, right)
+	}
+}
+
+func binaryOp(op string, left, right pluralExpr) pluralExpr {
+	return func(n uint64) uint64 {
+		a, b := left(n), right(n)
+		switch op {
+		case "==":
+			return boolValue(a == b)
+		case "!=":
+			return boolValue(a != b)
+		case "<=":
+			return boolValue(a <= b)
+		case ">=":
+			return boolValue(a >= b)
+		case "<":
+			return boolValue(a < b)
+		case ">":
+			return boolValue(a > b)
+		case "+":
+			return a + b
+		case "-":
+			return a - b
+		case "*":
+			return a * b
+		case "/":
+			if b == 0 {
+				return 0
+			}
+			return a / b
+		default: // "%"
+			if b == 0 {
+				return 0
+			}
+			return a % b
+		}
+	}
+}
+
+func (p *pluralParser) unary() (pluralExpr, error) {
+	if p.accept("!") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n uint64) uint64 { return boolValue(operand(n) == 0) }, nil
+	}
+	return p.primary()
+}
+
+func (p *pluralParser) primary() (pluralExpr, error) {
+	if p.accept("(") {
+		e, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ) in plural expression %q", p.input)
+		}
+		return e, nil
+	}
+	if p.accept("n") {
+		return func(n uint64) uint64 { return n }, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, fmt.Errorf("unexpected %q in plural expression %q", p.input[p.pos:], p.input)
+	}
+	value, err := strconv.ParseUint(p.input[start:p.pos], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(uint64) uint64 { return value }, nil
+}
+
+func boolValue(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
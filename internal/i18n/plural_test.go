@@ -0,0 +1,99 @@
+package i18n
+
+import "testing"
+
+func TestParsePluralForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		nplurals int
+		forms    map[uint64]int // Form of each count
+	}{
+		{
+			name:     "english",
+			header:   "nplurals=2; plural=(n != 1);",
+			nplurals: 2,
+			forms:    map[uint64]int{0: 1, 1: 0, 2: 1, 100: 1},
+		},
+		{
+			name:     "french",
+			header:   "nplurals=2; plural=(n > 1);",
+			nplurals: 2,
+			forms:    map[uint64]int{0: 0, 1: 0, 2: 1},
+		},
+		{
+			name:     "japanese",
+			header:   " nplurals=1; plural=0;",
+			nplurals: 1,
+			forms:    map[uint64]int{0: 0, 1: 0, 7: 0},
+		},
+		{
+			name:     "russian",
+			header:   "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+			nplurals: 3,
+			forms:    map[uint64]int{1: 0, 2: 1, 5: 2, 11: 2, 12: 2, 21: 0, 22: 1, 25: 2, 111: 2},
+		},
+		{
+			name:     "arabic",
+			header:   "nplurals=6; plural=n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5;",
+			nplurals: 6,
+			forms:    map[uint64]int{0: 0, 1: 1, 2: 2, 3: 3, 10: 3, 11: 4, 99: 4, 100: 5, 102: 5},
+		},
+		{
+			name:     "irish",
+			header:   "nplurals=5; plural=n==1 ? 0 : n==2 ? 1 : (n>2 && n<7) ? 2 :(n>6 && n<11) ? 3 : 4;",
+			nplurals: 5,
+			forms:    map[uint64]int{1: 0, 2: 1, 3: 2, 7: 3, 11: 4},
+		},
+		{
+			name:     "negation and arithmetic",
+			header:   "nplurals=2; plural=!(n*2-2);",
+			nplurals: 2,
+			forms:    map[uint64]int{1: 1, 2: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nplurals, plural, err := parsePluralForms(tt.header)
+			if err != nil {
+				t.Fatalf("parsePluralForms() error = %v", err)
+			}
+			if nplurals != tt.nplurals {
+				t.Errorf("nplurals = %d, want %d", nplurals, tt.nplurals)
+			}
+			for n, want := range tt.forms {
+				if got := plural(n); got != want {
+					t.Errorf("plural(%d) = %d, want %d", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePluralForms_Errors(t *testing.T) {
+	headers := []string{
+		"",
+		"nplurals=2;",
+		"plural=(n != 1);",
+		"nplurals=x; plural=(n != 1);",
+		"nplurals=2; plural=(n != 1;",
+		"nplurals=2; plural=n ? 1;",
+		"nplurals=2; plural=m;",
+		"nplurals=2; plural=n 1;",
+	}
+	for _, header := range headers {
+		if _, _, err := parsePluralForms(header); err == nil {
+			t.Errorf("parsePluralForms(%q): no error", header)
+		}
+	}
+}
+
+func TestCompilePlural_DivisionByZero(t *testing.T) {
+	eval, err := compilePlural("n / 0 + n % 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := eval(5); got != 0 {
+		t.Errorf("eval(5) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// poEntry is a message of a .po file being read.
+type poEntry struct {
+	msgid        string
+	msgidPlural  string
+	msgstr       []string
+	fuzzy        bool
+	hasContext   bool // Contexts are not used, so these messages are skipped
+	hasMsgid     bool
+	lastKeyword  string // Keyword continued by string lines
+	lastMsgstrID int    // Plural form of the last msgstr[N]
+}
+
+// parsePO reads a gettext .po catalog. Fuzzy and untranslated messages are
+// left out, as msgfmt does, and so are messages with a context.
+func parsePO(data []byte) (*catalog, error) {
+	c := newCatalog()
+	var entry poEntry
+
+	finish := func() error {
+		if !entry.hasMsgid {
+			return nil
+		}
+		if entry.msgid == "" {
+			if err := c.readHeader(entry.msgstr); err != nil {
+				return err
+			}
+		} else if !entry.fuzzy && !entry.hasContext {
+			c.add(entry.msgid, entry.msgstr)
+		}
+		entry = poEntry{}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			// Comments start the next message; obsolete ones (#~) are
+			// comments too
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.fuzzy = true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, `"`) {
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid string %s", lineNum, line)
+			}
+			switch entry.lastKeyword {
+			case "msgid":
+				entry.msgid += s
+			case "msgid_plural":
+				entry.msgidPlural += s
+			case "msgstr":
+				entry.msgstr[entry.lastMsgstrID] += s
+			case "msgctxt":
+			default:
+				return nil, fmt.Errorf("line %d: string without a keyword", lineNum)
+			}
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		s, err := strconv.Unquote(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid string %s", lineNum, rest)
+		}
+		switch {
+		case keyword == "msgctxt":
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			entry.hasContext = true
+		case keyword == "msgid":
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			entry.hasMsgid = true
+			entry.msgid = s
+		case keyword == "msgid_plural":
+			entry.msgidPlural = s
+		case keyword == "msgstr":
+			entry.msgstr = append(entry.msgstr, s)
+			entry.lastMsgstrID = len(entry.msgstr) - 1
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			form, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || form != len(entry.msgstr) {
+				return nil, fmt.Errorf("line %d: unexpected %s", lineNum, keyword)
+			}
+			entry.msgstr = append(entry.msgstr, s)
+			entry.lastMsgstrID = form
+			keyword = "msgstr"
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", lineNum, keyword)
+		}
+		entry.lastKeyword = keyword
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// readHeader reads the plural rule from the header of a catalog, the
+// translation of the empty msgid.
+func (c *catalog) readHeader(msgstr []string) error {
+	if len(msgstr) == 0 {
+		return nil
+	}
+	for _, line := range strings.Split(msgstr[0], "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "Plural-Forms") {
+			continue
+		}
+		nplurals, plural, err := parsePluralForms(value)
+		if err != nil {
+			return err
+		}
+		c.nplurals, c.plural = nplurals, plural
+	}
+	return nil
+}
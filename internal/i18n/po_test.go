@@ -0,0 +1,99 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testPO = `# Test catalog
+msgid ""
+msgstr ""
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && "
+"(n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+#. A comment for translators
+#: internal/ui/window.go:10
+msgid "Settings"
+msgstr "Ustawienia"
+
+msgid ""
+"Two "
+"lines\n"
+msgstr "Dwie \"linie\"\n"
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "Niepewne"
+
+#, c-format
+msgid "Not fuzzy %d"
+msgstr "Pewne %d"
+
+msgid "Untranslated"
+msgstr ""
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Otwórz menu"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d plik"
+msgstr[1] "%d pliki"
+msgstr[2] "%d plików"
+
+#~ msgid "Obsolete"
+#~ msgstr "Przestarzałe"
+`
+
+func TestParsePO(t *testing.T) {
+	c, err := parsePO([]byte(testPO))
+	if err != nil {
+		t.Fatalf("parsePO() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"Settings":     {"Ustawienia"},
+		"Two lines\n":  {"Dwie \"linie\"\n"},
+		"Not fuzzy %d": {"Pewne %d"},
+		"%d file":      {"%d plik", "%d pliki", "%d plików"},
+	}
+	if !reflect.DeepEqual(c.messages, want) {
+		t.Errorf("messages = %q, want %q", c.messages, want)
+	}
+
+	if c.nplurals != 3 {
+		t.Errorf("nplurals = %d, want 3", c.nplurals)
+	}
+	for n, want := range map[uint]string{1: "%d plik", 3: "%d pliki", 5: "%d plików", 22: "%d pliki", 112: "%d plików"} {
+		if got, _ := c.translatePlural("%d file", n); got != want {
+			t.Errorf("translatePlural(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestParsePO_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unknown keyword":   "msgid \"a\"\nmsgfoo \"b\"\n",
+		"unquoted string":   "msgid a\nmsgstr \"b\"\n",
+		"orphan string":     "\"a\"\n",
+		"form out of order": "msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[1] \"c\"\n",
+		"bad plural forms":  "msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=n !;\\n\"\n",
+	}
+	for name, po := range tests {
+		if _, err := parsePO([]byte(po)); err == nil {
+			t.Errorf("parsePO() with %s: no error", name)
+		}
+	}
+}
+
+func TestCatalog_Nil(t *testing.T) {
+	var c *catalog
+	if _, ok := c.translate("Settings"); ok {
+		t.Error("translate() on a nil catalog found a translation")
+	}
+	if _, ok := c.translatePlural("%d file", 2); ok {
+		t.Error("translatePlural() on a nil catalog found a translation")
+	}
+}
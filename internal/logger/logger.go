@@ -103,6 +103,16 @@ func (l *Logger) Close() error {
 	return nil
 }
 
+// Sync flushes the log file to disk.
+func (l *Logger) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		return l.file.Sync()
+	}
+	return nil
+}
+
 // SetLevel sets the minimum log level.
 func (l *Logger) SetLevel(level Level) {
 	l.mu.Lock()
@@ -187,6 +197,14 @@ func Close() error {
 	return nil
 }
 
+// Sync flushes the default log file to disk.
+func Sync() error {
+	if defaultLogger != nil {
+		return defaultLogger.Sync()
+	}
+	return nil
+}
+
 // LogFile returns the current log file path.
 func LogFile() string {
 	logDir := filepath.Join(config.GetDataDir(), "logs")
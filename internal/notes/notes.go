@@ -0,0 +1,155 @@
+// Package notes appends answers and chats to Markdown files in a notes
+// folder, such as an Obsidian vault or a Logseq graph. Each chat gets one
+// file with YAML front matter, and content that was already sent is not
+// added again.
+package notes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+// DefaultTags are the tags of a new note when none are configured.
+var DefaultTags = []string{"guanaco"}
+
+// Layouts of the date in the front matter and of section headings.
+const (
+	dateFormat    = "2006-01-02"
+	sectionFormat = "2006-01-02 15:04"
+)
+
+// Note is content to append to the note of a chat.
+type Note struct {
+	Title string    // Chat title, also used for the file name
+	Model string    // Model that wrote the content
+	Date  time.Time // When the content was sent to notes
+	Tags  []string
+	Body  string // Markdown content
+}
+
+// FileName returns the name of the note file for a chat title.
+func FileName(title string) string {
+	return store.ExportFileName(title, store.ExportMarkdown)
+}
+
+// Append adds note to the file of its chat in folder, creating the file with
+// front matter if needed. It returns the path of the file and whether the
+// body was added: a body that is already in the file is skipped.
+func Append(folder string, note Note) (string, bool, error) {
+	if folder == "" {
+		return "", false, fmt.Errorf("no notes folder configured")
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", false, fmt.Errorf("failed to create notes folder: %w", err)
+	}
+
+	path := filepath.Join(folder, FileName(note.Title))
+	marker := contentMarker(note.Body)
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return path, false, fmt.Errorf("failed to read note: %w", err)
+	}
+	if strings.Contains(string(existing), marker) {
+		return path, false, nil
+	}
+
+	var b strings.Builder
+	if len(existing) == 0 {
+		b.WriteString(FrontMatter(note))
+	} else if !strings.HasSuffix(string(existing), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(marker)
+	b.WriteString("\n")
+	b.WriteString(strings.TrimSpace(note.Body))
+	b.WriteString("\n")
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return path, false, fmt.Errorf("failed to open note: %w", err)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return path, false, fmt.Errorf("failed to write note: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return path, false, fmt.Errorf("failed to write note: %w", err)
+	}
+	return path, true, nil
+}
+
+// FrontMatter returns the YAML front matter for a new note file.
+func FrontMatter(note Note) string {
+	tags := note.Tags
+	if len(tags) == 0 {
+		tags = DefaultTags
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", store.YAMLString(note.Title))
+	fmt.Fprintf(&b, "date: %s\n", note.Date.Format(dateFormat))
+	fmt.Fprintf(&b, "model: %s\n", store.YAMLString(note.Model))
+	fmt.Fprintf(&b, "chat: %s\n", store.YAMLString(note.Title))
+	b.WriteString("tags:\n")
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "  - %s\n", store.YAMLString(tag))
+	}
+	b.WriteString("---\n")
+	return b.String()
+}
+
+// AnswerBody returns the Markdown section for a single answer, with the
+// question it replies to quoted above it.
+func AnswerBody(prompt, answer string, date time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", date.Format(sectionFormat))
+	if prompt = strings.TrimSpace(prompt); prompt != "" {
+		for _, line := range strings.Split(prompt, "\n") {
+			b.WriteString(strings.TrimRight("> "+line, " "))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.TrimSpace(answer))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// ChatBody returns the Markdown section for a full chat.
+func ChatBody(chat store.ExportedChat) (string, error) {
+	var b strings.Builder
+	if err := store.WriteExport(&b, []store.ExportedChat{chat}, store.ExportMarkdown); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ParseTags splits a comma-separated list of tags. Leading '#' signs are
+// removed and spaces inside a tag become dashes, as note apps expect.
+func ParseTags(text string) []string {
+	var tags []string
+	for _, part := range strings.Split(text, ",") {
+		tag := strings.TrimLeft(strings.TrimSpace(part), "#")
+		tag = strings.Join(strings.Fields(tag), "-")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// contentMarker returns the comment that identifies body in a note file.
+func contentMarker(body string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(body)))
+	return "<!-- guanaco:" + hex.EncodeToString(sum[:8]) + " -->"
+}
@@ -0,0 +1,104 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	folder := filepath.Join(t.TempDir(), "vault")
+	date := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	note := Note{
+		Title: "Trip ideas",
+		Model: "llama3",
+		Date:  date,
+		Tags:  []string{"travel"},
+		Body:  AnswerBody("Where should I go?", "Try the coast.", date),
+	}
+
+	path, added, err := Append(folder, note)
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if !added || path != filepath.Join(folder, "Trip ideas.md") {
+		t.Errorf("Append() = %q, %v, want a new Trip ideas.md", path, added)
+	}
+
+	// The same content is not added twice
+	if _, added, err := Append(folder, note); err != nil || added {
+		t.Errorf("Append() of a repeated note = %v, %v, want not added", added, err)
+	}
+
+	note.Body = AnswerBody("And in winter?", "The mountains.", date)
+	if _, added, err := Append(folder, note); err != nil || !added {
+		t.Errorf("Append() of a new answer = %v, %v, want added", added, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.HasPrefix(content, "---\ntitle: \"Trip ideas\"\ndate: 2024-05-01\nmodel: \"llama3\"\n") {
+		t.Errorf("note does not start with front matter:\n%s", content)
+	}
+	if strings.Count(content, "---\n") != 2 {
+		t.Errorf("note has more than one front matter block:\n%s", content)
+	}
+	if strings.Count(content, "Try the coast.") != 1 || !strings.Contains(content, "The mountains.") {
+		t.Errorf("note content = %q, want both answers once", content)
+	}
+}
+
+func TestAppendWithoutFolder(t *testing.T) {
+	if _, _, err := Append("", Note{Title: "Chat", Body: "Hello"}); err == nil {
+		t.Error("Append() without a folder should fail")
+	}
+}
+
+func TestFrontMatter(t *testing.T) {
+	got := FrontMatter(Note{
+		Title: `Say "hi"`,
+		Model: "llama3",
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	})
+	want := "---\n" +
+		"title: \"Say \\\"hi\\\"\"\n" +
+		"date: 2024-05-01\n" +
+		"model: \"llama3\"\n" +
+		"chat: \"Say \\\"hi\\\"\"\n" +
+		"tags:\n" +
+		"  - \"guanaco\"\n" +
+		"---\n"
+	if got != want {
+		t.Errorf("FrontMatter() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAnswerBody(t *testing.T) {
+	date := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	got := AnswerBody("First line\n\nSecond line", "  The answer.\n", date)
+	want := "## 2024-05-01 09:30\n\n> First line\n>\n> Second line\n\nThe answer.\n"
+	if got != want {
+		t.Errorf("AnswerBody() = %q, want %q", got, want)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"ai", []string{"ai"}},
+		{"#ai, local llm ,, notes", []string{"ai", "local-llm", "notes"}},
+	}
+
+	for _, tt := range tests {
+		got := ParseTags(tt.input)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// API is the part of the Ollama server the app uses. Client implements it
+// over HTTP; tests can use a fake instead, and other transports, such as a
+// Unix socket, can take its place.
+type API interface {
+	// BaseURL returns the address of the server, for messages to the user.
+	BaseURL() string
+	IsHealthy(ctx context.Context) bool
+
+	ListModels(ctx context.Context) ([]Model, error)
+	HasModel(ctx context.Context, model string) bool
+	ShowModel(ctx context.Context, name string) (*ModelInfo, error)
+	PullModel(ctx context.Context, model string, callback PullProgressCallback) error
+	DeleteModel(ctx context.Context, name string) error
+	CopyModel(ctx context.Context, source, destination string) error
+	ListRunning(ctx context.Context) ([]RunningModel, error)
+	UnloadModel(ctx context.Context, name string) error
+
+	Chat(ctx context.Context, req *ChatRequest, callback TokenCallback) (*ChatResult, error)
+	Generate(ctx context.Context, req *GenerateRequest, callback TokenCallback) (*ResponseStats, error)
+	Embeddings(ctx context.Context, model string, input []string) ([][]float32, error)
+}
+
+var _ API = (*Client)(nil)
+
+// Embeddings returns the embedding of each text of input, computed by an
+// embedding model such as nomic-embed-text, in the same order.
+func (c *Client) Embeddings(ctx context.Context, model string, input []string) ([][]float32, error) {
+	body, err := json.Marshal(struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}{Model: model, Input: input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL()+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Embeddings [][]float32 `json:"embeddings"`
+		Error      string      `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Error != "" {
+		return nil, classifyError(result.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if len(result.Embeddings) != len(input) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(result.Embeddings), len(input))
+	}
+	return result.Embeddings, nil
+}
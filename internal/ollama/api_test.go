@@ -0,0 +1,81 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeAPI answers chats with fixed tokens, to test code that takes an API.
+type fakeAPI struct {
+	API
+	tokens []string
+	chats  []*ChatRequest
+}
+
+func (f *fakeAPI) Chat(ctx context.Context, req *ChatRequest, callback TokenCallback) (*ChatResult, error) {
+	f.chats = append(f.chats, req)
+	for _, token := range f.tokens {
+		callback(token)
+	}
+	return &ChatResult{Stats: &ResponseStats{EvalTokens: len(f.tokens)}}, nil
+}
+
+func TestStreamHandler_UsesAPI(t *testing.T) {
+	fake := &fakeAPI{tokens: []string{"Hello", " there"}}
+	handler := NewStreamHandler(fake)
+
+	var response strings.Builder
+	err := handler.Chat(context.Background(), &ChatRequest{Model: "llama3"}, func(token string) {
+		response.WriteString(token)
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if response.String() != "Hello there" {
+		t.Errorf("Chat() streamed %q, want %q", response.String(), "Hello there")
+	}
+	if len(fake.chats) != 1 || fake.chats[0].Model != "llama3" {
+		t.Errorf("Chat() sent %+v, want one request for llama3", fake.chats)
+	}
+}
+
+func TestClient_Embeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("request path = %q, want /api/embed", r.URL.Path)
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "llama3" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "\"llama3\" does not support embeddings"}`))
+			return
+		}
+		embeddings := make([][]float32, len(req.Input))
+		for i, text := range req.Input {
+			embeddings[i] = []float32{float32(len(text)), 0.5}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"model": req.Model, "embeddings": embeddings})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	got, err := client.Embeddings(context.Background(), "nomic-embed-text", []string{"one", "three"})
+	if err != nil {
+		t.Fatalf("Embeddings() error = %v", err)
+	}
+	if len(got) != 2 || got[0][0] != 3 || got[1][0] != 5 || got[1][1] != 0.5 {
+		t.Errorf("Embeddings() = %v", got)
+	}
+
+	if _, err := client.Embeddings(context.Background(), "llama3", []string{"one"}); err == nil || !strings.Contains(err.Error(), "does not support embeddings") {
+		t.Errorf("Embeddings(llama3) error = %v, want the server's error", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -40,17 +41,25 @@ type modelsResponse struct {
 
 // Client is an HTTP client for the Ollama API.
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	mu           sync.RWMutex
+	baseURL      string
+	conn         Connection
+	transport    *http.Transport
+	httpClient   *http.Client
+	streamClient *http.Client // Without timeout
 }
 
 // NewClient creates a new Ollama client with the given base URL.
 func NewClient(baseURL string) *Client {
+	transport := Connection{}.transport()
 	return &Client{
-		baseURL: baseURL,
+		baseURL:   baseURL,
+		transport: transport,
 		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
+			Transport: transport,
+			Timeout:   DefaultTimeout,
 		},
+		streamClient: &http.Client{Transport: transport},
 	}
 }
 
@@ -59,14 +68,29 @@ func NewClientDefault() *Client {
 	return NewClient(DefaultBaseURL)
 }
 
+// BaseURL returns the server URL the client talks to.
+func (c *Client) BaseURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.baseURL
+}
+
+// SetBaseURL points the client at another server. Requests already in
+// flight keep using the previous URL.
+func (c *Client) SetBaseURL(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = baseURL
+}
+
 // IsHealthy checks if the Ollama server is running and responsive.
 func (c *Client) IsHealthy(ctx context.Context) bool {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL(), nil)
 	if err != nil {
 		return false
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, false)
 	if err != nil {
 		return false
 	}
@@ -77,14 +101,14 @@ func (c *Client) IsHealthy(ctx context.Context) bool {
 
 // ListModels returns all available models from the Ollama server.
 func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
-	url := c.baseURL + "/api/tags"
+	url := c.BaseURL() + "/api/tags"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -102,12 +126,171 @@ func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
 	return modelsResp.Models, nil
 }
 
+// ModelDetails describes the format and size of a model.
+type ModelDetails struct {
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// ModelInfo is the information about a model returned by /api/show.
+type ModelInfo struct {
+	Modelfile    string         `json:"modelfile"`
+	Parameters   string         `json:"parameters"`
+	Template     string         `json:"template"`
+	License      string         `json:"license"`
+	Details      ModelDetails   `json:"details"`
+	Metadata     map[string]any `json:"model_info"`
+	Capabilities []string       `json:"capabilities"` // e.g. "completion", "tools"; empty on older servers
+}
+
+// SupportsTools reports whether the model can call tools. Servers that
+// don't report capabilities are asked through the model's template, which
+// mentions the tools when the model supports them.
+func (m *ModelInfo) SupportsTools() bool {
+	if len(m.Capabilities) > 0 {
+		for _, c := range m.Capabilities {
+			if c == "tools" {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(m.Template, ".Tools")
+}
+
+// SupportsVision reports whether the model accepts images. Servers that
+// don't report capabilities are asked through the model's families and
+// metadata, which mention the image encoder of vision models.
+func (m *ModelInfo) SupportsVision() bool {
+	if len(m.Capabilities) > 0 {
+		for _, c := range m.Capabilities {
+			if c == "vision" {
+				return true
+			}
+		}
+		return false
+	}
+	for _, family := range m.Details.Families {
+		if family == "clip" || family == "mllama" {
+			return true
+		}
+	}
+	for key := range m.Metadata {
+		if strings.Contains(key, ".vision.") {
+			return true
+		}
+	}
+	return false
+}
+
+// ContextLength returns the context length the model was trained with, from
+// its metadata, or 0 if it is unknown.
+func (m *ModelInfo) ContextLength() int {
+	arch, _ := m.Metadata["general.architecture"].(string)
+	if n, ok := m.Metadata[arch+".context_length"].(float64); ok {
+		return int(n)
+	}
+	for key, value := range m.Metadata {
+		if n, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// ShowModel returns information about a local model.
+func (c *Client) ShowModel(ctx context.Context, name string) (*ModelInfo, error) {
+	url := c.BaseURL() + "/api/show"
+
+	body, err := json.Marshal(struct {
+		Model string `json:"model"`
+	}{Model: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var info ModelInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &info, nil
+}
+
+// DeleteModel removes a local model and the data only it uses.
+func (c *Client) DeleteModel(ctx context.Context, name string) error {
+	body, err := json.Marshal(struct {
+		Model string `json:"model"`
+	}{Model: name})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.send(ctx, http.MethodDelete, "/api/delete", body)
+}
+
+// CopyModel creates a copy of a local model under another name.
+func (c *Client) CopyModel(ctx context.Context, source, destination string) error {
+	body, err := json.Marshal(struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	}{Source: source, Destination: destination})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.send(ctx, http.MethodPost, "/api/copy", body)
+}
+
+// send makes a request with a JSON body to an endpoint that replies with
+// just a status.
+func (c *Client) send(ctx context.Context, method, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL()+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req, false)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("ollama error: %s", errResp.Error)
+		}
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // PullProgressCallback is called with progress updates during model pull.
 type PullProgressCallback func(status string, completed, total int64)
 
 // PullModel downloads a model from the Ollama registry.
 func (c *Client) PullModel(ctx context.Context, model string, callback PullProgressCallback) error {
-	url := c.baseURL + "/api/pull"
+	url := c.BaseURL() + "/api/pull"
 
 	// Use json.Marshal to safely encode the model name
 	reqBody := struct {
@@ -127,9 +310,7 @@ func (c *Client) PullModel(ctx context.Context, model string, callback PullProgr
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	// Use a client without timeout for long downloads
-	pullClient := &http.Client{}
-	resp, err := pullClient.Do(req)
+	resp, err := c.do(req, true)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -182,7 +363,12 @@ func (c *Client) HasModel(ctx context.Context, model string) bool {
 	if err != nil {
 		return false
 	}
+	return ModelInstalled(models, model)
+}
 
+// ModelInstalled reports whether model is among models. A name without a
+// tag matches any tag of the model.
+func ModelInstalled(models []Model, model string) bool {
 	for _, m := range models {
 		if m.Name == model || strings.HasPrefix(m.Name, model+":") {
 			return true
@@ -2,6 +2,7 @@ package ollama
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -33,6 +34,30 @@ func TestNewClientDefault(t *testing.T) {
 	}
 }
 
+func TestClient_SetBaseURL(t *testing.T) {
+	down := NewClient("http://127.0.0.1:1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if down.IsHealthy(ctx) {
+		t.Fatal("IsHealthy() = true for unreachable server")
+	}
+
+	down.SetBaseURL(server.URL)
+	if got := down.BaseURL(); got != server.URL {
+		t.Errorf("BaseURL() = %q, want %q", got, server.URL)
+	}
+	if !down.IsHealthy(ctx) {
+		t.Error("IsHealthy() = false after switching to a running server")
+	}
+}
+
 func TestClient_IsHealthy(t *testing.T) {
 	// Create mock server that responds to health check
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -120,6 +145,135 @@ func TestClient_ListModels_Error(t *testing.T) {
 	}
 }
 
+func TestClient_ShowModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/show" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3:latest" {
+			t.Errorf("request model = %q, want %q", req.Model, "llama3:latest")
+		}
+
+		w.Write([]byte(`{
+			"license": "LLAMA 3 COMMUNITY LICENSE",
+			"modelfile": "FROM llama3",
+			"template": "{{ .Prompt }}",
+			"details": {"format": "gguf", "family": "llama", "parameter_size": "8.0B", "quantization_level": "Q4_0"},
+			"model_info": {"general.architecture": "llama", "llama.context_length": 8192}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info, err := client.ShowModel(ctx, "llama3:latest")
+	if err != nil {
+		t.Fatalf("ShowModel() error = %v", err)
+	}
+
+	if info.Details.ParameterSize != "8.0B" || info.Details.QuantizationLevel != "Q4_0" || info.Details.Family != "llama" {
+		t.Errorf("ShowModel() details = %+v", info.Details)
+	}
+	if info.Template != "{{ .Prompt }}" || info.License != "LLAMA 3 COMMUNITY LICENSE" {
+		t.Errorf("ShowModel() = %+v, want template and license", info)
+	}
+	if got := info.ContextLength(); got != 8192 {
+		t.Errorf("ContextLength() = %d, want 8192", got)
+	}
+}
+
+func TestClient_ShowModel_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).ShowModel(context.Background(), "missing")
+	if err == nil {
+		t.Error("ShowModel() should return error for 404 response")
+	}
+}
+
+func TestClient_DeleteModel(t *testing.T) {
+	var method, path, model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		model = req.Model
+	}))
+	defer server.Close()
+
+	if err := NewClient(server.URL).DeleteModel(context.Background(), "llama3:latest"); err != nil {
+		t.Fatalf("DeleteModel() error = %v", err)
+	}
+	if method != http.MethodDelete || path != "/api/delete" || model != "llama3:latest" {
+		t.Errorf("DeleteModel() sent %s %s for %q", method, path, model)
+	}
+}
+
+func TestClient_DeleteModel_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "model 'missing' not found"}`))
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL).DeleteModel(context.Background(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("DeleteModel() error = %v, want server error message", err)
+	}
+}
+
+func TestClient_CopyModel(t *testing.T) {
+	var path string
+	var req struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&req)
+	}))
+	defer server.Close()
+
+	if err := NewClient(server.URL).CopyModel(context.Background(), "llama3:latest", "llama3-copy:latest"); err != nil {
+		t.Fatalf("CopyModel() error = %v", err)
+	}
+	if path != "/api/copy" || req.Source != "llama3:latest" || req.Destination != "llama3-copy:latest" {
+		t.Errorf("CopyModel() sent %s with %+v", path, req)
+	}
+}
+
+func TestModelInfo_ContextLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]any
+		want     int
+	}{
+		{name: "unknown", metadata: nil, want: 0},
+		{name: "architecture key", metadata: map[string]any{"general.architecture": "qwen2", "qwen2.context_length": float64(32768)}, want: 32768},
+		{name: "without architecture", metadata: map[string]any{"gemma.context_length": float64(8192)}, want: 8192},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &ModelInfo{Metadata: tt.metadata}
+			if got := info.ContextLength(); got != tt.want {
+				t.Errorf("ContextLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestModel_String(t *testing.T) {
 	model := Model{
 		Name: "llama3:latest",
@@ -131,3 +285,24 @@ func TestModel_String(t *testing.T) {
 		t.Errorf("Model.String() = %q, want to contain 'llama3'", str)
 	}
 }
+
+func TestModelInstalled(t *testing.T) {
+	models := []Model{{Name: "llama3:latest"}, {Name: "qwen2.5:7b"}}
+
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"llama3:latest", true},
+		{"llama3", true},
+		{"qwen2.5:7b", true},
+		{"qwen2.5:14b", false},
+		{"llama", false},
+		{"mistral", false},
+	}
+	for _, tt := range tests {
+		if got := ModelInstalled(models, tt.model); got != tt.want {
+			t.Errorf("ModelInstalled(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package ollama
+
+import (
+	"context"
+	"maps"
+	"net"
+	"net/http"
+)
+
+// SocketBaseURL is the base URL of requests to a server reached over a Unix
+// socket. The host is not used to connect, only to build the request URLs.
+const SocketBaseURL = "http://localhost"
+
+// Connection holds how the client reaches the server, besides its URL.
+type Connection struct {
+	// Socket is the path of a Unix socket the server listens on. When set,
+	// requests are sent over the socket instead of TCP.
+	Socket string
+
+	// Headers are added to every request, such as an Authorization header
+	// checked by a proxy in front of the server.
+	Headers map[string]string
+}
+
+// Equal reports whether conn and other connect the same way.
+func (conn Connection) Equal(other Connection) bool {
+	return conn.Socket == other.Socket && maps.Equal(conn.Headers, other.Headers)
+}
+
+// transport returns the HTTP transport dialing the socket of conn, or TCP
+// when it has none.
+func (conn Connection) transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if conn.Socket != "" {
+		socket := conn.Socket
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+	return transport
+}
+
+// SetConnection changes how the client reaches the server, and reports
+// whether it differs from before. Requests already in flight finish over
+// the previous connection.
+func (c *Client) SetConnection(conn Connection) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if conn.Equal(c.conn) {
+		return false
+	}
+	old := c.transport
+	c.conn = Connection{Socket: conn.Socket, Headers: maps.Clone(conn.Headers)}
+	c.transport = c.conn.transport()
+	c.httpClient = &http.Client{Transport: c.transport, Timeout: DefaultTimeout}
+	c.streamClient = &http.Client{Transport: c.transport}
+	old.CloseIdleConnections()
+	return true
+}
+
+// do sends req with the headers of the connection. Streaming requests are
+// sent without a timeout, as loading a model or downloading one can take
+// minutes.
+func (c *Client) do(req *http.Request, stream bool) (*http.Response, error) {
+	c.mu.RLock()
+	client := c.httpClient
+	if stream {
+		client = c.streamClient
+	}
+	for name, value := range c.conn.Headers {
+		req.Header.Set(name, value)
+	}
+	c.mu.RUnlock()
+
+	return client.Do(req)
+}
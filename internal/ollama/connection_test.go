@@ -0,0 +1,66 @@
+package ollama
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestClient_SetConnection_Socket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "ollama.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client := NewClient(SocketBaseURL)
+	if client.IsHealthy(context.Background()) {
+		t.Fatal("IsHealthy() = true before the socket is set")
+	}
+
+	if !client.SetConnection(Connection{Socket: socket}) {
+		t.Error("SetConnection() = false, want true for a new socket")
+	}
+	if !client.IsHealthy(context.Background()) {
+		t.Error("IsHealthy() = false over the socket")
+	}
+}
+
+func TestClient_SetConnection_Headers(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	conn := Connection{Headers: map[string]string{"Authorization": "Bearer secret"}}
+	if !client.SetConnection(conn) {
+		t.Error("SetConnection() = false, want true for new headers")
+	}
+	if client.SetConnection(conn) {
+		t.Error("SetConnection() = true, want false for the same headers")
+	}
+
+	// Changing the map passed in must not change the client
+	conn.Headers["Authorization"] = "Bearer other"
+
+	if _, err := client.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+	}
+}
@@ -0,0 +1,38 @@
+package ollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FormatJSON is the output format asking for any JSON value.
+const FormatJSON = "json"
+
+// OutputFormat returns the format of a request for an output format: ""
+// for text, FormatJSON for any JSON value, or else a JSON schema the
+// response must follow.
+func OutputFormat(format string) (json.RawMessage, error) {
+	format = strings.TrimSpace(format)
+	switch format {
+	case "":
+		return nil, nil
+	case FormatJSON:
+		return json.RawMessage(`"json"`), nil
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(format), &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if schema == nil {
+		return nil, errors.New("invalid JSON schema: not an object")
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(format)); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return compact.Bytes(), nil
+}
@@ -0,0 +1,53 @@
+package ollama
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{" json ", `"json"`, false},
+		{"{\n  \"type\": \"object\",\n  \"properties\": {\"age\": {\"type\": \"integer\"}}\n}", `{"type":"object","properties":{"age":{"type":"integer"}}}`, false},
+		{"{\"type\": ", "", true},
+		{"[1, 2]", "", true},
+		{"null", "", true},
+		{"yaml", "", true},
+	}
+	for _, tt := range tests {
+		got, err := OutputFormat(tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("OutputFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("OutputFormat(%q) = %s, want %s", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestChatRequest_Format(t *testing.T) {
+	format, _ := OutputFormat(FormatJSON)
+	data, err := json.Marshal(&ChatRequest{Model: "llama3", Format: format})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req map[string]any
+	json.Unmarshal(data, &req)
+	if req["format"] != "json" {
+		t.Errorf("format = %v, want %q", req["format"], "json")
+	}
+
+	// Text responses leave the field out
+	data, _ = json.Marshal(&ChatRequest{Model: "llama3"})
+	req = nil
+	json.Unmarshal(data, &req)
+	if _, ok := req["format"]; ok {
+		t.Errorf("request %s has a format for text", data)
+	}
+}
@@ -0,0 +1,118 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// GenerateRequest represents a request to the generate API, which completes
+// a single prompt instead of a conversation. It works with base models that
+// have no chat template.
+type GenerateRequest struct {
+	Model  string   `json:"model"`
+	Prompt string   `json:"prompt"`
+	System string   `json:"system,omitempty"`
+	Images []string `json:"images,omitempty"`
+
+	// Template overrides the prompt template of the model.
+	Template string `json:"template,omitempty"`
+
+	// Raw sends the prompt as is, without applying any template.
+	Raw bool `json:"raw,omitempty"`
+
+	// KeepAlive is how long the model stays loaded after the request,
+	// e.g. "10m", or "-1" to keep it loaded. Empty uses the server default.
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"`
+
+	// Format constrains the response to JSON, see OutputFormat.
+	Format json.RawMessage `json:"format,omitempty"`
+
+	Stream bool `json:"stream"`
+}
+
+// generateResponse represents a streaming response chunk from the generate API.
+type generateResponse struct {
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"`
+	ResponseStats
+}
+
+// Generate sends a completion request and streams the response tokens.
+// The callback is called for each token received.
+// Returns when the response is complete or context is cancelled.
+func (h *StreamHandler) Generate(ctx context.Context, req *GenerateRequest, callback TokenCallback) error {
+	_, err := h.GenerateWithStats(ctx, req, callback)
+	return err
+}
+
+// GenerateWithStats is like Generate, and also returns the statistics of
+// the response.
+func (h *StreamHandler) GenerateWithStats(ctx context.Context, req *GenerateRequest, callback TokenCallback) (*ResponseStats, error) {
+	return h.client.Generate(ctx, req, callback)
+}
+
+// Generate sends a completion request and streams the response tokens to
+// callback. It returns the statistics of the response once it is complete,
+// or when ctx is cancelled.
+func (c *Client) Generate(ctx context.Context, req *GenerateRequest, callback TokenCallback) (*ResponseStats, error) {
+	// Always stream
+	req.Stream = true
+
+	return c.stream(ctx, "/api/generate", req, func(line []byte) (streamChunk, bool) {
+		var chunk generateResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return streamChunk{}, false
+		}
+		return streamChunk{Token: chunk.Response, Done: chunk.Done, Error: chunk.Error, Stats: chunk.ResponseStats}, true
+	}, callback)
+}
+
+// CompletionPrompt flattens a conversation into the system prompt and the
+// prompt of a generate request. A single user message is used as the prompt
+// itself, so base models simply continue the text. Longer conversations are
+// written as a transcript ending with the assistant's turn, left open (or
+// holding a partial reply) for the model to complete.
+func CompletionPrompt(messages []Message) (system, prompt string) {
+	var systemParts []string
+	var turns []Message
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			systemParts = append(systemParts, msg.Content)
+		} else {
+			turns = append(turns, msg)
+		}
+	}
+	system = strings.Join(systemParts, "\n\n")
+
+	if len(turns) == 1 && turns[0].Role == "user" {
+		return system, turns[0].Content
+	}
+
+	var builder strings.Builder
+	for i, msg := range turns {
+		if i > 0 {
+			builder.WriteString("\n\n")
+		}
+		builder.WriteString(transcriptSpeaker(msg.Role))
+		builder.WriteString(": ")
+		builder.WriteString(msg.Content)
+	}
+	if len(turns) == 0 || turns[len(turns)-1].Role != "assistant" {
+		if len(turns) > 0 {
+			builder.WriteString("\n\n")
+		}
+		builder.WriteString(transcriptSpeaker("assistant"))
+		builder.WriteString(":")
+	}
+	return system, builder.String()
+}
+
+// transcriptSpeaker returns the name of the speaker of role in a transcript.
+func transcriptSpeaker(role string) string {
+	if role == "assistant" {
+		return "Assistant"
+	}
+	return "User"
+}
@@ -0,0 +1,130 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamHandler_Generate_ReceivesTokens(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		tokens := []string{"Once", " upon", " a time"}
+		for i, token := range tokens {
+			data, _ := json.Marshal(map[string]interface{}{
+				"response": token,
+				"done":     i == len(tokens)-1,
+			})
+			w.Write(data)
+			w.Write([]byte("\n"))
+		}
+	}))
+	defer server.Close()
+
+	handler := NewStreamHandler(NewClient(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received strings.Builder
+	err := handler.Generate(ctx, &GenerateRequest{
+		Model:     "base",
+		Prompt:    "Tell a story:",
+		Raw:       true,
+		KeepAlive: "10m",
+	}, func(token string) {
+		received.WriteString(token)
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if received.String() != "Once upon a time" {
+		t.Errorf("Generate() received = %q, want %q", received.String(), "Once upon a time")
+	}
+	for key, want := range map[string]interface{}{
+		"model":      "base",
+		"prompt":     "Tell a story:",
+		"raw":        true,
+		"keep_alive": "10m",
+		"stream":     true,
+	} {
+		if got[key] != want {
+			t.Errorf("request %s = %v, want %v", key, got[key], want)
+		}
+	}
+	if _, ok := got["template"]; ok {
+		t.Error("request should omit an empty template")
+	}
+}
+
+func TestStreamHandler_Generate_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error": "model 'base' not found"}` + "\n"))
+	}))
+	defer server.Close()
+
+	handler := NewStreamHandler(NewClient(server.URL))
+	err := handler.Generate(context.Background(), &GenerateRequest{Model: "base", Prompt: "Hi"}, func(token string) {})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Generate() error = %v, want server error message", err)
+	}
+}
+
+func TestCompletionPrompt(t *testing.T) {
+	tests := []struct {
+		name       string
+		messages   []Message
+		wantSystem string
+		wantPrompt string
+	}{
+		{
+			name: "single message is the prompt",
+			messages: []Message{
+				{Role: "system", Content: "Be brief"},
+				{Role: "user", Content: "The quick brown fox"},
+			},
+			wantSystem: "Be brief",
+			wantPrompt: "The quick brown fox",
+		},
+		{
+			name: "conversation as transcript",
+			messages: []Message{
+				{Role: "user", Content: "Hi"},
+				{Role: "assistant", Content: "Hello!"},
+				{Role: "user", Content: "How are you?"},
+			},
+			wantPrompt: "User: Hi\n\nAssistant: Hello!\n\nUser: How are you?\n\nAssistant:",
+		},
+		{
+			name: "partial reply is continued",
+			messages: []Message{
+				{Role: "user", Content: "Count"},
+				{Role: "assistant", Content: "One, two"},
+			},
+			wantPrompt: "User: Count\n\nAssistant: One, two",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			system, prompt := CompletionPrompt(tt.messages)
+			if system != tt.wantSystem {
+				t.Errorf("CompletionPrompt() system = %q, want %q", system, tt.wantSystem)
+			}
+			if prompt != tt.wantPrompt {
+				t.Errorf("CompletionPrompt() prompt = %q, want %q", prompt, tt.wantPrompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeepAlive is how long a model stays loaded after a request: a duration
+// such as "10m", or a number of seconds such as "300". Negative values keep
+// it loaded. Empty uses the server default.
+type KeepAlive string
+
+// MarshalJSON sends a number of seconds as a JSON number: Ollama reads a
+// string as a duration, which needs a unit.
+func (k KeepAlive) MarshalJSON() ([]byte, error) {
+	value := strings.TrimSpace(string(k))
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return []byte(value), nil
+	}
+	return json.Marshal(value)
+}
+
+// RunningModel is a model loaded in the memory of the server, as listed by
+// /api/ps.
+type RunningModel struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`       // Memory used, in bytes
+	SizeVRAM  int64     `json:"size_vram"`  // Part of Size in video memory
+	ExpiresAt time.Time `json:"expires_at"` // When it is unloaded unless used again
+}
+
+// KeptLoaded reports whether the model stays loaded until it is unloaded
+// explicitly, as with a negative keep-alive. Ollama reports those with an
+// expiry years ahead.
+func (m RunningModel) KeptLoaded(now time.Time) bool {
+	return m.ExpiresAt.After(now.AddDate(1, 0, 0))
+}
+
+// ListRunning returns the models loaded in memory.
+func (c *Client) ListRunning(ctx context.Context) ([]RunningModel, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL()+"/api/ps", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Models []RunningModel `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Models, nil
+}
+
+// UnloadModel frees the memory of a loaded model, with a generate request
+// that keeps it loaded for no time.
+func (c *Client) UnloadModel(ctx context.Context, name string) error {
+	body, err := json.Marshal(struct {
+		Model     string `json:"model"`
+		KeepAlive int    `json:"keep_alive"`
+		Stream    bool   `json:"stream"`
+	}{Model: name})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.send(ctx, http.MethodPost, "/api/generate", body)
+}
@@ -0,0 +1,101 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_ListRunning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ps" || r.Method != http.MethodGet {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{
+			"models": [
+				{"name": "llama3:latest", "size": 5137025024, "size_vram": 5137025024, "expires_at": "2024-06-04T14:38:31.83753-07:00"}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	models, err := NewClient(server.URL).ListRunning(context.Background())
+	if err != nil {
+		t.Fatalf("ListRunning() error = %v", err)
+	}
+	if len(models) != 1 {
+		t.Fatalf("ListRunning() returned %d models, want 1", len(models))
+	}
+	m := models[0]
+	if m.Name != "llama3:latest" || m.Size != 5137025024 || m.SizeVRAM != 5137025024 {
+		t.Errorf("ListRunning()[0] = %+v", m)
+	}
+	if m.ExpiresAt.IsZero() {
+		t.Error("ListRunning()[0].ExpiresAt not decoded")
+	}
+}
+
+func TestClient_ListRunning_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL).ListRunning(context.Background()); err == nil {
+		t.Error("ListRunning() should return error for 404 response")
+	}
+}
+
+func TestClient_UnloadModel(t *testing.T) {
+	var path string
+	var req map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&req)
+	}))
+	defer server.Close()
+
+	if err := NewClient(server.URL).UnloadModel(context.Background(), "llama3:latest"); err != nil {
+		t.Fatalf("UnloadModel() error = %v", err)
+	}
+	if path != "/api/generate" || req["model"] != "llama3:latest" || req["keep_alive"] != float64(0) || req["stream"] != false {
+		t.Errorf("UnloadModel() sent %s with %v", path, req)
+	}
+}
+
+func TestRunningModel_KeptLoaded(t *testing.T) {
+	now := time.Date(2024, 6, 4, 12, 0, 0, 0, time.UTC)
+	if (RunningModel{ExpiresAt: now.Add(5 * time.Minute)}).KeptLoaded(now) {
+		t.Error("KeptLoaded() = true for a model expiring in 5 minutes")
+	}
+	if !(RunningModel{ExpiresAt: now.AddDate(290, 0, 0)}).KeptLoaded(now) {
+		t.Error("KeptLoaded() = false for a model kept loaded indefinitely")
+	}
+}
+
+func TestKeepAlive_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		keepAlive KeepAlive
+		want      string
+	}{
+		{"10m", `{"keep_alive":"10m"}`},
+		{"-1", `{"keep_alive":-1}`},
+		{" 300 ", `{"keep_alive":300}`},
+		{"-1m", `{"keep_alive":"-1m"}`},
+		{"", `{}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(struct {
+			KeepAlive KeepAlive `json:"keep_alive,omitempty"`
+		}{tt.keepAlive})
+		if err != nil {
+			t.Fatalf("Marshal(%q) error = %v", tt.keepAlive, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("KeepAlive %q sent as %s, want %s", tt.keepAlive, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package ollama
+
+import "time"
+
+// ResponseStats are the measurements Ollama reports in the final chunk of
+// a streamed response. Durations are sent in nanoseconds, which is how a
+// time.Duration decodes from JSON.
+type ResponseStats struct {
+	PromptTokens   int           `json:"prompt_eval_count"`
+	EvalTokens     int           `json:"eval_count"`
+	TotalDuration  time.Duration `json:"total_duration"`
+	LoadDuration   time.Duration `json:"load_duration"`
+	PromptDuration time.Duration `json:"prompt_eval_duration"`
+	EvalDuration   time.Duration `json:"eval_duration"`
+}
+
+// TokensPerSecond returns the generation speed of the response, or zero
+// when it is unknown.
+func (s ResponseStats) TokensPerSecond() float64 {
+	if s.EvalTokens == 0 || s.EvalDuration <= 0 {
+		return 0
+	}
+	return float64(s.EvalTokens) / s.EvalDuration.Seconds()
+}
+
+// Add returns the sum of s and other, as for a reply made of several
+// requests.
+func (s ResponseStats) Add(other ResponseStats) ResponseStats {
+	return ResponseStats{
+		PromptTokens:   s.PromptTokens + other.PromptTokens,
+		EvalTokens:     s.EvalTokens + other.EvalTokens,
+		TotalDuration:  s.TotalDuration + other.TotalDuration,
+		LoadDuration:   s.LoadDuration + other.LoadDuration,
+		PromptDuration: s.PromptDuration + other.PromptDuration,
+		EvalDuration:   s.EvalDuration + other.EvalDuration,
+	}
+}
@@ -0,0 +1,91 @@
+package ollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamHandler_Chat_Stats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":""},"done":true,"total_duration":3000000000,"load_duration":500000000,"prompt_eval_count":12,"prompt_eval_duration":250000000,"eval_count":40,"eval_duration":2000000000}` + "\n"))
+	}))
+	defer server.Close()
+
+	handler := NewStreamHandler(NewClient(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := handler.ChatWithTools(ctx, &ChatRequest{
+		Model:    "test",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}, func(string) {})
+	if err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+
+	want := ResponseStats{
+		PromptTokens:   12,
+		EvalTokens:     40,
+		TotalDuration:  3 * time.Second,
+		LoadDuration:   500 * time.Millisecond,
+		PromptDuration: 250 * time.Millisecond,
+		EvalDuration:   2 * time.Second,
+	}
+	if result.Stats == nil || *result.Stats != want {
+		t.Fatalf("ChatWithTools() stats = %+v, want %+v", result.Stats, want)
+	}
+	if got := result.Stats.TokensPerSecond(); got != 20 {
+		t.Errorf("TokensPerSecond() = %v, want 20", got)
+	}
+}
+
+func TestStreamHandler_GenerateWithStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response":"Once","done":false}` + "\n"))
+		w.Write([]byte(`{"response":"","done":true,"prompt_eval_count":3,"eval_count":5,"eval_duration":1000000000}` + "\n"))
+	}))
+	defer server.Close()
+
+	handler := NewStreamHandler(NewClient(server.URL))
+	stats, err := handler.GenerateWithStats(context.Background(), &GenerateRequest{Model: "base", Prompt: "Hi"}, func(string) {})
+	if err != nil {
+		t.Fatalf("GenerateWithStats() error = %v", err)
+	}
+	if stats == nil || stats.PromptTokens != 3 || stats.EvalTokens != 5 || stats.EvalDuration != time.Second {
+		t.Errorf("GenerateWithStats() stats = %+v, want 3 prompt and 5 eval tokens in 1s", stats)
+	}
+}
+
+func TestResponseStats_TokensPerSecond(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats ResponseStats
+		want  float64
+	}{
+		{"measured", ResponseStats{EvalTokens: 50, EvalDuration: 2 * time.Second}, 25},
+		{"no duration", ResponseStats{EvalTokens: 50}, 0},
+		{"no tokens", ResponseStats{EvalDuration: time.Second}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.TokensPerSecond(); got != tt.want {
+				t.Errorf("TokensPerSecond() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseStats_Add(t *testing.T) {
+	a := ResponseStats{PromptTokens: 10, EvalTokens: 4, TotalDuration: time.Second, EvalDuration: 200 * time.Millisecond}
+	b := ResponseStats{PromptTokens: 30, EvalTokens: 6, TotalDuration: 2 * time.Second, LoadDuration: time.Second, EvalDuration: 300 * time.Millisecond}
+
+	want := ResponseStats{PromptTokens: 40, EvalTokens: 10, TotalDuration: 3 * time.Second, LoadDuration: time.Second, EvalDuration: 500 * time.Millisecond}
+	if got := a.Add(b); got != want {
+		t.Errorf("Add() = %+v, want %+v", got, want)
+	}
+}
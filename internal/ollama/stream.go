@@ -5,32 +5,72 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 )
 
+// ErrContextOverflow is returned when the prompt does not fit in the model's context window.
+var ErrContextOverflow = errors.New("prompt exceeds the model's context length")
+
+// contextOverflowMarkers are substrings of Ollama error messages reporting a context overflow.
+var contextOverflowMarkers = []string{
+	"context length",
+	"context window",
+	"maximum context",
+	"prompt is too long",
+	"too many tokens",
+}
+
+// classifyError converts an Ollama error message into an error,
+// wrapping ErrContextOverflow when the message reports a context overflow.
+func classifyError(msg string) error {
+	lower := strings.ToLower(msg)
+	for _, marker := range contextOverflowMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("%w: %s", ErrContextOverflow, msg)
+		}
+	}
+	return fmt.Errorf("ollama error: %s", msg)
+}
+
 // Message represents a chat message.
 type Message struct {
-	Role    string   `json:"role"`
-	Content string   `json:"content"`
-	Images  []string `json:"images,omitempty"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"` // Tools the assistant asked to run
+	ToolName  string     `json:"tool_name,omitempty"`  // Tool whose result a "tool" message holds
 }
 
 // ChatRequest represents a request to the chat API.
 type ChatRequest struct {
 	Model    string    `json:"model"`
 	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream"`
+	Tools    []Tool    `json:"tools,omitempty"`
+
+	// KeepAlive is how long the model stays loaded after the request,
+	// e.g. "10m", or "-1" to keep it loaded. Empty uses the server default.
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"`
+
+	// Format constrains the response to JSON, see OutputFormat.
+	Format json.RawMessage `json:"format,omitempty"`
+
+	Stream bool `json:"stream"`
 }
 
 // chatResponse represents a streaming response chunk from the chat API.
 type chatResponse struct {
 	Message struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
+		Role      string     `json:"role"`
+		Content   string     `json:"content"`
+		ToolCalls []ToolCall `json:"tool_calls"`
 	} `json:"message"`
 	Done  bool   `json:"done"`
 	Error string `json:"error,omitempty"`
+	ResponseStats
 }
 
 // TokenCallback is called for each token received during streaming.
@@ -38,11 +78,11 @@ type TokenCallback func(token string)
 
 // StreamHandler handles streaming chat responses from Ollama.
 type StreamHandler struct {
-	client *Client
+	client API
 }
 
 // NewStreamHandler creates a new stream handler.
-func NewStreamHandler(client *Client) *StreamHandler {
+func NewStreamHandler(client API) *StreamHandler {
 	return &StreamHandler{
 		client: client,
 	}
@@ -52,43 +92,99 @@ func NewStreamHandler(client *Client) *StreamHandler {
 // The callback is called for each token received.
 // Returns when the response is complete or context is cancelled.
 func (h *StreamHandler) Chat(ctx context.Context, req *ChatRequest, callback TokenCallback) error {
+	_, err := h.ChatWithTools(ctx, req, callback)
+	return err
+}
+
+// ChatResult is what a chat response carries besides its tokens.
+type ChatResult struct {
+	// ToolCalls are the tools the model asked to run. When there are any,
+	// the caller is expected to run them and send their results back in a
+	// new request.
+	ToolCalls []ToolCall
+	// Stats are the measurements of the response, nil when the server
+	// sent none.
+	Stats *ResponseStats
+}
+
+// ChatWithTools is like Chat, and also returns the tool calls the model
+// made and the statistics of the response.
+func (h *StreamHandler) ChatWithTools(ctx context.Context, req *ChatRequest, callback TokenCallback) (*ChatResult, error) {
+	return h.client.Chat(ctx, req, callback)
+}
+
+// Chat sends a chat request and streams the response tokens to callback.
+// It returns the tool calls the model made and the statistics of the
+// response once it is complete, or when ctx is cancelled.
+func (c *Client) Chat(ctx context.Context, req *ChatRequest, callback TokenCallback) (*ChatResult, error) {
 	// Always stream
 	req.Stream = true
 
+	result := &ChatResult{}
+	stats, err := c.stream(ctx, "/api/chat", req, func(line []byte) (streamChunk, bool) {
+		var chunk chatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return streamChunk{}, false
+		}
+		result.ToolCalls = append(result.ToolCalls, chunk.Message.ToolCalls...)
+		return streamChunk{Token: chunk.Message.Content, Done: chunk.Done, Error: chunk.Error, Stats: chunk.ResponseStats}, true
+	}, callback)
+	result.Stats = stats
+	return result, err
+}
+
+// streamChunk is a streaming response chunk reduced to what all endpoints
+// have in common.
+type streamChunk struct {
+	Token string
+	Done  bool
+	Error string
+	Stats ResponseStats // Only meaningful on the final chunk
+}
+
+// stream posts req to path and calls callback with the token of each chunk
+// of the response, decoded with decode. It returns the statistics of the
+// final chunk, or nil when the response ended without one.
+func (c *Client) stream(ctx context.Context, path string, req any, decode func(line []byte) (streamChunk, bool), callback TokenCallback) (*ResponseStats, error) {
 	// Encode request body
 	body, err := json.Marshal(req)
 	if err != nil {
-		return fmt.Errorf("failed to encode request: %w", err)
+		return nil, fmt.Errorf("failed to encode request: %w", err)
 	}
 
 	// Create HTTP request
-	url := h.client.baseURL + "/api/chat"
+	url := c.BaseURL() + path
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	// Use a client without timeout for streaming (model loading can take time)
-	streamClient := &http.Client{}
-	resp, err := streamClient.Do(httpReq)
+	resp, err := c.do(httpReq, true)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Check for error response
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+			return nil, classifyError(errResp.Error)
+		}
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	// Read streaming response
+	var stats *ResponseStats
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		default:
 		}
 
@@ -97,24 +193,25 @@ func (h *StreamHandler) Chat(ctx context.Context, req *ChatRequest, callback Tok
 			continue
 		}
 
-		var chunk chatResponse
-		if err := json.Unmarshal(line, &chunk); err != nil {
+		chunk, ok := decode(line)
+		if !ok {
 			// Skip malformed lines
 			continue
 		}
 
 		// Check for error in response
 		if chunk.Error != "" {
-			return fmt.Errorf("ollama error: %s", chunk.Error)
+			return nil, classifyError(chunk.Error)
 		}
 
 		// Call callback with token
-		if chunk.Message.Content != "" {
-			callback(chunk.Message.Content)
+		if chunk.Token != "" {
+			callback(chunk.Token)
 		}
 
 		// Check if done
 		if chunk.Done {
+			stats = &chunk.Stats
 			break
 		}
 	}
@@ -123,11 +220,11 @@ func (h *StreamHandler) Chat(ctx context.Context, req *ChatRequest, callback Tok
 		// Check if it was a context cancellation
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		default:
-			return fmt.Errorf("error reading response: %w", err)
+			return nil, fmt.Errorf("error reading response: %w", err)
 		}
 	}
 
-	return nil
+	return stats, nil
 }
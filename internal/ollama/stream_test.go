@@ -3,6 +3,7 @@ package ollama
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -165,6 +166,65 @@ func TestStreamHandler_Chat_Error(t *testing.T) {
 	}
 }
 
+func TestStreamHandler_Chat_ContextOverflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "input length exceeds maximum context length"}`))
+			},
+		},
+		{
+			name: "error chunk",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"error": "prompt is too long for the context window"}` + "\n"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			handler := NewStreamHandler(NewClient(server.URL))
+			err := handler.Chat(context.Background(), &ChatRequest{
+				Model:    "test",
+				Messages: []Message{{Role: "user", Content: "Hi"}},
+			}, func(token string) {})
+
+			if !errors.Is(err, ErrContextOverflow) {
+				t.Errorf("Chat() error = %v, want ErrContextOverflow", err)
+			}
+		})
+	}
+}
+
+func TestStreamHandler_Chat_ErrorMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "model 'nonexistent' not found"}`))
+	}))
+	defer server.Close()
+
+	handler := NewStreamHandler(NewClient(server.URL))
+	err := handler.Chat(context.Background(), &ChatRequest{
+		Model:    "nonexistent",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	}, func(token string) {})
+
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Chat() error = %v, want server error message", err)
+	}
+	if errors.Is(err, ErrContextOverflow) {
+		t.Error("Chat() should not report a context overflow for other errors")
+	}
+}
+
 func TestChatRequest_Validation(t *testing.T) {
 	req := &ChatRequest{
 		Model: "llama3",
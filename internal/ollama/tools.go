@@ -0,0 +1,39 @@
+package ollama
+
+// Tool describes a function the model can ask to run.
+type Tool struct {
+	Type     string       `json:"type"` // Always "function"
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction is the name, purpose and arguments of a tool.
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  ToolParameters `json:"parameters"`
+}
+
+// ToolParameters is the JSON schema of a tool's arguments.
+type ToolParameters struct {
+	Type       string                  `json:"type"` // Always "object"
+	Properties map[string]ToolProperty `json:"properties"`
+	Required   []string                `json:"required,omitempty"`
+}
+
+// ToolProperty describes one argument of a tool.
+type ToolProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// ToolCall is a request from the model to run a tool.
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction is the tool to run and its arguments.
+type ToolCallFunction struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
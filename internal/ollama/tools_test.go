@@ -0,0 +1,84 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamHandler_ChatWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != "current_time" {
+			t.Errorf("request tools = %+v, want current_time", req.Tools)
+		}
+
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"current_time","arguments":{"timezone":"Europe/Madrid"}}}]},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":""},"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	handler := NewStreamHandler(NewClient(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := handler.ChatWithTools(ctx, &ChatRequest{
+		Model:    "test",
+		Messages: []Message{{Role: "user", Content: "What time is it in Madrid?"}},
+		Tools:    []Tool{{Type: "function", Function: ToolFunction{Name: "current_time"}}},
+	}, func(string) {})
+	if err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+	calls := result.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "current_time" || calls[0].Function.Arguments["timezone"] != "Europe/Madrid" {
+		t.Errorf("ChatWithTools() calls = %+v, want current_time in Europe/Madrid", calls)
+	}
+}
+
+func TestModelInfo_SupportsTools(t *testing.T) {
+	tests := []struct {
+		name string
+		info ModelInfo
+		want bool
+	}{
+		{name: "capability", info: ModelInfo{Capabilities: []string{"completion", "tools"}}, want: true},
+		{name: "no capability", info: ModelInfo{Capabilities: []string{"completion"}, Template: "{{ .Tools }}"}, want: false},
+		{name: "template fallback", info: ModelInfo{Template: "{{- if .Tools }}"}, want: true},
+		{name: "unknown", info: ModelInfo{Template: "{{ .Prompt }}"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.SupportsTools(); got != tt.want {
+				t.Errorf("SupportsTools() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelInfo_SupportsVision(t *testing.T) {
+	tests := []struct {
+		name string
+		info ModelInfo
+		want bool
+	}{
+		{name: "capability", info: ModelInfo{Capabilities: []string{"completion", "vision"}}, want: true},
+		{name: "no capability", info: ModelInfo{Capabilities: []string{"completion"}, Details: ModelDetails{Families: []string{"llama", "clip"}}}, want: false},
+		{name: "family fallback", info: ModelInfo{Details: ModelDetails{Families: []string{"llama", "clip"}}}, want: true},
+		{name: "metadata fallback", info: ModelInfo{Metadata: map[string]any{"gemma3.vision.block_count": 27.0}}, want: true},
+		{name: "unknown", info: ModelInfo{Details: ModelDetails{Families: []string{"llama"}}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.SupportsVision(); got != tt.want {
+				t.Errorf("SupportsVision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
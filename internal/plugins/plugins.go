@@ -0,0 +1,281 @@
+// Package plugins loads document readers and tools written by third
+// parties. A plugin is a folder in the plugins directory holding a
+// plugin.json manifest and a program. The program is run once per request:
+// it gets a JSON request on stdin and writes a JSON response to stdout, so
+// plugins can be written in any language.
+//
+// A manifest looks like this:
+//
+//	{
+//	  "name": "notebooks",
+//	  "description": "Reads Jupyter notebooks",
+//	  "command": ["python3", "notebooks.py"],
+//	  "readers": [{"extensions": [".ipynb"]}],
+//	  "tools": [{"name": "word_count", "description": "Counts words", "parameters": {...}}],
+//	  "permissions": ["files"]
+//	}
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/tools"
+)
+
+// ManifestName is the name of the manifest file in a plugin's folder.
+const ManifestName = "plugin.json"
+
+// Timeout is how long a plugin may take to answer a request.
+const Timeout = 30 * time.Second
+
+// Permissions a plugin can declare. They are shown to the user, who decides
+// whether to enable the plugin; they are not enforced.
+const (
+	PermissionFiles    = "files"    // Reads the files it is given
+	PermissionNetwork  = "network"  // Connects to other computers
+	PermissionCommands = "commands" // Runs other programs
+)
+
+// Permissions lists the known permissions.
+var Permissions = []string{PermissionFiles, PermissionNetwork, PermissionCommands}
+
+// Request types sent to a plugin.
+const (
+	RequestRead = "read" // Extract the text of the file at Path
+	RequestTool = "tool" // Run the tool Tool with Arguments
+)
+
+// ReaderSpec declares a document reader of a plugin.
+type ReaderSpec struct {
+	Extensions []string `json:"extensions"` // e.g. ".ipynb"
+}
+
+// ToolSpec declares a tool of a plugin.
+type ToolSpec struct {
+	Name        string                         `json:"name"`
+	Description string                         `json:"description"`
+	Parameters  map[string]ollama.ToolProperty `json:"parameters,omitempty"`
+	Required    []string                       `json:"required,omitempty"`
+}
+
+// Manifest describes a plugin.
+type Manifest struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Version     string       `json:"version,omitempty"`
+	Command     []string     `json:"command"` // Program and arguments, relative to the plugin folder
+	Readers     []ReaderSpec `json:"readers,omitempty"`
+	Tools       []ToolSpec   `json:"tools,omitempty"`
+	Permissions []string     `json:"permissions,omitempty"`
+}
+
+// Request is what a plugin gets on stdin.
+type Request struct {
+	Type      string         `json:"type"`
+	Path      string         `json:"path,omitempty"`      // File to read, for RequestRead
+	Tool      string         `json:"tool,omitempty"`      // Tool to run, for RequestTool
+	Arguments map[string]any `json:"arguments,omitempty"` // Tool arguments, for RequestTool
+}
+
+// Response is what a plugin writes to stdout.
+type Response struct {
+	Text  string `json:"text"`
+	Error string `json:"error,omitempty"`
+}
+
+// Plugin is a plugin found in the plugins directory.
+type Plugin struct {
+	Manifest Manifest
+	Dir      string // Folder holding the manifest
+}
+
+// Load reads the plugins in the subfolders of dir. Folders whose manifest
+// is missing or invalid are reported in errs and skipped. A missing dir
+// has no plugins.
+func Load(dir string) (found []*Plugin, errs []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("failed to read plugins folder: %w", err)}
+	}
+
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		p, err := loadPlugin(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", entry.Name(), err))
+			continue
+		}
+		if names[p.Manifest.Name] {
+			errs = append(errs, fmt.Errorf("plugin %s: another plugin is named %q", entry.Name(), p.Manifest.Name))
+			continue
+		}
+		names[p.Manifest.Name] = true
+		found = append(found, p)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Manifest.Name < found[j].Manifest.Name })
+	return found, errs
+}
+
+// loadPlugin reads and checks the manifest of the plugin in dir.
+func loadPlugin(dir string) (*Plugin, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	m.Name = strings.TrimSpace(m.Name)
+	switch {
+	case m.Name == "":
+		return nil, errors.New("manifest has no name")
+	case len(m.Command) == 0 || m.Command[0] == "":
+		return nil, errors.New("manifest has no command")
+	case len(m.Readers) == 0 && len(m.Tools) == 0:
+		return nil, errors.New("manifest declares no readers or tools")
+	}
+	for i, r := range m.Readers {
+		for j, ext := range r.Extensions {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if ext != "" && !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			m.Readers[i].Extensions[j] = ext
+		}
+	}
+	for _, t := range m.Tools {
+		if t.Name == "" {
+			return nil, errors.New("manifest has a tool without a name")
+		}
+	}
+
+	return &Plugin{Manifest: m, Dir: dir}, nil
+}
+
+// Extensions returns the file extensions the plugin reads.
+func (p *Plugin) Extensions() []string {
+	var extensions []string
+	for _, r := range p.Manifest.Readers {
+		for _, ext := range r.Extensions {
+			if ext != "" {
+				extensions = append(extensions, ext)
+			}
+		}
+	}
+	return extensions
+}
+
+// Call runs the plugin with req and returns the text of its response.
+func (p *Plugin) Call(ctx context.Context, req Request) (string, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	program := p.Manifest.Command[0]
+	if strings.Contains(program, "/") && !filepath.IsAbs(program) {
+		program = filepath.Join(p.Dir, program)
+	}
+	cmd := exec.CommandContext(ctx, program, p.Manifest.Command[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), "GUANACO_PLUGIN_DIR="+p.Dir)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// Don't wait for children of the plugin still holding the output open
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("plugin %s failed: %w: %s", p.Manifest.Name, err, msg)
+		}
+		return "", fmt.Errorf("plugin %s failed: %w", p.Manifest.Name, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return "", fmt.Errorf("plugin %s sent an invalid response: %w", p.Manifest.Name, err)
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Text, nil
+}
+
+// Reader is a document reader provided by a plugin. It implements
+// rag.Reader.
+type Reader struct {
+	plugin *Plugin
+}
+
+// Reader returns the document reader of the plugin, or nil if it reads no
+// files.
+func (p *Plugin) Reader() *Reader {
+	if len(p.Extensions()) == 0 {
+		return nil
+	}
+	return &Reader{plugin: p}
+}
+
+// CanRead returns true if the plugin reads files with the extension of
+// filename.
+func (r *Reader) CanRead(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range r.plugin.Extensions() {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Read asks the plugin for the text of the file at path.
+func (r *Reader) Read(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return r.plugin.Call(context.Background(), Request{Type: RequestRead, Path: abs})
+}
+
+// Tools returns the tools of the plugin, run by the plugin's program.
+func (p *Plugin) Tools() []tools.Tool {
+	result := make([]tools.Tool, len(p.Manifest.Tools))
+	for i, spec := range p.Manifest.Tools {
+		name := spec.Name
+		result[i] = tools.Tool{
+			Name:        spec.Name,
+			Description: spec.Description,
+			Parameters:  spec.Parameters,
+			Required:    spec.Required,
+			Run: func(args map[string]any) (string, error) {
+				return p.Call(context.Background(), Request{Type: RequestTool, Tool: name, Arguments: args})
+			},
+		}
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePlugin creates a plugin folder in dir with the given manifest and a
+// run.sh script.
+func writePlugin(t *testing.T, dir, folder, manifest, script string) string {
+	t.Helper()
+	pluginDir := filepath.Join(dir, folder)
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginDir, ManifestName), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if script != "" {
+		if err := os.WriteFile(filepath.Join(pluginDir, "run.sh"), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pluginDir
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "notebooks", `{"name": "notebooks", "command": ["./run.sh"], "readers": [{"extensions": ["IPYNB"]}], "permissions": ["files"]}`, "")
+	writePlugin(t, dir, "counter", `{"name": "counter", "command": ["./run.sh"], "tools": [{"name": "word_count"}]}`, "")
+	writePlugin(t, dir, "copy", `{"name": "counter", "command": ["./run.sh"], "tools": [{"name": "other"}]}`, "")
+	writePlugin(t, dir, "broken", `{"name": `, "")
+	writePlugin(t, dir, "empty", `{"name": "empty", "command": ["./run.sh"]}`, "")
+
+	found, errs := Load(dir)
+
+	if len(found) != 2 || found[0].Manifest.Name != "counter" || found[1].Manifest.Name != "notebooks" {
+		t.Fatalf("Load() found %d plugins, want counter and notebooks", len(found))
+	}
+	if got := found[1].Extensions(); len(got) != 1 || got[0] != ".ipynb" {
+		t.Errorf("Extensions() = %v, want [.ipynb]", got)
+	}
+	if found[0].Reader() != nil {
+		t.Error("Reader() of a plugin without readers should be nil")
+	}
+	if len(errs) != 3 {
+		t.Errorf("Load() reported %d errors, want 3: %v", len(errs), errs)
+	}
+}
+
+func TestLoad_MissingFolder(t *testing.T) {
+	found, errs := Load(filepath.Join(t.TempDir(), "missing"))
+	if len(found) != 0 || len(errs) != 0 {
+		t.Errorf("Load() of a missing folder = %v, %v; want nothing", found, errs)
+	}
+}
+
+func TestReader(t *testing.T) {
+	dir := t.TempDir()
+	// Answer with the request, so the test can check what was sent
+	writePlugin(t, dir, "echo", `{"name": "echo", "command": ["./run.sh"], "readers": [{"extensions": [".ipynb"]}]}`,
+		`request=$(cat); printf '{"text": "%s"}' "$(printf '%s' "$request" | sed 's/"/\\"/g')"`)
+	found, _ := Load(dir)
+	reader := found[0].Reader()
+
+	if !reader.CanRead("Analysis.IPYNB") || reader.CanRead("notes.txt") {
+		t.Error("CanRead() should match only the declared extensions")
+	}
+
+	got, err := reader.Read("/tmp/analysis.ipynb")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !strings.Contains(got, `"type":"read"`) || !strings.Contains(got, `"path":"/tmp/analysis.ipynb"`) {
+		t.Errorf("Read() sent %s, want a read request for the file", got)
+	}
+}
+
+func TestTools(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "counter", `{"name": "counter", "command": ["./run.sh"], "tools": [{"name": "word_count", "description": "Counts words", "required": ["text"]}]}`,
+		`grep -q '"tool":"word_count"' && echo '{"text": "3"}' || echo '{"error": "unknown tool"}'`)
+	found, _ := Load(dir)
+
+	pluginTools := found[0].Tools()
+	if len(pluginTools) != 1 || pluginTools[0].Name != "word_count" || pluginTools[0].Description != "Counts words" {
+		t.Fatalf("Tools() = %+v, want word_count", pluginTools)
+	}
+	got, err := pluginTools[0].Run(map[string]any{"text": "one two three"})
+	if err != nil || got != "3" {
+		t.Errorf("Run() = %q, %v; want 3", got, err)
+	}
+
+	if _, err := found[0].Call(t.Context(), Request{Type: RequestTool, Tool: "other"}); err == nil || err.Error() != "unknown tool" {
+		t.Errorf("Call() error = %v, want the plugin's error", err)
+	}
+}
+
+func TestCall_Failure(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "bad", `{"name": "bad", "command": ["./run.sh"], "tools": [{"name": "x"}]}`, `echo 'not json'`)
+	writePlugin(t, dir, "crash", `{"name": "crash", "command": ["./run.sh"], "tools": [{"name": "y"}]}`, `echo 'boom' >&2; exit 2`)
+	found, _ := Load(dir)
+
+	if _, err := found[0].Call(t.Context(), Request{Type: RequestTool, Tool: "x"}); err == nil || !strings.Contains(err.Error(), "invalid response") {
+		t.Errorf("Call() error = %v, want an invalid response error", err)
+	}
+	if _, err := found[1].Call(t.Context(), Request{Type: RequestTool, Tool: "y"}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Call() error = %v, want the plugin's stderr", err)
+	}
+}
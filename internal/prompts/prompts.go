@@ -0,0 +1,57 @@
+// Package prompts fills the placeholders of saved prompt templates.
+//
+// A placeholder is a name in double braces, such as {{text}} or
+// {{language}}. When a template is used, the user is asked for a value for
+// each of its placeholders.
+package prompts
+
+import (
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches a placeholder, allowing spaces inside the braces.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Placeholders returns the names of the placeholders in content, in order
+// of first appearance and without repeats. Names in skip are left out, for
+// variables that are filled in elsewhere.
+func Placeholders(content string, skip ...string) []string {
+	seen := make(map[string]bool)
+	for _, name := range skip {
+		seen[name] = true
+	}
+
+	var names []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// Fill replaces the placeholders of content that have a value in values.
+// Other placeholders are left as they are.
+func Fill(content string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(content, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// Label turns a placeholder name into a label for the field asking for
+// its value, such as "Target language" for target_language.
+func Label(name string) string {
+	label := strings.TrimSpace(strings.ReplaceAll(name, "_", " "))
+	if label == "" {
+		return name
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
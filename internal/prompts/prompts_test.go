@@ -0,0 +1,85 @@
+package prompts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		skip    []string
+		want    []string
+	}{
+		{"none", "Summarize this", nil, nil},
+		{"in order", "Translate to {{language}}:\n\n{{text}}", nil, []string{"language", "text"}},
+		{"repeated", "{{text}} and again {{text}}", nil, []string{"text"}},
+		{"spaces", "Hello {{ name }}", nil, []string{"name"}},
+		{"skipped", "{{calendar}} Plan my day around {{goal}}", []string{"calendar"}, []string{"goal"}},
+		{"not names", "{{}} {{1st}} {{two words}} {single}", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Placeholders(tt.content, tt.skip...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Placeholders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		values  map[string]string
+		want    string
+	}{
+		{
+			name:    "all",
+			content: "Translate to {{language}}:\n\n{{text}}",
+			values:  map[string]string{"language": "Spanish", "text": "Good morning"},
+			want:    "Translate to Spanish:\n\nGood morning",
+		},
+		{
+			name:    "repeated and spaced",
+			content: "{{ text }} / {{text}}",
+			values:  map[string]string{"text": "hi"},
+			want:    "hi / hi",
+		},
+		{
+			name:    "missing left alone",
+			content: "{{calendar}}\nWhat should I do about {{topic}}?",
+			values:  map[string]string{"topic": "lunch"},
+			want:    "{{calendar}}\nWhat should I do about lunch?",
+		},
+		{
+			name:    "values aren't expanded again",
+			content: "{{a}} {{b}}",
+			values:  map[string]string{"a": "{{b}}", "b": "x"},
+			want:    "{{b}} x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fill(tt.content, tt.values); got != tt.want {
+				t.Errorf("Fill() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := map[string]string{
+		"text":            "Text",
+		"target_language": "Target language",
+		"_":               "_",
+	}
+	for name, want := range tests {
+		if got := Label(name); got != want {
+			t.Errorf("Label(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package rag
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ZIP archives are not read as a whole. Their files are listed so that the
+// user can pick the ones to send, which are extracted to a temporary folder,
+// read with the usual readers and joined in a single attachment. The folder
+// is removed once they are read.
+
+// maxArchiveFileSize limits the size of each file extracted from an
+// archive, which guards against files that expand far beyond their
+// compressed size.
+const maxArchiveFileSize = 50 * 1024 * 1024
+
+// ArchiveEntry is a file inside an archive.
+type ArchiveEntry struct {
+	// Name is the path of the file inside the archive.
+	Name string
+
+	// Size is the uncompressed size in bytes.
+	Size int64
+
+	// Supported tells whether the file can be read as context.
+	Supported bool
+}
+
+// IsArchive reports whether a file is an archive whose files can be
+// attached.
+func IsArchive(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".zip"
+}
+
+// ListArchive returns the files of the archive at path, in the order they
+// are stored. Folders and the metadata left by macOS are left out. Images
+// and nested archives are not supported, as they can't be joined with the
+// text of the other files.
+func (p *Processor) ListArchive(path string) ([]ArchiveEntry, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+
+	var entries []ArchiveEntry
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || isArchiveMetadata(file.Name) {
+			continue
+		}
+		size := int64(file.UncompressedSize64)
+		entries = append(entries, ArchiveEntry{
+			Name:      file.Name,
+			Size:      size,
+			Supported: p.CanProcess(file.Name) && !IsImage(file.Name) && size <= maxArchiveFileSize,
+		})
+	}
+	return entries, nil
+}
+
+// ProcessArchive extracts the files named in names from the archive at path
+// and reads them as a single document, each file under its name.
+func (p *Processor) ProcessArchive(archivePath string, names []string) (*DocumentResult, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no files selected")
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+
+	dir, err := os.MkdirTemp("", "guanaco-archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary folder: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	var parts []string
+	read := 0
+	for i, name := range names {
+		file, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not in the archive", name)
+		}
+
+		// Each file goes in its own folder under its base name, so that
+		// paths in the archive can't point outside the temporary folder
+		target := filepath.Join(dir, fmt.Sprint(i), path.Base(name))
+		text := ""
+		if err := extractArchiveFile(file, target); err != nil {
+			text = fmt.Sprintf("(unreadable file: %v)", err)
+		} else if result, err := p.Process(target); err != nil {
+			text = fmt.Sprintf("(unreadable file: %v)", err)
+		} else {
+			text = result.Content
+			read++
+		}
+		parts = append(parts, fmt.Sprintf("--- %s ---\n%s", name, text))
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("none of the selected files could be read")
+	}
+
+	content := strings.Join(parts, "\n\n")
+	return &DocumentResult{
+		Filename:      filepath.Base(archivePath),
+		Content:       content,
+		Chunks:        p.chunker.Chunk(content),
+		TokenEstimate: EstimateTokens(content),
+	}, nil
+}
+
+// extractArchiveFile writes the content of file to target.
+func extractArchiveFile(file *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	n, err := io.Copy(dst, io.LimitReader(src, maxArchiveFileSize+1))
+	if err != nil {
+		return err
+	}
+	if n > maxArchiveFileSize {
+		return fmt.Errorf("file too large")
+	}
+	return nil
+}
+
+// isArchiveMetadata reports whether a file of an archive holds metadata
+// added by macOS rather than content.
+func isArchiveMetadata(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") || path.Base(name) == ".DS_Store"
+}
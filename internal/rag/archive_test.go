@@ -0,0 +1,121 @@
+package rag
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIsArchive(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{"project.zip", true},
+		{"PROJECT.ZIP", true},
+		{"report.docx", false},
+		{"backup.tar.gz", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsArchive(tt.filename); got != tt.want {
+			t.Errorf("IsArchive(%q) = %v, want %v", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestProcessor_ListArchive(t *testing.T) {
+	processor := NewProcessor()
+	path := writeZip(t, "project.zip", map[string]string{
+		"README.md":            "# Project",
+		"src/main.go":          "package main",
+		"docs/notes.txt":       "Some notes",
+		"logo.png":             "not really a png",
+		"vendor.zip":           "nested",
+		"__MACOSX/._notes.txt": "metadata",
+	})
+
+	entries, err := processor.ListArchive(path)
+	if err != nil {
+		t.Fatalf("ListArchive() error = %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, entry := range entries {
+		got[entry.Name] = entry.Supported
+	}
+	want := map[string]bool{
+		"README.md":      true,
+		"src/main.go":    false,
+		"docs/notes.txt": true,
+		"logo.png":       false,
+		"vendor.zip":     false,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ListArchive() = %v, want %v", got, want)
+	}
+	for name, supported := range want {
+		if s, ok := got[name]; !ok || s != supported {
+			t.Errorf("entry %q supported = %v (listed %v), want %v", name, s, ok, supported)
+		}
+	}
+
+	t.Run("not an archive", func(t *testing.T) {
+		if _, err := processor.ListArchive("testdata/sample.txt"); err == nil {
+			t.Error("expected error for a file that isn't an archive")
+		}
+	})
+}
+
+func TestProcessor_ProcessArchive(t *testing.T) {
+	processor := NewProcessor()
+	path := writeZip(t, "project.zip", map[string]string{
+		"README.md":      "# Project\n\nA small project.",
+		"docs/notes.txt": "Remember the deadline.",
+		"data.csv":       "name,age\nAna,30",
+	})
+
+	t.Run("selected files", func(t *testing.T) {
+		result, err := processor.ProcessArchive(path, []string{"docs/notes.txt", "README.md"})
+		if err != nil {
+			t.Fatalf("ProcessArchive() error = %v", err)
+		}
+		if result.Filename != "project.zip" {
+			t.Errorf("Filename = %q, want %q", result.Filename, "project.zip")
+		}
+		want := "--- docs/notes.txt ---\nRemember the deadline.\n\n--- README.md ---\n# Project\n\nA small project."
+		if result.Content != want {
+			t.Errorf("Content = %q, want %q", result.Content, want)
+		}
+		if strings.Contains(result.Content, "Ana") {
+			t.Error("unselected file was read")
+		}
+	})
+
+	t.Run("temporary files are removed", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("TMPDIR", dir)
+		if _, err := processor.ProcessArchive(path, []string{"data.csv"}); err != nil {
+			t.Fatalf("ProcessArchive() error = %v", err)
+		}
+		left, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("failed to read temp dir: %v", err)
+		}
+		if len(left) != 0 {
+			t.Errorf("temporary folder left behind: %v", left)
+		}
+	})
+
+	t.Run("unknown file", func(t *testing.T) {
+		if _, err := processor.ProcessArchive(path, []string{"missing.txt"}); err == nil {
+			t.Error("expected error for a file that isn't in the archive")
+		}
+	})
+
+	t.Run("nothing selected", func(t *testing.T) {
+		if _, err := processor.ProcessArchive(path, nil); err == nil {
+			t.Error("expected error when no files are selected")
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package rag
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// csvDelimiters are the field separators recognized in .csv files.
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// CsvReader reads CSV and TSV files as a Markdown table.
+type CsvReader struct {
+	maxRows int
+}
+
+// NewCsvReader creates a CSV reader keeping at most maxRows rows (0 for all
+// of them).
+func NewCsvReader(maxRows int) *CsvReader {
+	return &CsvReader{maxRows: maxRows}
+}
+
+// Read reads a CSV or TSV file as a Markdown table.
+func (r *CsvReader) Read(path string) (string, error) {
+	table, err := readCSV(path)
+	if err != nil {
+		return "", err
+	}
+	return TablesMarkdown([]Table{table}, r.maxRows), nil
+}
+
+// CanRead returns true if the file is a CSV or TSV file.
+func (r *CsvReader) CanRead(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".csv" || ext == ".tsv"
+}
+
+// readCSV reads the table of a CSV or TSV file. The separator of .csv files
+// is guessed from their first line.
+func readCSV(path string) (Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Table{}, err
+	}
+	defer f.Close()
+
+	buffered := bufio.NewReader(f)
+	start, err := buffered.Peek(4096)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return Table{}, err
+	}
+
+	delimiter := '\t'
+	if strings.ToLower(filepath.Ext(path)) != ".tsv" {
+		delimiter = guessDelimiter(string(start))
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1 // Rows may have different lengths
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return Table{}, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) > 0 && len(records[0]) > 0 {
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff") // Byte order mark
+	}
+	return newTable("", records), nil
+}
+
+// guessDelimiter returns the separator used most in the first line of
+// text, defaulting to a comma.
+func guessDelimiter(text string) rune {
+	line, _, _ := strings.Cut(text, "\n")
+	best, bestCount := ',', 0
+	for _, delimiter := range csvDelimiters {
+		if count := strings.Count(line, string(delimiter)); count > bestCount {
+			best, bestCount = delimiter, count
+		}
+	}
+	return best
+}
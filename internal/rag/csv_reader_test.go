@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCsvReader_CanRead(t *testing.T) {
+	reader := NewCsvReader(DefaultMaxTableRows)
+
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"data.csv", true},
+		{"data.CSV", true},
+		{"data.tsv", true},
+		{"data.xlsx", false},
+		{"data.txt", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := reader.CanRead(tt.filename); got != tt.expected {
+				t.Errorf("CanRead(%q) = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCsvReader_Read(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     string
+	}{
+		{
+			name:     "comma separated",
+			filename: "data.csv",
+			content:  "name,age\nAna,31\n\"Pérez, Luis\",45\n",
+			want:     "| name | age |\n| --- | --- |\n| Ana | 31 |\n| Pérez, Luis | 45 |",
+		},
+		{
+			name:     "semicolon separated with byte order mark",
+			filename: "data.csv",
+			content:  "\ufeffname;price\nbread;1,20\n",
+			want:     "| name | price |\n| --- | --- |\n| bread | 1,20 |",
+		},
+		{
+			name:     "tab separated",
+			filename: "data.tsv",
+			content:  "a\tb\n1\t2\n",
+			want:     "| a | b |\n| --- | --- |\n| 1 | 2 |",
+		},
+		{
+			name:     "ragged rows and blank lines",
+			filename: "data.csv",
+			content:  "a,b\n\n1\n2,3,4\n",
+			want:     "| a | b |  |\n| --- | --- | --- |\n| 1 |  |  |\n| 2 | 3 | 4 |",
+		},
+	}
+
+	reader := NewCsvReader(DefaultMaxTableRows)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to create temp file: %v", err)
+			}
+
+			got, err := reader.Read(path)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Read() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("non-existent file", func(t *testing.T) {
+		if _, err := reader.Read("testdata/nonexistent.csv"); err == nil {
+			t.Error("expected error for non-existent file")
+		}
+	})
+}
+
+func TestGuessDelimiter(t *testing.T) {
+	tests := []struct {
+		text string
+		want rune
+	}{
+		{"a,b,c\n1;2", ','},
+		{"a;b;c", ';'},
+		{"a\tb", '\t'},
+		{"a|b|c", '|'},
+		{"single", ','},
+		{"", ','},
+	}
+
+	for _, tt := range tests {
+		if got := guessDelimiter(tt.text); got != tt.want {
+			t.Errorf("guessDelimiter(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
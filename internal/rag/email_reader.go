@@ -0,0 +1,292 @@
+package rag
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Emails are read as their headers, the plain text of their body and a
+// list of their attachments, which are not read themselves. Bodies with
+// only HTML are turned into plain text. An mbox file holds many emails,
+// which are read one after the other.
+
+// maxMboxMessages limits the emails read of an mbox file, keeping the last
+// ones, which are usually the most recent.
+const maxMboxMessages = 50
+
+// emailHeaders are the headers kept of each email, in this order.
+var emailHeaders = []string{"From", "To", "Cc", "Date", "Subject"}
+
+// EmailReader reads saved emails (.eml) and mailboxes (.mbox).
+type EmailReader struct{}
+
+// NewEmailReader creates a new email reader.
+func NewEmailReader() *EmailReader {
+	return &EmailReader{}
+}
+
+// emailAttachment is a file attached to an email.
+type emailAttachment struct {
+	filename    string
+	contentType string
+	size        int
+}
+
+// Read reads an email, or every email of a mailbox.
+func (r *EmailReader) Read(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.ToLower(filepath.Ext(path)) != ".mbox" {
+		return readEmail(data)
+	}
+
+	messages := splitMbox(data)
+	if len(messages) == 0 {
+		return "", fmt.Errorf("the mailbox has no emails")
+	}
+	skipped := 0
+	if len(messages) > maxMboxMessages {
+		skipped = len(messages) - maxMboxMessages
+		messages = messages[skipped:]
+	}
+
+	var parts []string
+	if skipped > 0 {
+		parts = append(parts, fmt.Sprintf("(%d older emails left out)", skipped))
+	}
+	for i, message := range messages {
+		text, err := readEmail(message)
+		if err != nil {
+			text = fmt.Sprintf("(unreadable email: %v)", err)
+		}
+		parts = append(parts, fmt.Sprintf("--- Email %d of %d ---\n%s", skipped+i+1, skipped+len(messages), text))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// CanRead returns true if the file is an email or a mailbox.
+func (r *EmailReader) CanRead(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".eml" || ext == ".mbox"
+}
+
+// readEmail returns the headers, body and attachments of a single email.
+func readEmail(data []byte) (string, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("invalid email: %w", err)
+	}
+
+	var builder strings.Builder
+	decoder := new(mime.WordDecoder)
+	for _, name := range emailHeaders {
+		value := msg.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		fmt.Fprintf(&builder, "%s: %s\n", name, value)
+	}
+
+	var plain, htmlBody []string
+	var attachments []emailAttachment
+	walkEmailPart(msg.Header, msg.Body, &plain, &htmlBody, &attachments)
+
+	body := strings.TrimSpace(strings.Join(plain, "\n\n"))
+	if body == "" && len(htmlBody) > 0 {
+		body = htmlToText(strings.Join(htmlBody, "\n"))
+	}
+	if body != "" {
+		builder.WriteString("\n")
+		builder.WriteString(body)
+		builder.WriteString("\n")
+	}
+
+	if len(attachments) > 0 {
+		builder.WriteString("\nAttachments:\n")
+		for _, a := range attachments {
+			fmt.Fprintf(&builder, "- %s (%s, %s)\n", a.filename, a.contentType, formatSize(a.size))
+		}
+	}
+
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// partHeader is the part of a MIME header used to read a part.
+type partHeader interface {
+	Get(key string) string
+}
+
+// walkEmailPart reads a MIME part, adding its text to plain or htmlBody,
+// or to attachments when it is a file. Multipart parts are read part by
+// part.
+func walkEmailPart(header partHeader, body io.Reader, plain, htmlBody *[]string, attachments *[]emailAttachment) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err != nil {
+				return
+			}
+			walkEmailPart(part.Header, part, plain, htmlBody, attachments)
+		}
+	}
+
+	content, err := io.ReadAll(decodeTransfer(body, header.Get("Content-Transfer-Encoding")))
+	if err != nil {
+		return
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(filename); err == nil {
+		filename = decoded
+	}
+
+	switch {
+	case disposition == "attachment" || filename != "" || !strings.HasPrefix(mediaType, "text/"):
+		if filename == "" {
+			filename = "unnamed"
+		}
+		*attachments = append(*attachments, emailAttachment{filename: filename, contentType: mediaType, size: len(content)})
+	case mediaType == "text/html":
+		*htmlBody = append(*htmlBody, decodeCharset(content, params["charset"]))
+	default:
+		*plain = append(*plain, decodeCharset(content, params["charset"]))
+	}
+}
+
+// decodeTransfer undoes the transfer encoding of a part's body.
+func decodeTransfer(body io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineSkipper{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// newlineSkipper drops the line breaks of base64 bodies.
+type newlineSkipper struct {
+	r io.Reader
+}
+
+func (s *newlineSkipper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// decodeCharset converts text in charset to UTF-8. Only Latin-1 needs
+// converting among the common ones; other charsets are kept as they are.
+func decodeCharset(content []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		runes := make([]rune, len(content))
+		for i, b := range content {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	default:
+		return string(content)
+	}
+}
+
+var (
+	htmlHiddenPattern = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBreakPattern  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLinesPattern = regexp.MustCompile(`\n\s*\n\s*\n+`)
+)
+
+// htmlToText returns the text of an HTML body, keeping its line breaks.
+func htmlToText(body string) string {
+	body = htmlHiddenPattern.ReplaceAllString(body, "")
+	body = htmlBreakPattern.ReplaceAllString(body, "\n")
+	body = htmlTagPattern.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	body = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(body, "\n\n"))
+}
+
+// splitMbox splits an mbox file into its emails. Each email starts with a
+// "From " line, and lines of the body starting with ">From " were escaped.
+func splitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	var current *bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			if current != nil {
+				messages = append(messages, current.Bytes())
+			}
+			current = &bytes.Buffer{}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = line[1:]
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	if current != nil {
+		messages = append(messages, current.Bytes())
+	}
+	return messages
+}
+
+// formatSize returns a size in bytes in a readable unit.
+func formatSize(size int) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
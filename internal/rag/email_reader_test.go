@@ -0,0 +1,147 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleEmail = "From: Ana <ana@example.com>\r\n" +
+	"To: team@example.com\r\n" +
+	"Date: Mon, 5 Oct 2026 09:30:00 +0200\r\n" +
+	"Subject: =?UTF-8?Q?Reuni=C3=B3n_del_lunes?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Hola equipo, la reuni=C3=B3n es a las 10.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Hola equipo</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"agenda.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"agenda.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"--outer--\r\n"
+
+// writeEmail writes content to a file named name in a temporary directory
+// and returns its path.
+func writeEmail(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	return path
+}
+
+func TestEmailReader_CanRead(t *testing.T) {
+	reader := NewEmailReader()
+
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"reply.eml", true},
+		{"reply.EML", true},
+		{"inbox.mbox", true},
+		{"reply.msg", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := reader.CanRead(tt.filename); got != tt.expected {
+				t.Errorf("CanRead(%q) = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEmailReader_Read(t *testing.T) {
+	reader := NewEmailReader()
+
+	t.Run("multipart email", func(t *testing.T) {
+		got, err := reader.Read(writeEmail(t, "meeting.eml", sampleEmail))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "From: Ana <ana@example.com>\n" +
+			"To: team@example.com\n" +
+			"Date: Mon, 5 Oct 2026 09:30:00 +0200\n" +
+			"Subject: Reunión del lunes\n\n" +
+			"Hola equipo, la reunión es a las 10.\n\n" +
+			"Attachments:\n" +
+			"- agenda.pdf (application/pdf, 9 B)"
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("HTML only", func(t *testing.T) {
+		email := "From: news@example.com\r\nSubject: News\r\nContent-Type: text/html; charset=iso-8859-1\r\n\r\n" +
+			"<html><head><style>p {}</style></head><body><p>Caf\xe9 &amp; more</p><p>Line<br>two</p></body></html>"
+		got, err := reader.Read(writeEmail(t, "news.eml", email))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "From: news@example.com\nSubject: News\n\nCafé & more\nLine\ntwo"
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("mailbox", func(t *testing.T) {
+		mbox := "From ana@example.com Mon Oct  5 09:30:00 2026\n" +
+			"From: ana@example.com\nSubject: First\n\nHello\n>From here on\n\n" +
+			"From bob@example.com Tue Oct  6 10:00:00 2026\n" +
+			"From: bob@example.com\nSubject: Second\n\nBye\n"
+		got, err := reader.Read(writeEmail(t, "inbox.mbox", mbox))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "--- Email 1 of 2 ---\nFrom: ana@example.com\nSubject: First\n\nHello\nFrom here on\n\n" +
+			"--- Email 2 of 2 ---\nFrom: bob@example.com\nSubject: Second\n\nBye"
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("large mailbox keeps the last emails", func(t *testing.T) {
+		var mbox strings.Builder
+		for i := 1; i <= maxMboxMessages+2; i++ {
+			fmt.Fprintf(&mbox, "From sender Mon Oct  5 09:30:00 2026\nSubject: Email %d\n\nBody\n\n", i)
+		}
+		got, err := reader.Read(writeEmail(t, "big.mbox", mbox.String()))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if !strings.HasPrefix(got, "(2 older emails left out)") || strings.Contains(got, "Subject: Email 2\n") {
+			t.Errorf("Read() = %q, want the first 2 emails left out", got[:100])
+		}
+	})
+
+	t.Run("empty mailbox", func(t *testing.T) {
+		if _, err := reader.Read(writeEmail(t, "empty.mbox", "")); err == nil {
+			t.Error("expected error for a mailbox without emails")
+		}
+	})
+
+	t.Run("not an email", func(t *testing.T) {
+		if _, err := reader.Read(writeEmail(t, "invalid.eml", "no headers here")); err == nil {
+			t.Error("expected error for invalid file")
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Jupyter notebooks (.ipynb) are JSON documents holding a list of cells.
+// They are read as Markdown: markdown cells as they are, and code cells as
+// fenced blocks tagged with the notebook's language, so the code is
+// highlighted, optionally followed by their text output.
+
+// maxNotebookOutput limits the characters kept of each cell output, as
+// outputs such as training logs can be very long.
+const maxNotebookOutput = 2000
+
+// defaultNotebookLanguage is assumed for notebooks that don't say their
+// language.
+const defaultNotebookLanguage = "python"
+
+// NotebookReader reads Jupyter notebooks.
+type NotebookReader struct {
+	includeOutputs bool
+}
+
+// NewNotebookReader creates a notebook reader, keeping the text output of
+// code cells when includeOutputs is set.
+func NewNotebookReader(includeOutputs bool) *NotebookReader {
+	return &NotebookReader{includeOutputs: includeOutputs}
+}
+
+// notebook is the part of an nbformat 4 notebook that is read.
+type notebook struct {
+	NBFormat int             `json:"nbformat"`
+	Metadata json.RawMessage `json:"metadata"`
+	Cells    []notebookCell  `json:"cells"`
+}
+
+// notebookMetadata holds the language of a notebook.
+type notebookMetadata struct {
+	LanguageInfo struct {
+		Name string `json:"name"`
+	} `json:"language_info"`
+	Kernelspec struct {
+		Language    string `json:"language"`
+		DisplayName string `json:"display_name"`
+	} `json:"kernelspec"`
+}
+
+type notebookCell struct {
+	CellType       string           `json:"cell_type"` // "markdown", "code" or "raw"
+	Source         notebookText     `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                  `json:"output_type"` // "stream", "execute_result", "display_data" or "error"
+	Text       notebookText            `json:"text"`        // For "stream"
+	Data       map[string]notebookText `json:"data"`        // For results, by MIME type
+	EName      string                  `json:"ename"`       // For "error"
+	EValue     string                  `json:"evalue"`
+}
+
+// notebookText is text stored either as a string or as a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = notebookText(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		// Non-text data, such as JSON outputs, is ignored
+		*t = ""
+		return nil
+	}
+	*t = notebookText(strings.Join(lines, ""))
+	return nil
+}
+
+// Read reads a notebook as Markdown.
+func (r *NotebookReader) Read(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return "", fmt.Errorf("invalid notebook: %w", err)
+	}
+	if nb.NBFormat < 4 {
+		return "", fmt.Errorf("unsupported notebook format %d, save it with a recent Jupyter", nb.NBFormat)
+	}
+
+	var meta notebookMetadata
+	if len(nb.Metadata) > 0 {
+		// Unexpected metadata only loses the language
+		_ = json.Unmarshal(nb.Metadata, &meta)
+	}
+	language := notebookLanguage(meta)
+
+	var builder strings.Builder
+	if kernel := meta.Kernelspec.DisplayName; kernel != "" {
+		fmt.Fprintf(&builder, "Kernel: %s\n\n", kernel)
+	}
+	for i, cell := range nb.Cells {
+		source := strings.TrimSpace(string(cell.Source))
+		if source == "" && len(cell.Outputs) == 0 {
+			continue
+		}
+
+		switch cell.CellType {
+		case "markdown":
+			fmt.Fprintf(&builder, "<!-- Cell %d: markdown -->\n%s\n\n", i+1, source)
+		case "code":
+			if cell.ExecutionCount != nil {
+				fmt.Fprintf(&builder, "<!-- Cell %d: code [%d] -->\n", i+1, *cell.ExecutionCount)
+			} else {
+				fmt.Fprintf(&builder, "<!-- Cell %d: code -->\n", i+1)
+			}
+			fmt.Fprintf(&builder, "```%s\n%s\n```\n\n", language, source)
+			if r.includeOutputs {
+				if output := notebookCellOutput(cell.Outputs); output != "" {
+					fmt.Fprintf(&builder, "Output:\n```\n%s\n```\n\n", output)
+				}
+			}
+		default:
+			fmt.Fprintf(&builder, "<!-- Cell %d: %s -->\n%s\n\n", i+1, cell.CellType, source)
+		}
+	}
+
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// CanRead returns true if the file is a Jupyter notebook.
+func (r *NotebookReader) CanRead(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".ipynb"
+}
+
+// notebookLanguage returns the programming language of a notebook, for the
+// code fences.
+func notebookLanguage(meta notebookMetadata) string {
+	for _, language := range []string{meta.LanguageInfo.Name, meta.Kernelspec.Language} {
+		if language = strings.ToLower(strings.TrimSpace(language)); language != "" {
+			return language
+		}
+	}
+	return defaultNotebookLanguage
+}
+
+// notebookCellOutput returns the text output of a code cell. Images and
+// other rich outputs are left out.
+func notebookCellOutput(outputs []notebookOutput) string {
+	var parts []string
+	for _, out := range outputs {
+		var text string
+		switch out.OutputType {
+		case "stream":
+			text = string(out.Text)
+		case "execute_result", "display_data":
+			text = string(out.Data["text/plain"])
+		case "error":
+			text = out.EName + ": " + out.EValue
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	output := strings.Join(parts, "\n")
+	if runes := []rune(output); len(runes) > maxNotebookOutput {
+		output = string(runes[:maxNotebookOutput]) + "\n…"
+	}
+	return output
+}
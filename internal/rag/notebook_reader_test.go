@@ -0,0 +1,151 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleNotebook = `{
+ "nbformat": 4,
+ "nbformat_minor": 5,
+ "metadata": {
+  "kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"},
+  "language_info": {"name": "python"}
+ },
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis\n", "Load the data."]},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "source": "print(1 + 1)",
+   "outputs": [{"output_type": "stream", "name": "stdout", "text": ["2\n"]}]},
+  {"cell_type": "code", "execution_count": null, "metadata": {}, "source": [], "outputs": []},
+  {"cell_type": "code", "execution_count": 2, "metadata": {}, "source": "df.shape",
+   "outputs": [{"output_type": "execute_result", "execution_count": 2, "metadata": {},
+    "data": {"text/plain": ["(10, 3)"], "image/png": "iVBORw0KGgo=", "application/json": {"a": 1}}}]},
+  {"cell_type": "code", "execution_count": 3, "metadata": {}, "source": "1 / 0",
+   "outputs": [{"output_type": "error", "ename": "ZeroDivisionError", "evalue": "division by zero", "traceback": []}]}
+ ]
+}`
+
+// writeNotebook writes content to a notebook file in a temporary directory
+// and returns its path.
+func writeNotebook(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "analysis.ipynb")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	return path
+}
+
+func TestNotebookReader_CanRead(t *testing.T) {
+	reader := NewNotebookReader(true)
+
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"analysis.ipynb", true},
+		{"analysis.IPYNB", true},
+		{"analysis.py", false},
+		{"analysis.json", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := reader.CanRead(tt.filename); got != tt.expected {
+				t.Errorf("CanRead(%q) = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNotebookReader_Read(t *testing.T) {
+	t.Run("with outputs", func(t *testing.T) {
+		got, err := NewNotebookReader(true).Read(writeNotebook(t, sampleNotebook))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "Kernel: Python 3\n\n" +
+			"<!-- Cell 1: markdown -->\n# Analysis\nLoad the data.\n\n" +
+			"<!-- Cell 2: code [1] -->\n```python\nprint(1 + 1)\n```\n\nOutput:\n```\n2\n```\n\n" +
+			"<!-- Cell 4: code [2] -->\n```python\ndf.shape\n```\n\nOutput:\n```\n(10, 3)\n```\n\n" +
+			"<!-- Cell 5: code [3] -->\n```python\n1 / 0\n```\n\nOutput:\n```\nZeroDivisionError: division by zero\n```"
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("without outputs", func(t *testing.T) {
+		got, err := NewNotebookReader(false).Read(writeNotebook(t, sampleNotebook))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if strings.Contains(got, "Output:") {
+			t.Errorf("Read() = %q, want no outputs", got)
+		}
+		if !strings.Contains(got, "```python\ndf.shape\n```") {
+			t.Errorf("Read() = %q, want the code cells", got)
+		}
+	})
+
+	t.Run("language from kernel", func(t *testing.T) {
+		path := writeNotebook(t, `{"nbformat": 4, "metadata": {"kernelspec": {"language": "R"}},
+			"cells": [{"cell_type": "code", "source": "summary(x)", "outputs": []}]}`)
+		got, err := NewNotebookReader(true).Read(path)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if want := "<!-- Cell 1: code -->\n```r\nsummary(x)\n```"; got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("long output is cut", func(t *testing.T) {
+		path := writeNotebook(t, `{"nbformat": 4, "metadata": {}, "cells": [{"cell_type": "code", "source": "log()",
+			"outputs": [{"output_type": "stream", "text": "`+strings.Repeat("x", maxNotebookOutput+10)+`"}]}]}`)
+		got, err := NewNotebookReader(true).Read(path)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if !strings.Contains(got, strings.Repeat("x", maxNotebookOutput)+"\n…") || strings.Contains(got, strings.Repeat("x", maxNotebookOutput+1)) {
+			t.Errorf("Read() did not cut the output to %d characters", maxNotebookOutput)
+		}
+	})
+
+	t.Run("old format", func(t *testing.T) {
+		path := writeNotebook(t, `{"nbformat": 3, "worksheets": []}`)
+		if _, err := NewNotebookReader(true).Read(path); err == nil {
+			t.Error("expected error for nbformat 3")
+		}
+	})
+
+	t.Run("not JSON", func(t *testing.T) {
+		if _, err := NewNotebookReader(true).Read(writeNotebook(t, "not a notebook")); err == nil {
+			t.Error("expected error for invalid file")
+		}
+	})
+}
+
+func TestProcessor_SetNotebookOutputs(t *testing.T) {
+	processor := NewProcessor()
+	path := writeNotebook(t, sampleNotebook)
+
+	result, err := processor.Process(path)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if !strings.Contains(result.Content, "Output:") {
+		t.Errorf("Process() = %q, want outputs by default", result.Content)
+	}
+
+	processor.SetNotebookOutputs(false)
+	result, err = processor.Process(path)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if strings.Contains(result.Content, "Output:") {
+		t.Errorf("Process() = %q, want no outputs", result.Content)
+	}
+}
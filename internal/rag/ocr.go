@@ -0,0 +1,69 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// OCRTimeout is how long reading the text of one image may take.
+const OCRTimeout = time.Minute
+
+// ErrOCRUnavailable is returned when the OCR command is not installed.
+var ErrOCRUnavailable = errors.New("tesseract is not installed")
+
+// OCR extracts the text of images with the tesseract command, so images can
+// be sent to models that can't see them.
+type OCR struct {
+	command string
+}
+
+// NewOCR creates an OCR using tesseract.
+func NewOCR() *OCR {
+	return &OCR{command: "tesseract"}
+}
+
+// Available reports whether the OCR command is installed.
+func (o *OCR) Available() bool {
+	_, err := exec.LookPath(o.command)
+	return err == nil
+}
+
+// ExtractText returns the text found in an image.
+func (o *OCR) ExtractText(ctx context.Context, image []byte) (string, error) {
+	if !o.Available() {
+		return "", ErrOCRUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, OCRTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, o.command, "stdin", "stdout")
+	cmd.Stdin = bytes.NewReader(image)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("ocr failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("ocr failed: %w", err)
+	}
+	return cleanText(string(output)), nil
+}
+
+// ExtractTextBase64 returns the text found in a base64-encoded image, as
+// produced by ImageReader.
+func (o *OCR) ExtractTextBase64(ctx context.Context, encoded string) (string, error) {
+	image, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid image data: %w", err)
+	}
+	return o.ExtractText(ctx, image)
+}
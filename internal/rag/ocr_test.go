@@ -0,0 +1,74 @@
+package rag
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeOCR returns an OCR running a shell script in place of tesseract.
+func fakeOCR(t *testing.T, script string) *OCR {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tesseract")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake tesseract: %v", err)
+	}
+	return &OCR{command: path}
+}
+
+func TestOCR_ExtractText(t *testing.T) {
+	// Echo the image back, as tesseract would print its text followed by a
+	// form feed
+	ocr := fakeOCR(t, `[ "$1" = stdin ] && [ "$2" = stdout ] || exit 1; cat; printf '\n\n\n\f'`)
+
+	got, err := ocr.ExtractText(context.Background(), []byte("Invoice 42\r\nTotal: 10"))
+	if err != nil {
+		t.Fatalf("ExtractText() error = %v", err)
+	}
+	if want := "Invoice 42\nTotal: 10"; got != want {
+		t.Errorf("ExtractText() = %q, want %q", got, want)
+	}
+}
+
+func TestOCR_ExtractTextBase64(t *testing.T) {
+	ocr := fakeOCR(t, "cat")
+
+	got, err := ocr.ExtractTextBase64(context.Background(), base64.StdEncoding.EncodeToString([]byte("hello")))
+	if err != nil {
+		t.Fatalf("ExtractTextBase64() error = %v", err)
+	}
+	if got != "hello" {
+		t.Errorf("ExtractTextBase64() = %q, want %q", got, "hello")
+	}
+
+	if _, err := ocr.ExtractTextBase64(context.Background(), "not base64!"); err == nil {
+		t.Error("ExtractTextBase64() with invalid data should fail")
+	}
+}
+
+func TestOCR_Failure(t *testing.T) {
+	ocr := fakeOCR(t, "echo 'Error in pixReadStream' >&2; exit 1")
+
+	_, err := ocr.ExtractText(context.Background(), []byte("x"))
+	if err == nil {
+		t.Fatal("ExtractText() should fail when tesseract fails")
+	}
+	if got := err.Error(); !strings.Contains(got, "pixReadStream") {
+		t.Errorf("ExtractText() error = %q, want tesseract's message", got)
+	}
+}
+
+func TestOCR_Unavailable(t *testing.T) {
+	ocr := &OCR{command: filepath.Join(t.TempDir(), "missing")}
+
+	if ocr.Available() {
+		t.Error("Available() = true for a missing command")
+	}
+	if _, err := ocr.ExtractText(context.Background(), []byte("x")); !errors.Is(err, ErrOCRUnavailable) {
+		t.Errorf("ExtractText() error = %v, want ErrOCRUnavailable", err)
+	}
+}
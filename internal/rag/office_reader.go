@@ -0,0 +1,199 @@
+package rag
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Office documents are zip archives holding the text in an XML file:
+// word/document.xml for Word (.docx) and content.xml for OpenDocument
+// (.odt). Only the text is kept, one line per paragraph.
+
+// maxOfficeXMLSize limits how much XML is read from an office document, as
+// a guard against archives that expand to huge files.
+const maxOfficeXMLSize = 64 << 20
+
+// maxSpaceRun limits the spaces written for one run of an OpenDocument.
+const maxSpaceRun = 1000
+
+// XML namespaces of the elements holding the text
+const (
+	docxNS      = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	odfTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+)
+
+// Archive members holding the text
+const (
+	docxContentPath = "word/document.xml"
+	odtContentPath  = "content.xml"
+)
+
+// DocxReader reads Word documents.
+type DocxReader struct{}
+
+// NewDocxReader creates a new Word document reader.
+func NewDocxReader() *DocxReader {
+	return &DocxReader{}
+}
+
+// Read extracts the text of a .docx file.
+func (r *DocxReader) Read(path string) (string, error) {
+	return readOfficeXML(path, docxContentPath, docxText)
+}
+
+// CanRead returns true if the file is a Word document.
+func (r *DocxReader) CanRead(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".docx"
+}
+
+// OdtReader reads OpenDocument text files.
+type OdtReader struct{}
+
+// NewOdtReader creates a new OpenDocument text reader.
+func NewOdtReader() *OdtReader {
+	return &OdtReader{}
+}
+
+// Read extracts the text of an .odt file.
+func (r *OdtReader) Read(path string) (string, error) {
+	return readOfficeXML(path, odtContentPath, odtText)
+}
+
+// CanRead returns true if the file is an OpenDocument text.
+func (r *OdtReader) CanRead(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".odt"
+}
+
+// readOfficeXML opens the zip archive at path and extracts the text of its
+// member name with extract.
+func readOfficeXML(path, name string, extract func(io.Reader) (string, error)) (string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open document: %w", err)
+	}
+	defer archive.Close()
+
+	file, err := archive.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to find %s in document: %w", name, err)
+	}
+	defer file.Close()
+
+	text, err := extract(io.LimitReader(file, maxOfficeXMLSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return cleanText(text), nil
+}
+
+// docxText returns the text of a WordprocessingML document.
+func docxText(r io.Reader) (string, error) {
+	var b strings.Builder
+	inText := false
+
+	err := walkXML(r, func(token xml.Token) {
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != docxNS {
+				return
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space != docxNS {
+				return
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	})
+	return b.String(), err
+}
+
+// odtText returns the text of the paragraphs and headings in the body of
+// an OpenDocument content file.
+func odtText(r io.Reader) (string, error) {
+	var b strings.Builder
+	inBody := false
+	paragraphs := 0 // Open paragraphs and headings, which may nest in notes
+
+	err := walkXML(r, func(token xml.Token) {
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space == odfOfficeNS && t.Name.Local == "body" {
+				inBody = true
+			}
+			if !inBody || t.Name.Space != odfTextNS {
+				return
+			}
+			switch t.Name.Local {
+			case "p", "h":
+				paragraphs++
+			case "s":
+				// A run of spaces, c of them
+				count := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+							count = min(n, maxSpaceRun)
+						}
+					}
+				}
+				b.WriteString(strings.Repeat(" ", count))
+			case "tab":
+				b.WriteByte('\t')
+			case "line-break":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space == odfOfficeNS && t.Name.Local == "body" {
+				inBody = false
+			}
+			if inBody && t.Name.Space == odfTextNS && (t.Name.Local == "p" || t.Name.Local == "h") {
+				paragraphs--
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inBody && paragraphs > 0 {
+				b.Write(t)
+			}
+		}
+	})
+	return b.String(), err
+}
+
+// walkXML passes each token of the XML read from r to handle.
+func walkXML(r io.Reader, handle func(xml.Token)) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		handle(token)
+	}
+}
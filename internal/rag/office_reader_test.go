@@ -0,0 +1,192 @@
+package rag
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeZip creates a zip archive named name in a temporary directory with
+// the given members and returns its path.
+func writeZip(t *testing.T, name string, members map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", name, err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for member, content := range members {
+		mw, err := w.Create(member)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", member, err)
+		}
+		if _, err := mw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s: %v", member, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return path
+}
+
+const sampleDocx = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Quarterly report</w:t></w:r></w:p>
+    <w:p>
+      <w:r><w:t xml:space="preserve">Sales grew </w:t></w:r>
+      <w:r><w:rPr><w:b/></w:rPr><w:t>12%</w:t></w:r>
+      <w:r><w:tab/><w:t>in Q3.</w:t></w:r>
+    </w:p>
+    <w:p><w:r><w:t>First line</w:t><w:br/><w:t>second line</w:t></w:r></w:p>
+    <w:tbl><w:tr><w:tc><w:p><w:r><w:t>Cell</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+  </w:body>
+</w:document>`
+
+const sampleOdt = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0">
+  <office:automatic-styles><style:style style:name="P1">Ignored</style:style></office:automatic-styles>
+  <office:body>
+    <office:text>
+      <text:h text:outline-level="1">Quarterly report</text:h>
+      <text:p>Sales grew<text:s/><text:span>12%</text:span><text:tab/>in Q3.</text:p>
+      <text:p>Wide<text:s text:c="3"/>gap<text:line-break/>next line</text:p>
+      <text:list><text:list-item><text:p>Item</text:p></text:list-item></text:list>
+    </office:text>
+  </office:body>
+</office:document-content>`
+
+func TestDocxReader_CanRead(t *testing.T) {
+	reader := NewDocxReader()
+
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"report.docx", true},
+		{"report.DOCX", true},
+		{"report.doc", false},
+		{"report.odt", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := reader.CanRead(tt.filename); got != tt.expected {
+				t.Errorf("CanRead(%q) = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDocxReader_Read(t *testing.T) {
+	reader := NewDocxReader()
+
+	t.Run("read document", func(t *testing.T) {
+		path := writeZip(t, "report.docx", map[string]string{
+			"[Content_Types].xml": `<Types/>`,
+			"word/document.xml":   sampleDocx,
+		})
+
+		got, err := reader.Read(path)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "Quarterly report\nSales grew 12%\tin Q3.\nFirst line\nsecond line\nCell"
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("missing document part", func(t *testing.T) {
+		path := writeZip(t, "empty.docx", map[string]string{"other.xml": "<a/>"})
+		if _, err := reader.Read(path); err == nil {
+			t.Error("expected error for archive without word/document.xml")
+		}
+	})
+
+	t.Run("not a zip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "invalid.docx")
+		if err := os.WriteFile(path, []byte("not a document"), 0644); err != nil {
+			t.Fatalf("failed to create temp file: %v", err)
+		}
+		if _, err := reader.Read(path); err == nil {
+			t.Error("expected error for invalid file")
+		}
+	})
+
+	t.Run("malformed XML", func(t *testing.T) {
+		path := writeZip(t, "broken.docx", map[string]string{"word/document.xml": "<w:document><w:body>"})
+		if _, err := reader.Read(path); err == nil {
+			t.Error("expected error for malformed XML")
+		}
+	})
+}
+
+func TestOdtReader_CanRead(t *testing.T) {
+	reader := NewOdtReader()
+
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"notes.odt", true},
+		{"notes.ODT", true},
+		{"notes.ods", false},
+		{"notes.docx", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := reader.CanRead(tt.filename); got != tt.expected {
+				t.Errorf("CanRead(%q) = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOdtReader_Read(t *testing.T) {
+	reader := NewOdtReader()
+
+	t.Run("read document", func(t *testing.T) {
+		path := writeZip(t, "notes.odt", map[string]string{
+			"mimetype":    "application/vnd.oasis.opendocument.text",
+			"content.xml": sampleOdt,
+		})
+
+		got, err := reader.Read(path)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "Quarterly report\nSales grew 12%\tin Q3.\nWide   gap\nnext line\nItem"
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("missing content", func(t *testing.T) {
+		path := writeZip(t, "empty.odt", map[string]string{"mimetype": "application/vnd.oasis.opendocument.text"})
+		if _, err := reader.Read(path); err == nil {
+			t.Error("expected error for archive without content.xml")
+		}
+	})
+}
+
+func TestProcessor_OfficeDocuments(t *testing.T) {
+	processor := NewProcessor()
+
+	path := writeZip(t, "report.docx", map[string]string{"word/document.xml": sampleDocx})
+	result, err := processor.Process(path)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if result.Filename != "report.docx" || len(result.Chunks) == 0 {
+		t.Errorf("Process() = %+v, want chunks of report.docx", result)
+	}
+}
@@ -38,6 +38,13 @@ func NewProcessor() *Processor {
 		readers: []Reader{
 			NewTxtReader(),
 			NewPdfReader(),
+			NewDocxReader(),
+			NewOdtReader(),
+			NewCsvReader(DefaultMaxTableRows),
+			NewXlsxReader(DefaultMaxTableRows),
+			NewNotebookReader(true),
+			NewEmailReader(),
+			NewSubtitleReader(),
 			NewImageReader(),
 		},
 		chunker: NewChunker(DefaultChunkSize, DefaultOverlap),
@@ -49,6 +56,16 @@ func (p *Processor) SetChunkSize(size, overlap int) {
 	p.chunker = NewChunker(size, overlap)
 }
 
+// SetNotebookOutputs sets whether the output of notebook code cells is
+// read along with the code.
+func (p *Processor) SetNotebookOutputs(include bool) {
+	for i, reader := range p.readers {
+		if _, ok := reader.(*NotebookReader); ok {
+			p.readers[i] = NewNotebookReader(include)
+		}
+	}
+}
+
 // AddReader adds a custom reader to the processor.
 func (p *Processor) AddReader(reader Reader) {
 	p.readers = append(p.readers, reader)
@@ -112,5 +129,5 @@ func (p *Processor) ProcessForContext(path string) (string, error) {
 
 // SupportedExtensions returns a list of supported file extensions.
 func (p *Processor) SupportedExtensions() []string {
-	return []string{".txt", ".text", ".md", ".markdown", ".pdf", ".jpg", ".jpeg", ".png", ".webp", ".gif"}
+	return []string{".txt", ".text", ".md", ".markdown", ".pdf", ".docx", ".odt", ".csv", ".tsv", ".xlsx", ".ipynb", ".eml", ".mbox", ".srt", ".vtt", ".jpg", ".jpeg", ".png", ".webp", ".gif"}
 }
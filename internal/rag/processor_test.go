@@ -69,8 +69,16 @@ func TestProcessor_CanProcess(t *testing.T) {
 		{"document.md", true},
 		{"document.pdf", true},
 		{"document.doc", false},
-		{"document.docx", false},
-		{"document.xlsx", false},
+		{"document.docx", true},
+		{"document.odt", true},
+		{"document.xlsx", true},
+		{"document.csv", true},
+		{"document.xls", false},
+		{"analysis.ipynb", true},
+		{"reply.eml", true},
+		{"inbox.mbox", true},
+		{"lecture.srt", true},
+		{"lecture.vtt", true},
 		{"", false},
 	}
 
@@ -0,0 +1,122 @@
+package rag
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Source is a document to select context from.
+type Source struct {
+	Name    string
+	Content string
+}
+
+// Passage is a piece of a source selected as context.
+type Passage struct {
+	Source string
+	Text   string
+	Start  string // Time the passage starts at in subtitles, such as "00:12:30"
+}
+
+// SelectPassages returns the context to send from sources for query, within
+// about budget tokens. When all sources fit they are returned whole.
+// Otherwise they are split into chunks and the chunks sharing the most words
+// with the query are kept, returned in document order.
+func SelectPassages(query string, sources []Source, chunker *Chunker, budget int) []Passage {
+	total := 0
+	for _, src := range sources {
+		total += EstimateTokens(src.Content)
+	}
+	if total <= budget {
+		passages := make([]Passage, 0, len(sources))
+		for _, src := range sources {
+			if text := strings.TrimSpace(src.Content); text != "" {
+				passages = append(passages, Passage{Source: src.Name, Text: text})
+			}
+		}
+		return passages
+	}
+
+	type candidate struct {
+		source, index int
+		text          string
+		start         string
+		score         int
+		tokens        int
+	}
+
+	terms := queryTerms(query)
+	var candidates []candidate
+	for i, src := range sources {
+		offset := 0
+		for j, chunk := range chunker.Chunk(src.Content) {
+			// Chunks overlap, so each is searched from the start of the last
+			if at := strings.Index(src.Content[offset:], chunk); at >= 0 {
+				offset += at
+			}
+
+			lower := strings.ToLower(chunk)
+			score := 0
+			for _, term := range terms {
+				score += strings.Count(lower, term)
+			}
+			candidates = append(candidates, candidate{
+				source: i,
+				index:  j,
+				text:   chunk,
+				start:  timestampAt(src.Content, offset),
+				score:  score,
+				tokens: EstimateTokens(chunk),
+			})
+		}
+	}
+
+	// Best matches first; without matches, the beginning of each document
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if candidates[a].score != candidates[b].score {
+			return candidates[a].score > candidates[b].score
+		}
+		return candidates[a].index < candidates[b].index
+	})
+
+	var selected []candidate
+	remaining := budget
+	for _, c := range candidates {
+		if c.tokens <= remaining {
+			selected = append(selected, c)
+			remaining -= c.tokens
+		}
+	}
+
+	sort.Slice(selected, func(a, b int) bool {
+		if selected[a].source != selected[b].source {
+			return selected[a].source < selected[b].source
+		}
+		return selected[a].index < selected[b].index
+	})
+
+	passages := make([]Passage, len(selected))
+	for i, c := range selected {
+		passages[i] = Passage{Source: sources[c.source].Name, Text: c.text, Start: c.start}
+	}
+	return passages
+}
+
+// queryTerms returns the distinct lowercase words of query with at least
+// three characters.
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if utf8.RuneCountInString(word) < 3 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectPassages_WholeDocumentsWhenTheyFit(t *testing.T) {
+	sources := []Source{
+		{Name: "a.txt", Content: "First document."},
+		{Name: "empty.txt", Content: "   "},
+		{Name: "b.txt", Content: "Second document."},
+	}
+
+	got := SelectPassages("anything", sources, NewChunker(100, 0), 1000)
+	want := []Passage{
+		{Source: "a.txt", Text: "First document."},
+		{Source: "b.txt", Text: "Second document."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SelectPassages() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSelectPassages_RetrievesMatchingChunks(t *testing.T) {
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 4)
+	manual := strings.Join([]string{
+		filler + "installation steps",
+		filler + "the router password can be reset from the admin page",
+		filler + "warranty terms",
+	}, "\n\n")
+	sources := []Source{
+		{Name: "manual.txt", Content: manual},
+		{Name: "faq.txt", Content: filler + "how to reset the password of your account"},
+	}
+
+	chunker := NewChunker(150, 0)
+	got := SelectPassages("How do I reset the password?", sources, chunker, 80)
+
+	if len(got) != 2 {
+		t.Fatalf("SelectPassages() returned %d passages, want 2: %+v", len(got), got)
+	}
+	// Returned in document order, not by score
+	if got[0].Source != "manual.txt" || !strings.Contains(got[0].Text, "router password") {
+		t.Errorf("first passage = %+v, want the manual's password section", got[0])
+	}
+	if got[1].Source != "faq.txt" {
+		t.Errorf("second passage = %+v, want the FAQ", got[1])
+	}
+
+	total := 0
+	for _, p := range got {
+		total += EstimateTokens(p.Text)
+	}
+	if total > 80 {
+		t.Errorf("selected %d tokens, over the budget of 80", total)
+	}
+}
+
+func TestSelectPassages_NoMatchesKeepsBeginning(t *testing.T) {
+	content := strings.Repeat("alpha beta gamma delta epsilon ", 30)
+	sources := []Source{{Name: "doc.txt", Content: content}}
+
+	chunker := NewChunker(100, 0)
+	got := SelectPassages("summarize", sources, chunker, 30)
+	if len(got) == 0 {
+		t.Fatal("SelectPassages() returned no passages")
+	}
+	if first := chunker.Chunk(content)[0]; got[0].Text != first {
+		t.Errorf("first passage = %q, want the first chunk %q", got[0].Text, first)
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	got := queryTerms("How do I reset the Password? The password!")
+	want := []string{"how", "reset", "the", "password"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("queryTerms() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package rag
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subtitles (.srt and .vtt) are read as the spoken text without the cue
+// numbers, exact timings and styling. The text is grouped into paragraphs
+// of about subtitleParagraph, each starting with the time it is spoken at,
+// such as "[00:12:30] ...". Chunks keep these markers, so the model can
+// tell when something is said, and SelectPassages reports the time each
+// selected passage starts at.
+
+// subtitleParagraph is how much speech goes in each paragraph, and so how
+// coarse the timestamps are.
+const subtitleParagraph = time.Minute
+
+var (
+	// timestampMarker matches the timestamp that starts a paragraph.
+	timestampMarker = regexp.MustCompile(`(?m)^\[(\d{2,}:\d{2}:\d{2})\] `)
+
+	subtitleTagPattern   = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+	subtitleBlankPattern = regexp.MustCompile(`\n\s*\n`)
+)
+
+// SubtitleReader reads SubRip (.srt) and WebVTT (.vtt) subtitles.
+type SubtitleReader struct{}
+
+// NewSubtitleReader creates a new subtitle reader.
+func NewSubtitleReader() *SubtitleReader {
+	return &SubtitleReader{}
+}
+
+// subtitleCue is a piece of text shown from start.
+type subtitleCue struct {
+	start time.Duration
+	text  string
+}
+
+// Read reads subtitles as timestamped paragraphs.
+func (r *SubtitleReader) Read(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	cues := parseSubtitles(string(data))
+	if len(cues) == 0 {
+		return "", fmt.Errorf("no subtitles found")
+	}
+
+	var paragraphs []string
+	var current []string
+	var paragraphStart time.Duration
+	for _, cue := range cues {
+		if len(current) > 0 && cue.start-paragraphStart >= subtitleParagraph {
+			paragraphs = append(paragraphs, formatTimestamp(paragraphStart)+" "+strings.Join(current, " "))
+			current = nil
+		}
+		if len(current) == 0 {
+			paragraphStart = cue.start
+		}
+		current = append(current, cue.text)
+	}
+	paragraphs = append(paragraphs, formatTimestamp(paragraphStart)+" "+strings.Join(current, " "))
+
+	return strings.Join(paragraphs, "\n\n"), nil
+}
+
+// CanRead returns true if the file is a subtitle file.
+func (r *SubtitleReader) CanRead(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".srt" || ext == ".vtt"
+}
+
+// parseSubtitles returns the cues of SubRip or WebVTT subtitles, in order.
+// Blocks without a timing line, such as the WebVTT header and notes, are
+// left out, as is text repeated from the previous cue, which captions that
+// roll up line by line are full of.
+func parseSubtitles(content string) []subtitleCue {
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var cues []subtitleCue
+	previous := ""
+	for _, block := range subtitleBlankPattern.Split(content, -1) {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+		start, ok := parseCueTime(strings.Split(lines[timing], "-->")[0])
+		if !ok {
+			continue
+		}
+
+		text := cleanCueText(strings.Join(lines[timing+1:], " "))
+		text, previous = withoutRepeat(text, previous), text
+		if text == "" {
+			continue
+		}
+		cues = append(cues, subtitleCue{start: start, text: text})
+	}
+	return cues
+}
+
+// withoutRepeat returns text without the words it repeats from the end of
+// previous. Single words aren't taken for a repeat unless they are the
+// whole cue, as they are often said again.
+func withoutRepeat(text, previous string) string {
+	words := strings.Fields(text)
+	before := strings.Fields(previous)
+	for n := min(len(words), len(before)); n >= 1; n-- {
+		if n == 1 && len(words) > 1 {
+			break
+		}
+		if slices.Equal(words[:n], before[len(before)-n:]) {
+			return strings.Join(words[n:], " ")
+		}
+	}
+	return text
+}
+
+// parseCueTime parses a cue time such as "01:02:03,456" (SubRip) or
+// "02:03.456" (WebVTT, where the hours are optional).
+func parseCueTime(value string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(strings.ReplaceAll(value, ",", ".")), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	total := time.Duration(seconds * float64(time.Second))
+
+	units := []time.Duration{time.Minute, time.Hour}
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		total += time.Duration(n) * units[len(parts)-2-i]
+	}
+	return total, true
+}
+
+// cleanCueText removes the styling tags of a cue and puts it on one line.
+func cleanCueText(text string) string {
+	text = subtitleTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// formatTimestamp returns the marker that starts a paragraph spoken at d.
+func formatTimestamp(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("[%02d:%02d:%02d]", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// timestampAt returns the time of the last paragraph starting at or before
+// offset in content, or "" if there is none.
+func timestampAt(content string, offset int) string {
+	last := ""
+	for _, match := range timestampMarker.FindAllStringSubmatchIndex(content, -1) {
+		if match[0] > offset {
+			break
+		}
+		last = content[match[2]:match[3]]
+	}
+	return last
+}
@@ -0,0 +1,150 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSubtitles writes content to a file named name in a temporary
+// directory and returns its path.
+func writeSubtitles(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	return path
+}
+
+func TestSubtitleReader_CanRead(t *testing.T) {
+	reader := NewSubtitleReader()
+
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"lecture.srt", true},
+		{"lecture.VTT", true},
+		{"lecture.sub", false},
+		{"lecture.txt", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := reader.CanRead(tt.filename); got != tt.expected {
+				t.Errorf("CanRead(%q) = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSubtitleReader_Read(t *testing.T) {
+	reader := NewSubtitleReader()
+
+	t.Run("SubRip", func(t *testing.T) {
+		srt := "\ufeff1\r\n00:00:01,000 --> 00:00:03,500\r\nWelcome to the <i>lecture</i>.\r\n\r\n" +
+			"2\r\n00:00:04,000 --> 00:00:06,000\r\n{\\an8}Today: entropy\r\nand heat.\r\n\r\n" +
+			"3\r\n00:01:10,000 --> 00:01:12,000\r\nLet's start.\r\n"
+		got, err := reader.Read(writeSubtitles(t, "lecture.srt", srt))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "[00:00:01] Welcome to the lecture. Today: entropy and heat.\n\n[00:01:10] Let's start."
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("WebVTT with rolling captions", func(t *testing.T) {
+		vtt := "WEBVTT\nKind: captions\n\nNOTE generated automatically\n\n" +
+			"intro\n00:05.000 --> 00:07.000 align:start\n<c.yellow>so today</c> we &amp; you\n\n" +
+			"00:07.000 --> 00:09.000\nso today we & you\nlook at entropy\n\n" +
+			"00:09.000 --> 00:09.010\nlook at entropy\n\n" +
+			"01:02:03.000 --> 01:02:05.000\nIt's the end\n"
+		got, err := reader.Read(writeSubtitles(t, "lecture.vtt", vtt))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "[00:00:05] so today we & you look at entropy\n\n[01:02:03] It's the end"
+		if got != want {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("no cues", func(t *testing.T) {
+		if _, err := reader.Read(writeSubtitles(t, "empty.vtt", "WEBVTT\n\n")); err == nil {
+			t.Error("expected error for subtitles without cues")
+		}
+	})
+}
+
+func TestWithoutRepeat(t *testing.T) {
+	tests := []struct {
+		text, previous, want string
+	}{
+		{"look at entropy", "", "look at entropy"},
+		{"so today we look at entropy", "so today we", "look at entropy"},
+		{"look at entropy and heat", "so today we look at entropy", "and heat"},
+		{"entropy", "so today we look at entropy", ""},
+		{"the end", "we reach the", "the end"},
+		{"look at entropy", "something else", "look at entropy"},
+	}
+	for _, tt := range tests {
+		if got := withoutRepeat(tt.text, tt.previous); got != tt.want {
+			t.Errorf("withoutRepeat(%q, %q) = %q, want %q", tt.text, tt.previous, got, tt.want)
+		}
+	}
+}
+
+func TestParseCueTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"00:00:01,500", 1500 * time.Millisecond, true},
+		{" 01:02:03.000 ", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"02:03.250", 2*time.Minute + 3250*time.Millisecond, true},
+		{"3.0", 0, false},
+		{"aa:00:01", 0, false},
+		{"1:2:3:4", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseCueTime(tt.value)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("parseCueTime(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSelectPassages_SubtitleTimestamps(t *testing.T) {
+	var paragraphs []string
+	for minute := 0; minute < 6; minute++ {
+		topic := "filler words about nothing in particular"
+		if minute == 4 {
+			topic = "here we discuss entropy at length"
+		}
+		paragraphs = append(paragraphs, fmt.Sprintf("[00:%02d:00] %s %s", minute, topic, strings.Repeat("and so on ", 5)))
+	}
+	sources := []Source{{Name: "lecture.srt", Content: strings.Join(paragraphs, "\n\n")}}
+
+	got := SelectPassages("When is entropy discussed?", sources, NewChunker(120, 0), 30)
+	if len(got) != 1 || !strings.Contains(got[0].Text, "entropy") {
+		t.Fatalf("SelectPassages() = %+v, want the paragraph about entropy", got)
+	}
+	if got[0].Start != "00:04:00" {
+		t.Errorf("passage starts at %q, want 00:04:00", got[0].Start)
+	}
+
+	// A chunk starting inside a paragraph has the time of that paragraph
+	if start := timestampAt(sources[0].Content, strings.Index(sources[0].Content, "entropy")); start != "00:04:00" {
+		t.Errorf("timestampAt() = %q, want 00:04:00", start)
+	}
+}
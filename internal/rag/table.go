@@ -0,0 +1,161 @@
+package rag
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxTableRows is how many rows of a spreadsheet are sent by default.
+// Larger tables keep their first and last rows.
+const DefaultMaxTableRows = 200
+
+// tableExtensions lists the spreadsheet extensions read as tables.
+var tableExtensions = map[string]bool{
+	".csv":  true,
+	".tsv":  true,
+	".xlsx": true,
+}
+
+// Table is a sheet of a spreadsheet, with its first row as the header.
+type Table struct {
+	Name   string // Sheet name, empty for CSV files
+	Header []string
+	Rows   [][]string
+}
+
+// IsTable reports whether a file is a spreadsheet read as a table.
+func IsTable(filename string) bool {
+	return tableExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// ReadTables reads the sheets of the spreadsheet at path.
+func ReadTables(path string) ([]Table, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+		table, err := readCSV(path)
+		if err != nil {
+			return nil, err
+		}
+		return []Table{table}, nil
+	case ".xlsx":
+		return readXLSX(path)
+	default:
+		return nil, fmt.Errorf("unsupported spreadsheet: %s", filepath.Base(path))
+	}
+}
+
+// newTable makes a table of records, the first being the header. Empty
+// records are dropped.
+func newTable(name string, records [][]string) Table {
+	table := Table{Name: name}
+	for _, record := range records {
+		if isEmptyRecord(record) {
+			continue
+		}
+		if table.Header == nil {
+			table.Header = record
+		} else {
+			table.Rows = append(table.Rows, record)
+		}
+	}
+	return table
+}
+
+// isEmptyRecord reports whether every cell of record is blank.
+func isEmptyRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Columns returns the number of columns of the widest row.
+func (t Table) Columns() int {
+	columns := len(t.Header)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	return columns
+}
+
+// SampleRows returns the rows kept when a table is limited to maxRows: the
+// first and last rows, with the ones in between left out. All rows are kept
+// when maxRows is 0 or the table fits.
+func SampleRows(rows [][]string, maxRows int) (head, tail [][]string) {
+	if maxRows <= 0 || len(rows) <= maxRows {
+		return rows, nil
+	}
+	return rows[:maxRows-maxRows/2], rows[len(rows)-maxRows/2:]
+}
+
+// Markdown formats the table as a Markdown table of at most maxRows rows
+// (0 for all of them), noting how many were left out.
+func (t Table) Markdown(maxRows int) string {
+	columns := t.Columns()
+	if columns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = markdownCell(row[i])
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(t.Header)
+	b.WriteString(strings.Repeat("| --- ", columns) + "|\n")
+
+	head, tail := SampleRows(t.Rows, maxRows)
+	for _, row := range head {
+		writeRow(row)
+	}
+	if tail != nil {
+		ellipsis := make([]string, columns)
+		for i := range ellipsis {
+			ellipsis[i] = "…"
+		}
+		writeRow(ellipsis)
+		for _, row := range tail {
+			writeRow(row)
+		}
+		shown := len(head) + len(tail)
+		fmt.Fprintf(&b, "\n(%d of %d rows shown, %d rows in the middle omitted)\n", shown, len(t.Rows), len(t.Rows)-shown)
+	}
+
+	return b.String()
+}
+
+// markdownCell escapes a cell value for a Markdown table.
+func markdownCell(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.ReplaceAll(value, "\r\n", " ")
+	value = strings.ReplaceAll(value, "\n", " ")
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+// TablesMarkdown formats tables as Markdown, each limited to maxRows rows
+// and headed by its sheet name when it has one.
+func TablesMarkdown(tables []Table, maxRows int) string {
+	var parts []string
+	for _, table := range tables {
+		markdown := table.Markdown(maxRows)
+		if markdown == "" {
+			continue
+		}
+		if table.Name != "" {
+			markdown = fmt.Sprintf("Sheet: %s\n\n%s", table.Name, markdown)
+		}
+		parts = append(parts, strings.TrimSuffix(markdown, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
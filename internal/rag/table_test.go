@@ -0,0 +1,118 @@
+package rag
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIsTable(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{"data.csv", true},
+		{"data.TSV", true},
+		{"book.xlsx", true},
+		{"book.xls", false},
+		{"notes.txt", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := IsTable(tt.filename); got != tt.want {
+				t.Errorf("IsTable(%q) = %v, want %v", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+// numberedRows returns n rows holding their number.
+func numberedRows(n int) [][]string {
+	rows := make([][]string, n)
+	for i := range rows {
+		rows[i] = []string{strconv.Itoa(i + 1)}
+	}
+	return rows
+}
+
+func TestSampleRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     int
+		maxRows  int
+		wantHead int
+		wantTail int
+	}{
+		{"fits", 5, 10, 5, 0},
+		{"exactly the limit", 10, 10, 10, 0},
+		{"no limit", 50, 0, 50, 0},
+		{"even limit", 100, 10, 5, 5},
+		{"odd limit", 100, 5, 3, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := numberedRows(tt.rows)
+			head, tail := SampleRows(rows, tt.maxRows)
+			if len(head) != tt.wantHead || len(tail) != tt.wantTail {
+				t.Fatalf("SampleRows() kept %d + %d rows, want %d + %d", len(head), len(tail), tt.wantHead, tt.wantTail)
+			}
+			if len(tail) > 0 && tail[len(tail)-1][0] != strconv.Itoa(tt.rows) {
+				t.Errorf("tail ends with row %s, want the last row", tail[len(tail)-1][0])
+			}
+		})
+	}
+}
+
+func TestTableMarkdown(t *testing.T) {
+	table := Table{
+		Header: []string{"Name", "Notes"},
+		Rows: [][]string{
+			{"Ana", "likes a|b"},
+			{"Luis", "two\nlines", "extra"},
+			{"Eva"},
+		},
+	}
+
+	want := "| Name | Notes |  |\n" +
+		"| --- | --- | --- |\n" +
+		"| Ana | likes a\\|b |  |\n" +
+		"| Luis | two lines | extra |\n" +
+		"| Eva |  |  |\n"
+	if got := table.Markdown(0); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := (Table{}).Markdown(0); got != "" {
+		t.Errorf("Markdown() of an empty table = %q, want empty", got)
+	}
+}
+
+func TestTableMarkdown_Sampled(t *testing.T) {
+	table := Table{Header: []string{"n"}, Rows: numberedRows(10)}
+
+	got := table.Markdown(4)
+	for _, want := range []string{"| 1 |", "| 2 |", "| … |", "| 9 |", "| 10 |", "(4 of 10 rows shown, 6 rows in the middle omitted)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown(4) = %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "| 5 |") {
+		t.Errorf("Markdown(4) = %q, should leave out the middle rows", got)
+	}
+}
+
+func TestTablesMarkdown(t *testing.T) {
+	tables := []Table{
+		{Name: "Sales", Header: []string{"Q1"}, Rows: [][]string{{"10"}}},
+		{Name: "Empty"},
+		{Name: "Costs", Header: []string{"Q1"}, Rows: [][]string{{"4"}}},
+	}
+
+	want := "Sheet: Sales\n\n| Q1 |\n| --- |\n| 10 |\n\nSheet: Costs\n\n| Q1 |\n| --- |\n| 4 |"
+	if got := TablesMarkdown(tables, 0); got != want {
+		t.Errorf("TablesMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
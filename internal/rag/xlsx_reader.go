@@ -0,0 +1,219 @@
+package rag
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// An Excel workbook is a zip archive: xl/workbook.xml lists the sheets,
+// xl/_rels/workbook.xml.rels maps them to their files, and text cells refer
+// to the strings in xl/sharedStrings.xml.
+
+// XlsxReader reads Excel workbooks as Markdown tables, one per sheet.
+type XlsxReader struct {
+	maxRows int
+}
+
+// NewXlsxReader creates an Excel reader keeping at most maxRows rows of
+// each sheet (0 for all of them).
+func NewXlsxReader(maxRows int) *XlsxReader {
+	return &XlsxReader{maxRows: maxRows}
+}
+
+// Read reads the sheets of an .xlsx file as Markdown tables.
+func (r *XlsxReader) Read(path string) (string, error) {
+	tables, err := readXLSX(path)
+	if err != nil {
+		return "", err
+	}
+	return TablesMarkdown(tables, r.maxRows), nil
+}
+
+// CanRead returns true if the file is an Excel workbook.
+func (r *XlsxReader) CanRead(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".xlsx"
+}
+
+// xlsxWorkbook is the list of sheets of xl/workbook.xml.
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships maps the IDs of xl/_rels/workbook.xml.rels to files.
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a string made of a plain text or rich text runs.
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String returns the whole text.
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// xlsxSharedStrings is xl/sharedStrings.xml.
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxSheet holds the cells of a worksheet.
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the sheets of an Excel workbook.
+func readXLSX(filePath string) ([]Table, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	defer archive.Close()
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(archive, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+
+	// Workbooks without text cells have no shared strings
+	var shared xlsxSharedStrings
+	err = decodeZipXML(archive, "xl/sharedStrings.xml", &shared)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	var tables []Table
+	for _, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.ID]
+		if !ok {
+			continue
+		}
+		var data xlsxSheet
+		if err := decodeZipXML(archive, target, &data); err != nil {
+			return nil, err
+		}
+		tables = append(tables, newTable(sheet.Name, sheetRecords(data, shared)))
+	}
+	return tables, nil
+}
+
+// sheetRecords returns the cell values of a worksheet, row by row. Cells
+// are placed in their column, leaving gaps for missing ones.
+func sheetRecords(sheet xlsxSheet, shared xlsxSharedStrings) [][]string {
+	records := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var record []string
+		for i, cell := range row.Cells {
+			column := columnIndex(cell.Ref)
+			if column < 0 {
+				column = i
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				if index, err := strconv.Atoi(value); err == nil && index >= 0 && index < len(shared.Items) {
+					value = shared.Items[index].String()
+				}
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = strings.ToUpper(strconv.FormatBool(value == "1"))
+			}
+
+			for len(record) <= column {
+				record = append(record, "")
+			}
+			record[column] = value
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// maxColumns limits the column of a cell reference, so that a corrupt
+// reference can't make huge rows.
+const maxColumns = 16384
+
+// columnIndex returns the zero-based column of a cell reference such as
+// "B7", or -1 if it has none.
+func columnIndex(ref string) int {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+		if column > maxColumns {
+			return -1
+		}
+	}
+	if letters == 0 {
+		return -1
+	}
+	return column - 1
+}
+
+// decodeZipXML decodes the XML file name of archive into v.
+func decodeZipXML(archive *zip.ReadCloser, name string, v any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to find %s in workbook: %w", name, err)
+	}
+	defer file.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(file, maxOfficeXMLSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
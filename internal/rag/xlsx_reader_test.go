@@ -0,0 +1,140 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Sales" sheetId="1" r:id="rId1"/>
+    <sheet name="Notes" sheetId="2" r:id="rId2"/>
+  </sheets>
+</workbook>`
+
+const sampleWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+</Relationships>`
+
+const sampleSharedStrings = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="4" uniqueCount="4">
+  <si><t>Region</t></si>
+  <si><t>Total</t></si>
+  <si><r><t>No</t></r><r><rPr><b/></rPr><t>rth</t></r></si>
+  <si><t>South</t></si>
+</sst>`
+
+const sampleSheet1 = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>Closed</t></is></c></row>
+    <row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>1250.5</v></c><c r="C2" t="b"><v>1</v></c></row>
+    <row r="4"><c r="A4" t="s"><v>3</v></c><c r="C4" t="b"><v>0</v></c></row>
+  </sheetData>
+</worksheet>`
+
+const sampleSheet2 = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData/>
+</worksheet>`
+
+func TestXlsxReader_CanRead(t *testing.T) {
+	reader := NewXlsxReader(DefaultMaxTableRows)
+
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"book.xlsx", true},
+		{"book.XLSX", true},
+		{"book.xls", false},
+		{"book.csv", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := reader.CanRead(tt.filename); got != tt.expected {
+				t.Errorf("CanRead(%q) = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestXlsxReader_Read(t *testing.T) {
+	reader := NewXlsxReader(DefaultMaxTableRows)
+
+	t.Run("read workbook", func(t *testing.T) {
+		path := writeZip(t, "book.xlsx", map[string]string{
+			"xl/workbook.xml":            sampleWorkbook,
+			"xl/_rels/workbook.xml.rels": sampleWorkbookRels,
+			"xl/sharedStrings.xml":       sampleSharedStrings,
+			"xl/worksheets/sheet1.xml":   sampleSheet1,
+			"xl/worksheets/sheet2.xml":   sampleSheet2,
+		})
+
+		got, err := reader.Read(path)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		want := "Sheet: Sales\n\n" +
+			"| Region | Total | Closed |\n" +
+			"| --- | --- | --- |\n" +
+			"| North | 1250.5 | TRUE |\n" +
+			"| South |  | FALSE |"
+		if got != want {
+			t.Errorf("Read() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("without shared strings", func(t *testing.T) {
+		path := writeZip(t, "numbers.xlsx", map[string]string{
+			"xl/workbook.xml":            sampleWorkbook,
+			"xl/_rels/workbook.xml.rels": sampleWorkbookRels,
+			"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row><c r="A1"><v>1</v></c><c r="B1"><v>2</v></c></row></sheetData></worksheet>`,
+			"xl/worksheets/sheet2.xml":   sampleSheet2,
+		})
+
+		got, err := reader.Read(path)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if !strings.Contains(got, "| 1 | 2 |") {
+			t.Errorf("Read() = %q, want the numbers as header", got)
+		}
+	})
+
+	t.Run("missing workbook", func(t *testing.T) {
+		path := writeZip(t, "broken.xlsx", map[string]string{"xl/styles.xml": "<styleSheet/>"})
+		if _, err := reader.Read(path); err == nil {
+			t.Error("expected error for archive without xl/workbook.xml")
+		}
+	})
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"A1", 0},
+		{"B7", 1},
+		{"Z10", 25},
+		{"AA3", 26},
+		{"AB3", 27},
+		{"b2", 1},
+		{"12", -1},
+		{"", -1},
+		{"ZZZZZ1", -1},
+	}
+
+	for _, tt := range tests {
+		if got := columnIndex(tt.ref); got != tt.want {
+			t.Errorf("columnIndex(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package snippet runs shell and Python snippets from code blocks in a
+// bubblewrap sandbox, without network access, with the home folder hidden
+// and everything but a scratch /tmp read-only.
+package snippet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Timeout is how long a snippet may run before it is stopped.
+const Timeout = 30 * time.Second
+
+// MaxOutput is how much output of a snippet is kept, in bytes.
+const MaxOutput = 64 * 1024
+
+// sandboxCommand is the bubblewrap command snippets run in.
+const sandboxCommand = "bwrap"
+
+// sandboxPath is the PATH of snippets. The home folder is hidden, so
+// interpreters installed there can't be used.
+const sandboxPath = "/usr/local/bin:/usr/bin:/bin"
+
+// ErrUnsupported is returned for languages that can't be run.
+var ErrUnsupported = errors.New("snippets in this language can't be run")
+
+// ErrTimeout is returned when a snippet runs longer than Timeout.
+var ErrTimeout = fmt.Errorf("stopped after %s", Timeout)
+
+// interpreter runs a snippet saved to a file.
+type interpreter struct {
+	command string
+	ext     string // Extension of the snippet file
+}
+
+var (
+	shell  = &interpreter{"sh", ".sh"}
+	bash   = &interpreter{"bash", ".sh"}
+	python = &interpreter{"python3", ".py"}
+)
+
+// interpreters maps code block languages to their interpreter.
+var interpreters = map[string]*interpreter{
+	"sh":      shell,
+	"shell":   shell,
+	"bash":    bash,
+	"python":  python,
+	"python3": python,
+	"py":      python,
+}
+
+func interpreterFor(language string) *interpreter {
+	return interpreters[strings.ToLower(strings.TrimSpace(language))]
+}
+
+// Supported reports whether snippets in language, the language of a code
+// block, can be run when the sandbox and interpreter are installed.
+func Supported(language string) bool {
+	return interpreterFor(language) != nil
+}
+
+// Available reports whether snippets in language can be run: it is
+// supported, and bubblewrap and the interpreter are installed.
+func Available(language string) bool {
+	in := interpreterFor(language)
+	if in == nil {
+		return false
+	}
+	if _, err := exec.LookPath(sandboxCommand); err != nil {
+		return false
+	}
+	_, err := exec.LookPath(in.command)
+	return err == nil
+}
+
+// Run runs code, written in language, in the sandbox and returns its
+// combined output, cut to MaxOutput. When the snippet fails, the output so
+// far is returned along with the error.
+func Run(ctx context.Context, language, code string) (string, error) {
+	in := interpreterFor(language)
+	if in == nil {
+		return "", ErrUnsupported
+	}
+	if _, err := exec.LookPath(sandboxCommand); err != nil {
+		return "", fmt.Errorf("%s is not installed", sandboxCommand)
+	}
+
+	file, err := os.CreateTemp("", "guanaco-snippet-*"+in.ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snippet file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(code); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write snippet: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	home, _ := os.UserHomeDir()
+	script := "/tmp/snippet" + in.ext
+	args := append(sandboxArgs(file.Name(), script, hiddenFolders(home)), in.command, script)
+
+	output := &limitedBuffer{max: MaxOutput}
+	cmd := exec.CommandContext(ctx, sandboxCommand, args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = ErrTimeout
+	}
+	return output.String(), err
+}
+
+// sandboxArgs returns the bubblewrap arguments running a command with the
+// snippet file bound read-only at script, and the folders in hidden
+// replaced by empty ones.
+func sandboxArgs(file, script string, hidden []string) []string {
+	args := []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	for _, dir := range hidden {
+		args = append(args, "--tmpfs", dir)
+	}
+	return append(args,
+		"--ro-bind", file, script,
+		"--chdir", "/tmp",
+		"--unshare-all",
+		"--die-with-parent",
+		"--new-session",
+		"--clearenv",
+		"--setenv", "PATH", sandboxPath,
+		"--setenv", "HOME", "/tmp",
+		"--setenv", "LANG", "C.UTF-8",
+	)
+}
+
+// hiddenFolders returns the existing folders hidden from snippets: the
+// home folder, and /run with the session's sockets.
+func hiddenFolders(home string) []string {
+	var hidden []string
+	for _, dir := range []string{home, "/run"} {
+		if dir == "" || dir == "/" || dir == "/tmp" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			hidden = append(hidden, dir)
+		}
+	}
+	return hidden
+}
+
+// limitedBuffer keeps the first max bytes written to it and drops the
+// rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// String returns the output kept, ending with "…" when some was dropped.
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return strings.ToValidUTF8(b.buf.String(), "") + "…"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,102 @@
+package snippet
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSupported(t *testing.T) {
+	for _, language := range []string{"sh", "bash", "Python", " py "} {
+		if !Supported(language) {
+			t.Errorf("Supported(%q) = false, want true", language)
+		}
+	}
+	for _, language := range []string{"", "go", "javascript"} {
+		if Supported(language) {
+			t.Errorf("Supported(%q) = true, want false", language)
+		}
+	}
+}
+
+func TestRun_Unsupported(t *testing.T) {
+	if _, err := Run(context.Background(), "go", "package main"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Run(go) error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestRun_NoSandbox(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if Available("sh") {
+		t.Error("Available(sh) = true with an empty PATH")
+	}
+	if _, err := Run(context.Background(), "sh", "echo hi"); err == nil {
+		t.Error("Run() succeeded without bubblewrap installed")
+	}
+}
+
+func TestSandboxArgs(t *testing.T) {
+	args := sandboxArgs("/tmp/guanaco-snippet-1.py", "/tmp/snippet.py", []string{"/home/ana"})
+	joined := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"--ro-bind / /",
+		"--tmpfs /tmp --tmpfs /home/ana --ro-bind /tmp/guanaco-snippet-1.py /tmp/snippet.py",
+		"--unshare-all",
+		"--clearenv",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("sandboxArgs() = %q, want it to contain %q", joined, want)
+		}
+	}
+}
+
+func TestHiddenFolders(t *testing.T) {
+	home := t.TempDir()
+	if got := hiddenFolders(home); !slices.Contains(got, home) {
+		t.Errorf("hiddenFolders() = %v, want it to contain %s", got, home)
+	}
+	if got := hiddenFolders(filepath.Join(home, "missing")); slices.Contains(got, filepath.Join(home, "missing")) {
+		t.Errorf("hiddenFolders() = %v, want missing folders left out", got)
+	}
+	if got := hiddenFolders("/"); slices.Contains(got, "/") {
+		t.Errorf("hiddenFolders() = %v, want the root left out", got)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 5}
+	b.Write([]byte("abc"))
+	b.Write([]byte("défg"))
+	if got, want := b.String(), "abcd…"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	if !Available("sh") {
+		t.Skip("bubblewrap is not installed")
+	}
+
+	output, err := Run(context.Background(), "sh", "echo hello; echo oops >&2")
+	if err != nil {
+		t.Fatalf("Run() error = %v, output %q", err, output)
+	}
+	if !strings.Contains(output, "hello") || !strings.Contains(output, "oops") {
+		t.Errorf("Run() output = %q, want stdout and stderr", output)
+	}
+
+	home, _ := os.UserHomeDir()
+	if output, _ := Run(context.Background(), "sh", "ls -A "+home); strings.TrimSpace(output) != "" {
+		t.Errorf("home folder visible in the sandbox: %q", output)
+	}
+
+	if _, err := Run(context.Background(), "sh", "exit 3"); err == nil {
+		t.Error("Run() succeeded for a failing snippet")
+	}
+}
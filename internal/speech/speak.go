@@ -0,0 +1,151 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrNoSynthesizer is returned when none of the speech synthesizers is
+// installed.
+var ErrNoSynthesizer = errors.New("no speech synthesizer found, install speech-dispatcher or espeak-ng")
+
+// synthesizer is a command reading the text on its standard input aloud.
+type synthesizer struct {
+	command string
+	args    []string
+	cancel  []string // Stops speech queued by another process, nil if killing the command does
+}
+
+// synthesizers lists the supported speech synthesizers, most preferred
+// first. Speech Dispatcher uses the voice set in the desktop settings.
+var synthesizers = []synthesizer{
+	{"spd-say", []string{"--wait", "--pipe-mode"}, []string{"spd-say", "--cancel"}},
+	{"espeak-ng", []string{"--stdin"}, nil},
+	{"espeak", []string{"--stdin"}, nil},
+}
+
+// Speaker reads text aloud with the first speech synthesizer found, one
+// text at a time.
+type Speaker struct {
+	synth *synthesizer
+
+	mu     sync.Mutex
+	stop   context.CancelFunc // Stops the text being read, nil if none
+	speech int                // Counts the texts read, to tell them apart
+}
+
+// NewSpeaker creates a speaker using the first installed synthesizer.
+func NewSpeaker() *Speaker {
+	for i := range synthesizers {
+		if _, err := exec.LookPath(synthesizers[i].command); err == nil {
+			return &Speaker{synth: &synthesizers[i]}
+		}
+	}
+	return &Speaker{}
+}
+
+// Available reports whether a speech synthesizer is installed.
+func (s *Speaker) Available() bool {
+	return s.synth != nil
+}
+
+// Speak reads text aloud, stopping the text read before. It returns once
+// the text has been read, or as soon as Stop is called.
+func (s *Speaker) Speak(text string) error {
+	if s.synth == nil {
+		return ErrNoSynthesizer
+	}
+	s.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.speech++
+	speech := s.speech
+	s.stop = cancel
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if s.speech == speech {
+			s.stop = nil
+		}
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	cmd := exec.CommandContext(ctx, s.synth.command, s.synth.args...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", s.synth.command, err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", s.synth.command, err)
+	}
+	return nil
+}
+
+// Stop stops reading the current text, if any.
+func (s *Speaker) Stop() {
+	s.mu.Lock()
+	stop := s.stop
+	s.stop = nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+
+	stop()
+	if s.synth.cancel != nil {
+		exec.Command(s.synth.cancel[0], s.synth.cancel[1:]...).Run()
+	}
+}
+
+var (
+	// codeBlock matches fenced code blocks, which aren't read
+	codeBlock = regexp.MustCompile("(?s)```.*?(```|$)")
+	// mdImage matches images, of which only the description is read
+	mdImage = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	// mdLink matches links, of which only the text is read
+	mdLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	// lineMarkup matches headings, quotes, list bullets and table rules at
+	// the start of a line
+	lineMarkup = regexp.MustCompile(`(?m)^\s*(#{1,6}\s+|>\s?|[-*+]\s+|\|?[\s:|-]*-{3,}[\s:|-]*$)`)
+	// inlineMarkup matches emphasis and inline code markers
+	inlineMarkup = regexp.MustCompile("\\*\\*|__|[*`~]")
+	// thinking matches the reasoning of models that think aloud
+	thinking = regexp.MustCompile(`(?s)<think>.*?(</think>|$)`)
+)
+
+// SpeakableText returns a Markdown response as it is read aloud: the
+// reasoning and code blocks are left out, and the markup is removed.
+func SpeakableText(markdown string) string {
+	text := thinking.ReplaceAllString(markdown, "")
+	text = codeBlock.ReplaceAllString(text, "")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = lineMarkup.ReplaceAllString(text, "")
+	text = inlineMarkup.ReplaceAllString(text, "")
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		// Table cells are read as a list
+		line = strings.Trim(strings.TrimSpace(line), "|")
+		line = strings.ReplaceAll(line, " |", ",")
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,84 @@
+package speech
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSpeakableText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"plain", "Hello there.", "Hello there."},
+		{"emphasis", "This is **bold**, *italic* and `code`.", "This is bold, italic and code."},
+		{"heading and list", "## Steps\n\n- First\n- Second", "Steps\nFirst\nSecond"},
+		{"link", "See [the docs](https://example.com).", "See the docs."},
+		{"code block", "Run this:\n```sh\nls -la\n```\nDone.", "Run this:\nDone."},
+		{"thinking", "<think>Let me see.</think>The answer is 4.", "The answer is 4."},
+		{"table", "| Name | Age |\n|------|-----|\n| Ana | 30 |", "Name, Age\nAna, 30"},
+		{"quote", "> Quoted text", "Quoted text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SpeakableText(tt.markdown); got != tt.want {
+				t.Errorf("SpeakableText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpeaker_Unavailable(t *testing.T) {
+	s := &Speaker{}
+	if s.Available() {
+		t.Error("Available() = true without a synthesizer")
+	}
+	if err := s.Speak("Hello"); !errors.Is(err, ErrNoSynthesizer) {
+		t.Errorf("Speak() error = %v, want ErrNoSynthesizer", err)
+	}
+	s.Stop()
+}
+
+func TestSpeaker_Speak(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "spoken")
+	s := &Speaker{synth: &synthesizer{command: "sh", args: []string{"-c", "cat > " + out}}}
+
+	if err := s.Speak("Hello"); err != nil {
+		t.Fatalf("Speak() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || string(data) != "Hello" {
+		t.Errorf("spoken = %q, %v, want %q", data, err, "Hello")
+	}
+}
+
+func TestSpeaker_Stop(t *testing.T) {
+	s := &Speaker{synth: &synthesizer{command: "sleep", args: []string{"10"}}}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Speak("Hello") }()
+
+	// Give the command time to start
+	time.Sleep(200 * time.Millisecond)
+	s.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Speak() error = %v, want nil once stopped", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Speak() didn't return after Stop()")
+	}
+}
+
+func TestSpeaker_Failure(t *testing.T) {
+	s := &Speaker{synth: &synthesizer{command: "sh", args: []string{"-c", "echo 'no audio device' >&2; exit 1"}}}
+	if err := s.Speak("Hello"); err == nil {
+		t.Error("Speak() error = nil for a failing synthesizer")
+	}
+}
@@ -0,0 +1,194 @@
+// Package speech records the microphone and turns speech into text with a
+// local speech recognizer, such as whisper.cpp, run as an external command,
+// and reads responses aloud with a local speech synthesizer.
+package speech
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// TranscribeTimeout is how long transcribing one recording may take.
+const TranscribeTimeout = 2 * time.Minute
+
+// stopTimeout is how long a recorder has to finish its file once asked to
+// stop, before it is killed.
+const stopTimeout = 2 * time.Second
+
+// FilePlaceholder is replaced by the path of the recording in the
+// transcription command.
+const FilePlaceholder = "{file}"
+
+// ErrNoRecorder is returned when none of the recording commands is
+// installed.
+var ErrNoRecorder = errors.New("no audio recorder found, install pipewire, pulseaudio-utils or alsa-utils")
+
+// recorder is a command recording the microphone to a WAV file until it is
+// interrupted.
+type recorder struct {
+	command string
+	args    func(path string) []string
+}
+
+// recorders lists the supported recording commands, most preferred first.
+// They record 16 kHz mono, the format whisper.cpp expects.
+var recorders = []recorder{
+	{"pw-record", func(path string) []string {
+		return []string{"--rate", "16000", "--channels", "1", "--format", "s16", path}
+	}},
+	{"parecord", func(path string) []string {
+		return []string{"--rate=16000", "--channels=1", "--format=s16le", "--file-format=wav", path}
+	}},
+	{"arecord", func(path string) []string {
+		return []string{"-q", "-f", "S16_LE", "-r", "16000", "-c", "1", "-t", "wav", path}
+	}},
+}
+
+// Recorder records the microphone with the first recording command found.
+type Recorder struct {
+	rec *recorder
+}
+
+// NewRecorder creates a recorder using the first installed recording
+// command.
+func NewRecorder() *Recorder {
+	for i := range recorders {
+		if _, err := exec.LookPath(recorders[i].command); err == nil {
+			return &Recorder{rec: &recorders[i]}
+		}
+	}
+	return &Recorder{}
+}
+
+// Available reports whether a recording command is installed.
+func (r *Recorder) Available() bool {
+	return r.rec != nil
+}
+
+// Recording is a recording in progress.
+type Recording struct {
+	Path string // WAV file being written
+
+	cmd  *exec.Cmd
+	done chan error
+}
+
+// Start starts recording the microphone to a new WAV file in the temporary
+// directory. The caller removes the file when done with it.
+func (r *Recorder) Start() (*Recording, error) {
+	if r.rec == nil {
+		return nil, ErrNoRecorder
+	}
+
+	f, err := os.CreateTemp("", "guanaco-speech-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+	f.Close()
+
+	rec := &Recording{Path: f.Name(), done: make(chan error, 1)}
+	rec.cmd = exec.Command(r.rec.command, r.rec.args(rec.Path)...)
+	var stderr bytes.Buffer
+	rec.cmd.Stderr = &stderr
+	if err := rec.cmd.Start(); err != nil {
+		os.Remove(rec.Path)
+		return nil, fmt.Errorf("failed to start %s: %w", r.rec.command, err)
+	}
+	go func() {
+		err := rec.cmd.Wait()
+		if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		rec.done <- err
+	}()
+	return rec, nil
+}
+
+// Stop ends the recording, letting the recorder finish the file.
+func (rec *Recording) Stop() error {
+	select {
+	case err := <-rec.done:
+		// Ended on its own, e.g. without a microphone
+		return fmt.Errorf("recording stopped early: %w", errOrExit(err))
+	default:
+	}
+
+	rec.cmd.Process.Signal(syscall.SIGINT)
+	select {
+	case <-rec.done:
+		// Recorders exit with an error status when interrupted
+	case <-time.After(stopTimeout):
+		rec.cmd.Process.Kill()
+		<-rec.done
+	}
+
+	info, err := os.Stat(rec.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read recording: %w", err)
+	}
+	if info.Size() == 0 {
+		return errors.New("nothing was recorded")
+	}
+	return nil
+}
+
+// errOrExit returns err, or an error saying the recorder exited when it
+// exited without one.
+func errOrExit(err error) error {
+	if err == nil {
+		return errors.New("the recorder exited")
+	}
+	return err
+}
+
+// Transcribe runs command with sh to turn the recording at path into text,
+// with FilePlaceholder in command replaced by the quoted path, and returns
+// what it printed.
+func Transcribe(ctx context.Context, command, path string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", errors.New("no transcription command set")
+	}
+	ctx, cancel := context.WithTimeout(ctx, TranscribeTimeout)
+	defer cancel()
+
+	script := strings.ReplaceAll(command, FilePlaceholder, shellQuote(path))
+	if !strings.Contains(command, FilePlaceholder) {
+		script += " " + shellQuote(path)
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("transcription failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("transcription failed: %w", err)
+	}
+	return cleanTranscript(string(output)), nil
+}
+
+// shellQuote quotes s as a single sh word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// nonSpeech matches the markers recognizers print for sounds that aren't
+// speech, such as "[BLANK_AUDIO]" or "(music)", and whisper.cpp timestamps.
+var nonSpeech = regexp.MustCompile(`\[[^\]]*\]|\([A-Za-z _]+\)`)
+
+// cleanTranscript joins the lines of a transcript and drops what isn't
+// speech.
+func cleanTranscript(text string) string {
+	text = nonSpeech.ReplaceAllString(text, " ")
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,113 @@
+package speech
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscribe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "it's a recording.wav")
+	if err := os.WriteFile(path, []byte("hello from the mic"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Transcribe(context.Background(), "cat {file}; echo '[BLANK_AUDIO]'", path)
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if got != "hello from the mic" {
+		t.Errorf("Transcribe() = %q, want %q", got, "hello from the mic")
+	}
+
+	// Without the placeholder the path is appended
+	got, err = Transcribe(context.Background(), "cat", path)
+	if err != nil || got != "hello from the mic" {
+		t.Errorf("Transcribe() without placeholder = %q, %v", got, err)
+	}
+}
+
+func TestTranscribe_Failure(t *testing.T) {
+	_, err := Transcribe(context.Background(), "echo 'model not found' >&2; exit 1", "x.wav")
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("Transcribe() error = %v, want the command's message", err)
+	}
+
+	if _, err := Transcribe(context.Background(), " ", "x.wav"); err == nil {
+		t.Error("Transcribe() with no command should fail")
+	}
+}
+
+func TestCleanTranscript(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{" Hello there.\n How are you?\n", "Hello there. How are you?"},
+		{"[00:00:00.000 --> 00:00:02.000]  Hi\n", "Hi"},
+		{"[BLANK_AUDIO]\n", ""},
+		{"(music) Play it again (3 times)", "Play it again (3 times)"},
+	}
+	for _, tt := range tests {
+		if got := cleanTranscript(tt.in); got != tt.want {
+			t.Errorf("cleanTranscript(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRecorder_Unavailable(t *testing.T) {
+	r := &Recorder{}
+	if r.Available() {
+		t.Error("Available() = true without a recording command")
+	}
+	if _, err := r.Start(); err != ErrNoRecorder {
+		t.Errorf("Start() error = %v, want ErrNoRecorder", err)
+	}
+}
+
+func TestRecorder_StartStop(t *testing.T) {
+	// Writes the file when interrupted, as recorders finish the WAV header
+	script := filepath.Join(t.TempDir(), "record")
+	body := "#!/bin/sh\ntrap 'printf RIFF > \"$1\"; exit 130' INT\nwhile :; do sleep 0.05; done\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r := &Recorder{rec: &recorder{command: script, args: func(path string) []string { return []string{path} }}}
+
+	rec, err := r.Start()
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer os.Remove(rec.Path)
+
+	// Give the script time to set its trap
+	time.Sleep(200 * time.Millisecond)
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	data, err := os.ReadFile(rec.Path)
+	if err != nil || string(data) != "RIFF" {
+		t.Errorf("recording = %q, %v, want %q", data, err, "RIFF")
+	}
+}
+
+func TestRecorder_EndedEarly(t *testing.T) {
+	r := &Recorder{rec: &recorder{command: "sh", args: func(string) []string {
+		return []string{"-c", "echo 'no such device' >&2; exit 1"}
+	}}}
+
+	rec, err := r.Start()
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer os.Remove(rec.Path)
+
+	// Let the recorder fail before stopping it
+	time.Sleep(200 * time.Millisecond)
+	if err := rec.Stop(); err == nil || !strings.Contains(err.Error(), "no such device") {
+		t.Errorf("Stop() error = %v, want the recorder's message", err)
+	}
+}
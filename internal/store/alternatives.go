@@ -0,0 +1,57 @@
+package store
+
+import "fmt"
+
+// UpdateMessageReplaces records that a message is a regenerated version of
+// the response replaces, which stays in the database superseded.
+func (d *DB) UpdateMessageReplaces(id, replaces int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("UPDATE messages SET replaces = ? WHERE id = ?", replaces, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message replaces: %w", err)
+	}
+	return nil
+}
+
+// GetAlternatives returns the earlier responses that a regenerated message
+// replaced, one after another, oldest first.
+func (d *DB) GetAlternatives(messageID int64) ([]*Message, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	rows, err := d.db.Query(`
+		WITH RECURSIVE earlier(id) AS (
+			SELECT replaces FROM messages WHERE id = ? AND replaces != 0
+			UNION
+			SELECT m.replaces FROM messages m JOIN earlier e ON m.id = e.id WHERE m.replaces != 0
+		)
+		SELECT id, chat_id, role, content, critique, truncated, replaces, created_at
+		FROM messages WHERE id IN (SELECT id FROM earlier) ORDER BY id ASC
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alternatives: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		msg := &Message{}
+		err := rows.Scan(
+			&msg.ID,
+			&msg.ChatID,
+			&msg.Role,
+			&msg.Content,
+			&msg.Critique,
+			&msg.Truncated,
+			&msg.Replaces,
+			&msg.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
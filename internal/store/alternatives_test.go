@@ -0,0 +1,48 @@
+package store
+
+import "testing"
+
+func TestDB_GetAlternatives(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	db.AddMessage(chat.ID, RoleUser, "Name a color")
+	first, _ := db.AddMessage(chat.ID, RoleAssistant, "Blue")
+	db.AddMessage(chat.ID, RoleUser, "Another one")
+	db.AddMessage(chat.ID, RoleAssistant, "Green")
+
+	// Regenerating the first response twice
+	db.SupersedeMessagesFrom(chat.ID, first.ID)
+	second, _ := db.AddMessage(chat.ID, RoleAssistant, "Red")
+	if err := db.UpdateMessageReplaces(second.ID, first.ID); err != nil {
+		t.Fatalf("UpdateMessageReplaces() error = %v", err)
+	}
+	db.SupersedeMessagesFrom(chat.ID, second.ID)
+	third, _ := db.AddMessage(chat.ID, RoleAssistant, "Yellow")
+	db.UpdateMessageReplaces(third.ID, second.ID)
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 2 || messages[1].Replaces != second.ID {
+		t.Fatalf("GetMessages() = %+v, want the prompt and the last response replacing %d", messages, second.ID)
+	}
+
+	alternatives, err := db.GetAlternatives(third.ID)
+	if err != nil {
+		t.Fatalf("GetAlternatives() error = %v", err)
+	}
+	if len(alternatives) != 2 || alternatives[0].Content != "Blue" || alternatives[1].Content != "Red" {
+		t.Errorf("GetAlternatives() = %+v, want Blue and Red", alternatives)
+	}
+
+	alternatives, err = db.GetAlternatives(first.ID)
+	if err != nil {
+		t.Fatalf("GetAlternatives() error = %v", err)
+	}
+	if len(alternatives) != 0 {
+		t.Errorf("GetAlternatives() = %+v, want none for a response that replaced nothing", alternatives)
+	}
+}
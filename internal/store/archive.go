@@ -0,0 +1,62 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// InactiveChats returns the chats that are not archived and haven't been
+// changed or written in since cutoff, most recently used first. A chat is
+// last used when its latest message was written or, if later, when it was
+// last changed.
+func (d *DB) InactiveChats(cutoff time.Time) ([]*Chat, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	chats, err := d.listChats()
+	if err != nil {
+		return nil, err
+	}
+
+	var inactive []*Chat
+	for _, chat := range chats {
+		if chat.Archived || chat.UpdatedAt.After(cutoff) {
+			continue
+		}
+		if len(d.pendingMessages(chat.ID)) > 0 {
+			continue
+		}
+
+		var last time.Time
+		err := d.db.QueryRow(
+			"SELECT created_at FROM messages WHERE chat_id = ? ORDER BY id DESC LIMIT 1",
+			chat.ID,
+		).Scan(&last)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get last message: %w", err)
+		}
+		// Compared here: timestamps are stored as text with their zone,
+		// which doesn't compare reliably in SQL
+		if last.After(cutoff) {
+			continue
+		}
+		inactive = append(inactive, chat)
+	}
+	return inactive, nil
+}
+
+// SetChatsArchived archives or unarchives the chats with the given IDs.
+// Archived chats are left out of the chat list until they are shown, and a
+// chat is unarchived when a message is saved in it. The update time of the
+// chats is kept, so that unarchiving doesn't move them to the top.
+func (d *DB) SetChatsArchived(ids []int64, archived bool) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if _, err := tx.Exec("UPDATE chats SET archived = ? WHERE id = ?", archived, id); err != nil {
+				return fmt.Errorf("failed to archive chat: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDB_InactiveChats(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -60)
+
+	stale, _ := db.CreateChat("llama3")
+	db.AddMessage(stale.ID, RoleUser, "Old question")
+	db.db.Exec("UPDATE messages SET created_at = ? WHERE chat_id = ?", old, stale.ID)
+
+	// Created long ago, but written in today
+	active, _ := db.CreateChat("llama3")
+	db.AddMessage(active.ID, RoleUser, "New question")
+
+	empty, _ := db.CreateChat("llama3")
+	db.db.Exec("UPDATE chats SET created_at = ?, updated_at = ?", old, old)
+
+	chats, err := db.InactiveChats(now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("InactiveChats() error = %v", err)
+	}
+	if len(chats) != 2 {
+		t.Fatalf("InactiveChats() returned %d chats, want 2", len(chats))
+	}
+	for _, chat := range chats {
+		if chat.ID == active.ID {
+			t.Errorf("InactiveChats() returned chat %d, which has a new message", active.ID)
+		}
+	}
+
+	if err := db.SetChatsArchived([]int64{stale.ID, empty.ID}, true); err != nil {
+		t.Fatalf("SetChatsArchived() error = %v", err)
+	}
+	got, _ := db.GetChat(stale.ID)
+	if !got.Archived {
+		t.Error("GetChat() Archived = false after SetChatsArchived(true)")
+	}
+	if !got.UpdatedAt.Equal(old) {
+		t.Errorf("SetChatsArchived() changed UpdatedAt to %v", got.UpdatedAt)
+	}
+	if chats, _ := db.InactiveChats(time.Now()); len(chats) != 1 || chats[0].ID != active.ID {
+		t.Errorf("InactiveChats() = %d chats, want only the chat left unarchived", len(chats))
+	}
+
+	// Writing in an archived chat brings it back
+	if err := db.SaveMessage(&Message{ChatID: stale.ID, Role: RoleUser, Content: "Back again"}, nil); err != nil {
+		t.Fatalf("SaveMessage() error = %v", err)
+	}
+	if got, _ := db.GetChat(stale.ID); got.Archived {
+		t.Error("SaveMessage() left the chat archived")
+	}
+
+	if err := db.SetChatsArchived([]int64{empty.ID}, false); err != nil {
+		t.Fatalf("SetChatsArchived(false) error = %v", err)
+	}
+	if got, _ := db.GetChat(empty.ID); got.Archived {
+		t.Error("SetChatsArchived(false) left the chat archived")
+	}
+}
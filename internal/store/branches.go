@@ -0,0 +1,122 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// CloneChatUpTo creates a branch of a chat: a new chat with the same
+// settings, documents and tags, holding a copy of the messages up to and
+// including messageID. The new chat records chatID as its parent.
+func (d *DB) CloneChatUpTo(chatID, messageID int64) (*Chat, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM messages WHERE id = ? AND chat_id = ? AND superseded = 0",
+		messageID, chatID,
+	).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message: %w", err)
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("message %d is not part of chat %d", messageID, chatID)
+	}
+
+	now := time.Now()
+	result, err := tx.Exec(`
+		INSERT INTO chats (title, model, system_prompt, language, completion_mode, template, keep_alive, output_format, parent_id, title_locked, work_dir, muted, icon, color, created_at, updated_at)
+		SELECT title, model, system_prompt, language, completion_mode, template, keep_alive, output_format, id, title_locked, work_dir, muted, icon, color, ?, ?
+		FROM chats WHERE id = ?
+	`, now, now, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone chat: %w", err)
+	}
+	branchID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	// Read the messages first: the single connection is busy until the
+	// rows are closed
+	rows, err := tx.Query(`
+		SELECT id, role, content, critique, truncated, model_switch, agent, agent_slot, created_at
+		FROM messages WHERE chat_id = ? AND superseded = 0 AND id <= ? ORDER BY created_at ASC
+	`, chatID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	var messages []*Message
+	for rows.Next() {
+		msg := &Message{}
+		if err := rows.Scan(&msg.ID, &msg.Role, &msg.Content, &msg.Critique, &msg.Truncated, &msg.ModelSwitch, &msg.Agent, &msg.AgentSlot, &msg.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, msg := range messages {
+		result, err := tx.Exec(
+			"INSERT INTO messages (chat_id, role, content, critique, truncated, model_switch, agent, agent_slot, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			branchID, msg.Role, msg.Content, msg.Critique, msg.Truncated, msg.ModelSwitch, msg.Agent, msg.AgentSlot, msg.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy message: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO attachments (message_id, filename, content) SELECT ?, filename, content FROM attachments WHERE message_id = ?",
+			id, msg.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy attachments: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO message_stats (message_id, prompt_tokens, eval_tokens, total_duration, load_duration, prompt_duration, eval_duration)
+			SELECT ?, prompt_tokens, eval_tokens, total_duration, load_duration, prompt_duration, eval_duration
+			FROM message_stats WHERE message_id = ?
+		`, id, msg.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy message stats: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO documents (chat_id, filename, content, created_at) SELECT ?, filename, content, created_at FROM documents WHERE chat_id = ? ORDER BY id ASC",
+		branchID, chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy documents: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO chat_tags (chat_id, tag_id) SELECT ?, tag_id FROM chat_tags WHERE chat_id = ?",
+		branchID, chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy tags: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit branch: %w", err)
+	}
+
+	return d.getChat(branchID)
+}
@@ -0,0 +1,84 @@
+package store
+
+import "testing"
+
+func TestDB_CloneChatUpTo(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	db.UpdateChatTitle(chat.ID, "Trip ideas")
+	db.UpdateChatSystemPrompt(chat.ID, "Be brief")
+	db.UpdateChatLabel(chat.ID, "🏖", "blue")
+	db.AddDocument(chat.ID, "guide.md", "# Guide")
+	travel, _ := db.AddTag("travel")
+	db.TagChat(chat.ID, travel.ID)
+
+	first, _ := db.AddMessage(chat.ID, RoleUser, "Where should I go?")
+	db.AddAttachment(first.ID, "map.txt", "Coast")
+	answer, _ := db.AddMessage(chat.ID, RoleAssistant, "Try the coast.")
+	db.UpdateMessageCritique(answer.ID, "Too short")
+	db.AddMessage(chat.ID, RoleUser, "And in winter?")
+	db.AddMessage(chat.ID, RoleAssistant, "The mountains.")
+
+	branch, err := db.CloneChatUpTo(chat.ID, answer.ID)
+	if err != nil {
+		t.Fatalf("CloneChatUpTo() error = %v", err)
+	}
+	if branch.ID == chat.ID || branch.ParentID != chat.ID {
+		t.Errorf("CloneChatUpTo() = chat %d with parent %d, want a new chat with parent %d", branch.ID, branch.ParentID, chat.ID)
+	}
+	if branch.Title != "Trip ideas" || branch.SystemPrompt != "Be brief" || branch.Model != "llama3" || branch.Icon != "🏖" || branch.Color != "blue" {
+		t.Errorf("CloneChatUpTo() = %+v, want the settings of the original chat", branch)
+	}
+
+	messages, _ := db.GetMessages(branch.ID)
+	if len(messages) != 2 || messages[0].Content != "Where should I go?" || messages[1].Content != "Try the coast." {
+		t.Fatalf("GetMessages(branch) = %+v, want the first two messages", messages)
+	}
+	if messages[1].Critique != "Too short" {
+		t.Errorf("branch critique = %q, want %q", messages[1].Critique, "Too short")
+	}
+
+	attachments, _ := db.GetMessageAttachments(messages[0].ID)
+	if len(attachments) != 1 || attachments[0].Filename != "map.txt" {
+		t.Errorf("branch attachments = %+v, want map.txt", attachments)
+	}
+	docs, _ := db.ListDocuments(branch.ID)
+	if len(docs) != 1 || docs[0].Filename != "guide.md" {
+		t.Errorf("branch documents = %+v, want guide.md", docs)
+	}
+	chatTags, _ := db.ListChatTags()
+	if tags := chatTags[branch.ID]; len(tags) != 1 || tags[0].Name != "travel" {
+		t.Errorf("branch tags = %+v, want travel", tags)
+	}
+
+	// The original chat is left as it was
+	original, _ := db.GetMessages(chat.ID)
+	if len(original) != 4 {
+		t.Errorf("GetMessages(original) = %d messages, want 4", len(original))
+	}
+}
+
+func TestDB_CloneChatUpToOtherChat(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	other, _ := db.CreateChat("llama3")
+	msg, _ := db.AddMessage(other.ID, RoleUser, "Hello")
+
+	if _, err := db.CloneChatUpTo(chat.ID, msg.ID); err == nil {
+		t.Error("CloneChatUpTo() with a message of another chat should fail")
+	}
+	chats, _ := db.ListChats()
+	if len(chats) != 2 {
+		t.Errorf("ListChats() = %d chats, want 2", len(chats))
+	}
+}
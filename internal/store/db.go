@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -11,12 +12,25 @@ import (
 
 const schema = `
 CREATE TABLE IF NOT EXISTS chats (
-    id            INTEGER PRIMARY KEY AUTOINCREMENT,
-    title         TEXT NOT NULL DEFAULT 'New Chat',
-    model         TEXT NOT NULL,
-    system_prompt TEXT NOT NULL DEFAULT '',
-    created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    title           TEXT NOT NULL DEFAULT 'New Chat',
+    model           TEXT NOT NULL,
+    system_prompt   TEXT NOT NULL DEFAULT '',
+    language        TEXT NOT NULL DEFAULT '',
+    completion_mode TEXT NOT NULL DEFAULT '',
+    template        TEXT NOT NULL DEFAULT '',
+    keep_alive      TEXT NOT NULL DEFAULT '',
+    output_format   TEXT NOT NULL DEFAULT '',
+    parent_id       INTEGER NOT NULL DEFAULT 0,
+    title_locked    INTEGER NOT NULL DEFAULT 0,
+    work_dir        TEXT NOT NULL DEFAULT '',
+    archived        INTEGER NOT NULL DEFAULT 0,
+    import_id       TEXT NOT NULL DEFAULT '',
+    muted           INTEGER NOT NULL DEFAULT 0,
+    icon            TEXT NOT NULL DEFAULT '',
+    color           TEXT NOT NULL DEFAULT '',
+    created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE TABLE IF NOT EXISTS messages (
@@ -24,6 +38,16 @@ CREATE TABLE IF NOT EXISTS messages (
     chat_id     INTEGER NOT NULL,
     role        TEXT NOT NULL CHECK(role IN ('user', 'assistant', 'system')),
     content     TEXT NOT NULL,
+    critique    TEXT NOT NULL DEFAULT '',
+    superseded  INTEGER NOT NULL DEFAULT 0,
+    truncated   INTEGER NOT NULL DEFAULT 0,
+    replaces    INTEGER NOT NULL DEFAULT 0,
+    bookmarked  INTEGER NOT NULL DEFAULT 0,
+    rating      INTEGER NOT NULL DEFAULT 0,
+    annotation  TEXT NOT NULL DEFAULT '',
+    model_switch INTEGER NOT NULL DEFAULT 0,
+    agent       TEXT NOT NULL DEFAULT '',
+    agent_slot  INTEGER NOT NULL DEFAULT 0,
     created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE
 );
@@ -36,35 +60,162 @@ CREATE TABLE IF NOT EXISTS attachments (
     FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS documents (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id     INTEGER NOT NULL,
+    filename    TEXT NOT NULL,
+    content     TEXT NOT NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS message_stats (
+    message_id       INTEGER PRIMARY KEY,
+    prompt_tokens    INTEGER NOT NULL DEFAULT 0,
+    eval_tokens      INTEGER NOT NULL DEFAULT 0,
+    total_duration   INTEGER NOT NULL DEFAULT 0,
+    load_duration    INTEGER NOT NULL DEFAULT 0,
+    prompt_duration  INTEGER NOT NULL DEFAULT 0,
+    eval_duration    INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name        TEXT NOT NULL,
+    content     TEXT NOT NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name        TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS chat_tags (
+    chat_id     INTEGER NOT NULL,
+    tag_id      INTEGER NOT NULL,
+    PRIMARY KEY (chat_id, tag_id),
+    FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS drafts (
+    chat_id     INTEGER PRIMARY KEY,
+    content     TEXT NOT NULL DEFAULT '',
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS draft_attachments (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id     INTEGER NOT NULL,
+    filename    TEXT NOT NULL,
+    content     TEXT NOT NULL,
+    FOREIGN KEY (chat_id) REFERENCES drafts(chat_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS history_summaries (
+    chat_id     INTEGER PRIMARY KEY,
+    content     TEXT NOT NULL,
+    through_id  INTEGER NOT NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE
+);
+
 CREATE INDEX IF NOT EXISTS idx_messages_chat_id ON messages(chat_id);
 CREATE INDEX IF NOT EXISTS idx_attachments_message_id ON attachments(message_id);
+CREATE INDEX IF NOT EXISTS idx_documents_chat_id ON documents(chat_id);
 CREATE INDEX IF NOT EXISTS idx_chats_updated_at ON chats(updated_at DESC);
 CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at);
+CREATE INDEX IF NOT EXISTS idx_chat_tags_tag_id ON chat_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_draft_attachments_chat_id ON draft_attachments(chat_id);
 `
 
-// migration adds new columns to existing databases
-const migration = `
--- Add system_prompt column if it doesn't exist
-ALTER TABLE chats ADD COLUMN system_prompt TEXT NOT NULL DEFAULT '';
-`
+// migrations add new columns to existing databases.
+// Each statement runs on its own so a column that already exists
+// doesn't prevent the following ones from being applied.
+var migrations = []string{
+	`ALTER TABLE chats ADD COLUMN system_prompt TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN language TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN completion_mode TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN template TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN keep_alive TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN parent_id INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE chats ADD COLUMN title_locked INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE chats ADD COLUMN work_dir TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN archived INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE chats ADD COLUMN import_id TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN muted INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE chats ADD COLUMN icon TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN color TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN output_format TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE messages ADD COLUMN critique TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE messages ADD COLUMN superseded INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE messages ADD COLUMN truncated INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE messages ADD COLUMN replaces INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE messages ADD COLUMN bookmarked INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE messages ADD COLUMN rating INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE messages ADD COLUMN annotation TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE messages ADD COLUMN model_switch INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE messages ADD COLUMN agent TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE messages ADD COLUMN agent_slot INTEGER NOT NULL DEFAULT 0`,
+}
 
 // DB wraps the SQLite database connection.
 type DB struct {
-	db *sql.DB
+	db   *sql.DB
+	path string
+
+	// Held for reading while the connection and statements are in use, and
+	// for writing while Reconnect or Close replaces them
+	connMu sync.RWMutex
+
+	// Messages that couldn't be written, kept until FlushPending succeeds
+	pendingMu sync.Mutex
+	pending   []*PendingMessage
 
 	// Prepared statements for performance
-	stmtCreateChat            *sql.Stmt
-	stmtGetChat               *sql.Stmt
-	stmtListChats             *sql.Stmt
-	stmtUpdateChatTitle       *sql.Stmt
+	stmtCreateChat             *sql.Stmt
+	stmtGetChat                *sql.Stmt
+	stmtListChats              *sql.Stmt
+	stmtUpdateChatTitle        *sql.Stmt
+	stmtRenameChat             *sql.Stmt
 	stmtUpdateChatSystemPrompt *sql.Stmt
-	stmtDeleteChat            *sql.Stmt
-	stmtAddMessage            *sql.Stmt
-	stmtGetMessages           *sql.Stmt
+	stmtUpdateChatLanguage     *sql.Stmt
+	stmtUpdateChatCompletion   *sql.Stmt
+	stmtDeleteChat             *sql.Stmt
+	stmtAddMessage             *sql.Stmt
+	stmtGetMessages            *sql.Stmt
+	stmtUpdateMessageCritique  *sql.Stmt
+	stmtSupersedeMessagesFrom  *sql.Stmt
+	stmtUpdateMessageContent   *sql.Stmt
+	stmtDeleteMessagesAfter    *sql.Stmt
+	stmtUpdateMessageTruncated *sql.Stmt
 }
 
 // NewDB creates a new database connection and initializes the schema.
 func NewDB(path string) (*DB, error) {
+	sqlDB, err := openDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{db: sqlDB, path: path}
+
+	// Prepare statements
+	if err := db.prepareStatements(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// openDB opens the database file and brings its schema up to date.
+func openDB(path string) (*sql.DB, error) {
 	sqlDB, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -79,6 +230,22 @@ func NewDB(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
+	// Wait for other processes holding a lock, such as a second instance,
+	// instead of failing at once
+	if _, err := sqlDB.Exec("PRAGMA busy_timeout = 5000"); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
+	}
+
+	// Write-ahead logging makes each commit a single append, and lets another
+	// process, such as a second instance, read while a message is written.
+	// Within this process the single connection still serializes access.
+	// In-memory databases keep their own journal mode.
+	if _, err := sqlDB.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to enable write-ahead logging: %w", err)
+	}
+
 	// Create schema
 	if _, err := sqlDB.Exec(schema); err != nil {
 		sqlDB.Close()
@@ -86,17 +253,11 @@ func NewDB(path string) (*DB, error) {
 	}
 
 	// Run migrations (ignore errors for columns that already exist)
-	sqlDB.Exec(migration)
-
-	db := &DB{db: sqlDB}
-
-	// Prepare statements
-	if err := db.prepareStatements(); err != nil {
-		sqlDB.Close()
-		return nil, err
+	for _, m := range migrations {
+		sqlDB.Exec(m)
 	}
 
-	return db, nil
+	return sqlDB, nil
 }
 
 func (d *DB) prepareStatements() error {
@@ -111,7 +272,7 @@ func (d *DB) prepareStatements() error {
 	}
 
 	d.stmtGetChat, err = d.db.Prepare(`
-		SELECT id, title, model, system_prompt, created_at, updated_at
+		SELECT id, title, model, system_prompt, language, completion_mode, template, keep_alive, output_format, parent_id, title_locked, work_dir, archived, muted, icon, color, created_at, updated_at
 		FROM chats WHERE id = ?
 	`)
 	if err != nil {
@@ -119,7 +280,7 @@ func (d *DB) prepareStatements() error {
 	}
 
 	d.stmtListChats, err = d.db.Prepare(`
-		SELECT id, title, model, system_prompt, created_at, updated_at
+		SELECT id, title, model, system_prompt, language, completion_mode, template, keep_alive, output_format, parent_id, title_locked, work_dir, archived, muted, icon, color, created_at, updated_at
 		FROM chats ORDER BY updated_at DESC
 	`)
 	if err != nil {
@@ -127,12 +288,19 @@ func (d *DB) prepareStatements() error {
 	}
 
 	d.stmtUpdateChatTitle, err = d.db.Prepare(`
-		UPDATE chats SET title = ?, updated_at = ? WHERE id = ?
+		UPDATE chats SET title = ?, updated_at = ? WHERE id = ? AND title_locked = 0
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare UpdateChatTitle: %w", err)
 	}
 
+	d.stmtRenameChat, err = d.db.Prepare(`
+		UPDATE chats SET title = ?, title_locked = 1, updated_at = ? WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare RenameChat: %w", err)
+	}
+
 	d.stmtUpdateChatSystemPrompt, err = d.db.Prepare(`
 		UPDATE chats SET system_prompt = ?, updated_at = ? WHERE id = ?
 	`)
@@ -140,6 +308,20 @@ func (d *DB) prepareStatements() error {
 		return fmt.Errorf("failed to prepare UpdateChatSystemPrompt: %w", err)
 	}
 
+	d.stmtUpdateChatLanguage, err = d.db.Prepare(`
+		UPDATE chats SET language = ?, updated_at = ? WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare UpdateChatLanguage: %w", err)
+	}
+
+	d.stmtUpdateChatCompletion, err = d.db.Prepare(`
+		UPDATE chats SET completion_mode = ?, template = ?, keep_alive = ?, output_format = ?, updated_at = ? WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare UpdateChatCompletion: %w", err)
+	}
+
 	d.stmtDeleteChat, err = d.db.Prepare(`DELETE FROM chats WHERE id = ?`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare DeleteChat: %w", err)
@@ -154,19 +336,62 @@ func (d *DB) prepareStatements() error {
 	}
 
 	d.stmtGetMessages, err = d.db.Prepare(`
-		SELECT id, chat_id, role, content, created_at
-		FROM messages WHERE chat_id = ? ORDER BY created_at ASC
+		SELECT id, chat_id, role, content, critique, truncated, replaces, bookmarked, rating, annotation, model_switch, agent, agent_slot, created_at
+		FROM messages WHERE chat_id = ? AND superseded = 0 ORDER BY created_at ASC
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare GetMessages: %w", err)
 	}
 
+	d.stmtUpdateMessageCritique, err = d.db.Prepare(`
+		UPDATE messages SET critique = ? WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare UpdateMessageCritique: %w", err)
+	}
+
+	d.stmtSupersedeMessagesFrom, err = d.db.Prepare(`
+		UPDATE messages SET superseded = 1 WHERE chat_id = ? AND id >= ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare SupersedeMessagesFrom: %w", err)
+	}
+
+	d.stmtUpdateMessageContent, err = d.db.Prepare(`
+		UPDATE messages SET content = ? WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare UpdateMessageContent: %w", err)
+	}
+
+	d.stmtDeleteMessagesAfter, err = d.db.Prepare(`
+		DELETE FROM messages WHERE chat_id = ? AND id > ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare DeleteMessagesAfter: %w", err)
+	}
+
+	d.stmtUpdateMessageTruncated, err = d.db.Prepare(`
+		UPDATE messages SET truncated = ? WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare UpdateMessageTruncated: %w", err)
+	}
+
 	return nil
 }
 
 // Close closes the database connection.
 func (d *DB) Close() error {
-	// Close prepared statements
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	d.closeStatements()
+	return d.db.Close()
+}
+
+// closeStatements closes the prepared statements.
+func (d *DB) closeStatements() {
 	if d.stmtCreateChat != nil {
 		d.stmtCreateChat.Close()
 	}
@@ -179,9 +404,18 @@ func (d *DB) Close() error {
 	if d.stmtUpdateChatTitle != nil {
 		d.stmtUpdateChatTitle.Close()
 	}
+	if d.stmtRenameChat != nil {
+		d.stmtRenameChat.Close()
+	}
 	if d.stmtUpdateChatSystemPrompt != nil {
 		d.stmtUpdateChatSystemPrompt.Close()
 	}
+	if d.stmtUpdateChatLanguage != nil {
+		d.stmtUpdateChatLanguage.Close()
+	}
+	if d.stmtUpdateChatCompletion != nil {
+		d.stmtUpdateChatCompletion.Close()
+	}
 	if d.stmtDeleteChat != nil {
 		d.stmtDeleteChat.Close()
 	}
@@ -191,12 +425,28 @@ func (d *DB) Close() error {
 	if d.stmtGetMessages != nil {
 		d.stmtGetMessages.Close()
 	}
-
-	return d.db.Close()
+	if d.stmtUpdateMessageCritique != nil {
+		d.stmtUpdateMessageCritique.Close()
+	}
+	if d.stmtSupersedeMessagesFrom != nil {
+		d.stmtSupersedeMessagesFrom.Close()
+	}
+	if d.stmtUpdateMessageContent != nil {
+		d.stmtUpdateMessageContent.Close()
+	}
+	if d.stmtDeleteMessagesAfter != nil {
+		d.stmtDeleteMessagesAfter.Close()
+	}
+	if d.stmtUpdateMessageTruncated != nil {
+		d.stmtUpdateMessageTruncated.Close()
+	}
 }
 
 // CreateChat creates a new chat with the given model.
 func (d *DB) CreateChat(model string) (*Chat, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	now := time.Now()
 	chat := NewChat(model)
 	chat.CreatedAt = now
@@ -218,12 +468,32 @@ func (d *DB) CreateChat(model string) (*Chat, error) {
 
 // GetChat retrieves a chat by ID.
 func (d *DB) GetChat(id int64) (*Chat, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	return d.getChat(id)
+}
+
+// getChat retrieves a chat by ID, with connMu held.
+func (d *DB) getChat(id int64) (*Chat, error) {
 	chat := &Chat{}
 	err := d.stmtGetChat.QueryRow(id).Scan(
 		&chat.ID,
 		&chat.Title,
 		&chat.Model,
 		&chat.SystemPrompt,
+		&chat.Language,
+		&chat.CompletionMode,
+		&chat.Template,
+		&chat.KeepAlive,
+		&chat.OutputFormat,
+		&chat.ParentID,
+		&chat.TitleLocked,
+		&chat.WorkDir,
+		&chat.Archived,
+		&chat.Muted,
+		&chat.Icon,
+		&chat.Color,
 		&chat.CreatedAt,
 		&chat.UpdatedAt,
 	)
@@ -235,6 +505,14 @@ func (d *DB) GetChat(id int64) (*Chat, error) {
 
 // ListChats returns all chats ordered by update time (most recent first).
 func (d *DB) ListChats() ([]*Chat, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	return d.listChats()
+}
+
+// listChats returns all chats, with connMu held.
+func (d *DB) listChats() ([]*Chat, error) {
 	rows, err := d.stmtListChats.Query()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list chats: %w", err)
@@ -249,6 +527,18 @@ func (d *DB) ListChats() ([]*Chat, error) {
 			&chat.Title,
 			&chat.Model,
 			&chat.SystemPrompt,
+			&chat.Language,
+			&chat.CompletionMode,
+			&chat.Template,
+			&chat.KeepAlive,
+			&chat.OutputFormat,
+			&chat.ParentID,
+			&chat.TitleLocked,
+			&chat.WorkDir,
+			&chat.Archived,
+			&chat.Muted,
+			&chat.Icon,
+			&chat.Color,
 			&chat.CreatedAt,
 			&chat.UpdatedAt,
 		)
@@ -261,8 +551,12 @@ func (d *DB) ListChats() ([]*Chat, error) {
 	return chats, rows.Err()
 }
 
-// UpdateChatTitle updates the title of a chat.
+// UpdateChatTitle updates the title of a chat. Chats renamed by the user
+// keep their title, so generated titles don't replace it.
 func (d *DB) UpdateChatTitle(id int64, title string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	_, err := d.stmtUpdateChatTitle.Exec(title, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update chat title: %w", err)
@@ -270,8 +564,24 @@ func (d *DB) UpdateChatTitle(id int64, title string) error {
 	return nil
 }
 
+// RenameChat sets the title of a chat chosen by the user, which is no
+// longer replaced by generated titles.
+func (d *DB) RenameChat(id int64, title string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtRenameChat.Exec(title, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to rename chat: %w", err)
+	}
+	return nil
+}
+
 // UpdateChatSystemPrompt updates the system prompt of a chat.
 func (d *DB) UpdateChatSystemPrompt(id int64, systemPrompt string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	_, err := d.stmtUpdateChatSystemPrompt.Exec(systemPrompt, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update chat system prompt: %w", err)
@@ -279,17 +589,90 @@ func (d *DB) UpdateChatSystemPrompt(id int64, systemPrompt string) error {
 	return nil
 }
 
+// UpdateChatLanguage updates the response language override of a chat.
+// An empty language means the global setting applies.
+func (d *DB) UpdateChatLanguage(id int64, language string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtUpdateChatLanguage.Exec(language, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update chat language: %w", err)
+	}
+	return nil
+}
+
+// UpdateChatWorkDir sets the folder whose files the model may read in a
+// chat. An empty dir takes the access away.
+func (d *DB) UpdateChatWorkDir(id int64, dir string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec(`UPDATE chats SET work_dir = ?, updated_at = ? WHERE id = ?`, dir, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update chat working folder: %w", err)
+	}
+	return nil
+}
+
+// UpdateChatMuted sets whether the responses of a chat are kept from being
+// read aloud.
+func (d *DB) UpdateChatMuted(id int64, muted bool) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("UPDATE chats SET muted = ? WHERE id = ?", muted, id)
+	if err != nil {
+		return fmt.Errorf("failed to update chat mute: %w", err)
+	}
+	return nil
+}
+
+// UpdateChatLabel sets the emoji and the color name a chat is marked with
+// in the chat list. Empty ones remove the mark.
+func (d *DB) UpdateChatLabel(id int64, icon, color string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("UPDATE chats SET icon = ?, color = ? WHERE id = ?", icon, color, id)
+	if err != nil {
+		return fmt.Errorf("failed to update chat label: %w", err)
+	}
+	return nil
+}
+
+// UpdateChatCompletion updates how the responses of a chat are requested:
+// the completion mode, the template override, the keep-alive duration and
+// the output format.
+func (d *DB) UpdateChatCompletion(id int64, mode CompletionMode, template, keepAlive, outputFormat string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtUpdateChatCompletion.Exec(mode, template, keepAlive, outputFormat, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update chat completion settings: %w", err)
+	}
+	return nil
+}
+
 // DeleteChat deletes a chat and its messages (cascade).
 func (d *DB) DeleteChat(id int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	_, err := d.stmtDeleteChat.Exec(id)
 	if err != nil {
 		return fmt.Errorf("failed to delete chat: %w", err)
 	}
+	d.discardPending(id)
 	return nil
 }
 
 // AddMessage adds a message to a chat.
 func (d *DB) AddMessage(chatID int64, role Role, content string) (*Message, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	now := time.Now()
 	msg := &Message{
 		ChatID:    chatID,
@@ -312,8 +695,104 @@ func (d *DB) AddMessage(chatID int64, role Role, content string) (*Message, erro
 	return msg, nil
 }
 
+// SaveMessage writes a message with its attachments, critique, truncated
+// flag, replaced response and statistics in one transaction, so that either
+// all of it is stored or none of it is. The IDs of msg and of the
+// attachments are set once it is saved.
+func (d *DB) SaveMessage(msg *Message, attachments []Attachment) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	return d.saveMessage(msg, attachments)
+}
+
+// saveMessage writes a message like SaveMessage, with connMu held.
+func (d *DB) saveMessage(msg *Message, attachments []Attachment) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+
+	var id int64
+	err := d.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			"INSERT INTO messages (chat_id, role, content, critique, truncated, replaces, agent, agent_slot, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			msg.ChatID, msg.Role, msg.Content, msg.Critique, msg.Truncated, msg.Replaces, msg.Agent, msg.AgentSlot, msg.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add message: %w", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		for _, a := range attachments {
+			_, err := tx.Exec(
+				"INSERT INTO attachments (message_id, filename, content) VALUES (?, ?, ?)",
+				id, a.Filename, a.Content,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to add attachment: %w", err)
+			}
+		}
+
+		// A chat that gets new messages is in use again
+		if _, err := tx.Exec("UPDATE chats SET archived = 0 WHERE id = ?", msg.ChatID); err != nil {
+			return fmt.Errorf("failed to unarchive chat: %w", err)
+		}
+
+		if msg.Stats != nil {
+			stats := *msg.Stats
+			stats.MessageID = id
+			return saveMessageStats(tx, &stats)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg.ID = id
+	if msg.Stats != nil {
+		msg.Stats.MessageID = id
+	}
+	for i := range attachments {
+		attachments[i].MessageID = id
+	}
+	return nil
+}
+
+// WithTx runs fn in a transaction, which is committed if fn succeeds and
+// rolled back if it returns an error.
+func (d *DB) WithTx(fn func(tx *sql.Tx) error) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	return d.withTx(fn)
+}
+
+// withTx runs fn in a transaction like WithTx, with connMu held.
+func (d *DB) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // GetMessages retrieves all messages for a chat in chronological order.
 func (d *DB) GetMessages(chatID int64) ([]*Message, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	rows, err := d.stmtGetMessages.Query(chatID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get messages: %w", err)
@@ -328,6 +807,15 @@ func (d *DB) GetMessages(chatID int64) ([]*Message, error) {
 			&msg.ChatID,
 			&msg.Role,
 			&msg.Content,
+			&msg.Critique,
+			&msg.Truncated,
+			&msg.Replaces,
+			&msg.Bookmarked,
+			&msg.Rating,
+			&msg.Annotation,
+			&msg.ModelSwitch,
+			&msg.Agent,
+			&msg.AgentSlot,
 			&msg.CreatedAt,
 		)
 		if err != nil {
@@ -335,12 +823,133 @@ func (d *DB) GetMessages(chatID int64) ([]*Message, error) {
 		}
 		messages = append(messages, msg)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Messages waiting to be saved are newer than the stored ones
+	return append(messages, d.pendingMessages(chatID)...), nil
+}
+
+// UpdateMessageCritique stores the self-review critique of an assistant message.
+func (d *DB) UpdateMessageCritique(id int64, critique string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtUpdateMessageCritique.Exec(critique, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message critique: %w", err)
+	}
+	return nil
+}
+
+// SupersedeMessagesFrom hides a message and every later message of the chat,
+// e.g. when a response is regenerated. Superseded messages stay in the
+// database but are no longer returned by GetMessages.
+func (d *DB) SupersedeMessagesFrom(chatID, messageID int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtSupersedeMessagesFrom.Exec(chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to supersede messages: %w", err)
+	}
+	return nil
+}
+
+// UpdateMessageContent replaces the content of a message.
+func (d *DB) UpdateMessageContent(id int64, content string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtUpdateMessageContent.Exec(content, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message content: %w", err)
+	}
+	return nil
+}
+
+// DeleteMessagesAfter deletes every message of the chat that came after the
+// given message, along with their attachments.
+func (d *DB) DeleteMessagesAfter(chatID, messageID int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtDeleteMessagesAfter.Exec(chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+	return nil
+}
+
+// DeleteMessage deletes a message along with its attachments, e.g. a prompt
+// taken back for editing before its response was written.
+func (d *DB) DeleteMessage(id int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("DELETE FROM messages WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	return nil
+}
+
+// UpdateMessageTruncated records whether the generation of a message was
+// stopped before the model finished it.
+func (d *DB) UpdateMessageTruncated(id int64, truncated bool) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.stmtUpdateMessageTruncated.Exec(truncated, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message truncated: %w", err)
+	}
+	return nil
+}
+
+// UpdateMessageBookmarked marks a message as bookmarked or clears the mark.
+func (d *DB) UpdateMessageBookmarked(id int64, bookmarked bool) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("UPDATE messages SET bookmarked = ? WHERE id = ?", bookmarked, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message bookmark: %w", err)
+	}
+	return nil
+}
+
+// UpdateMessageRating sets the rating of a message: RatingUp, RatingDown or
+// RatingNone.
+func (d *DB) UpdateMessageRating(id int64, rating int) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("UPDATE messages SET rating = ? WHERE id = ?", rating, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message rating: %w", err)
+	}
+	return nil
+}
+
+// UpdateMessageAnnotation sets the note the user wrote about a message.
+func (d *DB) UpdateMessageAnnotation(id int64, annotation string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
 
-	return messages, rows.Err()
+	_, err := d.db.Exec("UPDATE messages SET annotation = ? WHERE id = ?", annotation, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message annotation: %w", err)
+	}
+	return nil
 }
 
 // AddAttachment saves an attachment for a message.
 func (d *DB) AddAttachment(messageID int64, filename, content string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	_, err := d.db.Exec(
 		"INSERT INTO attachments (message_id, filename, content) VALUES (?, ?, ?)",
 		messageID, filename, content,
@@ -353,6 +962,9 @@ func (d *DB) AddAttachment(messageID int64, filename, content string) error {
 
 // GetMessageAttachments returns attachments for a message.
 func (d *DB) GetMessageAttachments(messageID int64) ([]Attachment, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	rows, err := d.db.Query(
 		"SELECT id, message_id, filename, content FROM attachments WHERE message_id = ?",
 		messageID,
@@ -376,6 +988,9 @@ func (d *DB) GetMessageAttachments(messageID int64) ([]Attachment, error) {
 // GetAttachmentsForMessages returns attachments for multiple messages in a single query.
 // This avoids N+1 queries when loading message history.
 func (d *DB) GetAttachmentsForMessages(messageIDs []int64) (map[int64][]Attachment, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
 	result := make(map[int64][]Attachment)
 	if len(messageIDs) == 0 {
 		return result, nil
@@ -1,6 +1,9 @@
 package store
 
 import (
+	"database/sql"
+	"errors"
+	"path/filepath"
 	"testing"
 )
 
@@ -16,6 +19,30 @@ func TestNewDB(t *testing.T) {
 	}
 }
 
+func TestNewDB_WriteAheadLogging(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "guanaco.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	var mode string
+	if err := db.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatalf("failed to read journal mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+
+	var timeout int
+	if err := db.db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatalf("failed to read busy timeout: %v", err)
+	}
+	if timeout == 0 {
+		t.Error("busy_timeout is not set")
+	}
+}
+
 func TestDB_SchemaCreated(t *testing.T) {
 	db, err := NewDB(":memory:")
 	if err != nil {
@@ -128,6 +155,29 @@ func TestDB_UpdateChatTitle(t *testing.T) {
 	}
 }
 
+func TestDB_RenameChat(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+
+	if err := db.RenameChat(chat.ID, "Trip to Lisbon"); err != nil {
+		t.Fatalf("RenameChat() error = %v", err)
+	}
+	// Generated titles don't replace the user's
+	if err := db.UpdateChatTitle(chat.ID, "Travel Plans"); err != nil {
+		t.Fatalf("UpdateChatTitle() error = %v", err)
+	}
+
+	updated, _ := db.GetChat(chat.ID)
+	if updated.Title != "Trip to Lisbon" || !updated.TitleLocked {
+		t.Errorf("renamed chat = %q (locked %v), want %q locked", updated.Title, updated.TitleLocked, "Trip to Lisbon")
+	}
+}
+
 func TestDB_DeleteChat(t *testing.T) {
 	db, err := NewDB(":memory:")
 	if err != nil {
@@ -277,3 +327,461 @@ func TestDB_GetAttachmentsForMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestDB_UpdateChatLanguage(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	if chat.Language != "" {
+		t.Errorf("CreateChat() language = %q, want empty", chat.Language)
+	}
+
+	if err := db.UpdateChatLanguage(chat.ID, "fr"); err != nil {
+		t.Fatalf("UpdateChatLanguage() error = %v", err)
+	}
+
+	updated, _ := db.GetChat(chat.ID)
+	if updated.Language != "fr" {
+		t.Errorf("UpdateChatLanguage() language = %q, want %q", updated.Language, "fr")
+	}
+}
+
+func TestDB_UpdateChatCompletion(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	if chat.CompletionMode != CompletionChat {
+		t.Errorf("CreateChat() completion mode = %q, want chat", chat.CompletionMode)
+	}
+
+	if err := db.UpdateChatCompletion(chat.ID, CompletionRaw, "{{ .Prompt }}", "10m", "json"); err != nil {
+		t.Fatalf("UpdateChatCompletion() error = %v", err)
+	}
+
+	updated, _ := db.GetChat(chat.ID)
+	if updated.CompletionMode != CompletionRaw || updated.Template != "{{ .Prompt }}" || updated.KeepAlive != "10m" || updated.OutputFormat != "json" {
+		t.Errorf("GetChat() = %+v, want the completion settings", updated)
+	}
+
+	chats, _ := db.ListChats()
+	if len(chats) != 1 || chats[0].CompletionMode != CompletionRaw || chats[0].OutputFormat != "json" {
+		t.Errorf("ListChats() = %+v, want the completion settings", chats)
+	}
+}
+
+func TestDB_UpdateChatWorkDir(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	if err := db.UpdateChatWorkDir(chat.ID, "/home/ana/src/app"); err != nil {
+		t.Fatalf("UpdateChatWorkDir() error = %v", err)
+	}
+
+	updated, _ := db.GetChat(chat.ID)
+	if updated.WorkDir != "/home/ana/src/app" {
+		t.Errorf("GetChat() work dir = %q, want %q", updated.WorkDir, "/home/ana/src/app")
+	}
+	chats, _ := db.ListChats()
+	if len(chats) != 1 || chats[0].WorkDir != "/home/ana/src/app" {
+		t.Errorf("ListChats() = %+v, want the working folder", chats)
+	}
+
+	if err := db.UpdateChatWorkDir(chat.ID, ""); err != nil {
+		t.Fatalf("UpdateChatWorkDir() error = %v", err)
+	}
+	updated, _ = db.GetChat(chat.ID)
+	if updated.WorkDir != "" {
+		t.Errorf("GetChat() work dir = %q, want it removed", updated.WorkDir)
+	}
+}
+
+func TestDB_UpdateChatMuted(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	if err := db.UpdateChatMuted(chat.ID, true); err != nil {
+		t.Fatalf("UpdateChatMuted() error = %v", err)
+	}
+
+	updated, _ := db.GetChat(chat.ID)
+	if !updated.Muted {
+		t.Error("GetChat() muted = false, want true")
+	}
+	chats, _ := db.ListChats()
+	if len(chats) != 1 || !chats[0].Muted {
+		t.Errorf("ListChats() = %+v, want the chat muted", chats)
+	}
+	if !updated.UpdatedAt.Equal(chat.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want it unchanged", updated.UpdatedAt)
+	}
+
+	if err := db.UpdateChatMuted(chat.ID, false); err != nil {
+		t.Fatalf("UpdateChatMuted() error = %v", err)
+	}
+	if updated, _ = db.GetChat(chat.ID); updated.Muted {
+		t.Error("GetChat() muted = true after unmuting")
+	}
+}
+
+func TestDB_UpdateChatLabel(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	if err := db.UpdateChatLabel(chat.ID, "🧪", "green"); err != nil {
+		t.Fatalf("UpdateChatLabel() error = %v", err)
+	}
+
+	updated, _ := db.GetChat(chat.ID)
+	if updated.Icon != "🧪" || updated.Color != "green" {
+		t.Errorf("GetChat() label = %q, %q, want 🧪, green", updated.Icon, updated.Color)
+	}
+	chats, _ := db.ListChats()
+	if len(chats) != 1 || chats[0].Icon != "🧪" || chats[0].Color != "green" {
+		t.Errorf("ListChats() = %+v, want the chat labeled", chats)
+	}
+	if !updated.UpdatedAt.Equal(chat.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want it unchanged", updated.UpdatedAt)
+	}
+
+	if err := db.UpdateChatLabel(chat.ID, "", ""); err != nil {
+		t.Fatalf("UpdateChatLabel() error = %v", err)
+	}
+	if updated, _ = db.GetChat(chat.ID); updated.Icon != "" || updated.Color != "" {
+		t.Errorf("GetChat() label = %q, %q after removing it", updated.Icon, updated.Color)
+	}
+}
+
+func TestDB_UpdateMessageCritique(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg, _ := db.AddMessage(chat.ID, RoleAssistant, "Revised answer")
+
+	if err := db.UpdateMessageCritique(msg.ID, "Missed an edge case"); err != nil {
+		t.Fatalf("UpdateMessageCritique() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].Critique != "Missed an edge case" {
+		t.Errorf("GetMessages() = %+v, want critique %q", messages, "Missed an edge case")
+	}
+}
+
+func TestDB_SupersedeMessagesFrom(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	other, _ := db.CreateChat("llama3")
+	db.AddMessage(chat.ID, RoleUser, "Question")
+	answer, _ := db.AddMessage(chat.ID, RoleAssistant, "First answer")
+	db.AddMessage(chat.ID, RoleUser, "Follow-up")
+	db.AddMessage(other.ID, RoleUser, "Other chat")
+
+	if err := db.SupersedeMessagesFrom(chat.ID, answer.ID); err != nil {
+		t.Fatalf("SupersedeMessagesFrom() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].Content != "Question" {
+		t.Errorf("GetMessages() = %+v, want only the question", messages)
+	}
+
+	// Other chats are not affected
+	otherMessages, _ := db.GetMessages(other.ID)
+	if len(otherMessages) != 1 {
+		t.Errorf("GetMessages(other) returned %d messages, want 1", len(otherMessages))
+	}
+
+	// New messages are visible again
+	db.AddMessage(chat.ID, RoleAssistant, "Second answer")
+	messages, _ = db.GetMessages(chat.ID)
+	if len(messages) != 2 || messages[1].Content != "Second answer" {
+		t.Errorf("GetMessages() after regenerate = %+v", messages)
+	}
+}
+
+func TestDB_UpdateMessageContent(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg, _ := db.AddMessage(chat.ID, RoleUser, "Helo")
+
+	if err := db.UpdateMessageContent(msg.ID, "Hello"); err != nil {
+		t.Fatalf("UpdateMessageContent() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].Content != "Hello" {
+		t.Errorf("GetMessages() = %+v, want content %q", messages, "Hello")
+	}
+}
+
+func TestDB_UpdateMessageTruncated(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg, _ := db.AddMessage(chat.ID, RoleAssistant, "The answer is")
+
+	messages, _ := db.GetMessages(chat.ID)
+	if messages[0].Truncated {
+		t.Fatal("new message is truncated")
+	}
+
+	for _, truncated := range []bool{true, false} {
+		if err := db.UpdateMessageTruncated(msg.ID, truncated); err != nil {
+			t.Fatalf("UpdateMessageTruncated(%v) error = %v", truncated, err)
+		}
+		messages, _ := db.GetMessages(chat.ID)
+		if messages[0].Truncated != truncated {
+			t.Errorf("after UpdateMessageTruncated(%v), Truncated = %v", truncated, messages[0].Truncated)
+		}
+	}
+}
+
+func TestDB_UpdateMessageBookmarked(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg, _ := db.AddMessage(chat.ID, RoleAssistant, "Use os.ReadFile.")
+
+	for _, bookmarked := range []bool{true, false} {
+		if err := db.UpdateMessageBookmarked(msg.ID, bookmarked); err != nil {
+			t.Fatalf("UpdateMessageBookmarked(%v) error = %v", bookmarked, err)
+		}
+		messages, _ := db.GetMessages(chat.ID)
+		if messages[0].Bookmarked != bookmarked {
+			t.Errorf("after UpdateMessageBookmarked(%v), Bookmarked = %v", bookmarked, messages[0].Bookmarked)
+		}
+	}
+}
+
+func TestDB_UpdateMessageRatingAndAnnotation(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg, _ := db.AddMessage(chat.ID, RoleAssistant, "Use os.ReadFile.")
+
+	if err := db.UpdateMessageRating(msg.ID, RatingDown); err != nil {
+		t.Fatalf("UpdateMessageRating() error = %v", err)
+	}
+	if err := db.UpdateMessageAnnotation(msg.ID, "Misses error handling"); err != nil {
+		t.Fatalf("UpdateMessageAnnotation() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if messages[0].Rating != RatingDown || messages[0].Annotation != "Misses error handling" {
+		t.Errorf("GetMessages() = rating %d, annotation %q", messages[0].Rating, messages[0].Annotation)
+	}
+	page, _ := db.GetMessagesPage(chat.ID, 10, 0)
+	if page[0].Rating != RatingDown || page[0].Annotation != "Misses error handling" {
+		t.Errorf("GetMessagesPage() = rating %d, annotation %q", page[0].Rating, page[0].Annotation)
+	}
+
+	stats, err := db.GetChatStats(chat.ID)
+	if err != nil {
+		t.Fatalf("GetChatStats() error = %v", err)
+	}
+	if stats.RatedUp != 0 || stats.RatedDown != 1 || stats.Annotated != 1 {
+		t.Errorf("GetChatStats() = %d up, %d down, %d annotated; want 0, 1, 1", stats.RatedUp, stats.RatedDown, stats.Annotated)
+	}
+}
+
+func TestDB_SaveMessage(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg := &Message{
+		ChatID:    chat.ID,
+		Role:      RoleAssistant,
+		Content:   "Use os.ReadFile.",
+		Critique:  "Too short",
+		Truncated: true,
+		Stats:     &MessageStats{PromptTokens: 12, EvalTokens: 4},
+	}
+	attachments := []Attachment{{Filename: "notes.txt", Content: "hello"}}
+
+	if err := db.SaveMessage(msg, attachments); err != nil {
+		t.Fatalf("SaveMessage() error = %v", err)
+	}
+	if msg.ID == 0 || attachments[0].MessageID != msg.ID || msg.CreatedAt.IsZero() {
+		t.Errorf("SaveMessage() left ID %d, attachment message ID %d, created %v", msg.ID, attachments[0].MessageID, msg.CreatedAt)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].Critique != "Too short" || !messages[0].Truncated {
+		t.Fatalf("GetMessages() = %+v, want the saved message", messages)
+	}
+	saved, _ := db.GetMessageAttachments(msg.ID)
+	if len(saved) != 1 || saved[0].Content != "hello" {
+		t.Errorf("GetMessageAttachments() = %+v, want notes.txt", saved)
+	}
+	stats, _ := db.GetMessageStats([]int64{msg.ID})
+	if stats[msg.ID] == nil || stats[msg.ID].EvalTokens != 4 {
+		t.Errorf("GetMessageStats() = %+v, want the saved statistics", stats)
+	}
+}
+
+func TestDB_SaveMessage_Agent(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg := &Message{ChatID: chat.ID, Role: RoleAssistant, Content: "**Critic** (mistral)\n\nI disagree.", Agent: "Critic · mistral", AgentSlot: 1}
+	if err := db.SaveMessage(msg, nil); err != nil {
+		t.Fatalf("SaveMessage() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].Agent != "Critic · mistral" || messages[0].AgentSlot != 1 {
+		t.Errorf("GetMessages() = %+v, want the agent kept", messages)
+	}
+	page, _ := db.GetMessagesPage(chat.ID, 10, 0)
+	if len(page) != 1 || page[0].Agent != "Critic · mistral" || page[0].AgentSlot != 1 {
+		t.Errorf("GetMessagesPage() = %+v, want the agent kept", page)
+	}
+
+	branch, err := db.CloneChatUpTo(chat.ID, msg.ID)
+	if err != nil {
+		t.Fatalf("CloneChatUpTo() error = %v", err)
+	}
+	copied, _ := db.GetMessages(branch.ID)
+	if len(copied) != 1 || copied[0].Agent != "Critic · mistral" || copied[0].AgentSlot != 1 {
+		t.Errorf("branch messages = %+v, want the agent copied", copied)
+	}
+}
+
+func TestDB_WithTx_RollsBack(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	failure := errors.New("attachment failed")
+	err = db.WithTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("INSERT INTO messages (chat_id, role, content) VALUES (?, 'user', 'Hello')", chat.ID); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithTx() error = %v, want %v", err, failure)
+	}
+
+	if messages, _ := db.GetMessages(chat.ID); len(messages) != 0 {
+		t.Errorf("GetMessages() = %d messages, want the insert rolled back", len(messages))
+	}
+}
+
+func TestDB_DeleteMessage(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	first, _ := db.AddMessage(chat.ID, RoleUser, "First")
+	prompt, _ := db.AddMessage(chat.ID, RoleUser, "Prompt")
+	db.AddAttachment(prompt.ID, "notes.txt", "content")
+
+	if err := db.DeleteMessage(prompt.ID); err != nil {
+		t.Fatalf("DeleteMessage() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].ID != first.ID {
+		t.Errorf("GetMessages() = %+v, want only the first message", messages)
+	}
+	attachments, _ := db.GetMessageAttachments(prompt.ID)
+	if len(attachments) != 0 {
+		t.Errorf("attachments of deleted message = %d, want 0", len(attachments))
+	}
+}
+
+func TestDB_DeleteMessagesAfter(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	other, _ := db.CreateChat("llama3")
+	first, _ := db.AddMessage(chat.ID, RoleUser, "First")
+	db.AddMessage(chat.ID, RoleAssistant, "Answer")
+	second, _ := db.AddMessage(chat.ID, RoleUser, "Second")
+	db.AddAttachment(second.ID, "notes.txt", "content")
+	db.AddMessage(other.ID, RoleUser, "Other chat")
+
+	if err := db.DeleteMessagesAfter(chat.ID, first.ID); err != nil {
+		t.Fatalf("DeleteMessagesAfter() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].ID != first.ID {
+		t.Errorf("GetMessages() = %+v, want only the first message", messages)
+	}
+
+	attachments, _ := db.GetMessageAttachments(second.ID)
+	if len(attachments) != 0 {
+		t.Errorf("attachments of deleted message = %d, want 0", len(attachments))
+	}
+
+	otherMessages, _ := db.GetMessages(other.ID)
+	if len(otherMessages) != 1 {
+		t.Errorf("GetMessages(other) returned %d messages, want 1", len(otherMessages))
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// AddDocument adds a document to a chat's library.
+func (d *DB) AddDocument(chatID int64, filename, content string) (*Document, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	doc := &Document{
+		ChatID:    chatID,
+		Filename:  filename,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := d.db.Exec(
+		"INSERT INTO documents (chat_id, filename, content, created_at) VALUES (?, ?, ?, ?)",
+		doc.ChatID, doc.Filename, doc.Content, doc.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add document: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	doc.ID = id
+	return doc, nil
+}
+
+// ListDocuments returns the documents of a chat in the order they were added.
+func (d *DB) ListDocuments(chatID int64) ([]*Document, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	rows, err := d.db.Query(
+		"SELECT id, chat_id, filename, content, created_at FROM documents WHERE chat_id = ? ORDER BY id ASC",
+		chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*Document
+	for rows.Next() {
+		doc := &Document{}
+		if err := rows.Scan(&doc.ID, &doc.ChatID, &doc.Filename, &doc.Content, &doc.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// DeleteDocument removes a document from its chat's library.
+func (d *DB) DeleteDocument(id int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("DELETE FROM documents WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
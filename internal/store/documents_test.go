@@ -0,0 +1,60 @@
+package store
+
+import "testing"
+
+func TestDB_Documents(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	other, _ := db.CreateChat("llama3")
+
+	first, err := db.AddDocument(chat.ID, "guide.md", "# Guide")
+	if err != nil {
+		t.Fatalf("AddDocument() error = %v", err)
+	}
+	if first.ID == 0 {
+		t.Error("AddDocument() returned a document without an ID")
+	}
+	db.AddDocument(chat.ID, "notes.txt", "Notes")
+	db.AddDocument(other.ID, "other.txt", "Other")
+
+	docs, err := db.ListDocuments(chat.ID)
+	if err != nil {
+		t.Fatalf("ListDocuments() error = %v", err)
+	}
+	if len(docs) != 2 || docs[0].Filename != "guide.md" || docs[1].Content != "Notes" {
+		t.Fatalf("ListDocuments() = %+v, want guide.md and notes.txt", docs)
+	}
+
+	if err := db.DeleteDocument(first.ID); err != nil {
+		t.Fatalf("DeleteDocument() error = %v", err)
+	}
+	docs, _ = db.ListDocuments(chat.ID)
+	if len(docs) != 1 || docs[0].Filename != "notes.txt" {
+		t.Errorf("ListDocuments() after delete = %+v, want notes.txt", docs)
+	}
+}
+
+func TestDB_DocumentsDeletedWithChat(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	db.AddDocument(chat.ID, "guide.md", "# Guide")
+
+	if err := db.DeleteChat(chat.ID); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+
+	docs, _ := db.ListDocuments(chat.ID)
+	if len(docs) != 0 {
+		t.Errorf("ListDocuments() after DeleteChat() = %d documents, want 0", len(docs))
+	}
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SaveDraft replaces the draft of a chat with draft, or deletes it when
+// draft is empty.
+func (d *DB) SaveDraft(draft *Draft) error {
+	if draft.UpdatedAt.IsZero() {
+		draft.UpdatedAt = time.Now()
+	}
+	return d.WithTx(func(tx *sql.Tx) error {
+		// Its attachments go with it
+		if _, err := tx.Exec("DELETE FROM drafts WHERE chat_id = ?", draft.ChatID); err != nil {
+			return fmt.Errorf("failed to delete draft: %w", err)
+		}
+		if draft.Empty() {
+			return nil
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO drafts (chat_id, content, updated_at) VALUES (?, ?, ?)",
+			draft.ChatID, draft.Text, draft.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save draft: %w", err)
+		}
+		for _, a := range draft.Attachments {
+			_, err := tx.Exec(
+				"INSERT INTO draft_attachments (chat_id, filename, content) VALUES (?, ?, ?)",
+				draft.ChatID, a.Filename, a.Content,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to save draft attachment: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetDraft returns the draft of a chat, or nil if it has none.
+func (d *DB) GetDraft(chatID int64) (*Draft, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	draft := &Draft{ChatID: chatID}
+	err := d.db.QueryRow(
+		"SELECT content, updated_at FROM drafts WHERE chat_id = ?", chatID,
+	).Scan(&draft.Text, &draft.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	rows, err := d.db.Query(
+		"SELECT id, filename, content FROM draft_attachments WHERE chat_id = ? ORDER BY id", chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.ID, &a.Filename, &a.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan draft attachment: %w", err)
+		}
+		draft.Attachments = append(draft.Attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// DeleteDraft deletes the draft of a chat, if it has one.
+func (d *DB) DeleteDraft(chatID int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	if _, err := d.db.Exec("DELETE FROM drafts WHERE chat_id = ?", chatID); err != nil {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+	return nil
+}
+
+// DraftChatIDs returns the IDs of the chats that have a draft.
+func (d *DB) DraftChatIDs() (map[int64]bool, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	rows, err := d.db.Query("SELECT chat_id FROM drafts")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drafts: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan draft: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
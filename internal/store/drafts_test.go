@@ -0,0 +1,98 @@
+package store
+
+import "testing"
+
+func newDraftTestDB(t *testing.T) (*DB, *Chat) {
+	t.Helper()
+
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	chat, _ := db.CreateChat("llama3")
+	return db, chat
+}
+
+func TestDB_SaveDraft(t *testing.T) {
+	db, chat := newDraftTestDB(t)
+
+	draft := &Draft{
+		ChatID:      chat.ID,
+		Text:        "Summarize this",
+		Attachments: []Attachment{{Filename: "notes.txt", Content: "hello"}},
+	}
+	if err := db.SaveDraft(draft); err != nil {
+		t.Fatalf("SaveDraft() error = %v", err)
+	}
+
+	got, err := db.GetDraft(chat.ID)
+	if err != nil {
+		t.Fatalf("GetDraft() error = %v", err)
+	}
+	if got == nil || got.Text != "Summarize this" {
+		t.Fatalf("GetDraft() = %+v, want the saved text", got)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].Filename != "notes.txt" || got.Attachments[0].Content != "hello" {
+		t.Errorf("Attachments = %+v, want notes.txt", got.Attachments)
+	}
+
+	// Saving again replaces the text and the attachments
+	if err := db.SaveDraft(&Draft{ChatID: chat.ID, Text: "Shorter"}); err != nil {
+		t.Fatalf("SaveDraft() error = %v", err)
+	}
+	got, _ = db.GetDraft(chat.ID)
+	if got == nil || got.Text != "Shorter" || len(got.Attachments) != 0 {
+		t.Errorf("GetDraft() = %+v, want the new text alone", got)
+	}
+
+	ids, err := db.DraftChatIDs()
+	if err != nil {
+		t.Fatalf("DraftChatIDs() error = %v", err)
+	}
+	if len(ids) != 1 || !ids[chat.ID] {
+		t.Errorf("DraftChatIDs() = %v, want %d", ids, chat.ID)
+	}
+}
+
+func TestDB_SaveDraft_Empty(t *testing.T) {
+	db, chat := newDraftTestDB(t)
+	db.SaveDraft(&Draft{ChatID: chat.ID, Text: "Hello"})
+
+	if err := db.SaveDraft(&Draft{ChatID: chat.ID, Text: "  \n"}); err != nil {
+		t.Fatalf("SaveDraft() error = %v", err)
+	}
+	if got, err := db.GetDraft(chat.ID); err != nil || got != nil {
+		t.Errorf("GetDraft() = %+v, %v, want no draft", got, err)
+	}
+}
+
+func TestDB_DeleteDraft(t *testing.T) {
+	db, chat := newDraftTestDB(t)
+	db.SaveDraft(&Draft{ChatID: chat.ID, Attachments: []Attachment{{Filename: "a.txt", Content: "a"}}})
+
+	if err := db.DeleteDraft(chat.ID); err != nil {
+		t.Fatalf("DeleteDraft() error = %v", err)
+	}
+	if got, _ := db.GetDraft(chat.ID); got != nil {
+		t.Errorf("GetDraft() = %+v after DeleteDraft()", got)
+	}
+	var count int
+	db.db.QueryRow("SELECT COUNT(*) FROM draft_attachments").Scan(&count)
+	if count != 0 {
+		t.Errorf("%d draft attachments left, want 0", count)
+	}
+}
+
+func TestDB_DraftDeletedWithChat(t *testing.T) {
+	db, chat := newDraftTestDB(t)
+	db.SaveDraft(&Draft{ChatID: chat.ID, Text: "Hello", Attachments: []Attachment{{Filename: "a.txt", Content: "a"}}})
+
+	if err := db.DeleteChat(chat.ID); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+	if ids, _ := db.DraftChatIDs(); len(ids) != 0 {
+		t.Errorf("DraftChatIDs() = %v after deleting the chat", ids)
+	}
+}
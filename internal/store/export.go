@@ -0,0 +1,285 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a file format chats can be exported to.
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "markdown"
+	ExportJSON     ExportFormat = "json"
+	ExportText     ExportFormat = "text"
+)
+
+// ExportFormats lists the supported export formats in display order.
+var ExportFormats = []ExportFormat{ExportMarkdown, ExportJSON, ExportText, ExportOpenAI, ExportShareGPT}
+
+// exportTimeFormat is the timestamp layout used in Markdown and text exports.
+const exportTimeFormat = "2006-01-02 15:04"
+
+// Extension returns the file extension for the format, including the dot.
+func (f ExportFormat) Extension() string {
+	switch f {
+	case ExportJSON:
+		return ".json"
+	case ExportText:
+		return ".txt"
+	case ExportOpenAI, ExportShareGPT:
+		return ".jsonl"
+	default:
+		return ".md"
+	}
+}
+
+// AttachmentInfo describes an attachment without its content.
+type AttachmentInfo struct {
+	Filename string `json:"filename"`
+	Size     int    `json:"size"` // Length of the stored content in characters
+}
+
+// ExportedMessage is a message with its attachment metadata.
+type ExportedMessage struct {
+	*Message
+	Attachments []AttachmentInfo `json:"attachments,omitempty"`
+}
+
+// ExportedChat is a chat with all its messages, ready to be written out.
+type ExportedChat struct {
+	*Chat
+	Tags     []string          `json:"tags,omitempty"`
+	Messages []ExportedMessage `json:"messages"`
+}
+
+// exportDocument is the top-level JSON export structure.
+type exportDocument struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	Chats      []ExportedChat `json:"chats"`
+}
+
+// LoadExport loads the given chats with their messages and attachment
+// metadata. When chatIDs is empty, all chats are loaded.
+func (d *DB) LoadExport(chatIDs []int64) ([]ExportedChat, error) {
+	var chats []*Chat
+	if len(chatIDs) == 0 {
+		all, err := d.ListChats()
+		if err != nil {
+			return nil, err
+		}
+		chats = all
+	} else {
+		for _, id := range chatIDs {
+			chat, err := d.GetChat(id)
+			if err != nil {
+				return nil, err
+			}
+			chats = append(chats, chat)
+		}
+	}
+
+	chatTags, err := d.ListChatTags()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ExportedChat, 0, len(chats))
+	for _, chat := range chats {
+		messages, err := d.GetMessages(chat.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]int64, len(messages))
+		for i, msg := range messages {
+			ids[i] = msg.ID
+		}
+		attachmentMap, err := d.GetAttachmentsForMessages(ids)
+		if err != nil {
+			return nil, err
+		}
+
+		exported := ExportedChat{Chat: chat, Messages: make([]ExportedMessage, 0, len(messages))}
+		for _, tag := range chatTags[chat.ID] {
+			exported.Tags = append(exported.Tags, tag.Name)
+		}
+		for _, msg := range messages {
+			em := ExportedMessage{Message: msg}
+			for _, a := range attachmentMap[msg.ID] {
+				em.Attachments = append(em.Attachments, AttachmentInfo{Filename: a.Filename, Size: len(a.Content)})
+			}
+			exported.Messages = append(exported.Messages, em)
+		}
+		result = append(result, exported)
+	}
+
+	return result, nil
+}
+
+// WriteExport writes chats to w in the given format. Training formats
+// include every exchange; see WriteTrainingExport to filter them.
+func WriteExport(w io.Writer, chats []ExportedChat, format ExportFormat) error {
+	switch format {
+	case ExportOpenAI, ExportShareGPT:
+		return WriteTrainingExport(w, chats, format, TrainingOptions{})
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exportDocument{ExportedAt: time.Now(), Chats: chats})
+	case ExportText:
+		return writeText(w, chats)
+	case ExportMarkdown:
+		return writeMarkdown(w, chats)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// FrontMatter returns YAML front matter describing chat, for Markdown
+// exports read by static site generators and note apps.
+func FrontMatter(chat ExportedChat) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", YAMLString(chat.Title))
+	fmt.Fprintf(&b, "date: %s\n", chat.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "updated: %s\n", chat.UpdatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "model: %s\n", YAMLString(chat.Model))
+	if len(chat.Tags) == 0 {
+		b.WriteString("tags: []\n")
+	} else {
+		b.WriteString("tags:\n")
+		for _, tag := range chat.Tags {
+			fmt.Fprintf(&b, "  - %s\n", YAMLString(tag))
+		}
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// YAMLString quotes s as a YAML string, for front matter. JSON strings are
+// valid YAML.
+func YAMLString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// roleLabel returns the display name of a role.
+func roleLabel(role Role) string {
+	switch role {
+	case RoleUser:
+		return "User"
+	case RoleAssistant:
+		return "Assistant"
+	default:
+		return "System"
+	}
+}
+
+func writeMarkdown(w io.Writer, chats []ExportedChat) error {
+	var b strings.Builder
+	for i, chat := range chats {
+		if i > 0 {
+			b.WriteString("\n---\n\n")
+		}
+		fmt.Fprintf(&b, "# %s\n\n", chat.Title)
+		fmt.Fprintf(&b, "- Model: %s\n", chat.Model)
+		fmt.Fprintf(&b, "- Created: %s\n", chat.CreatedAt.Format(exportTimeFormat))
+		fmt.Fprintf(&b, "- Updated: %s\n", chat.UpdatedAt.Format(exportTimeFormat))
+		if chat.SystemPrompt != "" {
+			fmt.Fprintf(&b, "- System prompt: %s\n", strings.ReplaceAll(chat.SystemPrompt, "\n", " "))
+		}
+
+		for _, msg := range chat.Messages {
+			if msg.ModelSwitch {
+				fmt.Fprintf(&b, "\n*Switched to %s*\n", msg.Content)
+				continue
+			}
+			fmt.Fprintf(&b, "\n## %s · %s\n\n", roleLabel(msg.Role), msg.CreatedAt.Format(exportTimeFormat))
+			for _, a := range msg.Attachments {
+				fmt.Fprintf(&b, "> 📎 %s (%d characters)\n", a.Filename, a.Size)
+			}
+			if len(msg.Attachments) > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(strings.TrimSpace(msg.Content))
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeText(w io.Writer, chats []ExportedChat) error {
+	var b strings.Builder
+	for i, chat := range chats {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(chat.Title)
+		b.WriteString("\n")
+		b.WriteString(strings.Repeat("=", len([]rune(chat.Title))))
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "Model: %s\n", chat.Model)
+		fmt.Fprintf(&b, "Created: %s\n", chat.CreatedAt.Format(exportTimeFormat))
+
+		for _, msg := range chat.Messages {
+			if msg.ModelSwitch {
+				fmt.Fprintf(&b, "\n[%s] Switched to %s\n", msg.CreatedAt.Format(exportTimeFormat), msg.Content)
+				continue
+			}
+			fmt.Fprintf(&b, "\n[%s] %s:\n", msg.CreatedAt.Format(exportTimeFormat), roleLabel(msg.Role))
+			for _, a := range msg.Attachments {
+				fmt.Fprintf(&b, "  Attachment: %s (%d characters)\n", a.Filename, a.Size)
+			}
+			b.WriteString(strings.TrimSpace(msg.Content))
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// unsafeFileChars matches characters that are not safe in file names.
+var unsafeFileChars = regexp.MustCompile(`[^\p{L}\p{N}._ -]+`)
+
+// ExportFileName returns a file name for exporting a chat with the given title.
+func ExportFileName(title string, format ExportFormat) string {
+	name := strings.TrimSpace(unsafeFileChars.ReplaceAllString(title, ""))
+	if name == "" {
+		name = "guanaco-export"
+	}
+	return name + format.Extension()
+}
+
+// ExportFileNamePlaceholders lists the placeholders of export file name
+// templates.
+var ExportFileNamePlaceholders = []string{"{{title}}", "{{slug}}", "{{date}}", "{{model}}"}
+
+// nonSlugChars matches runs of characters that are not kept in slugs.
+var nonSlugChars = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// TemplateExportFileName returns a file name for exporting chat, made from
+// template by filling in the chat's {{title}}, {{slug}} (the title in lower
+// case with dashes), {{date}} (when it was created, as YYYY-MM-DD) and
+// {{model}}. An empty template names the file after the title.
+func TemplateExportFileName(template string, chat *Chat, format ExportFormat) string {
+	template = strings.TrimSuffix(strings.TrimSpace(template), format.Extension())
+	if template == "" {
+		return ExportFileName(chat.Title, format)
+	}
+
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(chat.Title), "-"), "-")
+	model := strings.NewReplacer(":", "-", "/", "-").Replace(chat.Model)
+	name := strings.NewReplacer(
+		"{{title}}", chat.Title,
+		"{{slug}}", slug,
+		"{{date}}", chat.CreatedAt.Format("2006-01-02"),
+		"{{model}}", model,
+	).Replace(template)
+	return ExportFileName(name, format)
+}
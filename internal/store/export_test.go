@@ -0,0 +1,178 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newExportTestDB(t *testing.T) (*DB, *Chat) {
+	t.Helper()
+
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	chat, _ := db.CreateChat("llama3")
+	db.UpdateChatTitle(chat.ID, "Go tips")
+	msg, _ := db.AddMessage(chat.ID, RoleUser, "How do I read a file?")
+	db.AddAttachment(msg.ID, "notes.txt", "hello")
+	db.AddMessage(chat.ID, RoleAssistant, "Use os.ReadFile.")
+
+	return db, chat
+}
+
+func TestDB_LoadExport(t *testing.T) {
+	db, chat := newExportTestDB(t)
+	db.CreateChat("mistral")
+
+	all, err := db.LoadExport(nil)
+	if err != nil {
+		t.Fatalf("LoadExport() error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("LoadExport(nil) returned %d chats, want 2", len(all))
+	}
+
+	one, err := db.LoadExport([]int64{chat.ID})
+	if err != nil {
+		t.Fatalf("LoadExport() error = %v", err)
+	}
+	if len(one) != 1 || len(one[0].Messages) != 2 {
+		t.Fatalf("LoadExport() = %+v, want 1 chat with 2 messages", one)
+	}
+	attachments := one[0].Messages[0].Attachments
+	if len(attachments) != 1 || attachments[0].Filename != "notes.txt" || attachments[0].Size != 5 {
+		t.Errorf("Attachments = %+v, want notes.txt with size 5", attachments)
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	db, chat := newExportTestDB(t)
+	chats, err := db.LoadExport([]int64{chat.ID})
+	if err != nil {
+		t.Fatalf("LoadExport() error = %v", err)
+	}
+
+	tests := []struct {
+		format ExportFormat
+		want   []string
+	}{
+		{ExportMarkdown, []string{"# Go tips", "- Model: llama3", "## User", "📎 notes.txt", "Use os.ReadFile."}},
+		{ExportText, []string{"Go tips\n=======", "Model: llama3", "] User:", "Attachment: notes.txt", "Use os.ReadFile."}},
+		{ExportJSON, []string{`"title": "Go tips"`, `"model": "llama3"`, `"filename": "notes.txt"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteExport(&buf, chats, tt.format); err != nil {
+				t.Fatalf("WriteExport() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteExport_JSONRoundTrip(t *testing.T) {
+	db, chat := newExportTestDB(t)
+	chats, _ := db.LoadExport([]int64{chat.ID})
+
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, chats, ExportJSON); err != nil {
+		t.Fatalf("WriteExport() error = %v", err)
+	}
+
+	var doc exportDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Chats) != 1 || len(doc.Chats[0].Messages) != 2 {
+		t.Errorf("decoded %+v, want 1 chat with 2 messages", doc.Chats)
+	}
+}
+
+func TestWriteExport_UnknownFormat(t *testing.T) {
+	if err := WriteExport(&bytes.Buffer{}, nil, ExportFormat("pdf")); err == nil {
+		t.Error("WriteExport() expected error for unknown format")
+	}
+}
+
+func TestExportFileName(t *testing.T) {
+	tests := []struct {
+		title  string
+		format ExportFormat
+		want   string
+	}{
+		{"Go tips", ExportMarkdown, "Go tips.md"},
+		{"a/b: c?", ExportJSON, "ab c.json"},
+		{"", ExportText, "guanaco-export.txt"},
+		{"Go tips", ExportShareGPT, "Go tips.jsonl"},
+	}
+
+	for _, tt := range tests {
+		if got := ExportFileName(tt.title, tt.format); got != tt.want {
+			t.Errorf("ExportFileName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateExportFileName(t *testing.T) {
+	chat := &Chat{
+		Title:     "Go tips: files",
+		Model:     "llama3:8b",
+		CreatedAt: time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		template string
+		format   ExportFormat
+		want     string
+	}{
+		{"", ExportMarkdown, "Go tips files.md"},
+		{"{{title}}-{{date}}", ExportMarkdown, "Go tips files-2024-03-05.md"},
+		{"{{date}}-{{slug}}.md", ExportMarkdown, "2024-03-05-go-tips-files.md"},
+		{"{{model}} {{slug}}", ExportJSON, "llama3-8b go-tips-files.json"},
+		{"posts/{{slug}}", ExportText, "postsgo-tips-files.txt"},
+	}
+
+	for _, tt := range tests {
+		if got := TemplateExportFileName(tt.template, chat, tt.format); got != tt.want {
+			t.Errorf("TemplateExportFileName(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestFrontMatter(t *testing.T) {
+	db, chat := newExportTestDB(t)
+	tag, _ := db.AddTag("golang")
+	db.TagChat(chat.ID, tag.ID)
+
+	chats, err := db.LoadExport([]int64{chat.ID})
+	if err != nil {
+		t.Fatalf("LoadExport() error = %v", err)
+	}
+	if len(chats[0].Tags) != 1 || chats[0].Tags[0] != "golang" {
+		t.Fatalf("Tags = %v, want [golang]", chats[0].Tags)
+	}
+
+	got := FrontMatter(chats[0])
+	for _, want := range []string{"---\ntitle: \"Go tips\"\n", "\nmodel: \"llama3\"\n", "\ntags:\n  - \"golang\"\n---\n\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FrontMatter() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	chats[0].Tags = nil
+	if got := FrontMatter(chats[0]); !strings.Contains(got, "\ntags: []\n") {
+		t.Errorf("FrontMatter() without tags = %q, want empty tags", got)
+	}
+}
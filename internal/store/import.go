@@ -0,0 +1,489 @@
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ImportSource is an app whose exported chats can be imported.
+type ImportSource string
+
+const (
+	// ImportChatGPT reads the conversations.json file of a ChatGPT data
+	// export.
+	ImportChatGPT ImportSource = "chatgpt"
+	// ImportOpenWebUI reads the JSON file of an Open WebUI chat export.
+	ImportOpenWebUI ImportSource = "openwebui"
+)
+
+// ErrUnknownImport is returned when a file is not an export that can be
+// imported.
+var ErrUnknownImport = errors.New("not a ChatGPT or Open WebUI export")
+
+// ImportedChat is a chat read from the export of another app, not yet
+// saved.
+type ImportedChat struct {
+	SourceID     string // Source and ID of the chat in it, to recognize it when imported again
+	Title        string
+	Model        string // Empty when the model doesn't run on Ollama
+	SystemPrompt string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Messages     []*Message // Only Role, Content and CreatedAt are set
+}
+
+// ImportConflict is what happens to a chat that was imported before.
+type ImportConflict int
+
+const (
+	ImportSkip     ImportConflict = iota // Keep the chat imported before
+	ImportReplace                        // Replace it with the new copy
+	ImportKeepBoth                       // Add the new copy as another chat
+)
+
+// ImportOptions control how chats are imported.
+type ImportOptions struct {
+	Conflict ImportConflict
+	Model    string // Model of the chats whose model doesn't run on Ollama
+}
+
+// ImportResult counts what happened to the chats of an import.
+type ImportResult struct {
+	Imported int // New chats, including replacements
+	Replaced int // Chats imported before that were replaced
+	Skipped  int // Chats imported before that were kept
+}
+
+// importTitleLength is the length, in characters, of the titles made from
+// the first message of chats without one.
+const importTitleLength = 50
+
+// ParseImport reads a ChatGPT or Open WebUI export, telling them apart by
+// their content. Chats without messages are left out.
+func ParseImport(r io.Reader) (ImportSource, []ImportedChat, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	// Open WebUI also exports single chats as an object
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		data = slices.Concat([]byte("["), data, []byte("]"))
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil || len(items) == 0 {
+		return "", nil, ErrUnknownImport
+	}
+	var probe struct {
+		Mapping json.RawMessage `json:"mapping"`
+		Chat    json.RawMessage `json:"chat"`
+	}
+	if err := json.Unmarshal(items[0], &probe); err != nil {
+		return "", nil, ErrUnknownImport
+	}
+
+	var source ImportSource
+	var chats []ImportedChat
+	switch {
+	case probe.Mapping != nil:
+		source = ImportChatGPT
+		var conversations []chatGPTConversation
+		if err := json.Unmarshal(data, &conversations); err != nil {
+			return "", nil, fmt.Errorf("failed to parse ChatGPT export: %w", err)
+		}
+		for _, c := range conversations {
+			chats = append(chats, c.chat())
+		}
+	case probe.Chat != nil:
+		source = ImportOpenWebUI
+		var exported []openWebUIChat
+		if err := json.Unmarshal(data, &exported); err != nil {
+			return "", nil, fmt.Errorf("failed to parse Open WebUI export: %w", err)
+		}
+		for _, c := range exported {
+			chats = append(chats, c.chat())
+		}
+	default:
+		return "", nil, ErrUnknownImport
+	}
+
+	kept := chats[:0]
+	for _, chat := range chats {
+		if len(chat.Messages) > 0 {
+			kept = append(kept, chat)
+		}
+	}
+	return source, kept, nil
+}
+
+// chatGPTConversation is a conversation of a ChatGPT export. Its messages
+// form a tree, as edited prompts and regenerated responses start new
+// branches, and current_node is the last message of the branch shown.
+type chatGPTConversation struct {
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	CreateTime  float64                `json:"create_time"`
+	UpdateTime  float64                `json:"update_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	Parent   string   `json:"parent"`
+	Children []string `json:"children"`
+	Message  *struct {
+		Author struct {
+			Role string `json:"role"`
+		} `json:"author"`
+		CreateTime float64 `json:"create_time"`
+		Content    struct {
+			ContentType string            `json:"content_type"`
+			Parts       []json.RawMessage `json:"parts"`
+		} `json:"content"`
+		Metadata struct {
+			Hidden bool `json:"is_visually_hidden_from_conversation"`
+		} `json:"metadata"`
+	} `json:"message"`
+}
+
+// chat returns the messages of the branch shown in ChatGPT. Tool calls and
+// their results, and hidden system messages, are left out. ChatGPT models
+// don't run on Ollama, so the chat is left without one.
+func (c chatGPTConversation) chat() ImportedChat {
+	chat := ImportedChat{
+		SourceID:  string(ImportChatGPT) + ":" + c.ID,
+		Title:     c.Title,
+		CreatedAt: unixTime(c.CreateTime),
+		UpdatedAt: unixTime(c.UpdateTime),
+	}
+
+	// Without a current node, the latest branch is followed from the root
+	node := c.CurrentNode
+	if _, ok := c.Mapping[node]; !ok {
+		node = c.root()
+		visited := map[string]bool{node: true}
+		for n, ok := c.Mapping[node]; ok && len(n.Children) > 0; n, ok = c.Mapping[node] {
+			child := n.Children[len(n.Children)-1]
+			if visited[child] {
+				break // A malformed export with a cycle
+			}
+			visited[child] = true
+			node = child
+		}
+	}
+
+	seen := make(map[string]bool)
+	for n, ok := c.Mapping[node]; ok && !seen[node]; n, ok = c.Mapping[node] {
+		seen[node] = true
+		node = n.Parent
+
+		msg := n.Message
+		if msg == nil || msg.Metadata.Hidden {
+			continue
+		}
+		if msg.Content.ContentType != "text" && msg.Content.ContentType != "multimodal_text" {
+			continue
+		}
+		role := Role(msg.Author.Role)
+		if role != RoleUser && role != RoleAssistant && role != RoleSystem {
+			continue
+		}
+		var parts []string
+		for _, part := range msg.Content.Parts {
+			if text := rawText(part); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		content := strings.TrimSpace(strings.Join(parts, "\n\n"))
+		if content == "" {
+			continue
+		}
+		chat.Messages = append(chat.Messages, &Message{Role: role, Content: content, CreatedAt: unixTime(msg.CreateTime)})
+	}
+	slices.Reverse(chat.Messages)
+	return chat
+}
+
+// root returns the node without a parent the conversation starts from. When
+// a malformed export has several, the earliest one is taken.
+func (c chatGPTConversation) root() string {
+	root, rootTime := "", 0.0
+	for id, n := range c.Mapping {
+		if n.Parent != "" {
+			continue
+		}
+		created := 0.0
+		if n.Message != nil {
+			created = n.Message.CreateTime
+		}
+		if root == "" || created < rootTime || (created == rootTime && id < root) {
+			root, rootTime = id, created
+		}
+	}
+	return root
+}
+
+// openWebUIChat is a chat of an Open WebUI export. Its messages are kept
+// both as a list and as a tree, in history, whose currentId is the last
+// message of the branch shown.
+type openWebUIChat struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	CreatedAt float64 `json:"created_at"`
+	UpdatedAt float64 `json:"updated_at"`
+	Chat      struct {
+		Title  string   `json:"title"`
+		Models []string `json:"models"`
+		Params struct {
+			System string `json:"system"`
+		} `json:"params"`
+		Timestamp float64            `json:"timestamp"`
+		Messages  []openWebUIMessage `json:"messages"`
+		History   struct {
+			CurrentID string                      `json:"currentId"`
+			Messages  map[string]openWebUIMessage `json:"messages"`
+		} `json:"history"`
+	} `json:"chat"`
+}
+
+type openWebUIMessage struct {
+	ParentID  string          `json:"parentId"`
+	Role      string          `json:"role"`
+	Content   json.RawMessage `json:"content"`
+	Model     string          `json:"model"`
+	Timestamp float64         `json:"timestamp"`
+}
+
+// chat returns the messages of the branch shown in Open WebUI, whose
+// models run on Ollama.
+func (c openWebUIChat) chat() ImportedChat {
+	chat := ImportedChat{
+		SourceID:     string(ImportOpenWebUI) + ":" + c.ID,
+		Title:        c.Title,
+		SystemPrompt: c.Chat.Params.System,
+		CreatedAt:    unixTime(c.CreatedAt),
+		UpdatedAt:    unixTime(c.UpdatedAt),
+	}
+	if chat.Title == "" {
+		chat.Title = c.Chat.Title
+	}
+	if chat.CreatedAt.IsZero() {
+		chat.CreatedAt = unixTime(c.Chat.Timestamp)
+	}
+	if len(c.Chat.Models) > 0 {
+		chat.Model = c.Chat.Models[0]
+	}
+
+	messages := c.Chat.Messages
+	if history := c.Chat.History; history.Messages[history.CurrentID].Role != "" {
+		messages = nil
+		seen := make(map[string]bool)
+		for id := history.CurrentID; id != "" && !seen[id]; id = history.Messages[id].ParentID {
+			seen[id] = true
+			msg, ok := history.Messages[id]
+			if !ok {
+				break
+			}
+			messages = append(messages, msg)
+		}
+		slices.Reverse(messages)
+	}
+
+	for _, msg := range messages {
+		role := Role(msg.Role)
+		content := strings.TrimSpace(rawText(msg.Content))
+		if (role != RoleUser && role != RoleAssistant && role != RoleSystem) || content == "" {
+			continue
+		}
+		if chat.Model == "" && msg.Model != "" {
+			chat.Model = msg.Model
+		}
+		chat.Messages = append(chat.Messages, &Message{Role: role, Content: content, CreatedAt: unixTime(msg.Timestamp)})
+	}
+	return chat
+}
+
+// rawText returns the text of a JSON message content: a string, or a list
+// of strings and {"type": "text", "text": ...} parts. Images and other
+// parts are left out.
+func rawText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	var parts []json.RawMessage
+	if json.Unmarshal(raw, &parts) != nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range parts {
+		var object struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(part, &text) == nil {
+			texts = append(texts, text)
+		} else if json.Unmarshal(part, &object) == nil && object.Type == "text" {
+			texts = append(texts, object.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// unixTime returns the time of a Unix timestamp in seconds, or in
+// milliseconds for timestamps too large to be seconds. It returns the zero
+// time for 0.
+func unixTime(ts float64) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+	if ts > 1e12 {
+		ts /= 1000
+	}
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+// importTitle returns the title of an imported chat: its own, or the
+// start of its first message.
+func importTitle(chat ImportedChat) string {
+	if title := strings.TrimSpace(chat.Title); title != "" {
+		return title
+	}
+	first, _, _ := strings.Cut(chat.Messages[0].Content, "\n")
+	if utf8.RuneCountInString(first) > importTitleLength {
+		first = string([]rune(first)[:importTitleLength]) + "…"
+	}
+	return first
+}
+
+// CountImported returns how many of chats were imported before.
+func (d *DB) CountImported(chats []ImportedChat) (int, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	count := 0
+	for _, chat := range chats {
+		id, err := importedChatID(d.db, chat.SourceID)
+		if err != nil {
+			return 0, err
+		}
+		if id != 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// queryRower runs queries on a database or in a transaction.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// importedChatID returns the ID of the chat imported before from sourceID,
+// or 0 if there is none.
+func importedChatID(q queryRower, sourceID string) (int64, error) {
+	if sourceID == "" {
+		return 0, nil
+	}
+	var id int64
+	err := q.QueryRow("SELECT id FROM chats WHERE import_id = ? ORDER BY id LIMIT 1", sourceID).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to find imported chat: %w", err)
+	}
+	return id, nil
+}
+
+// ImportChats saves chats read by ParseImport, each in its own
+// transaction, and calls progress after each one with how many are done.
+// Imported chats keep their titles, which are not generated again.
+func (d *DB) ImportChats(chats []ImportedChat, opts ImportOptions, progress func(done int)) (ImportResult, error) {
+	var result ImportResult
+	for i, chat := range chats {
+		err := d.WithTx(func(tx *sql.Tx) error {
+			existing, err := importedChatID(tx, chat.SourceID)
+			if err != nil {
+				return err
+			}
+			if existing != 0 {
+				switch opts.Conflict {
+				case ImportSkip:
+					result.Skipped++
+					return nil
+				case ImportReplace:
+					if _, err := tx.Exec("DELETE FROM chats WHERE import_id = ?", chat.SourceID); err != nil {
+						return fmt.Errorf("failed to replace chat: %w", err)
+					}
+					result.Replaced++
+				}
+			}
+			if err := importChat(tx, chat, opts.Model); err != nil {
+				return err
+			}
+			result.Imported++
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+		if progress != nil {
+			progress(i + 1)
+		}
+	}
+	return result, nil
+}
+
+// importChat inserts chat and its messages, with model when the chat has
+// none.
+func importChat(tx *sql.Tx, chat ImportedChat, model string) error {
+	if chat.Model != "" {
+		model = chat.Model
+	}
+	now := time.Now()
+	created, updated := chat.CreatedAt, chat.UpdatedAt
+	if created.IsZero() {
+		created = now
+	}
+	if updated.Before(created) {
+		updated = created
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO chats (title, model, system_prompt, title_locked, import_id, created_at, updated_at) VALUES (?, ?, ?, 1, ?, ?, ?)",
+		importTitle(chat), model, chat.SystemPrompt, chat.SourceID, created, updated,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to import chat: %w", err)
+	}
+	chatID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	for _, msg := range chat.Messages {
+		createdAt := msg.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = created
+		}
+		_, err := tx.Exec(
+			"INSERT INTO messages (chat_id, role, content, created_at) VALUES (?, ?, ?, ?)",
+			chatID, msg.Role, msg.Content, createdAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to import message: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const chatGPTExport = `[{
+	"id": "c1",
+	"title": "Sourdough",
+	"create_time": 1700000000.5,
+	"update_time": 1700000600,
+	"current_node": "a2",
+	"mapping": {
+		"root": {"parent": null, "children": ["sys"], "message": null},
+		"sys": {"parent": "root", "children": ["u1"], "message": {
+			"author": {"role": "system"}, "create_time": null,
+			"content": {"content_type": "text", "parts": [""]},
+			"metadata": {"is_visually_hidden_from_conversation": true}}},
+		"u1": {"parent": "sys", "children": ["a1", "a2"], "message": {
+			"author": {"role": "user"}, "create_time": 1700000100,
+			"content": {"content_type": "text", "parts": ["How long to proof?"]}}},
+		"a1": {"parent": "u1", "children": [], "message": {
+			"author": {"role": "assistant"}, "create_time": 1700000200,
+			"content": {"content_type": "text", "parts": ["First answer"]}}},
+		"a2": {"parent": "u1", "children": [], "message": {
+			"author": {"role": "assistant"}, "create_time": 1700000300,
+			"content": {"content_type": "multimodal_text", "parts": [{"asset_pointer": "file-1"}, "About 4 hours."]}}}
+	}
+}, {
+	"id": "c2", "title": "Empty", "current_node": "root",
+	"mapping": {"root": {"parent": null, "children": [], "message": null}}
+}]`
+
+const openWebUIExport = `[{
+	"id": "w1",
+	"title": "",
+	"created_at": 1700000000,
+	"updated_at": 1700000900,
+	"chat": {
+		"title": "Go generics",
+		"models": ["llama3:latest"],
+		"params": {"system": "Be brief."},
+		"messages": [],
+		"history": {
+			"currentId": "m3",
+			"messages": {
+				"m1": {"parentId": null, "role": "user", "content": "Explain generics", "timestamp": 1700000100},
+				"m2": {"parentId": "m1", "role": "assistant", "content": "Old answer", "timestamp": 1700000200},
+				"m3": {"parentId": "m1", "role": "assistant", "content": "Type parameters.", "model": "llama3:latest", "timestamp": 1700000300}
+			}
+		}
+	}
+}]`
+
+func TestParseImport_ChatGPT(t *testing.T) {
+	source, chats, err := ParseImport(strings.NewReader(chatGPTExport))
+	if err != nil {
+		t.Fatalf("ParseImport() error = %v", err)
+	}
+	if source != ImportChatGPT {
+		t.Errorf("ParseImport() source = %q, want %q", source, ImportChatGPT)
+	}
+	if len(chats) != 1 {
+		t.Fatalf("ParseImport() returned %d chats, want the one with messages", len(chats))
+	}
+	chat := chats[0]
+	if chat.SourceID != "chatgpt:c1" || chat.Title != "Sourdough" || chat.Model != "" {
+		t.Errorf("ParseImport() chat = %+v", chat)
+	}
+	if len(chat.Messages) != 2 {
+		t.Fatalf("ParseImport() returned %d messages, want 2", len(chat.Messages))
+	}
+	if chat.Messages[0].Role != RoleUser || chat.Messages[1].Content != "About 4 hours." {
+		t.Errorf("ParseImport() messages = %q, %q, want the current branch", chat.Messages[0].Content, chat.Messages[1].Content)
+	}
+	if chat.Messages[0].CreatedAt.Unix() != 1700000100 {
+		t.Errorf("ParseImport() CreatedAt = %v", chat.Messages[0].CreatedAt)
+	}
+}
+
+func TestChatGPTConversation_WithoutCurrentNode(t *testing.T) {
+	text := func(role, content string, created float64) string {
+		return `{"author": {"role": "` + role + `"}, "create_time": ` + strconv.FormatFloat(created, 'f', -1, 64) +
+			`, "content": {"content_type": "text", "parts": ["` + content + `"]}}`
+	}
+
+	// Two parentless nodes: the stray one is later than the real root
+	export := `{"id": "c2", "mapping": {
+		"stray": {"parent": "", "children": [], "message": ` + text("user", "Stray", 1700000900) + `},
+		"root": {"parent": "", "children": ["m1"], "message": null},
+		"m1": {"parent": "root", "children": ["m2"], "message": ` + text("user", "Question", 1700000100) + `},
+		"m2": {"parent": "m1", "children": [], "message": ` + text("assistant", "Answer", 1700000200) + `}
+	}}`
+	var conv chatGPTConversation
+	if err := json.Unmarshal([]byte(export), &conv); err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		chat := conv.chat()
+		if len(chat.Messages) != 2 || chat.Messages[0].Content != "Question" || chat.Messages[1].Content != "Answer" {
+			t.Fatalf("chat() messages = %d, want the branch from the earliest root", len(chat.Messages))
+		}
+	}
+
+	// A child cycle ends the walk instead of looping forever
+	cyclic := `{"id": "c3", "mapping": {
+		"root": {"parent": "", "children": ["m1"], "message": null},
+		"m1": {"parent": "root", "children": ["m2"], "message": ` + text("user", "Question", 1700000100) + `},
+		"m2": {"parent": "m1", "children": ["m1"], "message": ` + text("assistant", "Answer", 1700000200) + `}
+	}}`
+	conv = chatGPTConversation{}
+	if err := json.Unmarshal([]byte(cyclic), &conv); err != nil {
+		t.Fatal(err)
+	}
+	if chat := conv.chat(); len(chat.Messages) != 2 {
+		t.Errorf("chat() with a cycle = %d messages, want 2", len(chat.Messages))
+	}
+}
+
+func TestParseImport_OpenWebUI(t *testing.T) {
+	source, chats, err := ParseImport(strings.NewReader(openWebUIExport))
+	if err != nil {
+		t.Fatalf("ParseImport() error = %v", err)
+	}
+	if source != ImportOpenWebUI || len(chats) != 1 {
+		t.Fatalf("ParseImport() = %q with %d chats", source, len(chats))
+	}
+	chat := chats[0]
+	if chat.Title != "Go generics" || chat.Model != "llama3:latest" || chat.SystemPrompt != "Be brief." {
+		t.Errorf("ParseImport() chat = %+v", chat)
+	}
+	if len(chat.Messages) != 2 || chat.Messages[1].Content != "Type parameters." {
+		t.Errorf("ParseImport() messages = %d, want the current branch", len(chat.Messages))
+	}
+
+	// A single chat is exported as an object
+	single := strings.TrimSuffix(strings.TrimPrefix(openWebUIExport, "["), "]")
+	if _, chats, err := ParseImport(strings.NewReader(single)); err != nil || len(chats) != 1 {
+		t.Errorf("ParseImport(object) = %d chats, error %v", len(chats), err)
+	}
+}
+
+func TestParseImport_Unknown(t *testing.T) {
+	for _, input := range []string{"", "not json", `[]`, `[{"foo": 1}]`, `{"exported_at": "", "chats": []}`} {
+		if _, _, err := ParseImport(strings.NewReader(input)); !errors.Is(err, ErrUnknownImport) {
+			t.Errorf("ParseImport(%q) error = %v, want ErrUnknownImport", input, err)
+		}
+	}
+}
+
+func TestDB_ImportChats(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	_, chats, _ := ParseImport(strings.NewReader(chatGPTExport))
+	var done []int
+	result, err := db.ImportChats(chats, ImportOptions{Model: "llama3"}, func(n int) {
+		done = append(done, n)
+	})
+	if err != nil {
+		t.Fatalf("ImportChats() error = %v", err)
+	}
+	if result != (ImportResult{Imported: 1}) || len(done) != 1 {
+		t.Errorf("ImportChats() = %+v, progress %v", result, done)
+	}
+
+	list, _ := db.ListChats()
+	if len(list) != 1 {
+		t.Fatalf("ListChats() = %d chats, want 1", len(list))
+	}
+	chat := list[0]
+	if chat.Title != "Sourdough" || chat.Model != "llama3" || !chat.TitleLocked {
+		t.Errorf("imported chat = %+v", chat)
+	}
+	if chat.CreatedAt.Unix() != 1700000000 {
+		t.Errorf("imported chat CreatedAt = %v", chat.CreatedAt)
+	}
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 2 || messages[1].Content != "About 4 hours." {
+		t.Errorf("imported messages = %d", len(messages))
+	}
+
+	if count, err := db.CountImported(chats); err != nil || count != 1 {
+		t.Errorf("CountImported() = %d, %v, want 1", count, err)
+	}
+
+	tests := []struct {
+		conflict ImportConflict
+		want     ImportResult
+		chats    int
+	}{
+		{ImportSkip, ImportResult{Skipped: 1}, 1},
+		{ImportReplace, ImportResult{Imported: 1, Replaced: 1}, 1},
+		{ImportKeepBoth, ImportResult{Imported: 1}, 2},
+	}
+	for _, tt := range tests {
+		result, err := db.ImportChats(chats, ImportOptions{Conflict: tt.conflict, Model: "llama3"}, nil)
+		if err != nil {
+			t.Fatalf("ImportChats(%d) error = %v", tt.conflict, err)
+		}
+		if result != tt.want {
+			t.Errorf("ImportChats(%d) = %+v, want %+v", tt.conflict, result, tt.want)
+		}
+		if list, _ := db.ListChats(); len(list) != tt.chats {
+			t.Errorf("ImportChats(%d) left %d chats, want %d", tt.conflict, len(list), tt.chats)
+		}
+	}
+}
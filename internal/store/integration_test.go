@@ -0,0 +1,521 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// These tests open databases in files, as the app does, rather than in
+// memory: databases left by older versions, several connections at once,
+// and chats of the size long-running users have.
+
+// openSnapshot creates a database file from one of the schema snapshots in
+// testdata, as an older version of the app left it.
+func openSnapshot(t *testing.T, snapshot string) string {
+	t.Helper()
+	script, err := os.ReadFile(snapshot)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "guanaco.db")
+	sqlDB, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer sqlDB.Close()
+	if _, err := sqlDB.Exec(string(script)); err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	return path
+}
+
+// tableColumns returns the columns of each table of sqlDB, sorted, as the
+// order of columns added by migrations differs from a new database.
+func tableColumns(t *testing.T, sqlDB *sql.DB) map[string][]string {
+	t.Helper()
+	rows, err := sqlDB.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		t.Fatalf("failed to list tables: %v", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("failed to read table: %v", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	columns := make(map[string][]string)
+	for _, table := range tables {
+		rows, err := sqlDB.Query("SELECT name FROM pragma_table_info(?)", table)
+		if err != nil {
+			t.Fatalf("failed to list columns of %s: %v", table, err)
+		}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatalf("failed to read column: %v", err)
+			}
+			columns[table] = append(columns[table], name)
+		}
+		rows.Close()
+		slices.Sort(columns[table])
+	}
+	return columns
+}
+
+// countRows returns how many rows of table match where.
+func countRows(t *testing.T, db *DB, table, where string, args ...any) int {
+	t.Helper()
+	var count int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&count); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return count
+}
+
+func TestMigrations_FromSnapshots(t *testing.T) {
+	snapshots, err := filepath.Glob(filepath.Join("testdata", "schema-*.sql"))
+	if err != nil || len(snapshots) == 0 {
+		t.Fatalf("no schema snapshots found: %v", err)
+	}
+
+	fresh, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	want := tableColumns(t, fresh.db)
+	fresh.Close()
+
+	for _, snapshot := range snapshots {
+		t.Run(strings.TrimSuffix(filepath.Base(snapshot), ".sql"), func(t *testing.T) {
+			path := openSnapshot(t, snapshot)
+
+			// Opening twice runs the migrations again on an up-to-date schema
+			for range 2 {
+				db, err := NewDB(path)
+				if err != nil {
+					t.Fatalf("NewDB() error = %v", err)
+				}
+				if got := tableColumns(t, db.db); !mapsEqual(got, want) {
+					t.Errorf("migrated schema = %v, want %v", got, want)
+				}
+				db.Close()
+			}
+
+			db, err := NewDB(path)
+			if err != nil {
+				t.Fatalf("NewDB() error = %v", err)
+			}
+			defer db.Close()
+
+			chat, err := db.GetChat(1)
+			if err != nil {
+				t.Fatalf("GetChat() error = %v", err)
+			}
+			if chat.Title != "Old chat" || chat.Model != "llama2" {
+				t.Errorf("chat = %q with %q, want the old chat", chat.Title, chat.Model)
+			}
+			if chat.Archived || chat.Muted || chat.Icon != "" || chat.ParentID != 0 {
+				t.Errorf("chat has settings it never had: %+v", chat)
+			}
+			if want := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC); !chat.CreatedAt.Equal(want) {
+				t.Errorf("CreatedAt = %v, want %v", chat.CreatedAt, want)
+			}
+
+			messages, err := db.GetMessages(chat.ID)
+			if err != nil {
+				t.Fatalf("GetMessages() error = %v", err)
+			}
+			if len(messages) != 2 || messages[0].Content != "Hello" || messages[1].Role != RoleAssistant {
+				t.Fatalf("messages = %+v, want the old conversation", messages)
+			}
+			attachments, err := db.GetMessageAttachments(messages[0].ID)
+			if err != nil || len(attachments) != 1 || attachments[0].Filename != "notes.txt" {
+				t.Errorf("attachments = %+v, %v, want notes.txt", attachments, err)
+			}
+
+			// Features added since work on the old chat
+			if err := db.UpdateChatLabel(chat.ID, "📚", "blue"); err != nil {
+				t.Errorf("UpdateChatLabel() error = %v", err)
+			}
+			tag, err := db.AddTag("old")
+			if err != nil {
+				t.Fatalf("AddTag() error = %v", err)
+			}
+			if err := db.TagChat(chat.ID, tag.ID); err != nil {
+				t.Errorf("TagChat() error = %v", err)
+			}
+			if err := db.SaveDraft(&Draft{ChatID: chat.ID, Text: "Unsent"}); err != nil {
+				t.Errorf("SaveDraft() error = %v", err)
+			}
+			reply := &Message{ChatID: chat.ID, Role: RoleAssistant, Content: "Welcome back", Stats: &MessageStats{EvalTokens: 3}}
+			if err := db.SaveMessage(reply, nil); err != nil {
+				t.Errorf("SaveMessage() error = %v", err)
+			}
+			if chats, err := db.ListChats(); err != nil || len(chats) != 1 || chats[0].Icon != "📚" {
+				t.Errorf("ListChats() = %+v, %v, want the labeled chat", chats, err)
+			}
+		})
+	}
+}
+
+// mapsEqual reports whether two sets of table columns are the same.
+func mapsEqual(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for table, columns := range a {
+		if !slices.Equal(columns, b[table]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDB_ConcurrentAccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guanaco.db")
+
+	// Two connections, as with a second instance of the app
+	first, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer first.Close()
+	second, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer second.Close()
+
+	const writers, messagesEach = 4, 25
+	chats := make([]*Chat, writers)
+	for i := range chats {
+		if chats[i], err = first.CreateChat("llama3"); err != nil {
+			t.Fatalf("CreateChat() error = %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*writers)
+	done := make(chan struct{})
+
+	for i, chat := range chats {
+		// Writers take turns between the connections
+		db := first
+		if i%2 == 1 {
+			db = second
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range messagesEach {
+				msg := &Message{ChatID: chat.ID, Role: RoleUser, Content: fmt.Sprintf("Message %d", n)}
+				if err := db.SaveMessage(msg, []Attachment{{Filename: "a.txt", Content: "a"}}); err != nil {
+					errs <- fmt.Errorf("SaveMessage() in chat %d: %w", chat.ID, err)
+					return
+				}
+			}
+		}()
+	}
+
+	// Readers go on while messages are written
+	var readers sync.WaitGroup
+	for _, db := range []*DB{first, second} {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := db.ListChats(); err != nil {
+					errs <- fmt.Errorf("ListChats(): %w", err)
+					return
+				}
+				if _, err := db.GetMessages(chats[0].ID); err != nil {
+					errs <- fmt.Errorf("GetMessages(): %w", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for _, chat := range chats {
+		messages, err := second.GetMessages(chat.ID)
+		if err != nil {
+			t.Fatalf("GetMessages() error = %v", err)
+		}
+		if len(messages) != messagesEach {
+			t.Errorf("chat %d has %d messages, want %d", chat.ID, len(messages), messagesEach)
+		}
+	}
+	if got := countRows(t, first, "attachments", "1"); got != writers*messagesEach {
+		t.Errorf("%d attachments saved, want %d", got, writers*messagesEach)
+	}
+}
+
+func TestDB_DeleteChatRemovesEverything(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "guanaco.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	tag, err := db.AddTag("work")
+	if err != nil {
+		t.Fatalf("AddTag() error = %v", err)
+	}
+
+	// Fills in everything that belongs to a chat
+	fill := func() *Chat {
+		chat, err := db.CreateChat("llama3")
+		if err != nil {
+			t.Fatalf("CreateChat() error = %v", err)
+		}
+		msg := &Message{ChatID: chat.ID, Role: RoleAssistant, Content: "Answer", Stats: &MessageStats{EvalTokens: 10}}
+		if err := db.SaveMessage(msg, []Attachment{{Filename: "a.txt", Content: "a"}, {Filename: "b.txt", Content: "b"}}); err != nil {
+			t.Fatalf("SaveMessage() error = %v", err)
+		}
+		if _, err := db.AddDocument(chat.ID, "doc.txt", "Document"); err != nil {
+			t.Fatalf("AddDocument() error = %v", err)
+		}
+		if err := db.SaveDraft(&Draft{ChatID: chat.ID, Text: "Draft", Attachments: []Attachment{{Filename: "c.txt", Content: "c"}}}); err != nil {
+			t.Fatalf("SaveDraft() error = %v", err)
+		}
+		if err := db.SaveHistorySummary(&HistorySummary{ChatID: chat.ID, Content: "Summary", ThroughID: msg.ID}); err != nil {
+			t.Fatalf("SaveHistorySummary() error = %v", err)
+		}
+		if err := db.TagChat(chat.ID, tag.ID); err != nil {
+			t.Fatalf("TagChat() error = %v", err)
+		}
+		return chat
+	}
+	deleted := fill()
+	kept := fill()
+
+	if err := db.DeleteChat(deleted.ID); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+
+	byChat := []string{"messages", "documents", "drafts", "draft_attachments", "history_summaries", "chat_tags"}
+	for _, table := range byChat {
+		if got := countRows(t, db, table, "chat_id = ?", deleted.ID); got != 0 {
+			t.Errorf("%d rows of %s left after deleting the chat", got, table)
+		}
+		if got := countRows(t, db, table, "chat_id = ?", kept.ID); got == 0 {
+			t.Errorf("rows of %s of the other chat were deleted", table)
+		}
+	}
+	for _, table := range []string{"attachments", "message_stats"} {
+		if got := countRows(t, db, table, "message_id NOT IN (SELECT id FROM messages)"); got != 0 {
+			t.Errorf("%d rows of %s left without their message", got, table)
+		}
+		if got := countRows(t, db, table, "1"); got == 0 {
+			t.Errorf("rows of %s of the other chat were deleted", table)
+		}
+	}
+	if got := countRows(t, db, "tags", "id = ?", tag.ID); got != 1 {
+		t.Error("the tag was deleted with the chat")
+	}
+}
+
+// largeChat is the size of the chat of the performance tests, about a
+// year of daily use.
+const largeChat = 2000
+
+// fillChat adds count messages with an attachment every tenth message to a
+// new chat of db, in one transaction.
+func fillChat(tb testing.TB, db *DB, count int) *Chat {
+	tb.Helper()
+	chat, err := db.CreateChat("llama3")
+	if err != nil {
+		tb.Fatalf("CreateChat() error = %v", err)
+	}
+	content := strings.Repeat("Some words of a longer answer. ", 30)
+	err = db.WithTx(func(tx *sql.Tx) error {
+		for n := range count {
+			result, err := tx.Exec("INSERT INTO messages (chat_id, role, content, created_at) VALUES (?, ?, ?, ?)",
+				chat.ID, RoleAssistant, content, time.Now())
+			if err != nil {
+				return err
+			}
+			if n%10 == 0 {
+				id, _ := result.LastInsertId()
+				if _, err := tx.Exec("INSERT INTO attachments (message_id, filename, content) VALUES (?, ?, ?)", id, "file.txt", content); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("failed to fill chat: %v", err)
+	}
+	return chat
+}
+
+func TestDB_LargeChat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large chat in short mode")
+	}
+	db, err := NewDB(filepath.Join(t.TempDir(), "guanaco.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+	chat := fillChat(t, db, largeChat)
+
+	messages, err := db.GetMessages(chat.ID)
+	if err != nil {
+		t.Fatalf("GetMessages() error = %v", err)
+	}
+	if len(messages) != largeChat {
+		t.Fatalf("GetMessages() returned %d messages, want %d", len(messages), largeChat)
+	}
+	ids := make([]int64, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	attachments, err := db.GetAttachmentsForMessages(ids)
+	if err != nil {
+		t.Fatalf("GetAttachmentsForMessages() error = %v", err)
+	}
+	if len(attachments) != largeChat/10 {
+		t.Errorf("%d messages with attachments, want %d", len(attachments), largeChat/10)
+	}
+
+	page, err := db.GetMessagesPage(chat.ID, 50, 0)
+	if err != nil || len(page) != 50 {
+		t.Fatalf("GetMessagesPage() = %d messages, %v, want 50", len(page), err)
+	}
+	if page[49].ID != ids[largeChat-1] || page[0].ID != ids[largeChat-50] {
+		t.Errorf("GetMessagesPage() = %d to %d, want the last 50 messages", page[0].ID, page[49].ID)
+	}
+
+	if err := db.DeleteChat(chat.ID); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+	if got := countRows(t, db, "messages", "chat_id = ?", chat.ID); got != 0 {
+		t.Errorf("%d messages left after DeleteChat()", got)
+	}
+	if got := countRows(t, db, "attachments", "1"); got != 0 {
+		t.Errorf("%d attachments left after DeleteChat()", got)
+	}
+
+	// Timings are measured by the benchmarks; the plans only catch a lost index
+	plans := []struct {
+		query string
+		args  []any
+		index string
+	}{
+		{"SELECT id FROM messages WHERE chat_id = ? AND superseded = 0 ORDER BY created_at ASC",
+			[]any{chat.ID}, "idx_messages_chat_id"},
+		{"SELECT id FROM messages WHERE chat_id = ? AND superseded = 0 AND (? = 0 OR id < ?) ORDER BY id DESC LIMIT ?",
+			[]any{chat.ID, 0, 0, 50}, "idx_messages_chat_id"},
+		{"SELECT id FROM attachments WHERE message_id IN (?, ?)",
+			[]any{ids[0], ids[1]}, "idx_attachments_message_id"},
+	}
+	for _, p := range plans {
+		plan := queryPlan(t, db.db, p.query, p.args...)
+		if !strings.Contains(plan, "INDEX "+p.index) {
+			t.Errorf("plan of %q = %q, want %s used", p.query, plan, p.index)
+		}
+	}
+	// The last page is read backwards along the index, without sorting
+	if plan := queryPlan(t, db.db, plans[1].query, plans[1].args...); strings.Contains(plan, "TEMP B-TREE") {
+		t.Errorf("plan of the last page = %q, want no sort", plan)
+	}
+}
+
+// queryPlan returns the steps of the plan SQLite chooses for query, one per
+// line.
+func queryPlan(t *testing.T, db *sql.DB, query string, args ...any) string {
+	t.Helper()
+	rows, err := db.Query("EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		t.Fatalf("EXPLAIN QUERY PLAN error = %v", err)
+	}
+	defer rows.Close()
+
+	var steps []string
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatalf("failed to scan plan: %v", err)
+		}
+		steps = append(steps, detail)
+	}
+	return strings.Join(steps, "\n")
+}
+
+func BenchmarkDB_SaveMessage(b *testing.B) {
+	db, err := NewDB(filepath.Join(b.TempDir(), "guanaco.db"))
+	if err != nil {
+		b.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+	chat, _ := db.CreateChat("llama3")
+
+	for b.Loop() {
+		msg := &Message{ChatID: chat.ID, Role: RoleUser, Content: "Hello", Stats: &MessageStats{EvalTokens: 1}}
+		if err := db.SaveMessage(msg, nil); err != nil {
+			b.Fatalf("SaveMessage() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkDB_GetMessages(b *testing.B) {
+	db, err := NewDB(filepath.Join(b.TempDir(), "guanaco.db"))
+	if err != nil {
+		b.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+	chat := fillChat(b, db, largeChat)
+
+	for b.Loop() {
+		if _, err := db.GetMessages(chat.ID); err != nil {
+			b.Fatalf("GetMessages() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkDB_ListChats(b *testing.B) {
+	db, err := NewDB(filepath.Join(b.TempDir(), "guanaco.db"))
+	if err != nil {
+		b.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+	for range 500 {
+		if _, err := db.CreateChat("llama3"); err != nil {
+			b.Fatalf("CreateChat() error = %v", err)
+		}
+	}
+
+	for b.Loop() {
+		if _, err := db.ListChats(); err != nil {
+			b.Fatalf("ListChats() error = %v", err)
+		}
+	}
+}
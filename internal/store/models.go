@@ -1,7 +1,10 @@
 // Package store provides data persistence using SQLite.
 package store
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Role represents the sender of a message in a chat.
 type Role string
@@ -12,23 +15,104 @@ const (
 	RoleSystem    Role = "system"
 )
 
+// CompletionMode selects how a chat's responses are requested from Ollama.
+type CompletionMode string
+
+const (
+	// CompletionChat uses the chat API and the model's chat template.
+	CompletionChat CompletionMode = ""
+	// CompletionGenerate uses the generate API with the model's template,
+	// or the chat's template override.
+	CompletionGenerate CompletionMode = "generate"
+	// CompletionRaw uses the generate API and sends the prompt as is, for
+	// base models without a chat template.
+	CompletionRaw CompletionMode = "raw"
+)
+
 // Chat represents a conversation with the AI.
 type Chat struct {
-	ID           int64     `json:"id"`
-	Title        string    `json:"title"`
-	Model        string    `json:"model"`
-	SystemPrompt string    `json:"system_prompt"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             int64          `json:"id"`
+	Title          string         `json:"title"`
+	Model          string         `json:"model"`
+	SystemPrompt   string         `json:"system_prompt"`
+	Language       string         `json:"language"` // Overrides the global response language when set
+	CompletionMode CompletionMode `json:"completion_mode,omitempty"`
+	Template       string         `json:"template,omitempty"`      // Prompt template override for CompletionGenerate
+	KeepAlive      string         `json:"keep_alive,omitempty"`    // How long the model stays loaded, e.g. "10m"
+	OutputFormat   string         `json:"output_format,omitempty"` // "json", a JSON schema responses follow, or empty for text
+	ParentID       int64          `json:"parent_id,omitempty"`     // Chat this one was branched from, 0 if none
+	TitleLocked    bool           `json:"-"`                       // Renamed by the user, so no title is generated
+	WorkDir        string         `json:"-"`                       // Folder the model may read files from, empty if none
+	Archived       bool           `json:"archived,omitempty"`      // Hidden from the chat list until shown
+	Muted          bool           `json:"-"`                       // Responses aren't read aloud, see config.AppConfig.ReadAloud
+	Icon           string         `json:"icon,omitempty"`          // Emoji shown before the title, empty if none
+	Color          string         `json:"color,omitempty"`         // Name of the color marking the chat in the list, empty if none
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 }
 
 // Message represents a single message in a chat.
 type Message struct {
-	ID        int64     `json:"id"`
-	ChatID    int64     `json:"chat_id"`
-	Role      Role      `json:"role"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int64     `json:"id"`
+	ChatID      int64     `json:"chat_id"`
+	Role        Role      `json:"role"`
+	Content     string    `json:"content"`
+	Critique    string    `json:"critique,omitempty"`     // Self-review notes for a revised answer
+	Truncated   bool      `json:"truncated,omitempty"`    // Generation was stopped before the model finished
+	Replaces    int64     `json:"-"`                      // Response this one regenerated, 0 if none
+	Bookmarked  bool      `json:"bookmarked,omitempty"`   // Marked by the user as a good example
+	Rating      int       `json:"rating,omitempty"`       // RatingUp, RatingDown or RatingNone
+	Annotation  string    `json:"annotation,omitempty"`   // The user's note about the message
+	ModelSwitch bool      `json:"model_switch,omitempty"` // System marker: the chat went on with the model named in the content
+	Agent       string    `json:"agent,omitempty"`        // Multi-agent participant who wrote it, as "Name · model"
+	AgentSlot   int       `json:"agent_slot,omitempty"`   // Which of the participants, for its styling
+	CreatedAt   time.Time `json:"created_at"`
+
+	Stats *MessageStats `json:"-"` // Token usage of a generated message, saved with it when set
+}
+
+// Ratings the user can give to a response.
+const (
+	RatingNone = 0
+	RatingUp   = 1
+	RatingDown = -1
+)
+
+// MessageStats are the token counts and timings Ollama reported for a
+// generated message. Durations are stored in nanoseconds.
+type MessageStats struct {
+	MessageID      int64
+	PromptTokens   int
+	EvalTokens     int
+	TotalDuration  time.Duration
+	LoadDuration   time.Duration
+	PromptDuration time.Duration
+	EvalDuration   time.Duration
+}
+
+// ChatStats sums the usage of the current messages of a chat.
+type ChatStats struct {
+	Messages     int // Messages that haven't been superseded by an edit
+	Responses    int // Assistant messages
+	RatedUp      int // Responses rated as good
+	RatedDown    int // Responses rated as bad
+	Annotated    int // Messages with a note
+	Measured     int // Messages with statistics
+	Characters   int // Characters of all message contents
+	PromptTokens int
+	EvalTokens   int
+	EvalDuration time.Duration
+	TotalTime    time.Duration
+}
+
+// Usage is the token usage of one generated message.
+type Usage struct {
+	ChatID       int64
+	ChatTitle    string
+	Model        string // Model of the chat
+	CreatedAt    time.Time
+	PromptTokens int
+	EvalTokens   int
 }
 
 // Attachment represents a file attached to a message.
@@ -39,6 +123,58 @@ type Attachment struct {
 	Content   string `json:"content"`
 }
 
+// HistorySummary condenses the start of a long chat. It is sent to the
+// model in place of the messages up to ThroughID, which stay in the
+// transcript.
+type HistorySummary struct {
+	ChatID    int64
+	Content   string
+	ThroughID int64 // Last message covered
+	CreatedAt time.Time
+}
+
+// Draft is the unsent input of a chat: the text being typed and the files
+// attached to it, kept when another chat is opened or the app closes.
+type Draft struct {
+	ChatID      int64
+	Text        string
+	Attachments []Attachment // MessageID is unused
+	UpdatedAt   time.Time
+}
+
+// Empty reports whether the draft has neither text nor attachments.
+func (d *Draft) Empty() bool {
+	return strings.TrimSpace(d.Text) == "" && len(d.Attachments) == 0
+}
+
+// PromptTemplate is a saved prompt that can be inserted in the input,
+// with {{name}} placeholders filled in when it is used.
+type PromptTemplate struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Tag is a label, such as "work" or "personal", used to group chats.
+// Names are unique, ignoring case.
+type Tag struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Document is a file in a chat's library. Unlike attachments, documents
+// belong to the chat and are part of the context of every request.
+type Document struct {
+	ID        int64     `json:"id"`
+	ChatID    int64     `json:"chat_id"`
+	Filename  string    `json:"filename"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // NewChat creates a new Chat with default values.
 func NewChat(model string) *Chat {
 	now := time.Now()
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// UpdateChatModel sets the model a chat goes on with.
+func (d *DB) UpdateChatModel(id int64, model string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("UPDATE chats SET model = ?, updated_at = ? WHERE id = ?", model, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update chat model: %w", err)
+	}
+	return nil
+}
+
+// SwitchChatModel sets the model of a chat and records the change in one
+// transaction, with a system message marked ModelSwitch whose content is
+// the new model, so that the history tells which model wrote the answers
+// that follow. The saved marker is returned. Markers are shown in the
+// transcript but never sent to a model.
+func (d *DB) SwitchChatModel(chatID int64, model string) (*Message, error) {
+	msg := NewMessage(chatID, RoleSystem, model)
+	msg.ModelSwitch = true
+	err := d.WithTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("UPDATE chats SET model = ?, updated_at = ? WHERE id = ?", model, msg.CreatedAt, chatID); err != nil {
+			return fmt.Errorf("failed to update chat model: %w", err)
+		}
+		result, err := tx.Exec(
+			"INSERT INTO messages (chat_id, role, content, model_switch, created_at) VALUES (?, ?, ?, ?, ?)",
+			chatID, msg.Role, msg.Content, msg.ModelSwitch, msg.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add message: %w", err)
+		}
+		msg.ID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
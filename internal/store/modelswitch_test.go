@@ -0,0 +1,93 @@
+package store
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDB_SwitchChatModel(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	db.AddMessage(chat.ID, RoleUser, "Hello")
+	db.AddMessage(chat.ID, RoleAssistant, "Hi!")
+
+	marker, err := db.SwitchChatModel(chat.ID, "mistral")
+	if err != nil {
+		t.Fatalf("SwitchChatModel() error = %v", err)
+	}
+	if marker.ID == 0 || !marker.ModelSwitch || marker.Content != "mistral" {
+		t.Errorf("SwitchChatModel() = %+v, want a saved mistral marker", marker)
+	}
+
+	got, _ := db.GetChat(chat.ID)
+	if got.Model != "mistral" {
+		t.Errorf("Model = %q, want mistral", got.Model)
+	}
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 3 || messages[2].ID != marker.ID || !messages[2].ModelSwitch {
+		t.Errorf("GetMessages() = %+v, want the marker last", messages)
+	}
+}
+
+func TestDB_UpdateChatModel(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	if err := db.UpdateChatModel(chat.ID, "mistral"); err != nil {
+		t.Fatalf("UpdateChatModel() error = %v", err)
+	}
+	got, _ := db.GetChat(chat.ID)
+	if got.Model != "mistral" {
+		t.Errorf("Model = %q, want mistral", got.Model)
+	}
+	if messages, _ := db.GetMessages(chat.ID); len(messages) != 0 {
+		t.Errorf("GetMessages() = %+v, want no marker", messages)
+	}
+}
+
+func TestWriteExport_ModelSwitch(t *testing.T) {
+	db, chat := newExportTestDB(t)
+	db.SwitchChatModel(chat.ID, "mistral")
+	chats, err := db.LoadExport([]int64{chat.ID})
+	if err != nil {
+		t.Fatalf("LoadExport() error = %v", err)
+	}
+
+	tests := map[ExportFormat]string{
+		ExportMarkdown: "\n*Switched to mistral*\n",
+		ExportText:     "] Switched to mistral\n",
+	}
+	for format, want := range tests {
+		var buf bytes.Buffer
+		if err := WriteExport(&buf, chats, format); err != nil {
+			t.Fatalf("WriteExport(%s) error = %v", format, err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteExport(%s) missing %q:\n%s", format, want, buf.String())
+		}
+	}
+}
+
+func TestWriteTrainingExport_SkipsModelSwitch(t *testing.T) {
+	db, chat := newExportTestDB(t)
+	db.SwitchChatModel(chat.ID, "mistral")
+	chats, _ := db.LoadExport([]int64{chat.ID})
+
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, chats, ExportOpenAI); err != nil {
+		t.Fatalf("WriteExport() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "mistral") {
+		t.Errorf("training export includes the model switch:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+)
+
+// GetMessagesPage returns up to limit current messages of a chat that come
+// before the message beforeID, oldest first, so long chats can be loaded a
+// page at a time from the end. A beforeID of 0 returns the latest page,
+// which also holds the messages waiting to be saved.
+func (d *DB) GetMessagesPage(chatID int64, limit int, beforeID int64) ([]*Message, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	query := `
+		SELECT id, chat_id, role, content, critique, truncated, replaces, bookmarked, rating, annotation, model_switch, agent, agent_slot, created_at
+		FROM messages WHERE chat_id = ? AND superseded = 0 AND (? = 0 OR id < ?)
+		ORDER BY id DESC LIMIT ?`
+	rows, err := d.db.Query(query, chatID, beforeID, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		msg := &Message{}
+		err := rows.Scan(
+			&msg.ID,
+			&msg.ChatID,
+			&msg.Role,
+			&msg.Content,
+			&msg.Critique,
+			&msg.Truncated,
+			&msg.Replaces,
+			&msg.Bookmarked,
+			&msg.Rating,
+			&msg.Annotation,
+			&msg.ModelSwitch,
+			&msg.Agent,
+			&msg.AgentSlot,
+			&msg.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Reverse(messages)
+
+	if beforeID == 0 {
+		messages = append(messages, d.pendingMessages(chatID)...)
+	}
+	return messages, nil
+}
+
+// CountMessagesBefore returns how many current messages of a chat come
+// before the message beforeID.
+func (d *DB) CountMessagesBefore(chatID, beforeID int64) (int, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	var count int
+	err := d.db.QueryRow(
+		"SELECT COUNT(*) FROM messages WHERE chat_id = ? AND superseded = 0 AND id < ?",
+		chatID, beforeID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDB_GetMessagesPage(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	other, _ := db.CreateChat("llama3")
+	var ids []int64
+	for i := 1; i <= 7; i++ {
+		msg, _ := db.AddMessage(chat.ID, RoleUser, fmt.Sprintf("Message %d", i))
+		ids = append(ids, msg.ID)
+		db.AddMessage(other.ID, RoleUser, "Other chat")
+	}
+
+	contents := func(messages []*Message) string {
+		var s string
+		for _, msg := range messages {
+			s += msg.Content[len("Message "):]
+		}
+		return s
+	}
+
+	latest, err := db.GetMessagesPage(chat.ID, 3, 0)
+	if err != nil {
+		t.Fatalf("GetMessagesPage() error = %v", err)
+	}
+	if got := contents(latest); got != "567" {
+		t.Errorf("latest page = %q, want messages 567", got)
+	}
+
+	earlier, _ := db.GetMessagesPage(chat.ID, 3, latest[0].ID)
+	if got := contents(earlier); got != "234" {
+		t.Errorf("earlier page = %q, want messages 234", got)
+	}
+	first, _ := db.GetMessagesPage(chat.ID, 3, earlier[0].ID)
+	if got := contents(first); got != "1" {
+		t.Errorf("first page = %q, want message 1", got)
+	}
+
+	t.Run("count before", func(t *testing.T) {
+		for _, tt := range []struct {
+			before int64
+			want   int
+		}{{latest[0].ID, 4}, {earlier[0].ID, 1}, {ids[0], 0}} {
+			got, err := db.CountMessagesBefore(chat.ID, tt.before)
+			if err != nil {
+				t.Fatalf("CountMessagesBefore() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CountMessagesBefore(%d) = %d, want %d", tt.before, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("superseded messages are left out", func(t *testing.T) {
+		db.SupersedeMessagesFrom(chat.ID, ids[5])
+		page, _ := db.GetMessagesPage(chat.ID, 3, 0)
+		if got := contents(page); got != "345" {
+			t.Errorf("latest page = %q, want messages 345", got)
+		}
+	})
+
+	t.Run("pending messages are in the latest page", func(t *testing.T) {
+		db.BufferMessage(&Message{ChatID: chat.ID, Role: RoleAssistant, Content: "Message 8"}, nil)
+		page, _ := db.GetMessagesPage(chat.ID, 3, 0)
+		if got := contents(page); got != "3458" {
+			t.Errorf("latest page = %q, want messages 3458", got)
+		}
+		page, _ = db.GetMessagesPage(chat.ID, 3, page[0].ID)
+		if got := contents(page); got != "12" {
+			t.Errorf("earlier page = %q, want messages 12", got)
+		}
+	})
+}
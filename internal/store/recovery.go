@@ -0,0 +1,212 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// FailureKind classifies a database error by its cause.
+type FailureKind int
+
+const (
+	// FailureOther is an error with no known cause, e.g. a constraint violation.
+	FailureOther FailureKind = iota
+	// FailureBusy means the database was locked by another connection.
+	FailureBusy
+	// FailureDiskFull means there is no space left to write.
+	FailureDiskFull
+	// FailureIO is a read or write error from the filesystem.
+	FailureIO
+	// FailureReadOnly means the database file can't be written.
+	FailureReadOnly
+	// FailureCorrupt means the database file is damaged.
+	FailureCorrupt
+	// FailureClosed means the connection is no longer usable.
+	FailureClosed
+)
+
+// String returns a short name for logging.
+func (k FailureKind) String() string {
+	switch k {
+	case FailureBusy:
+		return "busy"
+	case FailureDiskFull:
+		return "disk full"
+	case FailureIO:
+		return "I/O error"
+	case FailureReadOnly:
+		return "read-only"
+	case FailureCorrupt:
+		return "corrupt"
+	case FailureClosed:
+		return "closed"
+	default:
+		return "other"
+	}
+}
+
+// Recoverable reports whether the failure may go away on its own, so that
+// reconnecting and writing again is worth trying.
+func (k FailureKind) Recoverable() bool {
+	switch k {
+	case FailureBusy, FailureDiskFull, FailureIO, FailureReadOnly, FailureClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// ClassifyError returns the kind of failure behind a database error.
+func ClassifyError(err error) FailureKind {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// Extended result codes keep the primary code in the low byte
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+			return FailureBusy
+		case sqlite3.SQLITE_FULL:
+			return FailureDiskFull
+		case sqlite3.SQLITE_IOERR, sqlite3.SQLITE_CANTOPEN:
+			return FailureIO
+		case sqlite3.SQLITE_READONLY:
+			return FailureReadOnly
+		case sqlite3.SQLITE_CORRUPT, sqlite3.SQLITE_NOTADB:
+			return FailureCorrupt
+		}
+		return FailureOther
+	}
+
+	if errors.Is(err, sql.ErrConnDone) {
+		return FailureClosed
+	}
+	// database/sql doesn't export these errors
+	if err != nil && (strings.Contains(err.Error(), "sql: database is closed") ||
+		strings.Contains(err.Error(), "sql: statement is closed")) {
+		return FailureClosed
+	}
+	return FailureOther
+}
+
+// Reconnect closes the connection and opens the database again, e.g. after
+// an I/O error left it unusable. On failure the old connection is kept. An
+// in-memory database comes back empty. It waits for the queries running in
+// other goroutines to finish, and holds new ones until it is done.
+func (d *DB) Reconnect() error {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	sqlDB, err := openDB(d.path)
+	if err != nil {
+		return err
+	}
+
+	old := d.db
+	d.closeStatements()
+	d.db = sqlDB
+	if err := d.prepareStatements(); err != nil {
+		d.db = old
+		sqlDB.Close()
+		if err := d.prepareStatements(); err != nil {
+			return fmt.Errorf("failed to restore connection: %w", err)
+		}
+		return err
+	}
+	old.Close()
+	return nil
+}
+
+// PendingMessage is a message kept in memory because it couldn't be saved.
+// Its ID is set once FlushPending writes it.
+type PendingMessage struct {
+	Message     *Message
+	Attachments []Attachment
+}
+
+// BufferMessage keeps a message in memory to be saved by FlushPending. Its
+// creation time is kept so it is ordered correctly once written.
+func (d *DB) BufferMessage(msg *Message, attachments []Attachment) *PendingMessage {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	p := &PendingMessage{Message: msg, Attachments: attachments}
+
+	d.pendingMu.Lock()
+	d.pending = append(d.pending, p)
+	d.pendingMu.Unlock()
+	return p
+}
+
+// PendingCount returns how many messages are waiting to be saved.
+func (d *DB) PendingCount() int {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+	return len(d.pending)
+}
+
+// pendingMessages returns copies of the buffered messages of a chat.
+func (d *DB) pendingMessages(chatID int64) []*Message {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+
+	var messages []*Message
+	for _, p := range d.pending {
+		if p.Message.ChatID == chatID {
+			msg := *p.Message
+			messages = append(messages, &msg)
+		}
+	}
+	return messages
+}
+
+// discardPending drops the buffered messages of a deleted chat.
+func (d *DB) discardPending(chatID int64) {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+
+	kept := d.pending[:0]
+	for _, p := range d.pending {
+		if p.Message.ChatID != chatID {
+			kept = append(kept, p)
+		}
+	}
+	d.pending = kept
+}
+
+// DiscardPending drops a buffered message before it is written.
+func (d *DB) DiscardPending(p *PendingMessage) {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+
+	d.pending = slices.DeleteFunc(d.pending, func(other *PendingMessage) bool {
+		return other == p
+	})
+}
+
+// FlushPending writes the buffered messages in order, each with its
+// attachments in one transaction. It stops at the first failure, keeping
+// that message and the ones after it, and returns the messages written.
+func (d *DB) FlushPending() ([]*PendingMessage, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+
+	var flushed []*PendingMessage
+	for len(d.pending) > 0 {
+		p := d.pending[0]
+		if err := d.saveMessage(p.Message, p.Attachments); err != nil {
+			return flushed, err
+		}
+		flushed = append(flushed, p)
+		d.pending = d.pending[1:]
+	}
+	d.pending = nil
+	return flushed, nil
+}
@@ -0,0 +1,291 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Run("constraint violation", func(t *testing.T) {
+		db, err := NewDB(":memory:")
+		if err != nil {
+			t.Fatalf("NewDB() error = %v", err)
+		}
+		defer db.Close()
+
+		chat, _ := db.CreateChat("llama3")
+		_, err = db.AddMessage(chat.ID, Role("bogus"), "Hello")
+		if got := ClassifyError(err); got != FailureOther {
+			t.Errorf("ClassifyError(%v) = %v, want %v", err, got, FailureOther)
+		}
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		db, err := NewDB(":memory:")
+		if err != nil {
+			t.Fatalf("NewDB() error = %v", err)
+		}
+		defer db.Close()
+
+		if _, err := db.db.Exec("PRAGMA query_only = ON"); err != nil {
+			t.Fatalf("PRAGMA error = %v", err)
+		}
+		_, err = db.CreateChat("llama3")
+		if got := ClassifyError(err); got != FailureReadOnly {
+			t.Errorf("ClassifyError(%v) = %v, want %v", err, got, FailureReadOnly)
+		}
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "guanaco.db")
+		garbage := make([]byte, 4096)
+		for i := range garbage {
+			garbage[i] = 'x'
+		}
+		if err := os.WriteFile(path, garbage, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := NewDB(path)
+		if got := ClassifyError(err); got != FailureCorrupt {
+			t.Errorf("ClassifyError(%v) = %v, want %v", err, got, FailureCorrupt)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		db, err := NewDB(":memory:")
+		if err != nil {
+			t.Fatalf("NewDB() error = %v", err)
+		}
+		db.Close()
+
+		_, err = db.CreateChat("llama3")
+		if got := ClassifyError(err); got != FailureClosed {
+			t.Errorf("ClassifyError(%v) = %v, want %v", err, got, FailureClosed)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if got := ClassifyError(nil); got != FailureOther {
+			t.Errorf("ClassifyError(nil) = %v, want %v", got, FailureOther)
+		}
+	})
+}
+
+func TestFailureKind_Recoverable(t *testing.T) {
+	tests := []struct {
+		kind FailureKind
+		want bool
+	}{
+		{FailureOther, false},
+		{FailureBusy, true},
+		{FailureDiskFull, true},
+		{FailureIO, true},
+		{FailureReadOnly, true},
+		{FailureCorrupt, false},
+		{FailureClosed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			if got := tt.kind.Recoverable(); got != tt.want {
+				t.Errorf("Recoverable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_Reconnect(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "guanaco.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	db.AddMessage(chat.ID, RoleUser, "Before")
+
+	// Simulate a connection that went bad
+	db.db.Close()
+	if _, err := db.AddMessage(chat.ID, RoleUser, "Lost"); ClassifyError(err) != FailureClosed {
+		t.Fatalf("AddMessage() on closed connection error = %v", err)
+	}
+
+	if err := db.Reconnect(); err != nil {
+		t.Fatalf("Reconnect() error = %v", err)
+	}
+	if _, err := db.AddMessage(chat.ID, RoleUser, "After"); err != nil {
+		t.Fatalf("AddMessage() after Reconnect() error = %v", err)
+	}
+
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 2 || messages[0].Content != "Before" || messages[1].Content != "After" {
+		t.Errorf("GetMessages() = %+v, want Before and After", messages)
+	}
+}
+
+func TestDB_ReconnectWhileQuerying(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "guanaco.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	for i := 0; i < 20; i++ {
+		db.AddMessage(chat.ID, RoleUser, "Hello")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				if _, err := db.GetMessagesPage(chat.ID, 5, 0); err != nil {
+					errs <- err
+				}
+				if _, err := db.CountMessagesBefore(chat.ID, 10); err != nil {
+					errs <- err
+				}
+				if err := db.UpdateChatTitle(chat.ID, "Title"); err != nil {
+					errs <- err
+				}
+				pending := db.BufferMessage(&Message{ChatID: chat.ID, Role: RoleUser, Content: "Buffered"}, nil)
+				if _, err := db.FlushPending(); err != nil {
+					db.DiscardPending(pending)
+					errs <- err
+				}
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := db.Reconnect(); err != nil {
+			t.Errorf("Reconnect() error = %v", err)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("query during Reconnect() error = %v", err)
+	}
+}
+
+func TestDB_FlushPending(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "guanaco.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	db.AddMessage(chat.ID, RoleUser, "Saved")
+
+	question := db.BufferMessage(&Message{ChatID: chat.ID, Role: RoleUser, Content: "Buffered"},
+		[]Attachment{{Filename: "notes.txt", Content: "notes"}})
+	db.BufferMessage(&Message{ChatID: chat.ID, Role: RoleAssistant, Content: "Answer", Critique: "Fine"}, nil)
+
+	if got := db.PendingCount(); got != 2 {
+		t.Fatalf("PendingCount() = %d, want 2", got)
+	}
+
+	// Buffered messages are visible before they are saved
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 3 || messages[1].ID != 0 || messages[2].Content != "Answer" {
+		t.Fatalf("GetMessages() before flush = %+v", messages)
+	}
+
+	// A failed flush keeps everything buffered
+	db.db.Close()
+	if _, err := db.FlushPending(); err == nil {
+		t.Fatal("FlushPending() on closed connection should fail")
+	}
+	if got := db.PendingCount(); got != 2 {
+		t.Fatalf("PendingCount() after failed flush = %d, want 2", got)
+	}
+
+	if err := db.Reconnect(); err != nil {
+		t.Fatalf("Reconnect() error = %v", err)
+	}
+	flushed, err := db.FlushPending()
+	if err != nil {
+		t.Fatalf("FlushPending() error = %v", err)
+	}
+	if len(flushed) != 2 || flushed[0] != question || question.Message.ID == 0 {
+		t.Fatalf("FlushPending() = %+v, want both messages with IDs", flushed)
+	}
+	if got := db.PendingCount(); got != 0 {
+		t.Errorf("PendingCount() after flush = %d, want 0", got)
+	}
+
+	messages, _ = db.GetMessages(chat.ID)
+	if len(messages) != 3 {
+		t.Fatalf("GetMessages() after flush returned %d messages, want 3", len(messages))
+	}
+	if messages[1].ID != question.Message.ID || messages[2].Critique != "Fine" {
+		t.Errorf("GetMessages() after flush = %+v", messages)
+	}
+
+	attachments, _ := db.GetMessageAttachments(question.Message.ID)
+	if len(attachments) != 1 || attachments[0].Filename != "notes.txt" {
+		t.Errorf("GetMessageAttachments() = %+v, want notes.txt", attachments)
+	}
+}
+
+func TestDB_DeleteChatDiscardsPending(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	deleted, _ := db.CreateChat("llama3")
+	kept, _ := db.CreateChat("llama3")
+	db.BufferMessage(&Message{ChatID: deleted.ID, Role: RoleUser, Content: "Gone"}, nil)
+	db.BufferMessage(&Message{ChatID: kept.ID, Role: RoleUser, Content: "Kept"}, nil)
+
+	if err := db.DeleteChat(deleted.ID); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+	if got := db.PendingCount(); got != 1 {
+		t.Fatalf("PendingCount() = %d, want 1", got)
+	}
+
+	// Flushing must not fail on the deleted chat's message
+	if _, err := db.FlushPending(); err != nil {
+		t.Fatalf("FlushPending() error = %v", err)
+	}
+	messages, _ := db.GetMessages(kept.ID)
+	if len(messages) != 1 || messages[0].Content != "Kept" {
+		t.Errorf("GetMessages() = %+v, want Kept", messages)
+	}
+}
+
+func TestDB_DiscardPending(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	discarded := db.BufferMessage(&Message{ChatID: chat.ID, Role: RoleUser, Content: "Taken back"}, nil)
+	db.BufferMessage(&Message{ChatID: chat.ID, Role: RoleUser, Content: "Kept"}, nil)
+
+	db.DiscardPending(discarded)
+	if got := db.PendingCount(); got != 1 {
+		t.Fatalf("PendingCount() = %d, want 1", got)
+	}
+
+	if _, err := db.FlushPending(); err != nil {
+		t.Fatalf("FlushPending() error = %v", err)
+	}
+	messages, _ := db.GetMessages(chat.ID)
+	if len(messages) != 1 || messages[0].Content != "Kept" {
+		t.Errorf("GetMessages() = %+v, want Kept", messages)
+	}
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// saveMessageStats writes stats for their message with e, replacing any
+// it already had.
+func saveMessageStats(e execer, stats *MessageStats) error {
+	_, err := e.Exec(
+		`INSERT OR REPLACE INTO message_stats
+			(message_id, prompt_tokens, eval_tokens, total_duration, load_duration, prompt_duration, eval_duration)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		stats.MessageID, stats.PromptTokens, stats.EvalTokens,
+		int64(stats.TotalDuration), int64(stats.LoadDuration), int64(stats.PromptDuration), int64(stats.EvalDuration),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save message stats: %w", err)
+	}
+	return nil
+}
+
+// SaveMessageStats stores the statistics of a message, replacing any it
+// already had.
+func (d *DB) SaveMessageStats(stats *MessageStats) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	return saveMessageStats(d.db, stats)
+}
+
+// GetMessageStats returns the statistics of the given messages in a single
+// query, keyed by message ID. Messages without statistics are left out.
+func (d *DB) GetMessageStats(messageIDs []int64) (map[int64]*MessageStats, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	result := make(map[int64]*MessageStats)
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(messageIDs))
+	args := make([]interface{}, len(messageIDs))
+	for i, id := range messageIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(
+		`SELECT message_id, prompt_tokens, eval_tokens, total_duration, load_duration, prompt_duration, eval_duration
+			FROM message_stats WHERE message_id IN (%s)`,
+		strings.Join(placeholders, ","),
+	)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s MessageStats
+		var total, load, prompt, eval int64
+		if err := rows.Scan(&s.MessageID, &s.PromptTokens, &s.EvalTokens, &total, &load, &prompt, &eval); err != nil {
+			return nil, fmt.Errorf("failed to scan message stats: %w", err)
+		}
+		s.TotalDuration = time.Duration(total)
+		s.LoadDuration = time.Duration(load)
+		s.PromptDuration = time.Duration(prompt)
+		s.EvalDuration = time.Duration(eval)
+		result[s.MessageID] = &s
+	}
+	return result, rows.Err()
+}
+
+// GetChatStats sums the statistics of the current messages of a chat.
+func (d *DB) GetChatStats(chatID int64) (*ChatStats, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	var stats ChatStats
+	var evalDuration, totalTime int64
+	err := d.db.QueryRow(`
+		SELECT COUNT(*),
+			COUNT(CASE WHEN m.role = 'assistant' THEN 1 END),
+			COUNT(CASE WHEN m.rating > 0 THEN 1 END),
+			COUNT(CASE WHEN m.rating < 0 THEN 1 END),
+			COUNT(CASE WHEN m.annotation != '' THEN 1 END),
+			COUNT(s.message_id),
+			COALESCE(SUM(LENGTH(m.content)), 0),
+			COALESCE(SUM(s.prompt_tokens), 0),
+			COALESCE(SUM(s.eval_tokens), 0),
+			COALESCE(SUM(s.eval_duration), 0),
+			COALESCE(SUM(s.total_duration), 0)
+		FROM messages m
+		LEFT JOIN message_stats s ON s.message_id = m.id
+		WHERE m.chat_id = ? AND m.superseded = 0`,
+		chatID,
+	).Scan(&stats.Messages, &stats.Responses, &stats.RatedUp, &stats.RatedDown, &stats.Annotated, &stats.Measured, &stats.Characters,
+		&stats.PromptTokens, &stats.EvalTokens, &evalDuration, &totalTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat stats: %w", err)
+	}
+	stats.EvalDuration = time.Duration(evalDuration)
+	stats.TotalTime = time.Duration(totalTime)
+	return &stats, nil
+}
+
+// ListUsage returns the statistics of the messages generated since the
+// given time, oldest first, with the chat each belongs to. Messages that
+// were superseded by an edit are included, as their tokens were spent all
+// the same.
+func (d *DB) ListUsage(since time.Time) ([]Usage, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT c.id, c.title, c.model, m.created_at, s.prompt_tokens, s.eval_tokens
+		FROM message_stats s
+		JOIN messages m ON m.id = s.message_id
+		JOIN chats c ON c.id = m.chat_id
+		ORDER BY m.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []Usage
+	for rows.Next() {
+		var u Usage
+		if err := rows.Scan(&u.ChatID, &u.ChatTitle, &u.Model, &u.CreatedAt, &u.PromptTokens, &u.EvalTokens); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		// Filtered here: timestamps are stored as text with their zone,
+		// which doesn't compare reliably in SQL
+		if u.CreatedAt.Before(since) {
+			continue
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
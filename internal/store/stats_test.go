@@ -0,0 +1,148 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDB_MessageStats(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	question, _ := db.AddMessage(chat.ID, RoleUser, "Hello")
+	answer, _ := db.AddMessage(chat.ID, RoleAssistant, "Hi there")
+
+	stats := &MessageStats{
+		MessageID:      answer.ID,
+		PromptTokens:   12,
+		EvalTokens:     3,
+		TotalDuration:  2 * time.Second,
+		LoadDuration:   time.Second,
+		PromptDuration: 100 * time.Millisecond,
+		EvalDuration:   500 * time.Millisecond,
+	}
+	if err := db.SaveMessageStats(stats); err != nil {
+		t.Fatalf("SaveMessageStats() error = %v", err)
+	}
+
+	got, err := db.GetMessageStats([]int64{question.ID, answer.ID})
+	if err != nil {
+		t.Fatalf("GetMessageStats() error = %v", err)
+	}
+	if len(got) != 1 || got[answer.ID] == nil || *got[answer.ID] != *stats {
+		t.Fatalf("GetMessageStats() = %+v, want the stats of the answer only", got)
+	}
+
+	// Saving again replaces the stats, as when a response is continued
+	stats.EvalTokens = 8
+	if err := db.SaveMessageStats(stats); err != nil {
+		t.Fatalf("SaveMessageStats() again error = %v", err)
+	}
+	got, _ = db.GetMessageStats([]int64{answer.ID})
+	if got[answer.ID].EvalTokens != 8 {
+		t.Errorf("EvalTokens after replace = %d, want 8", got[answer.ID].EvalTokens)
+	}
+
+	// Stats go with their message
+	if err := db.DeleteMessagesAfter(chat.ID, question.ID); err != nil {
+		t.Fatalf("DeleteMessagesAfter() error = %v", err)
+	}
+	got, _ = db.GetMessageStats([]int64{answer.ID})
+	if len(got) != 0 {
+		t.Errorf("GetMessageStats() after delete = %+v, want none", got)
+	}
+}
+
+func TestDB_GetChatStats(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	other, _ := db.CreateChat("llama3")
+
+	db.AddMessage(chat.ID, RoleUser, "Hello")
+	first, _ := db.AddMessage(chat.ID, RoleAssistant, "Hi!")
+	db.SaveMessageStats(&MessageStats{MessageID: first.ID, PromptTokens: 10, EvalTokens: 2, EvalDuration: time.Second, TotalDuration: 2 * time.Second})
+	edited, _ := db.AddMessage(chat.ID, RoleUser, "Old question")
+	old, _ := db.AddMessage(chat.ID, RoleAssistant, "Old answer")
+	db.SaveMessageStats(&MessageStats{MessageID: old.ID, EvalTokens: 100})
+	db.SupersedeMessagesFrom(chat.ID, edited.ID)
+	db.AddMessage(chat.ID, RoleUser, "¿Qué tal?")
+	second, _ := db.AddMessage(chat.ID, RoleAssistant, "Bien")
+	db.SaveMessageStats(&MessageStats{MessageID: second.ID, PromptTokens: 20, EvalTokens: 4, EvalDuration: time.Second, TotalDuration: 3 * time.Second})
+
+	unrelated, _ := db.AddMessage(other.ID, RoleAssistant, "Elsewhere")
+	db.SaveMessageStats(&MessageStats{MessageID: unrelated.ID, EvalTokens: 50})
+
+	stats, err := db.GetChatStats(chat.ID)
+	if err != nil {
+		t.Fatalf("GetChatStats() error = %v", err)
+	}
+	want := ChatStats{
+		Messages:     4,
+		Responses:    2,
+		Measured:     2,
+		Characters:   len("Hello") + len("Hi!") + len([]rune("¿Qué tal?")) + len("Bien"),
+		PromptTokens: 30,
+		EvalTokens:   6,
+		EvalDuration: 2 * time.Second,
+		TotalTime:    5 * time.Second,
+	}
+	if *stats != want {
+		t.Errorf("GetChatStats() = %+v, want %+v", *stats, want)
+	}
+
+	empty, _ := db.CreateChat("llama3")
+	stats, err = db.GetChatStats(empty.ID)
+	if err != nil || *stats != (ChatStats{}) {
+		t.Errorf("GetChatStats() of an empty chat = %+v, %v, want zero", stats, err)
+	}
+}
+
+func TestDB_ListUsage(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	chat, _ := db.CreateChat("llama3")
+	db.UpdateChatTitle(chat.ID, "Recipes")
+	other, _ := db.CreateChat("qwen3")
+
+	old, _ := db.AddMessage(chat.ID, RoleAssistant, "Old")
+	db.SaveMessageStats(&MessageStats{MessageID: old.ID, EvalTokens: 100})
+	db.db.Exec("UPDATE messages SET created_at = ? WHERE id = ?", now.AddDate(0, 0, -30), old.ID)
+
+	db.AddMessage(chat.ID, RoleUser, "Question")
+	answer, _ := db.AddMessage(chat.ID, RoleAssistant, "Answer")
+	db.SaveMessageStats(&MessageStats{MessageID: answer.ID, PromptTokens: 10, EvalTokens: 5})
+	elsewhere, _ := db.AddMessage(other.ID, RoleAssistant, "Elsewhere")
+	db.SaveMessageStats(&MessageStats{MessageID: elsewhere.ID, PromptTokens: 1, EvalTokens: 2})
+
+	usage, err := db.ListUsage(now.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("ListUsage() error = %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("ListUsage() = %+v, want 2 entries", usage)
+	}
+	first := usage[0]
+	if first.ChatID != chat.ID || first.ChatTitle != "Recipes" || first.Model != "llama3" || first.PromptTokens != 10 || first.EvalTokens != 5 {
+		t.Errorf("ListUsage()[0] = %+v, want the answer in Recipes", first)
+	}
+	if usage[1].Model != "qwen3" {
+		t.Errorf("ListUsage()[1].Model = %q, want qwen3", usage[1].Model)
+	}
+	if first.CreatedAt.IsZero() {
+		t.Error("ListUsage() left CreatedAt unset")
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SaveHistorySummary replaces the summary of a chat's history.
+func (d *DB) SaveHistorySummary(summary *HistorySummary) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	if summary.CreatedAt.IsZero() {
+		summary.CreatedAt = time.Now()
+	}
+	_, err := d.db.Exec(`
+		INSERT INTO history_summaries (chat_id, content, through_id, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET content = excluded.content, through_id = excluded.through_id, created_at = excluded.created_at
+	`, summary.ChatID, summary.Content, summary.ThroughID, summary.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save history summary: %w", err)
+	}
+	return nil
+}
+
+// GetHistorySummary returns the summary of a chat's history, or nil if it
+// has none.
+func (d *DB) GetHistorySummary(chatID int64) (*HistorySummary, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	summary := &HistorySummary{ChatID: chatID}
+	err := d.db.QueryRow(
+		"SELECT content, through_id, created_at FROM history_summaries WHERE chat_id = ?", chatID,
+	).Scan(&summary.Content, &summary.ThroughID, &summary.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history summary: %w", err)
+	}
+	return summary, nil
+}
+
+// DeleteHistorySummary deletes the summary of a chat's history, if any.
+func (d *DB) DeleteHistorySummary(chatID int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	if _, err := d.db.Exec("DELETE FROM history_summaries WHERE chat_id = ?", chatID); err != nil {
+		return fmt.Errorf("failed to delete history summary: %w", err)
+	}
+	return nil
+}
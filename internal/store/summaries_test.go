@@ -0,0 +1,60 @@
+package store
+
+import "testing"
+
+func TestDB_HistorySummary(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	first, _ := db.AddMessage(chat.ID, RoleUser, "First")
+	second, _ := db.AddMessage(chat.ID, RoleAssistant, "Second")
+
+	if got, err := db.GetHistorySummary(chat.ID); err != nil || got != nil {
+		t.Fatalf("GetHistorySummary() = %+v, %v, want none", got, err)
+	}
+
+	if err := db.SaveHistorySummary(&HistorySummary{ChatID: chat.ID, Content: "Old", ThroughID: first.ID}); err != nil {
+		t.Fatalf("SaveHistorySummary() error = %v", err)
+	}
+	if err := db.SaveHistorySummary(&HistorySummary{ChatID: chat.ID, Content: "New", ThroughID: second.ID}); err != nil {
+		t.Fatalf("SaveHistorySummary() again error = %v", err)
+	}
+
+	got, err := db.GetHistorySummary(chat.ID)
+	if err != nil {
+		t.Fatalf("GetHistorySummary() error = %v", err)
+	}
+	if got == nil || got.Content != "New" || got.ThroughID != second.ID {
+		t.Errorf("GetHistorySummary() = %+v, want the newer summary", got)
+	}
+
+	if err := db.DeleteHistorySummary(chat.ID); err != nil {
+		t.Fatalf("DeleteHistorySummary() error = %v", err)
+	}
+	if got, _ := db.GetHistorySummary(chat.ID); got != nil {
+		t.Errorf("GetHistorySummary() after delete = %+v, want none", got)
+	}
+}
+
+func TestDB_DeleteChatDeletesHistorySummary(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	chat, _ := db.CreateChat("llama3")
+	msg, _ := db.AddMessage(chat.ID, RoleUser, "First")
+	db.SaveHistorySummary(&HistorySummary{ChatID: chat.ID, Content: "Summary", ThroughID: msg.ID})
+
+	if err := db.DeleteChat(chat.ID); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+	if got, _ := db.GetHistorySummary(chat.ID); got != nil {
+		t.Errorf("GetHistorySummary() of deleted chat = %+v, want none", got)
+	}
+}
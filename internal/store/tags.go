@@ -0,0 +1,139 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// AddTag creates a tag, or returns the tag that already has the name,
+// ignoring case.
+func (d *DB) AddTag(name string) (*Tag, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec(
+		"INSERT OR IGNORE INTO tags (name, created_at) VALUES (?, ?)",
+		name, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add tag: %w", err)
+	}
+
+	tag := &Tag{}
+	err = d.db.QueryRow(
+		"SELECT id, name, created_at FROM tags WHERE name = ?",
+		name,
+	).Scan(&tag.ID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return tag, nil
+}
+
+// ListTags returns all tags sorted by name.
+func (d *DB) ListTags() ([]*Tag, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	rows, err := d.db.Query(
+		"SELECT id, name, created_at FROM tags ORDER BY name COLLATE NOCASE ASC, id ASC",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*Tag
+	for rows.Next() {
+		tag := &Tag{}
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// RenameTag changes the name of a tag. It fails if another tag has the
+// name.
+func (d *DB) RenameTag(id int64, name string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("UPDATE tags SET name = ? WHERE id = ?", name, id)
+	if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return nil
+}
+
+// DeleteTag removes a tag from every chat and deletes it.
+func (d *DB) DeleteTag(id int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("DELETE FROM tags WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// TagChat adds a tag to a chat. Adding a tag the chat already has does
+// nothing.
+func (d *DB) TagChat(chatID, tagID int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec(
+		"INSERT OR IGNORE INTO chat_tags (chat_id, tag_id) VALUES (?, ?)",
+		chatID, tagID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to tag chat: %w", err)
+	}
+	return nil
+}
+
+// UntagChat removes a tag from a chat.
+func (d *DB) UntagChat(chatID, tagID int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec(
+		"DELETE FROM chat_tags WHERE chat_id = ? AND tag_id = ?",
+		chatID, tagID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to untag chat: %w", err)
+	}
+	return nil
+}
+
+// ListChatTags returns the tags of every tagged chat, keyed by chat ID and
+// sorted by name.
+func (d *DB) ListChatTags() (map[int64][]*Tag, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT ct.chat_id, t.id, t.name, t.created_at
+		FROM chat_tags ct JOIN tags t ON t.id = ct.tag_id
+		ORDER BY t.name COLLATE NOCASE ASC, t.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat tags: %w", err)
+	}
+	defer rows.Close()
+
+	chatTags := make(map[int64][]*Tag)
+	for rows.Next() {
+		var chatID int64
+		tag := &Tag{}
+		if err := rows.Scan(&chatID, &tag.ID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat tag: %w", err)
+		}
+		chatTags[chatID] = append(chatTags[chatID], tag)
+	}
+	return chatTags, rows.Err()
+}
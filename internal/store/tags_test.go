@@ -0,0 +1,115 @@
+package store
+
+import "testing"
+
+func TestDB_Tags(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	work, err := db.AddTag("work")
+	if err != nil {
+		t.Fatalf("AddTag() error = %v", err)
+	}
+	if work.ID == 0 || work.Name != "work" {
+		t.Errorf("AddTag() = %+v, want a saved tag named work", work)
+	}
+	again, err := db.AddTag("Work")
+	if err != nil {
+		t.Fatalf("AddTag() existing error = %v", err)
+	}
+	if again.ID != work.ID || again.Name != "work" {
+		t.Errorf("AddTag(%q) = %+v, want the existing tag %d", "Work", again, work.ID)
+	}
+	code, _ := db.AddTag("Code")
+	personal, _ := db.AddTag("personal")
+
+	tags, err := db.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	if len(names) != 3 || names[0] != "Code" || names[1] != "personal" || names[2] != "work" {
+		t.Fatalf("ListTags() names = %v, want sorted ignoring case", names)
+	}
+
+	if err := db.RenameTag(personal.ID, "home"); err != nil {
+		t.Fatalf("RenameTag() error = %v", err)
+	}
+	if err := db.RenameTag(personal.ID, "WORK"); err == nil {
+		t.Error("RenameTag() to the name of another tag succeeded, want an error")
+	}
+	tags, _ = db.ListTags()
+	if tags[1].Name != "home" {
+		t.Errorf("after rename = %q, want %q", tags[1].Name, "home")
+	}
+
+	if err := db.DeleteTag(code.ID); err != nil {
+		t.Fatalf("DeleteTag() error = %v", err)
+	}
+	tags, _ = db.ListTags()
+	if len(tags) != 2 {
+		t.Errorf("ListTags() after delete has %d tags, want 2", len(tags))
+	}
+}
+
+func TestDB_ChatTags(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	first, _ := db.CreateChat("llama3")
+	second, _ := db.CreateChat("llama3")
+	db.CreateChat("llama3")
+	work, _ := db.AddTag("work")
+	code, _ := db.AddTag("code")
+
+	for _, tag := range []struct{ chat, tag int64 }{
+		{first.ID, work.ID},
+		{first.ID, code.ID},
+		{first.ID, code.ID}, // Tagging twice is ignored
+		{second.ID, work.ID},
+	} {
+		if err := db.TagChat(tag.chat, tag.tag); err != nil {
+			t.Fatalf("TagChat(%d, %d) error = %v", tag.chat, tag.tag, err)
+		}
+	}
+
+	chatTags, err := db.ListChatTags()
+	if err != nil {
+		t.Fatalf("ListChatTags() error = %v", err)
+	}
+	if len(chatTags) != 2 {
+		t.Fatalf("ListChatTags() = %d chats, want the 2 tagged ones", len(chatTags))
+	}
+	if tags := chatTags[first.ID]; len(tags) != 2 || tags[0].Name != "code" || tags[1].Name != "work" {
+		t.Errorf("tags of first chat = %+v, want code and work", tags)
+	}
+
+	if err := db.UntagChat(first.ID, work.ID); err != nil {
+		t.Fatalf("UntagChat() error = %v", err)
+	}
+	chatTags, _ = db.ListChatTags()
+	if tags := chatTags[first.ID]; len(tags) != 1 || tags[0].ID != code.ID {
+		t.Errorf("tags of first chat after untag = %+v, want code", tags)
+	}
+
+	// Deleting a chat or a tag removes its links
+	if err := db.DeleteChat(second.ID); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+	if err := db.DeleteTag(code.ID); err != nil {
+		t.Fatalf("DeleteTag() error = %v", err)
+	}
+	chatTags, _ = db.ListChatTags()
+	if len(chatTags) != 0 {
+		t.Errorf("ListChatTags() = %+v, want no tagged chats", chatTags)
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// AddPromptTemplate saves a new prompt template.
+func (d *DB) AddPromptTemplate(name, content string) (*PromptTemplate, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	now := time.Now()
+	tmpl := &PromptTemplate{
+		Name:      name,
+		Content:   content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	result, err := d.db.Exec(
+		"INSERT INTO prompt_templates (name, content, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		tmpl.Name, tmpl.Content, tmpl.CreatedAt, tmpl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add prompt template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	tmpl.ID = id
+	return tmpl, nil
+}
+
+// ListPromptTemplates returns all prompt templates sorted by name.
+func (d *DB) ListPromptTemplates() ([]*PromptTemplate, error) {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	rows, err := d.db.Query(
+		"SELECT id, name, content, created_at, updated_at FROM prompt_templates ORDER BY name COLLATE NOCASE ASC, id ASC",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*PromptTemplate
+	for rows.Next() {
+		tmpl := &PromptTemplate{}
+		if err := rows.Scan(&tmpl.ID, &tmpl.Name, &tmpl.Content, &tmpl.CreatedAt, &tmpl.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, rows.Err()
+}
+
+// UpdatePromptTemplate changes the name and content of a prompt template.
+func (d *DB) UpdatePromptTemplate(id int64, name, content string) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec(
+		"UPDATE prompt_templates SET name = ?, content = ?, updated_at = ? WHERE id = ?",
+		name, content, time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update prompt template: %w", err)
+	}
+	return nil
+}
+
+// DeletePromptTemplate removes a prompt template.
+func (d *DB) DeletePromptTemplate(id int64) error {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	_, err := d.db.Exec("DELETE FROM prompt_templates WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete prompt template: %w", err)
+	}
+	return nil
+}
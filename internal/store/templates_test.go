@@ -0,0 +1,50 @@
+package store
+
+import "testing"
+
+func TestDB_PromptTemplates(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	translate, err := db.AddPromptTemplate("translate", "Translate to {{language}}:\n\n{{text}}")
+	if err != nil {
+		t.Fatalf("AddPromptTemplate() error = %v", err)
+	}
+	if translate.ID == 0 {
+		t.Error("AddPromptTemplate() returned a template without an ID")
+	}
+	db.AddPromptTemplate("Summarize", "Summarize:\n\n{{text}}")
+	db.AddPromptTemplate("explain", "Explain like I'm five: {{text}}")
+
+	templates, err := db.ListPromptTemplates()
+	if err != nil {
+		t.Fatalf("ListPromptTemplates() error = %v", err)
+	}
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+	}
+	if len(names) != 3 || names[0] != "explain" || names[1] != "Summarize" || names[2] != "translate" {
+		t.Fatalf("ListPromptTemplates() names = %v, want sorted ignoring case", names)
+	}
+
+	if err := db.UpdatePromptTemplate(translate.ID, "Translate", "Translate into {{language}}: {{text}}"); err != nil {
+		t.Fatalf("UpdatePromptTemplate() error = %v", err)
+	}
+	templates, _ = db.ListPromptTemplates()
+	last := templates[2]
+	if last.Name != "Translate" || last.Content != "Translate into {{language}}: {{text}}" {
+		t.Errorf("after update = %+v, want the new name and content", last)
+	}
+
+	if err := db.DeletePromptTemplate(translate.ID); err != nil {
+		t.Fatalf("DeletePromptTemplate() error = %v", err)
+	}
+	templates, _ = db.ListPromptTemplates()
+	if len(templates) != 2 {
+		t.Errorf("ListPromptTemplates() after delete has %d templates, want 2", len(templates))
+	}
+}
@@ -0,0 +1,37 @@
+-- The first released schema: chats without a system prompt.
+CREATE TABLE chats (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    title       TEXT NOT NULL DEFAULT 'New Chat',
+    model       TEXT NOT NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE messages (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id     INTEGER NOT NULL,
+    role        TEXT NOT NULL CHECK(role IN ('user', 'assistant', 'system')),
+    content     TEXT NOT NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE
+);
+
+CREATE TABLE attachments (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id  INTEGER NOT NULL,
+    filename    TEXT NOT NULL,
+    content     TEXT NOT NULL,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_messages_chat_id ON messages(chat_id);
+
+INSERT INTO chats (id, title, model, created_at, updated_at)
+VALUES (1, 'Old chat', 'llama2', '2024-01-02 10:00:00', '2024-01-02 10:05:00');
+
+INSERT INTO messages (id, chat_id, role, content, created_at) VALUES
+    (1, 1, 'user', 'Hello', '2024-01-02 10:00:00'),
+    (2, 1, 'assistant', 'Hi there!', '2024-01-02 10:00:05');
+
+INSERT INTO attachments (message_id, filename, content)
+VALUES (1, 'notes.txt', 'Some notes');
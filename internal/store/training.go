@@ -0,0 +1,169 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Training data formats, for fine-tuning models on exported chats. Both
+// write one conversation per line as JSON (JSONL).
+const (
+	// ExportOpenAI writes {"messages": [{"role": ..., "content": ...}]}.
+	ExportOpenAI ExportFormat = "openai"
+	// ExportShareGPT writes {"conversations": [{"from": ..., "value": ...}]}.
+	ExportShareGPT ExportFormat = "sharegpt"
+)
+
+// IsTraining reports whether the format is a training data format.
+func (f ExportFormat) IsTraining() bool {
+	return f == ExportOpenAI || f == ExportShareGPT
+}
+
+// TrainingOptions filter what goes into a training data export.
+type TrainingOptions struct {
+	BookmarkedOnly bool // Keep only the exchanges whose response is bookmarked
+	RatedUpOnly    bool // Keep only the exchanges whose response is rated as good
+	NoRatedDown    bool // Leave out the exchanges whose response is rated as bad
+	NoSystem       bool // Leave out system prompts and system messages
+}
+
+// keeps reports whether the exchange ending in the response msg passes the
+// filters.
+func (o TrainingOptions) keeps(msg *Message) bool {
+	switch {
+	case o.BookmarkedOnly && !msg.Bookmarked:
+		return false
+	case o.RatedUpOnly && msg.Rating != RatingUp:
+		return false
+	case o.NoRatedDown && msg.Rating == RatingDown:
+		return false
+	}
+	return true
+}
+
+// ErrNoTrainingData is returned when no exchange of the chats is left to
+// export.
+var ErrNoTrainingData = errors.New("no exchanges to export")
+
+// trainingTurn is a message of a training conversation.
+type trainingTurn struct {
+	Role    Role
+	Content string
+}
+
+// WriteTrainingExport writes each chat to w as a training conversation in
+// format, one per line. Chats left without exchanges are skipped.
+func WriteTrainingExport(w io.Writer, chats []ExportedChat, format ExportFormat, opts TrainingOptions) error {
+	if !format.IsTraining() {
+		return fmt.Errorf("unsupported training format: %s", format)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	written := 0
+	for _, chat := range chats {
+		turns := trainingTurns(chat, opts)
+		if len(turns) == 0 {
+			continue
+		}
+		if err := enc.Encode(trainingLine(turns, format)); err != nil {
+			return err
+		}
+		written++
+	}
+	if written == 0 {
+		return ErrNoTrainingData
+	}
+	return nil
+}
+
+// trainingTurns returns the messages of chat that make up its training
+// conversation: the system prompt, and each response with the messages
+// that led to it. Unanswered messages at the end are left out, and nil is
+// returned when the chat has no exchange to keep.
+func trainingTurns(chat ExportedChat, opts TrainingOptions) []trainingTurn {
+	var turns []trainingTurn
+	if prompt := strings.TrimSpace(chat.SystemPrompt); prompt != "" && !opts.NoSystem {
+		turns = append(turns, trainingTurn{RoleSystem, prompt})
+	}
+
+	// Messages since the last response, kept if one answers them
+	var pending []trainingTurn
+	answered := false
+	for _, msg := range chat.Messages {
+		content := strings.TrimSpace(msg.Content)
+		if msg.Role == RoleUser {
+			content = stripAttachmentIndicator(content)
+		}
+		if content == "" {
+			continue
+		}
+
+		switch msg.Role {
+		case RoleSystem:
+			if !opts.NoSystem && !msg.ModelSwitch {
+				pending = append(pending, trainingTurn{RoleSystem, content})
+			}
+		case RoleUser:
+			pending = append(pending, trainingTurn{RoleUser, content})
+		case RoleAssistant:
+			hasUser := false
+			for _, turn := range pending {
+				hasUser = hasUser || turn.Role == RoleUser
+			}
+			if hasUser && opts.keeps(msg.Message) {
+				turns = append(turns, pending...)
+				turns = append(turns, trainingTurn{RoleAssistant, content})
+				answered = true
+			}
+			pending = nil
+		}
+	}
+
+	if !answered {
+		return nil
+	}
+	return turns
+}
+
+// stripAttachmentIndicator removes the "[📎 filename]" line shown above
+// user messages with attachments, which the model never saw.
+func stripAttachmentIndicator(content string) string {
+	if !strings.HasPrefix(content, "[📎") {
+		return content
+	}
+	if idx := strings.Index(content, "]"); idx != -1 {
+		return strings.TrimSpace(content[idx+1:])
+	}
+	return content
+}
+
+// trainingLine returns the JSON object of a conversation in format.
+func trainingLine(turns []trainingTurn, format ExportFormat) any {
+	if format == ExportShareGPT {
+		type message struct {
+			From  string `json:"from"`
+			Value string `json:"value"`
+		}
+		names := map[Role]string{RoleSystem: "system", RoleUser: "human", RoleAssistant: "gpt"}
+		conversation := make([]message, len(turns))
+		for i, turn := range turns {
+			conversation[i] = message{names[turn.Role], turn.Content}
+		}
+		return map[string]any{"conversations": conversation}
+	}
+
+	type message struct {
+		Role    Role   `json:"role"`
+		Content string `json:"content"`
+	}
+	messages := make([]message, len(turns))
+	for i, turn := range turns {
+		messages[i] = message{turn.Role, turn.Content}
+	}
+	return map[string]any{"messages": messages}
+}
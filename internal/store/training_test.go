@@ -0,0 +1,124 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// trainingChat returns a chat with a system prompt, an attachment and two
+// exchanges, the second of them bookmarked.
+func trainingChat() ExportedChat {
+	message := func(role Role, content string, bookmarked bool) ExportedMessage {
+		return ExportedMessage{Message: &Message{Role: role, Content: content, Bookmarked: bookmarked}}
+	}
+	return ExportedChat{
+		Chat: &Chat{Title: "Go tips", SystemPrompt: "You are a Go expert."},
+		Messages: []ExportedMessage{
+			message(RoleUser, "[📎 notes.txt]\n\nHow do I read a file?", false),
+			message(RoleAssistant, "Use os.ReadFile.", false),
+			message(RoleUser, "And write one?", false),
+			message(RoleAssistant, "Use os.WriteFile.", true),
+			message(RoleUser, "Thanks!", false),
+		},
+	}
+}
+
+func TestWriteTrainingExport_OpenAI(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTrainingExport(&buf, []ExportedChat{trainingChat()}, ExportOpenAI, TrainingOptions{}); err != nil {
+		t.Fatalf("WriteTrainingExport() error = %v", err)
+	}
+
+	want := `{"messages":[` +
+		`{"role":"system","content":"You are a Go expert."},` +
+		`{"role":"user","content":"How do I read a file?"},` +
+		`{"role":"assistant","content":"Use os.ReadFile."},` +
+		`{"role":"user","content":"And write one?"},` +
+		`{"role":"assistant","content":"Use os.WriteFile."}]}` + "\n"
+	if buf.String() != want {
+		t.Errorf("WriteTrainingExport() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteTrainingExport_ShareGPT(t *testing.T) {
+	var buf bytes.Buffer
+	opts := TrainingOptions{BookmarkedOnly: true, NoSystem: true}
+	if err := WriteTrainingExport(&buf, []ExportedChat{trainingChat()}, ExportShareGPT, opts); err != nil {
+		t.Fatalf("WriteTrainingExport() error = %v", err)
+	}
+
+	var line struct {
+		Conversations []struct {
+			From  string `json:"from"`
+			Value string `json:"value"`
+		} `json:"conversations"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	var got []string
+	for _, turn := range line.Conversations {
+		got = append(got, turn.From+": "+turn.Value)
+	}
+	want := "human: And write one? | gpt: Use os.WriteFile."
+	if strings.Join(got, " | ") != want {
+		t.Errorf("conversations = %q, want %q", strings.Join(got, " | "), want)
+	}
+}
+
+func TestWriteTrainingExport_OneLinePerChat(t *testing.T) {
+	unanswered := ExportedChat{Chat: &Chat{}, Messages: []ExportedMessage{{Message: &Message{Role: RoleUser, Content: "Hello?"}}}}
+	chats := []ExportedChat{trainingChat(), unanswered, trainingChat()}
+
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, chats, ExportOpenAI); err != nil {
+		t.Fatalf("WriteExport() error = %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("wrote %d lines, want 2:\n%s", lines, buf.String())
+	}
+}
+
+func TestWriteTrainingExport_NothingToExport(t *testing.T) {
+	chat := trainingChat()
+	for _, msg := range chat.Messages {
+		msg.Bookmarked = false
+	}
+
+	err := WriteTrainingExport(&bytes.Buffer{}, []ExportedChat{chat}, ExportOpenAI, TrainingOptions{BookmarkedOnly: true})
+	if !errors.Is(err, ErrNoTrainingData) {
+		t.Errorf("WriteTrainingExport() error = %v, want ErrNoTrainingData", err)
+	}
+}
+
+func TestTrainingOptions_Ratings(t *testing.T) {
+	chat := trainingChat()
+	chat.Messages[1].Rating = RatingDown
+	chat.Messages[3].Rating = RatingUp
+
+	tests := []struct {
+		name string
+		opts TrainingOptions
+		want []string
+	}{
+		{"all", TrainingOptions{NoSystem: true}, []string{"Use os.ReadFile.", "Use os.WriteFile."}},
+		{"rated up only", TrainingOptions{NoSystem: true, RatedUpOnly: true}, []string{"Use os.WriteFile."}},
+		{"no rated down", TrainingOptions{NoSystem: true, NoRatedDown: true}, []string{"Use os.WriteFile."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, turn := range trainingTurns(chat, tt.opts) {
+				if turn.Role == RoleAssistant {
+					got = append(got, turn.Content)
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("responses = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// Calculator returns the tool that evaluates arithmetic expressions.
+func Calculator() Tool {
+	return Tool{
+		Name:        "calculate",
+		Description: "Evaluate an arithmetic expression exactly. Supports + - * / % ^, parentheses, sqrt, abs, round, floor, ceil, ln, log (base 10), pi and e.",
+		Parameters: map[string]ollama.ToolProperty{
+			"expression": {Type: "string", Description: "The expression, such as (17.5 * 3) / 4 or sqrt(2)^3."},
+		},
+		Required: []string{"expression"},
+		Run: func(args map[string]any) (string, error) {
+			expr := stringArg(args, "expression")
+			result, err := evaluate(expr)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s = %s", expr, formatNumber(result)), nil
+		},
+	}
+}
+
+// calcFunctions are the functions an expression can call.
+var calcFunctions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"round": math.Round,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"ln":    math.Log,
+	"log":   math.Log10,
+}
+
+// calcConstants are the named values an expression can use.
+var calcConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// evaluate computes an arithmetic expression. ^ binds tighter than the
+// other operators and is right-associative.
+func evaluate(expr string) (float64, error) {
+	p := &calcParser{input: strings.ReplaceAll(expr, "×", "*")}
+	p.input = strings.ReplaceAll(p.input, "÷", "/")
+	if strings.TrimSpace(p.input) == "" {
+		return 0, errors.New("empty expression")
+	}
+
+	v, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos+1)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, errors.New("the result is not a finite number")
+	}
+	return v, nil
+}
+
+// calcParser is a recursive-descent parser that evaluates as it parses.
+type calcParser struct {
+	input string
+	pos   int
+}
+
+func (p *calcParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end.
+func (p *calcParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// expression := term (('+' | '-') term)*
+func (p *calcParser) expression() (float64, error) {
+	v, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch p.peek() {
+		case '+':
+			p.pos++
+			r, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			v += r
+		case '-':
+			p.pos++
+			r, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			v -= r
+		default:
+			return v, nil
+		}
+	}
+}
+
+// term := unary (('*' | '/' | '%') unary)*
+func (p *calcParser) term() (float64, error) {
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return v, nil
+		}
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			v *= r
+		case '/':
+			if r == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v /= r
+		case '%':
+			if r == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v = math.Mod(v, r)
+		}
+	}
+}
+
+// unary := ('-' | '+') unary | power
+func (p *calcParser) unary() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		v, err := p.unary()
+		return -v, err
+	case '+':
+		p.pos++
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power := primary ('^' unary)?
+func (p *calcParser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	exp, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exp), nil
+}
+
+// primary := number | name | name '(' expression ')' | '(' expression ')'
+func (p *calcParser) primary() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		v, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, errors.New("missing closing parenthesis")
+		}
+		p.pos++
+		return v, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+		}
+		return v, nil
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		start := p.pos
+		for p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		name := strings.ToLower(p.input[start:p.pos])
+		if fn, ok := calcFunctions[name]; ok {
+			if p.peek() != '(' {
+				return 0, fmt.Errorf("%s needs parentheses", name)
+			}
+			arg, err := p.primary()
+			if err != nil {
+				return 0, err
+			}
+			return fn(arg), nil
+		}
+		if v, ok := calcConstants[name]; ok {
+			return v, nil
+		}
+		return 0, fmt.Errorf("unknown name %q", name)
+	case c == 0:
+		return 0, errors.New("unexpected end of expression")
+	default:
+		return 0, fmt.Errorf("unexpected %q at position %d", string(c), p.pos+1)
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 / 4", 2.5},
+		{"10 % 4", 2},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"2 * -3", -6},
+		{"sqrt(16) + abs(-2)", 6},
+		{"round(2.5) + floor(1.9) + ceil(1.1)", 6},
+		{"log(1000)", 3},
+		{"2 × 3 ÷ 4", 1.5},
+		{"PI", math.Pi},
+		{".5 + 0.25", 0.75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("evaluate(%q) error = %v", tt.expr, err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("evaluate(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Errors(t *testing.T) {
+	for _, expr := range []string{"", "1 +", "(1 + 2", "1 / 0", "2 $ 3", "foo(2)", "sqrt 4", "1 2", "sqrt(-1)"} {
+		if _, err := evaluate(expr); err == nil {
+			t.Errorf("evaluate(%q) should fail", expr)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	if got := formatNumber(0.1 + 0.2); got != "0.3" {
+		t.Errorf("formatNumber(0.1 + 0.2) = %q, want 0.3", got)
+	}
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+// CurrentTime returns the tool that tells the current date and time.
+func CurrentTime() Tool {
+	return Tool{
+		Name:        "current_time",
+		Description: "Get the current date, time and day of the week, in the user's time zone or in a given one.",
+		Parameters: map[string]ollama.ToolProperty{
+			"timezone": {
+				Type:        "string",
+				Description: "IANA time zone, such as Europe/Madrid or America/New_York. Leave empty for the user's time zone.",
+			},
+		},
+		Run: func(args map[string]any) (string, error) {
+			return timeIn(now(), stringArg(args, "timezone"))
+		},
+	}
+}
+
+// timeIn describes t in the named time zone, or in t's own when name is empty.
+func timeIn(t time.Time, name string) (string, error) {
+	if name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return "", fmt.Errorf("unknown time zone %q", name)
+		}
+		t = t.In(loc)
+	}
+
+	zone := t.Location().String()
+	if zone == "Local" {
+		zone = "local time"
+	}
+	return fmt.Sprintf("%s, %s (%s, %s, UTC%s)",
+		t.Format("Monday"), t.Format("2006-01-02 15:04:05"), zone, t.Format("MST"), t.Format("-07:00")), nil
+}
@@ -0,0 +1,366 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// The file tools let the model look at the working folder of a chat. They
+// only read, and every path is resolved inside the folder, following
+// symbolic links, so nothing outside it can be reached.
+
+// Limits of what the file tools send back, which all goes into the
+// model's context.
+const (
+	maxListedFiles   = 200
+	maxReadBytes     = 64 * 1024
+	maxSearchMatches = 50
+	maxSearchedSize  = 1024 * 1024
+)
+
+// skippedDirs are folders left out of listings and searches, as they hold
+// version control data or downloaded dependencies rather than the project.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+}
+
+// FileTools returns the tools that list, search and read the files of
+// root.
+func FileTools(root string) []Tool {
+	return []Tool{ListFiles(root), SearchFiles(root), ReadFile(root)}
+}
+
+// ListFiles returns the tool that lists a folder of root.
+func ListFiles(root string) Tool {
+	return Tool{
+		Name:        "list_files",
+		Description: "List the files and folders in a folder of the user's working folder. Folders end with a slash.",
+		Parameters: map[string]ollama.ToolProperty{
+			"path": {
+				Type:        "string",
+				Description: "Folder to list, relative to the working folder. Leave empty for the working folder itself.",
+			},
+		},
+		Run: func(args map[string]any) (string, error) {
+			return listFiles(root, stringArg(args, "path"))
+		},
+	}
+}
+
+// SearchFiles returns the tool that finds the lines of the files of root
+// containing a text.
+func SearchFiles(root string) Tool {
+	return Tool{
+		Name:        "search_files",
+		Description: "Find the lines containing a text in the files of the user's working folder, ignoring case.",
+		Parameters: map[string]ollama.ToolProperty{
+			"query": {
+				Type:        "string",
+				Description: "Text to look for.",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Folder or file to search, relative to the working folder. Leave empty to search everything.",
+			},
+		},
+		Required: []string{"query"},
+		Run: func(args map[string]any) (string, error) {
+			return searchFiles(root, stringArg(args, "path"), stringArg(args, "query"))
+		},
+	}
+}
+
+// ReadFile returns the tool that reads a text file of root.
+func ReadFile(root string) Tool {
+	return Tool{
+		Name:        "read_file",
+		Description: "Read a text file of the user's working folder. Long files are cut; read the rest by starting at a later line.",
+		Parameters: map[string]ollama.ToolProperty{
+			"path": {
+				Type:        "string",
+				Description: "File to read, relative to the working folder.",
+			},
+			"start_line": {
+				Type:        "number",
+				Description: "First line to read, starting at 1. Leave empty to read from the beginning.",
+			},
+		},
+		Required: []string{"path"},
+		Run: func(args map[string]any) (string, error) {
+			start := 1
+			if _, ok := args["start_line"]; ok {
+				n, err := numberArg(args, "start_line")
+				if err != nil {
+					return "", err
+				}
+				start = max(int(n), 1)
+			}
+			return readFile(root, stringArg(args, "path"), start)
+		},
+	}
+}
+
+// resolvePath returns the real path of name inside root. Names are always
+// taken as relative to root, and paths that lead outside it through ".." or
+// symbolic links are refused.
+func resolvePath(root, name string) (string, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("the working folder is not available")
+	}
+
+	target := filepath.Join(realRoot, filepath.FromSlash(path.Clean("/"+filepath.ToSlash(name))))
+	real, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", fmt.Errorf("%q does not exist", name)
+	}
+	if !insideDir(realRoot, real) {
+		return "", fmt.Errorf("%q is outside the working folder", name)
+	}
+	return real, nil
+}
+
+// insideDir reports whether path is dir or inside it.
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// displayPath returns path relative to root with forward slashes, as the
+// model sees it.
+func displayPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// listFiles lists the folder name of root, folders first.
+func listFiles(root, name string) (string, error) {
+	dir, err := resolvePath(root, name)
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a folder", name)
+	}
+
+	var folders, files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if !skippedDirs[entry.Name()] {
+				folders = append(folders, entry.Name()+"/")
+			}
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, fmt.Sprintf("%s (%s)", entry.Name(), formatBytes(info.Size())))
+		}
+	}
+	sort.Strings(folders)
+	sort.Strings(files)
+
+	lines := append(folders, files...)
+	if len(lines) == 0 {
+		return "The folder is empty.", nil
+	}
+	if len(lines) > maxListedFiles {
+		lines = append(lines[:maxListedFiles], fmt.Sprintf("(%d more not listed)", len(lines)-maxListedFiles))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// errEnoughMatches stops a search once it has enough matches.
+var errEnoughMatches = errors.New("enough matches")
+
+// searchFiles returns the lines of the files in name containing query.
+func searchFiles(root, name, query string) (string, error) {
+	if query == "" {
+		return "", fmt.Errorf("query must not be empty")
+	}
+	start, err := resolvePath(root, name)
+	if err != nil {
+		return "", err
+	}
+	realRoot, _ := filepath.EvalSymlinks(root)
+	needle := strings.ToLower(query)
+
+	var matches []string
+	err = filepath.WalkDir(start, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if p != start && skippedDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxSearchedSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), maxSearchedSize)
+		for line := 1; scanner.Scan(); line++ {
+			text := scanner.Text()
+			if !strings.Contains(strings.ToLower(text), needle) {
+				continue
+			}
+			matches = append(matches, fmt.Sprintf("%s:%d: %s", displayPath(realRoot, p), line, clipLine(strings.TrimSpace(text))))
+			if len(matches) >= maxSearchMatches {
+				return errEnoughMatches
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughMatches) {
+		return "", err
+	}
+
+	if len(matches) == 0 {
+		return fmt.Sprintf("No lines contain %q.", query), nil
+	}
+	result := strings.Join(matches, "\n")
+	if len(matches) >= maxSearchMatches {
+		result += fmt.Sprintf("\n(stopped after %d matches)", maxSearchMatches)
+	}
+	return result, nil
+}
+
+// readFile returns the text of the file name of root from line start,
+// each line with its number. The file is read only until the text sent
+// back is full, so large files don't have to fit in memory.
+func readFile(root, name string, start int) (string, error) {
+	file, err := resolvePath(root, name)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%q is a folder; list it instead", name)
+	}
+	// Pipes and devices could block the reading forever
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%q is not a regular file", name)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, 64*1024)
+	if head, _ := reader.Peek(8000); isBinary(head) {
+		return "", fmt.Errorf("%q is not a text file", name)
+	}
+
+	var builder strings.Builder
+	count := 0
+	for {
+		text, err := readLine(reader, maxReadBytes)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		count++
+		if count < start {
+			continue
+		}
+		line := fmt.Sprintf("%d: %s\n", count, text)
+		if builder.Len()+len(line) > maxReadBytes {
+			if builder.Len() == 0 {
+				// A single line too long to send back is sent in part
+				builder.WriteString(strings.ToValidUTF8(line[:maxReadBytes-100], ""))
+				fmt.Fprintf(&builder, "…\n(line %d cut; read on from line %d)", count, count+1)
+				return builder.String(), nil
+			}
+			fmt.Fprintf(&builder, "(cut at line %d; read on from line %d)", count-1, count)
+			return builder.String(), nil
+		}
+		builder.WriteString(line)
+	}
+	// An empty file still has one, empty line
+	if start > max(count, 1) {
+		return "", fmt.Errorf("%q has only %d lines", name, max(count, 1))
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+// readLine returns the next line of reader without its line ending. Only
+// the first limit bytes of a longer line are kept.
+func readLine(reader *bufio.Reader, limit int) (string, error) {
+	var line []byte
+	for {
+		part, more, err := reader.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if len(line) < limit {
+			line = append(line, part[:min(len(part), limit-len(line))]...)
+		}
+		if !more {
+			return string(line), nil
+		}
+	}
+}
+
+// isBinary reports whether data looks like a binary file rather than text.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// clipLine shortens long lines of search results, such as minified code.
+func clipLine(line string) string {
+	const maxLine = 200
+	if len(line) <= maxLine {
+		return line
+	}
+	cut := maxLine
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "…"
+}
+
+// formatBytes returns a size in bytes in a readable unit.
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// writeTree creates files under a temporary folder and returns the folder.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestListFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"README.md":       "# App",
+		"main.go":         "package main",
+		"internal/db.go":  "package internal",
+		".git/HEAD":       "ref: refs/heads/main",
+		"node_modules/x":  "",
+		"docs/guide.txt":  "Guide",
+		"docs/images/a.b": "",
+	})
+	r := NewRegistry(FileTools(root)...)
+
+	want := "docs/\ninternal/\nREADME.md (5 B)\nmain.go (12 B)"
+	if got := r.Run(call("list_files", nil)); got != want {
+		t.Errorf("list_files() = %q, want %q", got, want)
+	}
+	if got := r.Run(call("list_files", map[string]any{"path": "docs"})); got != "images/\nguide.txt (5 B)" {
+		t.Errorf("list_files(docs) = %q", got)
+	}
+	if got := r.Run(call("list_files", map[string]any{"path": "main.go"})); !strings.HasPrefix(got, "Error:") {
+		t.Errorf("list_files(main.go) = %q, want an error", got)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"notes.txt": "first\nsecond\nthird\n",
+		"image.png": "\x89PNG\x00\x00",
+	})
+	r := NewRegistry(FileTools(root)...)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"whole file", map[string]any{"path": "notes.txt"}, "1: first\n2: second\n3: third"},
+		{"from a line", map[string]any{"path": "notes.txt", "start_line": 2.0}, "2: second\n3: third"},
+		{"past the end", map[string]any{"path": "notes.txt", "start_line": 9.0}, `Error: "notes.txt" has only 3 lines`},
+		{"binary", map[string]any{"path": "image.png"}, `Error: "image.png" is not a text file`},
+		{"missing", map[string]any{"path": "nope.txt"}, `Error: "nope.txt" does not exist`},
+		{"folder", map[string]any{"path": "."}, `Error: "." is a folder; list it instead`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Run(call("read_file", tt.args)); got != tt.want {
+				t.Errorf("read_file(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("long file is cut", func(t *testing.T) {
+		var builder strings.Builder
+		for i := 0; i < 10000; i++ {
+			fmt.Fprintf(&builder, "line number %d of a long file\n", i)
+		}
+		long := writeTree(t, map[string]string{"long.txt": builder.String()})
+		got, err := readFile(long, "long.txt", 1)
+		if err != nil {
+			t.Fatalf("readFile() error = %v", err)
+		}
+		if len(got) > maxReadBytes+100 || !strings.Contains(got, "read on from line") {
+			t.Errorf("readFile() returned %d bytes ending %q, want it cut", len(got), got[len(got)-60:])
+		}
+	})
+
+	t.Run("oversized file is read only in part", func(t *testing.T) {
+		dir := t.TempDir()
+		f, err := os.Create(filepath.Join(dir, "huge.log"))
+		if err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		line := strings.Repeat("x", 1023) + "\n"
+		for i := 0; i < 32*1024; i++ {
+			f.WriteString(line)
+		}
+		// One line longer than everything sent back
+		f.WriteString(strings.Repeat("y", 2*maxReadBytes) + "\n")
+		f.Close()
+
+		got, err := readFile(dir, "huge.log", 1)
+		if err != nil {
+			t.Fatalf("readFile() error = %v", err)
+		}
+		if len(got) > maxReadBytes+100 || !strings.HasSuffix(got, "(cut at line 63; read on from line 64)") {
+			t.Errorf("readFile() returned %d bytes ending %q, want it cut at line 63", len(got), got[len(got)-60:])
+		}
+
+		got, err = readFile(dir, "huge.log", 32*1024+1)
+		if err != nil {
+			t.Fatalf("readFile() error = %v", err)
+		}
+		if len(got) > maxReadBytes || !strings.HasSuffix(got, "(line 32769 cut; read on from line 32770)") {
+			t.Errorf("readFile() of the long line = %d bytes ending %q, want it cut", len(got), got[max(len(got)-60, 0):])
+		}
+	})
+
+	t.Run("named pipe is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := syscall.Mkfifo(filepath.Join(dir, "pipe"), 0644); err != nil {
+			t.Skipf("cannot create a named pipe: %v", err)
+		}
+
+		done := make(chan string, 1)
+		go func() {
+			done <- NewRegistry(FileTools(dir)...).Run(call("read_file", map[string]any{"path": "pipe"}))
+		}()
+		select {
+		case got := <-done:
+			if want := `Error: "pipe" is not a regular file`; got != want {
+				t.Errorf("read_file(pipe) = %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("read_file(pipe) is blocked")
+		}
+	})
+}
+
+func TestFileTools_StayInRoot(t *testing.T) {
+	outside := writeTree(t, map[string]string{"secret.txt": "password"})
+	root := writeTree(t, map[string]string{"app/main.go": "package main"})
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symbolic links not available: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "app", "secret.txt")); err != nil {
+		t.Fatalf("failed to create link: %v", err)
+	}
+	r := NewRegistry(FileTools(root)...)
+
+	for _, path := range []string{"link/secret.txt", "app/secret.txt"} {
+		if got := r.Run(call("read_file", map[string]any{"path": path})); !strings.Contains(got, "outside the working folder") {
+			t.Errorf("read_file(%q) = %q, want it refused", path, got)
+		}
+	}
+
+	// Parent folders and absolute paths are taken as inside the root
+	for _, path := range []string{"../" + filepath.Base(outside) + "/secret.txt", filepath.Join(outside, "secret.txt")} {
+		if got := r.Run(call("read_file", map[string]any{"path": path})); !strings.Contains(got, "does not exist") {
+			t.Errorf("read_file(%q) = %q, want it not found", path, got)
+		}
+	}
+	if got := r.Run(call("list_files", map[string]any{"path": "../.."})); strings.Contains(got, "secret") {
+		t.Errorf("list_files(../..) = %q, want the working folder", got)
+	}
+
+	if got := r.Run(call("search_files", map[string]any{"query": "password"})); got != `No lines contain "password".` {
+		t.Errorf("search_files() = %q, want linked files left out", got)
+	}
+}
+
+func TestSearchFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":          "package main\n\nfunc main() {\n\tStartServer()\n}\n",
+		"server/server.go": "package server\n\n// StartServer listens on the port.\nfunc StartServer() {}\n",
+		".git/config":      "startserver",
+	})
+	r := NewRegistry(FileTools(root)...)
+
+	got := r.Run(call("search_files", map[string]any{"query": "startserver"}))
+	want := "main.go:4: StartServer()\nserver/server.go:3: // StartServer listens on the port.\nserver/server.go:4: func StartServer() {}"
+	if got != want {
+		t.Errorf("search_files() = %q, want %q", got, want)
+	}
+
+	got = r.Run(call("search_files", map[string]any{"query": "package", "path": "server"}))
+	if got != "server/server.go:1: package server" {
+		t.Errorf("search_files(server) = %q", got)
+	}
+
+	if got := r.Run(call("search_files", map[string]any{"query": "database"})); got != `No lines contain "database".` {
+		t.Errorf("search_files(database) = %q", got)
+	}
+}
+
+func TestRegistry_With(t *testing.T) {
+	builtin := Builtin()
+	extended := builtin.With(FileTools(t.TempDir())...)
+
+	if len(builtin.Names()) != 3 {
+		t.Errorf("With() changed the original registry: %v", builtin.Names())
+	}
+	if got := len(extended.Names()); got != 6 {
+		t.Errorf("With() = %v, want 6 tools", extended.Names())
+	}
+}
@@ -0,0 +1,155 @@
+// Package tools holds the functions models can call while answering, and
+// the built-in tools that ship with the app: the current time, unit
+// conversion and a calculator, and reading the working folder of a chat.
+// They run locally and never reach the network.
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// Tool is a function the model can call.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]ollama.ToolProperty
+	Required    []string
+	Run         func(args map[string]any) (string, error)
+}
+
+// Registry is a set of tools offered to the model.
+type Registry struct {
+	tools []Tool
+}
+
+// NewRegistry creates a registry with the given tools.
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{}
+	for _, t := range tools {
+		r.Register(t)
+	}
+	return r
+}
+
+// Builtin returns a registry with the built-in tools.
+func Builtin() *Registry {
+	return NewRegistry(CurrentTime(), ConvertUnits(), Calculator())
+}
+
+// Register adds a tool, replacing any tool with the same name.
+func (r *Registry) Register(tool Tool) {
+	for i, t := range r.tools {
+		if t.Name == tool.Name {
+			r.tools[i] = tool
+			return
+		}
+	}
+	r.tools = append(r.tools, tool)
+}
+
+// With returns a copy of the registry with tools added, replacing any with
+// the same name. The registry itself is left as it is.
+func (r *Registry) With(tools ...Tool) *Registry {
+	copied := &Registry{tools: append([]Tool(nil), r.tools...)}
+	for _, t := range tools {
+		copied.Register(t)
+	}
+	return copied
+}
+
+// Definitions returns the tools in the form sent to the model.
+func (r *Registry) Definitions() []ollama.Tool {
+	defs := make([]ollama.Tool, len(r.tools))
+	for i, t := range r.tools {
+		properties := t.Parameters
+		if properties == nil {
+			properties = map[string]ollama.ToolProperty{}
+		}
+		defs[i] = ollama.Tool{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters: ollama.ToolParameters{
+					Type:       "object",
+					Properties: properties,
+					Required:   t.Required,
+				},
+			},
+		}
+	}
+	return defs
+}
+
+// Names returns the names of the tools in alphabetical order.
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.tools))
+	for i, t := range r.tools {
+		names[i] = t.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs the tool the model asked for and returns the text to send back
+// as its result. Failures are reported to the model as text, so it can
+// correct the call or answer without the tool.
+func (r *Registry) Run(call ollama.ToolCall) string {
+	for _, t := range r.tools {
+		if t.Name != call.Function.Name {
+			continue
+		}
+		for _, name := range t.Required {
+			if _, ok := call.Function.Arguments[name]; !ok {
+				return fmt.Sprintf("Error: missing argument %q", name)
+			}
+		}
+		result, err := t.Run(call.Function.Arguments)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		return result
+	}
+	return fmt.Sprintf("Error: unknown tool %q", call.Function.Name)
+}
+
+// stringArg returns a text argument, or "" if it is missing.
+func stringArg(args map[string]any, name string) string {
+	switch v := args[name].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// numberArg returns a numeric argument. Models sometimes send numbers as
+// text, so both are accepted.
+func numberArg(args map[string]any, name string) (float64, error) {
+	switch v := args[name].(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number, got %q", name, v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+}
+
+// formatNumber formats a result without float noise such as 0.30000000000000004.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', 12, 64)
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+func call(name string, args map[string]any) ollama.ToolCall {
+	return ollama.ToolCall{Function: ollama.ToolCallFunction{Name: name, Arguments: args}}
+}
+
+func TestRegistry_Definitions(t *testing.T) {
+	defs := Builtin().Definitions()
+	if len(defs) != 3 {
+		t.Fatalf("Definitions() = %d tools, want 3", len(defs))
+	}
+	for _, def := range defs {
+		if def.Type != "function" || def.Function.Parameters.Type != "object" || def.Function.Description == "" {
+			t.Errorf("definition %+v is incomplete", def)
+		}
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry(Calculator())
+	r.Register(Tool{Name: "calculate", Run: func(map[string]any) (string, error) { return "replaced", nil }})
+
+	if names := r.Names(); len(names) != 1 {
+		t.Errorf("Names() = %v, want a single tool", names)
+	}
+	if got := r.Run(call("calculate", nil)); got != "replaced" {
+		t.Errorf("Run() = %q, want the replacement tool", got)
+	}
+}
+
+func TestRegistry_Run(t *testing.T) {
+	r := Builtin()
+	tests := []struct {
+		name string
+		call ollama.ToolCall
+		want string
+	}{
+		{"calculator", call("calculate", map[string]any{"expression": "2 + 2"}), "2 + 2 = 4"},
+		{"conversion", call("convert_units", map[string]any{"value": float64(10), "from": "km", "to": "m"}), "10 km = 10000 m"},
+		{"number as text", call("convert_units", map[string]any{"value": "1", "from": "kg", "to": "g"}), "1 kg = 1000 g"},
+		{"missing argument", call("calculate", map[string]any{}), `Error: missing argument "expression"`},
+		{"tool error", call("calculate", map[string]any{"expression": "1/0"}), "Error: division by zero"},
+		{"unknown tool", call("weather", nil), `Error: unknown tool "weather"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Run(tt.call); got != tt.want {
+				t.Errorf("Run() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentTime(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 5, 8, 12, 30, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	got := Builtin().Run(call("current_time", map[string]any{"timezone": "Asia/Tokyo"}))
+	want := "Wednesday, 2024-05-08 21:30:00 (Asia/Tokyo, JST, UTC+09:00)"
+	if got != want {
+		t.Errorf("current_time = %q, want %q", got, want)
+	}
+
+	got = Builtin().Run(call("current_time", map[string]any{"timezone": "Mars/Olympus"}))
+	if !strings.HasPrefix(got, "Error: unknown time zone") {
+		t.Errorf("current_time with an invalid zone = %q, want an error", got)
+	}
+}
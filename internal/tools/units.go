@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// unit is a unit of measure and its size in the base unit of its kind.
+type unit struct {
+	kind   string
+	factor float64
+}
+
+// units maps unit names and abbreviations to their definition. Temperatures
+// are not proportional and are converted separately.
+var units = map[string]unit{
+	// Length, in meters
+	"mm": {"length", 0.001}, "millimeter": {"length", 0.001},
+	"cm": {"length", 0.01}, "centimeter": {"length", 0.01},
+	"m": {"length", 1}, "meter": {"length", 1}, "metre": {"length", 1},
+	"km": {"length", 1000}, "kilometer": {"length", 1000}, "kilometre": {"length", 1000},
+	"in": {"length", 0.0254}, "inch": {"length", 0.0254}, "inches": {"length", 0.0254},
+	"ft": {"length", 0.3048}, "foot": {"length", 0.3048}, "feet": {"length", 0.3048},
+	"yd": {"length", 0.9144}, "yard": {"length", 0.9144},
+	"mi": {"length", 1609.344}, "mile": {"length", 1609.344},
+	"nmi": {"length", 1852}, "nautical mile": {"length", 1852},
+
+	// Mass, in kilograms
+	"mg": {"mass", 1e-6}, "milligram": {"mass", 1e-6},
+	"g": {"mass", 0.001}, "gram": {"mass", 0.001},
+	"kg": {"mass", 1}, "kilogram": {"mass", 1},
+	"t": {"mass", 1000}, "tonne": {"mass", 1000},
+	"oz": {"mass", 0.028349523125}, "ounce": {"mass", 0.028349523125},
+	"lb": {"mass", 0.45359237}, "lbs": {"mass", 0.45359237}, "pound": {"mass", 0.45359237},
+	"st": {"mass", 6.35029318}, "stone": {"mass", 6.35029318},
+
+	// Volume, in liters
+	"ml": {"volume", 0.001}, "milliliter": {"volume", 0.001},
+	"l": {"volume", 1}, "liter": {"volume", 1}, "litre": {"volume", 1},
+	"tsp": {"volume", 0.00492892159375}, "teaspoon": {"volume", 0.00492892159375},
+	"tbsp": {"volume", 0.01478676478125}, "tablespoon": {"volume", 0.01478676478125},
+	"fl oz": {"volume", 0.0295735295625}, "fluid ounce": {"volume", 0.0295735295625},
+	"cup": {"volume", 0.2365882365},
+	"pt":  {"volume", 0.473176473}, "pint": {"volume", 0.473176473},
+	"qt": {"volume", 0.946352946}, "quart": {"volume", 0.946352946},
+	"gal": {"volume", 3.785411784}, "gallon": {"volume", 3.785411784},
+
+	// Speed, in meters per second
+	"m/s":  {"speed", 1},
+	"km/h": {"speed", 1 / 3.6}, "kph": {"speed", 1 / 3.6},
+	"mph": {"speed", 0.44704},
+	"kn":  {"speed", 1852.0 / 3600}, "knot": {"speed", 1852.0 / 3600},
+
+	// Data, in bytes
+	"b": {"data", 1}, "byte": {"data", 1},
+	"kb": {"data", 1e3}, "kilobyte": {"data", 1e3},
+	"mb": {"data", 1e6}, "megabyte": {"data", 1e6},
+	"gb": {"data", 1e9}, "gigabyte": {"data", 1e9},
+	"tb": {"data", 1e12}, "terabyte": {"data", 1e12},
+	"kib": {"data", 1 << 10}, "mib": {"data", 1 << 20}, "gib": {"data", 1 << 30}, "tib": {"data", 1 << 40},
+
+	// Time, in seconds
+	"ms": {"time", 0.001}, "millisecond": {"time", 0.001},
+	"s": {"time", 1}, "sec": {"time", 1}, "second": {"time", 1},
+	"min": {"time", 60}, "minute": {"time", 60},
+	"h": {"time", 3600}, "hr": {"time", 3600}, "hour": {"time", 3600},
+	"day":  {"time", 86400},
+	"week": {"time", 604800},
+}
+
+// temperatures maps temperature names to a scale: "c", "f" or "k".
+var temperatures = map[string]string{
+	"c": "c", "celsius": "c",
+	"f": "f", "fahrenheit": "f",
+	"k": "k", "kelvin": "k",
+}
+
+// ConvertUnits returns the tool that converts between units of measure.
+func ConvertUnits() Tool {
+	return Tool{
+		Name:        "convert_units",
+		Description: "Convert a value between units of length, mass, volume (US), speed, temperature, data size or time, such as km to mi or °F to °C.",
+		Parameters: map[string]ollama.ToolProperty{
+			"value": {Type: "number", Description: "The value to convert."},
+			"from":  {Type: "string", Description: "The unit of the value, such as km, lb, °F, cup or GB."},
+			"to":    {Type: "string", Description: "The unit to convert to."},
+		},
+		Required: []string{"value", "from", "to"},
+		Run: func(args map[string]any) (string, error) {
+			value, err := numberArg(args, "value")
+			if err != nil {
+				return "", err
+			}
+			from, to := stringArg(args, "from"), stringArg(args, "to")
+			result, err := convert(value, from, to)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s %s = %s %s", formatNumber(value), from, formatNumber(result), to), nil
+		},
+	}
+}
+
+// convert converts value from one unit to another of the same kind.
+func convert(value float64, from, to string) (float64, error) {
+	fromName, toName := normalizeUnit(from), normalizeUnit(to)
+
+	fromScale, fromTemp := temperatures[fromName]
+	toScale, toTemp := temperatures[toName]
+	if fromTemp || toTemp {
+		if !fromTemp || !toTemp {
+			return 0, fmt.Errorf("can't convert %s to %s", from, to)
+		}
+		return convertTemperature(value, fromScale, toScale), nil
+	}
+
+	fromUnit, ok := units[fromName]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	toUnit, ok := units[toName]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if fromUnit.kind != toUnit.kind {
+		return 0, fmt.Errorf("can't convert %s (%s) to %s (%s)", from, fromUnit.kind, to, toUnit.kind)
+	}
+	return value * fromUnit.factor / toUnit.factor, nil
+}
+
+// convertTemperature converts between the "c", "f" and "k" scales.
+func convertTemperature(value float64, from, to string) float64 {
+	celsius := value
+	switch from {
+	case "f":
+		celsius = (value - 32) * 5 / 9
+	case "k":
+		celsius = value - 273.15
+	}
+	switch to {
+	case "f":
+		return celsius*9/5 + 32
+	case "k":
+		return celsius + 273.15
+	default:
+		return celsius
+	}
+}
+
+// normalizeUnit lowercases a unit name and removes degree signs and plurals
+// so that "°F", "Miles" and "kilometres" are found.
+func normalizeUnit(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "°"), "degrees ")
+	name = strings.Join(strings.Fields(name), " ")
+	if _, ok := units[name]; ok {
+		return name
+	}
+	if _, ok := temperatures[name]; ok {
+		return name
+	}
+	if singular, ok := strings.CutSuffix(name, "s"); ok {
+		if _, ok := units[singular]; ok {
+			return singular
+		}
+	}
+	return name
+}
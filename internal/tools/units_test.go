@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{5, "km", "mi", 3.10685596},
+		{1, "Miles", "kilometres", 1.609344},
+		{2, "lbs", "kg", 0.90718474},
+		{1, "gallon", "l", 3.785411784},
+		{100, "km/h", "mph", 62.1371192},
+		{1, "GiB", "MB", 1073.741824},
+		{90, "mins", "h", 1.5},
+		{1500, "ms", "s", 1.5},
+		{212, "°F", "°C", 100},
+		{0, "celsius", "kelvin", 273.15},
+		{300, "K", "F", 80.33},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			got, err := convert(tt.value, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("convert() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("convert(%v, %q, %q) = %v, want %v", tt.value, tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	tests := []struct{ from, to string }{
+		{"km", "kg"},
+		{"°C", "m"},
+		{"parsec", "m"},
+		{"m", "cubits"},
+	}
+
+	for _, tt := range tests {
+		if _, err := convert(1, tt.from, tt.to); err == nil {
+			t.Errorf("convert(1, %q, %q) should fail", tt.from, tt.to)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// Accessibility preferences read from the desktop.
+var (
+	reduceMotion  bool
+	highContrast  bool
+	a11yListeners = map[int]func(){}
+	a11yNextID    int
+)
+
+// motionReduced reports whether the desktop asks for reduced animations.
+func motionReduced() bool {
+	return reduceMotion
+}
+
+// onAccessibilityChanged registers a callback that runs when the reduced-motion
+// or high-contrast settings change. The returned function unregisters it, for
+// widgets that are destroyed before the app exits.
+func onAccessibilityChanged(callback func()) (unregister func()) {
+	id := a11yNextID
+	a11yNextID++
+	a11yListeners[id] = callback
+	return func() {
+		delete(a11yListeners, id)
+	}
+}
+
+// setupAccessibility reads the reduced-motion and high-contrast settings and
+// follows their changes.
+func setupAccessibility() {
+	settings := gtk.SettingsGetDefault()
+	styleManager := adw.StyleManagerGetDefault()
+
+	apply := func() {
+		if animations, ok := settings.ObjectProperty("gtk-enable-animations").(bool); ok {
+			reduceMotion = !animations
+		}
+		setHighContrast(styleManager.HighContrast())
+
+		logger.Debug("Accessibility settings", "reduceMotion", reduceMotion, "highContrast", highContrast)
+		for _, listener := range a11yListeners {
+			listener()
+		}
+	}
+
+	settings.NotifyProperty("gtk-enable-animations", apply)
+	styleManager.NotifyProperty("high-contrast", apply)
+	apply()
+}
+
+// setHighContrast switches the syntax style and code block colors.
+func setHighContrast(enabled bool) {
+	if enabled == highContrast {
+		return
+	}
+	highContrast = enabled
+	updateSyntaxStyle()
+}
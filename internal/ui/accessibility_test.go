@@ -0,0 +1,22 @@
+package ui
+
+import "testing"
+
+func TestOnAccessibilityChanged(t *testing.T) {
+	calls := 0
+	unregister := onAccessibilityChanged(func() { calls++ })
+	for _, listener := range a11yListeners {
+		listener()
+	}
+	if calls != 1 {
+		t.Fatalf("listener called %d times, want 1", calls)
+	}
+
+	unregister()
+	for _, listener := range a11yListeners {
+		listener()
+	}
+	if calls != 1 {
+		t.Errorf("listener called after unregistering")
+	}
+}
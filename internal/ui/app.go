@@ -2,12 +2,22 @@
 package ui
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
 	"github.com/diamondburned/gotk4/pkg/gdk/v4"
 	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 
+	"github.com/storo/guanaco/internal/config"
 	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
 )
 
 const styleCSS = `
@@ -33,6 +43,31 @@ const styleCSS = `
   font-style: italic;
 }
 
+/* Multi-agent conversation participants */
+.message-agent-0 .card {
+  background: alpha(@blue_3, 0.12);
+  border-radius: 12px;
+}
+
+.message-agent-1 .card {
+  background: alpha(@purple_3, 0.12);
+  border-radius: 12px;
+}
+
+.agent-name {
+  opacity: 0.8;
+}
+
+/* Message actions (regenerate, edit): shown on hover or focus */
+.message-actions {
+  opacity: 0;
+}
+
+.message-bubble:hover .message-actions,
+.message-actions:focus-within {
+  opacity: 0.8;
+}
+
 /* Input Area */
 .input-area {
   background: @card_bg_color;
@@ -73,9 +108,42 @@ const styleCSS = `
   background: alpha(@accent_bg_color, 0.25);
 }
 
+/* Warning above the input area for prompts over the context window */
+.context-warning,
+.missing-model {
+  background: alpha(@warning_bg_color, 0.15);
+  border-radius: 12px;
+  padding: 8px 12px 8px 12px;
+}
+
+/* Markdown tables */
+.table-block {
+  border: 1px solid alpha(@borders, 0.5);
+  border-radius: 8px;
+  margin: 4px 0;
+}
+
+.table-header {
+  border-bottom: 1px solid alpha(@borders, 0.5);
+  padding-bottom: 4px;
+}
+
+/* JSON responses */
+.json-block {
+  border: 1px solid alpha(@borders, 0.5);
+  border-radius: 8px;
+  margin: 4px 0;
+}
+
+/* Work done before a response, such as a model download */
+.status-bar {
+  background: alpha(@accent_bg_color, 0.1);
+  border-radius: 12px;
+  padding: 8px 12px 8px 12px;
+}
+
 /* Code Blocks */
 .code-block {
-  background: #282a36;
   border-radius: 8px;
   margin: 4px 0;
 }
@@ -87,13 +155,11 @@ const styleCSS = `
 .code-lang {
   font-size: 12px;
   opacity: 0.7;
-  color: #f8f8f2;
 }
 
 .code-content {
   font-family: monospace;
   font-size: 13px;
-  color: #f8f8f2;
   background: transparent;
 }
 
@@ -101,6 +167,17 @@ const styleCSS = `
   background: transparent;
 }
 
+.code-output {
+  font-family: monospace;
+  font-size: 12px;
+  border-top: 1px solid alpha(@borders, 0.3);
+  padding-top: 8px;
+}
+
+.code-output.error {
+  color: #ff5555;
+}
+
 /* Welcome Screen */
 .welcome-logo {
   margin-bottom: 16px;
@@ -137,45 +214,225 @@ const (
 // Application wraps the Adwaita application.
 type Application struct {
 	*adw.Application
-	window *MainWindow
+	windows []*MainWindow // Open windows, oldest first
+
+	// Shared by the windows, set up by the first one
+	ollamaClient *ollama.Client
+	db           *store.DB
+	appConfig    *config.AppConfig
+
+	// Reconnection to the database after a failed write, see retryStorage
+	storageRetry      glib.SourceHandle
+	storageRetryDelay uint
+	storageFailure    string // Banner title while messages wait, "" if none
+
+	// Running in the background, see holdInBackground
+	held     bool
+	quitting bool
 }
 
 // NewApplication creates a new Guanaco application.
 func NewApplication() *Application {
-	// Initialize i18n (detects system language)
-	i18n.Init("")
-
 	app := &Application{}
 
 	app.Application = adw.NewApplication(AppID, gio.ApplicationFlagsNone)
+	app.ConnectStartup(app.onStartup)
 	app.ConnectActivate(app.onActivate)
 
 	return app
 }
 
-// onActivate is called when the application is activated.
-func (a *Application) onActivate() {
+// onStartup sets up the styles once, when the application starts. Unlike
+// activation, it doesn't run again when Guanaco is started while it runs in
+// the background.
+func (a *Application) onStartup() {
 	// Load custom CSS
 	loadCSS()
+	setupAccessibility()
+	setupSyntaxStyle()
+	if _, err := reloadUserCSS(); err != nil {
+		logger.Error("Failed to load user stylesheet", "path", config.GetUserStylePath(), "error", err)
+	}
+}
 
+// onActivate is called when the application is activated.
+func (a *Application) onActivate() {
 	// Create main window if it doesn't exist
-	if a.window == nil {
-		a.window = NewMainWindow(a.Application)
+	if len(a.windows) == 0 {
+		NewMainWindow(a)
 	}
 
-	a.window.Present()
+	for _, win := range a.windows {
+		win.leaveBackground()
+	}
+	a.windows[0].Present()
 }
 
 // loadCSS loads the application stylesheet.
 func loadCSS() {
 	provider := gtk.NewCSSProvider()
-	provider.LoadFromData(styleCSS)
+	provider.LoadFromData(styleCSS + chatColorCSS())
 
 	display := gdk.DisplayGetDefault()
 	gtk.StyleContextAddProviderForDisplay(display, provider, gtk.STYLE_PROVIDER_PRIORITY_APPLICATION)
 }
 
-// Run starts the application.
+// userCSSProvider holds the user stylesheet while it is loaded.
+var userCSSProvider *gtk.CSSProvider
+
+// reloadUserCSS (re)loads the optional user stylesheet on top of the
+// built-in one. Returns false if the file doesn't exist. Parsing errors are
+// reported, but the rules that parsed correctly still apply.
+func reloadUserCSS() (bool, error) {
+	display := gdk.DisplayGetDefault()
+	if userCSSProvider != nil {
+		gtk.StyleContextRemoveProviderForDisplay(display, userCSSProvider)
+		userCSSProvider = nil
+	}
+
+	path := config.GetUserStylePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read user stylesheet: %w", err)
+	}
+
+	var parseErr error
+	provider := gtk.NewCSSProvider()
+	provider.ConnectParsingError(func(section *gtk.CSSSection, err error) {
+		logger.Warn("User stylesheet error", "location", section.String(), "error", err)
+		if parseErr == nil {
+			parseErr = fmt.Errorf("%s: %w", section.String(), err)
+		}
+	})
+	provider.LoadFromData(string(data))
+
+	// Above the app and high-contrast stylesheets so user rules win
+	gtk.StyleContextAddProviderForDisplay(display, provider, gtk.STYLE_PROVIDER_PRIORITY_APPLICATION+2)
+	userCSSProvider = provider
+	logger.Info("User stylesheet loaded", "path", path)
+
+	return true, parseErr
+}
+
+// appearanceProvider holds the stylesheet generated from the appearance
+// settings while it is loaded.
+var appearanceProvider *gtk.CSSProvider
+
+// applyAppearance applies the color scheme, syntax styles, accent color and
+// message density of cfg, replacing the ones applied before. It runs again
+// whenever they change, so they apply without a restart.
+func applyAppearance(cfg *config.AppConfig) {
+	plainTextMessages = cfg.PlainTextMessages
+	adw.StyleManagerGetDefault().SetColorScheme(adwColorScheme(cfg.ColorScheme))
+	setSyntaxStyles(cfg.SyntaxStyleLight, cfg.SyntaxStyleDark)
+
+	display := gdk.DisplayGetDefault()
+	if appearanceProvider != nil {
+		gtk.StyleContextRemoveProviderForDisplay(display, appearanceProvider)
+		appearanceProvider = nil
+	}
+
+	css := appearanceCSS(cfg.AccentColor, cfg.MessageDensity)
+	if css == "" {
+		return
+	}
+	provider := gtk.NewCSSProvider()
+	provider.LoadFromData(css)
+
+	// Above the app stylesheet, whose bubble spacing it changes
+	gtk.StyleContextAddProviderForDisplay(display, provider, gtk.STYLE_PROVIDER_PRIORITY_APPLICATION+1)
+	appearanceProvider = provider
+}
+
+// adwColorScheme returns the Adwaita color scheme for a ColorScheme setting.
+func adwColorScheme(scheme string) adw.ColorScheme {
+	switch scheme {
+	case config.ColorSchemeLight:
+		return adw.ColorSchemeForceLight
+	case config.ColorSchemeDark:
+		return adw.ColorSchemeForceDark
+	default:
+		return adw.ColorSchemeDefault
+	}
+}
+
+// densityCSS holds the rules of each message density other than the
+// default, comfortable one.
+var densityCSS = map[string]string{
+	config.DensityCompact: `
+.message-bubble {
+  margin: 0;
+}
+
+.message-user .card {
+  border-radius: 14px;
+  padding: 2px 10px;
+}
+
+.message-system .card {
+  padding: 2px 10px;
+}
+`,
+	config.DensitySpacious: `
+.message-bubble {
+  margin: 12px 0;
+}
+
+.message-user .card {
+  border-radius: 22px;
+  padding: 14px 20px;
+}
+
+.message-system .card {
+  padding: 12px 18px;
+}
+`,
+}
+
+// appearanceCSS returns the stylesheet for an accent color and a message
+// density. It is empty for the system accent and the default density. An
+// accent that isn't a "#rrggbb" color is ignored.
+func appearanceCSS(accent, density string) string {
+	var builder strings.Builder
+	if r, g, b, ok := parseHexColor(accent); ok {
+		// Text on the accent color is black on light accents
+		foreground := "#ffffff"
+		if 0.299*float64(r)+0.587*float64(g)+0.114*float64(b) > 160 {
+			foreground = "rgba(0, 0, 0, 0.8)"
+		}
+		hex := fmt.Sprintf("#%02x%02x%02x", r, g, b)
+		fmt.Fprintf(&builder, "@define-color accent_bg_color %s;\n", hex)
+		fmt.Fprintf(&builder, "@define-color accent_color %s;\n", hex)
+		fmt.Fprintf(&builder, "@define-color accent_fg_color %s;\n", foreground)
+	}
+	builder.WriteString(densityCSS[density])
+	return builder.String()
+}
+
+// parseHexColor parses a color written as "#rrggbb".
+func parseHexColor(color string) (r, g, b uint8, ok bool) {
+	color = strings.TrimSpace(color)
+	if len(color) != 7 || color[0] != '#' {
+		return 0, 0, 0, false
+	}
+	value, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(value >> 16), uint8(value >> 8), uint8(value), true
+}
+
+// Run starts the application. The portable mode option is handled here,
+// as GApplication rejects options it doesn't know.
 func (a *Application) Run(args []string) int {
+	args = config.SetupPortable(args)
+	if dir := config.PortableDir(); dir != "" {
+		logger.Info("Running in portable mode", "dir", dir)
+	}
+	// Initialize i18n (detects system language), once the data folder is known
+	i18n.Init(config.GetLocaleDir())
 	return a.Application.Run(args)
 }
@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// accentColor is a named accent offered in the appearance settings.
+type accentColor struct {
+	Name string
+	Hex  string
+}
+
+// accentColors are the accents offered besides the system one, the same as
+// GNOME's.
+var accentColors = []accentColor{
+	{"Blue", "#3584e4"},
+	{"Teal", "#2190a4"},
+	{"Green", "#3a944a"},
+	{"Yellow", "#c88800"},
+	{"Orange", "#ed5b00"},
+	{"Red", "#e62d42"},
+	{"Pink", "#d56199"},
+	{"Purple", "#9141ac"},
+	{"Slate", "#6f8396"},
+}
+
+// colorSchemes, messageDensities and syntaxStyles are the choices of their
+// dropdowns, in order.
+var (
+	colorSchemes     = []string{config.ColorSchemeSystem, config.ColorSchemeLight, config.ColorSchemeDark}
+	messageDensities = []string{config.DensityCompact, config.DensityComfortable, config.DensitySpacious}
+	syntaxStyles     = styles.Names()
+)
+
+// indexOf returns the index of value in values, or fallback if it isn't
+// there.
+func indexOf(values []string, value string, fallback int) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return fallback
+}
+
+// accentIndex returns the entry of the accent dropdown for a color: 0 for
+// the system accent, one of accentColors, or the last entry for a custom
+// color.
+func accentIndex(hex string) int {
+	if hex == "" {
+		return 0
+	}
+	for i, accent := range accentColors {
+		if accent.Hex == hex {
+			return i + 1
+		}
+	}
+	return len(accentColors) + 1
+}
+
+// rgbaHex returns a color as "#rrggbb".
+func rgbaHex(color *gdk.RGBA) string {
+	channel := func(v float32) int {
+		return int(v*255 + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", channel(color.Red()), channel(color.Green()), channel(color.Blue()))
+}
+
+// createAppearancePage creates the page choosing the color scheme, accent
+// color, message density and code styles. Changes are shown right away, and undone if
+// the dialog is closed without saving.
+func (d *SettingsDialog) createAppearancePage() *gtk.ScrolledWindow {
+	content := gtk.NewBox(gtk.OrientationVertical, 16)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	// === Style ===
+	schemeLabel := gtk.NewLabel(i18n.T("Style:"))
+	schemeLabel.SetXAlign(0)
+	schemeLabel.AddCSSClass("heading")
+	content.Append(schemeLabel)
+
+	d.schemeDropdown = gtk.NewDropDown(gtk.NewStringList([]string{
+		i18n.T("Follow system"),
+		i18n.T("Light"),
+		i18n.T("Dark"),
+	}), nil)
+	d.schemeDropdown.SetSelected(uint(indexOf(colorSchemes, d.config.ColorScheme, 0)))
+	d.schemeDropdown.NotifyProperty("selected", d.previewAppearance)
+	content.Append(d.schemeDropdown)
+
+	// === Accent Color ===
+	accentLabel := gtk.NewLabel(i18n.T("Accent Color:"))
+	accentLabel.SetXAlign(0)
+	accentLabel.SetMarginTop(8)
+	accentLabel.AddCSSClass("heading")
+	content.Append(accentLabel)
+
+	accentNames := []string{i18n.T("System")}
+	for _, accent := range accentColors {
+		accentNames = append(accentNames, i18n.T(accent.Name))
+	}
+	accentNames = append(accentNames, i18n.T("Custom"))
+
+	accentBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	d.accentDropdown = gtk.NewDropDown(gtk.NewStringList(accentNames), nil)
+	d.accentDropdown.SetHExpand(true)
+	d.accentDropdown.SetSelected(uint(accentIndex(d.config.AccentColor)))
+	accentBox.Append(d.accentDropdown)
+
+	colorDialog := gtk.NewColorDialog()
+	colorDialog.SetWithAlpha(false)
+	d.accentButton = gtk.NewColorDialogButton(colorDialog)
+	d.accentButton.SetTooltipText(i18n.T("Choose a custom accent color"))
+	custom := gdk.NewRGBA(0.21, 0.52, 0.89, 1)
+	if _, _, _, ok := parseHexColor(d.config.AccentColor); ok {
+		custom.Parse(d.config.AccentColor)
+	}
+	d.accentButton.SetRGBA(&custom)
+	d.accentButton.SetVisible(d.accentDropdown.Selected() == uint(len(accentColors)+1))
+	accentBox.Append(d.accentButton)
+	content.Append(accentBox)
+
+	d.accentDropdown.NotifyProperty("selected", func() {
+		d.accentButton.SetVisible(d.accentDropdown.Selected() == uint(len(accentColors)+1))
+		d.previewAppearance()
+	})
+	d.accentButton.NotifyProperty("rgba", d.previewAppearance)
+
+	// === Message Density ===
+	densityLabel := gtk.NewLabel(i18n.T("Message Density:"))
+	densityLabel.SetXAlign(0)
+	densityLabel.SetMarginTop(8)
+	densityLabel.AddCSSClass("heading")
+	content.Append(densityLabel)
+
+	densityHint := gtk.NewLabel(i18n.T("Space around the messages of a chat"))
+	densityHint.SetXAlign(0)
+	densityHint.AddCSSClass("dim-label")
+	densityHint.AddCSSClass("caption")
+	content.Append(densityHint)
+
+	d.densityDropdown = gtk.NewDropDown(gtk.NewStringList([]string{
+		i18n.T("Compact"),
+		i18n.T("Comfortable"),
+		i18n.T("Spacious"),
+	}), nil)
+	d.densityDropdown.SetSelected(uint(indexOf(messageDensities, d.config.MessageDensity, 1)))
+	d.densityDropdown.NotifyProperty("selected", d.previewAppearance)
+	content.Append(d.densityDropdown)
+
+	// === Code Style ===
+	syntaxLabel := gtk.NewLabel(i18n.T("Code Style:"))
+	syntaxLabel.SetXAlign(0)
+	syntaxLabel.SetMarginTop(8)
+	syntaxLabel.AddCSSClass("heading")
+	content.Append(syntaxLabel)
+
+	syntaxHint := gtk.NewLabel(i18n.T("Colors of code blocks in the light and dark styles"))
+	syntaxHint.SetXAlign(0)
+	syntaxHint.AddCSSClass("dim-label")
+	syntaxHint.AddCSSClass("caption")
+	content.Append(syntaxHint)
+
+	syntaxGrid := gtk.NewGrid()
+	syntaxGrid.SetRowSpacing(8)
+	syntaxGrid.SetColumnSpacing(12)
+	d.lightCodeStyle = d.newSyntaxDropdown(d.config.SyntaxStyleLight, config.DefaultSyntaxStyleLight)
+	d.darkCodeStyle = d.newSyntaxDropdown(d.config.SyntaxStyleDark, config.DefaultSyntaxStyleDark)
+	for row, choice := range []struct {
+		label    string
+		dropdown *gtk.DropDown
+	}{
+		{i18n.T("Light"), d.lightCodeStyle},
+		{i18n.T("Dark"), d.darkCodeStyle},
+	} {
+		label := gtk.NewLabel(choice.label)
+		label.SetXAlign(0)
+		syntaxGrid.Attach(label, 0, row, 1, 1)
+		syntaxGrid.Attach(choice.dropdown, 1, row, 1, 1)
+	}
+	content.Append(syntaxGrid)
+
+	// === Plain Text Messages ===
+	plainTextBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	plainTextBox.SetMarginTop(8)
+
+	plainTextText := gtk.NewBox(gtk.OrientationVertical, 2)
+	plainTextText.SetHExpand(true)
+
+	plainTextLabel := gtk.NewLabel(i18n.T("Plain text messages"))
+	plainTextLabel.SetXAlign(0)
+	plainTextLabel.AddCSSClass("heading")
+	plainTextText.Append(plainTextLabel)
+
+	plainTextHint := gtk.NewLabel(i18n.T("Show messages without formatting or code block widgets, with headings, lists, tables, quotes and code announced in words, for screen readers and braille displays"))
+	plainTextHint.SetXAlign(0)
+	plainTextHint.SetWrap(true)
+	plainTextHint.AddCSSClass("dim-label")
+	plainTextHint.AddCSSClass("caption")
+	plainTextText.Append(plainTextHint)
+	plainTextBox.Append(plainTextText)
+
+	d.plainTextSwitch = gtk.NewSwitch()
+	d.plainTextSwitch.SetActive(d.config.PlainTextMessages)
+	d.plainTextSwitch.SetVAlign(gtk.AlignCenter)
+	plainTextBox.Append(d.plainTextSwitch)
+	content.Append(plainTextBox)
+
+	// Closing without saving goes back to the saved appearance
+	d.ConnectCloseRequest(func() bool {
+		if !d.saved {
+			applyAppearance(d.config)
+		}
+		return false
+	})
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(content)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	return scrolled
+}
+
+// newSyntaxDropdown creates a dropdown choosing a Chroma style, with style
+// selected, or fallback if there is no such style.
+func (d *SettingsDialog) newSyntaxDropdown(style, fallback string) *gtk.DropDown {
+	dropdown := gtk.NewDropDown(gtk.NewStringList(syntaxStyles), nil)
+	dropdown.SetHExpand(true)
+	dropdown.SetSelected(uint(indexOf(syntaxStyles, style, indexOf(syntaxStyles, fallback, 0))))
+	dropdown.NotifyProperty("selected", d.previewAppearance)
+	return dropdown
+}
+
+// selectedSyntaxStyles returns the code styles chosen for the light and dark
+// color schemes.
+func (d *SettingsDialog) selectedSyntaxStyles() (light, dark string) {
+	return syntaxStyles[min(int(d.lightCodeStyle.Selected()), len(syntaxStyles)-1)],
+		syntaxStyles[min(int(d.darkCodeStyle.Selected()), len(syntaxStyles)-1)]
+}
+
+// selectedAppearance returns the color scheme, accent color and message
+// density chosen in the appearance page.
+func (d *SettingsDialog) selectedAppearance() (scheme, accent, density string) {
+	scheme = colorSchemes[min(int(d.schemeDropdown.Selected()), len(colorSchemes)-1)]
+	density = messageDensities[min(int(d.densityDropdown.Selected()), len(messageDensities)-1)]
+
+	switch index := int(d.accentDropdown.Selected()); {
+	case index >= 1 && index <= len(accentColors):
+		accent = accentColors[index-1].Hex
+	case index == len(accentColors)+1:
+		accent = rgbaHex(d.accentButton.RGBA())
+	}
+	return scheme, accent, density
+}
+
+// previewAppearance applies the appearance chosen in the page without
+// saving it.
+func (d *SettingsDialog) previewAppearance() {
+	preview := *d.config
+	preview.ColorScheme, preview.AccentColor, preview.MessageDensity = d.selectedAppearance()
+	preview.SyntaxStyleLight, preview.SyntaxStyleDark = d.selectedSyntaxStyles()
+	applyAppearance(&preview)
+}
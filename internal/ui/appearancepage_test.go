@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/config"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		color   string
+		r, g, b uint8
+		ok      bool
+	}{
+		{"#3584e4", 0x35, 0x84, 0xe4, true},
+		{" #FFFFFF ", 0xff, 0xff, 0xff, true},
+		{"", 0, 0, 0, false},
+		{"3584e4", 0, 0, 0, false},
+		{"#358", 0, 0, 0, false},
+		{"#35g4e4", 0, 0, 0, false},
+		{"#+584e4", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.color, func(t *testing.T) {
+			r, g, b, ok := parseHexColor(tt.color)
+			if r != tt.r || g != tt.g || b != tt.b || ok != tt.ok {
+				t.Errorf("parseHexColor(%q) = %d, %d, %d, %v, want %d, %d, %d, %v", tt.color, r, g, b, ok, tt.r, tt.g, tt.b, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAppearanceCSS(t *testing.T) {
+	if css := appearanceCSS("", config.DensityComfortable); css != "" {
+		t.Errorf("appearanceCSS() for the defaults = %q, want empty", css)
+	}
+	if css := appearanceCSS("blue", ""); css != "" {
+		t.Errorf("appearanceCSS() for an invalid accent = %q, want empty", css)
+	}
+
+	css := appearanceCSS("#E62D42", config.DensityCompact)
+	for _, want := range []string{
+		"@define-color accent_bg_color #e62d42;",
+		"@define-color accent_color #e62d42;",
+		"@define-color accent_fg_color #ffffff;",
+		".message-user .card",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("appearanceCSS() = %q, want it to contain %q", css, want)
+		}
+	}
+
+	// Light accents get dark text
+	if css := appearanceCSS("#f6d32d", ""); !strings.Contains(css, "accent_fg_color rgba(0, 0, 0, 0.8)") {
+		t.Errorf("appearanceCSS() for a light accent = %q, want dark text", css)
+	}
+}
+
+func TestAccentIndex(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want int
+	}{
+		{"", 0},
+		{"#3584e4", 1},
+		{"#6f8396", len(accentColors)},
+		{"#123456", len(accentColors) + 1},
+	}
+	for _, tt := range tests {
+		if got := accentIndex(tt.hex); got != tt.want {
+			t.Errorf("accentIndex(%q) = %d, want %d", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestIndexOf(t *testing.T) {
+	if got := indexOf(colorSchemes, config.ColorSchemeDark, 0); got != 2 {
+		t.Errorf("indexOf(dark) = %d, want 2", got)
+	}
+	if got := indexOf(messageDensities, "", 1); got != 1 {
+		t.Errorf("indexOf(\"\") = %d, want the fallback 1", got)
+	}
+}
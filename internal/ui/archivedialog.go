@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+// maxArchivePreselected is how many supported files an archive can have for
+// all of them to be selected when the dialog opens. Larger archives start
+// with nothing selected, as sending all their files is rarely wanted.
+const maxArchivePreselected = 20
+
+// initialArchiveSelection returns which entries are selected when the
+// dialog opens.
+func initialArchiveSelection(entries []rag.ArchiveEntry) []bool {
+	supported := 0
+	for _, entry := range entries {
+		if entry.Supported {
+			supported++
+		}
+	}
+
+	selected := make([]bool, len(entries))
+	if supported > maxArchivePreselected {
+		return selected
+	}
+	for i, entry := range entries {
+		selected[i] = entry.Supported
+	}
+	return selected
+}
+
+// ArchiveDialog lists the files of an archive and lets the user choose the
+// ones to attach.
+type ArchiveDialog struct {
+	*adw.Window
+
+	// Data
+	filename string
+	entries  []rag.ArchiveEntry
+	checks   []*gtk.CheckButton
+
+	// UI
+	caption   *gtk.Label
+	attachBtn *gtk.Button
+
+	// Callbacks
+	onAttach func(names []string)
+}
+
+// NewArchiveDialog creates a dialog to choose the files of the archive
+// filename, whose files are entries.
+func NewArchiveDialog(parent *gtk.Window, filename string, entries []rag.ArchiveEntry) *ArchiveDialog {
+	d := &ArchiveDialog{
+		filename: filename,
+		entries:  entries,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(filename)
+	d.SetModal(true)
+	d.SetDefaultSize(520, 560)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+
+	return d
+}
+
+func (d *ArchiveDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(adw.NewWindowTitle(d.filename, i18n.T("Choose the files to attach")))
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(12)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	d.caption = gtk.NewLabel("")
+	d.caption.SetXAlign(0)
+	d.caption.AddCSSClass("dim-label")
+	d.caption.AddCSSClass("caption")
+	content.Append(d.caption)
+
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+
+	selected := initialArchiveSelection(d.entries)
+	d.checks = make([]*gtk.CheckButton, len(d.entries))
+	for i, entry := range d.entries {
+		check := gtk.NewCheckButton()
+		check.SetActive(selected[i])
+		check.SetSensitive(entry.Supported)
+		check.ConnectToggled(d.updateSelection)
+		d.checks[i] = check
+
+		row := adw.NewActionRow()
+		row.SetUseMarkup(false)
+		row.SetTitle(entry.Name)
+		if entry.Supported {
+			row.SetSubtitle(glib.FormatSize(uint64(entry.Size)))
+		} else {
+			row.SetSubtitle(glib.FormatSize(uint64(entry.Size)) + " · " + i18n.T("Not supported"))
+			row.SetSensitive(false)
+		}
+		row.AddPrefix(check)
+		row.SetActivatableWidget(check)
+		list.Append(row)
+	}
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(list)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	content.Append(scrolled)
+
+	// === Buttons ===
+	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttonBox.SetHAlign(gtk.AlignEnd)
+	buttonBox.SetMarginTop(4)
+
+	cancelBtn := gtk.NewButton()
+	cancelBtn.SetLabel(i18n.T("Cancel"))
+	cancelBtn.ConnectClicked(func() {
+		d.Close()
+	})
+	buttonBox.Append(cancelBtn)
+
+	d.attachBtn = gtk.NewButton()
+	d.attachBtn.SetLabel(i18n.T("Attach"))
+	d.attachBtn.AddCSSClass("suggested-action")
+	d.attachBtn.ConnectClicked(func() {
+		names := d.selectedNames()
+		if len(names) > 0 && d.onAttach != nil {
+			d.onAttach(names)
+		}
+		d.Close()
+	})
+	buttonBox.Append(d.attachBtn)
+
+	content.Append(buttonBox)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(content)
+
+	d.SetContent(toolbarView)
+
+	d.updateSelection()
+}
+
+// selectedNames returns the names of the selected files, in archive order.
+func (d *ArchiveDialog) selectedNames() []string {
+	var names []string
+	for i, check := range d.checks {
+		if check.Active() && d.entries[i].Supported {
+			names = append(names, d.entries[i].Name)
+		}
+	}
+	return names
+}
+
+// updateSelection shows how many files are selected and allows attaching
+// them when there is any.
+func (d *ArchiveDialog) updateSelection() {
+	var count int
+	var size int64
+	for i, check := range d.checks {
+		if check.Active() && d.entries[i].Supported {
+			count++
+			size += d.entries[i].Size
+		}
+	}
+
+	d.caption.SetText(fmt.Sprintf(i18n.T("%d of %d files selected (%s)"), count, len(d.entries), glib.FormatSize(uint64(size))))
+	d.attachBtn.SetSensitive(count > 0)
+}
+
+// OnAttach sets the callback called with the names of the selected files
+// when they are attached.
+func (d *ArchiveDialog) OnAttach(callback func(names []string)) {
+	d.onAttach = callback
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/storo/guanaco/internal/rag"
+)
+
+func TestInitialArchiveSelection(t *testing.T) {
+	t.Run("small archive selects supported files", func(t *testing.T) {
+		entries := []rag.ArchiveEntry{
+			{Name: "README.md", Supported: true},
+			{Name: "logo.png", Supported: false},
+			{Name: "notes.txt", Supported: true},
+		}
+		got := initialArchiveSelection(entries)
+		want := []bool{true, false, true}
+		if !slices.Equal(got, want) {
+			t.Errorf("initialArchiveSelection() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("large archive selects nothing", func(t *testing.T) {
+		var entries []rag.ArchiveEntry
+		for i := 0; i <= maxArchivePreselected; i++ {
+			entries = append(entries, rag.ArchiveEntry{Name: fmt.Sprintf("file%d.txt", i), Supported: true})
+		}
+		for i, selected := range initialArchiveSelection(entries) {
+			if selected {
+				t.Errorf("entry %d selected, want none", i)
+			}
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"slices"
+
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// With "Run in background" on, closing the last window leaves Guanaco
+// running without a window: the application is held, and the database and
+// the Ollama client stay open, so starting Guanaco again opens a window at
+// once. GTK 4 has no status icon, so a notification says Guanaco is still
+// running. Quit Completely (Ctrl+Q) closes every window and exits.
+
+// keepRunning reports whether closing the last window leaves Guanaco
+// running in the background.
+func (a *Application) keepRunning() bool {
+	return a.appConfig != nil && a.appConfig.RunInBackground && !a.quitting
+}
+
+// holdInBackground keeps Guanaco running after its last window closed.
+func (a *Application) holdInBackground() {
+	if a.held {
+		return
+	}
+	a.held = true
+	a.Hold()
+	logger.Info("Running in background without a window")
+
+	notification := gio.NewNotification("Guanaco")
+	notification.SetBody(i18n.T("Guanaco is still running in the background. Open it again to get back to your chats"))
+	a.SendNotification("background", notification)
+}
+
+// releaseBackground stops holding Guanaco once a window is open again.
+func (a *Application) releaseBackground() {
+	if !a.held {
+		return
+	}
+	a.held = false
+	a.WithdrawNotification("background")
+	a.Release()
+}
+
+// QuitCompletely closes every window and exits, even when Guanaco runs in
+// the background. Windows that ask before closing can still cancel it.
+func (a *Application) QuitCompletely() {
+	logger.Info("Quitting completely")
+	a.quitting = true
+	for _, win := range slices.Clone(a.windows) {
+		win.Close()
+	}
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+
+	"github.com/storo/guanaco/internal/calendar"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// calendarUnavailable replaces the calendar variable when the events can't
+// be read.
+const calendarUnavailable = "The calendar could not be read."
+
+// usesCalendar reports whether any of texts contains the calendar variable.
+func usesCalendar(texts ...string) bool {
+	for _, text := range texts {
+		if strings.Contains(text, calendar.Variable) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandCalendar replaces the calendar variable in the content of messages
+// with events.
+func expandCalendar(messages []ollama.Message, events string) []ollama.Message {
+	for i := range messages {
+		messages[i].Content = strings.ReplaceAll(messages[i].Content, calendar.Variable, events)
+	}
+	return messages
+}
+
+// withCalendar fills the calendar variable in messages with today's events.
+// Without consent the variable is removed, so no events are sent.
+func (cv *ChatView) withCalendar(messages []ollama.Message) []ollama.Message {
+	var contents []string
+	for _, msg := range messages {
+		contents = append(contents, msg.Content)
+	}
+	if !usesCalendar(contents...) {
+		return messages
+	}
+	if cv.appConfig == nil || !cv.appConfig.CalendarEnabled {
+		return expandCalendar(messages, "")
+	}
+
+	sources := cv.appConfig.CalendarSources
+	if len(sources) == 0 {
+		sources = calendar.DefaultSources()
+	}
+	events, err := calendar.Today(sources, time.Now())
+	if err != nil {
+		logger.Error("Failed to read calendar", "error", err)
+		events = calendarUnavailable
+	}
+	return expandCalendar(messages, events)
+}
+
+// needsCalendarConsent reports whether sending text would use the calendar
+// variable before the user has allowed calendar access.
+func (cv *ChatView) needsCalendarConsent(text string) bool {
+	if cv.appConfig == nil || cv.appConfig.CalendarEnabled || cv.calendarDeclined {
+		return false
+	}
+	chatPrompt := ""
+	if cv.currentChat != nil {
+		chatPrompt = cv.currentChat.SystemPrompt
+	}
+	return usesCalendar(text, chatPrompt, cv.appConfig.GlobalSystemPrompt)
+}
+
+// confirmCalendarAccess asks whether today's events may be sent to the
+// model before sending text. Allowing it is remembered in the settings.
+func (cv *ChatView) confirmCalendarAccess(text string) {
+	dialog := adw.NewMessageDialog(cv.parentWindow(), i18n.T("Share Today's Calendar?"),
+		i18n.T("This prompt asks for your calendar. Guanaco will read today's events from your local calendars and send their times, titles and locations to the Ollama server with each message that uses it.\n\nYou can turn this off at any time in Settings."))
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("skip", i18n.T("Send Without Calendar"))
+	dialog.AddResponse("share", i18n.T("Share Calendar"))
+	dialog.SetResponseAppearance("share", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("share")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		switch response {
+		case "share":
+			cv.appConfig.CalendarEnabled = true
+			if err := cv.appConfig.Save(); err != nil {
+				logger.Error("Failed to save settings", "error", err)
+			}
+			logger.Info("Calendar access allowed")
+			cv.confirmRemoteFiles(text)
+		case "skip":
+			cv.calendarDeclined = true
+			cv.confirmRemoteFiles(text)
+		default:
+			// Give the text back so nothing typed is lost
+			cv.inputArea.SetText(text)
+		}
+	})
+
+	dialog.Present()
+}
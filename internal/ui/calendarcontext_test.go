@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+func TestUsesCalendar(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  bool
+	}{
+		{"no texts", nil, false},
+		{"plain text", []string{"What should I prepare?"}, false},
+		{"variable in a later text", []string{"Hi", "Today: {{calendar}}"}, true},
+		{"other variable", []string{"{{ calendar }}"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usesCalendar(tt.texts...); got != tt.want {
+				t.Errorf("usesCalendar(%q) = %v, want %v", tt.texts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandCalendar(t *testing.T) {
+	messages := []ollama.Message{
+		{Role: "system", Content: "Context: {{calendar}}"},
+		{Role: "user", Content: "What should I prepare for today?"},
+		{Role: "user", Content: "{{calendar}}\n{{calendar}}"},
+	}
+
+	got := expandCalendar(messages, "Standup at 9")
+	want := []string{"Context: Standup at 9", "What should I prepare for today?", "Standup at 9\nStandup at 9"}
+	for i, msg := range got {
+		if msg.Content != want[i] {
+			t.Errorf("message %d = %q, want %q", i, msg.Content, want[i])
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// ChatInfoDialog shows the token usage of a chat, summed over the
+// statistics of its responses.
+type ChatInfoDialog struct {
+	*adw.Window
+
+	// UI components
+	stack      *gtk.Stack
+	statusPage *adw.StatusPage
+	details    *gtk.Box
+
+	// State
+	db   *store.DB
+	chat *store.Chat
+}
+
+// NewChatInfoDialog creates a dialog showing the statistics of chat and
+// starts loading them.
+func NewChatInfoDialog(parent *gtk.Window, db *store.DB, chat *store.Chat) *ChatInfoDialog {
+	d := &ChatInfoDialog{
+		db:   db,
+		chat: chat,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Statistics"))
+	d.SetModal(true)
+	d.SetDefaultSize(420, 480)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+	go d.load()
+
+	return d
+}
+
+func (d *ChatInfoDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(adw.NewWindowTitle(i18n.T("Statistics"), d.chat.Title))
+
+	// Loading and error state
+	d.statusPage = adw.NewStatusPage()
+	spinner := gtk.NewSpinner()
+	spinner.SetSizeRequest(32, 32)
+	spinner.Start()
+	d.statusPage.SetChild(spinner)
+	d.statusPage.SetTitle(i18n.T("Loading..."))
+
+	d.details = gtk.NewBox(gtk.OrientationVertical, 18)
+	d.details.SetMarginTop(16)
+	d.details.SetMarginBottom(24)
+	d.details.SetMarginStart(24)
+	d.details.SetMarginEnd(24)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(d.details)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+
+	d.stack = gtk.NewStack()
+	d.stack.AddNamed(d.statusPage, "status")
+	d.stack.AddNamed(scrolled, "details")
+	d.stack.SetVisibleChildName("status")
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(d.stack)
+
+	d.SetContent(toolbarView)
+}
+
+// load reads the statistics in the background.
+func (d *ChatInfoDialog) load() {
+	stats, err := d.db.GetChatStats(d.chat.ID)
+	glib.IdleAdd(func() {
+		if err != nil {
+			logger.Error("Failed to load chat statistics", "chatID", d.chat.ID, "error", err)
+			d.statusPage.SetChild(nil)
+			d.statusPage.SetIconName("dialog-error-symbolic")
+			d.statusPage.SetTitle(i18n.T("Could not load statistics"))
+			d.statusPage.SetDescription(err.Error())
+			return
+		}
+		d.showStats(stats)
+		d.stack.SetVisibleChildName("details")
+	})
+}
+
+// showStats fills the dialog with stats.
+func (d *ChatInfoDialog) showStats(stats *store.ChatStats) {
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+
+	speed := ollama.ResponseStats{EvalTokens: stats.EvalTokens, EvalDuration: stats.EvalDuration}.TokensPerSecond()
+	for _, field := range []struct {
+		title, value string
+	}{
+		{i18n.T("Messages"), fmt.Sprint(stats.Messages)},
+		{i18n.T("Ratings"), fmt.Sprintf(i18n.T("%d good, %d bad, %d with notes"), stats.RatedUp, stats.RatedDown, stats.Annotated)},
+		{i18n.T("Characters"), fmt.Sprint(stats.Characters)},
+		{i18n.T("Prompt tokens"), fmt.Sprint(stats.PromptTokens)},
+		{i18n.T("Response tokens"), fmt.Sprint(stats.EvalTokens)},
+		{i18n.T("Total tokens"), fmt.Sprint(stats.PromptTokens + stats.EvalTokens)},
+		{i18n.T("Average speed"), formatSpeed(speed)},
+		{i18n.T("Generation time"), formatSeconds(stats.TotalTime)},
+	} {
+		row := adw.NewActionRow()
+		row.SetTitle(field.title)
+		row.SetSubtitle(field.value)
+		row.SetSubtitleSelectable(true)
+		row.AddCSSClass("property")
+		list.Append(row)
+	}
+	d.details.Append(list)
+
+	if stats.Measured < stats.Responses {
+		// Responses from before statistics were recorded, or from a
+		// server that didn't report them
+		note := gtk.NewLabel(i18n.T("Token counts only include responses with recorded statistics."))
+		note.SetWrap(true)
+		note.SetXAlign(0)
+		note.AddCSSClass("dim-label")
+		note.AddCSSClass("caption")
+		d.details.Append(note)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
 	"github.com/diamondburned/gotk4/pkg/gdk/v4"
 	"github.com/diamondburned/gotk4/pkg/gio/v2"
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
@@ -18,16 +20,24 @@ import (
 
 	"github.com/storo/guanaco/internal/assets"
 	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/hooks"
 	"github.com/storo/guanaco/internal/i18n"
 	"github.com/storo/guanaco/internal/logger"
 	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/plugins"
 	"github.com/storo/guanaco/internal/rag"
+	"github.com/storo/guanaco/internal/speech"
 	"github.com/storo/guanaco/internal/store"
+	"github.com/storo/guanaco/internal/tools"
 )
 
 // getGreeting returns a greeting based on the current time of day.
 func getGreeting() string {
-	hour := time.Now().Hour()
+	return greetingFor(time.Now().Hour())
+}
+
+// greetingFor returns the greeting of an hour of the day.
+func greetingFor(hour int) string {
 	switch {
 	case hour >= 6 && hour < 12:
 		return i18n.T("Good morning")
@@ -49,12 +59,19 @@ func getUsername() string {
 	return ""
 }
 
+// tokenFlushInterval is how often streamed content is shown. Tokens that
+// arrive in between are shown together, so fast models don't make the
+// bubble re-render its markup for every token.
+const tokenFlushInterval = 50 * time.Millisecond
+
 // tokenBuffer accumulates streaming tokens and flushes periodically to reduce UI updates.
 type tokenBuffer struct {
 	mu      sync.Mutex
 	content string
+	dirty   bool // Content changed since the last flush
 	ticker  *time.Ticker
 	done    chan struct{}
+	stopped chan struct{}
 	onFlush func(string)
 }
 
@@ -63,6 +80,7 @@ func newTokenBuffer(interval time.Duration, onFlush func(string)) *tokenBuffer {
 	tb := &tokenBuffer{
 		ticker:  time.NewTicker(interval),
 		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
 		onFlush: onFlush,
 	}
 	go tb.run()
@@ -70,6 +88,7 @@ func newTokenBuffer(interval time.Duration, onFlush func(string)) *tokenBuffer {
 }
 
 func (tb *tokenBuffer) run() {
+	defer close(tb.stopped)
 	for {
 		select {
 		case <-tb.ticker.C:
@@ -81,25 +100,32 @@ func (tb *tokenBuffer) run() {
 	}
 }
 
+// Write replaces the buffered content with everything streamed so far.
 func (tb *tokenBuffer) Write(content string) {
 	tb.mu.Lock()
 	tb.content = content
+	tb.dirty = true
 	tb.mu.Unlock()
 }
 
+// flush passes the content on if it changed since the last flush.
 func (tb *tokenBuffer) flush() {
 	tb.mu.Lock()
-	content := tb.content
+	content, dirty := tb.content, tb.dirty
+	tb.dirty = false
 	tb.mu.Unlock()
 
-	if content != "" && tb.onFlush != nil {
+	if dirty && content != "" && tb.onFlush != nil {
 		tb.onFlush(content)
 	}
 }
 
+// Stop flushes what is left and stops the buffer. It returns once the final
+// flush is done, so anything scheduled after it runs after the flush too.
 func (tb *tokenBuffer) Stop() {
 	tb.ticker.Stop()
 	close(tb.done)
+	<-tb.stopped
 }
 
 // ChatView displays the chat messages and handles interaction.
@@ -109,7 +135,10 @@ type ChatView struct {
 	// UI components
 	scrolled    *gtk.ScrolledWindow
 	messagesBox *gtk.Box
+	comparison  *modelComparison // Answers of two models not kept yet, nil if none
 	welcomeView *gtk.Box
+	greeting    *gtk.Label
+	pillsBox    *gtk.Box
 	loadingView *gtk.Box
 	inputArea   *InputArea
 
@@ -118,11 +147,77 @@ type ChatView struct {
 	currentBubble  *MessageBubble
 	isStreaming    bool
 	streamCancel   context.CancelFunc
-	userAtBottom   bool // Track if user is at bottom for auto-scroll
-	showingWelcome bool // Track if welcome view is showing
+	userAtBottom   bool        // Track if user is at bottom for auto-scroll
+	showingWelcome bool        // Track if welcome view is showing
+	lastPrompt     *sentPrompt // Prompt of the response being written, see StopAndEdit
+	editAfterStop  bool        // Take lastPrompt back once the response stops
+	dropAfterStop  bool        // Discard the response once it stops, see StopAndDiscard
+	historyMode    historyMode // How history is sent with the next request
+
+	// Windowed rendering of long chats (see messagewindow.go)
+	earlierCount   int         // Older messages not loaded yet
+	earliestID     int64       // Oldest loaded message, where the next page ends
+	earlierButton  *gtk.Button // Loads the page of messages before the loaded ones
+	loadingEarlier bool        // A page is being loaded; ignore the top edge
+
+	// Latency and rendering measurements for the debug overlay
+	stats *streamStats
+
+	// Bubbles of messages kept in memory until the database recovers
+	pendingBubbles map[*store.PendingMessage]*MessageBubble
+
+	// Sending calendar events was declined for this session
+	calendarDeclined bool
+
+	// Tools offered to models that can call them
+	tools       *tools.Registry
+	toolSupport *modelSupport
+
+	// Shows the working folder of the chat (see workdir.go)
+	workDirBanner *adw.Banner
+
+	// Prompts larger than the context window (see contextbudget.go)
+	contextLengths      *contextLengths
+	contextWarning      *gtk.Revealer
+	contextWarningLabel *gtk.Label
+	contextPassagesBtn  *gtk.Button
+	historyBudget       int // Tokens the next prompt may use when its history is trimmed
+	passageBudget       int // Tokens of attachment passages sent with the next prompt (0 = whole attachments)
+
+	// Work done before a response (see statusbar.go)
+	statusBar      *gtk.Revealer
+	statusLabel    *gtk.Label
+	statusProgress *gtk.ProgressBar
+
+	// Chats whose model isn't installed (see missingmodel.go)
+	missingModelBar     *gtk.Revealer
+	missingModelLabel   *gtk.Label
+	missingModelButtons *gtk.Box
+	missingModel        string // Model of the current chat known not to be installed
+	pullingModel        string // Missing model being downloaded again
+
+	// Plugins whose readers and tools are used
+	plugins []*plugins.Plugin
+
+	// Images are read with OCR for models that can't see them
+	visionSupport *modelSupport
+	ocr           *rag.OCR
+
+	// Completed responses are read aloud, see readAloud
+	speaker       *speech.Speaker
+	speakerWarned bool // Told once that no synthesizer is installed
+
+	// Whether the bubbles were rendered as plain text, see applyMessageRendering
+	plainTextRendered bool
+
+	// Whether the current chat has a saved draft, see saveDraft
+	hasDraft bool
+
+	// Unregisters the accessibility listener, see Destroy
+	unwatchA11y func()
 
 	// Dependencies
-	ollamaClient  *ollama.Client
+	ollamaClient  ollama.API
 	streamHandler *ollama.StreamHandler
 	db            *store.DB
 	ragProcessor  *rag.Processor
@@ -132,12 +227,20 @@ type ChatView struct {
 
 	// Callbacks
 	onError        func(error)
+	onNotice       func(string)
+	onResponseDone func(*store.Chat)
+	onDraftChanged func(chatID int64)
+	onToast        func(*adw.Toast)
 	onTitleChanged func(string)
 	onChatCreated  func(*store.Chat)
+	onBranched     func(*store.Chat)
+	onStorageError func(error)
+	onModelPulled  func(string)
+	onStreaming    func(bool)
 }
 
 // NewChatView creates a new chat view.
-func NewChatView(client *ollama.Client, db *store.DB) *ChatView {
+func NewChatView(client ollama.API, db *store.DB) *ChatView {
 	cv := &ChatView{
 		ollamaClient:   client,
 		streamHandler:  ollama.NewStreamHandler(client),
@@ -145,6 +248,16 @@ func NewChatView(client *ollama.Client, db *store.DB) *ChatView {
 		ragProcessor:   rag.NewProcessor(),
 		userAtBottom:   true, // Start at bottom
 		showingWelcome: true, // Start showing welcome view
+		stats:          &streamStats{},
+		pendingBubbles: make(map[*store.PendingMessage]*MessageBubble),
+		tools:          tools.NewRegistry(),
+		toolSupport:    newToolSupport(),
+		visionSupport:  newVisionSupport(),
+		contextLengths: newContextLengths(),
+		ocr:            rag.NewOCR(),
+		speaker:        speech.NewSpeaker(),
+
+		plainTextRendered: plainTextMessages,
 	}
 
 	cv.Box = gtk.NewBox(gtk.OrientationVertical, 0)
@@ -154,11 +267,24 @@ func NewChatView(client *ollama.Client, db *store.DB) *ChatView {
 	cv.setupUI()
 	cv.setupDropTarget()
 	cv.setupScrollTracking()
+	cv.setupEarlierLoading()
 
 	return cv
 }
 
+// Destroy unregisters what the chat view follows outside its window, when
+// the window closes.
+func (cv *ChatView) Destroy() {
+	if cv.unwatchA11y != nil {
+		cv.unwatchA11y()
+		cv.unwatchA11y = nil
+	}
+}
+
 func (cv *ChatView) setupUI() {
+	cv.workDirBanner = cv.newWorkDirBanner()
+	cv.Append(cv.workDirBanner)
+
 	// Messages area
 	cv.messagesBox = gtk.NewBox(gtk.OrientationVertical, 0)
 	cv.messagesBox.SetVExpand(true)
@@ -190,48 +316,29 @@ func (cv *ChatView) setupUI() {
 	logoImage.AddCSSClass("welcome-logo")
 	cv.welcomeView.Append(logoImage)
 
-	// Dynamic greeting based on time of day
-	greeting := getGreeting()
-	username := getUsername()
-	greetingText := greeting
-	if username != "" {
-		greetingText = fmt.Sprintf("%s, %s", greeting, username)
-	}
-	greetingLabel := gtk.NewLabel(greetingText)
-	greetingLabel.AddCSSClass("title-1")
-	greetingLabel.SetHAlign(gtk.AlignCenter)
-	greetingLabel.SetMarginTop(8)
-	cv.welcomeView.Append(greetingLabel)
+	// Dynamic greeting based on time of day, updated each time it is shown
+	cv.greeting = gtk.NewLabel("")
+	cv.greeting.AddCSSClass("title-1")
+	cv.greeting.SetHAlign(gtk.AlignCenter)
+	cv.greeting.SetMarginTop(8)
+	cv.greeting.SetWrap(true)
+	cv.greeting.SetJustify(gtk.JustifyCenter)
+	cv.welcomeView.Append(cv.greeting)
 
-	// Horizontal pills for suggestions
-	pillsBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
-	pillsBox.SetHAlign(gtk.AlignCenter)
-	pillsBox.SetMarginTop(24)
+	helpLabel := gtk.NewLabel(i18n.T("How can I help you today?"))
+	helpLabel.AddCSSClass("dim-label")
+	helpLabel.SetHAlign(gtk.AlignCenter)
+	cv.welcomeView.Append(helpLabel)
 
-	// Helper function to create simple pills (icon + title)
-	createPill := func(icon, title string) *gtk.Button {
-		btn := gtk.NewButton()
-		btn.AddCSSClass("flat")
-		btn.AddCSSClass("suggestion-pill")
+	cv.updateGreeting()
+	cv.welcomeView.ConnectMap(cv.updateGreeting)
 
-		box := gtk.NewBox(gtk.OrientationHorizontal, 6)
-
-		iconLabel := gtk.NewLabel(icon)
-		box.Append(iconLabel)
-
-		titleLabel := gtk.NewLabel(title)
-		box.Append(titleLabel)
-
-		btn.SetChild(box)
-		return btn
-	}
-
-	pillsBox.Append(createPill("💡", i18n.T("Explain")))
-	pillsBox.Append(createPill("💻", i18n.T("Write")))
-	pillsBox.Append(createPill("📝", i18n.T("Summarize")))
-	pillsBox.Append(createPill("🌐", i18n.T("Translate")))
-
-	cv.welcomeView.Append(pillsBox)
+	// Horizontal pills for suggestions
+	cv.pillsBox = gtk.NewBox(gtk.OrientationHorizontal, 8)
+	cv.pillsBox.SetHAlign(gtk.AlignCenter)
+	cv.pillsBox.SetMarginTop(24)
+	cv.setSuggestions(config.DefaultSuggestions)
+	cv.welcomeView.Append(cv.pillsBox)
 
 	// Loading view with spinner
 	cv.loadingView = gtk.NewBox(gtk.OrientationVertical, 12)
@@ -253,8 +360,23 @@ func (cv *ChatView) setupUI() {
 	cv.scrolled.SetChild(cv.welcomeView)
 	cv.scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
 	cv.scrolled.SetVExpand(true)
+
+	// Disable kinetic (smooth) scrolling when the desktop asks for reduced motion
+	cv.scrolled.SetKineticScrolling(!motionReduced())
+	cv.unwatchA11y = onAccessibilityChanged(func() {
+		cv.scrolled.SetKineticScrolling(!motionReduced())
+	})
 	cv.Append(cv.scrolled)
 
+	cv.statusBar = cv.newStatusBar()
+	cv.Append(cv.statusBar)
+
+	cv.contextWarning = cv.newContextWarning()
+	cv.Append(cv.contextWarning)
+
+	cv.missingModelBar = cv.newMissingModelBar()
+	cv.Append(cv.missingModelBar)
+
 	// Separator
 	separator := gtk.NewSeparator(gtk.OrientationHorizontal)
 	cv.Append(separator)
@@ -264,14 +386,24 @@ func (cv *ChatView) setupUI() {
 	cv.inputArea.OnSend(cv.onSendMessage)
 	cv.inputArea.OnAttach(cv.onAttachFile)
 	cv.inputArea.OnStop(cv.StopStreaming)
+	cv.inputArea.OnStopAndEdit(cv.StopAndEdit)
+	cv.inputArea.OnStopAndDiscard(cv.StopAndDiscard)
+	cv.inputArea.OnTemplateChosen(cv.usePromptTemplate)
+	cv.inputArea.OnSpeechError(cv.handleError)
 	cv.Append(cv.inputArea)
 }
 
 func (cv *ChatView) setupDropTarget() {
-	// Create drop target for files
-	dropTarget := gtk.NewDropTarget(gio.GTypeFile, gdk.ActionCopy)
+	// Create drop target for files and text selections. Files come first so
+	// a file dragged from a file manager is not dropped as its path.
+	dropTarget := gtk.NewDropTarget(glib.TypeInvalid, gdk.ActionCopy)
+	dropTarget.SetGTypes([]glib.Type{gio.GTypeFile, glib.TypeString})
 
 	dropTarget.ConnectDrop(func(value *glib.Value, x, y float64) bool {
+		if text, ok := value.GoValue().(string); ok {
+			return cv.insertDroppedText(text)
+		}
+
 		file := value.Object()
 		if file == nil {
 			return false
@@ -294,19 +426,45 @@ func (cv *ChatView) setupDropTarget() {
 	cv.AddController(dropTarget)
 }
 
-func (cv *ChatView) onAttachFile() {
-	// Get parent window
-	var parentWindow *gtk.Window
+// insertDroppedText quotes text dragged from another app in the input, or
+// attaches it as a text file when it is long.
+func (cv *ChatView) insertDroppedText(text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return false
+	}
+
+	if shouldAttachDroppedText(text) {
+		var existing []string
+		for _, pill := range cv.inputArea.GetAttachments() {
+			existing = append(existing, pill.Filename())
+		}
+		name := uniqueAttachmentName(droppedTextAttachmentName, existing)
+		cv.inputArea.AddAttachment(NewAttachmentPill(name, strings.TrimSpace(text)))
+		logger.Info("Dropped text attached", "filename", name, "length", len(text))
+	} else {
+		cv.inputArea.SetText(appendQuote(cv.inputArea.GetText(), text))
+		logger.Info("Dropped text quoted", "length", len(text))
+	}
+
+	cv.inputArea.Focus()
+	return true
+}
+
+// parentWindow returns the window containing the chat view, if any.
+func (cv *ChatView) parentWindow() *gtk.Window {
 	if root := cv.Root(); root != nil {
 		if nw, ok := root.CastType(gtk.GTypeWindow).(*gtk.Window); ok {
-			parentWindow = nw
+			return nw
 		}
 	}
+	return nil
+}
 
+func (cv *ChatView) onAttachFile() {
 	// Create file chooser dialog
 	dialog := gtk.NewFileChooserNative(
 		i18n.T("Select Document"),
-		parentWindow,
+		cv.parentWindow(),
 		gtk.FileChooserActionOpen,
 		i18n.T("Open"),
 		i18n.T("Cancel"),
@@ -318,11 +476,25 @@ func (cv *ChatView) onAttachFile() {
 	allFilter.AddPattern("*.txt")
 	allFilter.AddPattern("*.md")
 	allFilter.AddPattern("*.pdf")
+	allFilter.AddPattern("*.docx")
+	allFilter.AddPattern("*.odt")
+	allFilter.AddPattern("*.csv")
+	allFilter.AddPattern("*.tsv")
+	allFilter.AddPattern("*.xlsx")
+	allFilter.AddPattern("*.ipynb")
+	allFilter.AddPattern("*.eml")
+	allFilter.AddPattern("*.mbox")
+	allFilter.AddPattern("*.srt")
+	allFilter.AddPattern("*.vtt")
 	allFilter.AddPattern("*.jpg")
 	allFilter.AddPattern("*.jpeg")
 	allFilter.AddPattern("*.png")
 	allFilter.AddPattern("*.webp")
 	allFilter.AddPattern("*.gif")
+	allFilter.AddPattern("*.zip")
+	for _, ext := range pluginExtensions(cv.plugins) {
+		allFilter.AddPattern("*" + ext)
+	}
 	dialog.AddFilter(allFilter)
 
 	imageFilter := gtk.NewFileFilter()
@@ -345,6 +517,41 @@ func (cv *ChatView) onAttachFile() {
 	pdfFilter.AddPattern("*.pdf")
 	dialog.AddFilter(pdfFilter)
 
+	officeFilter := gtk.NewFileFilter()
+	officeFilter.SetName(i18n.T("Office Documents"))
+	officeFilter.AddPattern("*.docx")
+	officeFilter.AddPattern("*.odt")
+	dialog.AddFilter(officeFilter)
+
+	spreadsheetFilter := gtk.NewFileFilter()
+	spreadsheetFilter.SetName(i18n.T("Spreadsheets"))
+	spreadsheetFilter.AddPattern("*.csv")
+	spreadsheetFilter.AddPattern("*.tsv")
+	spreadsheetFilter.AddPattern("*.xlsx")
+	dialog.AddFilter(spreadsheetFilter)
+
+	notebookFilter := gtk.NewFileFilter()
+	notebookFilter.SetName(i18n.T("Jupyter Notebooks"))
+	notebookFilter.AddPattern("*.ipynb")
+	dialog.AddFilter(notebookFilter)
+
+	emailFilter := gtk.NewFileFilter()
+	emailFilter.SetName(i18n.T("Emails"))
+	emailFilter.AddPattern("*.eml")
+	emailFilter.AddPattern("*.mbox")
+	dialog.AddFilter(emailFilter)
+
+	subtitleFilter := gtk.NewFileFilter()
+	subtitleFilter.SetName(i18n.T("Subtitles"))
+	subtitleFilter.AddPattern("*.srt")
+	subtitleFilter.AddPattern("*.vtt")
+	dialog.AddFilter(subtitleFilter)
+
+	archiveFilter := gtk.NewFileFilter()
+	archiveFilter.SetName(i18n.T("ZIP Archives"))
+	archiveFilter.AddPattern("*.zip")
+	dialog.AddFilter(archiveFilter)
+
 	dialog.ConnectResponse(func(response int) {
 		if response == int(gtk.ResponseAccept) {
 			file := dialog.File()
@@ -379,12 +586,22 @@ func (cv *ChatView) processAndAttachFile(path string) {
 		return
 	}
 
+	if rag.IsArchive(filename) {
+		cv.chooseArchiveFiles(path)
+		return
+	}
+
 	// Check if file type is supported
 	if !cv.ragProcessor.CanProcess(filename) {
 		cv.handleError(fmt.Errorf(i18n.T("unsupported file type: %s"), filename))
 		return
 	}
 
+	if rag.IsTable(filename) {
+		cv.previewAndAttachTable(path)
+		return
+	}
+
 	// Show loading indicator
 	cv.inputArea.ShowLoadingIndicator()
 
@@ -404,6 +621,98 @@ func (cv *ChatView) processAndAttachFile(path string) {
 			// Create and add attachment pill
 			pill := NewAttachmentPill(result.Filename, result.Content)
 			cv.inputArea.AddAttachment(pill)
+			if pill.IsImage() {
+				cv.checkImageSupport()
+			}
+		})
+	}()
+}
+
+// previewAndAttachTable reads a spreadsheet and shows its tables, attaching
+// them as Markdown once the user confirms.
+func (cv *ChatView) previewAndAttachTable(path string) {
+	filename := filepath.Base(path)
+	maxRows := config.DefaultMaxTableRows
+	if cv.appConfig != nil {
+		maxRows = cv.appConfig.MaxTableRows
+	}
+
+	cv.inputArea.ShowLoadingIndicator()
+
+	go func() {
+		tables, err := rag.ReadTables(path)
+
+		glib.IdleAdd(func() {
+			cv.inputArea.HideLoadingIndicator()
+
+			if err == nil && rag.TablesMarkdown(tables, maxRows) == "" {
+				err = errors.New(i18n.T("the file has no data"))
+			}
+			if err != nil {
+				cv.handleError(fmt.Errorf(i18n.T("failed to process %s: %v"), filename, err))
+				return
+			}
+
+			dialog := NewTablePreviewDialog(cv.parentWindow(), filename, tables, maxRows)
+			dialog.OnAttach(func() {
+				logger.Info("Spreadsheet attached", "filename", filename, "sheets", len(tables))
+				cv.inputArea.AddAttachment(NewAttachmentPill(filename, rag.TablesMarkdown(tables, maxRows)))
+			})
+			dialog.Present()
+		})
+	}()
+}
+
+// chooseArchiveFiles lists the files of an archive for the user to choose
+// the ones to attach, which are then read together as one attachment.
+func (cv *ChatView) chooseArchiveFiles(path string) {
+	filename := filepath.Base(path)
+
+	cv.inputArea.ShowLoadingIndicator()
+
+	go func() {
+		entries, err := cv.ragProcessor.ListArchive(path)
+
+		glib.IdleAdd(func() {
+			cv.inputArea.HideLoadingIndicator()
+
+			if err == nil && !slices.ContainsFunc(entries, func(e rag.ArchiveEntry) bool { return e.Supported }) {
+				err = errors.New(i18n.T("the archive has no supported files"))
+			}
+			if err != nil {
+				cv.handleError(fmt.Errorf(i18n.T("failed to process %s: %v"), filename, err))
+				return
+			}
+
+			dialog := NewArchiveDialog(cv.parentWindow(), filename, entries)
+			dialog.OnAttach(func(names []string) {
+				cv.attachArchiveFiles(path, names)
+			})
+			dialog.Present()
+		})
+	}()
+}
+
+// attachArchiveFiles extracts and reads the named files of an archive and
+// attaches them as one document.
+func (cv *ChatView) attachArchiveFiles(path string, names []string) {
+	filename := filepath.Base(path)
+
+	cv.inputArea.ShowLoadingIndicator()
+
+	go func() {
+		result, err := cv.ragProcessor.ProcessArchive(path, names)
+
+		glib.IdleAdd(func() {
+			cv.inputArea.HideLoadingIndicator()
+
+			if err != nil {
+				cv.handleError(fmt.Errorf(i18n.T("failed to process %s: %v"), filename, err))
+				return
+			}
+
+			logger.Info("Archive files attached", "filename", filename, "files", len(names), "tokens", result.TokenEstimate)
+			cv.inputArea.AddAttachment(NewAttachmentPill(result.Filename, result.Content))
 		})
 	}()
 }
@@ -418,11 +727,128 @@ func (cv *ChatView) onSendMessage(text string) {
 		return
 	}
 
+	// Compared answers not kept are dropped
+	cv.removeComparison()
+
 	// Validate model is selected
 	if cv.currentModel == "" {
 		cv.handleError(errors.New(i18n.T("please enter a model name (e.g., llama3.2)")))
 		return
 	}
+	if cv.waitForMissingModel(text) {
+		return
+	}
+
+	text = cv.moveOverflowToAttachment(text)
+
+	// Ask before calendar events are first sent to the model
+	if cv.needsCalendarConsent(text) {
+		cv.confirmCalendarAccess(text)
+		return
+	}
+
+	cv.confirmRemoteFiles(text)
+}
+
+// confirmAndSend sends text, asking for confirmation first when the prompt
+// is over the configured size.
+func (cv *ChatView) confirmAndSend(text string) {
+	if budget := cv.currentContextBudget(); budget > 0 {
+		if size := cv.estimatePromptSize(text); size.Total() > budget {
+			cv.showContextWarning(text, size, budget)
+			return
+		}
+	}
+
+	if cv.appConfig != nil && cv.appConfig.PromptWarnTokens > 0 {
+		size := cv.estimatePromptSize(text)
+		if size.Total() > cv.appConfig.PromptWarnTokens {
+			cv.confirmLargePrompt(text, size)
+			return
+		}
+	}
+
+	cv.sendMessage(text, historyFull)
+}
+
+// moveOverflowToAttachment attaches text as a file when it is longer than
+// the configured maximum, and returns the excerpt to send in its place.
+func (cv *ChatView) moveOverflowToAttachment(text string) string {
+	if cv.appConfig == nil {
+		return text
+	}
+	excerpt, split := splitOverflow(text, cv.appConfig.MaxMessageLength)
+	if !split {
+		return text
+	}
+
+	var existing []string
+	for _, pill := range cv.inputArea.GetAttachments() {
+		existing = append(existing, pill.Filename())
+	}
+	name := uniqueAttachmentName(overflowAttachmentName, existing)
+	cv.inputArea.AddAttachment(NewAttachmentPill(name, text))
+
+	logger.Info("Long message moved to attachment", "filename", name, "length", len(text))
+	cv.notify(i18n.T("The message was too long and has been attached as a text file"))
+	return excerpt
+}
+
+// estimatePromptSize estimates the token breakdown of sending text with the
+// current attachments and history.
+func (cv *ChatView) estimatePromptSize(text string) promptSize {
+	size := promptSize{
+		History: estimateMessagesTokens(cv.buildMessageHistory()),
+		Message: rag.EstimateTokens(text),
+	}
+	for _, pill := range cv.inputArea.GetAttachments() {
+		if !pill.IsImage() {
+			size.Attachments += rag.EstimateTokens(pill.Content())
+		}
+	}
+	return size
+}
+
+// confirmLargePrompt shows the prompt size breakdown and lets the user send,
+// trim or summarize the history, or go back to editing.
+func (cv *ChatView) confirmLargePrompt(text string, size promptSize) {
+	body := fmt.Sprintf(i18n.T("This message will send about %d tokens:\n\n• Conversation history: %d\n• Attachments: %d\n• Message: %d\n\nPrompts larger than the model's context window are silently truncated."),
+		size.Total(), size.History, size.Attachments, size.Message)
+
+	dialog := adw.NewMessageDialog(cv.parentWindow(), i18n.T("Send Large Prompt?"), body)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("summarize", i18n.T("Summarize History"))
+	dialog.AddResponse("trim", i18n.T("Trim History"))
+	dialog.AddResponse("send", i18n.T("Send Anyway"))
+	dialog.SetResponseAppearance("send", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("send")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		switch response {
+		case "send":
+			cv.sendMessage(text, historyFull)
+		case "trim":
+			cv.historyBudget = cv.appConfig.PromptWarnTokens
+			cv.sendMessage(text, historyTrimmed)
+		case "summarize":
+			cv.sendMessage(text, historySummarized)
+		default:
+			// Give the text back so nothing typed is lost
+			cv.inputArea.SetText(text)
+			cv.inputArea.Focus()
+		}
+	})
+
+	dialog.Present()
+}
+
+// sendMessage adds the user message to the chat and starts the response
+// using the given history mode.
+func (cv *ChatView) sendMessage(text string, mode historyMode) {
+	cv.stats.Begin(time.Now())
+	cv.historyMode = mode
+	cv.hideContextWarning()
 
 	// Build full prompt with attachments
 	data := cv.buildPromptWithAttachments(text)
@@ -445,27 +871,27 @@ func (cv *ChatView) onSendMessage(text string) {
 			displayText = fmt.Sprintf("[📎 %s]", strings.Join(attachmentNames, ", "))
 		}
 	}
-	cv.addMessage(store.RoleUser, displayText)
+	userBubble := cv.addMessage(store.RoleUser, displayText)
 
 	// Get attachments before clearing (need for DB save)
-	attachments := cv.inputArea.GetAttachments()
+	var attachments []store.Attachment
+	for _, pill := range cv.inputArea.GetAttachments() {
+		attachments = append(attachments, store.Attachment{Filename: pill.Filename(), Content: pill.Content()})
+	}
+
+	cv.lastPrompt = &sentPrompt{text: text, attachments: attachments, bubble: userBubble}
 
 	// Clear attachments after using them
 	cv.inputArea.ClearAttachments()
+	cv.clearDraft()
 
 	// Save to database with attachments
 	if cv.db != nil && cv.currentChat != nil {
-		msg, err := cv.db.AddMessage(cv.currentChat.ID, store.RoleUser, displayText)
-		if err == nil && len(attachments) > 0 {
-			for _, pill := range attachments {
-				err := cv.db.AddAttachment(msg.ID, pill.Filename(), pill.Content())
-				if err != nil {
-					logger.Error("Failed to save attachment", "filename", pill.Filename(), "error", err)
-				} else {
-					logger.Info("Attachment saved", "messageID", msg.ID, "filename", pill.Filename(), "contentLen", len(pill.Content()))
-				}
-			}
-		}
+		cv.saveMessage(userBubble, &store.Message{
+			ChatID:  cv.currentChat.ID,
+			Role:    store.RoleUser,
+			Content: displayText,
+		}, attachments)
 	}
 
 	// Check if model exists, pull if needed, then stream
@@ -486,17 +912,27 @@ func (cv *ChatView) buildPromptWithAttachments(userText string) attachmentData {
 
 	var builder strings.Builder
 	var images []string
+	var sources []rag.Source
+	passageBudget := cv.passageBudget
+	cv.passageBudget = 0
 
 	// Separate images from documents
 	for _, pill := range attachments {
-		if pill.IsImage() {
+		switch {
+		case pill.IsImage():
 			images = append(images, pill.Content())
-		} else {
+		case passageBudget > 0:
+			sources = append(sources, rag.Source{Name: pill.Filename(), Content: pill.Content()})
+		default:
 			builder.WriteString(fmt.Sprintf("[Document: %s]\n", pill.Filename()))
 			builder.WriteString(pill.Content())
 			builder.WriteString("\n\n")
 		}
 	}
+	if len(sources) > 0 {
+		// Only the parts that match the message, see sendOverContext
+		builder.WriteString(selectAttachmentPassages(userText, sources, passageBudget))
+	}
 
 	// Add user's question/message
 	if userText != "" {
@@ -518,65 +954,43 @@ func (cv *ChatView) ensureModelAndStream(data attachmentData) {
 	// Check if model exists locally
 	if cv.ollamaClient.HasModel(ctx, cv.currentModel) {
 		logger.Debug("Model available locally", "model", cv.currentModel)
-		cv.startStreaming(data)
+		cv.summarizeAndStream(data)
 		return
 	}
 
 	logger.Info("Model not found, pulling", "model", cv.currentModel)
 
 	// Model not found, need to pull it
-	cv.isStreaming = true
+	cv.setStreaming(true)
 	cv.inputArea.SetInputSensitive(false)
 
-	// Create a status bubble to show download progress
-	cv.currentBubble = cv.addMessage(store.RoleSystem, fmt.Sprintf(i18n.T("Downloading model %s..."), cv.currentModel))
+	// Show the download progress in the status bar
+	model := cv.currentModel
+	cv.showStatus(fmt.Sprintf(i18n.T("Downloading model %s..."), model), -1)
 
 	go func() {
-		err := cv.ollamaClient.PullModel(ctx, cv.currentModel, func(status string, completed, total int64) {
-			var progressText string
-			if total > 0 {
-				percent := float64(completed) / float64(total) * 100
-				progressText = fmt.Sprintf("Downloading %s: %s (%.1f%%)", cv.currentModel, status, percent)
-			} else {
-				progressText = fmt.Sprintf("Downloading %s: %s", cv.currentModel, status)
-			}
+		err := cv.ollamaClient.PullModel(ctx, model, func(status string, completed, total int64) {
+			progressText := pullProgressText(model, status, completed, total)
+			fraction := pullProgressFraction(completed, total)
 
 			glib.IdleAdd(func() {
-				if cv.currentBubble != nil {
-					cv.currentBubble.SetContent(progressText)
-					cv.scrollToBottom()
-				}
+				cv.showStatus(progressText, fraction)
 			})
 		})
 
 		glib.IdleAdd(func() {
+			cv.hideStatus()
+			cv.setStreaming(false)
 			if err != nil {
 				logger.Error("Failed to download model", "error", err)
-				if cv.currentBubble != nil {
-					cv.currentBubble.SetContent(i18n.T("Model download failed. Please check your connection."))
-				}
-				cv.isStreaming = false
+				cv.notify(i18n.T("Model download failed. Please check your connection."))
 				cv.inputArea.SetInputSensitive(true)
 				cv.inputArea.Focus()
 				return
 			}
 
-			// Remove the download status bubble
-			if cv.currentBubble != nil {
-				cv.messagesBox.Remove(cv.currentBubble)
-				// Remove from messages slice
-				for i, bubble := range cv.messages {
-					if bubble == cv.currentBubble {
-						cv.messages = append(cv.messages[:i], cv.messages[i+1:]...)
-						break
-					}
-				}
-				cv.currentBubble = nil
-			}
-			cv.isStreaming = false
-
 			// Now start the actual chat
-			cv.startStreaming(data)
+			cv.summarizeAndStream(data)
 		})
 	}()
 }
@@ -613,28 +1027,49 @@ func (cv *ChatView) addMessage(role store.Role, content string) *MessageBubble {
 	}
 
 	bubble := NewMessageBubble(role, content)
+	bubble.SetJSONOutput(cv.jsonOutput())
 	cv.messages = append(cv.messages, bubble)
 	cv.messagesBox.Append(bubble)
 	cv.scrollToBottom()
 	return bubble
 }
 
-const streamingTimeout = 5 * time.Minute
+// jsonOutput reports whether the current chat asks for JSON responses.
+func (cv *ChatView) jsonOutput() bool {
+	return cv.currentChat != nil && cv.currentChat.OutputFormat != ""
+}
 
-func (cv *ChatView) startStreaming(data attachmentData) {
-	// Create context with both timeout and cancellation
-	ctx, cancel := context.WithTimeout(context.Background(), streamingTimeout)
-	cv.streamCancel = cancel
+// UpdateOutputFormat shows the responses as JSON or as text after the
+// output format of the chat changed.
+func (cv *ChatView) UpdateOutputFormat() {
+	for _, bubble := range cv.messages {
+		bubble.SetJSONOutput(cv.jsonOutput())
+	}
+}
 
-	cv.isStreaming = true
-	cv.inputArea.SetStreamingMode(true)
+const streamingTimeout = 5 * time.Minute
 
+func (cv *ChatView) startStreaming(data attachmentData) {
 	// Create placeholder for response with thinking animation
 	cv.currentBubble = cv.addMessage(store.RoleAssistant, "")
 	cv.currentBubble.SetThinking(true)
 
-	// Build message history
+	// Build message history. The new user message is already stored, so
+	// drop it here and send the full prompt with images instead.
 	messages := cv.buildMessageHistory()
+	if n := len(messages); n > 0 && messages[n-1].Role == "user" {
+		messages = messages[:n-1]
+	}
+	mode := cv.historyMode
+	cv.historyMode = historyFull
+	historyBudget := cv.historyBudget
+	cv.historyBudget = 0
+	if mode == historyTrimmed {
+		budget := historyBudget - rag.EstimateTokens(data.textContent)
+		var dropped int
+		messages, dropped = trimHistory(messages, budget)
+		logger.Info("Trimmed history", "dropped", dropped)
+	}
 
 	// Log what we're sending
 	logger.Info("Sending to model", "historyCount", len(messages), "newContentLen", len(data.textContent))
@@ -652,13 +1087,58 @@ func (cv *ChatView) startStreaming(data attachmentData) {
 	}
 	messages = append(messages, userMsg)
 
+	cv.streamResponse(messages, mode)
+}
+
+// streamResponse streams the model's reply to messages into cv.currentBubble
+// and saves it once complete. When the bubble already holds a saved response
+// (see continueResponse) the reply is appended to it.
+func (cv *ChatView) streamResponse(messages []ollama.Message, mode historyMode) {
+	// Create context with both timeout and cancellation
+	ctx, cancel := context.WithTimeout(context.Background(), streamingTimeout)
+	cv.streamCancel = cancel
+
+	cv.setStreaming(true)
+	cv.inputArea.SetStreamingMode(true)
+	cv.inputArea.SetCanStopAndEdit(cv.canTakeBack(cv.lastPrompt))
+
 	// Start streaming in goroutine
+	model := cv.currentModel
+	bubble := cv.currentBubble
+	continuing := bubble.MessageID() != 0
+	partial := ""
+	if continuing {
+		partial = bubble.GetContent()
+	}
+	completion := chatCompletionSettings(cv.currentChat)
+	// Self-review talks to the model through the chat API, which base
+	// models used in completion mode may not support
+	selfReview := cv.appConfig != nil && cv.appConfig.SelfReview && !continuing && completion.Mode == store.CompletionChat
+	reviewModel := model
+	if cv.appConfig != nil {
+		reviewModel = cv.appConfig.EffectiveUtilityModel(model)
+	}
 	go func() {
 		var response strings.Builder
+		response.WriteString(partial)
+
+		messages = cv.readImagesIfBlind(ctx, model, messages)
 
-		// Buffer tokens and flush every 50ms to reduce UI updates
-		buffer := newTokenBuffer(50*time.Millisecond, func(content string) {
+		if mode == historySummarized {
+			history, last := messages[:len(messages)-1], messages[len(messages)-1]
+			summarized, err := cv.summarizeHistory(ctx, model, history, summaryKeepRecent)
+			if err != nil {
+				logger.Error("Failed to summarize history, sending full history", "error", err)
+			} else {
+				messages = append(summarized, last)
+			}
+		}
+
+		// Buffer tokens and flush them together to reduce UI updates
+		buffer := newTokenBuffer(tokenFlushInterval, func(content string) {
+			cv.stats.FlushQueued()
 			glib.IdleAdd(func() {
+				cv.stats.Flushed(time.Now())
 				if cv.currentBubble != nil {
 					wasThinking := cv.currentBubble.IsThinking()
 					cv.currentBubble.SetContent(content)
@@ -671,44 +1151,105 @@ func (cv *ChatView) startStreaming(data attachmentData) {
 			})
 		})
 
-		err := cv.streamHandler.Chat(ctx, &ollama.ChatRequest{
-			Model:    cv.currentModel,
-			Messages: messages,
-		}, func(token string) {
+		cv.stats.RequestSent(time.Now())
+		stats, err := cv.chatWithContextRetry(ctx, model, messages, completion, func(token string) {
+			cv.stats.Token(time.Now())
 			response.WriteString(token)
 			buffer.Write(response.String())
+		}, func() bool {
+			return response.Len() == len(partial)
 		})
 
 		buffer.Stop() // Final flush and cleanup
+		cv.stats.Finish()
+		logger.Debug("Response stats", "stats", cv.stats.Snapshot())
+
+		// Optionally critique and revise the answer before saving it
+		finalContent := response.String()
+		var critique string
+		if err == nil && selfReview && finalContent != "" {
+			finalContent, critique = cv.reviseResponse(ctx, reviewModel, messages, finalContent)
+		}
 
 		// Finalize on main thread
 		glib.IdleAdd(func() {
 			cv.streamCancel = nil
-			cv.isStreaming = false
+			cv.setStreaming(false)
 			cv.inputArea.SetStreamingMode(false)
 			cv.inputArea.Focus()
 
+			if cv.takeBackAfterStop(err) {
+				return
+			}
+			if cv.discardAfterStop(err, bubble, partial) {
+				return
+			}
+
 			// Handle errors
+			truncated := false
+			if err != nil && continuing {
+				// The saved response is still unfinished
+				bubble.SetTruncated(true)
+			}
 			if err != nil {
 				switch err {
 				case context.Canceled:
 					// User cancelled, no error to show
+					truncated = finalContent != ""
 				case context.DeadlineExceeded:
 					cv.handleError(errors.New(i18n.T("Response timed out. The model took too long to respond.")))
 					return
 				default:
+					if errors.Is(err, ollama.ErrContextOverflow) {
+						err = errors.New(i18n.T("The conversation is too long for this model. Start a new chat or send a shorter message."))
+					}
 					cv.handleError(err)
 					return
 				}
 			}
 
+			if critique != "" {
+				bubble.SetCritique(critique)
+			}
+
+			// A stopped response can be continued later
+			bubble.SetTruncated(truncated)
+
+			// The continuation of a response adds to its usage
+			if continuing {
+				stats = addStats(bubble.Stats(), stats)
+			}
+			if stats != nil {
+				bubble.SetStats(stats)
+			}
+
+			if !truncated && finalContent != "" {
+				cv.runResponseHooks(messages, finalContent)
+				cv.readAloud(finalContent)
+				if cv.onResponseDone != nil && cv.currentChat != nil {
+					cv.onResponseDone(cv.currentChat)
+				}
+			}
+
+			if continuing {
+				cv.saveContinuedResponse(bubble, finalContent, truncated, stats)
+				return
+			}
+
 			// Save assistant response to database (even if cancelled, save partial)
-			finalContent := response.String()
 			if cv.db != nil && cv.currentChat != nil && finalContent != "" {
-				cv.db.AddMessage(cv.currentChat.ID, store.RoleAssistant, finalContent)
+				cv.saveMessage(bubble, &store.Message{
+					ChatID:    cv.currentChat.ID,
+					Role:      store.RoleAssistant,
+					Content:   finalContent,
+					Critique:  critique,
+					Truncated: truncated,
+					Replaces:  bubble.Replaces(),
+					Stats:     messageStats(stats),
+				}, nil)
 
 				// Generate title for new chats
-				if cv.currentChat.Title == "New Chat" {
+				if cv.currentChat.Title == "New Chat" && !cv.currentChat.TitleLocked {
 					go cv.generateTitle()
 				}
 			}
@@ -716,6 +1257,59 @@ func (cv *ChatView) startStreaming(data attachmentData) {
 	}()
 }
 
+// runResponseHooks runs the hooks for a completed response to messages.
+func (cv *ChatView) runResponseHooks(messages []ollama.Message, response string) {
+	payload := hooks.Response{
+		Event:    hooks.ResponseCompleted,
+		Model:    cv.currentModel,
+		Prompt:   lastUserContent(messages),
+		Response: response,
+		Time:     time.Now(),
+	}
+	if cv.currentChat != nil {
+		payload.ChatID = cv.currentChat.ID
+		payload.ChatTitle = cv.currentChat.Title
+	}
+	runHooks(cv.appConfig, hooks.ResponseCompleted, payload)
+}
+
+// maxContextRetries is how many times a request is retried with a smaller
+// history window after a context overflow.
+const maxContextRetries = 3
+
+// chatWithContextRetry streams a request with the given completion settings.
+// When the model rejects the prompt for exceeding its context length, the
+// oldest history is dropped (halving the history each time) and the request
+// is retried. canRetry reports whether nothing has been streamed yet.
+// The statistics of the reply are returned along with any error.
+func (cv *ChatView) chatWithContextRetry(ctx context.Context, model string, messages []ollama.Message, completion completionSettings, onToken ollama.TokenCallback, canRetry func() bool) (*ollama.ResponseStats, error) {
+	budget := estimateMessagesTokens(messages)
+	totalDropped := 0
+
+	for attempt := 0; ; attempt++ {
+		stats, err := cv.streamCompletion(ctx, model, messages, completion, onToken)
+		if !errors.Is(err, ollama.ErrContextOverflow) || attempt >= maxContextRetries || !canRetry() {
+			return stats, err
+		}
+
+		// Keep the new message, shrink everything before it
+		history, last := messages[:len(messages)-1], messages[len(messages)-1]
+		budget /= 2
+		trimmed, dropped := trimHistory(history, budget-estimateMessagesTokens([]ollama.Message{last}))
+		if dropped == 0 {
+			return nil, err
+		}
+		messages = append(trimmed, last)
+		totalDropped += dropped
+
+		logger.Warn("Context overflow, retrying with less history", "attempt", attempt+1, "dropped", totalDropped)
+		notice := contextRetryNotice(totalDropped)
+		glib.IdleAdd(func() {
+			cv.notify(notice)
+		})
+	}
+}
+
 // StopStreaming cancels the current streaming response.
 func (cv *ChatView) StopStreaming() {
 	if cv.streamCancel != nil {
@@ -728,13 +1322,15 @@ func (cv *ChatView) buildMessageHistory() []ollama.Message {
 
 	// Build effective system prompt (chat-specific > global, + language instruction)
 	chatPrompt := ""
+	chatLanguage := ""
 	if cv.currentChat != nil {
 		chatPrompt = cv.currentChat.SystemPrompt
+		chatLanguage = cv.currentChat.Language
 	}
 
 	var systemPrompt string
 	if cv.appConfig != nil {
-		systemPrompt = cv.appConfig.GetEffectiveSystemPrompt(chatPrompt)
+		systemPrompt = cv.appConfig.GetEffectiveSystemPrompt(chatPrompt, chatLanguage)
 	} else if chatPrompt != "" {
 		systemPrompt = chatPrompt
 	}
@@ -763,13 +1359,29 @@ func (cv *ChatView) buildMessageHistory() []ollama.Message {
 			// Load all attachments in a single query (avoids N+1)
 			attachmentMap, _ := cv.db.GetAttachmentsForMessages(userMsgIDs)
 
+			// Send the stored summary in place of the turns it covers
+			summary, _ := cv.db.GetHistorySummary(cv.currentChat.ID)
+			if end := summaryEnd(summary, dbMessages); end > 0 {
+				messages = append(messages, summaryMessage(summary.Content))
+				dbMessages = dbMessages[end:]
+			}
+
 			for _, msg := range dbMessages {
+				if msg.ModelSwitch {
+					continue // Shown in the transcript only
+				}
 				content := msg.Content
+				var images []string
 
 				// For user messages, check if there are attachments
 				if msg.Role == store.RoleUser {
 					if attachments, ok := attachmentMap[msg.ID]; ok && len(attachments) > 0 {
 						content = cv.rebuildContentWithAttachments(msg.Content, attachments)
+						for _, att := range attachments {
+							if rag.IsImage(att.Filename) {
+								images = append(images, att.Content)
+							}
+						}
 						logger.Info("Rebuilt content with attachments", "messageID", msg.ID, "attachmentCount", len(attachments))
 					}
 				}
@@ -777,9 +1389,10 @@ func (cv *ChatView) buildMessageHistory() []ollama.Message {
 				messages = append(messages, ollama.Message{
 					Role:    string(msg.Role),
 					Content: content,
+					Images:  images,
 				})
 			}
-			return messages
+			return cv.withCalendar(cv.withDocuments(messages))
 		}
 	}
 
@@ -788,6 +1401,9 @@ func (cv *ChatView) buildMessageHistory() []ollama.Message {
 		if bubble == cv.currentBubble {
 			continue // Skip the current streaming bubble
 		}
+		if bubble.IsModelSwitch() {
+			continue
+		}
 
 		role := "user"
 		if bubble.GetRole() == store.RoleAssistant {
@@ -802,15 +1418,43 @@ func (cv *ChatView) buildMessageHistory() []ollama.Message {
 		})
 	}
 
-	return messages
+	return cv.withCalendar(messages)
+}
+
+// withDocuments adds the chat's library documents to messages, narrowed
+// down to the parts relevant to the last user message when they don't fit.
+func (cv *ChatView) withDocuments(messages []ollama.Message) []ollama.Message {
+	docs, err := cv.db.ListDocuments(cv.currentChat.ID)
+	if err != nil {
+		logger.Error("Failed to load documents", "chatID", cv.currentChat.ID, "error", err)
+		return messages
+	}
+	if len(docs) == 0 {
+		return messages
+	}
+
+	sources := make([]rag.Source, len(docs))
+	for i, doc := range docs {
+		sources[i] = rag.Source{Name: doc.Filename, Content: doc.Content}
+	}
+	passages := rag.SelectPassages(lastUserContent(messages), sources, documentChunker, documentContextBudget)
+	if len(passages) == 0 {
+		return messages
+	}
+
+	logger.Info("Added documents to context", "chatID", cv.currentChat.ID, "documents", len(docs), "passages", len(passages))
+	return insertDocumentContext(messages, formatDocumentContext(passages))
 }
 
 // rebuildContentWithAttachments reconstructs the full prompt from display text and attachments.
 func (cv *ChatView) rebuildContentWithAttachments(displayText string, attachments []store.Attachment) string {
 	var builder strings.Builder
 
-	// Add document contents
+	// Add document contents (images are sent separately)
 	for _, att := range attachments {
+		if rag.IsImage(att.Filename) {
+			continue
+		}
 		builder.WriteString(fmt.Sprintf("[Document: %s]\n", att.Filename))
 		builder.WriteString(att.Content)
 		builder.WriteString("\n\n")
@@ -842,6 +1486,25 @@ func extractUserText(displayText string) string {
 	return displayText
 }
 
+// replaceUserText replaces the user's text in display text, keeping the
+// attachment indicator prefix if there is one.
+func replaceUserText(displayText, text string) string {
+	if strings.HasPrefix(displayText, "[📎") {
+		idx := strings.Index(displayText, "]\n\n")
+		if idx == -1 {
+			idx = strings.Index(displayText, "]")
+		}
+		if idx != -1 {
+			indicator := displayText[:idx+1]
+			if text == "" {
+				return indicator
+			}
+			return indicator + "\n\n" + text
+		}
+	}
+	return text
+}
+
 func (cv *ChatView) scrollToBottom() {
 	// Don't auto-scroll if user scrolled up during streaming
 	if cv.isStreaming && !cv.userAtBottom {
@@ -860,6 +1523,14 @@ func (cv *ChatView) setupScrollTracking() {
 	})
 }
 
+// notify shows an informational message to the user.
+func (cv *ChatView) notify(message string) {
+	logger.Info("ChatView notice", "message", message)
+	if cv.onNotice != nil {
+		cv.onNotice(message)
+	}
+}
+
 func (cv *ChatView) handleError(err error) {
 	logger.Error("ChatView error", "error", err)
 	if cv.onError != nil {
@@ -869,12 +1540,92 @@ func (cv *ChatView) handleError(err error) {
 
 // SetModel sets the current model for chat.
 func (cv *ChatView) SetModel(model string) {
+	changed := model != cv.currentModel
 	cv.currentModel = model
+	if changed {
+		cv.checkImageSupport()
+		cv.loadContextLength()
+		if model != cv.missingModel {
+			cv.hideMissingModel()
+		}
+	}
 }
 
 // SetAppConfig sets the application configuration.
 func (cv *ChatView) SetAppConfig(cfg *config.AppConfig) {
 	cv.appConfig = cfg
+	runSnippets = cfg.RunSnippets
+	codeWrap = cfg.CodeWrap
+	codeWrapChanged = func(wrap bool) {
+		cfg.CodeWrap = wrap
+		codeWrap = wrap
+		if err := cfg.Save(); err != nil {
+			logger.Error("Failed to save settings", "error", err)
+		}
+	}
+	cv.ragProcessor.SetNotebookOutputs(cfg.NotebookOutputs)
+	cv.inputArea.SetSpeechCommand(cfg.SpeechCommand)
+	cv.setSuggestions(cfg.WelcomeSuggestions())
+	cv.updateTools()
+}
+
+// updateGreeting greets the user for the current time of day.
+func (cv *ChatView) updateGreeting() {
+	greeting := getGreeting()
+	if username := getUsername(); username != "" {
+		greeting = fmt.Sprintf("%s, %s", greeting, username)
+	}
+	cv.greeting.SetLabel(greeting)
+}
+
+// promptLanguage returns the language prompts are written in: the
+// configured language of responses, or the interface language when
+// responses follow the user.
+func (cv *ChatView) promptLanguage() string {
+	if cv.appConfig != nil && cv.appConfig.ResponseLanguage != "" && cv.appConfig.ResponseLanguage != "auto" {
+		return cv.appConfig.ResponseLanguage
+	}
+	return i18n.CurrentLanguage()
+}
+
+// setSuggestions shows a pill on the welcome screen for each suggestion.
+func (cv *ChatView) setSuggestions(suggestions []config.Suggestion) {
+	for {
+		child := cv.pillsBox.FirstChild()
+		if child == nil {
+			break
+		}
+		cv.pillsBox.Remove(child)
+	}
+	for _, suggestion := range suggestions {
+		cv.pillsBox.Append(cv.newSuggestionPill(suggestion))
+	}
+	cv.pillsBox.SetVisible(len(suggestions) > 0)
+}
+
+// newSuggestionPill returns a pill inserting the prompt of a suggestion in
+// the input. The label is translated into the interface language and the
+// prompt into the language of responses, so the defaults follow them while
+// configured ones are shown as written.
+func (cv *ChatView) newSuggestionPill(suggestion config.Suggestion) *gtk.Button {
+	btn := gtk.NewButton()
+	btn.AddCSSClass("flat")
+	btn.AddCSSClass("suggestion-pill")
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 6)
+	if suggestion.Icon != "" {
+		box.Append(gtk.NewLabel(suggestion.Icon))
+	}
+	box.Append(gtk.NewLabel(i18n.T(suggestion.Label)))
+	btn.SetChild(box)
+
+	prompt := i18n.TLang(cv.promptLanguage(), suggestion.Prompt)
+	btn.SetTooltipText(strings.TrimSpace(prompt))
+	btn.ConnectClicked(func() {
+		cv.inputArea.SetText(prompt)
+		cv.inputArea.Focus()
+	})
+	return btn
 }
 
 // SetChat loads an existing chat.
@@ -884,10 +1635,15 @@ func (cv *ChatView) SetChat(chat *store.Chat) {
 		return
 	}
 
+	cv.saveDraft()
 	cv.currentChat = chat
 	cv.currentModel = chat.Model
 	cv.inputArea.SetModel(chat.Model)
+	cv.loadContextLength()
 	cv.clearMessages()
+	cv.checkChatModel()
+	cv.updateWorkDirBanner()
+	cv.restoreDraft()
 
 	if cv.db == nil {
 		return
@@ -902,7 +1658,7 @@ func (cv *ChatView) SetChat(chat *store.Chat) {
 
 	// Load messages asynchronously
 	go func() {
-		messages, err := cv.db.GetMessages(chatID)
+		messages, earlier, err := cv.loadMessagePage(chatID, 0)
 
 		// Update UI on main thread
 		glib.IdleAdd(func() {
@@ -922,9 +1678,7 @@ func (cv *ChatView) SetChat(chat *store.Chat) {
 			cv.scrolled.SetChild(cv.messagesBox)
 			cv.showingWelcome = false
 
-			for _, msg := range messages {
-				cv.addMessage(msg.Role, msg.Content)
-			}
+			cv.showMessages(messages, earlier)
 
 			// If no messages, show welcome view
 			if len(messages) == 0 {
@@ -937,8 +1691,11 @@ func (cv *ChatView) SetChat(chat *store.Chat) {
 
 // NewChat starts a new chat.
 func (cv *ChatView) NewChat() {
+	cv.saveDraft()
 	cv.currentChat = nil
 	cv.clearMessages()
+	cv.updateWorkDirBanner()
+	cv.restoreDraft()
 }
 
 // EnsureChat creates a new chat if none exists.
@@ -955,6 +1712,13 @@ func (cv *ChatView) clearMessages() {
 	}
 	cv.messages = nil
 	cv.currentBubble = nil
+	cv.removeComparison()
+	cv.hideContextWarning()
+	cv.hideMissingModel()
+
+	cv.earliestID = 0
+	cv.earlierCount = 0
+	cv.updateEarlierButton()
 
 	// Show welcome view again
 	cv.scrolled.SetChild(cv.welcomeView)
@@ -966,11 +1730,50 @@ func (cv *ChatView) OnError(callback func(error)) {
 	cv.onError = callback
 }
 
+// OnNotice sets the callback for informational messages.
+func (cv *ChatView) OnNotice(callback func(string)) {
+	cv.onNotice = callback
+}
+
+// OnResponseDone sets the callback for when a response to chat completed.
+func (cv *ChatView) OnResponseDone(callback func(*store.Chat)) {
+	cv.onResponseDone = callback
+}
+
+// ChatRenamed updates the current chat when the user renamed it, so no
+// title is generated for it.
+func (cv *ChatView) ChatRenamed(chat *store.Chat) {
+	if cv.currentChat == nil || cv.currentChat.ID != chat.ID {
+		return
+	}
+	cv.currentChat.Title = chat.Title
+	cv.currentChat.TitleLocked = true
+}
+
+// OnToast sets the callback showing toasts with an action.
+func (cv *ChatView) OnToast(callback func(*adw.Toast)) {
+	cv.onToast = callback
+}
+
 // IsStreaming returns whether a response is currently streaming.
 func (cv *ChatView) IsStreaming() bool {
 	return cv.isStreaming
 }
 
+// setStreaming records whether a response is streaming, and notifies it.
+func (cv *ChatView) setStreaming(streaming bool) {
+	cv.isStreaming = streaming
+	if cv.onStreaming != nil {
+		cv.onStreaming(streaming)
+	}
+}
+
+// OnStreamingChanged sets the callback for when a response starts or stops
+// streaming.
+func (cv *ChatView) OnStreamingChanged(callback func(streaming bool)) {
+	cv.onStreaming = callback
+}
+
 // GetCurrentChat returns the current chat.
 func (cv *ChatView) GetCurrentChat() *store.Chat {
 	return cv.currentChat
@@ -991,9 +1794,15 @@ func (cv *ChatView) OnChatCreated(callback func(*store.Chat)) {
 	cv.onChatCreated = callback
 }
 
+// OnBranched sets the callback for when a new chat has been branched from a
+// message of the current one.
+func (cv *ChatView) OnBranched(callback func(*store.Chat)) {
+	cv.onBranched = callback
+}
+
 // generateTitle asks the model to generate a short title for the conversation.
 func (cv *ChatView) generateTitle() {
-	if cv.db == nil || cv.currentChat == nil || len(cv.messages) < 2 {
+	if cv.db == nil || cv.currentChat == nil || cv.currentChat.TitleLocked || len(cv.messages) < 2 {
 		return
 	}
 
@@ -1023,14 +1832,19 @@ func (cv *ChatView) generateTitle() {
 	// Build prompt with language preference
 	prompt := fmt.Sprintf("Generate a very short title (3-5 words max) for a conversation that starts with: %q\nRespond with ONLY the title, nothing else.", userMsg)
 	if cv.appConfig != nil {
-		if langInstruction := cv.appConfig.LanguageInstruction(); langInstruction != "" {
+		if langInstruction := config.LanguageInstructionFor(cv.appConfig.EffectiveLanguage(cv.currentChat.Language)); langInstruction != "" {
 			prompt = prompt + "\n" + langInstruction
 		}
 	}
 
+	model := cv.currentModel
+	if cv.appConfig != nil {
+		model = cv.appConfig.EffectiveUtilityModel(model)
+	}
+
 	var title strings.Builder
 	err := cv.streamHandler.Chat(ctx, &ollama.ChatRequest{
-		Model:    cv.currentModel,
+		Model:    model,
 		Messages: []ollama.Message{{Role: "user", Content: prompt}},
 	}, func(token string) {
 		title.WriteString(token)
@@ -1054,6 +1868,9 @@ func (cv *ChatView) generateTitle() {
 		logger.Error("Failed to update chat title", "error", err)
 		return
 	}
+	if cv.currentChat.TitleLocked {
+		return // Renamed by the user while generating
+	}
 
 	cv.currentChat.Title = newTitle
 	logger.Info("Chat title updated", "chatID", cv.currentChat.ID, "title", newTitle)
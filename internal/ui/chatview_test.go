@@ -35,6 +35,27 @@ func TestGetGreeting(t *testing.T) {
 	}
 }
 
+func TestGreetingFor(t *testing.T) {
+	tests := []struct {
+		hour int
+		want string
+	}{
+		{0, "Good evening"},
+		{5, "Good evening"},
+		{6, "Good morning"},
+		{11, "Good morning"},
+		{12, "Good afternoon"},
+		{18, "Good afternoon"},
+		{19, "Good evening"},
+		{23, "Good evening"},
+	}
+	for _, tt := range tests {
+		if got := greetingFor(tt.hour); got != tt.want {
+			t.Errorf("greetingFor(%d) = %q, want %q", tt.hour, got, tt.want)
+		}
+	}
+}
+
 func TestGetUsername(t *testing.T) {
 	// Just verify it doesn't panic and returns some value
 	username := getUsername()
@@ -95,6 +116,58 @@ func TestExtractUserText(t *testing.T) {
 	}
 }
 
+func TestReplaceUserText(t *testing.T) {
+	tests := []struct {
+		name        string
+		displayText string
+		text        string
+		want        string
+	}{
+		{
+			name:        "plain text",
+			displayText: "Hello world",
+			text:        "Hello there",
+			want:        "Hello there",
+		},
+		{
+			name:        "keeps attachment indicator",
+			displayText: "[📎 document.pdf]\n\nSummarize this",
+			text:        "Translate this",
+			want:        "[📎 document.pdf]\n\nTranslate this",
+		},
+		{
+			name:        "adds text to attachment only message",
+			displayText: "[📎 file.txt]",
+			text:        "What is this?",
+			want:        "[📎 file.txt]\n\nWhat is this?",
+		},
+		{
+			name:        "removes text from attachment message",
+			displayText: "[📎 image.png]\n\nDescribe it",
+			text:        "",
+			want:        "[📎 image.png]",
+		},
+		{
+			name:        "text starting with bracket but not attachment",
+			displayText: "[some text] more text",
+			text:        "[other text]",
+			want:        "[other text]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := replaceUserText(tt.displayText, tt.text)
+			if got != tt.want {
+				t.Errorf("replaceUserText(%q, %q) = %q, want %q", tt.displayText, tt.text, got, tt.want)
+			}
+			if extractUserText(got) != tt.text {
+				t.Errorf("extractUserText(%q) = %q, want %q", got, extractUserText(got), tt.text)
+			}
+		})
+	}
+}
+
 func TestTokenBuffer(t *testing.T) {
 	t.Run("accumulates and flushes content", func(t *testing.T) {
 		var flushed []string
@@ -175,4 +248,42 @@ func TestTokenBuffer(t *testing.T) {
 			t.Errorf("flush count = %d, want 0 for empty buffer", flushCount)
 		}
 	})
+
+	t.Run("unchanged content not flushed again", func(t *testing.T) {
+		flushCount := 0
+		var mu sync.Mutex
+
+		buffer := newTokenBuffer(5*time.Millisecond, func(content string) {
+			mu.Lock()
+			flushCount++
+			mu.Unlock()
+		})
+
+		buffer.Write("Hello")
+		time.Sleep(50 * time.Millisecond)
+		buffer.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if flushCount != 1 {
+			t.Errorf("flush count = %d, want 1 for content written once", flushCount)
+		}
+	})
+
+	t.Run("stop waits for the final flush", func(t *testing.T) {
+		var flushed string
+
+		buffer := newTokenBuffer(1*time.Hour, func(content string) {
+			time.Sleep(10 * time.Millisecond)
+			flushed = content
+		})
+
+		buffer.Write("Done")
+		buffer.Stop()
+
+		if flushed != "Done" {
+			t.Errorf("content after Stop() = %q, want %q", flushed, "Done")
+		}
+	})
 }
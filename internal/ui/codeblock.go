@@ -14,21 +14,39 @@ import (
 // Shared syntax highlighter instance
 var sharedHighlighter = NewSyntaxHighlighter()
 
+// codeWrap is whether new code blocks wrap long lines instead of scrolling
+// them, set from the settings by ChatView.SetAppConfig.
+var codeWrap = true
+
+// codeWrapChanged is called with the wrap mode chosen in a code block, to
+// keep it as the preference. nil until set by ChatView.SetAppConfig.
+var codeWrapChanged func(wrap bool)
+
 // CodeBlock is a widget that displays code with syntax highlighting and a copy button.
 type CodeBlock struct {
 	*gtk.Box
 
 	// UI components
-	header     *gtk.Box
-	langLabel  *gtk.Label
-	copyBtn    *gtk.Button
-	textView   *gtk.TextView
-	textBuffer *gtk.TextBuffer
-	scrolled   *gtk.ScrolledWindow
+	header      *gtk.Box
+	langLabel   *gtk.Label
+	wrapBtn     *gtk.ToggleButton
+	copyBtn     *gtk.Button
+	popOutBtn   *gtk.Button
+	renderBtn   *gtk.ToggleButton // Shows a diagram, nil for other code
+	picture     *gtk.Picture      // Rendered diagram, once shown
+	runBtn      *gtk.Button       // Runs the code, nil for code that can't be run
+	outputLabel *gtk.Label        // Output of the last run, once run
+	textView    *gtk.TextView
+	textBuffer  *gtk.TextBuffer
+	gutter      *lineNumbers
+	scrolled    *gtk.ScrolledWindow
 
 	// Data
 	code     string
 	language string
+	window   *CodeWindow // Popped out copy, while open
+
+	diagramCode string // Code the rendered diagram was made from
 }
 
 // NewCodeBlock creates a new code block widget.
@@ -43,6 +61,7 @@ func NewCodeBlock(code, language string) *CodeBlock {
 
 	cb.setupUI()
 	cb.applyHighlighting()
+	followSyntaxStyle(gtk.BaseWidget(cb), cb.applyHighlighting)
 
 	return cb
 }
@@ -70,6 +89,24 @@ func (cb *CodeBlock) setupUI() {
 		cb.header.Append(spacer)
 	}
 
+	cb.addDiagramToggle()
+	cb.addSnippetButtons()
+
+	// Wrap toggle
+	cb.wrapBtn = gtk.NewToggleButton()
+	cb.wrapBtn.SetIconName("format-justify-fill-symbolic")
+	cb.wrapBtn.SetTooltipText(i18n.T("Wrap long lines"))
+	cb.wrapBtn.AddCSSClass("flat")
+	cb.wrapBtn.AddCSSClass("circular")
+	cb.wrapBtn.SetActive(codeWrap)
+	cb.wrapBtn.ConnectToggled(func() {
+		cb.setWrap(cb.wrapBtn.Active())
+		if codeWrapChanged != nil {
+			codeWrapChanged(cb.wrapBtn.Active())
+		}
+	})
+	cb.header.Append(cb.wrapBtn)
+
 	// Copy button
 	cb.copyBtn = gtk.NewButton()
 	cb.copyBtn.SetIconName("edit-copy-symbolic")
@@ -79,6 +116,15 @@ func (cb *CodeBlock) setupUI() {
 	cb.copyBtn.ConnectClicked(cb.copyToClipboard)
 	cb.header.Append(cb.copyBtn)
 
+	// Pop out button
+	cb.popOutBtn = gtk.NewButton()
+	cb.popOutBtn.SetIconName("window-new-symbolic")
+	cb.popOutBtn.SetTooltipText(i18n.T("Open in window"))
+	cb.popOutBtn.AddCSSClass("flat")
+	cb.popOutBtn.AddCSSClass("circular")
+	cb.popOutBtn.ConnectClicked(cb.popOut)
+	cb.header.Append(cb.popOutBtn)
+
 	cb.Append(cb.header)
 
 	// Create text buffer and view for syntax highlighting
@@ -88,11 +134,11 @@ func (cb *CodeBlock) setupUI() {
 	cb.textView.SetCursorVisible(false)
 	cb.textView.SetMonospace(true)
 	cb.textView.AddCSSClass("code-content")
-	cb.textView.SetWrapMode(gtk.WrapWordChar)
-	cb.textView.SetLeftMargin(12)
 	cb.textView.SetRightMargin(12)
 	cb.textView.SetTopMargin(4)
 	cb.textView.SetBottomMargin(12)
+	cb.gutter = newLineNumbers(cb.textView)
+	cb.setWrap(codeWrap)
 
 	// Wrap in scrolled window for horizontal scrolling on long lines
 	cb.scrolled = gtk.NewScrolledWindow()
@@ -104,14 +150,52 @@ func (cb *CodeBlock) setupUI() {
 	cb.Append(cb.scrolled)
 }
 
+// applyHighlighting shows the code highlighted, keeping the horizontal
+// scroll position of long lines as the code is streamed in.
 func (cb *CodeBlock) applyHighlighting() {
-	tokens := sharedHighlighter.Highlight(cb.code, cb.language)
+	hadjustment := cb.scrolled.HAdjustment()
+	scrolledTo := hadjustment.Value()
+
+	highlightCode(cb.textBuffer, cb.code, cb.language)
+
+	// A single line goes without a number
+	lines := countLines(cb.code)
+	cb.gutter.SetVisible(lines > 1)
+	cb.gutter.setLineCount(lines)
+	if lines > 1 {
+		cb.textView.SetLeftMargin(4)
+	} else {
+		cb.textView.SetLeftMargin(12)
+	}
+
+	if scrolledTo > 0 {
+		// The new text is laid out before the scroll range is known
+		glib.IdleAdd(func() {
+			hadjustment.SetValue(scrolledTo)
+		})
+	}
+}
+
+// setWrap wraps long lines, or scrolls them sideways.
+func (cb *CodeBlock) setWrap(wrap bool) {
+	if wrap {
+		cb.textView.SetWrapMode(gtk.WrapWordChar)
+	} else {
+		cb.textView.SetWrapMode(gtk.WrapNone)
+	}
+	cb.gutter.QueueDraw()
+}
+
+// highlightCode replaces the text of buffer with code, colored for
+// language.
+func highlightCode(buffer *gtk.TextBuffer, code, language string) {
+	tokens := sharedHighlighter.Highlight(code, language)
 
 	// Clear buffer
-	cb.textBuffer.SetText("")
+	buffer.SetText("")
 
 	// Get iterator at start
-	iter := cb.textBuffer.StartIter()
+	iter := buffer.StartIter()
 
 	for _, tok := range tokens {
 		if tok.Text == "" {
@@ -119,30 +203,32 @@ func (cb *CodeBlock) applyHighlighting() {
 		}
 
 		// Create or get tag for this style
-		tag := cb.getOrCreateTag(tok.Color, tok.Bold, tok.Italic)
+		tag := syntaxTag(buffer, tok.Color, tok.Bold, tok.Italic)
 
 		if tag != nil {
 			// Insert with tag
 			startOffset := iter.Offset()
-			cb.textBuffer.Insert(iter, tok.Text)
-			startIter := cb.textBuffer.IterAtOffset(startOffset)
-			endIter := cb.textBuffer.IterAtOffset(iter.Offset())
-			cb.textBuffer.ApplyTag(tag, startIter, endIter)
+			buffer.Insert(iter, tok.Text)
+			startIter := buffer.IterAtOffset(startOffset)
+			endIter := buffer.IterAtOffset(iter.Offset())
+			buffer.ApplyTag(tag, startIter, endIter)
 		} else {
 			// Insert without tag
-			cb.textBuffer.Insert(iter, tok.Text)
+			buffer.Insert(iter, tok.Text)
 		}
 	}
 }
 
-func (cb *CodeBlock) getOrCreateTag(color string, bold, italic bool) *gtk.TextTag {
+// syntaxTag returns the tag of buffer for a token style, creating it if
+// needed, or nil for unstyled text.
+func syntaxTag(buffer *gtk.TextBuffer, color string, bold, italic bool) *gtk.TextTag {
 	if color == "" && !bold && !italic {
 		return nil
 	}
 
 	tagName := fmt.Sprintf("syntax_%s_%v_%v", color, bold, italic)
 
-	tagTable := cb.textBuffer.TagTable()
+	tagTable := buffer.TagTable()
 	tag := tagTable.Lookup(tagName)
 
 	if tag == nil {
@@ -181,10 +267,36 @@ func (cb *CodeBlock) copyToClipboard() {
 	})
 }
 
+// popOut shows the code in its own window, or raises the window if it is
+// already open.
+func (cb *CodeBlock) popOut() {
+	if cb.window != nil {
+		cb.window.Present()
+		return
+	}
+
+	var parent *gtk.Window
+	if root := cb.Root(); root != nil {
+		parent, _ = root.CastType(gtk.GTypeWindow).(*gtk.Window)
+	}
+	cb.window = NewCodeWindow(parent, cb.code, cb.language)
+	cb.window.ConnectCloseRequest(func() bool {
+		cb.window = nil
+		return false
+	})
+	cb.window.Present()
+}
+
 // SetCode updates the code content with new highlighting.
 func (cb *CodeBlock) SetCode(code string) {
 	cb.code = code
 	cb.applyHighlighting()
+	if cb.renderBtn != nil && cb.renderBtn.Active() {
+		cb.showDiagram()
+	}
+	if cb.window != nil {
+		cb.window.SetCode(code)
+	}
 }
 
 // GetCode returns the code content.
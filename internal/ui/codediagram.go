@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/diagram"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// Code blocks of Mermaid or Graphviz diagrams have a toggle in their
+// header that shows the diagram as an image, rendered by mmdc or dot. The
+// toggle is only there when the command is installed, and when rendering
+// fails the source stays shown with the error in the toggle's tooltip.
+
+// addDiagramToggle adds the toggle showing the diagram to the header, for
+// code in a diagram language that can be rendered.
+func (cb *CodeBlock) addDiagramToggle() {
+	if !diagram.Available(cb.language) {
+		return
+	}
+	cb.renderBtn = gtk.NewToggleButton()
+	cb.renderBtn.SetIconName("image-x-generic-symbolic")
+	cb.renderBtn.SetTooltipText(i18n.T("Show diagram"))
+	cb.renderBtn.AddCSSClass("flat")
+	cb.renderBtn.AddCSSClass("circular")
+	cb.renderBtn.ConnectToggled(func() {
+		if cb.renderBtn.Active() {
+			cb.showDiagram()
+		} else {
+			cb.showSource()
+		}
+	})
+	cb.header.Append(cb.renderBtn)
+}
+
+// showDiagram shows the rendered diagram in place of the source,
+// rendering it first if needed.
+func (cb *CodeBlock) showDiagram() {
+	if cb.picture != nil && cb.diagramCode == cb.code {
+		cb.scrolled.SetVisible(false)
+		cb.picture.SetVisible(true)
+		return
+	}
+	if !cb.renderBtn.Sensitive() {
+		return // Already rendering
+	}
+
+	code := cb.code
+	language := cb.language
+	cb.renderBtn.SetSensitive(false)
+	cb.renderBtn.SetTooltipText(i18n.T("Rendering diagram…"))
+	go func() {
+		image, err := diagram.Render(context.Background(), language, code)
+
+		glib.IdleAdd(func() {
+			cb.renderBtn.SetSensitive(true)
+			var texture *gdk.Texture
+			if err == nil {
+				texture, err = gdk.NewTextureFromBytes(glib.NewBytesWithGo(image))
+			}
+			if err != nil {
+				logger.Error("Failed to render diagram", "language", language, "error", err)
+				cb.renderBtn.SetActive(false)
+				cb.renderBtn.SetTooltipText(fmt.Sprintf(i18n.T("Couldn't render the diagram: %v"), err))
+				return
+			}
+			cb.renderBtn.SetTooltipText(i18n.T("Show source"))
+
+			if cb.picture != nil {
+				cb.Remove(cb.picture)
+			}
+			cb.picture = gtk.NewPictureForPaintable(texture)
+			cb.picture.SetContentFit(gtk.ContentFitScaleDown)
+			cb.picture.SetCanShrink(true)
+			cb.picture.SetMarginStart(12)
+			cb.picture.SetMarginEnd(12)
+			cb.picture.SetMarginBottom(12)
+			cb.diagramCode = code
+			cb.Append(cb.picture)
+
+			switch {
+			case !cb.renderBtn.Active():
+				cb.picture.SetVisible(false)
+			case cb.code != code:
+				cb.picture.SetVisible(false)
+				cb.showDiagram() // Changed while rendering
+			default:
+				cb.scrolled.SetVisible(false)
+			}
+		})
+	}()
+}
+
+// showSource shows the source of the diagram again.
+func (cb *CodeBlock) showSource() {
+	if cb.picture != nil {
+		cb.picture.SetVisible(false)
+	}
+	cb.scrolled.SetVisible(true)
+	if cb.renderBtn.Sensitive() {
+		cb.renderBtn.SetTooltipText(i18n.T("Show diagram"))
+	}
+}
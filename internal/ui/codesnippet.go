@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/snippet"
+)
+
+// Code blocks can be saved to a file named after their language. When
+// running snippets is turned on in the settings, shell and Python code
+// blocks also have a button running them in a sandbox, with the output
+// shown below the code.
+
+// runSnippets is whether shell and Python code blocks can be run, set from
+// the settings by ChatView.SetAppConfig.
+var runSnippets bool
+
+// codeFileName returns the suggested name of a file holding code in
+// language: "snippet" with the extension of the language, or the file name
+// of languages such as Dockerfile.
+func codeFileName(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if lexer := lexers.Get(language); lexer != nil && language != "" {
+		for _, pattern := range lexer.Config().Filenames {
+			if ext, ok := strings.CutPrefix(pattern, "*"); ok && !strings.ContainsAny(ext, "*?[") {
+				return "snippet" + ext
+			}
+			if !strings.ContainsAny(pattern, "*?[") {
+				return pattern
+			}
+		}
+	}
+	if plainExtension.MatchString(language) {
+		return "snippet." + language
+	}
+	return "snippet.txt"
+}
+
+// plainExtension matches languages that can be used as a file extension.
+var plainExtension = regexp.MustCompile(`^[a-z0-9]{1,10}$`)
+
+// addSnippetButtons adds the buttons saving the code to a file and, for
+// code that can be run, running it to the header.
+func (cb *CodeBlock) addSnippetButtons() {
+	if runSnippets && snippet.Available(cb.language) {
+		cb.runBtn = gtk.NewButton()
+		cb.runBtn.SetIconName("media-playback-start-symbolic")
+		cb.runBtn.SetTooltipText(i18n.T("Run in a sandbox"))
+		cb.runBtn.AddCSSClass("flat")
+		cb.runBtn.AddCSSClass("circular")
+		cb.runBtn.ConnectClicked(cb.run)
+		cb.header.Append(cb.runBtn)
+	}
+
+	saveBtn := gtk.NewButton()
+	saveBtn.SetIconName("document-save-as-symbolic")
+	saveBtn.SetTooltipText(i18n.T("Save as…"))
+	saveBtn.AddCSSClass("flat")
+	saveBtn.AddCSSClass("circular")
+	saveBtn.ConnectClicked(cb.saveToFile)
+	cb.header.Append(saveBtn)
+}
+
+// saveToFile asks where to save the code and writes it there.
+func (cb *CodeBlock) saveToFile() {
+	var parent *gtk.Window
+	if root := cb.Root(); root != nil {
+		parent, _ = root.CastType(gtk.GTypeWindow).(*gtk.Window)
+	}
+
+	chooser := gtk.NewFileChooserNative(
+		i18n.T("Save Code"),
+		parent,
+		gtk.FileChooserActionSave,
+		i18n.T("Save"),
+		i18n.T("Cancel"),
+	)
+	chooser.SetCurrentName(codeFileName(cb.language))
+
+	code := cb.code
+	chooser.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := chooser.File(); file != nil && file.Path() != "" {
+				path := file.Path()
+				if !strings.HasSuffix(code, "\n") {
+					code += "\n"
+				}
+				if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+					logger.Error("Failed to save code", "path", path, "error", err)
+					cb.showOutput(fmt.Sprintf(i18n.T("Couldn't save the code: %v"), err), true)
+				} else {
+					logger.Info("Code saved to file", "path", path)
+				}
+			}
+		}
+		chooser.Destroy()
+	})
+
+	chooser.Show()
+}
+
+// run runs the code in the sandbox and shows its output below it.
+func (cb *CodeBlock) run() {
+	code := cb.code
+	language := cb.language
+	cb.runBtn.SetSensitive(false)
+	cb.runBtn.SetTooltipText(i18n.T("Running…"))
+	go func() {
+		output, err := snippet.Run(context.Background(), language, code)
+
+		glib.IdleAdd(func() {
+			cb.runBtn.SetSensitive(true)
+			cb.runBtn.SetTooltipText(i18n.T("Run in a sandbox"))
+
+			text := strings.TrimRight(output, "\n")
+			if err != nil {
+				logger.Warn("Snippet failed", "language", language, "error", err)
+				if text != "" {
+					text += "\n"
+				}
+				text += fmt.Sprintf(i18n.T("Failed: %v"), err)
+			} else if text == "" {
+				text = i18n.T("No output")
+			}
+			cb.showOutput(text, err != nil)
+		})
+	}()
+}
+
+// showOutput shows text below the code, replacing the output shown before.
+func (cb *CodeBlock) showOutput(text string, failed bool) {
+	if cb.outputLabel == nil {
+		cb.outputLabel = gtk.NewLabel("")
+		cb.outputLabel.SetXAlign(0)
+		cb.outputLabel.SetWrap(true)
+		cb.outputLabel.SetWrapMode(pango.WrapWordChar)
+		cb.outputLabel.SetSelectable(true)
+		cb.outputLabel.SetMarginStart(12)
+		cb.outputLabel.SetMarginEnd(12)
+		cb.outputLabel.SetMarginBottom(12)
+		cb.outputLabel.AddCSSClass("code-output")
+		cb.Append(cb.outputLabel)
+	}
+	cb.outputLabel.SetText(text)
+	if failed {
+		cb.outputLabel.AddCSSClass("error")
+	} else {
+		cb.outputLabel.RemoveCSSClass("error")
+	}
+}
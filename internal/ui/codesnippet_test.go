@@ -0,0 +1,25 @@
+package ui
+
+import "testing"
+
+func TestCodeFileName(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"python", "snippet.py"},
+		{"Go", "snippet.go"},
+		{"bash", "snippet.sh"},
+		{"c", "snippet.c"},
+		{"dockerfile", "Dockerfile"},
+		{"mermaid", "snippet.mermaid"},
+		{"", "snippet.txt"},
+		{"some language", "snippet.txt"},
+	}
+
+	for _, tt := range tests {
+		if got := codeFileName(tt.language); got != tt.want {
+			t.Errorf("codeFileName(%q) = %q, want %q", tt.language, got, tt.want)
+		}
+	}
+}
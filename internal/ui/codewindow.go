@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// countLines returns how many lines code has, not counting an empty line
+// after a final newline.
+func countLines(code string) int {
+	if code == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(code, "\n"), "\n") + 1
+}
+
+// CodeWindow shows a code block in its own resizable window, with line
+// numbers and a choice to wrap long lines, so that long code can be read
+// while the conversation goes on.
+type CodeWindow struct {
+	*adw.Window
+
+	// UI components
+	title      *adw.WindowTitle
+	textView   *gtk.TextView
+	textBuffer *gtk.TextBuffer
+	gutter     *lineNumbers
+	copyBtn    *gtk.Button
+
+	// Data
+	code     string
+	language string
+}
+
+// NewCodeWindow creates a window showing code highlighted for language.
+func NewCodeWindow(parent *gtk.Window, code, language string) *CodeWindow {
+	w := &CodeWindow{language: language}
+
+	w.Window = adw.NewWindow()
+	w.SetDefaultSize(760, 560)
+	if parent != nil {
+		w.SetTransientFor(parent)
+	}
+
+	w.setupUI()
+	w.SetCode(code)
+	followSyntaxStyle(gtk.BaseWidget(w), func() {
+		w.SetCode(w.code)
+	})
+
+	return w
+}
+
+func (w *CodeWindow) setupUI() {
+	name := w.language
+	if name == "" {
+		name = i18n.T("Code")
+	}
+	w.SetTitle(name)
+
+	headerBar := adw.NewHeaderBar()
+	w.title = adw.NewWindowTitle(name, "")
+	headerBar.SetTitleWidget(w.title)
+
+	// Wrap toggle
+	wrapBtn := gtk.NewToggleButton()
+	wrapBtn.SetIconName("format-justify-fill-symbolic")
+	wrapBtn.SetTooltipText(i18n.T("Wrap long lines"))
+	wrapBtn.ConnectToggled(func() {
+		if wrapBtn.Active() {
+			w.textView.SetWrapMode(gtk.WrapWordChar)
+		} else {
+			w.textView.SetWrapMode(gtk.WrapNone)
+		}
+		w.gutter.QueueDraw()
+	})
+	headerBar.PackStart(wrapBtn)
+
+	// Line numbers toggle
+	numbersBtn := gtk.NewToggleButton()
+	numbersBtn.SetIconName("view-list-ordered-symbolic")
+	numbersBtn.SetTooltipText(i18n.T("Show line numbers"))
+	numbersBtn.SetActive(true)
+	numbersBtn.ConnectToggled(func() {
+		w.gutter.SetVisible(numbersBtn.Active())
+	})
+	headerBar.PackStart(numbersBtn)
+
+	// Copy button
+	w.copyBtn = gtk.NewButton()
+	w.copyBtn.SetIconName("edit-copy-symbolic")
+	w.copyBtn.SetTooltipText(i18n.T("Copy code"))
+	w.copyBtn.ConnectClicked(func() {
+		gdk.DisplayGetDefault().Clipboard().SetText(w.code)
+		w.copyBtn.SetIconName("object-select-symbolic")
+		w.copyBtn.SetTooltipText(i18n.T("Copied!"))
+		glib.TimeoutAdd(1500, func() bool {
+			w.copyBtn.SetIconName("edit-copy-symbolic")
+			w.copyBtn.SetTooltipText(i18n.T("Copy code"))
+			return false
+		})
+	})
+	headerBar.PackEnd(w.copyBtn)
+
+	w.textBuffer = gtk.NewTextBuffer(nil)
+	w.textView = gtk.NewTextViewWithBuffer(w.textBuffer)
+	w.textView.SetEditable(false)
+	w.textView.SetMonospace(true)
+	w.textView.AddCSSClass("code-content")
+	w.textView.SetWrapMode(gtk.WrapNone)
+	w.textView.SetLeftMargin(12)
+	w.textView.SetRightMargin(12)
+	w.textView.SetTopMargin(12)
+	w.textView.SetBottomMargin(12)
+
+	w.gutter = newLineNumbers(w.textView)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(w.textView)
+	scrolled.SetPolicy(gtk.PolicyAutomatic, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	scrolled.AddCSSClass("code-window")
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(scrolled)
+
+	w.SetContent(toolbarView)
+}
+
+// SetCode replaces the code shown in the window.
+func (w *CodeWindow) SetCode(code string) {
+	w.code = code
+	highlightCode(w.textBuffer, code, w.language)
+
+	lines := countLines(code)
+	w.title.SetSubtitle(fmt.Sprintf(i18n.N("%d line", "%d lines", uint(lines)), lines))
+	w.gutter.setLineCount(lines)
+}
@@ -0,0 +1,26 @@
+package ui
+
+import "testing"
+
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want int
+	}{
+		{"empty", "", 0},
+		{"one line", "fmt.Println()", 1},
+		{"final newline", "a := 1\n", 1},
+		{"several lines", "a\nb\nc", 3},
+		{"blank lines", "a\n\n\nb\n", 4},
+		{"only a newline", "\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countLines(tt.code); got != tt.want {
+				t.Errorf("countLines(%q) = %d, want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// completionSettings is how the responses of a chat are requested.
+type completionSettings struct {
+	Mode      store.CompletionMode
+	Template  string
+	KeepAlive string
+	Format    string // Output format, see ollama.OutputFormat
+	WorkDir   string // Folder the file tools can read, empty if none
+}
+
+// chatCompletionSettings returns the completion settings of chat. A nil
+// chat uses the chat API.
+func chatCompletionSettings(chat *store.Chat) completionSettings {
+	if chat == nil {
+		return completionSettings{}
+	}
+	return completionSettings{
+		Mode:      chat.CompletionMode,
+		Template:  chat.Template,
+		KeepAlive: chat.KeepAlive,
+		Format:    chat.OutputFormat,
+		WorkDir:   chat.WorkDir,
+	}
+}
+
+// streamCompletion streams the reply to messages with the chat API, or with
+// the generate API when settings select a completion mode, and returns the
+// statistics of the reply. A chat without a keep-alive of its own uses the
+// default of the settings, and a chat with an output format asks for JSON.
+func (cv *ChatView) streamCompletion(ctx context.Context, model string, messages []ollama.Message, settings completionSettings, onToken ollama.TokenCallback) (*ollama.ResponseStats, error) {
+	settings.KeepAlive = strings.TrimSpace(settings.KeepAlive)
+	if cv.appConfig != nil {
+		settings.KeepAlive = cv.appConfig.EffectiveKeepAlive(settings.KeepAlive)
+	}
+	format, err := ollama.OutputFormat(settings.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.Mode == store.CompletionChat {
+		req := ollama.ChatRequest{
+			Model:     model,
+			Messages:  messages,
+			KeepAlive: ollama.KeepAlive(settings.KeepAlive),
+			Format:    format,
+		}
+		registry := cv.chatTools(settings.WorkDir)
+		if cv.toolsEnabled(ctx, model, registry) {
+			return cv.chatWithTools(ctx, req, registry, onToken)
+		}
+		result, err := cv.streamHandler.ChatWithTools(ctx, &req, onToken)
+		return result.Stats, err
+	}
+	req := buildGenerateRequest(model, messages, settings)
+	req.Format = format
+	return cv.streamHandler.GenerateWithStats(ctx, req, onToken)
+}
+
+// buildGenerateRequest turns a conversation into a generate request. In raw
+// mode no template is applied, so the system prompt goes at the start of
+// the prompt.
+func buildGenerateRequest(model string, messages []ollama.Message, settings completionSettings) *ollama.GenerateRequest {
+	system, prompt := ollama.CompletionPrompt(messages)
+	req := &ollama.GenerateRequest{
+		Model:     model,
+		Prompt:    prompt,
+		System:    system,
+		KeepAlive: ollama.KeepAlive(strings.TrimSpace(settings.KeepAlive)),
+	}
+
+	if settings.Mode == store.CompletionRaw {
+		req.Raw = true
+		req.System = ""
+		if system != "" {
+			req.Prompt = system + "\n\n" + prompt
+		}
+	} else {
+		req.Template = settings.Template
+	}
+
+	// Images of the latest user message, as in the chat API
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			req.Images = messages[i].Images
+			break
+		}
+	}
+	return req
+}
+
+// validKeepAlive reports whether value is a keep-alive Ollama accepts: empty
+// for the server default, a number of seconds or a duration such as "10m",
+// sent as ollama.KeepAlive. Negative values keep the model loaded
+// indefinitely.
+func validKeepAlive(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return true
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return true
+	}
+	_, err := time.ParseDuration(value)
+	return err == nil
+}
+
+// outputFormatFor returns the output format of a chat with JSON output on
+// or off and an optional schema.
+func outputFormatFor(jsonOutput bool, schema string) string {
+	if !jsonOutput {
+		return ""
+	}
+	if schema = strings.TrimSpace(schema); schema != "" {
+		return schema
+	}
+	return ollama.FormatJSON
+}
+
+// splitOutputFormat returns whether an output format asks for JSON, and
+// its schema if it has one.
+func splitOutputFormat(format string) (jsonOutput bool, schema string) {
+	format = strings.TrimSpace(format)
+	switch format {
+	case "":
+		return false, ""
+	case ollama.FormatJSON:
+		return true, ""
+	default:
+		return true, format
+	}
+}
+
+// validSchema reports whether schema is empty or a JSON schema Ollama can
+// constrain responses with.
+func validSchema(schema string) bool {
+	_, err := ollama.OutputFormat(schema)
+	return err == nil
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestBuildGenerateRequest(t *testing.T) {
+	messages := []ollama.Message{
+		{Role: "system", Content: "Write like a poet"},
+		{Role: "user", Content: "The sea at night", Images: []string{"aW1n"}},
+	}
+
+	t.Run("template", func(t *testing.T) {
+		req := buildGenerateRequest("base", messages, completionSettings{
+			Mode:      store.CompletionGenerate,
+			Template:  "{{ .System }} {{ .Prompt }}",
+			KeepAlive: " 10m ",
+		})
+		if req.Raw || req.System != "Write like a poet" || req.Prompt != "The sea at night" {
+			t.Errorf("buildGenerateRequest() = %+v, want the system prompt apart", req)
+		}
+		if req.Template != "{{ .System }} {{ .Prompt }}" || req.KeepAlive != "10m" {
+			t.Errorf("buildGenerateRequest() = %+v, want the template and keep-alive", req)
+		}
+		if len(req.Images) != 1 {
+			t.Errorf("buildGenerateRequest() images = %v, want the user's image", req.Images)
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		req := buildGenerateRequest("base", messages, completionSettings{
+			Mode:     store.CompletionRaw,
+			Template: "ignored",
+		})
+		if !req.Raw || req.System != "" || req.Template != "" {
+			t.Errorf("buildGenerateRequest() = %+v, want a raw request", req)
+		}
+		if req.Prompt != "Write like a poet\n\nThe sea at night" {
+			t.Errorf("buildGenerateRequest() prompt = %q, want the system prompt first", req.Prompt)
+		}
+	})
+}
+
+func TestValidKeepAlive(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", true},
+		{"10m", true},
+		{"1h30m", true},
+		{"300", true},
+		{"-1", true},
+		{"forever", false},
+		{"10 minutes", false},
+	}
+
+	for _, tt := range tests {
+		if got := validKeepAlive(tt.value); got != tt.want {
+			t.Errorf("validKeepAlive(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestOutputFormatFor(t *testing.T) {
+	tests := []struct {
+		jsonOutput bool
+		schema     string
+		want       string
+	}{
+		{false, `{"type": "object"}`, ""},
+		{true, "", "json"},
+		{true, "  ", "json"},
+		{true, ` {"type": "object"} `, `{"type": "object"}`},
+	}
+	for _, tt := range tests {
+		got := outputFormatFor(tt.jsonOutput, tt.schema)
+		if got != tt.want {
+			t.Errorf("outputFormatFor(%v, %q) = %q, want %q", tt.jsonOutput, tt.schema, got, tt.want)
+		}
+
+		// The dialog shows the saved format as it was entered
+		jsonOutput, schema := splitOutputFormat(got)
+		if jsonOutput != tt.jsonOutput || (jsonOutput && schema != strings.TrimSpace(tt.schema)) {
+			t.Errorf("splitOutputFormat(%q) = %v, %q", got, jsonOutput, schema)
+		}
+	}
+}
+
+func TestValidSchema(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   bool
+	}{
+		{"", true},
+		{`{"type": "object", "properties": {"name": {"type": "string"}}}`, true},
+		{`{"type": `, false},
+		{`["type"]`, false},
+	}
+	for _, tt := range tests {
+		if got := validSchema(tt.schema); got != tt.want {
+			t.Errorf("validSchema(%q) = %v, want %v", tt.schema, got, tt.want)
+		}
+	}
+}
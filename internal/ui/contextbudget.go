@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+// A prompt that won't fit the context window of the model, as reported by
+// Ollama, isn't sent right away: a warning above the input area tells how
+// large it is and offers to trim or summarize the history, to send only the
+// passages of the attachments relevant to the message, or to send it
+// anyway. Beyond the window Ollama silently drops the start of the prompt.
+
+// contextLengthTimeout bounds how long loading the context length of a
+// model may take.
+const contextLengthTimeout = 15 * time.Second
+
+// contextLengths remembers the context length of models, per server. It is
+// filled in the background, so access is locked.
+type contextLengths struct {
+	mu     sync.Mutex
+	models map[string]int // Keyed by server URL and model name
+}
+
+func newContextLengths() *contextLengths {
+	return &contextLengths{models: make(map[string]int)}
+}
+
+// get returns the context length of model, or 0 when it isn't known yet.
+func (c *contextLengths) get(client ollama.API, model string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.models[client.BaseURL()+" "+model]
+}
+
+// load asks the server for the context length of model, unless it is known.
+func (c *contextLengths) load(ctx context.Context, client ollama.API, model string) {
+	if c.get(client, model) > 0 {
+		return
+	}
+	info, err := client.ShowModel(ctx, model)
+	if err != nil {
+		logger.Error("Failed to load context length", "model", model, "error", err)
+		return
+	}
+	n := info.ContextLength()
+	if n <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	c.models[client.BaseURL()+" "+model] = n
+	c.mu.Unlock()
+	logger.Info("Loaded context length", "model", model, "tokens", n)
+}
+
+// loadContextLength loads the context length of the current model in the
+// background, and then tells the draft counter of the input area.
+func (cv *ChatView) loadContextLength() {
+	cv.inputArea.SetContextBudget(cv.currentContextBudget())
+	if cv.ollamaClient == nil || cv.currentModel == "" {
+		return
+	}
+	client := cv.ollamaClient
+	model := cv.currentModel
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), contextLengthTimeout)
+		defer cancel()
+		cv.contextLengths.load(ctx, client, model)
+
+		glib.IdleAdd(func() {
+			if cv.ollamaClient == client && cv.currentModel == model {
+				cv.inputArea.SetContextBudget(cv.currentContextBudget())
+			}
+		})
+	}()
+}
+
+// currentContextBudget returns the tokens a prompt to the current model may
+// use, or 0 when its context length isn't known.
+func (cv *ChatView) currentContextBudget() int {
+	if cv.ollamaClient == nil || cv.currentModel == "" {
+		return 0
+	}
+	return contextBudget(cv.contextLengths.get(cv.ollamaClient, cv.currentModel))
+}
+
+// newContextWarning creates the warning shown above the input area when a
+// prompt won't fit the context window.
+func (cv *ChatView) newContextWarning() *gtk.Revealer {
+	box := gtk.NewBox(gtk.OrientationVertical, 8)
+	box.AddCSSClass("context-warning")
+	box.SetMarginStart(12)
+	box.SetMarginEnd(12)
+	box.SetMarginBottom(8)
+
+	header := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	cv.contextWarningLabel = gtk.NewLabel("")
+	cv.contextWarningLabel.SetXAlign(0)
+	cv.contextWarningLabel.SetWrap(true)
+	cv.contextWarningLabel.SetHExpand(true)
+	header.Append(cv.contextWarningLabel)
+
+	closeBtn := gtk.NewButtonFromIconName("window-close-symbolic")
+	closeBtn.AddCSSClass("flat")
+	closeBtn.AddCSSClass("circular")
+	closeBtn.SetVAlign(gtk.AlignStart)
+	closeBtn.SetTooltipText(i18n.T("Keep editing"))
+	closeBtn.ConnectClicked(cv.hideContextWarning)
+	header.Append(closeBtn)
+	box.Append(header)
+
+	buttons := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttons.SetHAlign(gtk.AlignEnd)
+
+	trimBtn := gtk.NewButtonWithLabel(i18n.T("Trim History"))
+	trimBtn.ConnectClicked(func() {
+		cv.sendOverContext(historyTrimmed, false)
+	})
+	buttons.Append(trimBtn)
+
+	summarizeBtn := gtk.NewButtonWithLabel(i18n.T("Summarize History"))
+	summarizeBtn.ConnectClicked(func() {
+		cv.sendOverContext(historySummarized, false)
+	})
+	buttons.Append(summarizeBtn)
+
+	cv.contextPassagesBtn = gtk.NewButtonWithLabel(i18n.T("Send Relevant Passages"))
+	cv.contextPassagesBtn.SetTooltipText(i18n.T("Send only the parts of the attachments that match the message"))
+	cv.contextPassagesBtn.ConnectClicked(func() {
+		cv.sendOverContext(historyFull, true)
+	})
+	buttons.Append(cv.contextPassagesBtn)
+
+	sendBtn := gtk.NewButtonWithLabel(i18n.T("Send Anyway"))
+	sendBtn.AddCSSClass("destructive-action")
+	sendBtn.ConnectClicked(func() {
+		cv.sendOverContext(historyFull, false)
+	})
+	buttons.Append(sendBtn)
+	box.Append(buttons)
+
+	revealer := gtk.NewRevealer()
+	revealer.SetTransitionType(gtk.RevealerTransitionTypeSlideUp)
+	revealer.SetChild(box)
+	return revealer
+}
+
+// showContextWarning puts text back into the input area and tells that
+// the prompt, of size, is over budget, the tokens it may use.
+func (cv *ChatView) showContextWarning(text string, size promptSize, budget int) {
+	logger.Info("Prompt over the context window", "model", cv.currentModel, "tokens", size.Total(), "budget", budget)
+
+	cv.contextWarningLabel.SetText(fmt.Sprintf(i18n.T("This message will send about %d tokens, more than the %d that fit in the context window of %s (history: %d, attachments: %d, message: %d). The start of the conversation would be lost."),
+		size.Total(), budget, cv.currentModel, size.History, size.Attachments, size.Message))
+	cv.contextPassagesBtn.SetVisible(size.Attachments > 0 && attachmentBudget(size, budget) > 0)
+	cv.contextWarning.SetRevealChild(true)
+
+	// The input area clears its text once it has been handed over
+	glib.IdleAdd(func() {
+		cv.inputArea.SetText(text)
+		cv.inputArea.Focus()
+	})
+}
+
+// hideContextWarning hides the context window warning.
+func (cv *ChatView) hideContextWarning() {
+	cv.contextWarning.SetRevealChild(false)
+}
+
+// sendOverContext sends the message in the input area with the history
+// sent in mode, and with only the relevant passages of the attachments
+// when passages is set.
+func (cv *ChatView) sendOverContext(mode historyMode, passages bool) {
+	cv.hideContextWarning()
+	if cv.isStreaming {
+		return
+	}
+
+	text := strings.TrimSpace(cv.inputArea.GetText())
+	if text == "" && !cv.inputArea.HasAttachments() {
+		return
+	}
+	cv.inputArea.SetText("")
+
+	budget := cv.currentContextBudget()
+	cv.historyBudget = budget
+	if passages {
+		cv.passageBudget = attachmentBudget(cv.estimatePromptSize(text), budget)
+	}
+	cv.sendMessage(text, mode)
+}
+
+// contextBudget returns the tokens a prompt may use in a context window of
+// contextLength tokens, leaving a quarter of it for the response.
+func contextBudget(contextLength int) int {
+	return contextLength - contextLength/4
+}
+
+// attachmentBudget returns the tokens left for the attachments of a prompt
+// of size once its history and message fit in budget.
+func attachmentBudget(size promptSize, budget int) int {
+	return max(budget-size.History-size.Message, 0)
+}
+
+// selectAttachmentPassages returns the document part of a prompt made of
+// the passages of sources relevant to query, within about budget tokens.
+func selectAttachmentPassages(query string, sources []rag.Source, budget int) string {
+	var builder strings.Builder
+	for _, p := range rag.SelectPassages(query, sources, documentChunker, budget) {
+		builder.WriteString(fmt.Sprintf("[Document: %s, excerpt]\n", p.Source))
+		builder.WriteString(p.Text)
+		builder.WriteString("\n\n")
+	}
+	return builder.String()
+}
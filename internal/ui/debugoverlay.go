@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// debugOverlayInterval is how often the overlay refreshes, in milliseconds.
+const debugOverlayInterval = 250
+
+// DebugOverlay shows live streaming measurements on top of the chat to help
+// diagnose stutter.
+type DebugOverlay struct {
+	*gtk.Box
+
+	label *gtk.Label
+	stats *streamStats
+	timer glib.SourceHandle
+}
+
+// NewDebugOverlay creates a hidden overlay showing stats.
+func NewDebugOverlay(stats *streamStats) *DebugOverlay {
+	d := &DebugOverlay{stats: stats}
+
+	d.Box = gtk.NewBox(gtk.OrientationVertical, 0)
+	d.setupUI()
+
+	return d
+}
+
+func (d *DebugOverlay) setupUI() {
+	d.AddCSSClass("debug-overlay")
+	d.AddCSSClass("osd")
+	d.SetHAlign(gtk.AlignEnd)
+	d.SetVAlign(gtk.AlignStart)
+	d.SetMarginTop(12)
+	d.SetMarginEnd(12)
+	d.SetCanTarget(false) // Clicks go through to the chat
+	d.SetVisible(false)
+
+	d.label = gtk.NewLabel("")
+	d.label.SetXAlign(0)
+	d.label.AddCSSClass("monospace")
+	d.label.AddCSSClass("caption")
+	d.label.SetMarginTop(8)
+	d.label.SetMarginBottom(8)
+	d.label.SetMarginStart(12)
+	d.label.SetMarginEnd(12)
+	d.Append(d.label)
+}
+
+// Toggle shows or hides the overlay. It refreshes while shown.
+func (d *DebugOverlay) Toggle() {
+	if d.Visible() {
+		d.SetVisible(false)
+		if d.timer != 0 {
+			glib.SourceRemove(d.timer)
+			d.timer = 0
+		}
+		return
+	}
+
+	d.SetVisible(true)
+	d.refresh()
+	d.timer = glib.TimeoutAdd(debugOverlayInterval, func() bool {
+		d.refresh()
+		return true
+	})
+}
+
+// refresh shows the latest measurements.
+func (d *DebugOverlay) refresh() {
+	d.label.SetText(strings.Join(d.stats.Snapshot().Lines(), "\n"))
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+// documentContextBudget is about how many tokens of a chat's documents are
+// sent with each request. Larger libraries are narrowed down to the chunks
+// most relevant to the last user message.
+const documentContextBudget = 4000
+
+// documentChunker splits library documents for retrieval.
+var documentChunker = rag.NewChunker(rag.DefaultChunkSize, rag.DefaultOverlap)
+
+// formatDocumentContext formats the selected passages as a system message.
+func formatDocumentContext(passages []rag.Passage) string {
+	var builder strings.Builder
+	builder.WriteString("The user added these documents to the conversation. Use them when they are relevant to the question.")
+	for _, p := range passages {
+		if p.Start != "" {
+			builder.WriteString(fmt.Sprintf("\n\n[Document: %s, from %s]\n%s", p.Source, p.Start, p.Text))
+		} else {
+			builder.WriteString(fmt.Sprintf("\n\n[Document: %s]\n%s", p.Source, p.Text))
+		}
+	}
+	return builder.String()
+}
+
+// insertDocumentContext adds the document context to messages, right after
+// the system prompt if there is one.
+func insertDocumentContext(messages []ollama.Message, context string) []ollama.Message {
+	at := 0
+	if len(messages) > 0 && messages[0].Role == "system" {
+		at = 1
+	}
+
+	result := make([]ollama.Message, 0, len(messages)+1)
+	result = append(result, messages[:at]...)
+	result = append(result, ollama.Message{Role: "system", Content: context})
+	return append(result, messages[at:]...)
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+func TestFormatDocumentContext(t *testing.T) {
+	got := formatDocumentContext([]rag.Passage{
+		{Source: "guide.md", Text: "Step one"},
+		{Source: "notes.txt", Text: "Remember this"},
+		{Source: "lecture.srt", Text: "[00:12:00] Entropy", Start: "00:12:00"},
+	})
+
+	for _, want := range []string{"[Document: guide.md]\nStep one", "[Document: notes.txt]\nRemember this", "[Document: lecture.srt, from 00:12:00]\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatDocumentContext() = %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Index(got, "guide.md") > strings.Index(got, "notes.txt") {
+		t.Error("formatDocumentContext() changed the order of the passages")
+	}
+}
+
+func TestInsertDocumentContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []ollama.Message
+		want     []string
+	}{
+		{
+			name: "after the system prompt",
+			messages: []ollama.Message{
+				{Role: "system", Content: "prompt"},
+				{Role: "user", Content: "question"},
+			},
+			want: []string{"system:prompt", "system:docs", "user:question"},
+		},
+		{
+			name: "without a system prompt",
+			messages: []ollama.Message{
+				{Role: "user", Content: "question"},
+			},
+			want: []string{"system:docs", "user:question"},
+		},
+		{
+			name:     "empty history",
+			messages: nil,
+			want:     []string{"system:docs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := insertDocumentContext(tt.messages, "docs")
+			if len(got) != len(tt.want) {
+				t.Fatalf("insertDocumentContext() returned %d messages, want %d", len(got), len(tt.want))
+			}
+			for i, msg := range got {
+				if msg.Role+":"+msg.Content != tt.want[i] {
+					t.Errorf("message %d = %s:%s, want %s", i, msg.Role, msg.Content, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/plugins"
+	"github.com/storo/guanaco/internal/rag"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// DocumentsPanel lists the documents in the current chat's library. Files
+// dropped on it or added with its button are stored with the chat and sent
+// as context with every request.
+type DocumentsPanel struct {
+	*gtk.Box
+
+	listBox    *gtk.ListBox
+	scrolled   *gtk.ScrolledWindow
+	emptyState *gtk.Box
+	addButton  *gtk.Button
+
+	// Dependencies
+	db           *store.DB
+	ragProcessor *rag.Processor
+	plugins      []*plugins.Plugin
+	window       *gtk.Window
+	chat         *store.Chat
+
+	// Callbacks
+	onNeedChat func() *store.Chat
+	onError    func(error)
+}
+
+// NewDocumentsPanel creates a new documents panel.
+func NewDocumentsPanel(db *store.DB) *DocumentsPanel {
+	p := &DocumentsPanel{
+		db:           db,
+		ragProcessor: rag.NewProcessor(),
+	}
+
+	p.Box = gtk.NewBox(gtk.OrientationVertical, 0)
+	p.SetVExpand(true)
+	p.SetSizeRequest(260, -1)
+
+	p.setupUI()
+	p.setupDropTarget()
+
+	return p
+}
+
+func (p *DocumentsPanel) setupUI() {
+	p.AddCSSClass("documents-panel")
+
+	// Header
+	header := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	header.SetMarginTop(12)
+	header.SetMarginBottom(12)
+	header.SetMarginStart(12)
+	header.SetMarginEnd(12)
+
+	title := gtk.NewLabel(i18n.T("Documents"))
+	title.AddCSSClass("title-3")
+	title.SetHExpand(true)
+	title.SetXAlign(0)
+	header.Append(title)
+
+	p.addButton = gtk.NewButton()
+	p.addButton.SetIconName("list-add-symbolic")
+	p.addButton.SetTooltipText(i18n.T("Add Document"))
+	p.addButton.AddCSSClass("flat")
+	p.addButton.ConnectClicked(p.onAddDocument)
+	header.Append(p.addButton)
+
+	p.Append(header)
+	p.Append(gtk.NewSeparator(gtk.OrientationHorizontal))
+
+	// Document list
+	p.listBox = gtk.NewListBox()
+	p.listBox.SetSelectionMode(gtk.SelectionNone)
+	p.listBox.AddCSSClass("boxed-list")
+	p.listBox.SetMarginTop(12)
+	p.listBox.SetMarginStart(12)
+	p.listBox.SetMarginEnd(12)
+	p.listBox.SetVAlign(gtk.AlignStart)
+
+	p.scrolled = gtk.NewScrolledWindow()
+	p.scrolled.SetChild(p.listBox)
+	p.scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	p.scrolled.SetVExpand(true)
+	p.Append(p.scrolled)
+
+	// Empty state
+	p.emptyState = gtk.NewBox(gtk.OrientationVertical, 8)
+	p.emptyState.SetVExpand(true)
+	p.emptyState.SetVAlign(gtk.AlignCenter)
+	p.emptyState.SetMarginStart(16)
+	p.emptyState.SetMarginEnd(16)
+
+	emptyIcon := gtk.NewImageFromIconName("folder-documents-symbolic")
+	emptyIcon.SetIconSize(gtk.IconSizeLarge)
+	emptyIcon.AddCSSClass("dim-label")
+	p.emptyState.Append(emptyIcon)
+
+	emptyTitle := gtk.NewLabel(i18n.T("No documents"))
+	emptyTitle.AddCSSClass("dim-label")
+	p.emptyState.Append(emptyTitle)
+
+	emptyDesc := gtk.NewLabel(i18n.T("Drop files here to use them as context in every message of this chat"))
+	emptyDesc.SetWrap(true)
+	emptyDesc.SetJustify(gtk.JustifyCenter)
+	emptyDesc.AddCSSClass("dim-label")
+	emptyDesc.AddCSSClass("caption")
+	p.emptyState.Append(emptyDesc)
+
+	p.Append(p.emptyState)
+
+	p.Refresh()
+}
+
+// setupDropTarget adds dropped files to the library.
+func (p *DocumentsPanel) setupDropTarget() {
+	dropTarget := gtk.NewDropTarget(gio.GTypeFile, gdk.ActionCopy)
+
+	dropTarget.ConnectDrop(func(value *glib.Value, x, y float64) bool {
+		file := value.Object()
+		if file == nil {
+			return false
+		}
+
+		gfile, ok := file.Cast().(*gio.File)
+		if !ok || gfile.Path() == "" {
+			return false
+		}
+
+		p.addDocument(gfile.Path())
+		return true
+	})
+
+	p.AddController(dropTarget)
+}
+
+// SetWindow sets the parent window for dialogs.
+func (p *DocumentsPanel) SetWindow(window *gtk.Window) {
+	p.window = window
+}
+
+// SetChat shows the library of chat, or an empty panel for nil.
+func (p *DocumentsPanel) SetChat(chat *store.Chat) {
+	p.chat = chat
+	p.Refresh()
+}
+
+// Refresh reloads the document list from the database.
+func (p *DocumentsPanel) Refresh() {
+	for {
+		child := p.listBox.FirstChild()
+		if child == nil {
+			break
+		}
+		p.listBox.Remove(child)
+	}
+
+	var docs []*store.Document
+	if p.db != nil && p.chat != nil && p.chat.ID != 0 {
+		var err error
+		docs, err = p.db.ListDocuments(p.chat.ID)
+		if err != nil {
+			logger.Error("Failed to list documents", "chatID", p.chat.ID, "error", err)
+		}
+	}
+
+	for _, doc := range docs {
+		p.listBox.Append(p.createRow(doc))
+	}
+	p.scrolled.SetVisible(len(docs) > 0)
+	p.emptyState.SetVisible(len(docs) == 0)
+	p.addButton.SetSensitive(p.db != nil)
+}
+
+// createRow creates the list row for a document.
+func (p *DocumentsPanel) createRow(doc *store.Document) *adw.ActionRow {
+	row := adw.NewActionRow()
+	row.SetTitle(doc.Filename)
+	row.SetSubtitle(fmt.Sprintf(i18n.T("About %d tokens"), rag.EstimateTokens(doc.Content)))
+
+	removeBtn := gtk.NewButton()
+	removeBtn.SetIconName("user-trash-symbolic")
+	removeBtn.SetTooltipText(i18n.T("Remove document"))
+	removeBtn.SetVAlign(gtk.AlignCenter)
+	removeBtn.AddCSSClass("flat")
+	removeBtn.ConnectClicked(func() {
+		if err := p.db.DeleteDocument(doc.ID); err != nil {
+			logger.Error("Failed to delete document", "documentID", doc.ID, "error", err)
+			p.handleError(err)
+			return
+		}
+		logger.Info("Document removed", "chatID", doc.ChatID, "filename", doc.Filename)
+		p.Refresh()
+	})
+	row.AddSuffix(removeBtn)
+
+	return row
+}
+
+// onAddDocument lets the user pick a file to add.
+func (p *DocumentsPanel) onAddDocument() {
+	dialog := gtk.NewFileChooserNative(
+		i18n.T("Add Document"),
+		p.window,
+		gtk.FileChooserActionOpen,
+		i18n.T("Open"),
+		i18n.T("Cancel"),
+	)
+
+	filter := gtk.NewFileFilter()
+	filter.SetName(i18n.T("Supported Documents"))
+	filter.AddPattern("*.txt")
+	filter.AddPattern("*.md")
+	filter.AddPattern("*.pdf")
+	filter.AddPattern("*.docx")
+	filter.AddPattern("*.odt")
+	filter.AddPattern("*.csv")
+	filter.AddPattern("*.tsv")
+	filter.AddPattern("*.xlsx")
+	filter.AddPattern("*.ipynb")
+	filter.AddPattern("*.eml")
+	filter.AddPattern("*.mbox")
+	filter.AddPattern("*.srt")
+	filter.AddPattern("*.vtt")
+	for _, ext := range pluginExtensions(p.plugins) {
+		filter.AddPattern("*" + ext)
+	}
+	dialog.AddFilter(filter)
+
+	dialog.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := dialog.File(); file != nil && file.Path() != "" {
+				p.addDocument(file.Path())
+			}
+		}
+		dialog.Destroy()
+	})
+
+	dialog.Show()
+}
+
+// addDocument extracts the text of a file and stores it in the library,
+// creating the chat first if needed.
+func (p *DocumentsPanel) addDocument(path string) {
+	filename := filepath.Base(path)
+	if p.db == nil {
+		return
+	}
+	if rag.IsImage(filename) || !p.ragProcessor.CanProcess(filename) {
+		p.handleError(fmt.Errorf(i18n.T("unsupported file type: %s"), filename))
+		return
+	}
+
+	if p.chat == nil && p.onNeedChat != nil {
+		p.chat = p.onNeedChat()
+	}
+	if p.chat == nil || p.chat.ID == 0 {
+		return
+	}
+	chatID := p.chat.ID
+
+	logger.Info("Adding document to library", "chatID", chatID, "path", path)
+	go func() {
+		result, err := p.ragProcessor.Process(path)
+
+		glib.IdleAdd(func() {
+			if err != nil {
+				p.handleError(fmt.Errorf(i18n.T("failed to process %s: %v"), filename, err))
+				return
+			}
+			if _, err := p.db.AddDocument(chatID, result.Filename, result.Content); err != nil {
+				logger.Error("Failed to save document", "chatID", chatID, "filename", result.Filename, "error", err)
+				p.handleError(err)
+				return
+			}
+			logger.Info("Document added", "chatID", chatID, "filename", result.Filename, "tokens", result.TokenEstimate)
+			if p.chat != nil && p.chat.ID == chatID {
+				p.Refresh()
+			}
+		})
+	}()
+}
+
+func (p *DocumentsPanel) handleError(err error) {
+	if p.onError != nil {
+		p.onError(err)
+	}
+}
+
+// OnNeedChat sets the callback that provides a chat when a document is
+// added before the conversation has started.
+func (p *DocumentsPanel) OnNeedChat(callback func() *store.Chat) {
+	p.onNeedChat = callback
+}
+
+// OnError sets the callback for errors.
+func (p *DocumentsPanel) OnError(callback func(error)) {
+	p.onError = callback
+}
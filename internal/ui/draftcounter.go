@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+// A counter below the input area shows how long the draft is, in
+// characters and estimated tokens. It turns into a warning when the draft
+// alone comes close to what fits in the context window of the model, so
+// that it can be shortened before sending.
+
+// draftNearPercent is how much of the context budget a draft may take
+// before the counter warns about it.
+const draftNearPercent = 75
+
+// draftLevel is how close a draft is to the context budget.
+type draftLevel int
+
+const (
+	draftFits draftLevel = iota
+	draftNear
+	draftOver
+)
+
+// draftSize returns the level of a draft of tokens for a context budget of
+// budget tokens. An unknown budget, 0, always fits.
+func draftSize(tokens, budget int) draftLevel {
+	switch {
+	case budget <= 0:
+		return draftFits
+	case tokens > budget:
+		return draftOver
+	case tokens*100 >= budget*draftNearPercent:
+		return draftNear
+	default:
+		return draftFits
+	}
+}
+
+// draftCounterText returns the text of the counter for a draft of chars
+// characters and tokens tokens, at level for a budget of budget tokens.
+func draftCounterText(chars, tokens, budget int, level draftLevel) string {
+	switch level {
+	case draftOver:
+		return fmt.Sprintf(i18n.T("%d characters · about %d tokens, over the %d that fit in the context window"), chars, tokens, budget)
+	case draftNear:
+		return fmt.Sprintf(i18n.T("%d characters · about %d tokens, close to the %d that fit in the context window"), chars, tokens, budget)
+	default:
+		return fmt.Sprintf(i18n.T("%d characters · about %d tokens"), chars, tokens)
+	}
+}
+
+// newDraftCounter creates the counter shown below the input row.
+func (ia *InputArea) newDraftCounter() *gtk.Label {
+	label := gtk.NewLabel("")
+	label.SetXAlign(1)
+	label.SetMarginEnd(4)
+	label.AddCSSClass("caption")
+	label.AddCSSClass("dim-label")
+	label.SetVisible(false)
+	return label
+}
+
+// updateDraftCounter shows the size of the draft in the counter, hiding it
+// while the input is empty.
+func (ia *InputArea) updateDraftCounter() {
+	text := ia.GetText()
+	if text == "" {
+		ia.draftCounter.SetVisible(false)
+		return
+	}
+
+	tokens := rag.EstimateTokens(text)
+	level := draftSize(tokens, ia.contextBudget)
+	ia.draftCounter.SetText(draftCounterText(utf8.RuneCountInString(text), tokens, ia.contextBudget, level))
+	ia.draftCounter.SetVisible(true)
+
+	if level == draftFits {
+		ia.draftCounter.AddCSSClass("dim-label")
+	} else {
+		ia.draftCounter.RemoveCSSClass("dim-label")
+	}
+	if level == draftNear {
+		ia.draftCounter.AddCSSClass("warning")
+	} else {
+		ia.draftCounter.RemoveCSSClass("warning")
+	}
+	if level == draftOver {
+		ia.draftCounter.AddCSSClass("error")
+	} else {
+		ia.draftCounter.RemoveCSSClass("error")
+	}
+}
+
+// SetContextBudget sets the tokens a prompt to the current model may use,
+// which the draft counter warns about, or 0 when it isn't known.
+func (ia *InputArea) SetContextBudget(tokens int) {
+	ia.contextBudget = tokens
+	ia.updateDraftCounter()
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDraftSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens int
+		budget int
+		want   draftLevel
+	}{
+		{"unknown budget", 100000, 0, draftFits},
+		{"small draft", 100, 3072, draftFits},
+		{"just under near", 2303, 3072, draftFits},
+		{"near", 2304, 3072, draftNear},
+		{"at budget", 3072, 3072, draftNear},
+		{"over", 3073, 3072, draftOver},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := draftSize(tt.tokens, tt.budget); got != tt.want {
+				t.Errorf("draftSize(%d, %d) = %v, want %v", tt.tokens, tt.budget, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDraftCounterText(t *testing.T) {
+	if got, want := draftCounterText(42, 10, 3072, draftFits), "42 characters · about 10 tokens"; got != want {
+		t.Errorf("draftCounterText() = %q, want %q", got, want)
+	}
+	if got := draftCounterText(12000, 3000, 3072, draftNear); !strings.Contains(got, "close to the 3072") {
+		t.Errorf("draftCounterText() near = %q, want the budget mentioned", got)
+	}
+	if got := draftCounterText(16000, 4000, 3072, draftOver); !strings.Contains(got, "over the 3072") {
+		t.Errorf("draftCounterText() over = %q, want the budget mentioned", got)
+	}
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// What is typed and attached in a chat without being sent is kept as its
+// draft when another chat is opened or the window closes, and put back in
+// the input when the chat is opened again. Chats with a draft are marked
+// in the sidebar.
+
+// saveDraft keeps the input of the current chat as its draft, or deletes
+// the draft when the input is empty.
+func (cv *ChatView) saveDraft() {
+	chat := cv.currentChat
+	if cv.db == nil || chat == nil || chat.ID == 0 {
+		return
+	}
+
+	draft := &store.Draft{ChatID: chat.ID, Text: cv.inputArea.GetText()}
+	for _, pill := range cv.inputArea.GetAttachments() {
+		draft.Attachments = append(draft.Attachments, store.Attachment{Filename: pill.Filename(), Content: pill.Content()})
+	}
+	if draft.Empty() && !cv.hasDraft {
+		return
+	}
+
+	if err := cv.db.SaveDraft(draft); err != nil {
+		logger.Error("Failed to save draft", "chatID", chat.ID, "error", err)
+		return
+	}
+	cv.setHasDraft(chat.ID, !draft.Empty())
+}
+
+// SaveDraft keeps the input of the current chat as its draft, before the
+// window closes.
+func (cv *ChatView) SaveDraft() {
+	cv.saveDraft()
+}
+
+// restoreDraft replaces the input with the draft of the current chat, or
+// empties it when the chat has none.
+func (cv *ChatView) restoreDraft() {
+	cv.inputArea.SetText("")
+	cv.inputArea.ClearAttachments()
+	cv.hasDraft = false
+
+	chat := cv.currentChat
+	if cv.db == nil || chat == nil {
+		return
+	}
+	draft, err := cv.db.GetDraft(chat.ID)
+	if err != nil {
+		logger.Error("Failed to load draft", "chatID", chat.ID, "error", err)
+		return
+	}
+	if draft == nil {
+		return
+	}
+
+	cv.inputArea.SetText(draft.Text)
+	for _, a := range draft.Attachments {
+		cv.inputArea.AddAttachment(NewAttachmentPill(a.Filename, a.Content))
+	}
+	cv.hasDraft = true
+	logger.Info("Draft restored", "chatID", chat.ID, "attachments", len(draft.Attachments))
+}
+
+// clearDraft deletes the draft of the current chat once its input is sent.
+func (cv *ChatView) clearDraft() {
+	chat := cv.currentChat
+	if cv.db == nil || chat == nil || !cv.hasDraft {
+		return
+	}
+	if err := cv.db.DeleteDraft(chat.ID); err != nil {
+		logger.Error("Failed to delete draft", "chatID", chat.ID, "error", err)
+		return
+	}
+	cv.setHasDraft(chat.ID, false)
+}
+
+// setHasDraft records whether the chat has a draft, and reports a change.
+func (cv *ChatView) setHasDraft(chatID int64, has bool) {
+	if has == cv.hasDraft {
+		return
+	}
+	cv.hasDraft = has
+	if cv.onDraftChanged != nil {
+		cv.onDraftChanged(chatID)
+	}
+}
+
+// OnDraftChanged sets the callback for when a chat gets a draft or loses
+// it.
+func (cv *ChatView) OnDraftChanged(callback func(chatID int64)) {
+	cv.onDraftChanged = callback
+}
+
+// loadDrafts loads which chats have a draft.
+func (sb *Sidebar) loadDrafts() {
+	drafts, err := sb.db.DraftChatIDs()
+	if err != nil {
+		logger.Error("Failed to load drafts", "error", err)
+		return
+	}
+	sb.drafts = drafts
+}
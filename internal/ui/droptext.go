@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Dropped text longer than these limits is attached as a text file instead
+// of being quoted in the input.
+const (
+	droppedTextQuoteLines = 20
+	droppedTextQuoteChars = 2000
+)
+
+// droppedTextAttachmentName is the file name of the attachment holding a
+// long dropped text selection.
+const droppedTextAttachmentName = "dropped-text.txt"
+
+// quoteText formats text as a Markdown block quote.
+func quoteText(text string) string {
+	lines := strings.Split(strings.Trim(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendQuote returns the input text current with a quote of dropped added
+// after it, leaving an empty line where the user can type.
+func appendQuote(current, dropped string) string {
+	quote := quoteText(dropped) + "\n\n"
+	current = strings.TrimRight(current, " \t\n")
+	if current == "" {
+		return quote
+	}
+	return current + "\n\n" + quote
+}
+
+// shouldAttachDroppedText reports whether dropped text is too long to quote
+// in the input.
+func shouldAttachDroppedText(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.Count(text, "\n")+1 > droppedTextQuoteLines ||
+		utf8.RuneCountInString(text) > droppedTextQuoteChars
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "single line", text: "Hello world", want: "> Hello world"},
+		{name: "paragraphs", text: "First\n\nSecond  ", want: "> First\n>\n> Second"},
+		{name: "windows line endings", text: "One\r\nTwo\r\n", want: "> One\n> Two"},
+		{name: "surrounding space", text: "\n\n  indented\n", want: ">   indented"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteText(tt.text); got != tt.want {
+				t.Errorf("quoteText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendQuote(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		want    string
+	}{
+		{name: "empty input", current: "", want: "> quoted\n\n"},
+		{name: "blank input", current: " \n", want: "> quoted\n\n"},
+		{name: "after existing text", current: "Look at this:\n", want: "Look at this:\n\n> quoted\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendQuote(tt.current, "quoted"); got != tt.want {
+				t.Errorf("appendQuote(%q) = %q, want %q", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldAttachDroppedText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{name: "short paragraph", text: "A sentence from a browser.", want: false},
+		{name: "line limit", text: strings.Repeat("line\n", droppedTextQuoteLines), want: false},
+		{name: "too many lines", text: strings.Repeat("line\n", droppedTextQuoteLines+1), want: true},
+		{name: "too many characters", text: strings.Repeat("a", droppedTextQuoteChars+1), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldAttachDroppedText(tt.text); got != tt.want {
+				t.Errorf("shouldAttachDroppedText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// endpointRow holds the widgets of one server in the endpoints editor.
+type endpointRow struct {
+	box     *gtk.Box
+	active  *gtk.CheckButton
+	name    *gtk.Entry
+	url     *gtk.Entry
+	headers *gtk.MenuButton
+	buffer  *gtk.TextBuffer // Request headers, one per line
+}
+
+// EndpointsEditor edits the list of named Ollama servers and which one is active.
+type EndpointsEditor struct {
+	*gtk.Box
+
+	rowsBox *gtk.Box
+	rows    []*endpointRow
+}
+
+// NewEndpointsEditor creates an editor for the given endpoints. When the list
+// is empty, it starts with the local default server.
+func NewEndpointsEditor(endpoints []config.Endpoint, active string) *EndpointsEditor {
+	e := &EndpointsEditor{}
+
+	e.Box = gtk.NewBox(gtk.OrientationVertical, 6)
+
+	e.rowsBox = gtk.NewBox(gtk.OrientationVertical, 6)
+	e.Append(e.rowsBox)
+
+	if len(endpoints) == 0 {
+		endpoints = []config.Endpoint{{Name: i18n.T("Local"), URL: ollama.DefaultBaseURL}}
+		active = endpoints[0].Name
+	}
+	for i, ep := range endpoints {
+		row := e.addRow(ep)
+		if ep.Name == active || (i == 0 && !containsEndpoint(endpoints, active)) {
+			row.active.SetActive(true)
+		}
+	}
+
+	addBtn := gtk.NewButton()
+	addBtn.SetLabel(i18n.T("Add Server"))
+	addBtn.SetHAlign(gtk.AlignStart)
+	addBtn.AddCSSClass("flat")
+	addBtn.ConnectClicked(func() {
+		row := e.addRow(config.Endpoint{})
+		if len(e.rows) == 1 {
+			row.active.SetActive(true)
+		}
+		row.name.GrabFocus()
+	})
+	e.Append(addBtn)
+
+	return e
+}
+
+// containsEndpoint reports whether an endpoint with the given name exists.
+func containsEndpoint(endpoints []config.Endpoint, name string) bool {
+	for _, ep := range endpoints {
+		if ep.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addRow appends a row for the endpoint.
+func (e *EndpointsEditor) addRow(ep config.Endpoint) *endpointRow {
+	row := &endpointRow{}
+	row.box = gtk.NewBox(gtk.OrientationHorizontal, 6)
+
+	row.active = gtk.NewCheckButton()
+	row.active.SetTooltipText(i18n.T("Use this server"))
+	if len(e.rows) > 0 {
+		row.active.SetGroup(e.rows[0].active)
+	}
+	row.box.Append(row.active)
+
+	row.name = gtk.NewEntry()
+	row.name.SetText(ep.Name)
+	row.name.SetPlaceholderText(i18n.T("Name"))
+	row.name.SetWidthChars(8)
+	row.box.Append(row.name)
+
+	row.url = gtk.NewEntry()
+	row.url.SetText(ep.URL)
+	row.url.SetPlaceholderText(ollama.DefaultBaseURL)
+	row.url.SetTooltipText(i18n.T("Server URL, or unix:///path/to/socket for a Unix socket"))
+	row.url.SetHExpand(true)
+	row.url.ConnectChanged(func() {
+		row.url.RemoveCSSClass("error")
+	})
+	row.box.Append(row.url)
+
+	row.box.Append(row.setupHeaders(ep.Headers))
+
+	removeBtn := gtk.NewButton()
+	removeBtn.SetIconName("user-trash-symbolic")
+	removeBtn.SetTooltipText(i18n.T("Remove server"))
+	removeBtn.AddCSSClass("flat")
+	removeBtn.ConnectClicked(func() {
+		e.removeRow(row)
+	})
+	row.box.Append(removeBtn)
+
+	e.rows = append(e.rows, row)
+	e.rowsBox.Append(row.box)
+	return row
+}
+
+// setupHeaders creates the button editing the headers sent to the server,
+// for proxies that require authentication.
+func (row *endpointRow) setupHeaders(headers map[string]string) *gtk.MenuButton {
+	row.buffer = gtk.NewTextBuffer(nil)
+	row.buffer.SetText(config.FormatHeaders(headers))
+	row.buffer.ConnectChanged(func() {
+		row.headers.RemoveCSSClass("error")
+	})
+
+	box := gtk.NewBox(gtk.OrientationVertical, 6)
+	box.SetMarginTop(6)
+	box.SetMarginBottom(6)
+	box.SetMarginStart(6)
+	box.SetMarginEnd(6)
+
+	label := gtk.NewLabel(i18n.T("Request Headers"))
+	label.SetXAlign(0)
+	label.AddCSSClass("heading")
+	box.Append(label)
+
+	hint := gtk.NewLabel(i18n.T("One per line, as Name: value"))
+	hint.SetXAlign(0)
+	hint.AddCSSClass("dim-label")
+	hint.AddCSSClass("caption")
+	box.Append(hint)
+
+	textView := gtk.NewTextViewWithBuffer(row.buffer)
+	textView.SetMonospace(true)
+	textView.SetWrapMode(gtk.WrapWordChar)
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(textView)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetSizeRequest(320, 100)
+	scrolled.AddCSSClass("card")
+	box.Append(scrolled)
+
+	popover := gtk.NewPopover()
+	popover.SetChild(box)
+
+	row.headers = gtk.NewMenuButton()
+	row.headers.SetIconName("dialog-password-symbolic")
+	row.headers.SetTooltipText(i18n.T("Request headers"))
+	row.headers.AddCSSClass("flat")
+	row.headers.SetPopover(popover)
+	return row.headers
+}
+
+// removeRow removes a row, moving the selection to the first remaining row
+// if the removed one was active.
+func (e *EndpointsEditor) removeRow(row *endpointRow) {
+	wasActive := row.active.Active()
+	row.active.SetGroup(nil)
+	e.rowsBox.Remove(row.box)
+
+	for i, r := range e.rows {
+		if r == row {
+			e.rows = append(e.rows[:i], e.rows[i+1:]...)
+			break
+		}
+	}
+
+	// Regroup in case the removed row anchored the group
+	for _, r := range e.rows[min(1, len(e.rows)):] {
+		r.active.SetGroup(e.rows[0].active)
+	}
+	if wasActive && len(e.rows) > 0 {
+		e.rows[0].active.SetActive(true)
+	}
+}
+
+// Endpoints returns the edited endpoints and the name of the active one.
+// Rows with an empty URL are skipped; an invalid URL or header is
+// highlighted and reported as an error.
+func (e *EndpointsEditor) Endpoints() ([]config.Endpoint, string, error) {
+	var endpoints []config.Endpoint
+	active := ""
+	used := make(map[string]bool)
+
+	for i, row := range e.rows {
+		if strings.TrimSpace(row.url.Text()) == "" {
+			continue
+		}
+
+		url, err := config.NormalizeEndpointURL(row.url.Text())
+		if err != nil {
+			row.url.AddCSSClass("error")
+			row.url.GrabFocus()
+			return nil, "", err
+		}
+
+		start, end := row.buffer.Bounds()
+		headers, err := config.ParseHeaders(row.buffer.Text(start, end, false))
+		if err != nil {
+			row.headers.AddCSSClass("error")
+			row.headers.GrabFocus()
+			return nil, "", err
+		}
+
+		name := uniqueEndpointName(strings.TrimSpace(row.name.Text()), i+1, used)
+		used[name] = true
+		endpoints = append(endpoints, config.Endpoint{Name: name, URL: url, Headers: headers})
+
+		if row.active.Active() {
+			active = name
+		}
+	}
+
+	if active == "" && len(endpoints) > 0 {
+		active = endpoints[0].Name
+	}
+	return endpoints, active, nil
+}
+
+// uniqueEndpointName returns name, or a numbered default when it is empty,
+// with a suffix added if it is already used.
+func uniqueEndpointName(name string, index int, used map[string]bool) string {
+	if name == "" {
+		name = fmt.Sprintf(i18n.T("Server %d"), index)
+	}
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s (%d)", name, n)
+	}
+	return unique
+}
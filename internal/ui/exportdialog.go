@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// exportFormatNames returns the display names of store.ExportFormats.
+func exportFormatNames() []string {
+	return []string{
+		i18n.T("Markdown (.md)"),
+		i18n.T("JSON (.json)"),
+		i18n.T("Plain text (.txt)"),
+		i18n.T("OpenAI fine-tuning (.jsonl)"),
+		i18n.T("ShareGPT fine-tuning (.jsonl)"),
+	}
+}
+
+// ExportDialog lets the user export one chat or all chats to a file.
+type ExportDialog struct {
+	*adw.Window
+
+	// UI components
+	scopeDropdown  *gtk.DropDown
+	formatDropdown *gtk.DropDown
+	hint           *gtk.Label
+	trainingBox    *gtk.Box
+	bookmarkedOnly *gtk.CheckButton
+	ratedUpOnly    *gtk.CheckButton
+	noRatedDown    *gtk.CheckButton
+	noSystem       *gtk.CheckButton
+
+	// Data
+	db           *store.DB
+	chat         *store.Chat // nil when exporting all chats
+	parent       *gtk.Window
+	nameTemplate string // File name of a chat, see store.TemplateExportFileName
+	frontMatter  bool   // Start Markdown exports of one chat with front matter
+
+	// Callbacks
+	onExported func(path string, format store.ExportFormat, chatIDs []int64)
+	onError    func(error)
+}
+
+// NewExportDialog creates a new export dialog. When chat is nil, all chats
+// are exported.
+func NewExportDialog(parent *gtk.Window, db *store.DB, chat *store.Chat) *ExportDialog {
+	d := &ExportDialog{
+		db:     db,
+		chat:   chat,
+		parent: parent,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Export"))
+	d.SetModal(true)
+	d.SetDefaultSize(400, 280)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+
+	return d
+}
+
+func (d *ExportDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(gtk.NewLabel(i18n.T("Export")))
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	// === Scope ===
+	scopeLabel := gtk.NewLabel(i18n.T("Export:"))
+	scopeLabel.SetXAlign(0)
+	scopeLabel.AddCSSClass("heading")
+	content.Append(scopeLabel)
+
+	scopes := []string{i18n.T("All chats")}
+	if d.chat != nil {
+		scopes = []string{fmt.Sprintf(i18n.T("This chat (%s)"), truncatePreview(d.chat.Title, 30)), i18n.T("All chats")}
+	}
+	d.scopeDropdown = gtk.NewDropDown(gtk.NewStringList(scopes), nil)
+	d.scopeDropdown.SetSensitive(len(scopes) > 1)
+	content.Append(d.scopeDropdown)
+
+	// === Format ===
+	formatLabel := gtk.NewLabel(i18n.T("Format:"))
+	formatLabel.SetXAlign(0)
+	formatLabel.SetMarginTop(8)
+	formatLabel.AddCSSClass("heading")
+	content.Append(formatLabel)
+
+	d.formatDropdown = gtk.NewDropDown(gtk.NewStringList(exportFormatNames()), nil)
+	d.formatDropdown.NotifyProperty("selected", d.updateFormatOptions)
+	content.Append(d.formatDropdown)
+
+	d.hint = gtk.NewLabel("")
+	d.hint.SetXAlign(0)
+	d.hint.SetWrap(true)
+	d.hint.AddCSSClass("dim-label")
+	d.hint.AddCSSClass("caption")
+	content.Append(d.hint)
+
+	// === Training data filters ===
+	d.trainingBox = gtk.NewBox(gtk.OrientationVertical, 4)
+	d.bookmarkedOnly = gtk.NewCheckButtonWithLabel(i18n.T("Only bookmarked responses"))
+	d.trainingBox.Append(d.bookmarkedOnly)
+	d.ratedUpOnly = gtk.NewCheckButtonWithLabel(i18n.T("Only responses rated as good"))
+	d.trainingBox.Append(d.ratedUpOnly)
+	d.noRatedDown = gtk.NewCheckButtonWithLabel(i18n.T("Leave out responses rated as bad"))
+	d.noRatedDown.SetActive(true)
+	d.trainingBox.Append(d.noRatedDown)
+	d.noSystem = gtk.NewCheckButtonWithLabel(i18n.T("Leave out system prompts"))
+	d.trainingBox.Append(d.noSystem)
+	content.Append(d.trainingBox)
+
+	// === Buttons ===
+	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttonBox.SetHAlign(gtk.AlignEnd)
+	buttonBox.SetMarginTop(16)
+
+	cancelBtn := gtk.NewButton()
+	cancelBtn.SetLabel(i18n.T("Cancel"))
+	cancelBtn.ConnectClicked(func() {
+		d.Close()
+	})
+	buttonBox.Append(cancelBtn)
+
+	exportBtn := gtk.NewButton()
+	exportBtn.SetLabel(i18n.T("Export…"))
+	exportBtn.AddCSSClass("suggested-action")
+	exportBtn.ConnectClicked(d.onExportClicked)
+	buttonBox.Append(exportBtn)
+
+	content.Append(buttonBox)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(content)
+
+	d.SetContent(toolbarView)
+
+	d.updateFormatOptions()
+}
+
+// updateFormatOptions describes the chosen format and shows the filters of
+// training data formats.
+func (d *ExportDialog) updateFormatOptions() {
+	training := d.selectedFormat().IsTraining()
+	if training {
+		d.hint.SetText(i18n.T("One conversation per line, with the text of each message, for fine-tuning models."))
+	} else {
+		d.hint.SetText(i18n.T("Includes timestamps, the model used and attachment names."))
+	}
+	d.trainingBox.SetVisible(training)
+}
+
+// trainingOptions returns the chosen training data filters.
+func (d *ExportDialog) trainingOptions() store.TrainingOptions {
+	return store.TrainingOptions{
+		BookmarkedOnly: d.bookmarkedOnly.Active(),
+		RatedUpOnly:    d.ratedUpOnly.Active(),
+		NoRatedDown:    d.noRatedDown.Active(),
+		NoSystem:       d.noSystem.Active(),
+	}
+}
+
+// selectedFormat returns the format chosen in the dropdown.
+func (d *ExportDialog) selectedFormat() store.ExportFormat {
+	idx := int(d.formatDropdown.Selected())
+	if idx < len(store.ExportFormats) {
+		return store.ExportFormats[idx]
+	}
+	return store.ExportMarkdown
+}
+
+// selectedChatIDs returns the chats to export; nil means all chats.
+func (d *ExportDialog) selectedChatIDs() []int64 {
+	if d.chat != nil && d.scopeDropdown.Selected() == 0 {
+		return []int64{d.chat.ID}
+	}
+	return nil
+}
+
+func (d *ExportDialog) onExportClicked() {
+	format := d.selectedFormat()
+	chatIDs := d.selectedChatIDs()
+	opts := d.trainingOptions()
+
+	name := store.ExportFileName("guanaco-chats", format)
+	if chatIDs != nil {
+		name = store.TemplateExportFileName(d.nameTemplate, d.chat, format)
+	}
+
+	chooser := gtk.NewFileChooserNative(
+		i18n.T("Export Chats"),
+		d.parent,
+		gtk.FileChooserActionSave,
+		i18n.T("Save"),
+		i18n.T("Cancel"),
+	)
+	chooser.SetCurrentName(name)
+
+	chooser.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := chooser.File(); file != nil && file.Path() != "" {
+				d.export(file.Path(), chatIDs, format, opts)
+			}
+		}
+		chooser.Destroy()
+	})
+
+	d.Close()
+	chooser.Show()
+}
+
+// export writes the selected chats to path and reports the result.
+func (d *ExportDialog) export(path string, chatIDs []int64, format store.ExportFormat, opts store.TrainingOptions) {
+	err := writeExportFile(d.db, path, chatIDs, format, opts, d.frontMatter)
+	if err != nil {
+		logger.Error("Failed to export chats", "path", path, "error", err)
+		if d.onError != nil {
+			d.onError(err)
+		}
+		return
+	}
+
+	logger.Info("Chats exported", "path", path, "format", format)
+	if d.onExported != nil {
+		d.onExported(path, format, chatIDs)
+	}
+}
+
+// writeExportFile loads the chats and writes them to path. opts filter
+// training data formats. With frontMatter, a Markdown export of one chat
+// starts with YAML front matter.
+func writeExportFile(db *store.DB, path string, chatIDs []int64, format store.ExportFormat, opts store.TrainingOptions, frontMatter bool) error {
+	if db == nil {
+		return fmt.Errorf("no database available")
+	}
+
+	chats, err := db.LoadExport(chatIDs)
+	if err != nil {
+		return fmt.Errorf("failed to load chats: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	switch {
+	case format.IsTraining():
+		err = store.WriteTrainingExport(f, chats, format, opts)
+	case frontMatter && format == store.ExportMarkdown && len(chats) == 1:
+		if _, err = f.WriteString(store.FrontMatter(chats[0])); err == nil {
+			err = store.WriteExport(f, chats, format)
+		}
+	default:
+		err = store.WriteExport(f, chats, format)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		if errors.Is(err, store.ErrNoTrainingData) {
+			return errors.New(i18n.T("none of the chats has responses to export"))
+		}
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return f.Close()
+}
+
+// SetFileOptions sets the template of the file name of an exported chat,
+// and whether Markdown exports of it start with front matter.
+func (d *ExportDialog) SetFileOptions(nameTemplate string, frontMatter bool) {
+	d.nameTemplate = nameTemplate
+	d.frontMatter = frontMatter
+}
+
+// OnExported sets the callback for when the export file has been written.
+// chatIDs is nil when all chats were exported.
+func (d *ExportDialog) OnExported(callback func(path string, format store.ExportFormat, chatIDs []int64)) {
+	d.onExported = callback
+}
+
+// OnError sets the callback for when the export fails.
+func (d *ExportDialog) OnError(callback func(error)) {
+	d.onError = callback
+}
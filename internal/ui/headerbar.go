@@ -14,12 +14,26 @@ type HeaderBar struct {
 	// UI components
 	toggleSidebarBtn *gtk.Button
 	downloadButton   *gtk.Button
+	modelsButton     *gtk.Button
 	settingsButton   *gtk.Button
+	workDirButton    *gtk.Button
+	multiAgentButton *gtk.Button
+	compareButton    *gtk.Button
+	documentsButton  *gtk.ToggleButton
+	quitButton       *gtk.Button
+	readAloudButton  *gtk.Button
 
 	// Callbacks
 	onToggleSidebar func()
 	onDownloadModel func()
+	onManageModels  func()
 	onChatSettings  func()
+	onWorkDir       func()
+	onMultiAgent    func()
+	onCompare       func()
+	onDocuments     func(bool)
+	onQuit          func()
+	onMuteReadAloud func()
 }
 
 // NewHeaderBar creates a new header bar.
@@ -36,6 +50,18 @@ func NewHeaderBar() *HeaderBar {
 }
 
 func (hb *HeaderBar) setupUI() {
+	// Quit button, shown while Guanaco runs in the background (end/right side)
+	hb.quitButton = gtk.NewButton()
+	hb.quitButton.SetIconName("application-exit-symbolic")
+	hb.quitButton.SetTooltipText(i18n.T("Quit Completely"))
+	hb.quitButton.SetVisible(false)
+	hb.quitButton.ConnectClicked(func() {
+		if hb.onQuit != nil {
+			hb.onQuit()
+		}
+	})
+	hb.PackEnd(hb.quitButton)
+
 	// Toggle sidebar button (start/left side)
 	hb.toggleSidebarBtn = gtk.NewButton()
 	hb.toggleSidebarBtn.SetIconName("sidebar-show-symbolic")
@@ -58,6 +84,17 @@ func (hb *HeaderBar) setupUI() {
 	})
 	hb.PackEnd(hb.downloadButton)
 
+	// Installed models button
+	hb.modelsButton = gtk.NewButton()
+	hb.modelsButton.SetIconName("drive-harddisk-symbolic")
+	hb.modelsButton.SetTooltipText(i18n.T("Manage Models"))
+	hb.modelsButton.ConnectClicked(func() {
+		if hb.onManageModels != nil {
+			hb.onManageModels()
+		}
+	})
+	hb.PackEnd(hb.modelsButton)
+
 	// Chat settings button (system prompt)
 	hb.settingsButton = gtk.NewButton()
 	hb.settingsButton.SetIconName("emblem-system-symbolic")
@@ -68,6 +105,62 @@ func (hb *HeaderBar) setupUI() {
 		}
 	})
 	hb.PackEnd(hb.settingsButton)
+
+	// Working folder button (files the model may read)
+	hb.workDirButton = gtk.NewButton()
+	hb.workDirButton.SetIconName("folder-open-symbolic")
+	hb.workDirButton.SetTooltipText(i18n.T("Working Folder"))
+	hb.workDirButton.ConnectClicked(func() {
+		if hb.onWorkDir != nil {
+			hb.onWorkDir()
+		}
+	})
+	hb.PackEnd(hb.workDirButton)
+
+	// Multi-agent conversation button (experimental, hidden unless enabled)
+	hb.multiAgentButton = gtk.NewButton()
+	hb.multiAgentButton.SetVisible(false)
+	hb.multiAgentButton.SetIconName("system-users-symbolic")
+	hb.multiAgentButton.SetTooltipText(i18n.T("Multi-Agent Conversation (Experimental)"))
+	hb.multiAgentButton.ConnectClicked(func() {
+		if hb.onMultiAgent != nil {
+			hb.onMultiAgent()
+		}
+	})
+	hb.PackEnd(hb.multiAgentButton)
+
+	// Model comparison button
+	hb.compareButton = gtk.NewButton()
+	hb.compareButton.SetIconName("view-dual-symbolic")
+	hb.compareButton.SetTooltipText(i18n.T("Compare Models"))
+	hb.compareButton.ConnectClicked(func() {
+		if hb.onCompare != nil {
+			hb.onCompare()
+		}
+	})
+	hb.PackEnd(hb.compareButton)
+
+	// Chat documents panel toggle
+	hb.documentsButton = gtk.NewToggleButton()
+	hb.documentsButton.SetIconName("folder-documents-symbolic")
+	hb.documentsButton.SetTooltipText(i18n.T("Chat Documents"))
+	hb.documentsButton.ConnectToggled(func() {
+		if hb.onDocuments != nil {
+			hb.onDocuments(hb.documentsButton.Active())
+		}
+	})
+	hb.PackEnd(hb.documentsButton)
+
+	// Mutes reading responses aloud in the chat, shown while it is on
+	hb.readAloudButton = gtk.NewButton()
+	hb.readAloudButton.SetVisible(false)
+	hb.readAloudButton.ConnectClicked(func() {
+		if hb.onMuteReadAloud != nil {
+			hb.onMuteReadAloud()
+		}
+	})
+	hb.PackEnd(hb.readAloudButton)
+	hb.SetReadAloud(false, false)
 }
 
 // OnDownloadModel sets the callback for when the download button is clicked.
@@ -75,12 +168,71 @@ func (hb *HeaderBar) OnDownloadModel(callback func()) {
 	hb.onDownloadModel = callback
 }
 
+// OnManageModels sets the callback for when the models button is clicked.
+func (hb *HeaderBar) OnManageModels(callback func()) {
+	hb.onManageModels = callback
+}
+
 // OnChatSettings sets the callback for when the settings button is clicked.
 func (hb *HeaderBar) OnChatSettings(callback func()) {
 	hb.onChatSettings = callback
 }
 
+// OnWorkDir sets the callback for when the working folder button is clicked.
+func (hb *HeaderBar) OnWorkDir(callback func()) {
+	hb.onWorkDir = callback
+}
+
 // OnToggleSidebar sets the callback for when the toggle sidebar button is clicked.
 func (hb *HeaderBar) OnToggleSidebar(callback func()) {
 	hb.onToggleSidebar = callback
 }
+
+// OnDocuments sets the callback for when the documents button is toggled.
+func (hb *HeaderBar) OnDocuments(callback func(shown bool)) {
+	hb.onDocuments = callback
+}
+
+// OnMultiAgent sets the callback for when the multi-agent button is clicked.
+func (hb *HeaderBar) OnMultiAgent(callback func()) {
+	hb.onMultiAgent = callback
+}
+
+// OnCompare sets the callback for when the model comparison button is clicked.
+func (hb *HeaderBar) OnCompare(callback func()) {
+	hb.onCompare = callback
+}
+
+// SetMultiAgentVisible shows or hides the multi-agent conversation button.
+func (hb *HeaderBar) SetMultiAgentVisible(visible bool) {
+	hb.multiAgentButton.SetVisible(visible)
+}
+
+// SetQuitVisible shows or hides the button quitting Guanaco completely.
+func (hb *HeaderBar) SetQuitVisible(visible bool) {
+	hb.quitButton.SetVisible(visible)
+}
+
+// OnQuit sets the callback for when the quit button is clicked.
+func (hb *HeaderBar) OnQuit(callback func()) {
+	hb.onQuit = callback
+}
+
+// SetReadAloud shows the button muting reading aloud when enabled, with
+// the icon of a muted speaker when the chat is muted.
+func (hb *HeaderBar) SetReadAloud(enabled, muted bool) {
+	hb.readAloudButton.SetVisible(enabled)
+	if muted {
+		hb.readAloudButton.SetIconName("audio-volume-muted-symbolic")
+		hb.readAloudButton.SetTooltipText(i18n.T("Read Responses Aloud in This Chat"))
+	} else {
+		hb.readAloudButton.SetIconName("audio-volume-high-symbolic")
+		hb.readAloudButton.SetTooltipText(i18n.T("Mute Responses in This Chat"))
+	}
+}
+
+// OnMuteReadAloud sets the callback for when the read aloud button is
+// clicked.
+func (hb *HeaderBar) OnMuteReadAloud(callback func()) {
+	hb.onMuteReadAloud = callback
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/rag"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// With "Summarize history beyond" set in the settings, the older turns of
+// a long chat are condensed by the model into a summary before a message
+// is answered. The summary is stored with the chat and sent from then on
+// in place of the messages it covers, which stay in the transcript. Once
+// the history grows past the budget again, the summary is extended with
+// the next older turns. The most recent turns are always sent verbatim.
+
+// summaryEnd returns the number of leading messages covered by summary,
+// or 0 when there is none or the last message it covers is gone, as after
+// editing or deleting it.
+func summaryEnd(summary *store.HistorySummary, messages []*store.Message) int {
+	if summary == nil {
+		return 0
+	}
+	for i, msg := range messages {
+		if msg.ID == summary.ThroughID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// messagesToSummarize returns the messages a new summary should cover,
+// or nil when the history outside summary fits within budget tokens.
+// The last keepRecent messages are never summarized.
+func messagesToSummarize(messages []*store.Message, summary *store.HistorySummary, budget, keepRecent int) []*store.Message {
+	if budget <= 0 {
+		return nil
+	}
+	end := summaryEnd(summary, messages)
+
+	var uncovered []*store.Message
+	tokens := 0
+	if end > 0 {
+		tokens = rag.EstimateTokens(summary.Content)
+	}
+	for _, msg := range messages[end:] {
+		if msg.ModelSwitch {
+			continue // Shown in the transcript only
+		}
+		uncovered = append(uncovered, msg)
+		tokens += rag.EstimateTokens(msg.Content)
+	}
+	if tokens <= budget || len(uncovered) <= keepRecent {
+		return nil
+	}
+	return uncovered[:len(uncovered)-keepRecent]
+}
+
+// historySummaryPrompt asks for a summary of older, extending previous
+// when the conversation was already summarized.
+func historySummaryPrompt(previous string, older []ollama.Message) string {
+	var builder strings.Builder
+	builder.WriteString("Summarize the following conversation in a concise paragraph. Preserve names, facts, decisions and open questions. Respond with ONLY the summary.\n\n")
+	if previous != "" {
+		builder.WriteString("Summary of the earlier conversation:\n")
+		builder.WriteString(previous)
+		builder.WriteString("\n\n")
+	}
+	builder.WriteString(formatTranscript(older))
+	return builder.String()
+}
+
+// summaryMessage is the system message sent in place of the summarized turns.
+func summaryMessage(content string) ollama.Message {
+	return ollama.Message{
+		Role:    "system",
+		Content: "Summary of the earlier conversation:\n" + content,
+	}
+}
+
+// historyToSummarize returns the chat's current summary and the messages
+// to add to it before answering, if any.
+func (cv *ChatView) historyToSummarize() (*store.HistorySummary, []*store.Message) {
+	if cv.db == nil || cv.currentChat == nil || cv.appConfig == nil || cv.appConfig.SummarizeTokens <= 0 {
+		return nil, nil
+	}
+	messages, err := cv.db.GetMessages(cv.currentChat.ID)
+	if err != nil {
+		return nil, nil
+	}
+	summary, err := cv.db.GetHistorySummary(cv.currentChat.ID)
+	if err != nil {
+		logger.Error("Failed to load history summary", "error", err)
+		return nil, nil
+	}
+	if summaryEnd(summary, messages) == 0 {
+		summary = nil
+	}
+	return summary, messagesToSummarize(messages, summary, cv.appConfig.SummarizeTokens, summaryKeepRecent)
+}
+
+// summarizeAndStream extends the chat's history summary when the history
+// is over budget, then starts the response. A failed summary is logged
+// and the history is sent as it is.
+func (cv *ChatView) summarizeAndStream(data attachmentData) {
+	if cv.historyMode != historyFull {
+		cv.startStreaming(data) // Trimmed or summarized on request
+		return
+	}
+	summary, older := cv.historyToSummarize()
+	if len(older) == 0 {
+		cv.startStreaming(data)
+		return
+	}
+
+	chatID := cv.currentChat.ID
+	model := cv.appConfig.EffectiveUtilityModel(cv.currentModel)
+	previous := ""
+	if summary != nil {
+		previous = summary.Content
+	}
+	transcript := make([]ollama.Message, len(older))
+	for i, msg := range older {
+		transcript[i] = ollama.Message{Role: string(msg.Role), Content: msg.Content}
+	}
+	throughID := older[len(older)-1].ID
+
+	logger.Info("Summarizing history", "chatID", chatID, "messages", len(older))
+	// Stop cancels the summary, and then the response isn't requested
+	ctx, cancel := context.WithTimeout(context.Background(), streamingTimeout)
+	cv.streamCancel = cancel
+	cv.setStreaming(true)
+	cv.inputArea.SetStreamingMode(true)
+	cv.showStatus(i18n.T("Summarizing earlier messages…"), -1)
+
+	go func() {
+		var content strings.Builder
+		err := cv.streamHandler.Chat(ctx, &ollama.ChatRequest{
+			Model:    model,
+			Messages: []ollama.Message{{Role: "user", Content: historySummaryPrompt(previous, transcript)}},
+		}, func(token string) {
+			content.WriteString(token)
+		})
+		if err == nil && strings.TrimSpace(content.String()) != "" {
+			err = cv.db.SaveHistorySummary(&store.HistorySummary{
+				ChatID:    chatID,
+				Content:   strings.TrimSpace(content.String()),
+				ThroughID: throughID,
+			})
+		}
+
+		glib.IdleAdd(func() {
+			stopped := ctx.Err() != nil
+			cancel()
+			cv.streamCancel = nil
+			cv.hideStatus()
+			cv.setStreaming(false)
+			cv.inputArea.SetStreamingMode(false)
+			// Stopped, timed out or the window closed
+			if stopped {
+				logger.Info("History summary stopped", "chatID", chatID)
+				cv.inputArea.Focus()
+				return
+			}
+			if err != nil {
+				logger.Error("Failed to summarize history", "error", err)
+			}
+			cv.startStreaming(data)
+		})
+	}()
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+func historyMessages(n int) []*store.Message {
+	messages := make([]*store.Message, n)
+	for i := range messages {
+		role := store.RoleUser
+		if i%2 == 1 {
+			role = store.RoleAssistant
+		}
+		messages[i] = &store.Message{ID: int64(i + 1), Role: role, Content: strings.Repeat("word ", 100)}
+	}
+	return messages
+}
+
+func TestSummaryEnd(t *testing.T) {
+	messages := historyMessages(6)
+
+	if got := summaryEnd(nil, messages); got != 0 {
+		t.Errorf("summaryEnd(nil) = %d, want 0", got)
+	}
+	if got := summaryEnd(&store.HistorySummary{ThroughID: 3}, messages); got != 3 {
+		t.Errorf("summaryEnd(through 3) = %d, want 3", got)
+	}
+	if got := summaryEnd(&store.HistorySummary{ThroughID: 42}, messages); got != 0 {
+		t.Errorf("summaryEnd(missing message) = %d, want 0", got)
+	}
+}
+
+func TestMessagesToSummarize(t *testing.T) {
+	messages := historyMessages(10)
+
+	if got := messagesToSummarize(messages, nil, 0, 4); got != nil {
+		t.Errorf("disabled budget summarized %d messages", len(got))
+	}
+	if got := messagesToSummarize(messages, nil, 1000000, 4); got != nil {
+		t.Errorf("history within budget summarized %d messages", len(got))
+	}
+
+	got := messagesToSummarize(messages, nil, 100, 4)
+	if len(got) != 6 || got[0].ID != 1 || got[5].ID != 6 {
+		t.Fatalf("messagesToSummarize() = %d messages, want messages 1-6", len(got))
+	}
+
+	// Messages already covered by the summary are left out
+	summary := &store.HistorySummary{Content: "short", ThroughID: 4}
+	got = messagesToSummarize(messages, summary, 100, 4)
+	if len(got) != 2 || got[0].ID != 5 || got[1].ID != 6 {
+		t.Errorf("messagesToSummarize(summary through 4) = %d messages, want messages 5-6", len(got))
+	}
+
+	// Never summarizes the recent messages
+	if got := messagesToSummarize(messages[:4], nil, 1, 4); got != nil {
+		t.Errorf("summarized %d of the recent messages", len(got))
+	}
+}
+
+func TestMessagesToSummarize_SkipsModelSwitches(t *testing.T) {
+	messages := historyMessages(6)
+	messages[2].ModelSwitch = true
+
+	for _, msg := range messagesToSummarize(messages, nil, 10, 2) {
+		if msg.ModelSwitch {
+			t.Errorf("model switch notice %d was summarized", msg.ID)
+		}
+	}
+}
+
+func TestHistorySummaryPrompt(t *testing.T) {
+	older := []ollama.Message{{Role: "user", Content: "My cat is called Miso"}}
+
+	prompt := historySummaryPrompt("", older)
+	if !strings.Contains(prompt, "user: My cat is called Miso") {
+		t.Errorf("prompt is missing the transcript: %q", prompt)
+	}
+	if strings.Contains(prompt, "earlier conversation") {
+		t.Errorf("prompt without a previous summary mentions one: %q", prompt)
+	}
+
+	prompt = historySummaryPrompt("The user lives in Lima.", older)
+	if !strings.Contains(prompt, "The user lives in Lima.") {
+		t.Errorf("prompt is missing the previous summary: %q", prompt)
+	}
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"context"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/hooks"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// runHooks runs the commands configured for event in the background, with
+// payload on their stdin. Failures are only logged: hooks must never get in
+// the way of the app.
+func runHooks(cfg *config.AppConfig, event string, payload any) {
+	if cfg == nil {
+		return
+	}
+	for _, command := range cfg.HookCommands(event) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), hooks.Timeout)
+			defer cancel()
+
+			if err := hooks.Execute(ctx, command, event, payload); err != nil {
+				logger.Error("Hook failed", "event", event, "command", command, "error", err)
+				return
+			}
+			logger.Info("Hook ran", "event", event, "command", command)
+		}()
+	}
+}
@@ -0,0 +1,289 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// importSourceName returns the name of the app an export comes from.
+func importSourceName(source store.ImportSource) string {
+	if source == store.ImportOpenWebUI {
+		return "Open WebUI"
+	}
+	return "ChatGPT"
+}
+
+// importConflicts lists the choices for chats imported before, in the
+// order of importConflictNames.
+var importConflicts = []store.ImportConflict{store.ImportSkip, store.ImportReplace, store.ImportKeepBoth}
+
+// importConflictNames returns the display names of importConflicts.
+func importConflictNames() []string {
+	return []string{
+		i18n.T("Skip them"),
+		i18n.T("Replace them"),
+		i18n.T("Import them again"),
+	}
+}
+
+// ImportDialog imports the chats of a ChatGPT or Open WebUI export file.
+// The file is read when the dialog opens, and the chats are saved with a
+// progress bar once the user confirms.
+type ImportDialog struct {
+	*adw.Window
+
+	// UI components
+	status           *gtk.Label
+	modelBox         *gtk.Box
+	modelDropdown    *gtk.DropDown
+	conflictBox      *gtk.Box
+	conflictLabel    *gtk.Label
+	conflictDropdown *gtk.DropDown
+	progress         *gtk.ProgressBar
+	cancelBtn        *gtk.Button
+	importBtn        *gtk.Button
+
+	// Data
+	db           *store.DB
+	models       []string
+	defaultModel string
+	chats        []store.ImportedChat
+
+	// Callbacks
+	onImported func(store.ImportResult)
+	onError    func(error)
+}
+
+// NewImportDialog creates a dialog importing the chats of the export at
+// path. Chats whose model doesn't run on Ollama get one of models, with
+// defaultModel chosen at first.
+func NewImportDialog(parent *gtk.Window, db *store.DB, path string, models []string, defaultModel string) *ImportDialog {
+	d := &ImportDialog{
+		db:           db,
+		models:       models,
+		defaultModel: defaultModel,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Import Chats"))
+	d.SetModal(true)
+	d.SetDefaultSize(400, 260)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+	d.read(path)
+
+	return d
+}
+
+func (d *ImportDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(gtk.NewLabel(i18n.T("Import Chats")))
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	d.status = gtk.NewLabel(i18n.T("Reading the export…"))
+	d.status.SetXAlign(0)
+	d.status.SetWrap(true)
+	content.Append(d.status)
+
+	// === Model for chats from apps whose models don't run on Ollama ===
+	d.modelBox = gtk.NewBox(gtk.OrientationVertical, 4)
+	d.modelBox.SetVisible(false)
+	modelLabel := gtk.NewLabel(i18n.T("Continue these chats with:"))
+	modelLabel.SetXAlign(0)
+	modelLabel.SetMarginTop(8)
+	modelLabel.AddCSSClass("heading")
+	d.modelBox.Append(modelLabel)
+	d.modelDropdown = gtk.NewDropDown(gtk.NewStringList(d.models), nil)
+	if idx := slices.Index(d.models, d.defaultModel); idx >= 0 {
+		d.modelDropdown.SetSelected(uint(idx))
+	}
+	d.modelBox.Append(d.modelDropdown)
+	content.Append(d.modelBox)
+
+	// === Chats imported before ===
+	d.conflictBox = gtk.NewBox(gtk.OrientationVertical, 4)
+	d.conflictBox.SetVisible(false)
+	d.conflictLabel = gtk.NewLabel("")
+	d.conflictLabel.SetXAlign(0)
+	d.conflictLabel.SetWrap(true)
+	d.conflictLabel.SetMarginTop(8)
+	d.conflictLabel.AddCSSClass("heading")
+	d.conflictBox.Append(d.conflictLabel)
+	d.conflictDropdown = gtk.NewDropDown(gtk.NewStringList(importConflictNames()), nil)
+	d.conflictBox.Append(d.conflictDropdown)
+	content.Append(d.conflictBox)
+
+	d.progress = gtk.NewProgressBar()
+	d.progress.SetShowText(true)
+	d.progress.SetMarginTop(8)
+	d.progress.SetVisible(false)
+	content.Append(d.progress)
+
+	// === Buttons ===
+	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttonBox.SetHAlign(gtk.AlignEnd)
+	buttonBox.SetMarginTop(16)
+
+	d.cancelBtn = gtk.NewButton()
+	d.cancelBtn.SetLabel(i18n.T("Cancel"))
+	d.cancelBtn.ConnectClicked(func() {
+		d.Close()
+	})
+	buttonBox.Append(d.cancelBtn)
+
+	d.importBtn = gtk.NewButton()
+	d.importBtn.SetLabel(i18n.T("Import"))
+	d.importBtn.AddCSSClass("suggested-action")
+	d.importBtn.SetSensitive(false)
+	d.importBtn.ConnectClicked(d.onImportClicked)
+	buttonBox.Append(d.importBtn)
+
+	content.Append(buttonBox)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(content)
+
+	d.SetContent(toolbarView)
+}
+
+// read parses the export at path in the background, and counts the chats
+// that were imported before.
+func (d *ImportDialog) read(path string) {
+	go func() {
+		source, chats, err := readImportFile(path)
+		imported := 0
+		if err == nil {
+			imported, err = d.db.CountImported(chats)
+		}
+
+		glib.IdleAdd(func() {
+			if err != nil {
+				logger.Error("Failed to read import", "path", path, "error", err)
+				d.status.SetText(err.Error())
+				return
+			}
+			d.showChats(source, chats, imported)
+		})
+	}()
+}
+
+// readImportFile opens and parses the export at path.
+func readImportFile(path string) (store.ImportSource, []store.ImportedChat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	source, chats, err := store.ParseImport(f)
+	switch {
+	case errors.Is(err, store.ErrUnknownImport):
+		return "", nil, errors.New(i18n.T("This file is not a ChatGPT or Open WebUI export"))
+	case err != nil:
+		return "", nil, err
+	case len(chats) == 0:
+		return "", nil, errors.New(i18n.T("The export has no chats with messages"))
+	}
+	return source, chats, nil
+}
+
+// showChats tells what was found in the export and asks what to do with
+// the chats imported before, if any.
+func (d *ImportDialog) showChats(source store.ImportSource, chats []store.ImportedChat, imported int) {
+	d.chats = chats
+
+	if len(chats) == 1 {
+		d.status.SetText(fmt.Sprintf(i18n.T("Found 1 chat from %s."), importSourceName(source)))
+	} else {
+		d.status.SetText(fmt.Sprintf(i18n.T("Found %d chats from %s."), len(chats), importSourceName(source)))
+	}
+
+	needsModel := slices.ContainsFunc(chats, func(chat store.ImportedChat) bool {
+		return chat.Model == ""
+	})
+	d.modelBox.SetVisible(needsModel && len(d.models) > 0)
+
+	if imported > 0 {
+		d.conflictLabel.SetText(fmt.Sprintf(i18n.T("%d of them were imported before:"), imported))
+		d.conflictBox.SetVisible(true)
+	}
+
+	d.importBtn.SetSensitive(true)
+}
+
+// onImportClicked saves the chats in the background, moving the progress
+// bar after each one.
+func (d *ImportDialog) onImportClicked() {
+	opts := store.ImportOptions{
+		Conflict: importConflicts[d.conflictDropdown.Selected()],
+		Model:    d.defaultModel,
+	}
+	if idx := int(d.modelDropdown.Selected()); idx < len(d.models) {
+		opts.Model = d.models[idx]
+	}
+
+	d.importBtn.SetSensitive(false)
+	d.cancelBtn.SetSensitive(false)
+	d.modelBox.SetSensitive(false)
+	d.conflictBox.SetSensitive(false)
+	d.SetDeletable(false)
+	d.progress.SetVisible(true)
+
+	chats := d.chats
+	go func() {
+		result, err := d.db.ImportChats(chats, opts, func(done int) {
+			glib.IdleAdd(func() {
+				d.progress.SetFraction(float64(done) / float64(len(chats)))
+				d.progress.SetText(fmt.Sprintf(i18n.T("%d of %d"), done, len(chats)))
+			})
+		})
+
+		glib.IdleAdd(func() {
+			d.SetDeletable(true)
+			d.Close()
+			if err != nil {
+				logger.Error("Failed to import chats", "imported", result.Imported, "error", err)
+				if d.onError != nil {
+					d.onError(err)
+				}
+				return
+			}
+			logger.Info("Chats imported", "imported", result.Imported, "replaced", result.Replaced, "skipped", result.Skipped)
+			if d.onImported != nil {
+				d.onImported(result)
+			}
+		})
+	}()
+}
+
+// OnImported sets the callback for when the chats have been imported.
+func (d *ImportDialog) OnImported(callback func(store.ImportResult)) {
+	d.onImported = callback
+}
+
+// OnError sets the callback for when the chats can't be saved. Exports
+// that can't be read are reported in the dialog.
+func (d *ImportDialog) OnError(callback func(error)) {
+	d.onError = callback
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/storo/guanaco/internal/i18n"
 	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/speech"
+	"github.com/storo/guanaco/internal/store"
 )
 
 // InputArea is the chat input widget with expandable text entry.
@@ -23,6 +25,7 @@ type InputArea struct {
 	textView     *gtk.TextView
 	sendButton   *gtk.Button
 	stopButton   *gtk.Button
+	editButton   *gtk.Button // Stops and takes the prompt back
 	attachButton *gtk.Button
 	scrolled     *gtk.ScrolledWindow
 
@@ -33,15 +36,38 @@ type InputArea struct {
 	models       []ollama.Model
 	currentModel string
 
+	// Template picker, shown while a "/" search is typed
+	picker          *gtk.Popover
+	pickerList      *gtk.ListBox
+	pickerMatches   []*store.PromptTemplate
+	pickerDismissed bool // Closed with Escape, stays closed until the search is cleared
+	templates       []*store.PromptTemplate
+
+	// Speech input, see setupMicButton
+	micButton     *gtk.Button
+	recorder      *speech.Recorder
+	recording     *speech.Recording // Being recorded, nil if none
+	speechCommand string
+
+	// Draft counter, see updateDraftCounter
+	draftCounter  *gtk.Label
+	contextBudget int // Tokens a prompt may use, 0 if unknown
+
 	// State
 	attachments    []*AttachmentPill
 	loadingSpinner *gtk.Spinner
 
 	// Callbacks
-	onSend         func(text string)
-	onAttach       func()
-	onStop         func()
-	onModelChanged func(string)
+	onSend            func(text string)
+	onAttach          func()
+	onStop            func()
+	onStopAndEdit     func()
+	onStopAndDiscard  func()
+	onModelChanged    func(string)
+	onModelInfo       func(string)
+	onTemplateChosen  func(*store.PromptTemplate)
+	onManageTemplates func()
+	onSpeechError     func(error)
 }
 
 // NewInputArea creates a new input area.
@@ -83,6 +109,7 @@ func (ia *InputArea) setupUI() {
 		}
 	})
 	ia.inputBox.Append(ia.attachButton)
+	ia.setupMicButton()
 
 	// Text view in scrolled window
 	ia.textView = gtk.NewTextView()
@@ -97,6 +124,9 @@ func (ia *InputArea) setupUI() {
 	// Handle key press for Ctrl+Enter to send
 	keyController := gtk.NewEventControllerKey()
 	keyController.ConnectKeyPressed(func(keyval, keycode uint, state gdk.ModifierType) bool {
+		if ia.templatePickerKey(keyval) {
+			return true
+		}
 		if keyval == gdk.KEY_Return && state&gdk.ControlMask != 0 {
 			ia.send()
 			return true
@@ -118,6 +148,8 @@ func (ia *InputArea) setupUI() {
 	buffer := ia.textView.Buffer()
 	buffer.ConnectChanged(func() {
 		ia.updateHeight()
+		ia.updateTemplatePicker()
+		ia.updateDraftCounter()
 	})
 
 	// Model selector dropdown
@@ -169,7 +201,7 @@ func (ia *InputArea) setupUI() {
 	// Stop button (hidden initially, shown during streaming)
 	ia.stopButton = gtk.NewButton()
 	ia.stopButton.SetIconName("media-playback-stop-symbolic")
-	ia.stopButton.SetTooltipText(i18n.T("Stop generation"))
+	ia.stopButton.SetTooltipText(i18n.T("Stop generation (hold or right-click to discard the response)"))
 	ia.stopButton.AddCSSClass("destructive-action")
 	ia.stopButton.AddCSSClass("circular")
 	ia.stopButton.SetVAlign(gtk.AlignEnd)
@@ -180,6 +212,45 @@ func (ia *InputArea) setupUI() {
 		}
 	})
 	ia.inputBox.Append(ia.stopButton)
+
+	// Holding or right-clicking stop also discards the partial response.
+	// The long press claims the touch so that the button isn't clicked too.
+	discard := func() {
+		if ia.onStopAndDiscard != nil {
+			ia.onStopAndDiscard()
+		}
+	}
+	longPress := gtk.NewGestureLongPress()
+	longPress.SetPropagationPhase(gtk.PhaseCapture)
+	longPress.ConnectPressed(func(x, y float64) {
+		longPress.SetState(gtk.EventSequenceClaimed)
+		discard()
+	})
+	ia.stopButton.AddController(longPress)
+
+	rightClick := gtk.NewGestureClick()
+	rightClick.SetButton(3) // GDK_BUTTON_SECONDARY
+	rightClick.ConnectPressed(func(nPress int, x, y float64) {
+		discard()
+	})
+	ia.stopButton.AddController(rightClick)
+
+	// Stop and edit button, shown during streaming next to stop
+	ia.editButton = gtk.NewButton()
+	ia.editButton.SetIconName("document-edit-symbolic")
+	ia.editButton.SetTooltipText(i18n.T("Stop and edit the prompt"))
+	ia.editButton.AddCSSClass("circular")
+	ia.editButton.SetVAlign(gtk.AlignEnd)
+	ia.editButton.SetVisible(false)
+	ia.editButton.ConnectClicked(func() {
+		if ia.onStopAndEdit != nil {
+			ia.onStopAndEdit()
+		}
+	})
+	ia.inputBox.InsertChildAfter(ia.editButton, ia.sendButton)
+
+	ia.draftCounter = ia.newDraftCounter()
+	ia.Append(ia.draftCounter)
 }
 
 func (ia *InputArea) send() {
@@ -230,10 +301,11 @@ func (ia *InputArea) GetText() string {
 	return buffer.Text(start, end, false)
 }
 
-// SetText sets the text in the input.
+// SetText sets the text in the input, with the cursor at the end.
 func (ia *InputArea) SetText(text string) {
 	buffer := ia.textView.Buffer()
 	buffer.SetText(text)
+	buffer.PlaceCursor(buffer.EndIter())
 }
 
 // AddAttachment adds an attachment pill to the input area.
@@ -309,12 +381,33 @@ func (ia *InputArea) OnStop(callback func()) {
 	ia.onStop = callback
 }
 
+// OnStopAndEdit sets the callback for when the stop and edit button is
+// clicked.
+func (ia *InputArea) OnStopAndEdit(callback func()) {
+	ia.onStopAndEdit = callback
+}
+
+// OnStopAndDiscard sets the callback for when the stop button is held or
+// right-clicked.
+func (ia *InputArea) OnStopAndDiscard(callback func()) {
+	ia.onStopAndDiscard = callback
+}
+
+// SetCanStopAndEdit shows the stop and edit button while streaming, when
+// the prompt can be taken back.
+func (ia *InputArea) SetCanStopAndEdit(can bool) {
+	ia.editButton.SetVisible(can && ia.stopButton.Visible())
+}
+
 // SetStreamingMode toggles between send and stop buttons.
 func (ia *InputArea) SetStreamingMode(streaming bool) {
 	ia.sendButton.SetVisible(!streaming)
 	ia.stopButton.SetVisible(streaming)
+	ia.editButton.SetVisible(false)
 	ia.textView.SetSensitive(!streaming)
 	ia.attachButton.SetSensitive(!streaming)
+	// A switch made now would be recorded before the answer being written
+	ia.modelButton.SetSensitive(!streaming)
 }
 
 // selectModel updates the current model and triggers callback.
@@ -343,13 +436,31 @@ func (ia *InputArea) SetModels(models []ollama.Model) {
 	for _, model := range models {
 		label := gtk.NewLabel(model.Name)
 		label.SetXAlign(0)
+		label.SetHExpand(true)
 		label.SetMarginTop(8)
 		label.SetMarginBottom(8)
 		label.SetMarginStart(12)
-		label.SetMarginEnd(12)
+
+		name := model.Name
+		infoBtn := gtk.NewButtonFromIconName("help-about-symbolic")
+		infoBtn.SetTooltipText(i18n.T("Model Information"))
+		infoBtn.SetVAlign(gtk.AlignCenter)
+		infoBtn.SetMarginEnd(6)
+		infoBtn.AddCSSClass("flat")
+		infoBtn.AddCSSClass("circular")
+		infoBtn.ConnectClicked(func() {
+			ia.modelButton.Popdown()
+			if ia.onModelInfo != nil {
+				ia.onModelInfo(name)
+			}
+		})
+
+		box := gtk.NewBox(gtk.OrientationHorizontal, 4)
+		box.Append(label)
+		box.Append(infoBtn)
 
 		row := gtk.NewListBoxRow()
-		row.SetChild(label)
+		row.SetChild(box)
 		ia.modelListBox.Append(row)
 	}
 
@@ -365,6 +476,11 @@ func (ia *InputArea) SetModel(model string) {
 	ia.modelLabel.SetText(model)
 }
 
+// PopupModels opens the model selector.
+func (ia *InputArea) PopupModels() {
+	ia.modelButton.Popup()
+}
+
 // CurrentModel returns the currently selected model.
 func (ia *InputArea) CurrentModel() string {
 	return ia.currentModel
@@ -375,6 +491,12 @@ func (ia *InputArea) OnModelChanged(callback func(string)) {
 	ia.onModelChanged = callback
 }
 
+// OnModelInfo sets the callback for when information about a model is
+// requested from the model selector.
+func (ia *InputArea) OnModelInfo(callback func(string)) {
+	ia.onModelInfo = callback
+}
+
 // updateHeight adjusts the input area height based on content.
 func (ia *InputArea) updateHeight() {
 	buffer := ia.textView.Buffer()
@@ -0,0 +1,267 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// jsonExpandDepth is how many levels of a JSON document start expanded.
+const jsonExpandDepth = 2
+
+// JSONBlock is a widget that displays a JSON response as a tree whose
+// objects and arrays collapse, or as pretty-printed text, with a button
+// copying it.
+type JSONBlock struct {
+	*gtk.Box
+
+	// UI components
+	stack   *gtk.Stack
+	copyBtn *gtk.Button
+
+	// Data
+	pretty string // The document indented
+}
+
+// NewJSONBlock creates the view of a JSON document, which must be valid.
+func NewJSONBlock(text string) *JSONBlock {
+	jb := &JSONBlock{pretty: prettyJSON(text)}
+
+	jb.Box = gtk.NewBox(gtk.OrientationVertical, 0)
+	jb.AddCSSClass("json-block")
+
+	header := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	header.SetMarginStart(12)
+	header.SetMarginEnd(8)
+	header.SetMarginTop(4)
+
+	title := gtk.NewLabel("JSON")
+	title.AddCSSClass("dim-label")
+	title.AddCSSClass("caption")
+	header.Append(title)
+
+	spacer := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	spacer.SetHExpand(true)
+	header.Append(spacer)
+
+	textBtn := gtk.NewToggleButton()
+	textBtn.SetIconName("format-justify-left-symbolic")
+	textBtn.SetTooltipText(i18n.T("Show as Text"))
+	textBtn.AddCSSClass("flat")
+	textBtn.AddCSSClass("circular")
+	textBtn.ConnectToggled(func() {
+		if textBtn.Active() {
+			jb.stack.SetVisibleChildName("text")
+		} else {
+			jb.stack.SetVisibleChildName("tree")
+		}
+	})
+	header.Append(textBtn)
+
+	jb.copyBtn = gtk.NewButton()
+	jb.copyBtn.SetIconName("edit-copy-symbolic")
+	jb.copyBtn.SetTooltipText(i18n.T("Copy JSON"))
+	jb.copyBtn.AddCSSClass("flat")
+	jb.copyBtn.AddCSSClass("circular")
+	jb.copyBtn.ConnectClicked(jb.copyJSON)
+	header.Append(jb.copyBtn)
+	jb.Append(header)
+
+	tree := gtk.NewBox(gtk.OrientationVertical, 2)
+	if root, err := parseJSONTree(text); err == nil {
+		tree.Append(jsonNodeWidget(root, 0))
+	}
+
+	textLabel := gtk.NewLabel(jb.pretty)
+	textLabel.SetXAlign(0)
+	textLabel.SetSelectable(true)
+	textLabel.SetWrap(true)
+	textLabel.SetWrapMode(pango.WrapWordChar)
+	textLabel.AddCSSClass("monospace")
+
+	jb.stack = gtk.NewStack()
+	jb.stack.SetHhomogeneous(false)
+	jb.stack.SetVhomogeneous(false)
+	jb.stack.SetMarginStart(12)
+	jb.stack.SetMarginEnd(12)
+	jb.stack.SetMarginBottom(12)
+	jb.stack.AddNamed(tree, "tree")
+	jb.stack.AddNamed(textLabel, "text")
+	jb.Append(jb.stack)
+
+	return jb
+}
+
+// jsonNodeWidget creates the row of a node and, for objects and arrays,
+// an expander holding the rows of its children.
+func jsonNodeWidget(node jsonNode, depth int) gtk.Widgetter {
+	if len(node.Children) == 0 {
+		label := gtk.NewLabel("")
+		label.SetMarkup(jsonLabelMarkup(node.Label) + html.EscapeString(node.Value))
+		label.SetXAlign(0)
+		label.SetSelectable(true)
+		label.SetWrap(true)
+		label.SetWrapMode(pango.WrapWordChar)
+		label.AddCSSClass("monospace")
+		return label
+	}
+
+	children := gtk.NewBox(gtk.OrientationVertical, 2)
+	children.SetMarginStart(16)
+	for _, child := range node.Children {
+		children.Append(jsonNodeWidget(child, depth+1))
+	}
+
+	expander := gtk.NewExpander("")
+	expander.SetUseMarkup(true)
+	expander.SetLabel(jsonLabelMarkup(node.Label) + html.EscapeString(node.summary()))
+	expander.SetExpanded(depth < jsonExpandDepth)
+	expander.SetChild(children)
+	expander.AddCSSClass("monospace")
+	return expander
+}
+
+// jsonLabelMarkup returns the Pango markup of the key or index a node is
+// shown with, or "" for the document itself.
+func jsonLabelMarkup(label string) string {
+	if label == "" {
+		return ""
+	}
+	return "<b>" + html.EscapeString(label) + "</b>: "
+}
+
+func (jb *JSONBlock) copyJSON() {
+	gdk.DisplayGetDefault().Clipboard().SetText(jb.pretty)
+
+	// Visual feedback - change icon temporarily
+	jb.copyBtn.SetIconName("object-select-symbolic")
+	jb.copyBtn.SetTooltipText(i18n.T("Copied!"))
+
+	glib.TimeoutAdd(1500, func() bool {
+		jb.copyBtn.SetIconName("edit-copy-symbolic")
+		jb.copyBtn.SetTooltipText(i18n.T("Copy JSON"))
+		return false
+	})
+}
+
+// jsonNode is a value of a JSON document, with the members of objects in
+// the order they were written.
+type jsonNode struct {
+	Label    string     // Quoted key in the parent object, or index in the parent array
+	Value    string     // Scalars and empty objects and arrays, as written in JSON
+	Array    bool       // Whether the children are elements rather than members
+	Children []jsonNode // Members of a non-empty object, or elements of a non-empty array
+}
+
+// summary describes the size of an object or array.
+func (n jsonNode) summary() string {
+	count := uint(len(n.Children))
+	if n.Array {
+		return "[ " + fmt.Sprintf(i18n.N("%d item", "%d items", count), count) + " ]"
+	}
+	return "{ " + fmt.Sprintf(i18n.N("%d key", "%d keys", count), count) + " }"
+}
+
+// isJSONDocument reports whether text is a JSON object or array, the
+// responses shown as a tree.
+func isJSONDocument(text string) bool {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+		return false
+	}
+	return json.Valid([]byte(text))
+}
+
+// prettyJSON returns a JSON document indented, or text as is if it isn't
+// valid JSON.
+func prettyJSON(text string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(text)), "", "  "); err != nil {
+		return text
+	}
+	return buf.String()
+}
+
+// parseJSONTree parses a JSON document into a tree of nodes.
+func parseJSONTree(text string) (jsonNode, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	root, err := decodeJSONNode(dec)
+	if err != nil {
+		return jsonNode{}, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return jsonNode{}, errors.New("unexpected data after the JSON document")
+	}
+	return root, nil
+}
+
+// decodeJSONNode reads the next value of dec.
+func decodeJSONNode(dec *json.Decoder) (jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return jsonNode{}, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		node := jsonNode{Array: t == '['}
+		for i := 0; dec.More(); i++ {
+			label := strconv.Itoa(i)
+			if !node.Array {
+				key, err := dec.Token()
+				if err != nil {
+					return jsonNode{}, err
+				}
+				label = jsonString(key.(string))
+			}
+			child, err := decodeJSONNode(dec)
+			if err != nil {
+				return jsonNode{}, err
+			}
+			child.Label = label
+			node.Children = append(node.Children, child)
+		}
+		// Closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return jsonNode{}, err
+		}
+		if len(node.Children) == 0 {
+			node.Value = "{}"
+			if node.Array {
+				node.Value = "[]"
+			}
+		}
+		return node, nil
+	case string:
+		return jsonNode{Value: jsonString(t)}, nil
+	case json.Number:
+		return jsonNode{Value: t.String()}, nil
+	case bool:
+		return jsonNode{Value: strconv.FormatBool(t)}, nil
+	default: // nil
+		return jsonNode{Value: "null"}, nil
+	}
+}
+
+// jsonString returns s quoted as a JSON string, without escaping HTML
+// characters.
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
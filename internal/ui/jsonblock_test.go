@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONTree(t *testing.T) {
+	root, err := parseJSONTree(`{"name": "Ada <Lovelace>", "born": 1815, "tags": ["math", true, null], "notes": {}, "items": []}`)
+	if err != nil {
+		t.Fatalf("parseJSONTree() error = %v", err)
+	}
+
+	want := jsonNode{Children: []jsonNode{
+		{Label: `"name"`, Value: `"Ada <Lovelace>"`},
+		{Label: `"born"`, Value: "1815"},
+		{Label: `"tags"`, Array: true, Children: []jsonNode{
+			{Label: "0", Value: `"math"`},
+			{Label: "1", Value: "true"},
+			{Label: "2", Value: "null"},
+		}},
+		{Label: `"notes"`, Value: "{}"},
+		{Label: `"items"`, Array: true, Value: "[]"},
+	}}
+	if !reflect.DeepEqual(root, want) {
+		t.Errorf("parseJSONTree() = %+v, want %+v", root, want)
+	}
+}
+
+func TestParseJSONTree_Invalid(t *testing.T) {
+	for _, text := range []string{`{"a": 1`, `{"a": 1} {"b": 2}`, `[1, 2,]`, ``} {
+		if _, err := parseJSONTree(text); err == nil {
+			t.Errorf("parseJSONTree(%q) error = nil, want an error", text)
+		}
+	}
+}
+
+func TestJSONNodeSummary(t *testing.T) {
+	object := jsonNode{Children: []jsonNode{{Value: "1"}}}
+	if got := object.summary(); got != "{ 1 key }" {
+		t.Errorf("summary() = %q, want %q", got, "{ 1 key }")
+	}
+	array := jsonNode{Array: true, Children: []jsonNode{{Value: "1"}, {Value: "2"}}}
+	if got := array.summary(); got != "[ 2 items ]" {
+		t.Errorf("summary() = %q, want %q", got, "[ 2 items ]")
+	}
+}
+
+func TestIsJSONDocument(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{`{"a": 1}`, true},
+		{"\n[1, 2]\n", true},
+		{`{"a": 1`, false}, // Still streaming
+		{`"just a string"`, false},
+		{`42`, false},
+		{"Here is the JSON: {}", false},
+	}
+	for _, tt := range tests {
+		if got := isJSONDocument(tt.text); got != tt.want {
+			t.Errorf("isJSONDocument(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPrettyJSON(t *testing.T) {
+	if got, want := prettyJSON(`{"a":[1,2]}`), "{\n  \"a\": [\n    1,\n    2\n  ]\n}"; got != want {
+		t.Errorf("prettyJSON() = %q, want %q", got, want)
+	}
+	if got := prettyJSON("not json"); got != "not json" {
+		t.Errorf("prettyJSON() = %q, want the text as is", got)
+	}
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"slices"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// The interface language is chosen in the settings and follows the system
+// by default. Widgets get their labels when they are built, so after a
+// change the windows are opened again to show the new language.
+
+// interfaceLanguage returns the language the interface is shown in.
+func interfaceLanguage(cfg *config.AppConfig) string {
+	if cfg.InterfaceLanguage == "" || cfg.InterfaceLanguage == "auto" {
+		return i18n.SystemLanguage()
+	}
+	return cfg.InterfaceLanguage
+}
+
+// applyInterfaceLanguage switches to the interface language of cfg, and
+// reports whether it changed.
+func applyInterfaceLanguage(cfg *config.AppConfig) bool {
+	lang := interfaceLanguage(cfg)
+	if lang == i18n.CurrentLanguage() {
+		return false
+	}
+	i18n.SetLanguage(lang)
+	logger.Info("Interface language changed", "language", lang)
+	return true
+}
+
+// offerReload asks whether to open the windows again in the new interface
+// language. While a response or a model download runs, reopening would
+// stop it, so the language is only used by new windows until a restart.
+func (w *MainWindow) offerReload() {
+	for _, win := range w.app.windows {
+		if win.chatView.IsStreaming() || win.downloadingModel() != "" {
+			w.showToast(i18n.T("The new language is used in new windows and after a restart"))
+			return
+		}
+	}
+
+	dialog := adw.NewMessageDialog(&w.ApplicationWindow.Window, i18n.T("Reload the Interface?"),
+		i18n.T("The windows are opened again in the new language, on the same chats. Unsent text is kept as a draft."))
+	dialog.AddResponse("later", i18n.T("Later"))
+	dialog.AddResponse("reload", i18n.T("Reload Now"))
+	dialog.SetResponseAppearance("reload", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("reload")
+	dialog.SetCloseResponse("later")
+	dialog.ConnectResponse(func(response string) {
+		if response == "reload" {
+			w.app.reloadWindows()
+		}
+	})
+	dialog.Present()
+}
+
+// reloadWindows replaces every window with a new one of the same size on
+// the same chat, whose labels are in the current language. Unsent text is
+// saved as a draft first, for the new window to restore it.
+func (a *Application) reloadWindows() {
+	for _, old := range slices.Clone(a.windows) {
+		chat := old.chatView.GetCurrentChat()
+		if old.db != nil {
+			old.chatView.SaveDraft()
+		}
+
+		win := NewMainWindow(a)
+		width, height := old.DefaultSize()
+		win.SetDefaultSize(width, height)
+		if old.IsMaximized() {
+			win.Maximize()
+		}
+		win.Present()
+		if chat != nil {
+			win.onChatSelected(chat)
+			win.sidebar.SelectChat(chat)
+		}
+
+		old.Close()
+	}
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+func TestInterfaceLanguage(t *testing.T) {
+	t.Setenv("LANGUAGE", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "pt_BR.UTF-8")
+
+	tests := map[string]string{
+		"":     "pt",
+		"auto": "pt",
+		"de":   "de",
+	}
+	for setting, want := range tests {
+		cfg := &config.AppConfig{InterfaceLanguage: setting}
+		if got := interfaceLanguage(cfg); got != want {
+			t.Errorf("interfaceLanguage(%q) = %q, want %q", setting, got, want)
+		}
+	}
+}
+
+func TestApplyInterfaceLanguage(t *testing.T) {
+	i18n.SetLanguage("en")
+	defer i18n.SetLanguage("en")
+
+	cfg := &config.AppConfig{InterfaceLanguage: "fr"}
+	if !applyInterfaceLanguage(cfg) {
+		t.Error("applyInterfaceLanguage() = false, want true when the language changes")
+	}
+	if got := i18n.T("Settings"); got != "Paramètres" {
+		t.Errorf("T() = %q after switching to French", got)
+	}
+	if applyInterfaceLanguage(cfg) {
+		t.Error("applyInterfaceLanguage() = true, want false when the language is the same")
+	}
+}
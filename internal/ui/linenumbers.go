@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strconv"
+
+	"github.com/diamondburned/gotk4/pkg/cairo"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gotk4/pkg/pangocairo"
+)
+
+// Line number gutter, in pixels
+const (
+	gutterPadding  = 8
+	gutterFontSize = 13
+)
+
+// lineNumbers is a gutter showing the number of each line of a text view
+// next to it, following the lines as they scroll or rewrap.
+type lineNumbers struct {
+	*gtk.DrawingArea
+	view *gtk.TextView
+}
+
+// newLineNumbers adds a line number gutter to the left of view.
+func newLineNumbers(view *gtk.TextView) *lineNumbers {
+	n := &lineNumbers{DrawingArea: gtk.NewDrawingArea(), view: view}
+	n.SetDrawFunc(n.draw)
+	view.SetGutter(gtk.TextWindowLeft, n)
+
+	redraw := func() { n.QueueDraw() }
+	adjustment := view.VAdjustment()
+	adjustment.ConnectValueChanged(redraw)
+	adjustment.ConnectChanged(redraw)
+	return n
+}
+
+// newLayout creates the layout of a line number.
+func (n *lineNumbers) newLayout(text string) *pango.Layout {
+	layout := n.CreatePangoLayout(text)
+	font := pango.FontDescriptionFromString("Monospace")
+	font.SetAbsoluteSize(gutterFontSize * pango.SCALE)
+	layout.SetFontDescription(font)
+	return layout
+}
+
+// draw draws the number of each visible line next to it.
+func (n *lineNumbers) draw(_ *gtk.DrawingArea, cr *cairo.Context, width, height int) {
+	visible := n.view.VisibleRect()
+	iter, _ := n.view.LineAtY(visible.Y())
+
+	r, g, b, _ := parseHexColor(sharedHighlighter.GetLineNumberColor())
+	cr.SetSourceRGBA(float64(r)/255, float64(g)/255, float64(b)/255, 1)
+	for {
+		y, _ := n.view.LineYrange(iter)
+		if y > visible.Y()+visible.Height() {
+			break
+		}
+		_, windowY := n.view.BufferToWindowCoords(gtk.TextWindowLeft, 0, y)
+
+		layout := n.newLayout(strconv.Itoa(iter.Line() + 1))
+		textWidth, _ := layout.PixelSize()
+		cr.MoveTo(float64(width-gutterPadding-textWidth), float64(windowY))
+		pangocairo.ShowLayout(cr, layout)
+
+		if !iter.ForwardLine() {
+			break
+		}
+	}
+}
+
+// setLineCount makes room for the number of the last of lines lines.
+func (n *lineNumbers) setLineCount(lines int) {
+	numberWidth, _ := n.newLayout(strconv.Itoa(max(lines, 1))).PixelSize()
+	n.SetContentWidth(numberWidth + 2*gutterPadding)
+	n.QueueDraw()
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// LoadedModels is a header bar button listing the models loaded in the
+// memory of the Ollama server, each with a button unloading it. It is
+// shown while a model is loaded, and refreshed when a model is due to be
+// unloaded.
+type LoadedModels struct {
+	*gtk.MenuButton
+
+	client  ollama.API
+	listBox *gtk.ListBox
+	expiry  glib.SourceHandle // Refreshes when the next model unloads
+	stopped bool
+
+	onError func(err error)
+}
+
+// NewLoadedModels creates the loaded models button, hidden until Refresh
+// finds a model loaded.
+func NewLoadedModels(client ollama.API) *LoadedModels {
+	lm := &LoadedModels{client: client}
+
+	lm.MenuButton = gtk.NewMenuButton()
+	lm.SetIconName("media-playlist-consecutive-symbolic")
+	lm.SetTooltipText(i18n.T("Loaded Models"))
+	lm.SetVisible(false)
+
+	popover := gtk.NewPopover()
+	popover.SetAutohide(true)
+
+	box := gtk.NewBox(gtk.OrientationVertical, 6)
+	box.SetMarginTop(6)
+	box.SetMarginBottom(6)
+	box.SetMarginStart(6)
+	box.SetMarginEnd(6)
+
+	title := gtk.NewLabel(i18n.T("Loaded Models"))
+	title.SetXAlign(0)
+	title.AddCSSClass("heading")
+	box.Append(title)
+
+	lm.listBox = gtk.NewListBox()
+	lm.listBox.SetSelectionMode(gtk.SelectionNone)
+	lm.listBox.AddCSSClass("boxed-list")
+	lm.listBox.SetSizeRequest(280, -1)
+	box.Append(lm.listBox)
+
+	popover.SetChild(box)
+	popover.ConnectShow(lm.Refresh)
+	lm.SetPopover(popover)
+
+	return lm
+}
+
+// OnError sets the callback for when a model can't be unloaded.
+func (lm *LoadedModels) OnError(callback func(err error)) {
+	lm.onError = callback
+}
+
+// Refresh asks the server which models are loaded and updates the list.
+func (lm *LoadedModels) Refresh() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		models, err := lm.client.ListRunning(ctx)
+		if err != nil {
+			// Servers older than /api/ps, or not reachable
+			logger.Debug("Failed to list loaded models", "error", err)
+			models = nil
+		}
+		glib.IdleAdd(func() {
+			lm.show(models)
+		})
+	}()
+}
+
+// Stop stops refreshing the list, when its window closes.
+func (lm *LoadedModels) Stop() {
+	lm.stopped = true
+	lm.cancelExpiry()
+}
+
+// cancelExpiry cancels the refresh planned for when the next model unloads.
+func (lm *LoadedModels) cancelExpiry() {
+	if lm.expiry != 0 {
+		glib.SourceRemove(lm.expiry)
+		lm.expiry = 0
+	}
+}
+
+// show fills the list with models and plans the next refresh.
+func (lm *LoadedModels) show(models []ollama.RunningModel) {
+	if lm.stopped {
+		return
+	}
+	for {
+		child := lm.listBox.FirstChild()
+		if child == nil {
+			break
+		}
+		lm.listBox.Remove(child)
+	}
+
+	now := time.Now()
+	for _, model := range models {
+		lm.listBox.Append(lm.createRow(model, now))
+	}
+	if len(models) == 0 {
+		lm.Popdown()
+	}
+	lm.SetVisible(len(models) > 0)
+
+	lm.cancelExpiry()
+	if wait, ok := nextUnload(models, now); ok {
+		// A little later, so that the server has unloaded it
+		lm.expiry = glib.TimeoutSecondsAdd(uint(wait/time.Second)+2, func() bool {
+			lm.expiry = 0
+			lm.Refresh()
+			return false
+		})
+	}
+}
+
+// createRow creates the list row for a loaded model.
+func (lm *LoadedModels) createRow(model ollama.RunningModel, now time.Time) *adw.ActionRow {
+	row := adw.NewActionRow()
+	row.SetTitle(model.Name)
+	subtitle := glib.FormatSize(uint64(model.Size)) + " · " + processorShare(model.Size, model.SizeVRAM)
+	if unload := unloadTime(model, now); unload != "" {
+		subtitle += "\n" + unload
+	}
+	row.SetSubtitle(subtitle)
+
+	unloadBtn := gtk.NewButtonFromIconName("media-eject-symbolic")
+	unloadBtn.SetTooltipText(i18n.T("Unload from Memory"))
+	unloadBtn.SetVAlign(gtk.AlignCenter)
+	unloadBtn.AddCSSClass("flat")
+	unloadBtn.ConnectClicked(func() {
+		unloadBtn.SetSensitive(false)
+		lm.unload(model.Name)
+	})
+	row.AddSuffix(unloadBtn)
+
+	return row
+}
+
+// unload frees the memory of a model and refreshes the list.
+func (lm *LoadedModels) unload(name string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		err := lm.client.UnloadModel(ctx, name)
+		glib.IdleAdd(func() {
+			if err != nil {
+				logger.Error("Failed to unload model", "model", name, "error", err)
+				if lm.onError != nil {
+					lm.onError(fmt.Errorf("%s: %w", i18n.T("Failed to unload the model"), err))
+				}
+			} else {
+				logger.Info("Model unloaded", "model", name)
+			}
+			lm.Refresh()
+		})
+	}()
+}
+
+// processorShare returns where a model of size bytes with vram of them in
+// video memory runs, as "ollama ps" shows it.
+func processorShare(size, vram int64) string {
+	switch {
+	case size <= 0 || vram <= 0:
+		return i18n.T("100% CPU")
+	case vram >= size:
+		return i18n.T("100% GPU")
+	default:
+		gpu := int(vram * 100 / size)
+		return fmt.Sprintf(i18n.T("%d%%/%d%% CPU/GPU"), 100-gpu, gpu)
+	}
+}
+
+// unloadTime describes when a loaded model is unloaded, or returns "" if
+// the server didn't say.
+func unloadTime(model ollama.RunningModel, now time.Time) string {
+	if model.ExpiresAt.IsZero() {
+		return ""
+	}
+	if model.KeptLoaded(now) {
+		return i18n.T("Kept loaded")
+	}
+	remaining := model.ExpiresAt.Sub(now)
+	switch {
+	case remaining < time.Minute:
+		return i18n.T("Unloads in less than a minute")
+	case remaining < time.Hour:
+		minutes := uint(remaining.Round(time.Minute) / time.Minute)
+		return fmt.Sprintf(i18n.N("Unloads in %d minute", "Unloads in %d minutes", minutes), minutes)
+	default:
+		hours := uint(remaining.Round(time.Hour) / time.Hour)
+		return fmt.Sprintf(i18n.N("Unloads in %d hour", "Unloads in %d hours", hours), hours)
+	}
+}
+
+// nextUnload returns how long until the first of models is unloaded, if
+// any is due to be.
+func nextUnload(models []ollama.RunningModel, now time.Time) (time.Duration, bool) {
+	var next time.Duration
+	found := false
+	for _, model := range models {
+		if model.ExpiresAt.IsZero() || model.KeptLoaded(now) {
+			continue
+		}
+		wait := max(model.ExpiresAt.Sub(now), 0)
+		if !found || wait < next {
+			next, found = wait, true
+		}
+	}
+	return next, found
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+func TestProcessorShare(t *testing.T) {
+	tests := []struct {
+		size, vram int64
+		want       string
+	}{
+		{4 << 30, 4 << 30, "100% GPU"},
+		{4 << 30, 0, "100% CPU"},
+		{4 << 30, 3 << 30, "25%/75% CPU/GPU"},
+		{0, 0, "100% CPU"},
+	}
+	for _, tt := range tests {
+		if got := processorShare(tt.size, tt.vram); got != tt.want {
+			t.Errorf("processorShare(%d, %d) = %q, want %q", tt.size, tt.vram, got, tt.want)
+		}
+	}
+}
+
+func TestUnloadTime(t *testing.T) {
+	now := time.Date(2024, 6, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expires time.Time
+		want    string
+	}{
+		{time.Time{}, ""},
+		{now.Add(30 * time.Second), "Unloads in less than a minute"},
+		{now.Add(time.Minute + 10*time.Second), "Unloads in 1 minute"},
+		{now.Add(4*time.Minute + 50*time.Second), "Unloads in 5 minutes"},
+		{now.Add(2 * time.Hour), "Unloads in 2 hours"},
+		{now.AddDate(290, 0, 0), "Kept loaded"},
+	}
+	for _, tt := range tests {
+		if got := unloadTime(ollama.RunningModel{ExpiresAt: tt.expires}, now); got != tt.want {
+			t.Errorf("unloadTime(%v) = %q, want %q", tt.expires, got, tt.want)
+		}
+	}
+}
+
+func TestNextUnload(t *testing.T) {
+	now := time.Date(2024, 6, 4, 12, 0, 0, 0, time.UTC)
+	models := []ollama.RunningModel{
+		{Name: "kept", ExpiresAt: now.AddDate(290, 0, 0)},
+		{Name: "later", ExpiresAt: now.Add(10 * time.Minute)},
+		{Name: "sooner", ExpiresAt: now.Add(3 * time.Minute)},
+	}
+	if wait, ok := nextUnload(models, now); !ok || wait != 3*time.Minute {
+		t.Errorf("nextUnload() = %v, %v, want 3m, true", wait, ok)
+	}
+
+	// Nothing to wait for while every model is kept loaded
+	if _, ok := nextUnload(models[:1], now); ok {
+		t.Error("nextUnload() found an unload for a model kept loaded")
+	}
+	if _, ok := nextUnload(nil, now); ok {
+		t.Error("nextUnload() found an unload without models")
+	}
+}
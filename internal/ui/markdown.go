@@ -2,6 +2,7 @@ package ui
 
 import (
 	"bytes"
+	"fmt"
 	"html"
 	"regexp"
 	"strings"
@@ -14,11 +15,13 @@ import (
 	"github.com/yuin/goldmark/text"
 )
 
-// ContentPart represents a parsed content part (text or code).
+// ContentPart represents a parsed content part (text, code or a table).
 type ContentPart struct {
-	Type     string // "text" or "code"
-	Content  string
-	Language string // Only for code blocks
+	Type       string // "text", "code" or "table"
+	Content    string
+	Language   string           // Only for code blocks
+	Rows       [][]string       // Only for tables: Pango markup of the cells, header first
+	Alignments []east.Alignment // Only for tables: alignment of each column
 }
 
 // MarkdownRenderer converts Markdown to Pango markup for GTK labels.
@@ -32,6 +35,7 @@ func normalizeMarkdown(text string) string {
 	lines := strings.Split(text, "\n")
 	var result []string
 	inCodeBlock := false
+	mathCloser := "" // Closing delimiter of the display math being read
 
 	for i, line := range lines {
 		// Track code blocks to avoid modifying code
@@ -48,6 +52,22 @@ func normalizeMarkdown(text string) string {
 
 		trimmed := strings.TrimSpace(line)
 
+		// Leave display math as it is, up to the line that ends it
+		if mathCloser != "" {
+			if strings.HasSuffix(trimmed, mathCloser) {
+				mathCloser = ""
+			}
+			result = append(result, line)
+			continue
+		}
+		if opener, closer := displayMathDelimiters([]byte(trimmed)); opener != "" {
+			if !strings.Contains(trimmed[len(opener):], closer) {
+				mathCloser = closer
+			}
+			result = append(result, line)
+			continue
+		}
+
 		// Convert Unicode bullets to Markdown list syntax
 		if strings.HasPrefix(trimmed, "•") || strings.HasPrefix(trimmed, "▪") || strings.HasPrefix(trimmed, "▸") {
 			// Preserve original indentation
@@ -62,7 +82,7 @@ func normalizeMarkdown(text string) string {
 
 		// Detect potential headers: short line without punctuation, after blank, with content following
 		// Skip if line starts with a number followed by . (ordered list item)
-		isOrderedList := len(trimmed) > 2 && trimmed[0] >= '0' && trimmed[0] <= '9' && strings.Contains(trimmed[:3], ".")
+		isOrderedList := orderedListItemPattern.MatchString(trimmed)
 		if len(trimmed) > 0 && len(trimmed) < 60 &&
 			!isOrderedList &&
 			!strings.HasPrefix(trimmed, "#") &&
@@ -86,6 +106,10 @@ func normalizeMarkdown(text string) string {
 				nextLine := strings.TrimSpace(lines[i+1])
 				// Content after should not be empty and not be another potential header
 				hasContentAfter = nextLine != "" && !strings.HasPrefix(nextLine, "#")
+				// Text introducing display math isn't a header
+				if opener, _ := displayMathDelimiters([]byte(nextLine)); opener != "" {
+					hasContentAfter = false
+				}
 			}
 
 			if isAfterBlank && hasContentAfter {
@@ -106,6 +130,7 @@ func NewMarkdownRenderer() *MarkdownRenderer {
 			goldmark.WithExtensions(
 				extension.Strikethrough,
 				extension.Table,
+				mathExtension,
 			),
 			goldmark.WithParserOptions(
 				parser.WithAutoHeadingID(),
@@ -136,6 +161,19 @@ func (r *MarkdownRenderer) ToPango(markdown string) string {
 	return result
 }
 
+// orderedListItemPattern matches the start of an ordered list item, such as
+// "12." or "3)".
+var orderedListItemPattern = regexp.MustCompile(`^\d{1,9}[.)]`)
+
+// pangoTagPattern matches the tags of Pango markup.
+var pangoTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// ToPlainText converts markdown text to plain text as it reads when
+// rendered: without emphasis or links, but keeping list bullets and code.
+func (r *MarkdownRenderer) ToPlainText(markdown string) string {
+	return html.UnescapeString(pangoTagPattern.ReplaceAllString(r.ToPango(markdown), ""))
+}
+
 func (r *MarkdownRenderer) renderNode(buf *bytes.Buffer, node ast.Node, source []byte, depth int) {
 	switch n := node.(type) {
 	case *ast.Document:
@@ -237,14 +275,16 @@ func (r *MarkdownRenderer) renderNode(buf *bytes.Buffer, node ast.Node, source [
 		buf.WriteString("</a>")
 
 	case *ast.List:
+		level := listLevel(n)
+		indent := strings.Repeat("  ", level+1)
 		for i, child := 0, n.FirstChild(); child != nil; i, child = i+1, child.NextSibling() {
 			if listItem, ok := child.(*ast.ListItem); ok {
+				buf.WriteString(indent)
 				if n.IsOrdered() {
-					buf.WriteString("  ")
-					buf.WriteString(string(rune('1' + i)))
-					buf.WriteString(". ")
+					fmt.Fprintf(buf, "%d%c ", n.Start+i, n.Marker)
 				} else {
-					buf.WriteString("  • ")
+					buf.WriteString(listBullets[level%len(listBullets)])
+					buf.WriteString(" ")
 				}
 				r.renderListItemContent(buf, listItem, source, depth)
 				if child.NextSibling() != nil {
@@ -260,8 +300,11 @@ func (r *MarkdownRenderer) renderNode(buf *bytes.Buffer, node ast.Node, source [
 		// Handled by List
 
 	case *ast.Blockquote:
-		buf.WriteString("<i>▎ ")
-		r.renderBlockquoteContent(buf, n, source, depth)
+		// Every line gets a bar, so nested quotes get one per level
+		var quote bytes.Buffer
+		r.renderBlockquoteContent(&quote, n, source, depth)
+		buf.WriteString("<i>" + quoteBar)
+		buf.WriteString(strings.ReplaceAll(strings.TrimRight(quote.String(), "\n"), "\n", "\n"+quoteBar))
 		buf.WriteString("</i>")
 		if n.NextSibling() != nil {
 			buf.WriteString("\n\n")
@@ -309,6 +352,15 @@ func (r *MarkdownRenderer) renderNode(buf *bytes.Buffer, node ast.Node, source [
 	case *ast.String:
 		buf.WriteString(html.EscapeString(string(n.Value)))
 
+	case *mathInline:
+		buf.WriteString(renderMath(n.TeX, n.Display, true))
+
+	case *mathBlock:
+		buf.WriteString(renderMath(n.TeX(source), true, true))
+		if n.NextSibling() != nil {
+			buf.WriteString("\n\n")
+		}
+
 	case *ast.RawHTML:
 		// Skip raw HTML for security
 
@@ -330,27 +382,69 @@ func (r *MarkdownRenderer) renderChildren(buf *bytes.Buffer, node ast.Node, sour
 	}
 }
 
+// listBullets are the bullets of unordered lists, by nesting level.
+var listBullets = []string{"•", "◦", "▪"}
+
+// listLevel returns how many lists node is nested in.
+func listLevel(node ast.Node) int {
+	level := 0
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if _, ok := parent.(*ast.List); ok {
+			level++
+		}
+	}
+	return level
+}
+
+// renderListItemContent renders the blocks of a list item. The lines after
+// the first are indented to line up with the text after the bullet.
 func (r *MarkdownRenderer) renderListItemContent(buf *bytes.Buffer, item *ast.ListItem, source []byte, depth int) {
+	continuation := strings.Repeat("  ", listLevel(item)+1)
 	for child := item.FirstChild(); child != nil; child = child.NextSibling() {
-		if para, ok := child.(*ast.Paragraph); ok {
-			r.renderChildren(buf, para, source, depth)
-		} else {
-			r.renderNode(buf, child, source, depth)
+		block := strings.TrimRight(r.renderBlock(child, source, depth), "\n")
+		if _, ok := child.(*ast.List); ok {
+			// Nested lists start on their own line, indented further
+			buf.WriteString("\n" + block)
+			continue
+		}
+		if child.PreviousSibling() != nil {
+			buf.WriteString("\n" + continuation)
 		}
+		buf.WriteString(strings.ReplaceAll(block, "\n", "\n"+continuation))
 	}
 }
 
+// renderBlock returns the rendering of a block inside a list item or a
+// blockquote, a paragraph without the space after it.
+func (r *MarkdownRenderer) renderBlock(node ast.Node, source []byte, depth int) string {
+	var block bytes.Buffer
+	if para, ok := node.(*ast.Paragraph); ok {
+		r.renderChildren(&block, para, source, depth)
+	} else {
+		r.renderNode(&block, node, source, depth)
+	}
+	return block.String()
+}
+
+// quoteBar starts each line of a blockquote.
+const quoteBar = "▎ "
+
+// renderBlockquoteContent renders the blocks of a blockquote, with an empty
+// line between paragraphs.
 func (r *MarkdownRenderer) renderBlockquoteContent(buf *bytes.Buffer, quote *ast.Blockquote, source []byte, depth int) {
 	for child := quote.FirstChild(); child != nil; child = child.NextSibling() {
-		if para, ok := child.(*ast.Paragraph); ok {
-			r.renderChildren(buf, para, source, depth)
-		} else {
-			r.renderNode(buf, child, source, depth)
+		if prev := child.PreviousSibling(); prev != nil {
+			_, afterParagraph := prev.(*ast.Paragraph)
+			if _, paragraph := child.(*ast.Paragraph); paragraph && afterParagraph {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("\n")
 		}
+		buf.WriteString(strings.TrimRight(r.renderBlock(child, source, depth), "\n"))
 	}
 }
 
-// Parse splits markdown into content parts (text and code blocks).
+// Parse splits markdown into content parts (text, code blocks and tables).
 func (r *MarkdownRenderer) Parse(markdown string) []ContentPart {
 	// First decode any HTML entities in the input
 	markdown = html.UnescapeString(markdown)
@@ -436,6 +530,25 @@ func (r *MarkdownRenderer) Parse(markdown string) []ContentPart {
 				Content: codeBuf.String(),
 			})
 
+		case *east.Table:
+			// Flush any accumulated text
+			if textBuf.Len() > 0 {
+				text := strings.TrimSpace(textBuf.String())
+				if text != "" {
+					parts = append(parts, ContentPart{
+						Type:    "text",
+						Content: text,
+					})
+				}
+				textBuf.Reset()
+			}
+
+			parts = append(parts, ContentPart{
+				Type:       "table",
+				Rows:       r.tableRows(n, source),
+				Alignments: n.Alignments,
+			})
+
 		default:
 			// Render other nodes to text buffer
 			r.renderNode(&textBuf, child, source, 0)
@@ -456,3 +569,19 @@ func (r *MarkdownRenderer) Parse(markdown string) []ContentPart {
 
 	return parts
 }
+
+// tableRows returns the Pango markup of the cells of table, row by row with
+// the header first.
+func (r *MarkdownRenderer) tableRows(table *east.Table, source []byte) [][]string {
+	var rows [][]string
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			var buf bytes.Buffer
+			r.renderChildren(&buf, cell, source, 0)
+			cells = append(cells, strings.TrimSpace(buf.String()))
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
@@ -1,7 +1,10 @@
 package ui
 
 import (
+	"reflect"
 	"testing"
+
+	east "github.com/yuin/goldmark/extension/ast"
 )
 
 func TestMarkdownToPango(t *testing.T) {
@@ -80,11 +83,41 @@ func TestMarkdownToPango(t *testing.T) {
 			markdown: "1. first\n2. second",
 			expected: "1. first\n  2. second",
 		},
+		{
+			name:     "ordered list past nine",
+			markdown: "9. ninth\n10. tenth\n11. eleventh",
+			expected: "9. ninth\n  10. tenth\n  11. eleventh",
+		},
+		{
+			name:     "ordered list with start and parenthesis",
+			markdown: "3) third\n4) fourth",
+			expected: "3) third\n  4) fourth",
+		},
+		{
+			name:     "nested lists",
+			markdown: "1. first\n   - a\n   - b\n2. second\n   1. one\n      - deep",
+			expected: "1. first\n    ◦ a\n    ◦ b\n  2. second\n    1. one\n      ▪ deep",
+		},
 		{
 			name:     "blockquote",
 			markdown: "> This is a quote",
 			expected: "<i>▎ This is a quote</i>",
 		},
+		{
+			name:     "nested blockquote",
+			markdown: "> outer\n>\n> > inner\n> > more",
+			expected: "<i>▎ outer\n▎ <i>▎ inner\n▎ ▎ more</i></i>",
+		},
+		{
+			name:     "blockquote paragraphs",
+			markdown: "> first\n>\n> second",
+			expected: "<i>▎ first\n▎ \n▎ second</i>",
+		},
+		{
+			name:     "list item with several lines",
+			markdown: "- first line\n  second line\n\n  > quoted",
+			expected: "• first line\n    second line\n    <i>▎ quoted</i>",
+		},
 		{
 			name:     "horizontal rule",
 			markdown: "---",
@@ -114,6 +147,40 @@ func TestMarkdownToPango(t *testing.T) {
 	}
 }
 
+func TestMarkdownToPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "emphasis and links",
+			markdown: "Some **bold**, *italic* and [a link](https://example.com).",
+			expected: "Some bold, italic and a link.",
+		},
+		{
+			name:     "heading and list",
+			markdown: "# Steps\n\n- One\n- Two",
+			expected: "Steps\n\n  • One\n  • Two",
+		},
+		{
+			name:     "code keeps its symbols",
+			markdown: "Use `a < b && c`:\n\n```go\nif x > 0 {\n}\n```",
+			expected: "Use a < b && c:\n\nif x > 0 {\n}",
+		},
+	}
+
+	renderer := NewMarkdownRenderer()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderer.ToPlainText(tt.markdown); got != tt.expected {
+				t.Errorf("ToPlainText(%q)\ngot:  %q\nwant: %q", tt.markdown, got, tt.expected)
+			}
+		})
+	}
+}
+
 func BenchmarkMarkdownToPango(b *testing.B) {
 	renderer := NewMarkdownRenderer()
 	markdown := `# Hello World
@@ -137,3 +204,23 @@ This is a **bold** statement with *italic* text and some ` + "`code`" + `.
 		_ = renderer.ToPango(markdown)
 	}
 }
+
+func TestParse_Table(t *testing.T) {
+	r := NewMarkdownRenderer()
+	parts := r.Parse("Prices:\n\n| Item | Price |\n|:-----|------:|\n| **Tea** | 3 & 4 |\n| Cake | 5 |\n\nThat's all.")
+
+	if len(parts) != 3 {
+		t.Fatalf("Parse() returned %d parts, want text, table and text: %+v", len(parts), parts)
+	}
+	table := parts[1]
+	if table.Type != "table" {
+		t.Fatalf("part type = %q, want table", table.Type)
+	}
+	want := [][]string{{"Item", "Price"}, {"<b>Tea</b>", "3 &amp; 4"}, {"Cake", "5"}}
+	if !reflect.DeepEqual(table.Rows, want) {
+		t.Errorf("Rows = %q, want %q", table.Rows, want)
+	}
+	if len(table.Alignments) != 2 || table.Alignments[0] != east.AlignLeft || table.Alignments[1] != east.AlignRight {
+		t.Errorf("Alignments = %v, want left and right", table.Alignments)
+	}
+}
@@ -0,0 +1,569 @@
+package ui
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Math in answers, written in TeX between $...$ or \(...\) inline and
+// $$...$$ or \[...\] on lines of its own, is shown with Unicode symbols:
+// Greek letters, operators and arrows take their characters, fractions
+// are written with a slash and roots with √, and exponents and indices
+// use superscript characters or Pango's <sup> and <sub>. It is an
+// approximation, not typesetting, but reads far better than raw TeX.
+
+// mathExtension adds inline and display math to the markdown parser.
+var mathExtension goldmark.Extender = mathExtender{}
+
+type mathExtender struct{}
+
+func (mathExtender) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(mathBlockParser{}, 650)),
+		parser.WithInlineParsers(util.Prioritized(mathInlineParser{}, 500)),
+	)
+}
+
+// kindMathInline is the kind of inline math nodes.
+var kindMathInline = ast.NewNodeKind("MathInline")
+
+// mathInline is math within a paragraph.
+type mathInline struct {
+	ast.BaseInline
+	TeX     string
+	Display bool // Written between $$ or \[ \]
+}
+
+func (n *mathInline) Kind() ast.NodeKind {
+	return kindMathInline
+}
+
+func (n *mathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.TeX}, nil)
+}
+
+// kindMathBlock is the kind of display math blocks.
+var kindMathBlock = ast.NewNodeKind("MathBlock")
+
+// mathBlock is display math on lines of its own. Its lines hold the TeX.
+type mathBlock struct {
+	ast.BaseBlock
+	closer string
+	closed bool // Ended on its first line
+}
+
+func (n *mathBlock) Kind() ast.NodeKind {
+	return kindMathBlock
+}
+
+func (n *mathBlock) IsRaw() bool {
+	return true
+}
+
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// TeX returns the math of the block.
+func (n *mathBlock) TeX(source []byte) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		b.Write(line.Value(source))
+	}
+	return b.String()
+}
+
+// displayMathDelimiters returns the delimiters of display math starting
+// line, if it does.
+func displayMathDelimiters(line []byte) (opener, closer string) {
+	switch {
+	case bytes.HasPrefix(line, []byte("$$")):
+		return "$$", "$$"
+	case bytes.HasPrefix(line, []byte(`\[`)):
+		return `\[`, `\]`
+	}
+	return "", ""
+}
+
+// mathBlockParser parses display math on lines of its own.
+type mathBlockParser struct{}
+
+func (mathBlockParser) Trigger() []byte {
+	return []byte{'$', '\\'}
+}
+
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 {
+		return nil, parser.NoChildren
+	}
+	opener, closer := displayMathDelimiters(line[pos:])
+	if opener == "" {
+		return nil, parser.NoChildren
+	}
+
+	node := &mathBlock{closer: closer}
+	start := pos + len(opener)
+	body := util.TrimRightSpace(line[start:])
+	if i := bytes.Index(body, []byte(closer)); i >= 0 {
+		if !util.IsBlank(body[i+len(closer):]) {
+			return nil, parser.NoChildren // Inline math followed by text
+		}
+		node.Lines().Append(text.NewSegment(segment.Start+start, segment.Start+start+i))
+		node.closed = true
+	} else if !util.IsBlank(body) {
+		node.Lines().Append(text.NewSegment(segment.Start+start, segment.Start+start+len(body)))
+	}
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	block := node.(*mathBlock)
+	if block.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	closer := []byte(block.closer)
+
+	if i := bytes.Index(line, closer); i >= 0 && util.IsBlank(line[i+len(closer):]) {
+		if !util.IsBlank(line[:i]) {
+			node.Lines().Append(text.NewSegment(segment.Start, segment.Start+i))
+		}
+		newline := 1
+		if line[len(line)-1] != '\n' {
+			newline = 0
+		}
+		reader.Advance(segment.Stop - segment.Start - newline + segment.Padding)
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// mathInlineParser parses math within a line.
+type mathInlineParser struct{}
+
+func (mathInlineParser) Trigger() []byte {
+	return []byte{'$', '\\'}
+}
+
+func (mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	var opener, closer string
+	switch {
+	case bytes.HasPrefix(line, []byte("$$")):
+		opener, closer = "$$", "$$"
+	case bytes.HasPrefix(line, []byte("$")):
+		opener, closer = "$", "$"
+	case bytes.HasPrefix(line, []byte(`\(`)):
+		opener, closer = `\(`, `\)`
+	case bytes.HasPrefix(line, []byte(`\[`)):
+		opener, closer = `\[`, `\]`
+	default:
+		return nil
+	}
+
+	rest := line[len(opener):]
+	end := findMathCloser(rest, closer)
+	if end <= 0 {
+		return nil
+	}
+	tex := rest[:end]
+	if opener == "$" {
+		// Like Pandoc, so that prices such as $5 and $10 stay text: the
+		// math can't start or end with a space, nor be followed by a digit
+		after := end + len(closer)
+		if unicode.IsSpace(rune(tex[0])) || unicode.IsSpace(rune(tex[len(tex)-1])) ||
+			(after < len(rest) && rest[after] >= '0' && rest[after] <= '9') {
+			return nil
+		}
+	}
+
+	block.Advance(len(opener) + end + len(closer))
+	return &mathInline{TeX: string(tex), Display: opener == "$$" || opener == `\[`}
+}
+
+// findMathCloser returns the position of closer in s, skipping escaped
+// characters, or -1 when it isn't there.
+func findMathCloser(s []byte, closer string) int {
+	for i := 0; i < len(s); i++ {
+		if bytes.HasPrefix(s[i:], []byte(closer)) {
+			return i
+		}
+		if s[i] == '\\' {
+			i++
+		}
+	}
+	return -1
+}
+
+// renderMath converts TeX to text with Unicode symbols, as Pango markup
+// when markup is set. Line breaks of display math start new lines.
+func renderMath(tex string, display, markup bool) string {
+	// Only \\ breaks lines, as in TeX
+	tex = strings.ReplaceAll(tex, "\n", " ")
+	c := &texConverter{src: tex, display: display, markup: markup}
+	out := c.sequence(false)
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// texConverter converts TeX to text, one token at a time.
+type texConverter struct {
+	src     string
+	pos     int
+	display bool
+	markup  bool
+}
+
+// sequence converts tokens up to the end, or up to the closing brace of
+// a group when group is set.
+func (c *texConverter) sequence(group bool) string {
+	var b strings.Builder
+	for c.pos < len(c.src) {
+		ch := c.src[c.pos]
+		switch ch {
+		case '}':
+			c.pos++
+			if group {
+				return b.String()
+			}
+		case '^', '_':
+			c.pos++
+			b.WriteString(c.script(c.argument(), ch == '^'))
+		default:
+			b.WriteString(c.token())
+		}
+	}
+	return b.String()
+}
+
+// token converts the next token: a group, a command or a character.
+func (c *texConverter) token() string {
+	r, size := utf8.DecodeRuneInString(c.src[c.pos:])
+	switch r {
+	case '{':
+		c.pos++
+		return c.sequence(true)
+	case '\\':
+		return c.command()
+	case '&', '~':
+		c.pos++
+		return " "
+	case '-':
+		c.pos++
+		return "−"
+	case '\'':
+		c.pos++
+		return "′"
+	}
+	c.pos += size
+	return c.escape(string(r))
+}
+
+// argument converts the argument of a command or script: a group or a
+// single token.
+func (c *texConverter) argument() string {
+	for c.pos < len(c.src) && c.src[c.pos] == ' ' {
+		c.pos++
+	}
+	if c.pos >= len(c.src) {
+		return ""
+	}
+	return c.token()
+}
+
+// rawArgument returns the text of a group argument as it is written, for
+// \text and environment names.
+func (c *texConverter) rawArgument() string {
+	for c.pos < len(c.src) && c.src[c.pos] == ' ' {
+		c.pos++
+	}
+	if c.pos >= len(c.src) || c.src[c.pos] != '{' {
+		return ""
+	}
+	depth := 0
+	start := c.pos + 1
+	for ; c.pos < len(c.src); c.pos++ {
+		switch c.src[c.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				c.pos++
+				return c.src[start : c.pos-1]
+			}
+		}
+	}
+	return c.src[start:]
+}
+
+// command converts the command at the current position.
+func (c *texConverter) command() string {
+	c.pos++ // Backslash
+	if c.pos >= len(c.src) {
+		return ""
+	}
+	start := c.pos
+	for c.pos < len(c.src) && isASCIILetter(c.src[c.pos]) {
+		c.pos++
+	}
+	if c.pos == start {
+		c.pos++ // A single character, as in \{ or \,
+	}
+	name := c.src[start:c.pos]
+
+	if symbol, ok := texSymbols[name]; ok {
+		return symbol
+	}
+	if texFunctions[name] {
+		return name
+	}
+
+	switch name {
+	case "\\":
+		if c.display {
+			return "\n"
+		}
+		return "; "
+	case ",", ";", ":", " ", "quad", "qquad":
+		return " "
+	case "!", "left", "right", "big", "Big", "bigg", "Bigg", "bigl", "bigr", "Bigl", "Bigr",
+		"displaystyle", "textstyle", "limits", "nolimits", "nonumber":
+		return ""
+	case "{", "}", "$", "%", "#", "_", "&":
+		return c.escape(name)
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num := c.argument()
+		den := c.argument()
+		return c.parenthesize(num) + "/" + c.parenthesize(den)
+	case "binom", "dbinom", "tbinom":
+		n := c.argument()
+		k := c.argument()
+		return "C(" + n + ", " + k + ")"
+	case "sqrt":
+		index := ""
+		if c.pos < len(c.src) && c.src[c.pos] == '[' {
+			if end := strings.IndexByte(c.src[c.pos:], ']'); end > 0 {
+				index = (&texConverter{src: c.src[c.pos+1 : c.pos+end], markup: c.markup}).sequence(false)
+				c.pos += end + 1
+			}
+		}
+		root := "√"
+		switch index {
+		case "":
+		case "3":
+			root = "∛"
+		case "4":
+			root = "∜"
+		default:
+			root = c.script(index, true) + "√"
+		}
+		return root + c.parenthesize(c.argument())
+	case "text", "textrm", "textit", "textnormal", "mbox", "hbox":
+		return c.escape(c.rawArgument())
+	case "textbf", "mathbf", "boldsymbol", "bm":
+		arg := c.argument()
+		if c.markup && arg != "" {
+			return "<b>" + arg + "</b>"
+		}
+		return arg
+	case "mathrm", "mathit", "mathsf", "mathtt", "mathcal", "mathscr", "mathfrak", "operatorname":
+		return c.argument()
+	case "mathbb":
+		return strings.Map(func(r rune) rune {
+			if d, ok := doubleStruck[r]; ok {
+				return d
+			}
+			return r
+		}, c.argument())
+	case "hat", "widehat", "bar", "overline", "vec", "overrightarrow", "dot", "ddot", "tilde", "widetilde":
+		return combine(c.argument(), texAccents[name])
+	case "begin", "end":
+		c.rawArgument() // Environments such as matrices keep their rows and cells
+		return ""
+	case "pmod":
+		return " (mod " + c.argument() + ")"
+	case "mod", "bmod":
+		return " mod "
+	}
+	return c.escape(`\` + name)
+}
+
+// script converts a superscript or subscript, with Unicode characters
+// when it has them all, with <sup> or <sub> in markup, or written as ^x
+// and _x in text.
+func (c *texConverter) script(arg string, sup bool) string {
+	if arg == "" {
+		return ""
+	}
+	chars := subscripts
+	tag, mark := "sub", "_"
+	if sup {
+		chars = superscripts
+		tag, mark = "sup", "^"
+	}
+
+	var b strings.Builder
+	converted := true
+	for _, r := range arg {
+		s, ok := chars[r]
+		if !ok {
+			converted = false
+			break
+		}
+		b.WriteRune(s)
+	}
+	switch {
+	case converted:
+		return b.String()
+	case c.markup:
+		return "<" + tag + ">" + arg + "</" + tag + ">"
+	case utf8.RuneCountInString(arg) == 1:
+		return mark + arg
+	}
+	return mark + "(" + arg + ")"
+}
+
+// parenthesize wraps s in parentheses unless it is a single number, name
+// or symbol, as for the numerator of a fraction.
+func (c *texConverter) parenthesize(s string) string {
+	plain := s
+	if c.markup {
+		plain = html.UnescapeString(pangoTagPattern.ReplaceAllString(s, ""))
+	}
+	if utf8.RuneCountInString(plain) <= 1 {
+		return s
+	}
+	for _, r := range plain {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '.' {
+			return "(" + s + ")"
+		}
+	}
+	return s
+}
+
+// escape escapes s for markup.
+func (c *texConverter) escape(s string) string {
+	if c.markup {
+		return html.EscapeString(s)
+	}
+	return s
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// combine adds the combining character mark to s when it is a single
+// character, as for \hat{x}.
+func combine(s string, mark rune) string {
+	if utf8.RuneCountInString(s) != 1 {
+		return s
+	}
+	return s + string(mark)
+}
+
+// texAccents are the combining characters of accent commands.
+var texAccents = map[string]rune{
+	"hat": '̂', "widehat": '̂',
+	"bar": '̅', "overline": '̅',
+	"vec": '⃗', "overrightarrow": '⃗',
+	"dot": '̇', "ddot": '̈',
+	"tilde": '̃', "widetilde": '̃',
+}
+
+// texFunctions are the commands written as their name, such as \sin.
+var texFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true,
+	"arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true, "tanh": true,
+	"log": true, "ln": true, "lg": true, "exp": true, "lim": true, "max": true, "min": true,
+	"sup": true, "inf": true, "det": true, "dim": true, "ker": true, "deg": true, "gcd": true,
+	"arg": true, "Pr": true,
+}
+
+// texSymbols are the characters of symbol commands.
+var texSymbols = map[string]string{
+	// Greek letters
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+
+	// Operators
+	"times": "×", "cdot": "·", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆",
+	"circ": "∘", "bullet": "∙", "oplus": "⊕", "otimes": "⊗", "setminus": "∖",
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+	"bigcup": "⋃", "bigcap": "⋂", "cup": "∪", "cap": "∩", "wedge": "∧", "land": "∧",
+	"vee": "∨", "lor": "∨", "neg": "¬", "lnot": "¬",
+
+	// Relations
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈",
+	"equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝", "ll": "≪", "gg": "≫",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "supset": "⊃", "subseteq": "⊆",
+	"supseteq": "⊇", "perp": "⊥", "parallel": "∥", "mid": "∣", "models": "⊨", "vdash": "⊢",
+
+	// Arrows
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺",
+	"mapsto": "↦", "uparrow": "↑", "downarrow": "↓", "longrightarrow": "⟶", "longleftarrow": "⟵",
+
+	// Other symbols
+	"infty": "∞", "partial": "∂", "nabla": "∇", "forall": "∀", "exists": "∃", "nexists": "∄",
+	"emptyset": "∅", "varnothing": "∅", "angle": "∠", "triangle": "△", "degree": "°",
+	"prime": "′", "hbar": "ℏ", "ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"therefore": "∴", "because": "∵", "checkmark": "✓",
+	"langle": "⟨", "rangle": "⟩", "lceil": "⌈", "rceil": "⌉", "lfloor": "⌊", "rfloor": "⌋",
+	"|": "‖", "lvert": "|", "rvert": "|", "vert": "|", "lVert": "‖", "rVert": "‖", "Vert": "‖",
+}
+
+// doubleStruck are the letters of \mathbb.
+var doubleStruck = map[rune]rune{
+	'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+}
+
+// superscripts are the superscript characters.
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '−': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ', '′': '′',
+}
+
+// subscripts are the subscript characters.
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '−': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'o': 'ₒ', 'x': 'ₓ', 'h': 'ₕ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ',
+	'p': 'ₚ', 's': 'ₛ', 't': 'ₜ', 'i': 'ᵢ', 'j': 'ⱼ', 'r': 'ᵣ', 'u': 'ᵤ', 'v': 'ᵥ',
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestRenderMath(t *testing.T) {
+	tests := []struct {
+		name   string
+		tex    string
+		markup bool
+		want   string
+	}{
+		{"greek and operators", `\alpha \times \beta \leq \pi`, false, "α × β ≤ π"},
+		{"unicode superscript", `x^2 + y^{n+1}`, false, "x² + yⁿ⁺¹"},
+		{"unicode subscript", `a_1 + a_{i+1}`, false, "a₁ + aᵢ₊₁"},
+		{"markup superscript", `e^{i\theta}`, true, "e<sup>iθ</sup>"},
+		{"text superscript", `e^{i\theta}`, false, "e^(iθ)"},
+		{"simple fraction", `\frac{1}{2}`, false, "1/2"},
+		{"compound fraction", `\frac{a+b}{c}`, false, "(a+b)/c"},
+		{"square root", `\sqrt{x^2 + 1}`, false, "√(x² + 1)"},
+		{"cube root", `\sqrt[3]{8}`, false, "∛8"},
+		{"minus", `a - b`, false, "a − b"},
+		{"text", `\text{if } x > 0`, false, "if x > 0"},
+		{"escaped markup", `a < b`, true, "a &lt; b"},
+		{"blackboard bold", `x \in \mathbb{R}`, false, "x ∈ ℝ"},
+		{"accent", `\vec{v}`, false, "v⃗"},
+		{"functions", `\sin(x) \to \lim_{x \to 0}`, false, "sin(x) → lim_(x → 0)"},
+		{"sizing dropped", `\left( x \right)`, false, "( x )"},
+		{"unknown command", `\foo x`, false, `\foo x`},
+		{"bold markup", `\mathbf{v}`, true, "<b>v</b>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMath(tt.tex, false, tt.markup); got != tt.want {
+				t.Errorf("renderMath(%q) = %q, want %q", tt.tex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMath_LineBreaks(t *testing.T) {
+	tex := `\begin{cases} x & x \geq 0 \\ -x & x < 0 \end{cases}`
+
+	if got, want := renderMath(tex, true, false), "x x ≥ 0\n−x x < 0"; got != want {
+		t.Errorf("display renderMath() = %q, want %q", got, want)
+	}
+	if got, want := renderMath(tex, false, false), "x x ≥ 0 ; −x x < 0"; got != want {
+		t.Errorf("inline renderMath() = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownToPango_Math(t *testing.T) {
+	r := NewMarkdownRenderer()
+
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "inline dollars",
+			markdown: "The area is $\\pi r^2$ exactly.",
+			expected: "The area is π r² exactly.",
+		},
+		{
+			name:     "inline parentheses",
+			markdown: "Let \\(x_1 \\neq x_2\\) hold.",
+			expected: "Let x₁ ≠ x₂ hold.",
+		},
+		{
+			name:     "underscores are not emphasis",
+			markdown: "Both $a_i$ and $b_i$ are set.",
+			expected: "Both aᵢ and bᵢ are set.",
+		},
+		{
+			name:     "prices stay text",
+			markdown: "It costs $5 and $10.",
+			expected: "It costs $5 and $10.",
+		},
+		{
+			name:     "display block",
+			markdown: "Solve:\n\n$$\n\\frac{a}{b} = \\sqrt{2}\n$$\n\nDone.",
+			expected: "Solve:\n\na/b = √2\n\nDone.",
+		},
+		{
+			name:     "display block on one line",
+			markdown: "\\[ E = mc^2 \\]",
+			expected: "E = mc²",
+		},
+		{
+			name:     "display block after text",
+			markdown: "We get\n$$\nx^2\n$$",
+			expected: "We get\n\nx²",
+		},
+		{
+			name:     "code keeps dollars",
+			markdown: "Run `echo $HOME$`",
+			expected: "Run <tt>echo $HOME$</tt>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.ToPango(tt.markdown); got != tt.expected {
+				t.Errorf("ToPango(%q) = %q, want %q", tt.markdown, got, tt.expected)
+			}
+		})
+	}
+}
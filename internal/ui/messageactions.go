@@ -0,0 +1,423 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// setBubbleMessage associates a bubble with its stored message and enables
+// the actions that need it.
+func (cv *ChatView) setBubbleMessage(bubble *MessageBubble, id int64) {
+	bubble.SetMessageID(id)
+
+	if role := bubble.GetRole(); role == store.RoleUser || role == store.RoleAssistant {
+		bubble.ShowCopyActions()
+		bubble.OnQuote(func() {
+			cv.quoteInReply(bubble)
+		})
+		bubble.OnSaveToFile(func() {
+			cv.saveMessageToFile(bubble)
+		})
+	}
+
+	switch bubble.GetRole() {
+	case store.RoleAssistant:
+		bubble.OnRegenerate(func() {
+			cv.regenerate(bubble)
+		})
+		bubble.OnContinue(func() {
+			cv.continueResponse(bubble)
+		})
+		bubble.OnShare(func() {
+			cv.shareAsImage(bubble)
+		})
+		bubble.OnSendToNotes(func() {
+			cv.sendAnswerToNotes(bubble)
+		})
+		bubble.OnRate(func(rating int) {
+			cv.rateMessage(bubble, rating)
+		})
+		bubble.OnAnnotate(func() {
+			cv.annotateMessage(bubble)
+		})
+		bubble.OnBookmark(func(bookmarked bool) {
+			cv.bookmarkMessage(bubble, bookmarked)
+		})
+		if cv.appConfig != nil && len(cv.appConfig.Pipes()) > 0 {
+			bubble.OnPipe(func(anchor *gtk.Button) {
+				cv.choosePipeCommand(bubble, anchor)
+			})
+		}
+		if bubble.Replaces() != 0 {
+			bubble.OnCompare(func() {
+				cv.compareResponses(bubble)
+			})
+		}
+	case store.RoleUser:
+		bubble.OnEdit(func(text string) {
+			cv.editMessage(bubble, text)
+		})
+	}
+	bubble.OnBranch(func() {
+		cv.branchFrom(bubble)
+	})
+}
+
+// removeBubblesAfter removes every bubble that comes after bubble.
+func (cv *ChatView) removeBubblesAfter(bubble *MessageBubble) {
+	for i, b := range cv.messages {
+		if b != bubble {
+			continue
+		}
+		for _, later := range cv.messages[i+1:] {
+			cv.messagesBox.Remove(later)
+		}
+		cv.messages = cv.messages[:i+1]
+		return
+	}
+}
+
+// regenerate replaces an assistant response with a new one. The response
+// and everything after it are superseded in the database, and the history
+// up to the preceding user message is sent to the model again. The new
+// response records the one it replaces, so that they can be compared.
+func (cv *ChatView) regenerate(bubble *MessageBubble) {
+	if cv.isStreaming || cv.db == nil || cv.currentChat == nil || bubble.MessageID() == 0 {
+		return
+	}
+
+	chatID := cv.currentChat.ID
+	if err := cv.db.SupersedeMessagesFrom(chatID, bubble.MessageID()); err != nil {
+		logger.Error("Failed to supersede messages", "chatID", chatID, "messageID", bubble.MessageID(), "error", err)
+		cv.handleError(err)
+		return
+	}
+	logger.Info("Regenerating response", "chatID", chatID, "messageID", bubble.MessageID())
+
+	cv.removeBubblesAfter(bubble)
+	bubble.SetReplaces(bubble.MessageID())
+	bubble.SetMessageID(0)
+	bubble.SetBookmarked(false)
+	bubble.SetRating(store.RatingNone)
+	bubble.SetAnnotation("")
+	bubble.ClearCritique()
+	bubble.SetTruncated(false)
+	bubble.SetContent("")
+	bubble.SetThinking(true)
+	cv.currentBubble = bubble
+
+	cv.stats.Begin(time.Now())
+	cv.streamResponse(cv.buildMessageHistory(), historyFull)
+}
+
+// continueResponse asks the model to finish a response that was stopped.
+// The partial response is sent as the last message of the history, which
+// Ollama continues instead of starting a new reply, and the new tokens are
+// appended to it.
+func (cv *ChatView) continueResponse(bubble *MessageBubble) {
+	if cv.isStreaming || cv.db == nil || cv.currentChat == nil || bubble.MessageID() == 0 {
+		return
+	}
+	if len(cv.messages) == 0 || cv.messages[len(cv.messages)-1] != bubble {
+		cv.notify(i18n.T("Only the last response can be continued"))
+		return
+	}
+	logger.Info("Continuing response", "chatID", cv.currentChat.ID, "messageID", bubble.MessageID())
+
+	bubble.SetTruncated(false)
+	cv.currentBubble = bubble
+
+	cv.stats.Begin(time.Now())
+	cv.streamResponse(cv.buildMessageHistory(), historyFull)
+}
+
+// saveContinuedResponse stores the content and statistics of a continued
+// response.
+func (cv *ChatView) saveContinuedResponse(bubble *MessageBubble, content string, truncated bool, stats *ollama.ResponseStats) {
+	id := bubble.MessageID()
+	if err := cv.db.UpdateMessageContent(id, content); err != nil {
+		logger.Error("Failed to update continued response", "messageID", id, "error", err)
+		cv.handleError(err)
+		return
+	}
+	if err := cv.db.UpdateMessageTruncated(id, truncated); err != nil {
+		logger.Error("Failed to update truncated flag", "messageID", id, "error", err)
+	}
+	if saved := messageStats(stats); saved != nil {
+		saved.MessageID = id
+		if err := cv.db.SaveMessageStats(saved); err != nil {
+			logger.Error("Failed to save message stats", "messageID", id, "error", err)
+		}
+	}
+}
+
+// promptFor returns the content of the user message that bubble replies to.
+func (cv *ChatView) promptFor(bubble *MessageBubble) string {
+	for i := len(cv.messages) - 1; i >= 0; i-- {
+		if cv.messages[i] != bubble {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if cv.messages[j].GetRole() == store.RoleUser {
+				return cv.messages[j].GetContent()
+			}
+		}
+		break
+	}
+	return ""
+}
+
+// chatModel returns the model of the current chat, or the selected model.
+func (cv *ChatView) chatModel() string {
+	if cv.currentChat != nil && cv.currentChat.Model != "" {
+		return cv.currentChat.Model
+	}
+	return cv.currentModel
+}
+
+// shareAsImage asks where to save the exchange ending with bubble and
+// renders it as an image card.
+func (cv *ChatView) shareAsImage(bubble *MessageBubble) {
+	card := shareCard{
+		Prompt: cv.promptFor(bubble),
+		Answer: bubble.GetContent(),
+		Model:  cv.chatModel(),
+	}
+
+	chooser := gtk.NewFileChooserNative(
+		i18n.T("Share as image"),
+		cv.parentWindow(),
+		gtk.FileChooserActionSave,
+		i18n.T("Save"),
+		i18n.T("Cancel"),
+	)
+	chooser.SetCurrentName(shareCardFileName(time.Now()))
+
+	chooser.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := chooser.File(); file != nil && file.Path() != "" {
+				path := file.Path()
+				if err := renderShareCard(card, path); err != nil {
+					logger.Error("Failed to render image", "path", path, "error", err)
+					cv.handleError(err)
+				} else {
+					logger.Info("Exchange shared as image", "path", path)
+					cv.notify(fmt.Sprintf(i18n.T("Image saved to %s"), path))
+				}
+			}
+		}
+		chooser.Destroy()
+	})
+
+	chooser.Show()
+}
+
+// messageFileName returns the suggested file name for a message saved at t.
+func messageFileName(t time.Time) string {
+	return "guanaco-" + t.Format("2006-01-02-150405") + ".md"
+}
+
+// quoteInReply adds the message of bubble to the input as a block quote.
+func (cv *ChatView) quoteInReply(bubble *MessageBubble) {
+	text := bubble.Text()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	cv.inputArea.SetText(appendQuote(cv.inputArea.GetText(), text))
+	cv.inputArea.Focus()
+}
+
+// saveMessageToFile asks where to save the message of bubble and writes it
+// as a Markdown file.
+func (cv *ChatView) saveMessageToFile(bubble *MessageBubble) {
+	text := bubble.Text()
+
+	chooser := gtk.NewFileChooserNative(
+		i18n.T("Save to file"),
+		cv.parentWindow(),
+		gtk.FileChooserActionSave,
+		i18n.T("Save"),
+		i18n.T("Cancel"),
+	)
+	chooser.SetCurrentName(messageFileName(time.Now()))
+
+	chooser.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := chooser.File(); file != nil && file.Path() != "" {
+				path := file.Path()
+				if err := os.WriteFile(path, []byte(strings.TrimSpace(text)+"\n"), 0644); err != nil {
+					logger.Error("Failed to save message", "path", path, "error", err)
+					cv.handleError(err)
+				} else {
+					logger.Info("Message saved to file", "path", path)
+					cv.notify(fmt.Sprintf(i18n.T("Message saved to %s"), path))
+				}
+			}
+		}
+		chooser.Destroy()
+	})
+
+	chooser.Show()
+}
+
+// bookmarkMessage marks a response as a good one, to export it as training
+// data, or clears the mark.
+func (cv *ChatView) bookmarkMessage(bubble *MessageBubble, bookmarked bool) {
+	if cv.db == nil || bubble.MessageID() == 0 {
+		return
+	}
+	if err := cv.db.UpdateMessageBookmarked(bubble.MessageID(), bookmarked); err != nil {
+		logger.Error("Failed to update bookmark", "messageID", bubble.MessageID(), "error", err)
+		cv.handleError(err)
+		return
+	}
+	bubble.SetBookmarked(bookmarked)
+}
+
+// rateMessage sets the rating of a response.
+func (cv *ChatView) rateMessage(bubble *MessageBubble, rating int) {
+	if cv.db == nil || bubble.MessageID() == 0 {
+		return
+	}
+	if err := cv.db.UpdateMessageRating(bubble.MessageID(), rating); err != nil {
+		logger.Error("Failed to update rating", "messageID", bubble.MessageID(), "error", err)
+		cv.handleError(err)
+		return
+	}
+	bubble.SetRating(rating)
+}
+
+// annotateMessage asks for a note about a response, such as what is wrong
+// with it, and saves it. An empty note removes it.
+func (cv *ChatView) annotateMessage(bubble *MessageBubble) {
+	if cv.db == nil || bubble.MessageID() == 0 {
+		return
+	}
+
+	textView := gtk.NewTextView()
+	textView.Buffer().SetText(bubble.Annotation())
+	textView.SetWrapMode(gtk.WrapWordChar)
+	textView.SetTopMargin(8)
+	textView.SetBottomMargin(8)
+	textView.SetLeftMargin(8)
+	textView.SetRightMargin(8)
+	textView.AddCSSClass("card")
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(textView)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetSizeRequest(360, 120)
+
+	dialog := adw.NewMessageDialog(cv.parentWindow(), i18n.T("Note About This Response"),
+		i18n.T("What is good or wrong in it. Notes are kept with the response and included in JSON exports."))
+	dialog.SetExtraChild(scrolled)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("save", i18n.T("Save"))
+	dialog.SetResponseAppearance("save", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("save")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		if response != "save" {
+			return
+		}
+		buffer := textView.Buffer()
+		note := strings.TrimSpace(buffer.Text(buffer.StartIter(), buffer.EndIter(), false))
+		if err := cv.db.UpdateMessageAnnotation(bubble.MessageID(), note); err != nil {
+			logger.Error("Failed to save note", "messageID", bubble.MessageID(), "error", err)
+			cv.handleError(err)
+			return
+		}
+		bubble.SetAnnotation(note)
+	})
+
+	dialog.Present()
+	textView.GrabFocus()
+}
+
+// compareResponses shows what changed between a regenerated response and
+// the earlier responses it replaced.
+func (cv *ChatView) compareResponses(bubble *MessageBubble) {
+	if cv.db == nil || bubble.MessageID() == 0 {
+		return
+	}
+
+	alternatives, err := cv.db.GetAlternatives(bubble.MessageID())
+	if err != nil {
+		logger.Error("Failed to load earlier responses", "messageID", bubble.MessageID(), "error", err)
+		cv.handleError(err)
+		return
+	}
+	if len(alternatives) == 0 {
+		cv.notify(i18n.T("There are no earlier responses to compare with"))
+		return
+	}
+
+	NewResponseDiffDialog(cv.parentWindow(), alternatives, bubble.GetContent()).Present()
+}
+
+// branchFrom copies the conversation up to bubble into a new chat, leaving
+// the current one untouched.
+func (cv *ChatView) branchFrom(bubble *MessageBubble) {
+	if cv.isStreaming || cv.db == nil || cv.currentChat == nil || bubble.MessageID() == 0 {
+		return
+	}
+
+	branch, err := cv.db.CloneChatUpTo(cv.currentChat.ID, bubble.MessageID())
+	if err != nil {
+		logger.Error("Failed to branch chat", "chatID", cv.currentChat.ID, "messageID", bubble.MessageID(), "error", err)
+		cv.handleError(err)
+		return
+	}
+	logger.Info("Chat branched", "chatID", cv.currentChat.ID, "branchID", branch.ID)
+
+	if cv.onBranched != nil {
+		cv.onBranched(branch)
+	}
+}
+
+// editMessage replaces the text of a user message and restarts the
+// conversation from it. Everything after the message is deleted from the
+// database, keeping its attachments.
+func (cv *ChatView) editMessage(bubble *MessageBubble, text string) {
+	if cv.isStreaming || cv.db == nil || cv.currentChat == nil || bubble.MessageID() == 0 {
+		return
+	}
+
+	content := replaceUserText(bubble.GetContent(), text)
+	if content == "" {
+		return
+	}
+
+	chatID := cv.currentChat.ID
+	if err := cv.db.DeleteMessagesAfter(chatID, bubble.MessageID()); err != nil {
+		logger.Error("Failed to delete messages", "chatID", chatID, "messageID", bubble.MessageID(), "error", err)
+		cv.handleError(err)
+		return
+	}
+	if err := cv.db.UpdateMessageContent(bubble.MessageID(), content); err != nil {
+		logger.Error("Failed to update message", "messageID", bubble.MessageID(), "error", err)
+		cv.handleError(err)
+		return
+	}
+	logger.Info("Resending edited message", "chatID", chatID, "messageID", bubble.MessageID())
+
+	cv.removeBubblesAfter(bubble)
+	bubble.SetContent(content)
+
+	cv.currentBubble = cv.addMessage(store.RoleAssistant, "")
+	cv.currentBubble.SetThinking(true)
+
+	cv.stats.Begin(time.Now())
+	cv.streamResponse(cv.buildMessageHistory(), historyFull)
+}
@@ -1,11 +1,17 @@
 package ui
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 	"github.com/diamondburned/gotk4/pkg/pango"
 
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/ollama"
 	"github.com/storo/guanaco/internal/store"
 )
 
@@ -14,6 +20,15 @@ func containsCodeBlock(content string) bool {
 	return strings.Contains(content, "```")
 }
 
+// tableDelimiterPattern matches the line under the header of a Markdown
+// table, such as "|---|:--:|".
+var tableDelimiterPattern = regexp.MustCompile(`(?m)^ {0,3}\|?\s*:?-+:?\s*\|`)
+
+// containsTable checks if content has a table, shown in its own widget.
+func containsTable(content string) bool {
+	return tableDelimiterPattern.MatchString(content)
+}
+
 // Shared markdown renderer for all message bubbles
 var mdRenderer = NewMarkdownRenderer()
 
@@ -23,11 +38,50 @@ type MessageBubble struct {
 
 	contentBox        *gtk.Box
 	container         *gtk.Box
+	column            *gtk.Box // User bubbles: the card with the actions row below it
 	role              store.Role
 	content           string
-	textLabel         *gtk.Label          // Cached label for incremental updates
-	thinkingIndicator *ThinkingIndicator  // Animated indicator
-	isThinking        bool                // Whether we're showing the thinking animation
+	textLabel         *gtk.Label         // Cached label for incremental updates
+	thinkingIndicator *ThinkingIndicator // Animated indicator
+	isThinking        bool               // Whether we're showing the thinking animation
+	agentLabel        *gtk.Label         // Participant name in multi-agent conversations
+	critiqueLabel     *gtk.Label         // Self-review notes shown in an expander
+	critiqueExpander  *gtk.Expander      // Holds critiqueLabel
+	reasoningLabel    *gtk.Label         // Reasoning of a reasoning model, see reasoning.go
+	reasoningExpander *gtk.Expander      // Holds reasoningLabel
+	actionsBox        *gtk.Box           // Per-message action buttons
+	messageID         int64              // Database ID, 0 until the message is saved
+	replaces          int64              // Database ID of the response this one regenerated
+	editor            *gtk.Box           // Inline editor shown while editing
+	editView          *gtk.TextView
+	truncatedBar      *gtk.Box              // Notice and continue button for a stopped response
+	statsLabel        *gtk.Label            // Token usage footer of a response
+	stats             *ollama.ResponseStats // Token usage shown in statsLabel
+	copyActions       bool                  // Whether the copy buttons were added
+	bookmarked        bool                  // Marked by the user as a good response
+	bookmarkBtn       *gtk.Button           // Toggles bookmarked
+	rating            int                   // store.RatingUp, store.RatingDown or store.RatingNone
+	rateUpBtn         *gtk.Button
+	rateDownBtn       *gtk.Button
+	annotation        string      // The user's note about the message
+	annotateBtn       *gtk.Button // Opens the note, shown in its tooltip
+	modelSwitch       bool        // Marks where the chat went on with another model
+	jsonOutput        bool        // Responses are JSON, shown as a tree
+
+	// Callbacks
+	onRegenerate func()
+	onEdit       func(text string)
+	onContinue   func()
+	onShare      func()
+	onBranch     func()
+	onNotes      func()
+	onCompare    func()
+	onQuote      func()
+	onSaveFile   func()
+	onPipe       func(anchor *gtk.Button)
+	onBookmark   func(bookmarked bool)
+	onRate       func(rating int)
+	onAnnotate   func()
 }
 
 // NewMessageBubble creates a new message bubble.
@@ -69,11 +123,15 @@ func (mb *MessageBubble) setupUI() {
 		mb.container.AddCSSClass("card")
 		mb.container.Append(mb.contentBox)
 
+		// Actions go below the card, not inside it
+		mb.column = gtk.NewBox(gtk.OrientationVertical, 0)
+		mb.column.Append(mb.container)
+
 		// Spacer pushes bubble to the right
 		spacer := gtk.NewBox(gtk.OrientationHorizontal, 0)
 		spacer.SetHExpand(true)
 		mb.Append(spacer)
-		mb.Append(mb.container)
+		mb.Append(mb.column)
 
 	case store.RoleAssistant:
 		// Assistant: plain text, no card background
@@ -81,8 +139,11 @@ func (mb *MessageBubble) setupUI() {
 		mb.SetMarginStart(16)
 		mb.SetMarginEnd(48) // Leave space on the right
 
-		// No container/card - just contentBox directly
-		mb.Append(mb.contentBox)
+		// No card - content with the actions row below it
+		mb.container = gtk.NewBox(gtk.OrientationVertical, 0)
+		mb.container.SetHExpand(true)
+		mb.container.Append(mb.contentBox)
+		mb.Append(mb.container)
 
 	case store.RoleSystem:
 		// System: centered, subtle card
@@ -123,12 +184,26 @@ func (mb *MessageBubble) renderContent() {
 	// Reset cached label
 	mb.textLabel = nil
 
+	reasoning, content, open := splitReasoning(mb.content)
+	mb.setReasoning(reasoning, open)
+
+	if plainTextMessages {
+		mb.textLabel = mb.createPlainLabel(content)
+		mb.contentBox.Prepend(mb.textLabel)
+		return
+	}
+
+	if mb.showsJSON(content) {
+		mb.contentBox.Append(NewJSONBlock(content))
+		return
+	}
+
 	// Parse content into parts
-	parts := mdRenderer.Parse(mb.content)
+	parts := mdRenderer.Parse(content)
 
 	// If no parts, just add as text
 	if len(parts) == 0 {
-		label := mb.createTextLabel(mb.content)
+		label := mb.createTextLabel(content)
 		mb.textLabel = label // Cache for incremental updates
 		mb.contentBox.Prepend(label)
 		return
@@ -142,12 +217,14 @@ func (mb *MessageBubble) renderContent() {
 		return
 	}
 
-	// Multiple parts or has code blocks - full render
+	// Multiple parts or has code blocks or tables - full render
 	for _, part := range parts {
 		switch part.Type {
 		case "code":
 			codeBlock := NewCodeBlock(part.Content, part.Language)
 			mb.contentBox.Append(codeBlock)
+		case "table":
+			mb.contentBox.Append(NewTableBlock(part.Rows, part.Alignments))
 		case "text":
 			label := mb.createTextLabel(part.Content)
 			mb.contentBox.Append(label)
@@ -182,13 +259,28 @@ func (mb *MessageBubble) SetContent(content string) {
 		mb.SetThinking(false)
 	}
 
-	oldContent := mb.content
+	_, oldAnswer, _ := splitReasoning(mb.content)
 	mb.content = content
+	reasoning, answer, open := splitReasoning(content)
+
+	if plainTextMessages && mb.textLabel != nil {
+		mb.setReasoning(reasoning, open)
+		mb.textLabel.SetText(mdRenderer.ToAccessibleText(answer))
+		return
+	}
+
+	// A JSON response is shown as a tree once it is complete
+	if mb.showsJSON(answer) {
+		mb.renderContent()
+		return
+	}
 
 	// Optimization: if content doesn't have code blocks and we have a cached label,
 	// just update the markup without recreating widgets
-	if mb.textLabel != nil && !containsCodeBlock(content) && !containsCodeBlock(oldContent) {
-		mb.textLabel.SetMarkup(mdRenderer.ToPango(content))
+	if mb.textLabel != nil && !containsCodeBlock(answer) && !containsCodeBlock(oldAnswer) &&
+		!containsTable(answer) && !containsTable(oldAnswer) {
+		mb.setReasoning(reasoning, open)
+		mb.textLabel.SetMarkup(mdRenderer.ToPango(answer))
 		return
 	}
 
@@ -233,6 +325,585 @@ func (mb *MessageBubble) SetThinking(thinking bool) {
 	}
 }
 
+// SetAgent styles the bubble as a message from one participant of a
+// multi-agent conversation, showing its name above the content.
+func (mb *MessageBubble) SetAgent(name string, index int) {
+	if mb.agentLabel != nil {
+		mb.agentLabel.SetText(name)
+		return
+	}
+
+	side := index % 2
+	mb.AddCSSClass(fmt.Sprintf("message-agent-%d", side))
+
+	// Turn the content into a card with the participant name on top
+	mb.container.AddCSSClass("card")
+
+	mb.agentLabel = gtk.NewLabel(name)
+	mb.agentLabel.SetXAlign(0)
+	mb.agentLabel.SetMarginTop(8)
+	mb.agentLabel.SetMarginStart(16)
+	mb.agentLabel.SetMarginEnd(16)
+	mb.agentLabel.AddCSSClass("caption-heading")
+	mb.agentLabel.AddCSSClass("agent-name")
+	mb.container.Prepend(mb.agentLabel)
+
+	// Alternate sides so the exchange reads like a dialogue
+	if side == 1 {
+		mb.SetMarginStart(48)
+		mb.SetMarginEnd(16)
+	}
+}
+
+// SetCritique shows the self-review critique in a collapsed expander below
+// the content.
+func (mb *MessageBubble) SetCritique(critique string) {
+	if mb.critiqueLabel == nil {
+		mb.critiqueLabel = gtk.NewLabel("")
+		mb.critiqueLabel.SetWrap(true)
+		mb.critiqueLabel.SetWrapMode(pango.WrapWordChar)
+		mb.critiqueLabel.SetXAlign(0)
+		mb.critiqueLabel.SetSelectable(true)
+		mb.critiqueLabel.SetMarginTop(4)
+		mb.critiqueLabel.AddCSSClass("dim-label")
+
+		mb.critiqueExpander = gtk.NewExpander(i18n.T("Self-review"))
+		mb.critiqueExpander.SetChild(mb.critiqueLabel)
+		mb.critiqueExpander.SetMarginStart(16)
+		mb.critiqueExpander.SetMarginEnd(16)
+		mb.critiqueExpander.SetMarginBottom(8)
+		mb.critiqueExpander.AddCSSClass("critique")
+		mb.container.InsertChildAfter(mb.critiqueExpander, mb.contentBox)
+	}
+
+	mb.critiqueLabel.SetMarkup(mdRenderer.ToPango(critique))
+}
+
+// ClearCritique removes the self-review expander, if any.
+func (mb *MessageBubble) ClearCritique() {
+	if mb.critiqueExpander == nil {
+		return
+	}
+	mb.container.Remove(mb.critiqueExpander)
+	mb.critiqueExpander = nil
+	mb.critiqueLabel = nil
+}
+
+// SetMessageID associates the bubble with its database message.
+func (mb *MessageBubble) SetMessageID(id int64) {
+	mb.messageID = id
+}
+
+// MessageID returns the database ID of the message, or 0 if it isn't saved.
+func (mb *MessageBubble) MessageID() int64 {
+	return mb.messageID
+}
+
+// SetReplaces records the database ID of the earlier response that this
+// one regenerated.
+func (mb *MessageBubble) SetReplaces(id int64) {
+	mb.replaces = id
+}
+
+// Replaces returns the database ID of the response this one regenerated,
+// or 0 if it isn't a regenerated response.
+func (mb *MessageBubble) Replaces() int64 {
+	return mb.replaces
+}
+
+// SetJSONOutput sets whether the responses of the chat are JSON, shown as a
+// collapsible tree when they are a complete object or array.
+func (mb *MessageBubble) SetJSONOutput(jsonOutput bool) {
+	if mb.jsonOutput == jsonOutput {
+		return
+	}
+	mb.jsonOutput = jsonOutput
+	if mb.content != "" && !mb.isThinking {
+		mb.renderContent()
+	}
+}
+
+// showsJSON reports whether the answer of a response is shown as JSON.
+func (mb *MessageBubble) showsJSON(answer string) bool {
+	return mb.jsonOutput && mb.role == store.RoleAssistant && isJSONDocument(answer)
+}
+
+// SetTruncated shows or hides the notice that the response was stopped
+// before the model finished, with a button to continue it.
+func (mb *MessageBubble) SetTruncated(truncated bool) {
+	if !truncated {
+		if mb.truncatedBar != nil {
+			mb.container.Remove(mb.truncatedBar)
+			mb.truncatedBar = nil
+		}
+		return
+	}
+	if mb.truncatedBar != nil {
+		return
+	}
+
+	mb.truncatedBar = gtk.NewBox(gtk.OrientationHorizontal, 8)
+	mb.truncatedBar.AddCSSClass("truncated-notice")
+	mb.truncatedBar.SetMarginStart(16)
+	mb.truncatedBar.SetMarginEnd(16)
+	mb.truncatedBar.SetMarginBottom(8)
+
+	label := gtk.NewLabel(i18n.T("Response stopped"))
+	label.AddCSSClass("dim-label")
+	label.AddCSSClass("caption")
+	mb.truncatedBar.Append(label)
+
+	continueBtn := gtk.NewButtonWithLabel(i18n.T("Continue"))
+	continueBtn.SetTooltipText(i18n.T("Let the model finish the response"))
+	continueBtn.AddCSSClass("pill")
+	continueBtn.AddCSSClass("flat")
+	continueBtn.ConnectClicked(func() {
+		if mb.onContinue != nil {
+			mb.onContinue()
+		}
+	})
+	mb.truncatedBar.Append(continueBtn)
+
+	var after gtk.Widgetter = mb.contentBox
+	if mb.critiqueExpander != nil {
+		after = mb.critiqueExpander
+	}
+	mb.container.InsertChildAfter(mb.truncatedBar, after)
+}
+
+// IsTruncated returns whether the response is marked as stopped.
+func (mb *MessageBubble) IsTruncated() bool {
+	return mb.truncatedBar != nil
+}
+
+// OnContinue sets the callback for the continue button of a stopped
+// response.
+func (mb *MessageBubble) OnContinue(callback func()) {
+	mb.onContinue = callback
+}
+
+// SetStats shows the token usage of the response in a footer below its
+// content. Nil stats hide the footer.
+func (mb *MessageBubble) SetStats(stats *ollama.ResponseStats) {
+	mb.stats = stats
+	if stats == nil {
+		if mb.statsLabel != nil {
+			mb.container.Remove(mb.statsLabel)
+			mb.statsLabel = nil
+		}
+		return
+	}
+
+	if mb.statsLabel == nil {
+		mb.statsLabel = gtk.NewLabel("")
+		mb.statsLabel.SetXAlign(0)
+		mb.statsLabel.AddCSSClass("dim-label")
+		mb.statsLabel.AddCSSClass("caption")
+		mb.statsLabel.SetMarginStart(16)
+		mb.statsLabel.SetMarginEnd(16)
+		mb.statsLabel.SetMarginBottom(8)
+
+		var after gtk.Widgetter = mb.contentBox
+		if mb.truncatedBar != nil {
+			after = mb.truncatedBar
+		} else if mb.critiqueExpander != nil {
+			after = mb.critiqueExpander
+		}
+		mb.container.InsertChildAfter(mb.statsLabel, after)
+	}
+	mb.statsLabel.SetText(formatStatsFooter(*stats))
+	mb.statsLabel.SetTooltipText(formatStatsDetails(*stats))
+}
+
+// Stats returns the token usage of the response, or nil if unknown.
+func (mb *MessageBubble) Stats() *ollama.ResponseStats {
+	return mb.stats
+}
+
+// addAction adds a small icon button to the actions row below the content.
+func (mb *MessageBubble) addAction(iconName, tooltip string, callback func()) *gtk.Button {
+	if mb.actionsBox == nil {
+		mb.actionsBox = gtk.NewBox(gtk.OrientationHorizontal, 4)
+		mb.actionsBox.AddCSSClass("message-actions")
+		mb.actionsBox.SetMarginStart(12)
+		mb.actionsBox.SetMarginEnd(12)
+		if mb.column != nil {
+			mb.actionsBox.SetHAlign(gtk.AlignEnd)
+			mb.column.Append(mb.actionsBox)
+		} else {
+			mb.container.Append(mb.actionsBox)
+		}
+	}
+
+	btn := gtk.NewButton()
+	btn.SetIconName(iconName)
+	btn.SetTooltipText(tooltip)
+	btn.AddCSSClass("flat")
+	btn.AddCSSClass("circular")
+	btn.ConnectClicked(callback)
+	mb.actionsBox.Append(btn)
+	return btn
+}
+
+// Text returns the Markdown text of the message, without the attachment
+// indicator of user messages.
+func (mb *MessageBubble) Text() string {
+	if mb.role == store.RoleUser {
+		return extractUserText(mb.content)
+	}
+	return mb.content
+}
+
+// ShowCopyActions shows buttons to copy the message as Markdown or as plain
+// text.
+func (mb *MessageBubble) ShowCopyActions() {
+	if mb.copyActions {
+		return
+	}
+	mb.copyActions = true
+
+	var markdownBtn, plainBtn *gtk.Button
+	markdownBtn = mb.addAction("edit-copy-symbolic", i18n.T("Copy as Markdown"), func() {
+		copyWithFeedback(markdownBtn, mb.Text(), i18n.T("Copy as Markdown"))
+	})
+	plainBtn = mb.addAction("text-x-generic-symbolic", i18n.T("Copy as plain text"), func() {
+		copyWithFeedback(plainBtn, mdRenderer.ToPlainText(mb.Text()), i18n.T("Copy as plain text"))
+	})
+}
+
+// copyWithFeedback copies text to the clipboard and briefly shows on btn
+// that it was copied.
+func copyWithFeedback(btn *gtk.Button, text, tooltip string) {
+	gdk.DisplayGetDefault().Clipboard().SetText(text)
+
+	icon := btn.IconName()
+	btn.SetIconName("object-select-symbolic")
+	btn.SetTooltipText(i18n.T("Copied!"))
+	glib.TimeoutAdd(1500, func() bool {
+		btn.SetIconName(icon)
+		btn.SetTooltipText(tooltip)
+		return false
+	})
+}
+
+// OnQuote shows a button to quote the message in the reply that calls
+// callback when clicked.
+func (mb *MessageBubble) OnQuote(callback func()) {
+	if mb.onQuote == nil {
+		mb.addAction("format-indent-more-symbolic", i18n.T("Quote in reply"), func() {
+			if mb.onQuote != nil {
+				mb.onQuote()
+			}
+		})
+	}
+	mb.onQuote = callback
+}
+
+// OnSaveToFile shows a button to save the message as a Markdown file that
+// calls callback when clicked.
+func (mb *MessageBubble) OnSaveToFile(callback func()) {
+	if mb.onSaveFile == nil {
+		mb.addAction("document-save-symbolic", i18n.T("Save to file"), func() {
+			if mb.onSaveFile != nil {
+				mb.onSaveFile()
+			}
+		})
+	}
+	mb.onSaveFile = callback
+}
+
+// OnPipe shows a button to send the message to an external command that
+// calls callback with the button, to anchor a menu to, when clicked.
+func (mb *MessageBubble) OnPipe(callback func(anchor *gtk.Button)) {
+	if mb.onPipe == nil {
+		var btn *gtk.Button
+		btn = mb.addAction("utilities-terminal-symbolic", i18n.T("Pipe to command"), func() {
+			if mb.onPipe != nil {
+				mb.onPipe(btn)
+			}
+		})
+	}
+	mb.onPipe = callback
+}
+
+// OnBookmark shows a button to bookmark the message that calls callback
+// with the state to switch to when clicked.
+func (mb *MessageBubble) OnBookmark(callback func(bookmarked bool)) {
+	if mb.onBookmark == nil {
+		mb.bookmarkBtn = mb.addAction("non-starred-symbolic", i18n.T("Bookmark"), func() {
+			if mb.onBookmark != nil {
+				mb.onBookmark(!mb.bookmarked)
+			}
+		})
+		mb.updateBookmarkButton()
+	}
+	mb.onBookmark = callback
+}
+
+// SetBookmarked sets whether the message is bookmarked.
+func (mb *MessageBubble) SetBookmarked(bookmarked bool) {
+	mb.bookmarked = bookmarked
+	mb.updateBookmarkButton()
+}
+
+// updateBookmarkButton shows on the bookmark button whether the message is
+// bookmarked.
+func (mb *MessageBubble) updateBookmarkButton() {
+	if mb.bookmarkBtn == nil {
+		return
+	}
+	if mb.bookmarked {
+		mb.bookmarkBtn.SetIconName("starred-symbolic")
+		mb.bookmarkBtn.SetTooltipText(i18n.T("Remove bookmark"))
+	} else {
+		mb.bookmarkBtn.SetIconName("non-starred-symbolic")
+		mb.bookmarkBtn.SetTooltipText(i18n.T("Bookmark"))
+	}
+}
+
+// OnRate shows buttons to rate the message as good or bad that call
+// callback with the rating to switch to when clicked. Clicking the current
+// rating again clears it.
+func (mb *MessageBubble) OnRate(callback func(rating int)) {
+	if mb.onRate == nil {
+		rate := func(rating int) {
+			if mb.onRate == nil {
+				return
+			}
+			if mb.rating == rating {
+				rating = store.RatingNone
+			}
+			mb.onRate(rating)
+		}
+		mb.rateUpBtn = mb.addAction("face-smile-symbolic", i18n.T("Good response"), func() {
+			rate(store.RatingUp)
+		})
+		mb.rateDownBtn = mb.addAction("face-sad-symbolic", i18n.T("Bad response"), func() {
+			rate(store.RatingDown)
+		})
+		mb.updateRatingButtons()
+	}
+	mb.onRate = callback
+}
+
+// SetRating sets the rating of the message.
+func (mb *MessageBubble) SetRating(rating int) {
+	mb.rating = rating
+	mb.updateRatingButtons()
+}
+
+// updateRatingButtons highlights the button of the current rating.
+func (mb *MessageBubble) updateRatingButtons() {
+	if mb.rateUpBtn == nil {
+		return
+	}
+	for _, b := range []struct {
+		btn    *gtk.Button
+		rating int
+	}{{mb.rateUpBtn, store.RatingUp}, {mb.rateDownBtn, store.RatingDown}} {
+		if mb.rating == b.rating {
+			b.btn.AddCSSClass("accent")
+		} else {
+			b.btn.RemoveCSSClass("accent")
+		}
+	}
+}
+
+// OnAnnotate shows a button to write a note about the message that calls
+// callback when clicked.
+func (mb *MessageBubble) OnAnnotate(callback func()) {
+	if mb.onAnnotate == nil {
+		mb.annotateBtn = mb.addAction("document-properties-symbolic", i18n.T("Add note"), func() {
+			if mb.onAnnotate != nil {
+				mb.onAnnotate()
+			}
+		})
+		mb.updateAnnotateButton()
+	}
+	mb.onAnnotate = callback
+}
+
+// SetAnnotation sets the note about the message.
+func (mb *MessageBubble) SetAnnotation(annotation string) {
+	mb.annotation = annotation
+	mb.updateAnnotateButton()
+}
+
+// Annotation returns the note about the message.
+func (mb *MessageBubble) Annotation() string {
+	return mb.annotation
+}
+
+// updateAnnotateButton shows the note in the tooltip of the note button.
+func (mb *MessageBubble) updateAnnotateButton() {
+	if mb.annotateBtn == nil {
+		return
+	}
+	if mb.annotation == "" {
+		mb.annotateBtn.SetTooltipText(i18n.T("Add note"))
+		mb.annotateBtn.RemoveCSSClass("accent")
+		return
+	}
+	mb.annotateBtn.SetTooltipText(fmt.Sprintf(i18n.T("Note: %s"), mb.annotation))
+	mb.annotateBtn.AddCSSClass("accent")
+}
+
+// OnRegenerate shows a regenerate button that calls callback when clicked.
+func (mb *MessageBubble) OnRegenerate(callback func()) {
+	if mb.onRegenerate == nil {
+		mb.addAction("view-refresh-symbolic", i18n.T("Regenerate response"), func() {
+			if mb.onRegenerate != nil {
+				mb.onRegenerate()
+			}
+		})
+	}
+	mb.onRegenerate = callback
+}
+
+// OnShare shows a button to share the message as an image that calls
+// callback when clicked.
+func (mb *MessageBubble) OnShare(callback func()) {
+	if mb.onShare == nil {
+		mb.addAction("image-x-generic-symbolic", i18n.T("Share as image"), func() {
+			if mb.onShare != nil {
+				mb.onShare()
+			}
+		})
+	}
+	mb.onShare = callback
+}
+
+// OnCompare shows a button to compare the response with the earlier ones
+// it regenerated that calls callback when clicked.
+func (mb *MessageBubble) OnCompare(callback func()) {
+	if mb.onCompare == nil {
+		mb.addAction("view-dual-symbolic", i18n.T("Compare with earlier responses"), func() {
+			if mb.onCompare != nil {
+				mb.onCompare()
+			}
+		})
+	}
+	mb.onCompare = callback
+}
+
+// OnSendToNotes shows a button to add the message to the notes folder that
+// calls callback when clicked.
+func (mb *MessageBubble) OnSendToNotes(callback func()) {
+	if mb.onNotes == nil {
+		mb.addAction("accessories-text-editor-symbolic", i18n.T("Send to notes"), func() {
+			if mb.onNotes != nil {
+				mb.onNotes()
+			}
+		})
+	}
+	mb.onNotes = callback
+}
+
+// OnBranch shows a button to start a new chat from this message that calls
+// callback when clicked.
+func (mb *MessageBubble) OnBranch(callback func()) {
+	if mb.onBranch == nil {
+		mb.addAction("mail-forward-symbolic", i18n.T("Branch from here"), func() {
+			if mb.onBranch != nil {
+				mb.onBranch()
+			}
+		})
+	}
+	mb.onBranch = callback
+}
+
+// OnEdit shows an edit button that opens an inline editor with the message
+// text. When the edit is confirmed with changes, callback is called with the
+// new text.
+func (mb *MessageBubble) OnEdit(callback func(text string)) {
+	if mb.onEdit == nil {
+		mb.addAction("document-edit-symbolic", i18n.T("Edit message"), mb.startEditing)
+	}
+	mb.onEdit = callback
+}
+
+// startEditing replaces the content with an editor holding the message text.
+func (mb *MessageBubble) startEditing() {
+	if mb.editor != nil {
+		return
+	}
+	original := extractUserText(mb.content)
+
+	mb.editView = gtk.NewTextView()
+	mb.editView.SetWrapMode(gtk.WrapWordChar)
+	mb.editView.SetAcceptsTab(false)
+	mb.editView.SetSizeRequest(320, 72)
+	mb.editView.AddCSSClass("message-editor")
+	mb.editView.Buffer().SetText(original)
+
+	save := func() {
+		buffer := mb.editView.Buffer()
+		text := strings.TrimSpace(buffer.Text(buffer.StartIter(), buffer.EndIter(), false))
+		mb.stopEditing()
+		if text != original && mb.onEdit != nil {
+			mb.onEdit(text)
+		}
+	}
+
+	// Ctrl+Enter sends, Escape cancels, like the input area
+	keyController := gtk.NewEventControllerKey()
+	keyController.ConnectKeyPressed(func(keyval, keycode uint, state gdk.ModifierType) bool {
+		switch {
+		case keyval == gdk.KEY_Return && state&gdk.ControlMask != 0:
+			save()
+			return true
+		case keyval == gdk.KEY_Escape:
+			mb.stopEditing()
+			return true
+		}
+		return false
+	})
+	mb.editView.AddController(keyController)
+
+	cancelBtn := gtk.NewButton()
+	cancelBtn.SetLabel(i18n.T("Cancel"))
+	cancelBtn.ConnectClicked(mb.stopEditing)
+
+	sendBtn := gtk.NewButton()
+	sendBtn.SetLabel(i18n.T("Send"))
+	sendBtn.AddCSSClass("suggested-action")
+	sendBtn.ConnectClicked(save)
+
+	buttons := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttons.SetHAlign(gtk.AlignEnd)
+	buttons.Append(cancelBtn)
+	buttons.Append(sendBtn)
+
+	mb.editor = gtk.NewBox(gtk.OrientationVertical, 8)
+	mb.editor.SetMarginTop(8)
+	mb.editor.SetMarginBottom(8)
+	mb.editor.SetMarginStart(16)
+	mb.editor.SetMarginEnd(16)
+	mb.editor.Append(mb.editView)
+	mb.editor.Append(buttons)
+
+	mb.contentBox.SetVisible(false)
+	if mb.actionsBox != nil {
+		mb.actionsBox.SetVisible(false)
+	}
+	mb.container.InsertChildAfter(mb.editor, mb.contentBox)
+	mb.editView.GrabFocus()
+}
+
+// stopEditing removes the editor and shows the content again.
+func (mb *MessageBubble) stopEditing() {
+	if mb.editor == nil {
+		return
+	}
+	mb.container.Remove(mb.editor)
+	mb.editor = nil
+	mb.editView = nil
+	mb.contentBox.SetVisible(true)
+	if mb.actionsBox != nil {
+		mb.actionsBox.SetVisible(true)
+	}
+}
+
 // IsThinking returns whether the bubble is showing the thinking animation.
 func (mb *MessageBubble) IsThinking() bool {
 	return mb.isThinking
@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Long chats are rendered in windows: when a chat is opened only its most
+// recent messages are loaded from the database and get bubbles, and older
+// pages are loaded when the user scrolls to the top or asks for them with
+// the "show earlier" button. Recycling rows with a GtkListView was
+// considered, but bubbles vary a lot in height, hold selectable labels and code blocks,
+// and the streaming bubble must keep its identity while tokens arrive, all
+// of which fight row recycling. Capping how many bubbles exist gives most of
+// the resize and layout savings without those trade-offs.
+
+// messageWindowSize is how many messages are loaded at a time.
+const messageWindowSize = 40
+
+// loadMessagePage loads the page of a chat's messages before beforeID, 0
+// for the latest one, with their statistics, and counts the messages still
+// before it.
+func (cv *ChatView) loadMessagePage(chatID, beforeID int64) (messages []*store.Message, earlier int, err error) {
+	messages, err = cv.db.GetMessagesPage(chatID, messageWindowSize, beforeID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(messages) > 0 && messages[0].ID != 0 {
+		earlier, err = cv.db.CountMessagesBefore(chatID, messages[0].ID)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	cv.loadMessageStats(messages)
+	return messages, earlier, nil
+}
+
+// newStoredBubble creates a bubble for a message loaded from the database.
+func (cv *ChatView) newStoredBubble(msg *store.Message) *MessageBubble {
+	if msg.ModelSwitch {
+		bubble := NewModelSwitchBubble(msg.Content)
+		cv.setBubbleMessage(bubble, msg.ID)
+		return bubble
+	}
+	content := msg.Content
+	if msg.Agent != "" {
+		content = agentReply(content)
+	}
+	bubble := NewMessageBubble(msg.Role, content)
+	bubble.SetJSONOutput(cv.jsonOutput())
+	if msg.Agent != "" {
+		bubble.SetAgent(msg.Agent, msg.AgentSlot)
+	}
+	if msg.Critique != "" {
+		bubble.SetCritique(msg.Critique)
+	}
+	bubble.SetTruncated(msg.Truncated)
+	bubble.SetReplaces(msg.Replaces)
+	bubble.SetBookmarked(msg.Bookmarked)
+	bubble.SetRating(msg.Rating)
+	bubble.SetAnnotation(msg.Annotation)
+	if msg.Stats != nil {
+		bubble.SetStats(responseStats(msg.Stats))
+	}
+	cv.setBubbleMessage(bubble, msg.ID)
+	return bubble
+}
+
+// loadMessageStats sets the statistics of the assistant messages. A failure
+// only leaves the footers out.
+func (cv *ChatView) loadMessageStats(messages []*store.Message) {
+	var ids []int64
+	for _, msg := range messages {
+		if msg.Role == store.RoleAssistant {
+			ids = append(ids, msg.ID)
+		}
+	}
+
+	stats, err := cv.db.GetMessageStats(ids)
+	if err != nil {
+		logger.Error("Failed to load message stats", "error", err)
+		return
+	}
+	for _, msg := range messages {
+		msg.Stats = stats[msg.ID]
+	}
+}
+
+// showMessages renders the latest page of a chat's messages, with the
+// "show earlier" button above them when earlier messages remain.
+func (cv *ChatView) showMessages(messages []*store.Message, earlier int) {
+	cv.setEarlierMessages(messages, earlier)
+
+	for _, msg := range messages {
+		bubble := cv.newStoredBubble(msg)
+		cv.messages = append(cv.messages, bubble)
+		cv.messagesBox.Append(bubble)
+	}
+	cv.scrollToBottom()
+	cv.holdEarlierLoading()
+}
+
+// setupEarlierLoading realizes the next batch of pending messages whenever
+// the messages are scrolled to the top.
+func (cv *ChatView) setupEarlierLoading() {
+	cv.scrolled.ConnectEdgeReached(func(pos gtk.PositionType) {
+		if pos != gtk.PosTop || cv.showingWelcome {
+			return
+		}
+		cv.showEarlierMessages()
+	})
+}
+
+// holdEarlierLoading keeps scrolling from realizing more messages until the
+// new bubbles have been measured and the scroll position settled, so that
+// the top edge passed on the way doesn't load every pending batch at once.
+func (cv *ChatView) holdEarlierLoading() {
+	cv.loadingEarlier = true
+	glib.IdleAdd(func() {
+		cv.loadingEarlier = false
+	})
+}
+
+// setEarlierMessages records where the loaded messages start and how many
+// come before them.
+func (cv *ChatView) setEarlierMessages(loaded []*store.Message, earlier int) {
+	if len(loaded) > 0 {
+		cv.earliestID = loaded[0].ID
+	}
+	cv.earlierCount = earlier
+	cv.updateEarlierButton()
+}
+
+// showEarlierMessages loads the page of messages before the loaded ones and
+// shows it above them, keeping the visible messages in place.
+func (cv *ChatView) showEarlierMessages() {
+	if cv.loadingEarlier || cv.earlierCount == 0 || cv.db == nil || cv.currentChat == nil {
+		return
+	}
+	cv.loadingEarlier = true
+	chatID := cv.currentChat.ID
+	beforeID := cv.earliestID
+
+	go func() {
+		page, earlier, err := cv.loadMessagePage(chatID, beforeID)
+
+		glib.IdleAdd(func() {
+			if cv.currentChat == nil || cv.currentChat.ID != chatID || cv.earliestID != beforeID {
+				cv.loadingEarlier = false
+				return
+			}
+			if err != nil {
+				logger.Error("Failed to load earlier messages", "chatID", chatID, "error", err)
+				cv.handleError(err)
+				cv.loadingEarlier = false
+				return
+			}
+
+			adj := cv.scrolled.VAdjustment()
+			fromBottom := adj.Upper() - adj.Value()
+
+			bubbles := make([]*MessageBubble, 0, len(page))
+			var sibling gtk.Widgetter = cv.earlierButton
+			for _, msg := range page {
+				bubble := cv.newStoredBubble(msg)
+				cv.messagesBox.InsertChildAfter(bubble, sibling)
+				sibling = bubble
+				bubbles = append(bubbles, bubble)
+			}
+			cv.messages = append(bubbles, cv.messages...)
+			cv.setEarlierMessages(page, earlier)
+
+			// Restore the scroll offset once the new bubbles have been measured
+			glib.IdleAdd(func() {
+				adj.SetValue(adj.Upper() - fromBottom)
+				cv.loadingEarlier = false
+			})
+		})
+	}()
+}
+
+// updateEarlierButton shows the "show earlier" button at the top of the
+// messages while there are earlier messages to load, and hides it otherwise.
+func (cv *ChatView) updateEarlierButton() {
+	if cv.earlierButton == nil {
+		cv.earlierButton = gtk.NewButton()
+		cv.earlierButton.SetHAlign(gtk.AlignCenter)
+		cv.earlierButton.SetMarginTop(8)
+		cv.earlierButton.SetMarginBottom(8)
+		cv.earlierButton.AddCSSClass("pill")
+		cv.earlierButton.ConnectClicked(cv.showEarlierMessages)
+	}
+
+	attached := cv.earlierButton.Parent() != nil
+	n := cv.earlierCount
+	switch {
+	case n == 0 && attached:
+		cv.messagesBox.Remove(cv.earlierButton)
+	case n > 0:
+		cv.earlierButton.SetLabel(fmt.Sprintf(i18n.N("Show %d earlier message", "Show %d earlier messages", uint(n)), n))
+		if !attached {
+			cv.messagesBox.Prepend(cv.earlierButton)
+		}
+	}
+}
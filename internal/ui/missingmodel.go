@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// A chat keeps the model it was last used with. When that model is no
+// longer installed, as after deleting it in the model manager, a bar above
+// the input area says so when the chat is opened, and offers to download
+// the model again or to choose another one. Until then messages aren't
+// sent, rather than pulling the model unasked. New chats still download a
+// model typed into the selector when the first message is sent.
+
+// modelCheckTimeout bounds how long looking up the installed models may take.
+const modelCheckTimeout = 10 * time.Second
+
+// pullProgressText describes the progress of downloading model.
+func pullProgressText(model, status string, completed, total int64) string {
+	if total > 0 {
+		percent := float64(completed) / float64(total) * 100
+		return fmt.Sprintf("Downloading %s: %s (%.1f%%)", model, status, percent)
+	}
+	return fmt.Sprintf("Downloading %s: %s", model, status)
+}
+
+// pullProgressFraction returns the part of a download completed, from 0 to
+// 1, or -1 while its size isn't known.
+func pullProgressFraction(completed, total int64) float64 {
+	if total <= 0 {
+		return -1
+	}
+	return min(float64(completed)/float64(total), 1)
+}
+
+// newMissingModelBar creates the bar shown above the input area when the
+// model of the current chat isn't installed.
+func (cv *ChatView) newMissingModelBar() *gtk.Revealer {
+	box := gtk.NewBox(gtk.OrientationVertical, 8)
+	box.AddCSSClass("missing-model")
+	box.SetMarginStart(12)
+	box.SetMarginEnd(12)
+	box.SetMarginBottom(8)
+
+	cv.missingModelLabel = gtk.NewLabel("")
+	cv.missingModelLabel.SetXAlign(0)
+	cv.missingModelLabel.SetWrap(true)
+	box.Append(cv.missingModelLabel)
+
+	cv.missingModelButtons = gtk.NewBox(gtk.OrientationHorizontal, 8)
+	cv.missingModelButtons.SetHAlign(gtk.AlignEnd)
+
+	chooseBtn := gtk.NewButtonWithLabel(i18n.T("Choose Another Model"))
+	chooseBtn.ConnectClicked(func() {
+		cv.inputArea.PopupModels()
+	})
+	cv.missingModelButtons.Append(chooseBtn)
+
+	pullBtn := gtk.NewButtonWithLabel(i18n.T("Download Again"))
+	pullBtn.AddCSSClass("suggested-action")
+	pullBtn.ConnectClicked(cv.pullMissingModel)
+	cv.missingModelButtons.Append(pullBtn)
+	box.Append(cv.missingModelButtons)
+
+	revealer := gtk.NewRevealer()
+	revealer.SetTransitionType(gtk.RevealerTransitionTypeSlideUp)
+	revealer.SetChild(box)
+	return revealer
+}
+
+// checkChatModel looks for the model of the current chat among the
+// installed ones in the background, and shows the missing model bar when
+// it isn't there. Nothing is shown when the server can't be reached.
+func (cv *ChatView) checkChatModel() {
+	chat := cv.currentChat
+	if cv.ollamaClient == nil || chat == nil || chat.Model == "" {
+		return
+	}
+	client := cv.ollamaClient
+	chatID := chat.ID
+	model := chat.Model
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), modelCheckTimeout)
+		defer cancel()
+		models, err := client.ListModels(ctx)
+		if err != nil {
+			logger.Error("Failed to check chat model", "model", model, "error", err)
+			return
+		}
+		if ollama.ModelInstalled(models, model) {
+			return
+		}
+
+		glib.IdleAdd(func() {
+			if cv.currentChat == nil || cv.currentChat.ID != chatID || cv.currentModel != model {
+				return
+			}
+			logger.Info("Chat model not installed", "chatID", chatID, "model", model)
+			cv.showMissingModel(model)
+		})
+	}()
+}
+
+// showMissingModel tells that model, the model of the current chat, isn't
+// installed.
+func (cv *ChatView) showMissingModel(model string) {
+	cv.missingModel = model
+	if model == cv.pullingModel {
+		cv.missingModelLabel.SetText(fmt.Sprintf(i18n.T("Downloading model %s..."), model))
+	} else {
+		cv.missingModelLabel.SetText(fmt.Sprintf(i18n.T("%s, the model of this chat, is no longer installed. Download it again or choose another model to keep chatting."), model))
+	}
+	cv.missingModelButtons.SetSensitive(cv.pullingModel == "")
+	cv.missingModelBar.SetRevealChild(true)
+}
+
+// hideMissingModel hides the missing model bar.
+func (cv *ChatView) hideMissingModel() {
+	cv.missingModel = ""
+	cv.missingModelBar.SetRevealChild(false)
+}
+
+// waitForMissingModel keeps text from being sent while the model of the
+// current chat isn't installed, and reports whether it did.
+func (cv *ChatView) waitForMissingModel(text string) bool {
+	if cv.missingModel == "" || cv.missingModel != cv.currentModel {
+		return false
+	}
+	cv.notify(i18n.T("Download the model again or choose another one first"))
+
+	// The input area clears its text once it has been handed over
+	glib.IdleAdd(func() {
+		cv.inputArea.SetText(text)
+		cv.inputArea.Focus()
+	})
+	return true
+}
+
+// pullMissingModel downloads the missing model of the current chat again,
+// showing the progress in the bar.
+func (cv *ChatView) pullMissingModel() {
+	model := cv.missingModel
+	if model == "" || cv.pullingModel != "" {
+		return
+	}
+	cv.pullingModel = model
+	cv.missingModelButtons.SetSensitive(false)
+	logger.Info("Downloading chat model again", "model", model)
+
+	client := cv.ollamaClient
+	go func() {
+		err := client.PullModel(context.Background(), model, func(status string, completed, total int64) {
+			text := pullProgressText(model, status, completed, total)
+			glib.IdleAdd(func() {
+				if cv.missingModel == model {
+					cv.missingModelLabel.SetText(text)
+				}
+			})
+		})
+
+		glib.IdleAdd(func() {
+			cv.pullingModel = ""
+			cv.missingModelButtons.SetSensitive(true)
+			if err != nil {
+				logger.Error("Failed to download chat model", "model", model, "error", err)
+				if cv.missingModel == model {
+					cv.missingModelLabel.SetText(fmt.Sprintf(i18n.T("Failed to download %s: %v"), model, err))
+				}
+				return
+			}
+			if cv.missingModel == model {
+				cv.hideMissingModel()
+			}
+			if cv.onModelPulled != nil {
+				cv.onModelPulled(model)
+			}
+		})
+	}()
+}
+
+// OnModelPulled sets the callback for when the missing model of a chat
+// was downloaded again.
+func (cv *ChatView) OnModelPulled(callback func(string)) {
+	cv.onModelPulled = callback
+}
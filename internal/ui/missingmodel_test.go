@@ -0,0 +1,22 @@
+package ui
+
+import "testing"
+
+func TestPullProgressFraction(t *testing.T) {
+	tests := []struct {
+		completed, total int64
+		want             float64
+	}{
+		{0, 0, -1},
+		{50, 0, -1},
+		{0, 200, 0},
+		{50, 200, 0.25},
+		{200, 200, 1},
+		{250, 200, 1},
+	}
+	for _, tt := range tests {
+		if got := pullProgressFraction(tt.completed, tt.total); got != tt.want {
+			t.Errorf("pullProgressFraction(%d, %d) = %v, want %v", tt.completed, tt.total, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// A prompt can be sent to two models at once to compare their answers side
+// by side. Both answers stream together with their statistics below them,
+// and neither is saved until one is kept: it then becomes the reply in the
+// chat and the other one is dropped.
+
+// comparedModels returns the models compared at first: the current model
+// and the first other one.
+func comparedModels(models []string, current string) [2]string {
+	var pair [2]string
+	if current != "" {
+		pair[0] = current
+	} else if len(models) > 0 {
+		pair[0] = models[0]
+	}
+	pair[1] = pair[0]
+	for _, m := range models {
+		if m != pair[0] {
+			pair[1] = m
+			break
+		}
+	}
+	return pair
+}
+
+// CompareDialog asks for a prompt and the two models to send it to.
+type CompareDialog struct {
+	*adw.Window
+
+	// UI components
+	promptView *gtk.TextView
+	modelDrops [2]*gtk.DropDown
+
+	// Data
+	models []string
+
+	// Callbacks
+	onCompare func(prompt string, models [2]string)
+}
+
+// NewCompareDialog creates a dialog comparing models on a prompt, starting
+// with prompt and the current model.
+func NewCompareDialog(parent *gtk.Window, models []string, current, prompt string) *CompareDialog {
+	d := &CompareDialog{
+		models: models,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Compare Models"))
+	d.SetModal(true)
+	d.SetDefaultSize(460, 380)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI(current, prompt)
+
+	return d
+}
+
+func (d *CompareDialog) setupUI(current, prompt string) {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(gtk.NewLabel(i18n.T("Compare Models")))
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	desc := gtk.NewLabel(i18n.T("Both models answer at the same time, side by side. Keep the better answer to continue the chat with it."))
+	desc.AddCSSClass("dim-label")
+	desc.SetWrap(true)
+	desc.SetXAlign(0)
+	content.Append(desc)
+
+	// Prompt
+	promptLabel := gtk.NewLabel(i18n.T("Prompt:"))
+	promptLabel.SetXAlign(0)
+	promptLabel.AddCSSClass("heading")
+	content.Append(promptLabel)
+
+	d.promptView = gtk.NewTextView()
+	d.promptView.SetWrapMode(gtk.WrapWordChar)
+	d.promptView.SetTopMargin(8)
+	d.promptView.SetBottomMargin(8)
+	d.promptView.SetLeftMargin(8)
+	d.promptView.SetRightMargin(8)
+	d.promptView.Buffer().SetText(prompt)
+	promptScrolled := gtk.NewScrolledWindow()
+	promptScrolled.SetChild(d.promptView)
+	promptScrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	promptScrolled.SetMinContentHeight(100)
+	promptScrolled.SetVExpand(true)
+	promptScrolled.AddCSSClass("card")
+	content.Append(promptScrolled)
+
+	// Models
+	modelsLabel := gtk.NewLabel(i18n.T("Models:"))
+	modelsLabel.SetXAlign(0)
+	modelsLabel.AddCSSClass("heading")
+	content.Append(modelsLabel)
+
+	pair := comparedModels(d.models, current)
+	modelsBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	modelsBox.SetHomogeneous(true)
+	for i := range d.modelDrops {
+		d.modelDrops[i] = gtk.NewDropDown(gtk.NewStringList(d.models), nil)
+		for j, m := range d.models {
+			if m == pair[i] {
+				d.modelDrops[i].SetSelected(uint(j))
+			}
+		}
+		modelsBox.Append(d.modelDrops[i])
+	}
+	content.Append(modelsBox)
+
+	// Buttons
+	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttonBox.SetHAlign(gtk.AlignEnd)
+	buttonBox.SetMarginTop(8)
+
+	cancelBtn := gtk.NewButton()
+	cancelBtn.SetLabel(i18n.T("Cancel"))
+	cancelBtn.ConnectClicked(func() {
+		d.Close()
+	})
+	buttonBox.Append(cancelBtn)
+
+	compareBtn := gtk.NewButton()
+	compareBtn.SetLabel(i18n.T("Compare"))
+	compareBtn.AddCSSClass("suggested-action")
+	compareBtn.ConnectClicked(d.onCompareClicked)
+	buttonBox.Append(compareBtn)
+
+	content.Append(buttonBox)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(content)
+
+	d.SetContent(toolbarView)
+}
+
+func (d *CompareDialog) onCompareClicked() {
+	buffer := d.promptView.Buffer()
+	prompt := strings.TrimSpace(buffer.Text(buffer.StartIter(), buffer.EndIter(), false))
+	if prompt == "" || len(d.models) == 0 {
+		d.promptView.GrabFocus()
+		return
+	}
+
+	var models [2]string
+	for i, drop := range d.modelDrops {
+		if idx := int(drop.Selected()); idx < len(d.models) {
+			models[i] = d.models[idx]
+		}
+	}
+
+	if d.onCompare != nil {
+		d.onCompare(prompt, models)
+	}
+	d.Close()
+}
+
+// OnCompare sets the callback for when the comparison is started.
+func (d *CompareDialog) OnCompare(callback func(prompt string, models [2]string)) {
+	d.onCompare = callback
+}
+
+// modelComparison holds the answers of two models shown side by side.
+type modelComparison struct {
+	row      *gtk.Box
+	models   [2]string
+	bubbles  [2]*MessageBubble
+	keepBtns [2]*gtk.Button
+	stats    [2]*ollama.ResponseStats
+}
+
+// newModelComparison creates the side by side answers of models.
+func newModelComparison(models [2]string) *modelComparison {
+	c := &modelComparison{models: models}
+	c.row = gtk.NewBox(gtk.OrientationHorizontal, 8)
+	c.row.SetHomogeneous(true)
+
+	for i, model := range models {
+		column := gtk.NewBox(gtk.OrientationVertical, 4)
+
+		c.bubbles[i] = NewMessageBubble(store.RoleAssistant, "")
+		c.bubbles[i].SetAgent(model, i)
+		c.bubbles[i].SetThinking(true)
+		column.Append(c.bubbles[i])
+
+		c.keepBtns[i] = gtk.NewButton()
+		c.keepBtns[i].SetLabel(i18n.T("Keep This Answer"))
+		c.keepBtns[i].SetHAlign(gtk.AlignStart)
+		c.keepBtns[i].SetMarginStart(16)
+		c.keepBtns[i].SetSensitive(false)
+		column.Append(c.keepBtns[i])
+
+		c.row.Append(column)
+	}
+	return c
+}
+
+// StartComparison sends prompt to both models at once, after the history
+// of the current chat, and shows their answers side by side.
+func (cv *ChatView) StartComparison(prompt string, models [2]string) {
+	if cv.isStreaming {
+		return
+	}
+
+	cv.EnsureChat(models[0])
+	if cv.currentChat == nil {
+		return
+	}
+	messages := append(cv.buildMessageHistory(), ollama.Message{Role: "user", Content: prompt})
+
+	userBubble := cv.addMessage(store.RoleUser, prompt)
+	cv.saveMessage(userBubble, &store.Message{ChatID: cv.currentChat.ID, Role: store.RoleUser, Content: prompt}, nil)
+
+	cv.removeComparison()
+	comparison := newModelComparison(models)
+	cv.comparison = comparison
+	cv.messagesBox.Append(comparison.row)
+	cv.scrollToBottom()
+	for i := range models {
+		comparison.bubbles[i].SetJSONOutput(cv.jsonOutput())
+		comparison.keepBtns[i].ConnectClicked(func() {
+			cv.keepComparedAnswer(comparison, i)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), streamingTimeout)
+	cv.streamCancel = cancel
+	cv.setStreaming(true)
+	cv.inputArea.SetStreamingMode(true)
+	logger.Info("Comparing models", "models", models, "historyCount", len(messages)-1)
+
+	completion := chatCompletionSettings(cv.currentChat)
+	var wg sync.WaitGroup
+	errs := make([]error, len(models))
+	for i, model := range models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bubble := comparison.bubbles[i]
+
+			var response strings.Builder
+			buffer := newTokenBuffer(tokenFlushInterval, func(content string) {
+				glib.IdleAdd(func() {
+					bubble.SetContent(content)
+				})
+			})
+			stats, err := cv.streamCompletion(ctx, model, messages, completion, func(token string) {
+				response.WriteString(token)
+				buffer.Write(response.String())
+			})
+			buffer.Stop()
+			errs[i] = err
+
+			content := response.String()
+			glib.IdleAdd(func() {
+				if bubble.IsThinking() {
+					bubble.SetThinking(false)
+				}
+				comparison.stats[i] = stats
+				if stats != nil {
+					bubble.SetStats(stats)
+				}
+				comparison.keepBtns[i].SetSensitive(content != "")
+			})
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+
+		glib.IdleAdd(func() {
+			cv.streamCancel = nil
+			cv.setStreaming(false)
+			cv.inputArea.SetStreamingMode(false)
+			for i, err := range errs {
+				if err != nil && err != context.Canceled {
+					logger.Error("Model comparison failed", "model", models[i], "error", err)
+					cv.handleError(err)
+				}
+			}
+		})
+	}()
+}
+
+// keepComparedAnswer saves the answer in column i of comparison as the
+// reply in the chat, and drops the other one.
+func (cv *ChatView) keepComparedAnswer(comparison *modelComparison, i int) {
+	if cv.isStreaming || cv.comparison != comparison || cv.currentChat == nil {
+		return
+	}
+	content := comparison.bubbles[i].GetContent()
+	stats := comparison.stats[i]
+	cv.removeComparison()
+
+	logger.Info("Kept compared answer", "model", comparison.models[i])
+	bubble := cv.addMessage(store.RoleAssistant, content)
+	if stats != nil {
+		bubble.SetStats(stats)
+	}
+	cv.saveMessage(bubble, &store.Message{
+		ChatID:  cv.currentChat.ID,
+		Role:    store.RoleAssistant,
+		Content: content,
+		Stats:   messageStats(stats),
+	}, nil)
+}
+
+// removeComparison takes the side by side answers, if any, out of the chat.
+func (cv *ChatView) removeComparison() {
+	if cv.comparison == nil {
+		return
+	}
+	cv.messagesBox.Remove(cv.comparison.row)
+	cv.comparison = nil
+}
@@ -0,0 +1,24 @@
+package ui
+
+import "testing"
+
+func TestComparedModels(t *testing.T) {
+	tests := []struct {
+		name    string
+		models  []string
+		current string
+		want    [2]string
+	}{
+		{"current and first other", []string{"llama3", "mistral", "qwen"}, "mistral", [2]string{"mistral", "llama3"}},
+		{"no current model", []string{"llama3", "mistral"}, "", [2]string{"llama3", "mistral"}},
+		{"single model", []string{"llama3"}, "llama3", [2]string{"llama3", "llama3"}},
+		{"no models", nil, "", [2]string{"", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := comparedModels(tt.models, tt.current); got != tt.want {
+				t.Errorf("comparedModels(%v, %q) = %v, want %v", tt.models, tt.current, got, tt.want)
+			}
+		})
+	}
+}
@@ -26,17 +26,19 @@ type ModelDialog struct {
 	modelListBox *gtk.ListBox
 
 	// State
-	client        *ollama.Client
+	client        ollama.API
 	cancelFunc    context.CancelFunc
 	isDownloading bool
+	downloading   string // Name of the model being downloaded
 	models        []ollama.RegistryModel
 
 	// Callbacks
 	onModelDownloaded func(string)
+	onDownloadFailed  func(string, error)
 }
 
 // NewModelDialog creates a new model download dialog.
-func NewModelDialog(parent *gtk.Window, client *ollama.Client) *ModelDialog {
+func NewModelDialog(parent *gtk.Window, client ollama.API) *ModelDialog {
 	d := &ModelDialog{
 		client: client,
 	}
@@ -169,6 +171,7 @@ func (d *ModelDialog) startDownload() {
 
 	// Setup UI for downloading
 	d.isDownloading = true
+	d.downloading = modelName
 	d.entry.SetSensitive(false)
 	d.downloadBtn.SetSensitive(false)
 	d.downloadBtn.SetLabel(i18n.T("Downloading..."))
@@ -203,6 +206,9 @@ func (d *ModelDialog) startDownload() {
 				} else {
 					d.statusLabel.SetText(fmt.Sprintf("Error: %v", err))
 					d.statusLabel.AddCSSClass("error")
+					if d.onDownloadFailed != nil {
+						d.onDownloadFailed(modelName, err)
+					}
 				}
 				d.resetUI()
 				return
@@ -233,11 +239,33 @@ func (d *ModelDialog) resetUI() {
 	d.downloadBtn.SetLabel(i18n.T("Download"))
 }
 
+// Downloading returns the name of the model being downloaded, or an empty
+// string when no download is running.
+func (d *ModelDialog) Downloading() string {
+	if !d.isDownloading {
+		return ""
+	}
+	return d.downloading
+}
+
+// CancelDownload stops the running download, if any.
+func (d *ModelDialog) CancelDownload() {
+	if d.isDownloading && d.cancelFunc != nil {
+		d.cancelFunc()
+	}
+}
+
 // OnModelDownloaded sets the callback for when a model is successfully downloaded.
 func (d *ModelDialog) OnModelDownloaded(callback func(string)) {
 	d.onModelDownloaded = callback
 }
 
+// OnDownloadFailed sets the callback for when a download fails. Cancelled
+// downloads don't count.
+func (d *ModelDialog) OnDownloadFailed(callback func(model string, err error)) {
+	d.onDownloadFailed = callback
+}
+
 func (d *ModelDialog) loadAvailableModels() {
 	models := ollama.FetchAvailableModels(context.Background())
 
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// ModelInfoDialog shows the details of a local model reported by Ollama.
+type ModelInfoDialog struct {
+	*adw.Window
+
+	// UI components
+	stack      *gtk.Stack
+	statusPage *adw.StatusPage
+	details    *gtk.Box
+
+	// State
+	client ollama.API
+	model  string
+}
+
+// NewModelInfoDialog creates a dialog showing information about model and
+// starts loading it.
+func NewModelInfoDialog(parent *gtk.Window, client ollama.API, model string) *ModelInfoDialog {
+	d := &ModelInfoDialog{
+		client: client,
+		model:  model,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Model Information"))
+	d.SetModal(true)
+	d.SetDefaultSize(480, 560)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+	go d.load()
+
+	return d
+}
+
+func (d *ModelInfoDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(adw.NewWindowTitle(i18n.T("Model Information"), d.model))
+
+	// Loading and error state
+	d.statusPage = adw.NewStatusPage()
+	spinner := gtk.NewSpinner()
+	spinner.SetSizeRequest(32, 32)
+	spinner.Start()
+	d.statusPage.SetChild(spinner)
+	d.statusPage.SetTitle(i18n.T("Loading..."))
+
+	d.details = gtk.NewBox(gtk.OrientationVertical, 18)
+	d.details.SetMarginTop(16)
+	d.details.SetMarginBottom(24)
+	d.details.SetMarginStart(24)
+	d.details.SetMarginEnd(24)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(d.details)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+
+	d.stack = gtk.NewStack()
+	d.stack.AddNamed(d.statusPage, "status")
+	d.stack.AddNamed(scrolled, "details")
+	d.stack.SetVisibleChildName("status")
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(d.stack)
+
+	d.SetContent(toolbarView)
+}
+
+// load fetches the model information in the background.
+func (d *ModelInfoDialog) load() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	info, err := d.client.ShowModel(ctx, d.model)
+	glib.IdleAdd(func() {
+		if err != nil {
+			logger.Error("Failed to load model information", "model", d.model, "error", err)
+			d.statusPage.SetChild(nil)
+			d.statusPage.SetIconName("dialog-error-symbolic")
+			d.statusPage.SetTitle(i18n.T("Could not load model information"))
+			d.statusPage.SetDescription(err.Error())
+			return
+		}
+		d.showInfo(info)
+		d.stack.SetVisibleChildName("details")
+	})
+}
+
+// showInfo fills the dialog with info.
+func (d *ModelInfoDialog) showInfo(info *ollama.ModelInfo) {
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+
+	contextLength := ""
+	if n := info.ContextLength(); n > 0 {
+		contextLength = fmt.Sprintf(i18n.T("%d tokens"), n)
+	}
+	for _, field := range []struct {
+		title, value string
+	}{
+		{i18n.T("Parameters"), info.Details.ParameterSize},
+		{i18n.T("Quantization"), info.Details.QuantizationLevel},
+		{i18n.T("Family"), info.Details.Family},
+		{i18n.T("Context Length"), contextLength},
+		{i18n.T("Format"), info.Details.Format},
+	} {
+		value := field.value
+		if value == "" {
+			value = i18n.T("Unknown")
+		}
+		row := adw.NewActionRow()
+		row.SetTitle(field.title)
+		row.SetSubtitle(value)
+		row.SetSubtitleSelectable(true)
+		row.AddCSSClass("property")
+		list.Append(row)
+	}
+	d.details.Append(list)
+
+	d.appendText(i18n.T("Template"), info.Template, true)
+	d.appendText(i18n.T("License"), info.License, false)
+}
+
+// appendText adds a collapsed section with long text such as the license.
+// Empty text is skipped.
+func (d *ModelInfoDialog) appendText(title, text string, monospace bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	label := gtk.NewLabel(text)
+	label.SetWrap(true)
+	label.SetWrapMode(pango.WrapWordChar)
+	label.SetXAlign(0)
+	label.SetSelectable(true)
+	label.SetMarginTop(8)
+	if monospace {
+		label.AddCSSClass("monospace")
+	}
+
+	expander := gtk.NewExpander(title)
+	expander.SetChild(label)
+	d.details.Append(expander)
+}
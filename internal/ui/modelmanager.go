@@ -0,0 +1,288 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// duplicateModelName suggests a name for a copy of model that is not in
+// existing, keeping the tag: "llama3:8b" becomes "llama3-copy:8b", then
+// "llama3-copy-2:8b".
+func duplicateModelName(model string, existing []string) string {
+	used := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		used[e] = true
+	}
+
+	base, tag := model, ""
+	if i := strings.LastIndex(model, ":"); i > 0 {
+		base, tag = model[:i], model[i:]
+	}
+
+	name := base + "-copy" + tag
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s-copy-%d%s", base, n, tag)
+	}
+	return name
+}
+
+// ModelManager is a dialog listing the installed models, with actions to
+// duplicate and delete them.
+type ModelManager struct {
+	*adw.Window
+
+	// UI components
+	listBox    *gtk.ListBox
+	scrolled   *gtk.ScrolledWindow
+	statusPage *adw.StatusPage
+	toasts     *adw.ToastOverlay
+
+	// State
+	client ollama.API
+	models []ollama.Model
+
+	// Callbacks
+	onModelsChanged func()
+}
+
+// NewModelManager creates a new model manager and starts loading the models.
+func NewModelManager(parent *gtk.Window, client ollama.API) *ModelManager {
+	m := &ModelManager{
+		client: client,
+	}
+
+	m.Window = adw.NewWindow()
+	m.SetTitle(i18n.T("Manage Models"))
+	m.SetModal(true)
+	m.SetDefaultSize(520, 520)
+	if parent != nil {
+		m.SetTransientFor(parent)
+	}
+
+	m.setupUI()
+	m.refresh()
+
+	return m
+}
+
+func (m *ModelManager) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(gtk.NewLabel(i18n.T("Manage Models")))
+
+	refreshBtn := gtk.NewButtonFromIconName("view-refresh-symbolic")
+	refreshBtn.SetTooltipText(i18n.T("Refresh"))
+	refreshBtn.ConnectClicked(m.refresh)
+	headerBar.PackStart(refreshBtn)
+
+	m.listBox = gtk.NewListBox()
+	m.listBox.SetSelectionMode(gtk.SelectionNone)
+	m.listBox.AddCSSClass("boxed-list")
+	m.listBox.SetMarginTop(16)
+	m.listBox.SetMarginBottom(24)
+	m.listBox.SetMarginStart(24)
+	m.listBox.SetMarginEnd(24)
+	m.listBox.SetVAlign(gtk.AlignStart)
+
+	m.scrolled = gtk.NewScrolledWindow()
+	m.scrolled.SetChild(m.listBox)
+	m.scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	m.scrolled.SetVExpand(true)
+
+	m.statusPage = adw.NewStatusPage()
+	m.statusPage.SetIconName("drive-harddisk-symbolic")
+	m.statusPage.SetVExpand(true)
+	m.statusPage.SetVisible(false)
+
+	content := gtk.NewBox(gtk.OrientationVertical, 0)
+	content.Append(m.scrolled)
+	content.Append(m.statusPage)
+
+	m.toasts = adw.NewToastOverlay()
+	m.toasts.SetChild(content)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(m.toasts)
+
+	m.SetContent(toolbarView)
+}
+
+// refresh reloads the installed models in the background.
+func (m *ModelManager) refresh() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		models, err := m.client.ListModels(ctx)
+		glib.IdleAdd(func() {
+			if err != nil {
+				logger.Error("Failed to list models", "error", err)
+				m.showStatus(i18n.T("Could not load the installed models"), err.Error())
+				return
+			}
+			m.setModels(models)
+		})
+	}()
+}
+
+// setModels shows models in the list.
+func (m *ModelManager) setModels(models []ollama.Model) {
+	m.models = models
+	for {
+		child := m.listBox.FirstChild()
+		if child == nil {
+			break
+		}
+		m.listBox.Remove(child)
+	}
+
+	if len(models) == 0 {
+		m.showStatus(i18n.T("No models installed"), i18n.T("Download a model to start chatting."))
+		return
+	}
+	m.scrolled.SetVisible(true)
+	m.statusPage.SetVisible(false)
+
+	for _, model := range models {
+		m.listBox.Append(m.createRow(model))
+	}
+}
+
+// showStatus replaces the list with a message.
+func (m *ModelManager) showStatus(title, description string) {
+	m.scrolled.SetVisible(false)
+	m.statusPage.SetTitle(title)
+	m.statusPage.SetDescription(description)
+	m.statusPage.SetVisible(true)
+}
+
+// createRow creates the list row for a model.
+func (m *ModelManager) createRow(model ollama.Model) *adw.ActionRow {
+	row := adw.NewActionRow()
+	row.SetTitle(model.Name)
+	subtitle := glib.FormatSize(uint64(model.Size))
+	if !model.ModifiedAt.IsZero() {
+		subtitle += " · " + fmt.Sprintf(i18n.T("Modified %s"), model.ModifiedAt.Local().Format("2006-01-02"))
+	}
+	row.SetSubtitle(subtitle)
+
+	duplicateBtn := gtk.NewButtonFromIconName("edit-copy-symbolic")
+	duplicateBtn.SetTooltipText(i18n.T("Duplicate"))
+	duplicateBtn.SetVAlign(gtk.AlignCenter)
+	duplicateBtn.AddCSSClass("flat")
+	duplicateBtn.ConnectClicked(func() {
+		m.confirmDuplicate(model.Name)
+	})
+	row.AddSuffix(duplicateBtn)
+
+	deleteBtn := gtk.NewButtonFromIconName("user-trash-symbolic")
+	deleteBtn.SetTooltipText(i18n.T("Delete"))
+	deleteBtn.SetVAlign(gtk.AlignCenter)
+	deleteBtn.AddCSSClass("flat")
+	deleteBtn.ConnectClicked(func() {
+		m.confirmDelete(model.Name)
+	})
+	row.AddSuffix(deleteBtn)
+
+	return row
+}
+
+// confirmDuplicate asks for the name of the copy of model and creates it.
+func (m *ModelManager) confirmDuplicate(model string) {
+	var existing []string
+	for _, other := range m.models {
+		existing = append(existing, other.Name)
+	}
+
+	entry := gtk.NewEntry()
+	entry.SetText(duplicateModelName(model, existing))
+	entry.SetActivatesDefault(true)
+
+	dialog := adw.NewMessageDialog(&m.Window.Window, i18n.T("Duplicate Model"),
+		fmt.Sprintf(i18n.T("Create a copy of %s named:"), model))
+	dialog.SetExtraChild(entry)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("duplicate", i18n.T("Duplicate"))
+	dialog.SetResponseAppearance("duplicate", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("duplicate")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		name := strings.TrimSpace(entry.Text())
+		if response != "duplicate" || name == "" || name == model {
+			return
+		}
+		m.run(fmt.Sprintf(i18n.T("%s created"), name), func(ctx context.Context) error {
+			logger.Info("Duplicating model", "source", model, "destination", name)
+			return m.client.CopyModel(ctx, model, name)
+		})
+	})
+
+	dialog.Present()
+}
+
+// confirmDelete asks before deleting model.
+func (m *ModelManager) confirmDelete(model string) {
+	dialog := adw.NewMessageDialog(&m.Window.Window, i18n.T("Delete Model?"),
+		fmt.Sprintf(i18n.T("%s will be removed from this computer. You can download it again later."), model))
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("delete", i18n.T("Delete"))
+	dialog.SetResponseAppearance("delete", adw.ResponseDestructive)
+	dialog.SetDefaultResponse("cancel")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		if response != "delete" {
+			return
+		}
+		m.run(fmt.Sprintf(i18n.T("%s deleted"), model), func(ctx context.Context) error {
+			logger.Info("Deleting model", "model", model)
+			return m.client.DeleteModel(ctx, model)
+		})
+	})
+
+	dialog.Present()
+}
+
+// run performs a model operation in the background, then shows done as a
+// toast and reloads the list.
+func (m *ModelManager) run(done string, operation func(ctx context.Context) error) {
+	m.SetSensitive(false)
+	go func() {
+		// Copies of large models can take a while to write
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		err := operation(ctx)
+		glib.IdleAdd(func() {
+			m.SetSensitive(true)
+			if err != nil {
+				logger.Error("Model operation failed", "error", err)
+				m.toasts.AddToast(adw.NewToast(err.Error()))
+				return
+			}
+			m.toasts.AddToast(adw.NewToast(done))
+			m.refresh()
+			if m.onModelsChanged != nil {
+				m.onModelsChanged()
+			}
+		})
+	}()
+}
+
+// OnModelsChanged sets the callback for when a model is deleted or copied.
+func (m *ModelManager) OnModelsChanged(callback func()) {
+	m.onModelsChanged = callback
+}
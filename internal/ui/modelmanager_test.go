@@ -0,0 +1,30 @@
+package ui
+
+import "testing"
+
+func TestDuplicateModelName(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		existing []string
+		want     string
+	}{
+		{name: "keeps the tag", model: "llama3:8b", want: "llama3-copy:8b"},
+		{name: "without tag", model: "mistral", want: "mistral-copy"},
+		{name: "namespaced", model: "user/model:latest", want: "user/model-copy:latest"},
+		{
+			name:     "numbered when taken",
+			model:    "llama3:latest",
+			existing: []string{"llama3:latest", "llama3-copy:latest", "llama3-copy-2:latest"},
+			want:     "llama3-copy-3:latest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := duplicateModelName(tt.model, tt.existing); got != tt.want {
+				t.Errorf("duplicateModelName(%q) = %q, want %q", tt.model, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Changing the model of a chat that has messages leaves a marker in the
+// transcript ("Switched to mistral"), saved as a system message, so that
+// the history tells which model wrote which answers. The markers are never
+// sent to a model. The chat keeps the new model when it is opened again.
+
+// NewModelSwitchBubble creates the marker shown where a chat went on with
+// model.
+func NewModelSwitchBubble(model string) *MessageBubble {
+	bubble := NewMessageBubble(store.RoleSystem, modelSwitchText(model))
+	bubble.modelSwitch = true
+	return bubble
+}
+
+// modelSwitchText returns the text of the marker for a switch to model.
+func modelSwitchText(model string) string {
+	return fmt.Sprintf(i18n.T("Switched to %s"), model)
+}
+
+// IsModelSwitch reports whether the bubble marks a change of model.
+func (mb *MessageBubble) IsModelSwitch() bool {
+	return mb.modelSwitch
+}
+
+// SwitchModel changes the model the current chat goes on with. The chat
+// keeps it, and once it has messages the change is recorded with a marker.
+func (cv *ChatView) SwitchModel(model string) {
+	cv.SetModel(model)
+
+	chat := cv.currentChat
+	if chat == nil || cv.db == nil || chat.Model == model {
+		return
+	}
+
+	if len(cv.messages) == 0 {
+		if err := cv.db.UpdateChatModel(chat.ID, model); err != nil {
+			logger.Error("Failed to update chat model", "chatID", chat.ID, "model", model, "error", err)
+			return
+		}
+		chat.Model = model
+		return
+	}
+
+	marker, err := cv.db.SwitchChatModel(chat.ID, model)
+	if err != nil {
+		logger.Error("Failed to record model switch", "chatID", chat.ID, "model", model, "error", err)
+		cv.handleError(err)
+		return
+	}
+	chat.Model = model
+	logger.Info("Model switched", "chatID", chat.ID, "model", model)
+
+	bubble := NewModelSwitchBubble(model)
+	cv.setBubbleMessage(bubble, marker.ID)
+	cv.messages = append(cv.messages, bubble)
+	cv.messagesBox.Append(bubble)
+	cv.scrollToBottom()
+}
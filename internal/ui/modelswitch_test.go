@@ -0,0 +1,9 @@
+package ui
+
+import "testing"
+
+func TestModelSwitchText(t *testing.T) {
+	if got := modelSwitchText("mistral"); got != "Switched to mistral" {
+		t.Errorf("modelSwitchText() = %q, want %q", got, "Switched to mistral")
+	}
+}
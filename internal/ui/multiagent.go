@@ -0,0 +1,378 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// agentPersona describes one participant of a multi-agent conversation.
+type agentPersona struct {
+	Name   string
+	Model  string
+	Prompt string
+}
+
+// agentTurn is a single message produced by one of the agents.
+type agentTurn struct {
+	Speaker int // Index of the persona that spoke
+	Content string
+}
+
+// multiAgentConfig holds the settings for a multi-agent conversation.
+type multiAgentConfig struct {
+	Topic    string
+	Personas [2]agentPersona
+	Turns    int
+}
+
+// defaultAgentTurns is the default number of messages in a multi-agent conversation.
+const defaultAgentTurns = 6
+
+// buildAgentMessages builds the request for the given speaker. The speaker's
+// own turns are sent as assistant messages and the other agent's as user
+// messages, so each model sees a regular two-party conversation.
+func buildAgentMessages(cfg multiAgentConfig, turns []agentTurn, speaker int) []ollama.Message {
+	persona := cfg.Personas[speaker]
+	other := cfg.Personas[1-speaker]
+
+	system := fmt.Sprintf("You are %s, taking part in a conversation with %s about the following topic: %s\n"+
+		"Reply to the last message in a few short paragraphs, build on or challenge the other participant's points, and do not speak for them.",
+		persona.Name, other.Name, cfg.Topic)
+	if persona.Prompt != "" {
+		system = persona.Prompt + "\n\n" + system
+	}
+
+	messages := []ollama.Message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: cfg.Topic},
+	}
+	for _, turn := range turns {
+		role := "user"
+		if turn.Speaker == speaker {
+			role = "assistant"
+		}
+		messages = append(messages, ollama.Message{Role: role, Content: turn.Content})
+	}
+	return messages
+}
+
+// formatAgentMessage prefixes an agent's reply with its name and model for storage.
+func formatAgentMessage(persona agentPersona, content string) string {
+	return fmt.Sprintf("**%s** (%s)\n\n%s", persona.Name, persona.Model, content)
+}
+
+// agentReply returns the reply of a stored agent message, without the name
+// and model formatAgentMessage put before it.
+func agentReply(content string) string {
+	if _, reply, ok := strings.Cut(content, "\n\n"); ok {
+		return reply
+	}
+	return content
+}
+
+// agentLabel returns the name and model shown above a participant's replies.
+func agentLabel(persona agentPersona) string {
+	return fmt.Sprintf("%s · %s", persona.Name, persona.Model)
+}
+
+// MultiAgentDialog configures a conversation between two models.
+type MultiAgentDialog struct {
+	*adw.Window
+
+	// UI components
+	topicEntry    *gtk.Entry
+	nameEntries   [2]*gtk.Entry
+	modelDrops    [2]*gtk.DropDown
+	promptEntries [2]*gtk.Entry
+	turnsSpin     *gtk.SpinButton
+
+	// Data
+	models []string
+
+	// Callbacks
+	onStart func(multiAgentConfig)
+}
+
+// NewMultiAgentDialog creates a new multi-agent conversation dialog.
+func NewMultiAgentDialog(parent *gtk.Window, models []string) *MultiAgentDialog {
+	d := &MultiAgentDialog{
+		models: models,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Multi-Agent Conversation"))
+	d.SetModal(true)
+	d.SetDefaultSize(480, 560)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+
+	return d
+}
+
+func (d *MultiAgentDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(gtk.NewLabel(i18n.T("Multi-Agent Conversation")))
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	desc := gtk.NewLabel(i18n.T("Two models take turns discussing a topic. Experimental."))
+	desc.AddCSSClass("dim-label")
+	desc.SetWrap(true)
+	desc.SetXAlign(0)
+	content.Append(desc)
+
+	// Topic
+	topicLabel := gtk.NewLabel(i18n.T("Topic:"))
+	topicLabel.SetXAlign(0)
+	topicLabel.AddCSSClass("heading")
+	content.Append(topicLabel)
+
+	d.topicEntry = gtk.NewEntry()
+	d.topicEntry.SetPlaceholderText(i18n.T("What should they talk about?"))
+	content.Append(d.topicEntry)
+
+	// Personas
+	defaultNames := [2]string{i18n.T("Agent A"), i18n.T("Agent B")}
+	for i := 0; i < 2; i++ {
+		label := gtk.NewLabel(fmt.Sprintf(i18n.T("Participant %d:"), i+1))
+		label.SetXAlign(0)
+		label.SetMarginTop(8)
+		label.AddCSSClass("heading")
+		content.Append(label)
+
+		d.nameEntries[i] = gtk.NewEntry()
+		d.nameEntries[i].SetText(defaultNames[i])
+		d.nameEntries[i].SetPlaceholderText(i18n.T("Name"))
+		content.Append(d.nameEntries[i])
+
+		modelList := gtk.NewStringList(d.models)
+		d.modelDrops[i] = gtk.NewDropDown(modelList, nil)
+		if len(d.models) > 1 {
+			d.modelDrops[i].SetSelected(uint(i))
+		}
+		content.Append(d.modelDrops[i])
+
+		d.promptEntries[i] = gtk.NewEntry()
+		d.promptEntries[i].SetPlaceholderText(i18n.T("Persona (e.g. a skeptical scientist)"))
+		content.Append(d.promptEntries[i])
+	}
+
+	// Turns
+	turnsBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	turnsBox.SetMarginTop(8)
+	turnsLabel := gtk.NewLabel(i18n.T("Number of messages:"))
+	turnsLabel.SetHExpand(true)
+	turnsLabel.SetXAlign(0)
+	turnsLabel.AddCSSClass("heading")
+	turnsBox.Append(turnsLabel)
+
+	d.turnsSpin = gtk.NewSpinButtonWithRange(2, 40, 1)
+	d.turnsSpin.SetValue(defaultAgentTurns)
+	turnsBox.Append(d.turnsSpin)
+	content.Append(turnsBox)
+
+	// Buttons
+	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttonBox.SetHAlign(gtk.AlignEnd)
+	buttonBox.SetMarginTop(16)
+
+	cancelBtn := gtk.NewButton()
+	cancelBtn.SetLabel(i18n.T("Cancel"))
+	cancelBtn.ConnectClicked(func() {
+		d.Close()
+	})
+	buttonBox.Append(cancelBtn)
+
+	startBtn := gtk.NewButton()
+	startBtn.SetLabel(i18n.T("Start"))
+	startBtn.AddCSSClass("suggested-action")
+	startBtn.ConnectClicked(d.onStartClicked)
+	buttonBox.Append(startBtn)
+
+	content.Append(buttonBox)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(content)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(scrolled)
+
+	d.SetContent(toolbarView)
+}
+
+func (d *MultiAgentDialog) onStartClicked() {
+	topic := strings.TrimSpace(d.topicEntry.Text())
+	if topic == "" || len(d.models) == 0 {
+		d.topicEntry.GrabFocus()
+		return
+	}
+
+	cfg := multiAgentConfig{
+		Topic: topic,
+		Turns: d.turnsSpin.ValueAsInt(),
+	}
+	for i := 0; i < 2; i++ {
+		name := strings.TrimSpace(d.nameEntries[i].Text())
+		if name == "" {
+			name = fmt.Sprintf(i18n.T("Agent %d"), i+1)
+		}
+		model := ""
+		if idx := int(d.modelDrops[i].Selected()); idx < len(d.models) {
+			model = d.models[idx]
+		}
+		cfg.Personas[i] = agentPersona{
+			Name:   name,
+			Model:  model,
+			Prompt: strings.TrimSpace(d.promptEntries[i].Text()),
+		}
+	}
+
+	if d.onStart != nil {
+		d.onStart(cfg)
+	}
+	d.Close()
+}
+
+// OnStart sets the callback for when the conversation is started.
+func (d *MultiAgentDialog) OnStart(callback func(multiAgentConfig)) {
+	d.onStart = callback
+}
+
+// StartMultiAgent runs a conversation between two personas in a new chat.
+func (cv *ChatView) StartMultiAgent(cfg multiAgentConfig) {
+	if cv.isStreaming {
+		return
+	}
+
+	cv.NewChat()
+	cv.currentModel = cfg.Personas[0].Model
+	cv.createNewChat()
+	if cv.currentChat != nil && cv.db != nil {
+		title := truncatePreview(fmt.Sprintf("%s ↔ %s: %s", cfg.Personas[0].Name, cfg.Personas[1].Name, cfg.Topic), 60)
+		if err := cv.db.UpdateChatTitle(cv.currentChat.ID, title); err == nil {
+			cv.currentChat.Title = title
+			if cv.onTitleChanged != nil {
+				cv.onTitleChanged(title)
+			}
+		}
+	}
+
+	cv.addMessage(store.RoleUser, cfg.Topic)
+	if cv.db != nil && cv.currentChat != nil {
+		cv.saveMessage(nil, &store.Message{ChatID: cv.currentChat.ID, Role: store.RoleUser, Content: cfg.Topic}, nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cv.streamCancel = cancel
+	cv.setStreaming(true)
+	cv.inputArea.SetStreamingMode(true)
+
+	chatID := int64(0)
+	if cv.currentChat != nil {
+		chatID = cv.currentChat.ID
+	}
+
+	go func() {
+		var turns []agentTurn
+		var err error
+
+		for i := 0; i < cfg.Turns && ctx.Err() == nil; i++ {
+			speaker := i % 2
+			persona := cfg.Personas[speaker]
+
+			// Create the bubble on the main thread
+			bubbleCh := make(chan *MessageBubble, 1)
+			glib.IdleAdd(func() {
+				bubbleCh <- cv.addAgentBubble(persona, speaker)
+			})
+			bubble := <-bubbleCh
+
+			var response strings.Builder
+			buffer := newTokenBuffer(tokenFlushInterval, func(content string) {
+				glib.IdleAdd(func() {
+					bubble.SetContent(content)
+					if cv.userAtBottom {
+						cv.scrollToBottom()
+					}
+				})
+			})
+
+			streamCtx, streamCancel := context.WithTimeout(ctx, streamingTimeout)
+			err = cv.streamHandler.Chat(streamCtx, &ollama.ChatRequest{
+				Model:    persona.Model,
+				Messages: buildAgentMessages(cfg, turns, speaker),
+			}, func(token string) {
+				response.WriteString(token)
+				buffer.Write(response.String())
+			})
+			streamCancel()
+			buffer.Stop()
+
+			content := response.String()
+			if content != "" {
+				turns = append(turns, agentTurn{Speaker: speaker, Content: content})
+				if cv.db != nil && chatID != 0 {
+					msg := &store.Message{
+						ChatID:    chatID,
+						Role:      store.RoleAssistant,
+						Content:   formatAgentMessage(persona, content),
+						Agent:     agentLabel(persona),
+						AgentSlot: speaker,
+					}
+					glib.IdleAdd(func() {
+						cv.saveMessage(nil, msg, nil)
+					})
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+
+		glib.IdleAdd(func() {
+			cv.streamCancel = nil
+			cv.setStreaming(false)
+			cv.inputArea.SetStreamingMode(false)
+			if cv.currentBubble != nil && cv.currentBubble.IsThinking() {
+				cv.currentBubble.SetThinking(false)
+			}
+			cv.currentBubble = nil
+
+			if err != nil && err != context.Canceled {
+				logger.Error("Multi-agent conversation failed", "error", err)
+				cv.handleError(err)
+			}
+		})
+	}()
+}
+
+// addAgentBubble adds an assistant bubble styled for the given agent.
+func (cv *ChatView) addAgentBubble(persona agentPersona, speaker int) *MessageBubble {
+	bubble := cv.addMessage(store.RoleAssistant, "")
+	bubble.SetAgent(agentLabel(persona), speaker)
+	bubble.SetThinking(true)
+	cv.currentBubble = bubble
+	return bubble
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildAgentMessages(t *testing.T) {
+	cfg := multiAgentConfig{
+		Topic: "Is Go better than Rust?",
+		Personas: [2]agentPersona{
+			{Name: "Ana", Model: "llama3", Prompt: "You love Go."},
+			{Name: "Beto", Model: "mistral"},
+		},
+	}
+	turns := []agentTurn{
+		{Speaker: 0, Content: "Go is simple."},
+		{Speaker: 1, Content: "Rust is safe."},
+	}
+
+	t.Run("first speaker sees own turns as assistant", func(t *testing.T) {
+		messages := buildAgentMessages(cfg, turns, 0)
+		if len(messages) != 4 {
+			t.Fatalf("len(messages) = %d, want 4", len(messages))
+		}
+		if messages[0].Role != "system" || !strings.HasPrefix(messages[0].Content, "You love Go.") {
+			t.Errorf("system message = %q, want persona prompt first", messages[0].Content)
+		}
+		if messages[1].Role != "user" || messages[1].Content != cfg.Topic {
+			t.Errorf("messages[1] = %+v, want topic as user message", messages[1])
+		}
+		if messages[2].Role != "assistant" || messages[3].Role != "user" {
+			t.Errorf("roles = %s, %s; want assistant, user", messages[2].Role, messages[3].Role)
+		}
+	})
+
+	t.Run("second speaker sees roles reversed", func(t *testing.T) {
+		messages := buildAgentMessages(cfg, turns, 1)
+		if messages[2].Role != "user" || messages[3].Role != "assistant" {
+			t.Errorf("roles = %s, %s; want user, assistant", messages[2].Role, messages[3].Role)
+		}
+		if !strings.Contains(messages[0].Content, "You are Beto") {
+			t.Errorf("system message = %q, want speaker name", messages[0].Content)
+		}
+	})
+}
+
+func TestFormatAgentMessage(t *testing.T) {
+	got := formatAgentMessage(agentPersona{Name: "Ana", Model: "llama3"}, "Hello")
+	want := "**Ana** (llama3)\n\nHello"
+	if got != want {
+		t.Errorf("formatAgentMessage() = %q, want %q", got, want)
+	}
+	if reply := agentReply(got); reply != "Hello" {
+		t.Errorf("agentReply(%q) = %q, want %q", got, reply, "Hello")
+	}
+	if got := agentLabel(agentPersona{Name: "Ana", Model: "llama3"}); got != "Ana · llama3" {
+		t.Errorf("agentLabel() = %q", got)
+	}
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/notes"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// sendToNotes appends note to the configured notes folder and returns the
+// message to show the user.
+func sendToNotes(cfg *config.AppConfig, note notes.Note) (string, error) {
+	if cfg == nil || cfg.NotesFolder == "" {
+		return i18n.T("Choose a notes folder in Settings first"), nil
+	}
+	note.Tags = cfg.NotesTags
+
+	path, added, err := notes.Append(cfg.NotesFolder, note)
+	if err != nil {
+		return "", err
+	}
+	if !added {
+		return fmt.Sprintf(i18n.T("Already in %s"), filepath.Base(path)), nil
+	}
+	logger.Info("Sent to notes", "path", path)
+	return fmt.Sprintf(i18n.T("Added to %s"), filepath.Base(path)), nil
+}
+
+// sendAnswerToNotes adds the answer in bubble, with its question, to the
+// note of the current chat.
+func (cv *ChatView) sendAnswerToNotes(bubble *MessageBubble) {
+	now := time.Now()
+	note := notes.Note{
+		Title: i18n.T("New Chat"),
+		Model: cv.chatModel(),
+		Date:  now,
+		Body:  notes.AnswerBody(cv.promptFor(bubble), bubble.GetContent(), now),
+	}
+	if cv.currentChat != nil {
+		note.Title = cv.currentChat.Title
+	}
+
+	message, err := sendToNotes(cv.appConfig, note)
+	if err != nil {
+		logger.Error("Failed to send answer to notes", "error", err)
+		cv.handleError(err)
+		return
+	}
+	cv.notify(message)
+}
+
+// onSendChatToNotes adds a whole chat to its note.
+func (w *MainWindow) onSendChatToNotes(chat *store.Chat) {
+	chats, err := w.db.LoadExport([]int64{chat.ID})
+	if err != nil || len(chats) == 0 {
+		logger.Error("Failed to load chat for notes", "chatID", chat.ID, "error", err)
+		w.showToast(i18n.T("Failed to send chat to notes"))
+		return
+	}
+	body, err := notes.ChatBody(chats[0])
+	if err != nil {
+		logger.Error("Failed to format chat for notes", "chatID", chat.ID, "error", err)
+		w.showToast(i18n.T("Failed to send chat to notes"))
+		return
+	}
+
+	message, err := sendToNotes(w.appConfig, notes.Note{
+		Title: chat.Title,
+		Model: chat.Model,
+		Date:  time.Now(),
+		Body:  body,
+	})
+	if err != nil {
+		logger.Error("Failed to send chat to notes", "chatID", chat.ID, "error", err)
+		w.showToast(i18n.T("Failed to send chat to notes"))
+		return
+	}
+	w.showToast(message)
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// Desktop notifications tell about responses, downloads and errors that
+// finish while no Guanaco window has the focus. Each event can be turned
+// off in the settings, and none are sent during the quiet hours.
+
+// notify sends a notification for event with body, unless the settings
+// turn it off. A notification with the same id replaces the one before.
+func (a *Application) notify(event, id, body string) {
+	if a.appConfig != nil && !a.appConfig.ShouldNotify(event, time.Now()) {
+		logger.Debug("Notification not sent", "event", event)
+		return
+	}
+	notification := gio.NewNotification("Guanaco")
+	notification.SetBody(body)
+	a.SendNotification(id, notification)
+}
+
+// hasFocus reports whether one of the windows of Guanaco is active.
+func (a *Application) hasFocus() bool {
+	for _, win := range a.Windows() {
+		if win.IsActive() {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyUnfocused sends a notification for event when the user isn't
+// looking at Guanaco. Windows running in the background notify once they
+// are done instead.
+func (w *MainWindow) notifyUnfocused(event, id, body string) {
+	if w.inBackground || w.app.hasFocus() {
+		return
+	}
+	w.app.notify(event, id, body)
+}
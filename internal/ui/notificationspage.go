@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// notifyEventTitles returns the setting name and description of each
+// notification event, in the order of config.NotifyEvents.
+func notifyEventTitles() [][2]string {
+	return [][2]string{
+		{i18n.T("Responses"), i18n.T("A response finished")},
+		{i18n.T("Model downloads"), i18n.T("A model finished downloading")},
+		{i18n.T("Errors"), i18n.T("A response or download failed")},
+	}
+}
+
+// createNotificationsPage creates the page choosing which events send
+// desktop notifications, and when none are sent.
+func (d *SettingsDialog) createNotificationsPage() *gtk.ScrolledWindow {
+	content := gtk.NewBox(gtk.OrientationVertical, 16)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	eventsLabel := gtk.NewLabel(i18n.T("Notify About:"))
+	eventsLabel.SetXAlign(0)
+	eventsLabel.AddCSSClass("heading")
+	content.Append(eventsLabel)
+
+	eventsHint := gtk.NewLabel(i18n.T("Notifications are only sent while no Guanaco window has the focus"))
+	eventsHint.SetXAlign(0)
+	eventsHint.SetWrap(true)
+	eventsHint.AddCSSClass("dim-label")
+	eventsHint.AddCSSClass("caption")
+	content.Append(eventsHint)
+
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+	d.notifySwitches = make(map[string]*gtk.Switch, len(config.NotifyEvents))
+	titles := notifyEventTitles()
+	for i, event := range config.NotifyEvents {
+		row, sw := createSwitchRow(titles[i][0], titles[i][1], d.config.NotifyEnabled(event))
+		d.notifySwitches[event] = sw
+		list.Append(row)
+	}
+	content.Append(list)
+
+	// === Quiet Hours ===
+	quietBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	quietBox.SetMarginTop(8)
+
+	quietText := gtk.NewBox(gtk.OrientationVertical, 2)
+	quietText.SetHExpand(true)
+
+	quietLabel := gtk.NewLabel(i18n.T("Quiet hours"))
+	quietLabel.SetXAlign(0)
+	quietLabel.AddCSSClass("heading")
+	quietText.Append(quietLabel)
+
+	quietHint := gtk.NewLabel(i18n.T("Send no notifications from the first hour to the second, on a 24-hour clock"))
+	quietHint.SetXAlign(0)
+	quietHint.SetWrap(true)
+	quietHint.AddCSSClass("dim-label")
+	quietHint.AddCSSClass("caption")
+	quietText.Append(quietHint)
+	quietBox.Append(quietText)
+
+	d.quietSwitch = gtk.NewSwitch()
+	d.quietSwitch.SetActive(d.config.QuietHours)
+	d.quietSwitch.SetVAlign(gtk.AlignCenter)
+	quietBox.Append(d.quietSwitch)
+	content.Append(quietBox)
+
+	hoursBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	hoursBox.Append(gtk.NewLabel(i18n.T("From")))
+	d.quietStartSpin = gtk.NewSpinButtonWithRange(0, 23, 1)
+	d.quietStartSpin.SetValue(float64(d.config.QuietHoursStart))
+	d.quietStartSpin.SetWrap(true)
+	hoursBox.Append(d.quietStartSpin)
+	hoursBox.Append(gtk.NewLabel(i18n.T("to")))
+	d.quietEndSpin = gtk.NewSpinButtonWithRange(0, 23, 1)
+	d.quietEndSpin.SetValue(float64(d.config.QuietHoursEnd))
+	d.quietEndSpin.SetWrap(true)
+	hoursBox.Append(d.quietEndSpin)
+	hoursBox.SetSensitive(d.config.QuietHours)
+	d.quietSwitch.NotifyProperty("active", func() {
+		hoursBox.SetSensitive(d.quietSwitch.Active())
+	})
+	content.Append(hoursBox)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(content)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	return scrolled
+}
+
+// createSwitchRow creates a list row with a title, a description and a
+// switch set to active.
+func createSwitchRow(title, description string, active bool) (*gtk.ListBoxRow, *gtk.Switch) {
+	box := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	box.SetMarginTop(8)
+	box.SetMarginBottom(8)
+	box.SetMarginStart(12)
+	box.SetMarginEnd(12)
+
+	text := gtk.NewBox(gtk.OrientationVertical, 2)
+	text.SetHExpand(true)
+
+	titleLabel := gtk.NewLabel(title)
+	titleLabel.SetXAlign(0)
+	titleLabel.AddCSSClass("heading")
+	text.Append(titleLabel)
+
+	descriptionLabel := gtk.NewLabel(description)
+	descriptionLabel.SetXAlign(0)
+	descriptionLabel.SetWrap(true)
+	descriptionLabel.AddCSSClass("dim-label")
+	descriptionLabel.AddCSSClass("caption")
+	text.Append(descriptionLabel)
+	box.Append(text)
+
+	sw := gtk.NewSwitch()
+	sw.SetActive(active)
+	sw.SetVAlign(gtk.AlignCenter)
+	box.Append(sw)
+
+	row := gtk.NewListBoxRow()
+	row.SetChild(box)
+	row.SetActivatable(false)
+	return row, sw
+}
+
+// saveNotifications stores the notification settings of the page.
+func (d *SettingsDialog) saveNotifications() {
+	for event, sw := range d.notifySwitches {
+		d.config.SetNotifyEnabled(event, sw.Active())
+	}
+	d.config.QuietHours = d.quietSwitch.Active()
+	d.config.QuietHoursStart = d.quietStartSpin.ValueAsInt()
+	d.config.QuietHoursEnd = d.quietEndSpin.ValueAsInt()
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+// imageTextExtractor reads the text of a base64-encoded image.
+type imageTextExtractor func(ctx context.Context, image string) (string, error)
+
+// hasImages reports whether any of messages carries images.
+func hasImages(messages []ollama.Message) bool {
+	for _, m := range messages {
+		if len(m.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// imagesAsText returns a copy of messages with the images of each message
+// replaced by their text, which is put before the message content. Images
+// that can't be read are noted as such, and counted in failed. With a nil
+// extract the images are only noted as left out.
+func imagesAsText(ctx context.Context, messages []ollama.Message, extract imageTextExtractor) (result []ollama.Message, failed int) {
+	result = make([]ollama.Message, len(messages))
+	for i, m := range messages {
+		result[i] = m
+		if len(m.Images) == 0 {
+			continue
+		}
+
+		var builder strings.Builder
+		for j, image := range m.Images {
+			if extract == nil {
+				builder.WriteString(fmt.Sprintf("[Image %d left out: the model can't see images]\n\n", j+1))
+				continue
+			}
+			text, err := extract(ctx, image)
+			if err != nil {
+				logger.Error("Failed to read image text", "error", err)
+				failed++
+			}
+			builder.WriteString(fmt.Sprintf("[Image %d text]\n", j+1))
+			switch {
+			case err != nil:
+				builder.WriteString("(the image could not be read)")
+			case text == "":
+				builder.WriteString("(no text found)")
+			default:
+				builder.WriteString(text)
+			}
+			builder.WriteString("\n\n")
+		}
+		builder.WriteString(m.Content)
+
+		result[i].Content = builder.String()
+		result[i].Images = nil
+	}
+	return result, failed
+}
+
+// readImagesIfBlind replaces the images of messages with their text when
+// model can't see images, so they aren't silently ignored, or leaves them
+// out when they can't be read. It runs in the streaming goroutine.
+func (cv *ChatView) readImagesIfBlind(ctx context.Context, model string, messages []ollama.Message) []ollama.Message {
+	if !hasImages(messages) || cv.ollamaClient == nil || cv.visionSupport.supports(ctx, cv.ollamaClient, model) {
+		return messages
+	}
+
+	if cv.appConfig == nil || !cv.appConfig.ImageOCR {
+		logger.Warn("Leaving out images for a model without vision", "model", model)
+		messages, _ = imagesAsText(ctx, messages, nil)
+		glib.IdleAdd(func() {
+			cv.notify(fmt.Sprintf(i18n.T("%s can't see images, so they were left out"), model))
+		})
+		return messages
+	}
+	if !cv.ocr.Available() {
+		logger.Warn("Leaving out images for a model without vision", "model", model, "error", rag.ErrOCRUnavailable)
+		messages, _ = imagesAsText(ctx, messages, nil)
+		glib.IdleAdd(func() {
+			cv.notify(fmt.Sprintf(i18n.T("%s can't see images, so they were left out. Install tesseract to send their text instead"), model))
+		})
+		return messages
+	}
+
+	logger.Info("Reading image text for a model without vision", "model", model)
+	messages, failed := imagesAsText(ctx, messages, cv.ocr.ExtractTextBase64)
+	glib.IdleAdd(func() {
+		if failed > 0 {
+			cv.notify(fmt.Sprintf(i18n.T("%s can't see images. Some images could not be read and were left out"), model))
+		} else {
+			cv.notify(fmt.Sprintf(i18n.T("%s can't see images, so their text was sent instead"), model))
+		}
+	})
+	return messages
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+func TestImagesAsText(t *testing.T) {
+	texts := map[string]string{"a": "Invoice 42", "b": ""}
+	extract := func(ctx context.Context, image string) (string, error) {
+		text, ok := texts[image]
+		if !ok {
+			return "", errors.New("unreadable")
+		}
+		return text, nil
+	}
+	messages := []ollama.Message{
+		{Role: "user", Content: "First", Images: []string{"a"}},
+		{Role: "assistant", Content: "Reply"},
+		{Role: "user", Content: "What is this?", Images: []string{"b", "c"}},
+	}
+
+	got, failed := imagesAsText(context.Background(), messages, extract)
+
+	if failed != 1 {
+		t.Errorf("imagesAsText() failed = %d, want 1", failed)
+	}
+	want := []string{
+		"[Image 1 text]\nInvoice 42\n\nFirst",
+		"Reply",
+		"[Image 1 text]\n(no text found)\n\n[Image 2 text]\n(the image could not be read)\n\nWhat is this?",
+	}
+	for i, m := range got {
+		if m.Content != want[i] {
+			t.Errorf("message %d content = %q, want %q", i, m.Content, want[i])
+		}
+		if len(m.Images) != 0 {
+			t.Errorf("message %d still has %d images", i, len(m.Images))
+		}
+	}
+	if len(messages[0].Images) != 1 || messages[0].Content != "First" {
+		t.Error("imagesAsText() modified the original messages")
+	}
+}
+
+func TestImagesAsText_LeftOut(t *testing.T) {
+	messages := []ollama.Message{{Role: "user", Content: "Look", Images: []string{"a", "b"}}}
+
+	got, failed := imagesAsText(context.Background(), messages, nil)
+
+	want := "[Image 1 left out: the model can't see images]\n\n[Image 2 left out: the model can't see images]\n\nLook"
+	if failed != 0 || got[0].Content != want || got[0].Images != nil {
+		t.Errorf("imagesAsText(nil) = %q, %v, %d failed; want %q without images", got[0].Content, got[0].Images, failed, want)
+	}
+}
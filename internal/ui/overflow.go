@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// overflowExcerptLength is how many characters of an overlong message stay
+// in its body when the full text is moved to an attachment.
+const overflowExcerptLength = 280
+
+// overflowAttachmentName is the file name of the attachment holding the
+// full text of an overlong message.
+const overflowAttachmentName = "pasted-text.txt"
+
+// splitOverflow reports whether text is longer than maxLen characters and,
+// if so, returns the excerpt to keep in the message body. The excerpt is cut
+// at a line or word boundary when there is one nearby. A maxLen of 0 or less
+// disables the limit.
+func splitOverflow(text string, maxLen int) (string, bool) {
+	if maxLen <= 0 || utf8.RuneCountInString(text) <= maxLen {
+		return "", false
+	}
+
+	runes := []rune(text)
+	excerpt := string(runes[:min(overflowExcerptLength, maxLen, len(runes))])
+	if i := strings.LastIndexAny(excerpt, "\n "); i > len(excerpt)/2 {
+		excerpt = excerpt[:i]
+	}
+	return strings.TrimSpace(excerpt) + "…", true
+}
+
+// uniqueAttachmentName returns name, numbered if an attachment with that
+// name already exists, e.g. "pasted-text-2.txt".
+func uniqueAttachmentName(name string, existing []string) string {
+	used := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		used[e] = true
+	}
+
+	ext := ""
+	base := name
+	if i := strings.LastIndex(name, "."); i > 0 {
+		base, ext = name[:i], name[i:]
+	}
+
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+	return unique
+}
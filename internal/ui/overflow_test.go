@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitOverflow(t *testing.T) {
+	longLog := strings.Repeat("2025-01-01 12:00:00 INFO request handled\n", 100)
+
+	tests := []struct {
+		name       string
+		text       string
+		maxLen     int
+		wantSplit  bool
+		wantPrefix string
+	}{
+		{
+			name:   "short text",
+			text:   "Hello world",
+			maxLen: 100,
+		},
+		{
+			name:   "exactly at the limit",
+			text:   strings.Repeat("a", 100),
+			maxLen: 100,
+		},
+		{
+			name:   "limit disabled",
+			text:   longLog,
+			maxLen: 0,
+		},
+		{
+			name:       "long log cut at a line",
+			text:       longLog,
+			maxLen:     1000,
+			wantSplit:  true,
+			wantPrefix: "2025-01-01 12:00:00 INFO request handled\n",
+		},
+		{
+			name:       "limit below the excerpt length",
+			text:       "one two three four five six",
+			maxLen:     10,
+			wantSplit:  true,
+			wantPrefix: "one two",
+		},
+		{
+			name:       "multibyte characters",
+			text:       strings.Repeat("ñ", 500),
+			maxLen:     400,
+			wantSplit:  true,
+			wantPrefix: "ñññ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			excerpt, split := splitOverflow(tt.text, tt.maxLen)
+			if split != tt.wantSplit {
+				t.Fatalf("splitOverflow() split = %v, want %v", split, tt.wantSplit)
+			}
+			if !split {
+				return
+			}
+			if !strings.HasPrefix(excerpt, tt.wantPrefix) || !strings.HasSuffix(excerpt, "…") {
+				t.Errorf("splitOverflow() excerpt = %q, want prefix %q and an ellipsis", excerpt, tt.wantPrefix)
+			}
+			if !utf8.ValidString(excerpt) {
+				t.Errorf("splitOverflow() excerpt %q is not valid UTF-8", excerpt)
+			}
+			if n := utf8.RuneCountInString(excerpt); n > min(overflowExcerptLength, tt.maxLen)+1 {
+				t.Errorf("splitOverflow() excerpt has %d characters, too long", n)
+			}
+		})
+	}
+}
+
+func TestUniqueAttachmentName(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		want     string
+	}{
+		{"pasted-text.txt", nil, "pasted-text.txt"},
+		{"pasted-text.txt", []string{"notes.pdf"}, "pasted-text.txt"},
+		{"pasted-text.txt", []string{"pasted-text.txt"}, "pasted-text-2.txt"},
+		{"pasted-text.txt", []string{"pasted-text.txt", "pasted-text-2.txt"}, "pasted-text-3.txt"},
+		{"README", []string{"README"}, "README-2"},
+	}
+
+	for _, tt := range tests {
+		if got := uniqueAttachmentName(tt.name, tt.existing); got != tt.want {
+			t.Errorf("uniqueAttachmentName(%q, %v) = %q, want %q", tt.name, tt.existing, got, tt.want)
+		}
+	}
+}
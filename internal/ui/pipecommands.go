@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/hooks"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// Responses can be sent to the commands configured under "pipes" in the
+// settings, such as wl-copy or pandoc. A command runs only after the user
+// confirms it, unless they chose to always run it, and what it prints is
+// shown when it finishes.
+
+// choosePipeCommand shows the pipe commands in a menu below anchor and
+// sends the response of bubble to the one chosen.
+func (cv *ChatView) choosePipeCommand(bubble *MessageBubble, anchor *gtk.Button) {
+	if cv.appConfig == nil {
+		return
+	}
+
+	var items []rowMenuItem
+	for _, pipe := range cv.appConfig.Pipes() {
+		items = append(items, rowMenuItem{pipe.Label(), func() {
+			cv.confirmPipeCommand(pipe, bubble.Text())
+		}})
+	}
+	if len(items) == 0 {
+		return
+	}
+	showMenu(anchor, items, 0, float64(anchor.Height()))
+}
+
+// confirmPipeCommand asks before running pipe with text, unless the user
+// trusted it earlier.
+func (cv *ChatView) confirmPipeCommand(pipe config.PipeCommand, text string) {
+	if pipe.Trusted {
+		cv.runPipeCommand(pipe, text)
+		return
+	}
+
+	dialog := adw.NewMessageDialog(cv.parentWindow(), i18n.T("Run This Command?"),
+		fmt.Sprintf(i18n.T("The response will be sent to the standard input of:\n\n%s"), pipe.Command))
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("always", i18n.T("Always Run"))
+	dialog.AddResponse("run", i18n.T("Run"))
+	dialog.SetResponseAppearance("run", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("run")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		switch response {
+		case "always":
+			cv.appConfig.TrustPipeCommand(pipe.Command)
+			if err := cv.appConfig.Save(); err != nil {
+				logger.Error("Failed to save settings", "error", err)
+			}
+			cv.runPipeCommand(pipe, text)
+		case "run":
+			cv.runPipeCommand(pipe, text)
+		}
+	})
+
+	dialog.Present()
+}
+
+// runPipeCommand runs pipe in the background with text on its stdin and
+// shows what it printed.
+func (cv *ChatView) runPipeCommand(pipe config.PipeCommand, text string) {
+	logger.Info("Piping response to command", "command", pipe.Command)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), hooks.Timeout)
+		defer cancel()
+
+		output, err := hooks.Pipe(ctx, pipe.Command, text)
+
+		glib.IdleAdd(func() {
+			if err != nil {
+				logger.Error("Pipe command failed", "command", pipe.Command, "error", err)
+				cv.handleError(fmt.Errorf(i18n.T("%s failed: %v"), pipe.Label(), err))
+				return
+			}
+			if output == "" {
+				cv.notify(fmt.Sprintf(i18n.T("Sent to %s"), pipe.Label()))
+				return
+			}
+			cv.showPipeOutput(pipe, output)
+		})
+	}()
+}
+
+// showPipeOutput shows what pipe printed, with a button to copy it.
+func (cv *ChatView) showPipeOutput(pipe config.PipeCommand, output string) {
+	textView := gtk.NewTextView()
+	textView.Buffer().SetText(output)
+	textView.SetEditable(false)
+	textView.SetMonospace(true)
+	textView.SetWrapMode(gtk.WrapWordChar)
+	textView.AddCSSClass("card")
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(textView)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetSizeRequest(480, 240)
+
+	dialog := adw.NewMessageDialog(cv.parentWindow(), pipe.Label(), i18n.T("The command printed:"))
+	dialog.SetExtraChild(scrolled)
+	dialog.AddResponse("copy", i18n.T("Copy"))
+	dialog.AddResponse("close", i18n.T("Close"))
+	dialog.SetDefaultResponse("close")
+	dialog.SetCloseResponse("close")
+
+	dialog.ConnectResponse(func(response string) {
+		if response == "copy" {
+			gdk.DisplayGetDefault().Clipboard().SetText(output)
+			cv.notify(i18n.T("Copied to clipboard"))
+		}
+	})
+
+	dialog.Present()
+}
@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// With "Plain text messages" on in the appearance settings, messages are
+// shown as a single plain label instead of Pango markup and code block
+// widgets. Code blocks, lists, tables and quotes are announced in words
+// where they start and end, so that screen readers and braille displays
+// get the text and its structure through AT-SPI.
+
+// plainTextMessages is whether messages are rendered as plain text, set
+// from the settings by applyAppearance.
+var plainTextMessages bool
+
+// ToAccessibleText converts markdown text to plain text with its structure
+// spelled out: headings, code blocks, lists, tables and quotes are
+// announced in words, and the other markup is removed.
+func (r *MarkdownRenderer) ToAccessibleText(markdown string) string {
+	markdown = html.UnescapeString(markdown)
+	markdown = normalizeMarkdown(markdown)
+
+	source := []byte(markdown)
+	doc := r.md.Parser().Parse(text.NewReader(source))
+	return accessibleBlocks(doc, source)
+}
+
+// accessibleBlocks renders the block children of node, separated by blank
+// lines.
+func accessibleBlocks(node ast.Node, source []byte) string {
+	var blocks []string
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if block := strings.TrimSpace(accessibleBlock(child, source)); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// accessibleBlock renders a block node as plain text.
+func accessibleBlock(node ast.Node, source []byte) string {
+	switch n := node.(type) {
+	case *ast.Heading:
+		return fmt.Sprintf(i18n.T("Heading level %d: %s"), n.Level, accessibleInline(n, source))
+
+	case *ast.FencedCodeBlock:
+		start := i18n.T("Code block:")
+		if lang := string(n.Language(source)); lang != "" {
+			start = fmt.Sprintf(i18n.T("Code block, %s:"), lang)
+		}
+		return start + "\n" + codeLines(n, source) + "\n" + i18n.T("End of code block.")
+
+	case *ast.CodeBlock:
+		return i18n.T("Code block:") + "\n" + codeLines(n, source) + "\n" + i18n.T("End of code block.")
+
+	case *ast.List:
+		return accessibleList(n, source)
+
+	case *ast.Blockquote:
+		return i18n.T("Quote:") + "\n" + accessibleBlocks(n, source) + "\n" + i18n.T("End of quote.")
+
+	case *east.Table:
+		return accessibleTable(n, source)
+
+	case *mathBlock:
+		return renderMath(n.TeX(source), true, false)
+
+	case *ast.ThematicBreak, *ast.HTMLBlock:
+		return ""
+
+	default:
+		return accessibleInline(n, source)
+	}
+}
+
+// codeLines returns the lines of a code block, without the last newline.
+func codeLines(node ast.Node, source []byte) string {
+	var b strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		b.Write(line.Value(source))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// accessibleList renders a list with the number of its items, one item per
+// line.
+func accessibleList(list *ast.List, source []byte) string {
+	var items []string
+	for child := list.FirstChild(); child != nil; child = child.NextSibling() {
+		items = append(items, accessibleBlocks(child, source))
+	}
+
+	var start string
+	switch {
+	case len(items) == 1:
+		start = i18n.T("List of 1 item:")
+	case list.IsOrdered():
+		start = fmt.Sprintf(i18n.T("Numbered list of %d items:"), len(items))
+	default:
+		start = fmt.Sprintf(i18n.T("List of %d items:"), len(items))
+	}
+
+	lines := []string{start}
+	for i, item := range items {
+		if list.IsOrdered() {
+			lines = append(lines, fmt.Sprintf("%d. %s", list.Start+i, item))
+		} else {
+			lines = append(lines, "• "+item)
+		}
+	}
+	lines = append(lines, i18n.T("End of list."))
+	return strings.Join(lines, "\n")
+}
+
+// accessibleTable renders a table row by row, naming the column of each
+// cell after its header.
+func accessibleTable(table *east.Table, source []byte) string {
+	var headers []string
+	var rows [][]string
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, accessibleInline(cell, source))
+		}
+		if _, ok := row.(*east.TableHeader); ok {
+			headers = cells
+		} else {
+			rows = append(rows, cells)
+		}
+	}
+
+	lines := []string{fmt.Sprintf(i18n.T("Table with %d columns and %d rows:"), len(headers), len(rows))}
+	for i, cells := range rows {
+		named := make([]string, len(cells))
+		for j, cell := range cells {
+			named[j] = cell
+			if j < len(headers) && headers[j] != "" {
+				named[j] = headers[j] + ": " + cell
+			}
+		}
+		lines = append(lines, fmt.Sprintf(i18n.T("Row %d: %s"), i+1, strings.Join(named, "; ")))
+	}
+	lines = append(lines, i18n.T("End of table."))
+	return strings.Join(lines, "\n")
+}
+
+// accessibleInline renders the inline children of node as plain text.
+// Links keep their address after the text, and images their description.
+func accessibleInline(node ast.Node, source []byte) string {
+	var b strings.Builder
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(source))
+			if n.HardLineBreak() {
+				b.WriteString("\n")
+			} else if n.SoftLineBreak() {
+				b.WriteString(" ")
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		case *ast.Link:
+			label := accessibleInline(n, source)
+			b.WriteString(label)
+			if dest := string(n.Destination); dest != "" && dest != label {
+				b.WriteString(" (" + dest + ")")
+			}
+		case *ast.AutoLink:
+			b.Write(n.URL(source))
+		case *ast.Image:
+			b.WriteString(fmt.Sprintf(i18n.T("Image: %s"), accessibleInline(n, source)))
+		case *mathInline:
+			b.WriteString(renderMath(n.TeX, n.Display, false))
+		case *ast.RawHTML:
+			// Skipped, as in the rendered text
+		default:
+			b.WriteString(accessibleInline(n, source))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// createPlainLabel creates the label of a message shown as plain text.
+func (mb *MessageBubble) createPlainLabel(content string) *gtk.Label {
+	label := gtk.NewLabel(mdRenderer.ToAccessibleText(content))
+	label.SetWrap(true)
+	label.SetWrapMode(pango.WrapWordChar)
+	label.SetXAlign(0)
+	label.SetSelectable(true)
+	if mb.role == store.RoleSystem {
+		label.AddCSSClass("dim-label")
+	}
+	return label
+}
+
+// applyMessageRendering renders the messages again when plain text
+// messages were turned on or off since they were shown.
+func (cv *ChatView) applyMessageRendering() {
+	if cv.plainTextRendered == plainTextMessages {
+		return
+	}
+	cv.plainTextRendered = plainTextMessages
+	for _, bubble := range cv.messages {
+		if bubble != cv.currentBubble && bubble.GetContent() != "" {
+			bubble.renderContent()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ui
+
+import "testing"
+
+func TestToAccessibleText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "plain text",
+			markdown: "Hello **world**, this is *it*.",
+			want:     "Hello world, this is it.",
+		},
+		{
+			name:     "heading",
+			markdown: "## Setup\n\nInstall it.",
+			want:     "Heading level 2: Setup\n\nInstall it.",
+		},
+		{
+			name:     "code block",
+			markdown: "Run:\n\n```go\nfmt.Println(\"hi\")\n```",
+			want:     "Run:\n\nCode block, go:\nfmt.Println(\"hi\")\nEnd of code block.",
+		},
+		{
+			name:     "code block without language",
+			markdown: "```\nls\n```",
+			want:     "Code block:\nls\nEnd of code block.",
+		},
+		{
+			name:     "bullet list",
+			markdown: "- One\n- Two",
+			want:     "List of 2 items:\n• One\n• Two\nEnd of list.",
+		},
+		{
+			name:     "numbered list",
+			markdown: "3. Three\n4. Four",
+			want:     "Numbered list of 2 items:\n3. Three\n4. Four\nEnd of list.",
+		},
+		{
+			name:     "single item",
+			markdown: "- Only",
+			want:     "List of 1 item:\n• Only\nEnd of list.",
+		},
+		{
+			name:     "quote",
+			markdown: "> Be kind.",
+			want:     "Quote:\nBe kind.\nEnd of quote.",
+		},
+		{
+			name:     "table",
+			markdown: "| Name | Age |\n|------|-----|\n| Ana | 30 |\n| Luis | 25 |",
+			want:     "Table with 2 columns and 2 rows:\nRow 1: Name: Ana; Age: 30\nRow 2: Name: Luis; Age: 25\nEnd of table.",
+		},
+		{
+			name:     "link",
+			markdown: "See [the docs](https://example.com) or <https://go.dev>.",
+			want:     "See the docs (https://example.com) or https://go.dev.",
+		},
+		{
+			name:     "inline code",
+			markdown: "Call `main()` first.",
+			want:     "Call main() first.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mdRenderer.ToAccessibleText(tt.markdown); got != tt.want {
+				t.Errorf("ToAccessibleText() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/plugins"
+	"github.com/storo/guanaco/internal/rag"
+	"github.com/storo/guanaco/internal/tools"
+)
+
+// pluginPermissionTitle returns the description of a plugin permission
+// shown to the user.
+func pluginPermissionTitle(permission string) string {
+	switch permission {
+	case plugins.PermissionFiles:
+		return i18n.T("Reads the files it is given")
+	case plugins.PermissionNetwork:
+		return i18n.T("Connects to other computers")
+	case plugins.PermissionCommands:
+		return i18n.T("Runs other programs")
+	default:
+		return permission
+	}
+}
+
+// enabledPlugins returns the plugins of found whose name is in names.
+func enabledPlugins(found []*plugins.Plugin, names []string) []*plugins.Plugin {
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[name] = true
+	}
+	var result []*plugins.Plugin
+	for _, p := range found {
+		if enabled[p.Manifest.Name] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// newToolRegistry returns the tools offered to models: the built-in ones
+// when builtin is set, and those of the enabled plugins. Plugin tools
+// don't replace tools with the same name.
+func newToolRegistry(builtin bool, enabled []*plugins.Plugin) *tools.Registry {
+	registry := tools.NewRegistry()
+	if builtin {
+		registry = tools.Builtin()
+	}
+	names := make(map[string]bool)
+	for _, name := range registry.Names() {
+		names[name] = true
+	}
+	for _, p := range enabled {
+		for _, tool := range p.Tools() {
+			if names[tool.Name] {
+				logger.Warn("Plugin tool ignored, the name is taken", "plugin", p.Manifest.Name, "tool", tool.Name)
+				continue
+			}
+			names[tool.Name] = true
+			registry.Register(tool)
+		}
+	}
+	return registry
+}
+
+// newPluginProcessor returns a document processor that also uses the
+// readers of the enabled plugins, after the built-in ones.
+func newPluginProcessor(enabled []*plugins.Plugin) *rag.Processor {
+	processor := rag.NewProcessor()
+	for _, p := range enabled {
+		if reader := p.Reader(); reader != nil {
+			processor.AddReader(reader)
+		}
+	}
+	return processor
+}
+
+// pluginExtensions returns the file extensions read by the enabled plugins.
+func pluginExtensions(enabled []*plugins.Plugin) []string {
+	var extensions []string
+	for _, p := range enabled {
+		extensions = append(extensions, p.Extensions()...)
+	}
+	return extensions
+}
+
+// loadPlugins reads the plugins folder and gives the enabled plugins to the
+// chat view and the documents panel.
+func (w *MainWindow) loadPlugins() {
+	found, errs := plugins.Load(config.GetPluginsDir())
+	for _, err := range errs {
+		logger.Error("Failed to load plugin", "error", err)
+	}
+
+	enabled := enabledPlugins(found, w.appConfig.EnabledPlugins)
+	for _, p := range enabled {
+		logger.Info("Plugin enabled", "name", p.Manifest.Name, "extensions", p.Extensions(), "tools", len(p.Manifest.Tools))
+	}
+	w.chatView.SetPlugins(enabled)
+	w.documents.SetPlugins(enabled)
+}
+
+// SetPlugins sets the plugins whose readers and tools are used.
+func (cv *ChatView) SetPlugins(enabled []*plugins.Plugin) {
+	cv.plugins = enabled
+	cv.ragProcessor = newPluginProcessor(enabled)
+	if cv.appConfig != nil {
+		cv.ragProcessor.SetNotebookOutputs(cv.appConfig.NotebookOutputs)
+	}
+	cv.updateTools()
+}
+
+// updateTools rebuilds the tools offered to models from the settings and
+// the enabled plugins.
+func (cv *ChatView) updateTools() {
+	builtin := cv.appConfig != nil && cv.appConfig.BuiltinTools
+	cv.tools = newToolRegistry(builtin, cv.plugins)
+}
+
+// SetPlugins sets the plugins whose readers are used.
+func (p *DocumentsPanel) SetPlugins(enabled []*plugins.Plugin) {
+	p.plugins = enabled
+	p.ragProcessor = newPluginProcessor(enabled)
+}
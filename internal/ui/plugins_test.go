@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/storo/guanaco/internal/plugins"
+)
+
+func TestEnabledPlugins(t *testing.T) {
+	found := []*plugins.Plugin{
+		{Manifest: plugins.Manifest{Name: "counter"}},
+		{Manifest: plugins.Manifest{Name: "notebooks"}},
+	}
+
+	got := enabledPlugins(found, []string{"notebooks", "uninstalled"})
+	if len(got) != 1 || got[0].Manifest.Name != "notebooks" {
+		t.Errorf("enabledPlugins() = %v, want notebooks", got)
+	}
+}
+
+func TestNewToolRegistry(t *testing.T) {
+	counter := &plugins.Plugin{Manifest: plugins.Manifest{
+		Name:  "counter",
+		Tools: []plugins.ToolSpec{{Name: "word_count"}, {Name: "calculate"}},
+	}}
+
+	tests := []struct {
+		name    string
+		builtin bool
+		want    []string
+	}{
+		{name: "plugins only", builtin: false, want: []string{"calculate", "word_count"}},
+		{name: "built-in tools win", builtin: true, want: []string{"calculate", "convert_units", "current_time", "word_count"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newToolRegistry(tt.builtin, []*plugins.Plugin{counter}).Names(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newToolRegistry() names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
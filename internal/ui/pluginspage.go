@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/plugins"
+)
+
+// createPluginsPage creates the page listing the installed plugins, what
+// they add and the permissions they ask for, with a switch to enable each.
+func (d *SettingsDialog) createPluginsPage() *gtk.ScrolledWindow {
+	content := gtk.NewBox(gtk.OrientationVertical, 16)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	pluginsLabel := gtk.NewLabel(i18n.T("Plugins:"))
+	pluginsLabel.SetXAlign(0)
+	pluginsLabel.AddCSSClass("heading")
+	content.Append(pluginsLabel)
+
+	pluginsHint := gtk.NewLabel(fmt.Sprintf(i18n.T("Plugins add document readers and tools. Install a plugin by copying its folder to %s, then reopen Settings. Only enable plugins you trust: they run on this computer with your permissions."), config.GetPluginsDir()))
+	pluginsHint.SetXAlign(0)
+	pluginsHint.SetWrap(true)
+	pluginsHint.SetSelectable(true)
+	pluginsHint.AddCSSClass("dim-label")
+	pluginsHint.AddCSSClass("caption")
+	content.Append(pluginsHint)
+
+	found, errs := plugins.Load(config.GetPluginsDir())
+	enabled := make(map[string]bool, len(d.config.EnabledPlugins))
+	for _, name := range d.config.EnabledPlugins {
+		enabled[name] = true
+	}
+
+	d.pluginSwitches = make(map[string]*gtk.Switch, len(found))
+	if len(found) > 0 {
+		list := gtk.NewListBox()
+		list.SetSelectionMode(gtk.SelectionNone)
+		list.AddCSSClass("boxed-list")
+		for _, p := range found {
+			list.Append(d.createPluginRow(p, enabled[p.Manifest.Name]))
+		}
+		content.Append(list)
+	} else {
+		emptyLabel := gtk.NewLabel(i18n.T("No plugins installed"))
+		emptyLabel.SetXAlign(0)
+		emptyLabel.AddCSSClass("dim-label")
+		content.Append(emptyLabel)
+	}
+
+	// Plugins that couldn't be loaded, so their authors can fix them
+	for _, err := range errs {
+		errLabel := gtk.NewLabel(err.Error())
+		errLabel.SetXAlign(0)
+		errLabel.SetWrap(true)
+		errLabel.AddCSSClass("error")
+		errLabel.AddCSSClass("caption")
+		content.Append(errLabel)
+	}
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(content)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	return scrolled
+}
+
+// createPluginRow creates the row of a plugin in the plugins page.
+func (d *SettingsDialog) createPluginRow(p *plugins.Plugin, enabled bool) *gtk.ListBoxRow {
+	box := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	box.SetMarginTop(8)
+	box.SetMarginBottom(8)
+	box.SetMarginStart(12)
+	box.SetMarginEnd(12)
+
+	text := gtk.NewBox(gtk.OrientationVertical, 2)
+	text.SetHExpand(true)
+
+	title := p.Manifest.Name
+	if p.Manifest.Version != "" {
+		title += " " + p.Manifest.Version
+	}
+	nameLabel := gtk.NewLabel(title)
+	nameLabel.SetXAlign(0)
+	nameLabel.AddCSSClass("heading")
+	text.Append(nameLabel)
+
+	var details []string
+	if p.Manifest.Description != "" {
+		details = append(details, p.Manifest.Description)
+	}
+	if extensions := p.Extensions(); len(extensions) > 0 {
+		details = append(details, fmt.Sprintf(i18n.T("Reads: %s"), strings.Join(extensions, ", ")))
+	}
+	if len(p.Manifest.Tools) > 0 {
+		names := make([]string, len(p.Manifest.Tools))
+		for i, t := range p.Manifest.Tools {
+			names[i] = t.Name
+		}
+		details = append(details, fmt.Sprintf(i18n.T("Tools: %s"), strings.Join(names, ", ")))
+	}
+	permissions := make([]string, len(p.Manifest.Permissions))
+	for i, permission := range p.Manifest.Permissions {
+		permissions[i] = pluginPermissionTitle(permission)
+	}
+	if len(permissions) == 0 {
+		permissions = append(permissions, i18n.T("None declared"))
+	}
+	details = append(details, fmt.Sprintf(i18n.T("Permissions: %s"), strings.Join(permissions, ", ")))
+
+	detailsLabel := gtk.NewLabel(strings.Join(details, "\n"))
+	detailsLabel.SetXAlign(0)
+	detailsLabel.SetWrap(true)
+	detailsLabel.AddCSSClass("dim-label")
+	detailsLabel.AddCSSClass("caption")
+	text.Append(detailsLabel)
+	box.Append(text)
+
+	sw := gtk.NewSwitch()
+	sw.SetActive(enabled)
+	sw.SetVAlign(gtk.AlignCenter)
+	box.Append(sw)
+	d.pluginSwitches[p.Manifest.Name] = sw
+
+	row := gtk.NewListBoxRow()
+	row.SetActivatable(false)
+	row.SetChild(box)
+	return row
+}
+
+// enabledPluginNames returns the names of the plugins switched on. Plugins
+// enabled earlier that are no longer installed stay enabled, so they work
+// again when reinstalled.
+func (d *SettingsDialog) enabledPluginNames() []string {
+	var names []string
+	for _, name := range d.config.EnabledPlugins {
+		if _, listed := d.pluginSwitches[name]; !listed {
+			names = append(names, name)
+		}
+	}
+	for name, sw := range d.pluginSwitches {
+		if sw.Active() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
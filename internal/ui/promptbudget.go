@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+// historyMode controls how the conversation history is sent to the model.
+type historyMode int
+
+const (
+	// historyFull sends the complete history.
+	historyFull historyMode = iota
+	// historyTrimmed drops the oldest turns until the prompt fits the budget.
+	historyTrimmed
+	// historySummarized condenses older turns into a single system message.
+	historySummarized
+)
+
+// summaryKeepRecent is the number of recent messages kept verbatim when summarizing.
+const summaryKeepRecent = 4
+
+// promptSize is an estimated token breakdown of an outgoing prompt.
+type promptSize struct {
+	History     int
+	Attachments int
+	Message     int
+}
+
+// Total returns the total estimated token count.
+func (s promptSize) Total() int {
+	return s.History + s.Attachments + s.Message
+}
+
+// estimateMessagesTokens returns the estimated token count of a message list.
+func estimateMessagesTokens(messages []ollama.Message) int {
+	total := 0
+	for _, m := range messages {
+		total += rag.EstimateTokens(m.Content)
+	}
+	return total
+}
+
+// splitHistory separates leading system messages, older turns and the
+// most recent keepRecent turns.
+func splitHistory(messages []ollama.Message, keepRecent int) (prefix, older, recent []ollama.Message) {
+	i := 0
+	for i < len(messages) && messages[i].Role == "system" {
+		i++
+	}
+	prefix = messages[:i]
+	rest := messages[i:]
+
+	if keepRecent < 0 {
+		keepRecent = 0
+	}
+	if len(rest) <= keepRecent {
+		return prefix, nil, rest
+	}
+	return prefix, rest[:len(rest)-keepRecent], rest[len(rest)-keepRecent:]
+}
+
+// trimHistory drops the oldest non-system messages until the history fits
+// within budget tokens. Leading system messages are always kept.
+// Returns the trimmed history and the number of dropped messages.
+func trimHistory(messages []ollama.Message, budget int) ([]ollama.Message, int) {
+	prefix, _, rest := splitHistory(messages, len(messages))
+
+	used := estimateMessagesTokens(prefix)
+	keep := 0
+	for i := len(rest) - 1; i >= 0; i-- {
+		tokens := rag.EstimateTokens(rest[i].Content)
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		keep++
+	}
+
+	trimmed := make([]ollama.Message, 0, len(prefix)+keep)
+	trimmed = append(trimmed, prefix...)
+	trimmed = append(trimmed, rest[len(rest)-keep:]...)
+	return trimmed, len(rest) - keep
+}
+
+// contextRetryNotice tells that a request is retried without the dropped
+// oldest messages of the history. The singular doesn't show the count.
+func contextRetryNotice(dropped int) string {
+	notice := i18n.N("The conversation exceeded the model's context. Retrying without the oldest message.",
+		"The conversation exceeded the model's context. Retrying without the %d oldest messages.", uint(dropped))
+	if dropped == 1 {
+		return notice
+	}
+	return fmt.Sprintf(notice, dropped)
+}
+
+// formatTranscript renders messages as a plain-text transcript for summarization.
+func formatTranscript(messages []ollama.Message) string {
+	var builder strings.Builder
+	for _, m := range messages {
+		builder.WriteString(m.Role)
+		builder.WriteString(": ")
+		builder.WriteString(m.Content)
+		builder.WriteString("\n\n")
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// summarizeHistory asks the model to condense older turns into a single
+// system message, keeping the most recent keepRecent messages verbatim.
+func (cv *ChatView) summarizeHistory(ctx context.Context, model string, messages []ollama.Message, keepRecent int) ([]ollama.Message, error) {
+	prefix, older, recent := splitHistory(messages, keepRecent)
+	if len(older) == 0 {
+		return messages, nil
+	}
+
+	prompt := historySummaryPrompt("", older)
+
+	var summary strings.Builder
+	err := cv.streamHandler.Chat(ctx, &ollama.ChatRequest{
+		Model:    model,
+		Messages: []ollama.Message{{Role: "user", Content: prompt}},
+	}, func(token string) {
+		summary.WriteString(token)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize history: %w", err)
+	}
+
+	result := make([]ollama.Message, 0, len(prefix)+1+len(recent))
+	result = append(result, prefix...)
+	result = append(result, summaryMessage(strings.TrimSpace(summary.String())))
+	result = append(result, recent...)
+	return result, nil
+}
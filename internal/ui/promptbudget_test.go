@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+func TestSplitHistory(t *testing.T) {
+	messages := []ollama.Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "u1"},
+		{Role: "assistant", Content: "a1"},
+		{Role: "user", Content: "u2"},
+		{Role: "assistant", Content: "a2"},
+	}
+
+	prefix, older, recent := splitHistory(messages, 2)
+	if len(prefix) != 1 || prefix[0].Content != "sys" {
+		t.Errorf("prefix = %v, want system message only", prefix)
+	}
+	if len(older) != 2 || older[0].Content != "u1" {
+		t.Errorf("older = %v, want u1 and a1", older)
+	}
+	if len(recent) != 2 || recent[1].Content != "a2" {
+		t.Errorf("recent = %v, want u2 and a2", recent)
+	}
+
+	_, older, recent = splitHistory(messages, 10)
+	if len(older) != 0 || len(recent) != 4 {
+		t.Errorf("keepRecent larger than history: older = %d, recent = %d", len(older), len(recent))
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	long := strings.Repeat("word ", 40) // ~40 tokens
+	messages := []ollama.Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: "short"},
+	}
+
+	t.Run("keeps everything within budget", func(t *testing.T) {
+		trimmed, dropped := trimHistory(messages, 1000)
+		if dropped != 0 || len(trimmed) != len(messages) {
+			t.Errorf("dropped = %d, len = %d; want nothing dropped", dropped, len(trimmed))
+		}
+	})
+
+	t.Run("drops oldest messages first", func(t *testing.T) {
+		trimmed, dropped := trimHistory(messages, 30)
+		if dropped != 2 {
+			t.Errorf("dropped = %d, want 2", dropped)
+		}
+		if trimmed[0].Role != "system" {
+			t.Error("system message should always be kept")
+		}
+		if trimmed[len(trimmed)-1].Content != "short" {
+			t.Error("most recent message should be kept")
+		}
+	})
+
+	t.Run("zero budget keeps system only", func(t *testing.T) {
+		trimmed, _ := trimHistory(messages, 0)
+		if len(trimmed) != 1 || trimmed[0].Role != "system" {
+			t.Errorf("trimmed = %v, want only system message", trimmed)
+		}
+	})
+}
+
+func TestPromptSizeTotal(t *testing.T) {
+	size := promptSize{History: 10, Attachments: 20, Message: 5}
+	if size.Total() != 35 {
+		t.Errorf("Total() = %d, want 35", size.Total())
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	if got := contextBudget(8192); got != 6144 {
+		t.Errorf("contextBudget(8192) = %d, want 6144", got)
+	}
+	if got := contextBudget(0); got != 0 {
+		t.Errorf("contextBudget(0) = %d, want 0", got)
+	}
+}
+
+func TestAttachmentBudget(t *testing.T) {
+	size := promptSize{History: 1000, Attachments: 9000, Message: 200}
+	if got := attachmentBudget(size, 6000); got != 4800 {
+		t.Errorf("attachmentBudget() = %d, want 4800", got)
+	}
+	if got := attachmentBudget(size, 1000); got != 0 {
+		t.Errorf("attachmentBudget() with history over budget = %d, want 0", got)
+	}
+}
+
+func TestSelectAttachmentPassages(t *testing.T) {
+	filler := strings.Repeat("Nothing to see in this paragraph about the weather. ", 40)
+	report := filler + "\n\nThe quarterly revenue grew by twelve percent.\n\n" + filler
+	sources := []rag.Source{{Name: "report.txt", Content: report}}
+
+	got := selectAttachmentPassages("How much did revenue grow?", sources, 600)
+	if !strings.Contains(got, "[Document: report.txt, excerpt]") {
+		t.Errorf("selectAttachmentPassages() = %q, want the document named", got)
+	}
+	if !strings.Contains(got, "revenue grew") {
+		t.Errorf("selectAttachmentPassages() = %q, want the passage about revenue", got)
+	}
+	if rag.EstimateTokens(got) > 700 {
+		t.Errorf("selectAttachmentPassages() = %d tokens, want about the budget of 600", rag.EstimateTokens(got))
+	}
+}
+
+func TestContextRetryNotice(t *testing.T) {
+	i18n.SetLanguage("en")
+
+	tests := map[int]string{
+		1: "The conversation exceeded the model's context. Retrying without the oldest message.",
+		2: "The conversation exceeded the model's context. Retrying without the 2 oldest messages.",
+	}
+	for dropped, want := range tests {
+		if got := contextRetryNotice(dropped); got != want {
+			t.Errorf("contextRetryNotice(%d) = %q, want %q", dropped, got, want)
+		}
+	}
+
+	i18n.SetLanguage("es")
+	defer i18n.SetLanguage("en")
+	if got := contextRetryNotice(1); strings.Contains(got, "%!") {
+		t.Errorf("contextRetryNotice(1) = %q in Spanish", got)
+	}
+	if got := contextRetryNotice(3); !strings.Contains(got, "3") || strings.Contains(got, "%!") {
+		t.Errorf("contextRetryNotice(3) = %q in Spanish", got)
+	}
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/calendar"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/prompts"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// calendarPlaceholder is the name of the calendar variable, which is filled
+// in when the message is sent rather than when a template is used.
+var calendarPlaceholder = strings.TrimSuffix(strings.TrimPrefix(calendar.Variable, "{{"), "}}")
+
+// usePromptTemplate puts the content of tmpl in the input, asking first for
+// the values of its placeholders.
+func (cv *ChatView) usePromptTemplate(tmpl *store.PromptTemplate) {
+	names := prompts.Placeholders(tmpl.Content, calendarPlaceholder)
+	if len(names) == 0 {
+		cv.inputArea.SetText(tmpl.Content)
+		cv.inputArea.Focus()
+		return
+	}
+
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+	rows := make([]*adw.EntryRow, len(names))
+	for i, name := range names {
+		rows[i] = adw.NewEntryRow()
+		rows[i].SetTitle(prompts.Label(name))
+		list.Append(rows[i])
+	}
+
+	dialog := adw.NewMessageDialog(cv.parentWindow(), tmpl.Name, i18n.T("Fill in the template"))
+	dialog.SetExtraChild(list)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("insert", i18n.T("Insert"))
+	dialog.SetResponseAppearance("insert", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("insert")
+	dialog.SetCloseResponse("cancel")
+
+	// Enter moves to the next field, and inserts from the last one
+	for i, row := range rows {
+		i := i
+		row.ConnectEntryActivated(func() {
+			if i+1 < len(rows) {
+				rows[i+1].GrabFocus()
+			} else {
+				dialog.Response("insert")
+			}
+		})
+	}
+
+	dialog.ConnectResponse(func(response string) {
+		if response != "insert" {
+			cv.inputArea.Focus()
+			return
+		}
+		values := make(map[string]string, len(names))
+		for i, name := range names {
+			values[name] = rows[i].Text()
+		}
+		cv.inputArea.SetText(prompts.Fill(tmpl.Content, values))
+		cv.inputArea.Focus()
+	})
+
+	dialog.Present()
+	rows[0].GrabFocus()
+}
+
+// loadPromptTemplates gives the saved templates to the input's picker.
+func (w *MainWindow) loadPromptTemplates() {
+	if w.db == nil {
+		return
+	}
+	templates, err := w.db.ListPromptTemplates()
+	if err != nil {
+		logger.Error("Failed to load prompt templates", "error", err)
+		return
+	}
+	w.chatView.GetInputArea().SetPromptTemplates(templates)
+}
+
+// onManageTemplates opens the templates library.
+func (w *MainWindow) onManageTemplates() {
+	if w.db == nil {
+		return
+	}
+	dialog := NewPromptTemplatesDialog(&w.ApplicationWindow.Window, w.db)
+	dialog.OnChanged(w.loadPromptTemplates)
+	dialog.Present()
+}
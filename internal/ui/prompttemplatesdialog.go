@@ -0,0 +1,316 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// PromptTemplatesDialog lists the saved prompt templates and edits them.
+type PromptTemplatesDialog struct {
+	*adw.Window
+
+	// UI components
+	stack       *gtk.Stack
+	list        *gtk.ListBox
+	addBtn      *gtk.Button
+	nameEntry   *gtk.Entry
+	contentView *gtk.TextView
+
+	// State
+	db        *store.DB
+	templates []*store.PromptTemplate
+	editing   *store.PromptTemplate // Nil while adding a new template
+
+	// Callbacks
+	onChanged func()
+}
+
+// NewPromptTemplatesDialog creates a dialog managing the templates in db.
+func NewPromptTemplatesDialog(parent *gtk.Window, db *store.DB) *PromptTemplatesDialog {
+	d := &PromptTemplatesDialog{db: db}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Prompt Templates"))
+	d.SetModal(true)
+	d.SetDefaultSize(480, 560)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+	d.reload()
+
+	return d
+}
+
+func (d *PromptTemplatesDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(gtk.NewLabel(i18n.T("Prompt Templates")))
+
+	d.addBtn = gtk.NewButton()
+	d.addBtn.SetIconName("list-add-symbolic")
+	d.addBtn.SetTooltipText(i18n.T("New Template"))
+	d.addBtn.ConnectClicked(func() {
+		d.edit(nil)
+	})
+	headerBar.PackStart(d.addBtn)
+
+	// Empty state
+	empty := adw.NewStatusPage()
+	empty.SetIconName("document-edit-symbolic")
+	empty.SetTitle(i18n.T("No Templates"))
+	empty.SetDescription(i18n.T("Save prompts you use often, then type / in the message box to insert them"))
+	newBtn := gtk.NewButtonWithLabel(i18n.T("New Template"))
+	newBtn.SetHAlign(gtk.AlignCenter)
+	newBtn.AddCSSClass("pill")
+	newBtn.AddCSSClass("suggested-action")
+	newBtn.ConnectClicked(func() {
+		d.edit(nil)
+	})
+	empty.SetChild(newBtn)
+
+	// Template list
+	d.list = gtk.NewListBox()
+	d.list.SetSelectionMode(gtk.SelectionNone)
+	d.list.AddCSSClass("boxed-list")
+	d.list.SetVAlign(gtk.AlignStart)
+	d.list.SetMarginTop(16)
+	d.list.SetMarginBottom(24)
+	d.list.SetMarginStart(24)
+	d.list.SetMarginEnd(24)
+
+	listScrolled := gtk.NewScrolledWindow()
+	listScrolled.SetChild(d.list)
+	listScrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	listScrolled.SetVExpand(true)
+
+	d.stack = gtk.NewStack()
+	d.stack.SetTransitionType(gtk.StackTransitionTypeCrossfade)
+	d.stack.AddNamed(empty, "empty")
+	d.stack.AddNamed(listScrolled, "list")
+	d.stack.AddNamed(d.createEditor(), "editor")
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(d.stack)
+
+	d.SetContent(toolbarView)
+}
+
+// createEditor creates the page editing a template's name and content.
+func (d *PromptTemplatesDialog) createEditor() *gtk.Box {
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	nameLabel := gtk.NewLabel(i18n.T("Name"))
+	nameLabel.SetXAlign(0)
+	nameLabel.AddCSSClass("heading")
+	content.Append(nameLabel)
+
+	d.nameEntry = gtk.NewEntry()
+	d.nameEntry.SetPlaceholderText(i18n.T("Translate"))
+	d.nameEntry.ConnectChanged(func() {
+		d.nameEntry.RemoveCSSClass("error")
+	})
+	content.Append(d.nameEntry)
+
+	promptLabel := gtk.NewLabel(i18n.T("Prompt"))
+	promptLabel.SetXAlign(0)
+	promptLabel.SetMarginTop(8)
+	promptLabel.AddCSSClass("heading")
+	content.Append(promptLabel)
+
+	d.contentView = gtk.NewTextView()
+	d.contentView.SetWrapMode(gtk.WrapWordChar)
+	d.contentView.SetTopMargin(8)
+	d.contentView.SetBottomMargin(8)
+	d.contentView.SetLeftMargin(8)
+	d.contentView.SetRightMargin(8)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(d.contentView)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetMinContentHeight(160)
+	scrolled.SetVExpand(true)
+	scrolled.AddCSSClass("card")
+	content.Append(scrolled)
+
+	hint := gtk.NewLabel(i18n.T("Write {{name}} for text to fill in when the template is used, such as {{text}} or {{language}}."))
+	hint.SetWrap(true)
+	hint.SetXAlign(0)
+	hint.AddCSSClass("dim-label")
+	hint.AddCSSClass("caption")
+	content.Append(hint)
+
+	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttonBox.SetHAlign(gtk.AlignEnd)
+	buttonBox.SetMarginTop(8)
+
+	cancelBtn := gtk.NewButtonWithLabel(i18n.T("Cancel"))
+	cancelBtn.ConnectClicked(d.showList)
+	buttonBox.Append(cancelBtn)
+
+	saveBtn := gtk.NewButtonWithLabel(i18n.T("Save"))
+	saveBtn.AddCSSClass("suggested-action")
+	saveBtn.ConnectClicked(d.save)
+	buttonBox.Append(saveBtn)
+
+	content.Append(buttonBox)
+	return content
+}
+
+// reload reads the templates and shows the list.
+func (d *PromptTemplatesDialog) reload() {
+	templates, err := d.db.ListPromptTemplates()
+	if err != nil {
+		logger.Error("Failed to load prompt templates", "error", err)
+	}
+	d.templates = templates
+
+	d.list.RemoveAll()
+	for _, tmpl := range d.templates {
+		d.list.Append(d.createRow(tmpl))
+	}
+	d.showList()
+}
+
+// createRow creates the list row of tmpl, with buttons to edit and delete it.
+func (d *PromptTemplatesDialog) createRow(tmpl *store.PromptTemplate) *adw.ActionRow {
+	row := adw.NewActionRow()
+	row.SetTitle(tmpl.Name)
+	row.SetSubtitle(truncatePreview(tmpl.Content, 80))
+	row.SetTitleLines(1)
+	row.SetSubtitleLines(1)
+	row.SetActivatable(true)
+	row.ConnectActivated(func() {
+		d.edit(tmpl)
+	})
+
+	deleteBtn := gtk.NewButton()
+	deleteBtn.SetIconName("user-trash-symbolic")
+	deleteBtn.SetTooltipText(i18n.T("Delete template"))
+	deleteBtn.SetVAlign(gtk.AlignCenter)
+	deleteBtn.AddCSSClass("flat")
+	deleteBtn.ConnectClicked(func() {
+		d.confirmDelete(tmpl)
+	})
+	row.AddSuffix(deleteBtn)
+
+	return row
+}
+
+// showList shows the list of templates, or the empty state.
+func (d *PromptTemplatesDialog) showList() {
+	d.editing = nil
+	d.addBtn.SetVisible(true)
+	if len(d.templates) == 0 {
+		d.stack.SetVisibleChildName("empty")
+	} else {
+		d.stack.SetVisibleChildName("list")
+	}
+}
+
+// edit shows the editor for tmpl, or for a new template when it is nil.
+func (d *PromptTemplatesDialog) edit(tmpl *store.PromptTemplate) {
+	d.editing = tmpl
+	if tmpl != nil {
+		d.nameEntry.SetText(tmpl.Name)
+		d.contentView.Buffer().SetText(tmpl.Content)
+	} else {
+		d.nameEntry.SetText("")
+		d.contentView.Buffer().SetText("")
+	}
+	d.addBtn.SetVisible(false)
+	d.stack.SetVisibleChildName("editor")
+	d.nameEntry.GrabFocus()
+}
+
+// save stores the template being edited and goes back to the list.
+func (d *PromptTemplatesDialog) save() {
+	name := strings.TrimSpace(d.nameEntry.Text())
+	if name == "" {
+		d.nameEntry.AddCSSClass("error")
+		d.nameEntry.GrabFocus()
+		return
+	}
+	buffer := d.contentView.Buffer()
+	content := buffer.Text(buffer.StartIter(), buffer.EndIter(), false)
+	if strings.TrimSpace(content) == "" {
+		d.contentView.GrabFocus()
+		return
+	}
+
+	var err error
+	if d.editing != nil {
+		err = d.db.UpdatePromptTemplate(d.editing.ID, name, content)
+	} else {
+		_, err = d.db.AddPromptTemplate(name, content)
+	}
+	if err != nil {
+		logger.Error("Failed to save prompt template", "name", name, "error", err)
+		d.showError(err)
+		return
+	}
+	logger.Info("Prompt template saved", "name", name)
+
+	d.reload()
+	d.changed()
+}
+
+// confirmDelete asks before deleting tmpl.
+func (d *PromptTemplatesDialog) confirmDelete(tmpl *store.PromptTemplate) {
+	dialog := adw.NewMessageDialog(&d.Window.Window, i18n.T("Delete Template?"),
+		fmt.Sprintf(i18n.T("“%s” will be deleted permanently."), tmpl.Name))
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("delete", i18n.T("Delete"))
+	dialog.SetResponseAppearance("delete", adw.ResponseDestructive)
+	dialog.SetDefaultResponse("cancel")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		if response != "delete" {
+			return
+		}
+		if err := d.db.DeletePromptTemplate(tmpl.ID); err != nil {
+			logger.Error("Failed to delete prompt template", "id", tmpl.ID, "error", err)
+			d.showError(err)
+			return
+		}
+		d.reload()
+		d.changed()
+	})
+
+	dialog.Present()
+}
+
+// showError reports a failure to save or delete a template.
+func (d *PromptTemplatesDialog) showError(err error) {
+	dialog := adw.NewMessageDialog(&d.Window.Window, i18n.T("Could Not Update Templates"), err.Error())
+	dialog.AddResponse("ok", i18n.T("OK"))
+	dialog.Present()
+}
+
+// changed notifies that the templates have changed.
+func (d *PromptTemplatesDialog) changed() {
+	if d.onChanged != nil {
+		d.onChanged()
+	}
+}
+
+// OnChanged sets the callback for when templates are added, edited or
+// deleted.
+func (d *PromptTemplatesDialog) OnChanged(callback func()) {
+	d.onChanged = callback
+}
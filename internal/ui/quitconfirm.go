@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// Closing a window while a response is written or a model downloads asks
+// what to do: stop them and close, or keep running in the background. In
+// the background the window is hidden and the app keeps running until the
+// work is done, then a notification says so and the window closes. There is
+// no tray icon: starting Guanaco again shows the hidden window.
+
+// backgroundPoll is how often a window in the background checks whether its
+// work is done, in milliseconds.
+const backgroundPoll = 500
+
+// downloadingModel returns the name of the model the window is
+// downloading, or an empty string.
+func (w *MainWindow) downloadingModel() string {
+	if w.modelDialog == nil {
+		return ""
+	}
+	return w.modelDialog.Downloading()
+}
+
+// closeWarning describes what closing the window would interrupt.
+func closeWarning(streaming bool, download string) string {
+	switch {
+	case streaming && download != "":
+		return fmt.Sprintf(i18n.T("A response is still being written and %s is still downloading. Closing stops both; the response is saved as far as it got."), download)
+	case download != "":
+		return fmt.Sprintf(i18n.T("%s is still downloading. Closing cancels the download."), download)
+	default:
+		return i18n.T("A response is still being written. Closing stops it; the response is saved as far as it got.")
+	}
+}
+
+// confirmClose asks whether to stop the running work and close the window,
+// or keep it running in the background.
+func (w *MainWindow) confirmClose() {
+	dialog := adw.NewMessageDialog(&w.ApplicationWindow.Window, i18n.T("Stop and Close?"),
+		closeWarning(w.chatView.IsStreaming(), w.downloadingModel()))
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("background", i18n.T("Keep Running in Background"))
+	dialog.AddResponse("close", i18n.T("Stop and Close"))
+	dialog.SetResponseAppearance("close", adw.ResponseDestructive)
+	dialog.SetDefaultResponse("cancel")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		switch response {
+		case "cancel":
+			w.app.quitting = false
+		case "close":
+			w.stopAndClose()
+		case "background":
+			w.runInBackground()
+		}
+	})
+
+	dialog.Present()
+}
+
+// runInBackground hides the window until its response and download are
+// done, then notifies the user and closes it. Showing the window again
+// leaves the background.
+func (w *MainWindow) runInBackground() {
+	logger.Info("Running in background", "streaming", w.chatView.IsStreaming(), "download", w.downloadingModel())
+	w.inBackground = true
+	w.SetVisible(false)
+
+	// The notification counts as the one for the response, if any
+	event := config.NotifyDownload
+	if w.chatView.IsStreaming() {
+		event = config.NotifyResponse
+	}
+	if w.modelDialog != nil {
+		w.modelDialog.SetVisible(false)
+	}
+
+	glib.TimeoutAdd(backgroundPoll, func() bool {
+		if !w.inBackground {
+			return false
+		}
+		if w.chatView.IsStreaming() || w.downloadingModel() != "" {
+			return true
+		}
+
+		w.app.notify(event, "background-done", i18n.T("Finished the work left running in the background"))
+
+		w.inBackground = false
+		w.cleanup()
+		w.closeReady = true
+		w.Close()
+		return false
+	})
+}
+
+// leaveBackground shows a window that was running in the background.
+func (w *MainWindow) leaveBackground() {
+	if !w.inBackground {
+		return
+	}
+	logger.Info("Leaving background")
+	w.inBackground = false
+	w.SetVisible(true)
+	if w.modelDialog != nil && w.downloadingModel() != "" {
+		w.modelDialog.Present()
+	}
+}
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCloseWarning(t *testing.T) {
+	tests := []struct {
+		streaming bool
+		download  string
+		want      []string
+	}{
+		{true, "", []string{"response", "saved"}},
+		{false, "llama3", []string{"llama3", "cancels the download"}},
+		{true, "llama3", []string{"response", "llama3", "both"}},
+	}
+	for _, tt := range tests {
+		got := closeWarning(tt.streaming, tt.download)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("closeWarning(%v, %q) = %q, want it to mention %q", tt.streaming, tt.download, got, want)
+			}
+		}
+	}
+}
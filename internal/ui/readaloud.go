@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/speech"
+)
+
+// With "Read responses aloud" on in the settings, each completed response
+// is read aloud by a local speech synthesizer, leaving out code and
+// markup, for users who rely on spoken output. A chat can be muted with
+// the speaker button in the header bar, which also stops the reading.
+
+// readAloud reads a completed response aloud, unless the setting is off or
+// the current chat is muted.
+func (cv *ChatView) readAloud(response string) {
+	if cv.appConfig == nil || !cv.appConfig.ReadAloud {
+		return
+	}
+	if cv.currentChat != nil && cv.currentChat.Muted {
+		return
+	}
+	text := speech.SpeakableText(response)
+	if text == "" {
+		return
+	}
+	if !cv.speaker.Available() {
+		if !cv.speakerWarned {
+			cv.speakerWarned = true
+			cv.notify(i18n.T("Responses can't be read aloud: install speech-dispatcher or espeak-ng"))
+		}
+		return
+	}
+
+	go func() {
+		if err := cv.speaker.Speak(text); err != nil {
+			logger.Error("Failed to read response aloud", "error", err)
+		}
+	}()
+}
+
+// StopReading stops reading a response aloud.
+func (cv *ChatView) StopReading() {
+	cv.speaker.Stop()
+}
+
+// ReadAloudMuted reports whether the responses of the current chat are
+// kept from being read aloud.
+func (cv *ChatView) ReadAloudMuted() bool {
+	return cv.currentChat != nil && cv.currentChat.Muted
+}
+
+// SetReadAloudMuted mutes or unmutes reading aloud in the current chat.
+// Muting stops the response being read.
+func (cv *ChatView) SetReadAloudMuted(muted bool) {
+	chat := cv.currentChat
+	if chat == nil {
+		return
+	}
+	if cv.db != nil && chat.ID != 0 {
+		if err := cv.db.UpdateChatMuted(chat.ID, muted); err != nil {
+			logger.Error("Failed to save chat mute", "chatID", chat.ID, "error", err)
+			cv.handleError(err)
+			return
+		}
+	}
+	chat.Muted = muted
+	if muted {
+		cv.StopReading()
+	}
+	logger.Info("Reading aloud muted", "chatID", chat.ID, "muted", muted)
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// Reasoning models such as DeepSeek-R1 write their reasoning between
+// <think> and </think> before the answer. It is shown in a collapsed
+// expander above the answer, titled "Thinking…" while it is still being
+// written, so that the answer reads on its own. The stored message keeps
+// the tags.
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// splitReasoning separates the reasoning at the start of content from the
+// answer after it. open reports whether the reasoning is still being
+// written. Models whose template opens the tag only write the closing one.
+func splitReasoning(content string) (reasoning, answer string, open bool) {
+	if rest, ok := strings.CutPrefix(strings.TrimLeft(content, " \t\r\n"), thinkOpen); ok {
+		reasoning, answer, found := strings.Cut(rest, thinkClose)
+		return strings.TrimSpace(reasoning), strings.TrimSpace(answer), !found
+	}
+	if before, after, found := strings.Cut(content, thinkClose); found && !strings.Contains(before, thinkOpen) {
+		return strings.TrimSpace(before), strings.TrimSpace(after), false
+	}
+	return "", content, false
+}
+
+// setReasoning shows reasoning in an expander above the content, or
+// removes the expander when there is none.
+func (mb *MessageBubble) setReasoning(reasoning string, open bool) {
+	if reasoning == "" && !open {
+		if mb.reasoningExpander != nil {
+			mb.container.Remove(mb.reasoningExpander)
+			mb.reasoningExpander = nil
+			mb.reasoningLabel = nil
+		}
+		return
+	}
+
+	if mb.reasoningExpander == nil {
+		mb.reasoningLabel = gtk.NewLabel("")
+		mb.reasoningLabel.SetWrap(true)
+		mb.reasoningLabel.SetWrapMode(pango.WrapWordChar)
+		mb.reasoningLabel.SetXAlign(0)
+		mb.reasoningLabel.SetSelectable(true)
+		mb.reasoningLabel.SetMarginTop(4)
+		mb.reasoningLabel.AddCSSClass("dim-label")
+
+		mb.reasoningExpander = gtk.NewExpander("")
+		mb.reasoningExpander.SetChild(mb.reasoningLabel)
+		mb.reasoningExpander.SetMarginStart(16)
+		mb.reasoningExpander.SetMarginEnd(16)
+		mb.reasoningExpander.SetMarginTop(8)
+		mb.reasoningExpander.AddCSSClass("reasoning")
+		if prev := mb.contentBox.PrevSibling(); prev != nil {
+			mb.container.InsertChildAfter(mb.reasoningExpander, prev)
+		} else {
+			mb.container.Prepend(mb.reasoningExpander)
+		}
+	}
+
+	if open {
+		mb.reasoningExpander.SetLabel(i18n.T("Thinking…"))
+	} else {
+		mb.reasoningExpander.SetLabel(i18n.T("Reasoning"))
+	}
+	if plainTextMessages {
+		mb.reasoningLabel.SetText(mdRenderer.ToAccessibleText(reasoning))
+	} else {
+		mb.reasoningLabel.SetMarkup(mdRenderer.ToPango(reasoning))
+	}
+}
@@ -0,0 +1,31 @@
+package ui
+
+import "testing"
+
+func TestSplitReasoning(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		reasoning string
+		answer    string
+		open      bool
+	}{
+		{"no reasoning", "Hello there", "", "Hello there", false},
+		{"reasoning and answer", "<think>\nThe user greets me.\n</think>\n\nHello!", "The user greets me.", "Hello!", false},
+		{"still thinking", "<think>Let me see", "Let me see", "", true},
+		{"just opened", "<think>", "", "", true},
+		{"leading whitespace", "\n\n<think>Hmm</think>Yes", "Hmm", "Yes", false},
+		{"only the closing tag", "The template opened it.</think>\nAnswer", "The template opened it.", "Answer", false},
+		{"tags in the answer", "Use <think> and </think> tags", "", "Use <think> and </think> tags", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasoning, answer, open := splitReasoning(tt.content)
+			if reasoning != tt.reasoning || answer != tt.answer || open != tt.open {
+				t.Errorf("splitReasoning(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.content, reasoning, answer, open, tt.reasoning, tt.answer, tt.open)
+			}
+		})
+	}
+}
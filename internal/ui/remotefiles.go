@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// Attachments sent to an Ollama server on another machine leave this
+// computer, so sending them asks first, listing each file and how much of
+// it is sent. The question can be turned off in the settings.
+
+// outgoingFile is an attachment about to be sent.
+type outgoingFile struct {
+	name  string
+	chars int // Characters of text sent, for documents
+	image bool
+}
+
+// formatOutgoingFiles lists files one per line with what is sent of them,
+// followed by the total characters of text.
+func formatOutgoingFiles(files []outgoingFile) string {
+	var lines []string
+	total := 0
+	for _, f := range files {
+		if f.image {
+			lines = append(lines, fmt.Sprintf(i18n.T("• %s (image)"), f.name))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(i18n.T("• %s (%d characters)"), f.name, f.chars))
+		total += f.chars
+	}
+	if total > 0 {
+		lines = append(lines, "", fmt.Sprintf(i18n.T("%d characters of text in total"), total))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// serverHost returns the host of a server URL, for messages.
+func serverHost(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return raw
+}
+
+// needsRemoteFilesConsent reports whether the attachments are about to be
+// sent to a server on another machine, and the settings ask first.
+func (cv *ChatView) needsRemoteFilesConsent() bool {
+	if cv.appConfig == nil || !cv.appConfig.ConfirmRemoteFiles || cv.ollamaClient == nil {
+		return false
+	}
+	return cv.inputArea.HasAttachments() && !config.IsLocalURL(cv.ollamaClient.BaseURL())
+}
+
+// confirmRemoteFiles sends text, asking first when its attachments would
+// leave this computer.
+func (cv *ChatView) confirmRemoteFiles(text string) {
+	if !cv.needsRemoteFilesConsent() {
+		cv.confirmAndSend(text)
+		return
+	}
+
+	var files []outgoingFile
+	for _, pill := range cv.inputArea.GetAttachments() {
+		files = append(files, outgoingFile{
+			name:  pill.Filename(),
+			chars: utf8.RuneCountInString(pill.Content()),
+			image: pill.IsImage(),
+		})
+	}
+	host := serverHost(cv.ollamaClient.BaseURL())
+
+	body := fmt.Sprintf(i18n.T("These attachments will be sent to %s, which is not on this computer:\n\n%s\n\nYou can turn this question off in Settings."), host, formatOutgoingFiles(files))
+	dialog := adw.NewMessageDialog(cv.parentWindow(), i18n.T("Send Files to Another Machine?"), body)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("send", i18n.T("Send"))
+	dialog.SetResponseAppearance("send", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("cancel")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		if response == "send" {
+			logger.Info("Sending attachments to a remote server", "host", host, "files", len(files))
+			cv.confirmAndSend(text)
+			return
+		}
+		// Give the text back so nothing typed is lost
+		cv.inputArea.SetText(text)
+		cv.inputArea.Focus()
+	})
+
+	dialog.Present()
+}
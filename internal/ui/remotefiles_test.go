@@ -0,0 +1,33 @@
+package ui
+
+import "testing"
+
+func TestFormatOutgoingFiles(t *testing.T) {
+	got := formatOutgoingFiles([]outgoingFile{
+		{name: "report.pdf", chars: 1200},
+		{name: "photo.jpg", image: true},
+		{name: "notes.txt", chars: 300},
+	})
+	want := "• report.pdf (1200 characters)\n• photo.jpg (image)\n• notes.txt (300 characters)\n\n1500 characters of text in total"
+	if got != want {
+		t.Errorf("formatOutgoingFiles() = %q, want %q", got, want)
+	}
+
+	got = formatOutgoingFiles([]outgoingFile{{name: "photo.jpg", image: true}})
+	if got != "• photo.jpg (image)" {
+		t.Errorf("formatOutgoingFiles(image only) = %q, want no total", got)
+	}
+}
+
+func TestServerHost(t *testing.T) {
+	tests := map[string]string{
+		"https://ollama.example.com": "ollama.example.com",
+		"http://192.168.1.10:11434":  "192.168.1.10:11434",
+		"not a url":                  "not a url",
+	}
+	for raw, want := range tests {
+		if got := serverHost(raw); got != want {
+			t.Errorf("serverHost(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
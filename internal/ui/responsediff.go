@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/diff"
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Highlight colors of the changes, readable on light and dark backgrounds
+const (
+	diffInsertedColor = "rgba(46, 194, 126, 0.3)"
+	diffDeletedColor  = "rgba(224, 27, 36, 0.25)"
+)
+
+// alternativeLabel names the earlier response at index (from 0, oldest
+// first) in the list of responses to compare with.
+func alternativeLabel(index int, msg *store.Message) string {
+	return fmt.Sprintf(i18n.T("Response %d · %s"), index+1, msg.CreatedAt.Format("15:04"))
+}
+
+// diffSummary describes how many words changed between two responses.
+func diffSummary(inserted, deleted int) string {
+	if inserted == 0 && deleted == 0 {
+		return i18n.T("The responses are the same")
+	}
+	return fmt.Sprintf(i18n.T("%d words added, %d removed"), inserted, deleted)
+}
+
+// ResponseDiffDialog compares a response with the earlier responses it
+// regenerated, highlighting the words that changed either inline or side
+// by side.
+type ResponseDiffDialog struct {
+	*adw.Window
+
+	// UI components
+	alternativeDropdown *gtk.DropDown
+	summaryLabel        *gtk.Label
+	inlineBuffer        *gtk.TextBuffer
+	oldBuffer           *gtk.TextBuffer
+	newBuffer           *gtk.TextBuffer
+
+	// Data
+	alternatives []*store.Message
+	current      string
+}
+
+// NewResponseDiffDialog creates a dialog comparing current with the
+// earlier responses in alternatives, oldest first.
+func NewResponseDiffDialog(parent *gtk.Window, alternatives []*store.Message, current string) *ResponseDiffDialog {
+	d := &ResponseDiffDialog{
+		alternatives: alternatives,
+		current:      current,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Compare responses"))
+	d.SetModal(true)
+	d.SetDefaultSize(900, 600)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+	d.showAlternative(len(alternatives) - 1)
+
+	return d
+}
+
+func (d *ResponseDiffDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+
+	// The most recent earlier response is compared first
+	labels := make([]string, len(d.alternatives))
+	for i, msg := range d.alternatives {
+		labels[i] = alternativeLabel(i, msg)
+	}
+	d.alternativeDropdown = gtk.NewDropDown(gtk.NewStringList(labels), nil)
+	d.alternativeDropdown.SetTooltipText(i18n.T("Earlier response to compare with"))
+	d.alternativeDropdown.SetSelected(uint(len(labels) - 1))
+	d.alternativeDropdown.SetSensitive(len(labels) > 1)
+	d.alternativeDropdown.NotifyProperty("selected", func() {
+		d.showAlternative(int(d.alternativeDropdown.Selected()))
+	})
+	headerBar.PackStart(d.alternativeDropdown)
+
+	// === Inline ===
+	inlineView := newDiffTextView()
+	d.inlineBuffer = inlineView.Buffer()
+	addDiffTags(d.inlineBuffer)
+
+	// === Side by side ===
+	oldView := newDiffTextView()
+	d.oldBuffer = oldView.Buffer()
+	addDiffTags(d.oldBuffer)
+	newView := newDiffTextView()
+	d.newBuffer = newView.Buffer()
+	addDiffTags(d.newBuffer)
+
+	sideBySide := gtk.NewBox(gtk.OrientationHorizontal, 12)
+	sideBySide.SetHomogeneous(true)
+	sideBySide.Append(newDiffColumn(i18n.T("Earlier"), oldView))
+	sideBySide.Append(newDiffColumn(i18n.T("Current"), newView))
+
+	pages := adw.NewViewStack()
+	pages.SetVExpand(true)
+	pages.AddTitledWithIcon(newDiffScroll(inlineView), "inline", i18n.T("Inline"), "view-continuous-symbolic")
+	pages.AddTitledWithIcon(sideBySide, "side-by-side", i18n.T("Side by side"), "view-dual-symbolic")
+
+	switcher := adw.NewViewSwitcher()
+	switcher.SetStack(pages)
+	switcher.SetPolicy(adw.ViewSwitcherPolicyWide)
+	headerBar.SetTitleWidget(switcher)
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(12)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	d.summaryLabel = gtk.NewLabel("")
+	d.summaryLabel.SetXAlign(0)
+	d.summaryLabel.AddCSSClass("dim-label")
+	d.summaryLabel.AddCSSClass("caption")
+	content.Append(d.summaryLabel)
+	content.Append(pages)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(content)
+
+	d.SetContent(toolbarView)
+}
+
+// newDiffTextView creates a read-only view for compared text.
+func newDiffTextView() *gtk.TextView {
+	view := gtk.NewTextView()
+	view.SetEditable(false)
+	view.SetCursorVisible(false)
+	view.SetWrapMode(gtk.WrapWordChar)
+	view.SetLeftMargin(12)
+	view.SetRightMargin(12)
+	view.SetTopMargin(12)
+	view.SetBottomMargin(12)
+	return view
+}
+
+// newDiffScroll puts a text view in a scrolled card.
+func newDiffScroll(view *gtk.TextView) *gtk.ScrolledWindow {
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(view)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	scrolled.AddCSSClass("card")
+	return scrolled
+}
+
+// newDiffColumn creates a titled column of the side by side view.
+func newDiffColumn(title string, view *gtk.TextView) *gtk.Box {
+	column := gtk.NewBox(gtk.OrientationVertical, 6)
+
+	label := gtk.NewLabel(title)
+	label.SetXAlign(0)
+	label.AddCSSClass("heading")
+	column.Append(label)
+	column.Append(newDiffScroll(view))
+
+	return column
+}
+
+// addDiffTags adds the tags highlighting changes to buffer.
+func addDiffTags(buffer *gtk.TextBuffer) {
+	inserted := gtk.NewTextTag("inserted")
+	inserted.SetObjectProperty("background", diffInsertedColor)
+	buffer.TagTable().Add(inserted)
+
+	deleted := gtk.NewTextTag("deleted")
+	deleted.SetObjectProperty("background", diffDeletedColor)
+	deleted.SetObjectProperty("strikethrough", true)
+	buffer.TagTable().Add(deleted)
+}
+
+// showAlternative compares the earlier response at index with the current
+// one.
+func (d *ResponseDiffDialog) showAlternative(index int) {
+	if index < 0 || index >= len(d.alternatives) {
+		return
+	}
+
+	ops := diff.Words(d.alternatives[index].Content, d.current)
+	d.summaryLabel.SetLabel(diffSummary(diff.Changes(ops)))
+
+	writeDiff(d.inlineBuffer, ops, -1)
+	writeDiff(d.oldBuffer, ops, diff.Insert)
+	writeDiff(d.newBuffer, ops, diff.Delete)
+}
+
+// writeDiff fills buffer with the text of ops, highlighting the changes and
+// leaving out the ops of kind skip (-1 to keep all of them).
+func writeDiff(buffer *gtk.TextBuffer, ops []diff.Op, skip diff.Kind) {
+	buffer.SetText("")
+	for _, op := range ops {
+		if op.Kind == skip {
+			continue
+		}
+		start := buffer.CharCount()
+		buffer.Insert(buffer.EndIter(), op.Text)
+		switch op.Kind {
+		case diff.Insert:
+			buffer.ApplyTagByName("inserted", buffer.IterAtOffset(start), buffer.EndIter())
+		case diff.Delete:
+			buffer.ApplyTagByName("deleted", buffer.IterAtOffset(start), buffer.EndIter())
+		}
+	}
+}
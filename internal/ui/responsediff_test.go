@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestAlternativeLabel(t *testing.T) {
+	msg := &store.Message{CreatedAt: time.Date(2024, 5, 1, 9, 7, 0, 0, time.Local)}
+
+	if got, want := alternativeLabel(0, msg), "Response 1 · 09:07"; got != want {
+		t.Errorf("alternativeLabel() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffSummary(t *testing.T) {
+	tests := []struct {
+		inserted, deleted int
+		want              string
+	}{
+		{0, 0, "The responses are the same"},
+		{3, 1, "3 words added, 1 removed"},
+		{0, 5, "0 words added, 5 removed"},
+	}
+
+	for _, tt := range tests {
+		if got := diffSummary(tt.inserted, tt.deleted); got != tt.want {
+			t.Errorf("diffSummary(%d, %d) = %q, want %q", tt.inserted, tt.deleted, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+// buildCritiqueMessages builds the request asking the model to review an answer.
+func buildCritiqueMessages(question, answer string) []ollama.Message {
+	return []ollama.Message{
+		{
+			Role: "system",
+			Content: "You are a careful reviewer. Point out factual errors, missing steps, bugs in code, " +
+				"unclear explanations and parts that don't answer the question. Be brief and concrete, " +
+				"using a short bulleted list. If the answer is already good, say so in one sentence.",
+		},
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("Question:\n%s\n\nAnswer to review:\n%s", question, answer),
+		},
+	}
+}
+
+// buildRevisionMessages builds the request asking the model to rewrite an
+// answer taking the critique into account.
+func buildRevisionMessages(history []ollama.Message, answer, critique string) []ollama.Message {
+	messages := make([]ollama.Message, 0, len(history)+2)
+	messages = append(messages, history...)
+	messages = append(messages,
+		ollama.Message{Role: "assistant", Content: answer},
+		ollama.Message{
+			Role: "user",
+			Content: fmt.Sprintf("A reviewer gave this feedback on your answer:\n%s\n\n"+
+				"Write the final, improved answer to my previous message. Reply with the answer only, "+
+				"without mentioning the review. If no changes are needed, repeat your answer.", critique),
+		},
+	)
+	return messages
+}
+
+// lastUserContent returns the content of the last user message.
+func lastUserContent(messages []ollama.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// critiqueResponse asks the utility model to review an answer to the last
+// user message in messages.
+func (cv *ChatView) critiqueResponse(ctx context.Context, model string, messages []ollama.Message, answer string) (string, error) {
+	var critique strings.Builder
+	err := cv.streamHandler.Chat(ctx, &ollama.ChatRequest{
+		Model:    model,
+		Messages: buildCritiqueMessages(lastUserContent(messages), answer),
+	}, func(token string) {
+		critique.WriteString(token)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to critique response: %w", err)
+	}
+	return strings.TrimSpace(critique.String()), nil
+}
+
+// reviseResponse runs the critique-and-revise loop on a finished answer,
+// streaming the revision into the current bubble. It returns the final
+// answer and the critique; on failure the original answer is kept and the
+// critique is empty. Must be called from a background goroutine.
+func (cv *ChatView) reviseResponse(ctx context.Context, model string, messages []ollama.Message, answer string) (string, string) {
+	glib.IdleAdd(func() {
+		if cv.currentBubble != nil {
+			cv.currentBubble.SetThinking(true)
+		}
+	})
+	restore := func() {
+		glib.IdleAdd(func() {
+			if cv.currentBubble != nil {
+				cv.currentBubble.SetContent(answer)
+			}
+		})
+	}
+
+	critique, err := cv.critiqueResponse(ctx, model, messages, answer)
+	if err != nil || critique == "" {
+		logger.Error("Self-review failed, keeping original answer", "error", err)
+		restore()
+		return answer, ""
+	}
+
+	var revision strings.Builder
+	buffer := newTokenBuffer(50*time.Millisecond, func(content string) {
+		glib.IdleAdd(func() {
+			if cv.currentBubble != nil {
+				cv.currentBubble.SetContent(content)
+				if cv.userAtBottom {
+					cv.scrollToBottom()
+				}
+			}
+		})
+	})
+
+	_, err = cv.chatWithContextRetry(ctx, model, buildRevisionMessages(messages, answer, critique), completionSettings{}, func(token string) {
+		revision.WriteString(token)
+		buffer.Write(revision.String())
+	}, func() bool {
+		return revision.Len() == 0
+	})
+	buffer.Stop()
+
+	revised := strings.TrimSpace(revision.String())
+	if err != nil || revised == "" {
+		logger.Error("Self-review revision failed, keeping original answer", "error", err)
+		restore()
+		return answer, ""
+	}
+
+	logger.Info("Response revised after self-review", "originalLen", len(answer), "revisedLen", len(revised))
+	return revised, critique
+}
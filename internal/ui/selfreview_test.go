@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/ollama"
+)
+
+func TestBuildCritiqueMessages(t *testing.T) {
+	messages := buildCritiqueMessages("What is 2+2?", "5")
+
+	if len(messages) != 2 || messages[0].Role != "system" || messages[1].Role != "user" {
+		t.Fatalf("buildCritiqueMessages() roles = %+v, want system then user", messages)
+	}
+	if !strings.Contains(messages[1].Content, "What is 2+2?") || !strings.Contains(messages[1].Content, "5") {
+		t.Errorf("critique request missing question or answer: %q", messages[1].Content)
+	}
+}
+
+func TestBuildRevisionMessages(t *testing.T) {
+	history := []ollama.Message{
+		{Role: "system", Content: "Be helpful"},
+		{Role: "user", Content: "What is 2+2?"},
+	}
+
+	messages := buildRevisionMessages(history, "5", "The sum is wrong")
+
+	if len(messages) != 4 {
+		t.Fatalf("buildRevisionMessages() returned %d messages, want 4", len(messages))
+	}
+	if messages[2].Role != "assistant" || messages[2].Content != "5" {
+		t.Errorf("messages[2] = %+v, want the original answer", messages[2])
+	}
+	if messages[3].Role != "user" || !strings.Contains(messages[3].Content, "The sum is wrong") {
+		t.Errorf("messages[3] = %+v, want a user message with the critique", messages[3])
+	}
+	if len(history) != 2 {
+		t.Errorf("buildRevisionMessages() modified history")
+	}
+}
+
+func TestLastUserContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []ollama.Message
+		want     string
+	}{
+		{"empty", nil, ""},
+		{"last user", []ollama.Message{{Role: "user", Content: "a"}, {Role: "assistant", Content: "b"}, {Role: "user", Content: "c"}}, "c"},
+		{"skips assistant", []ollama.Message{{Role: "user", Content: "a"}, {Role: "assistant", Content: "b"}}, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lastUserContent(tt.messages); got != tt.want {
+				t.Errorf("lastUserContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,19 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 
+	"github.com/storo/guanaco/internal/calendar"
 	"github.com/storo/guanaco/internal/config"
 	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/notes"
+	"github.com/storo/guanaco/internal/store"
+	"github.com/storo/guanaco/internal/update"
 )
 
 // Language represents a selectable language option.
@@ -29,15 +37,58 @@ type SettingsDialog struct {
 
 	// UI components
 	modelDropdown    *gtk.DropDown
+	utilityDropdown  *gtk.DropDown
+	selfReviewSwitch *gtk.Switch
+	multiAgentSwitch *gtk.Switch
+	keepAliveEntry   *gtk.Entry
+	calendarSwitch   *gtk.Switch
+	toolsSwitch      *gtk.Switch
+	remoteSwitch     *gtk.Switch
+	ocrSwitch        *gtk.Switch
+	backgroundSwitch *gtk.Switch
+	readAloudSwitch  *gtk.Switch
+	plainTextSwitch  *gtk.Switch
+	notebookSwitch   *gtk.Switch
+	snippetsSwitch   *gtk.Switch
 	languageDropdown *gtk.DropDown
+	uiLangDropdown   *gtk.DropDown
 	systemPromptView *gtk.TextView
+	promptWarnSpin   *gtk.SpinButton
+	summarizeSpin    *gtk.SpinButton
+	maxLengthSpin    *gtk.SpinButton
+	tableRowsSpin    *gtk.SpinButton
+	archiveDaysSpin  *gtk.SpinButton
+	updatesSwitch    *gtk.Switch
+	channelDropdown  *gtk.DropDown
+	endpointsEditor  *EndpointsEditor
+	shortcutsEditor  *ShortcutsEditor
+	pluginSwitches   map[string]*gtk.Switch // Keyed by plugin name
+	notifySwitches   map[string]*gtk.Switch // Keyed by notification event
+	quietSwitch      *gtk.Switch
+	quietStartSpin   *gtk.SpinButton
+	quietEndSpin     *gtk.SpinButton
+	notesFolderEntry *gtk.Entry
+	notesTagsEntry   *gtk.Entry
+	exportNameEntry  *gtk.Entry
+	frontMatterCheck *gtk.CheckButton
+	speechEntry      *gtk.Entry
+	schemeDropdown   *gtk.DropDown
+	accentDropdown   *gtk.DropDown
+	accentButton     *gtk.ColorDialogButton
+	densityDropdown  *gtk.DropDown
+	lightCodeStyle   *gtk.DropDown
+	darkCodeStyle    *gtk.DropDown
 
 	// Data
 	config *config.AppConfig
 	models []string
+	saved  bool // Closed with Save, so the previewed appearance is kept
 
 	// Callbacks
-	onSave func(*config.AppConfig)
+	onSave        func(*config.AppConfig)
+	onExportAll   func()
+	onImport      func()
+	onReloadStyle func()
 }
 
 // NewSettingsDialog creates a new settings dialog.
@@ -50,7 +101,7 @@ func NewSettingsDialog(parent *gtk.Window, cfg *config.AppConfig, models []strin
 	d.Window = adw.NewWindow()
 	d.SetTitle(i18n.T("Settings"))
 	d.SetModal(true)
-	d.SetDefaultSize(450, 500)
+	d.SetDefaultSize(500, 640)
 	if parent != nil {
 		d.SetTransientFor(parent)
 	}
@@ -65,7 +116,6 @@ func (d *SettingsDialog) setupUI() {
 	headerBar := adw.NewHeaderBar()
 	headerBar.SetShowEndTitleButtons(true)
 	headerBar.SetShowStartTitleButtons(true)
-	headerBar.SetTitleWidget(gtk.NewLabel(i18n.T("Settings")))
 
 	// Main content
 	content := gtk.NewBox(gtk.OrientationVertical, 16)
@@ -74,15 +124,264 @@ func (d *SettingsDialog) setupUI() {
 	content.SetMarginStart(24)
 	content.SetMarginEnd(24)
 
+	// === Ollama Servers ===
+	serverLabel := gtk.NewLabel(i18n.T("Ollama Servers:"))
+	serverLabel.SetXAlign(0)
+	serverLabel.AddCSSClass("heading")
+	content.Append(serverLabel)
+
+	serverHint := gtk.NewLabel(i18n.T("The selected server is used immediately after saving"))
+	serverHint.SetXAlign(0)
+	serverHint.AddCSSClass("dim-label")
+	serverHint.AddCSSClass("caption")
+	content.Append(serverHint)
+
+	d.endpointsEditor = NewEndpointsEditor(d.config.Endpoints, d.config.ActiveEndpoint)
+	content.Append(d.endpointsEditor)
+
+	// === Remote Files ===
+	remoteBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	remoteBox.SetMarginTop(8)
+
+	remoteText := gtk.NewBox(gtk.OrientationVertical, 2)
+	remoteText.SetHExpand(true)
+
+	remoteLabel := gtk.NewLabel(i18n.T("Confirm files sent to other machines"))
+	remoteLabel.SetXAlign(0)
+	remoteLabel.AddCSSClass("heading")
+	remoteText.Append(remoteLabel)
+
+	remoteHint := gtk.NewLabel(i18n.T("Before attachments are sent to a server that isn't on this computer, list them and ask"))
+	remoteHint.SetXAlign(0)
+	remoteHint.SetWrap(true)
+	remoteHint.AddCSSClass("dim-label")
+	remoteHint.AddCSSClass("caption")
+	remoteText.Append(remoteHint)
+	remoteBox.Append(remoteText)
+
+	d.remoteSwitch = gtk.NewSwitch()
+	d.remoteSwitch.SetActive(d.config.ConfirmRemoteFiles)
+	d.remoteSwitch.SetVAlign(gtk.AlignCenter)
+	remoteBox.Append(d.remoteSwitch)
+	content.Append(remoteBox)
+
 	// === Default Model ===
 	modelLabel := gtk.NewLabel(i18n.T("Default Model:"))
 	modelLabel.SetXAlign(0)
 	modelLabel.AddCSSClass("heading")
 	content.Append(modelLabel)
 
-	d.modelDropdown = d.createModelDropdown()
+	d.modelDropdown = d.createModelDropdown(i18n.T("(None - use first available)"), d.config.DefaultModel)
 	content.Append(d.modelDropdown)
 
+	// === Utility Model ===
+	utilityLabel := gtk.NewLabel(i18n.T("Utility Model:"))
+	utilityLabel.SetXAlign(0)
+	utilityLabel.SetMarginTop(8)
+	utilityLabel.AddCSSClass("heading")
+	content.Append(utilityLabel)
+
+	utilityHint := gtk.NewLabel(i18n.T("Used for chat titles and self-review"))
+	utilityHint.SetXAlign(0)
+	utilityHint.AddCSSClass("dim-label")
+	utilityHint.AddCSSClass("caption")
+	content.Append(utilityHint)
+
+	d.utilityDropdown = d.createModelDropdown(i18n.T("(Same as chat model)"), d.config.UtilityModel)
+	content.Append(d.utilityDropdown)
+
+	// === Self-Review ===
+	reviewBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	reviewBox.SetMarginTop(8)
+
+	reviewText := gtk.NewBox(gtk.OrientationVertical, 2)
+	reviewText.SetHExpand(true)
+
+	reviewLabel := gtk.NewLabel(i18n.T("Self-review responses (experimental)"))
+	reviewLabel.SetXAlign(0)
+	reviewLabel.AddCSSClass("heading")
+	reviewText.Append(reviewLabel)
+
+	reviewHint := gtk.NewLabel(i18n.T("The utility model critiques each answer and writes a revised version"))
+	reviewHint.SetXAlign(0)
+	reviewHint.SetWrap(true)
+	reviewHint.AddCSSClass("dim-label")
+	reviewHint.AddCSSClass("caption")
+	reviewText.Append(reviewHint)
+	reviewBox.Append(reviewText)
+
+	d.selfReviewSwitch = gtk.NewSwitch()
+	d.selfReviewSwitch.SetActive(d.config.SelfReview)
+	d.selfReviewSwitch.SetVAlign(gtk.AlignCenter)
+	reviewBox.Append(d.selfReviewSwitch)
+	content.Append(reviewBox)
+
+	// === Multi-Agent ===
+	agentsBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	agentsBox.SetMarginTop(8)
+
+	agentsText := gtk.NewBox(gtk.OrientationVertical, 2)
+	agentsText.SetHExpand(true)
+
+	agentsLabel := gtk.NewLabel(i18n.T("Multi-agent conversations (experimental)"))
+	agentsLabel.SetXAlign(0)
+	agentsLabel.AddCSSClass("heading")
+	agentsText.Append(agentsLabel)
+
+	agentsHint := gtk.NewLabel(i18n.T("Show a button starting a conversation between two models"))
+	agentsHint.SetXAlign(0)
+	agentsHint.SetWrap(true)
+	agentsHint.AddCSSClass("dim-label")
+	agentsHint.AddCSSClass("caption")
+	agentsText.Append(agentsHint)
+	agentsBox.Append(agentsText)
+
+	d.multiAgentSwitch = gtk.NewSwitch()
+	d.multiAgentSwitch.SetActive(d.config.MultiAgent)
+	d.multiAgentSwitch.SetVAlign(gtk.AlignCenter)
+	agentsBox.Append(d.multiAgentSwitch)
+	content.Append(agentsBox)
+
+	// === Keep-Alive ===
+	keepAliveLabel := gtk.NewLabel(i18n.T("Keep Models Loaded For:"))
+	keepAliveLabel.SetXAlign(0)
+	keepAliveLabel.SetMarginTop(8)
+	keepAliveLabel.AddCSSClass("heading")
+	content.Append(keepAliveLabel)
+
+	keepAliveHint := gtk.NewLabel(i18n.T("How long a model stays in memory after a response, unless the chat sets its own"))
+	keepAliveHint.SetXAlign(0)
+	keepAliveHint.SetWrap(true)
+	keepAliveHint.AddCSSClass("dim-label")
+	keepAliveHint.AddCSSClass("caption")
+	content.Append(keepAliveHint)
+
+	d.keepAliveEntry = gtk.NewEntry()
+	d.keepAliveEntry.SetText(d.config.KeepAlive)
+	d.keepAliveEntry.SetPlaceholderText(i18n.T("Server default (e.g. 10m, 1h, -1 for always)"))
+	d.keepAliveEntry.ConnectChanged(func() {
+		if validKeepAlive(d.keepAliveEntry.Text()) {
+			d.keepAliveEntry.RemoveCSSClass("error")
+		} else {
+			d.keepAliveEntry.AddCSSClass("error")
+		}
+	})
+	content.Append(d.keepAliveEntry)
+
+	// === Built-in Tools ===
+	toolsBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	toolsBox.SetMarginTop(8)
+
+	toolsText := gtk.NewBox(gtk.OrientationVertical, 2)
+	toolsText.SetHExpand(true)
+
+	toolsLabel := gtk.NewLabel(i18n.T("Built-in tools"))
+	toolsLabel.SetXAlign(0)
+	toolsLabel.AddCSSClass("heading")
+	toolsText.Append(toolsLabel)
+
+	toolsHint := gtk.NewLabel(i18n.T("Models that support tools can check the time, convert units and calculate on this computer"))
+	toolsHint.SetXAlign(0)
+	toolsHint.SetWrap(true)
+	toolsHint.AddCSSClass("dim-label")
+	toolsHint.AddCSSClass("caption")
+	toolsText.Append(toolsHint)
+	toolsBox.Append(toolsText)
+
+	d.toolsSwitch = gtk.NewSwitch()
+	d.toolsSwitch.SetActive(d.config.BuiltinTools)
+	d.toolsSwitch.SetVAlign(gtk.AlignCenter)
+	toolsBox.Append(d.toolsSwitch)
+	content.Append(toolsBox)
+
+	// === Image Text ===
+	ocrBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	ocrBox.SetMarginTop(8)
+
+	ocrText := gtk.NewBox(gtk.OrientationVertical, 2)
+	ocrText.SetHExpand(true)
+
+	ocrLabel := gtk.NewLabel(i18n.T("Read text from images"))
+	ocrLabel.SetXAlign(0)
+	ocrLabel.AddCSSClass("heading")
+	ocrText.Append(ocrLabel)
+
+	ocrHint := gtk.NewLabel(i18n.T("Models that can't see images get the text found in them instead. Requires tesseract"))
+	ocrHint.SetXAlign(0)
+	ocrHint.SetWrap(true)
+	ocrHint.AddCSSClass("dim-label")
+	ocrHint.AddCSSClass("caption")
+	ocrText.Append(ocrHint)
+	ocrBox.Append(ocrText)
+
+	d.ocrSwitch = gtk.NewSwitch()
+	d.ocrSwitch.SetActive(d.config.ImageOCR)
+	d.ocrSwitch.SetVAlign(gtk.AlignCenter)
+	ocrBox.Append(d.ocrSwitch)
+	content.Append(ocrBox)
+
+	// === Notebook Outputs ===
+	notebookBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	notebookBox.SetMarginTop(8)
+
+	notebookText := gtk.NewBox(gtk.OrientationVertical, 2)
+	notebookText.SetHExpand(true)
+
+	notebookLabel := gtk.NewLabel(i18n.T("Include notebook outputs"))
+	notebookLabel.SetXAlign(0)
+	notebookLabel.AddCSSClass("heading")
+	notebookText.Append(notebookLabel)
+
+	notebookHint := gtk.NewLabel(i18n.T("Attached Jupyter notebooks keep the text output of their code cells"))
+	notebookHint.SetXAlign(0)
+	notebookHint.SetWrap(true)
+	notebookHint.AddCSSClass("dim-label")
+	notebookHint.AddCSSClass("caption")
+	notebookText.Append(notebookHint)
+	notebookBox.Append(notebookText)
+
+	d.notebookSwitch = gtk.NewSwitch()
+	d.notebookSwitch.SetActive(d.config.NotebookOutputs)
+	d.notebookSwitch.SetVAlign(gtk.AlignCenter)
+	notebookBox.Append(d.notebookSwitch)
+	content.Append(notebookBox)
+
+	// === Code Snippets ===
+	snippetsBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	snippetsBox.SetMarginTop(8)
+
+	snippetsText := gtk.NewBox(gtk.OrientationVertical, 2)
+	snippetsText.SetHExpand(true)
+
+	snippetsLabel := gtk.NewLabel(i18n.T("Run code snippets"))
+	snippetsLabel.SetXAlign(0)
+	snippetsLabel.AddCSSClass("heading")
+	snippetsText.Append(snippetsLabel)
+
+	snippetsHint := gtk.NewLabel(i18n.T("Shell and Python code blocks get a button running them in a sandbox without network or access to your files. Requires bubblewrap"))
+	snippetsHint.SetXAlign(0)
+	snippetsHint.SetWrap(true)
+	snippetsHint.AddCSSClass("dim-label")
+	snippetsHint.AddCSSClass("caption")
+	snippetsText.Append(snippetsHint)
+	snippetsBox.Append(snippetsText)
+
+	d.snippetsSwitch = gtk.NewSwitch()
+	d.snippetsSwitch.SetActive(d.config.RunSnippets)
+	d.snippetsSwitch.SetVAlign(gtk.AlignCenter)
+	snippetsBox.Append(d.snippetsSwitch)
+	content.Append(snippetsBox)
+
+	// === Interface Language ===
+	uiLangLabel := gtk.NewLabel(i18n.T("Interface Language:"))
+	uiLangLabel.SetXAlign(0)
+	uiLangLabel.SetMarginTop(8)
+	uiLangLabel.AddCSSClass("heading")
+	content.Append(uiLangLabel)
+
+	d.uiLangDropdown = d.createLanguageDropdown(d.config.InterfaceLanguage)
+	content.Append(d.uiLangDropdown)
+
 	// === Response Language ===
 	langLabel := gtk.NewLabel(i18n.T("Response Language:"))
 	langLabel.SetXAlign(0)
@@ -90,7 +389,7 @@ func (d *SettingsDialog) setupUI() {
 	langLabel.AddCSSClass("heading")
 	content.Append(langLabel)
 
-	d.languageDropdown = d.createLanguageDropdown()
+	d.languageDropdown = d.createLanguageDropdown(d.config.ResponseLanguage)
 	content.Append(d.languageDropdown)
 
 	// === Global System Prompt ===
@@ -118,10 +417,340 @@ func (d *SettingsDialog) setupUI() {
 	promptScrolled.AddCSSClass("card")
 	content.Append(promptScrolled)
 
+	// === Calendar ===
+	calendarBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	calendarBox.SetMarginTop(8)
+
+	calendarText := gtk.NewBox(gtk.OrientationVertical, 2)
+	calendarText.SetHExpand(true)
+
+	calendarLabel := gtk.NewLabel(i18n.T("Share today's calendar"))
+	calendarLabel.SetXAlign(0)
+	calendarLabel.AddCSSClass("heading")
+	calendarText.Append(calendarLabel)
+
+	calendarHint := gtk.NewLabel(fmt.Sprintf(i18n.T("Replaces %s in prompts with today's events from your local calendars, which are sent to the Ollama server"), calendar.Variable))
+	calendarHint.SetXAlign(0)
+	calendarHint.SetWrap(true)
+	calendarHint.AddCSSClass("dim-label")
+	calendarHint.AddCSSClass("caption")
+	calendarText.Append(calendarHint)
+	calendarBox.Append(calendarText)
+
+	d.calendarSwitch = gtk.NewSwitch()
+	d.calendarSwitch.SetActive(d.config.CalendarEnabled)
+	d.calendarSwitch.SetVAlign(gtk.AlignCenter)
+	calendarBox.Append(d.calendarSwitch)
+	content.Append(calendarBox)
+
+	// === Large Prompt Warning ===
+	warnLabel := gtk.NewLabel(i18n.T("Confirm prompts larger than (tokens):"))
+	warnLabel.SetXAlign(0)
+	warnLabel.SetMarginTop(8)
+	warnLabel.AddCSSClass("heading")
+	content.Append(warnLabel)
+
+	warnHint := gtk.NewLabel(i18n.T("Shows a size breakdown before sending (0 disables)"))
+	warnHint.SetXAlign(0)
+	warnHint.AddCSSClass("dim-label")
+	warnHint.AddCSSClass("caption")
+	content.Append(warnHint)
+
+	d.promptWarnSpin = gtk.NewSpinButtonWithRange(0, 1000000, 1000)
+	d.promptWarnSpin.SetValue(float64(d.config.PromptWarnTokens))
+	content.Append(d.promptWarnSpin)
+
+	// === History Summaries ===
+	summarizeLabel := gtk.NewLabel(i18n.T("Summarize history beyond (tokens):"))
+	summarizeLabel.SetXAlign(0)
+	summarizeLabel.SetMarginTop(8)
+	summarizeLabel.AddCSSClass("heading")
+	content.Append(summarizeLabel)
+
+	summarizeHint := gtk.NewLabel(i18n.T("Older messages are condensed into a summary before sending, the latest ones are kept as they are (0 disables)"))
+	summarizeHint.SetXAlign(0)
+	summarizeHint.SetWrap(true)
+	summarizeHint.AddCSSClass("dim-label")
+	summarizeHint.AddCSSClass("caption")
+	content.Append(summarizeHint)
+
+	d.summarizeSpin = gtk.NewSpinButtonWithRange(0, 1000000, 1000)
+	d.summarizeSpin.SetValue(float64(d.config.SummarizeTokens))
+	content.Append(d.summarizeSpin)
+
+	// === Long Messages ===
+	maxLengthLabel := gtk.NewLabel(i18n.T("Attach messages longer than (characters):"))
+	maxLengthLabel.SetXAlign(0)
+	maxLengthLabel.SetMarginTop(8)
+	maxLengthLabel.AddCSSClass("heading")
+	content.Append(maxLengthLabel)
+
+	maxLengthHint := gtk.NewLabel(i18n.T("The message keeps a short excerpt and the full text is sent as a file (0 disables)"))
+	maxLengthHint.SetXAlign(0)
+	maxLengthHint.SetWrap(true)
+	maxLengthHint.AddCSSClass("dim-label")
+	maxLengthHint.AddCSSClass("caption")
+	content.Append(maxLengthHint)
+
+	d.maxLengthSpin = gtk.NewSpinButtonWithRange(0, 10000000, 1000)
+	d.maxLengthSpin.SetValue(float64(d.config.MaxMessageLength))
+	content.Append(d.maxLengthSpin)
+
+	// === Spreadsheets ===
+	tableRowsLabel := gtk.NewLabel(i18n.T("Spreadsheet rows to send:"))
+	tableRowsLabel.SetXAlign(0)
+	tableRowsLabel.SetMarginTop(8)
+	tableRowsLabel.AddCSSClass("heading")
+	content.Append(tableRowsLabel)
+
+	tableRowsHint := gtk.NewLabel(i18n.T("Larger sheets keep their first and last rows (0 sends all of them)"))
+	tableRowsHint.SetXAlign(0)
+	tableRowsHint.SetWrap(true)
+	tableRowsHint.AddCSSClass("dim-label")
+	tableRowsHint.AddCSSClass("caption")
+	content.Append(tableRowsHint)
+
+	d.tableRowsSpin = gtk.NewSpinButtonWithRange(0, 100000, 50)
+	d.tableRowsSpin.SetValue(float64(d.config.MaxTableRows))
+	content.Append(d.tableRowsSpin)
+
+	// === Archiving ===
+	archiveLabel := gtk.NewLabel(i18n.T("Archive chats unused for (days):"))
+	archiveLabel.SetXAlign(0)
+	archiveLabel.SetMarginTop(8)
+	archiveLabel.AddCSSClass("heading")
+	content.Append(archiveLabel)
+
+	archiveHint := gtk.NewLabel(i18n.T("Checked at startup. Archived chats are hidden from the list until a message is sent in them (0 disables)"))
+	archiveHint.SetXAlign(0)
+	archiveHint.SetWrap(true)
+	archiveHint.AddCSSClass("dim-label")
+	archiveHint.AddCSSClass("caption")
+	content.Append(archiveHint)
+
+	d.archiveDaysSpin = gtk.NewSpinButtonWithRange(0, 3650, 7)
+	d.archiveDaysSpin.SetValue(float64(d.config.AutoArchiveDays))
+	content.Append(d.archiveDaysSpin)
+
+	// === Updates ===
+	updatesBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	updatesBox.SetMarginTop(8)
+
+	updatesText := gtk.NewBox(gtk.OrientationVertical, 2)
+	updatesText.SetHExpand(true)
+
+	updatesLabel := gtk.NewLabel(i18n.T("Check for updates"))
+	updatesLabel.SetXAlign(0)
+	updatesLabel.AddCSSClass("heading")
+	updatesText.Append(updatesLabel)
+
+	updatesHint := gtk.NewLabel(i18n.T("Once a week, ask GitHub whether a new version of Guanaco was released. Nothing about you or your chats is sent"))
+	updatesHint.SetXAlign(0)
+	updatesHint.SetWrap(true)
+	updatesHint.AddCSSClass("dim-label")
+	updatesHint.AddCSSClass("caption")
+	updatesText.Append(updatesHint)
+	updatesBox.Append(updatesText)
+
+	d.updatesSwitch = gtk.NewSwitch()
+	d.updatesSwitch.SetActive(d.config.UpdateCheck)
+	d.updatesSwitch.SetVAlign(gtk.AlignCenter)
+	updatesBox.Append(d.updatesSwitch)
+	content.Append(updatesBox)
+
+	d.channelDropdown = gtk.NewDropDown(gtk.NewStringList([]string{
+		i18n.T("Stable releases"),
+		i18n.T("Stable releases and pre-releases"),
+	}), nil)
+	if update.Channel(d.config.UpdateChannel) == update.ChannelPrerelease {
+		d.channelDropdown.SetSelected(1)
+	}
+	d.channelDropdown.SetSensitive(d.config.UpdateCheck)
+	d.updatesSwitch.NotifyProperty("active", func() {
+		d.channelDropdown.SetSensitive(d.updatesSwitch.Active())
+	})
+	content.Append(d.channelDropdown)
+
+	// === Data ===
+	dataLabel := gtk.NewLabel(i18n.T("Data:"))
+	dataLabel.SetXAlign(0)
+	dataLabel.SetMarginTop(8)
+	dataLabel.AddCSSClass("heading")
+	content.Append(dataLabel)
+
+	exportBtn := gtk.NewButton()
+	exportBtn.SetLabel(i18n.T("Export All Chats…"))
+	exportBtn.SetHAlign(gtk.AlignStart)
+	exportBtn.ConnectClicked(func() {
+		if d.onExportAll != nil {
+			d.onExportAll()
+		}
+	})
+	content.Append(exportBtn)
+
+	importBtn := gtk.NewButton()
+	importBtn.SetLabel(i18n.T("Import Chats…"))
+	importBtn.SetHAlign(gtk.AlignStart)
+	importBtn.SetTooltipText(i18n.T("Import the conversations.json of a ChatGPT data export, or an Open WebUI chat export"))
+	importBtn.ConnectClicked(func() {
+		if d.onImport != nil {
+			d.onImport()
+		}
+	})
+	content.Append(importBtn)
+
+	exportNameHint := gtk.NewLabel(fmt.Sprintf(i18n.T("File name of exported chats, made with %s"), strings.Join(store.ExportFileNamePlaceholders, ", ")))
+	exportNameHint.SetXAlign(0)
+	exportNameHint.SetWrap(true)
+	exportNameHint.SetMarginTop(4)
+	exportNameHint.AddCSSClass("dim-label")
+	exportNameHint.AddCSSClass("caption")
+	content.Append(exportNameHint)
+
+	d.exportNameEntry = gtk.NewEntry()
+	d.exportNameEntry.SetText(d.config.ExportFileName)
+	d.exportNameEntry.SetPlaceholderText("{{title}}")
+	content.Append(d.exportNameEntry)
+
+	d.frontMatterCheck = gtk.NewCheckButtonWithLabel(i18n.T("Start Markdown exports of a chat with YAML front matter"))
+	d.frontMatterCheck.SetTooltipText(i18n.T("Title, dates, model and tags, as read by static site generators and note apps"))
+	d.frontMatterCheck.SetActive(d.config.ExportFrontMatter)
+	content.Append(d.frontMatterCheck)
+
+	// === Notes ===
+	notesLabel := gtk.NewLabel(i18n.T("Notes folder:"))
+	notesLabel.SetXAlign(0)
+	notesLabel.SetMarginTop(8)
+	notesLabel.AddCSSClass("heading")
+	content.Append(notesLabel)
+
+	notesHint := gtk.NewLabel(i18n.T("Answers and chats sent to notes are added to Markdown files in this folder, such as an Obsidian vault"))
+	notesHint.SetXAlign(0)
+	notesHint.SetWrap(true)
+	notesHint.AddCSSClass("dim-label")
+	notesHint.AddCSSClass("caption")
+	content.Append(notesHint)
+
+	notesFolderBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	d.notesFolderEntry = gtk.NewEntry()
+	d.notesFolderEntry.SetText(d.config.NotesFolder)
+	d.notesFolderEntry.SetPlaceholderText(i18n.T("Not set"))
+	d.notesFolderEntry.SetHExpand(true)
+	notesFolderBox.Append(d.notesFolderEntry)
+
+	chooseFolderBtn := gtk.NewButton()
+	chooseFolderBtn.SetLabel(i18n.T("Choose…"))
+	chooseFolderBtn.ConnectClicked(d.chooseNotesFolder)
+	notesFolderBox.Append(chooseFolderBtn)
+	content.Append(notesFolderBox)
+
+	d.notesTagsEntry = gtk.NewEntry()
+	d.notesTagsEntry.SetText(strings.Join(d.config.NotesTags, ", "))
+	d.notesTagsEntry.SetPlaceholderText(fmt.Sprintf(i18n.T("Tags, separated by commas (default: %s)"), strings.Join(notes.DefaultTags, ", ")))
+	content.Append(d.notesTagsEntry)
+
+	// === Speech input ===
+	speechLabel := gtk.NewLabel(i18n.T("Speech to text command:"))
+	speechLabel.SetXAlign(0)
+	speechLabel.SetMarginTop(8)
+	speechLabel.AddCSSClass("heading")
+	content.Append(speechLabel)
+
+	speechHint := gtk.NewLabel(i18n.T("Shows a microphone button that records you and types what this command prints, run on the recording at {file}"))
+	speechHint.SetXAlign(0)
+	speechHint.SetWrap(true)
+	speechHint.AddCSSClass("dim-label")
+	speechHint.AddCSSClass("caption")
+	content.Append(speechHint)
+
+	d.speechEntry = gtk.NewEntry()
+	d.speechEntry.SetText(d.config.SpeechCommand)
+	d.speechEntry.SetPlaceholderText("whisper-cli -m ~/models/ggml-base.bin -nt -np -f {file}")
+	content.Append(d.speechEntry)
+
+	// === Read Aloud ===
+	readAloudBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	readAloudBox.SetMarginTop(8)
+
+	readAloudText := gtk.NewBox(gtk.OrientationVertical, 2)
+	readAloudText.SetHExpand(true)
+
+	readAloudLabel := gtk.NewLabel(i18n.T("Read responses aloud"))
+	readAloudLabel.SetXAlign(0)
+	readAloudLabel.AddCSSClass("heading")
+	readAloudText.Append(readAloudLabel)
+
+	readAloudHint := gtk.NewLabel(i18n.T("Each completed response is read aloud, except in chats muted with the speaker button. Requires speech-dispatcher or espeak-ng"))
+	readAloudHint.SetXAlign(0)
+	readAloudHint.SetWrap(true)
+	readAloudHint.AddCSSClass("dim-label")
+	readAloudHint.AddCSSClass("caption")
+	readAloudText.Append(readAloudHint)
+	readAloudBox.Append(readAloudText)
+
+	d.readAloudSwitch = gtk.NewSwitch()
+	d.readAloudSwitch.SetActive(d.config.ReadAloud)
+	d.readAloudSwitch.SetVAlign(gtk.AlignCenter)
+	readAloudBox.Append(d.readAloudSwitch)
+	content.Append(readAloudBox)
+
+	// === Background ===
+	backgroundBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	backgroundBox.SetMarginTop(8)
+
+	backgroundText := gtk.NewBox(gtk.OrientationVertical, 2)
+	backgroundText.SetHExpand(true)
+
+	backgroundLabel := gtk.NewLabel(i18n.T("Run in background"))
+	backgroundLabel.SetXAlign(0)
+	backgroundLabel.AddCSSClass("heading")
+	backgroundText.Append(backgroundLabel)
+
+	backgroundHint := gtk.NewLabel(i18n.T("Keep Guanaco running when its last window closes, so it opens instantly. Use Quit Completely or Ctrl+Q to exit"))
+	backgroundHint.SetXAlign(0)
+	backgroundHint.SetWrap(true)
+	backgroundHint.AddCSSClass("dim-label")
+	backgroundHint.AddCSSClass("caption")
+	backgroundText.Append(backgroundHint)
+	backgroundBox.Append(backgroundText)
+
+	d.backgroundSwitch = gtk.NewSwitch()
+	d.backgroundSwitch.SetActive(d.config.RunInBackground)
+	d.backgroundSwitch.SetVAlign(gtk.AlignCenter)
+	backgroundBox.Append(d.backgroundSwitch)
+	content.Append(backgroundBox)
+
+	// === Advanced ===
+	advancedLabel := gtk.NewLabel(i18n.T("Advanced:"))
+	advancedLabel.SetXAlign(0)
+	advancedLabel.SetMarginTop(8)
+	advancedLabel.AddCSSClass("heading")
+	content.Append(advancedLabel)
+
+	styleHint := gtk.NewLabel(fmt.Sprintf(i18n.T("Custom stylesheet: %s"), config.GetUserStylePath()))
+	styleHint.SetXAlign(0)
+	styleHint.SetWrap(true)
+	styleHint.SetSelectable(true)
+	styleHint.AddCSSClass("dim-label")
+	styleHint.AddCSSClass("caption")
+	content.Append(styleHint)
+
+	reloadStyleBtn := gtk.NewButton()
+	reloadStyleBtn.SetLabel(i18n.T("Reload Custom Style"))
+	reloadStyleBtn.SetHAlign(gtk.AlignStart)
+	reloadStyleBtn.ConnectClicked(func() {
+		if d.onReloadStyle != nil {
+			d.onReloadStyle()
+		}
+	})
+	content.Append(reloadStyleBtn)
+
 	// === Buttons ===
 	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
 	buttonBox.SetHAlign(gtk.AlignEnd)
-	buttonBox.SetMarginTop(16)
+	buttonBox.SetMarginTop(12)
+	buttonBox.SetMarginBottom(12)
+	buttonBox.SetMarginEnd(24)
 
 	cancelBtn := gtk.NewButton()
 	cancelBtn.SetLabel(i18n.T("Cancel"))
@@ -136,27 +765,68 @@ func (d *SettingsDialog) setupUI() {
 	saveBtn.ConnectClicked(d.onSaveClicked)
 	buttonBox.Append(saveBtn)
 
-	content.Append(buttonBox)
-
 	// Layout
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(content)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+
+	pages := adw.NewViewStack()
+	pages.AddTitledWithIcon(scrolled, "general", i18n.T("General"), "preferences-system-symbolic")
+	pages.AddTitledWithIcon(d.createAppearancePage(), "appearance", i18n.T("Appearance"), "applications-graphics-symbolic")
+	pages.AddTitledWithIcon(d.createNotificationsPage(), "notifications", i18n.T("Notifications"), "preferences-system-notifications-symbolic")
+	pages.AddTitledWithIcon(d.createShortcutsPage(), "shortcuts", i18n.T("Shortcuts"), "preferences-desktop-keyboard-shortcuts-symbolic")
+	pages.AddTitledWithIcon(d.createPluginsPage(), "plugins", i18n.T("Plugins"), "application-x-addon-symbolic")
+
+	switcher := adw.NewViewSwitcher()
+	switcher.SetPolicy(adw.ViewSwitcherPolicyWide)
+	switcher.SetStack(pages)
+	headerBar.SetTitleWidget(switcher)
+
 	toolbarView := adw.NewToolbarView()
 	toolbarView.AddTopBar(headerBar)
-	toolbarView.SetContent(content)
+	toolbarView.SetContent(pages)
+	toolbarView.AddBottomBar(buttonBox)
 
 	d.SetContent(toolbarView)
 }
 
-func (d *SettingsDialog) createModelDropdown() *gtk.DropDown {
+// createShortcutsPage creates the page editing the keyboard shortcuts.
+func (d *SettingsDialog) createShortcutsPage() *gtk.ScrolledWindow {
+	content := gtk.NewBox(gtk.OrientationVertical, 16)
+	content.SetMarginTop(16)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	shortcutsLabel := gtk.NewLabel(i18n.T("Keyboard Shortcuts:"))
+	shortcutsLabel.SetXAlign(0)
+	shortcutsLabel.AddCSSClass("heading")
+	content.Append(shortcutsLabel)
+
+	d.shortcutsEditor = NewShortcutsEditor(d.config.ShortcutBindings())
+	content.Append(d.shortcutsEditor)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(content)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	return scrolled
+}
+
+// createModelDropdown creates a model dropdown whose first entry, noneLabel,
+// stands for no model, with selected preselected.
+func (d *SettingsDialog) createModelDropdown(noneLabel, selected string) *gtk.DropDown {
 	// Create string list for models
 	modelList := gtk.NewStringList(nil)
 
 	// Add "None" option first
-	modelList.Append(i18n.T("(None - use first available)"))
+	modelList.Append(noneLabel)
 
 	selectedIdx := uint(0)
 	for i, model := range d.models {
 		modelList.Append(model)
-		if model == d.config.DefaultModel {
+		if model == selected {
 			selectedIdx = uint(i + 1) // +1 because of "None" option
 		}
 	}
@@ -167,13 +837,13 @@ func (d *SettingsDialog) createModelDropdown() *gtk.DropDown {
 	return dropdown
 }
 
-func (d *SettingsDialog) createLanguageDropdown() *gtk.DropDown {
+func (d *SettingsDialog) createLanguageDropdown(selected string) *gtk.DropDown {
 	langList := gtk.NewStringList(nil)
 
 	selectedIdx := uint(0)
 	for i, lang := range availableLanguages {
 		langList.Append(lang.Name)
-		if lang.Code == d.config.ResponseLanguage {
+		if lang.Code == selected {
 			selectedIdx = uint(i)
 		}
 	}
@@ -185,25 +855,79 @@ func (d *SettingsDialog) createLanguageDropdown() *gtk.DropDown {
 }
 
 func (d *SettingsDialog) onSaveClicked() {
-	// Get selected model
-	modelIdx := d.modelDropdown.Selected()
-	if modelIdx == 0 {
-		d.config.DefaultModel = ""
-	} else if int(modelIdx-1) < len(d.models) {
-		d.config.DefaultModel = d.models[modelIdx-1]
+	// Get servers
+	endpoints, active, err := d.endpointsEditor.Endpoints()
+	if err != nil {
+		return // Invalid URL is highlighted in the editor
 	}
-
-	// Get selected language
+	if !validKeepAlive(d.keepAliveEntry.Text()) {
+		d.keepAliveEntry.AddCSSClass("error")
+		d.keepAliveEntry.GrabFocus()
+		return
+	}
+	d.config.Endpoints = endpoints
+	d.config.ActiveEndpoint = active
+	d.config.ConfirmRemoteFiles = d.remoteSwitch.Active()
+
+	// Get selected models
+	d.config.DefaultModel = d.selectedModel(d.modelDropdown, d.config.DefaultModel)
+	d.config.UtilityModel = d.selectedModel(d.utilityDropdown, d.config.UtilityModel)
+	d.config.SelfReview = d.selfReviewSwitch.Active()
+	d.config.MultiAgent = d.multiAgentSwitch.Active()
+	d.config.KeepAlive = strings.TrimSpace(d.keepAliveEntry.Text())
+	d.config.CalendarEnabled = d.calendarSwitch.Active()
+	d.config.BuiltinTools = d.toolsSwitch.Active()
+	d.config.ImageOCR = d.ocrSwitch.Active()
+	d.config.RunInBackground = d.backgroundSwitch.Active()
+	d.config.NotebookOutputs = d.notebookSwitch.Active()
+	d.config.RunSnippets = d.snippetsSwitch.Active()
+	d.config.EnabledPlugins = d.enabledPluginNames()
+
+	// Get selected languages
 	langIdx := d.languageDropdown.Selected()
 	if int(langIdx) < len(availableLanguages) {
 		d.config.ResponseLanguage = availableLanguages[langIdx].Code
 	}
+	if idx := d.uiLangDropdown.Selected(); int(idx) < len(availableLanguages) {
+		d.config.InterfaceLanguage = availableLanguages[idx].Code
+	}
 
 	// Get system prompt
 	buffer := d.systemPromptView.Buffer()
 	start, end := buffer.Bounds()
 	d.config.GlobalSystemPrompt = buffer.Text(start, end, false)
 
+	d.config.PromptWarnTokens = d.promptWarnSpin.ValueAsInt()
+	d.config.SummarizeTokens = d.summarizeSpin.ValueAsInt()
+	d.config.MaxMessageLength = d.maxLengthSpin.ValueAsInt()
+	d.config.MaxTableRows = d.tableRowsSpin.ValueAsInt()
+	d.config.AutoArchiveDays = d.archiveDaysSpin.ValueAsInt()
+
+	// A new channel is checked right away
+	channel := string(update.ChannelStable)
+	if d.channelDropdown.Selected() == 1 {
+		channel = string(update.ChannelPrerelease)
+	}
+	if channel != d.config.UpdateChannel {
+		d.config.LastUpdateCheck = time.Time{}
+	}
+	d.config.UpdateCheck = d.updatesSwitch.Active()
+	d.config.UpdateChannel = channel
+
+	d.config.NotesFolder = strings.TrimSpace(d.notesFolderEntry.Text())
+	d.config.ExportFileName = strings.TrimSpace(d.exportNameEntry.Text())
+	d.config.ExportFrontMatter = d.frontMatterCheck.Active()
+	d.config.NotesTags = notes.ParseTags(d.notesTagsEntry.Text())
+	d.config.SpeechCommand = strings.TrimSpace(d.speechEntry.Text())
+	d.config.ReadAloud = d.readAloudSwitch.Active()
+
+	d.saveNotifications()
+	d.config.SetShortcutBindings(d.shortcutsEditor.Bindings())
+
+	d.config.ColorScheme, d.config.AccentColor, d.config.MessageDensity = d.selectedAppearance()
+	d.config.SyntaxStyleLight, d.config.SyntaxStyleDark = d.selectedSyntaxStyles()
+	d.config.PlainTextMessages = d.plainTextSwitch.Active()
+
 	// Save and notify
 	d.config.Save()
 
@@ -211,10 +935,61 @@ func (d *SettingsDialog) onSaveClicked() {
 		d.onSave(d.config)
 	}
 
+	d.saved = true
 	d.Close()
 }
 
+// chooseNotesFolder asks for the notes folder and puts it in the entry.
+func (d *SettingsDialog) chooseNotesFolder() {
+	chooser := gtk.NewFileChooserNative(
+		i18n.T("Choose Notes Folder"),
+		&d.Window.Window,
+		gtk.FileChooserActionSelectFolder,
+		i18n.T("Select"),
+		i18n.T("Cancel"),
+	)
+
+	chooser.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := chooser.File(); file != nil && file.Path() != "" {
+				d.notesFolderEntry.SetText(file.Path())
+			}
+		}
+		chooser.Destroy()
+	})
+
+	chooser.Show()
+}
+
+// selectedModel returns the model chosen in a dropdown created by
+// createModelDropdown, or current if the selection is out of range.
+func (d *SettingsDialog) selectedModel(dropdown *gtk.DropDown, current string) string {
+	idx := dropdown.Selected()
+	if idx == 0 {
+		return ""
+	}
+	if int(idx-1) < len(d.models) {
+		return d.models[idx-1]
+	}
+	return current
+}
+
 // OnSave sets the callback for when settings are saved.
 func (d *SettingsDialog) OnSave(callback func(*config.AppConfig)) {
 	d.onSave = callback
 }
+
+// OnExportAll sets the callback for when "Export All Chats" is clicked.
+func (d *SettingsDialog) OnExportAll(callback func()) {
+	d.onExportAll = callback
+}
+
+// OnImport sets the callback for when "Import Chats" is clicked.
+func (d *SettingsDialog) OnImport(callback func()) {
+	d.onImport = callback
+}
+
+// OnReloadStyle sets the callback for when "Reload Custom Style" is clicked.
+func (d *SettingsDialog) OnReloadStyle(callback func()) {
+	d.onReloadStyle = callback
+}
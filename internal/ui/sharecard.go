@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/diamondburned/gotk4/pkg/cairo"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/gdkpixbuf/v2"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gotk4/pkg/pangocairo"
+
+	"github.com/storo/guanaco/internal/assets"
+)
+
+// Layout of the image cards created by "Share as image", in pixels.
+const (
+	shareCardWidth      = 1080
+	shareCardPadding    = 64
+	shareCardLogoSize   = 56
+	shareCardBubblePad  = 28
+	shareCardMaxPrompt  = 600  // characters of the prompt shown
+	shareCardMaxAnswer  = 2500 // characters of the answer shown
+	shareCardFontFamily = "Cantarell"
+)
+
+// shareCard is an exchange rendered as an image.
+type shareCard struct {
+	Prompt string
+	Answer string
+	Model  string
+}
+
+// clipShareText shortens text to at most max characters, cutting at a word
+// boundary when there is one nearby, so long answers keep the card readable.
+func clipShareText(text string, max int) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+
+	clipped := string([]rune(text)[:max])
+	if i := strings.LastIndexAny(clipped, "\n "); i > len(clipped)*3/4 {
+		clipped = clipped[:i]
+	}
+	return strings.TrimSpace(clipped) + "…"
+}
+
+// shareCardFileName returns the suggested file name for a card created at t.
+func shareCardFileName(t time.Time) string {
+	return "guanaco-" + t.Format("2006-01-02-150405") + ".png"
+}
+
+// renderShareCard draws card as a PNG image at path: the logo and model name
+// on top, the prompt in a tinted bubble and the answer below it.
+func renderShareCard(card shareCard, path string) error {
+	contentWidth := shareCardWidth - 2*shareCardPadding
+
+	// Lay out the text on a scratch surface to measure the card height
+	scratch := cairo.CreateImageSurface(cairo.FormatARGB32, 1, 1)
+	measure := cairo.Create(scratch)
+
+	textX := shareCardLogoSize + 20
+	title := newShareCardLayout(measure, "Bold 26", contentWidth-textX)
+	title.SetText("Guanaco")
+	model := newShareCardLayout(measure, "17", contentWidth-textX)
+	model.SetText(card.Model)
+
+	prompt := newShareCardLayout(measure, "22", contentWidth-2*shareCardBubblePad)
+	prompt.SetText(clipShareText(card.Prompt, shareCardMaxPrompt))
+	answer := newShareCardLayout(measure, "22", contentWidth)
+	answer.SetMarkup(mdRenderer.ToPango(clipShareText(card.Answer, shareCardMaxAnswer)))
+
+	_, titleHeight := title.PixelSize()
+	_, modelHeight := model.PixelSize()
+	_, promptHeight := prompt.PixelSize()
+	_, answerHeight := answer.PixelSize()
+
+	headerHeight := max(shareCardLogoSize, titleHeight+modelHeight)
+	bubbleHeight := promptHeight + 2*shareCardBubblePad
+	height := shareCardPadding + headerHeight + 40 + bubbleHeight + 36 + answerHeight + shareCardPadding
+
+	surface := cairo.CreateImageSurface(cairo.FormatARGB32, shareCardWidth, height)
+	cr := cairo.Create(surface)
+
+	// Background
+	cr.SetSourceRGB(0.98, 0.98, 0.98)
+	cr.Paint()
+
+	// Header: logo, app name and model
+	x, y := float64(shareCardPadding), float64(shareCardPadding)
+	if logo := shareCardLogo(); logo != nil {
+		gdk.CairoSetSourcePixbuf(cr, logo, x, y+float64(headerHeight-logo.Height())/2)
+		cr.Paint()
+	}
+	textTop := y + float64(headerHeight-titleHeight-modelHeight)/2
+	cr.SetSourceRGB(0.14, 0.12, 0.19)
+	showShareCardLayout(cr, title, x+float64(textX), textTop)
+	cr.SetSourceRGB(0.47, 0.46, 0.48)
+	showShareCardLayout(cr, model, x+float64(textX), textTop+float64(titleHeight))
+	y += float64(headerHeight + 40)
+
+	// Prompt bubble
+	roundedRectangle(cr, x, y, float64(contentWidth), float64(bubbleHeight), 20)
+	cr.SetSourceRGBA(0.21, 0.52, 0.89, 0.12)
+	cr.Fill()
+	cr.SetSourceRGB(0.14, 0.12, 0.19)
+	showShareCardLayout(cr, prompt, x+shareCardBubblePad, y+shareCardBubblePad)
+	y += float64(bubbleHeight + 36)
+
+	// Answer
+	showShareCardLayout(cr, answer, x, y)
+
+	if err := surface.WriteToPNG(path); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+	return nil
+}
+
+// newShareCardLayout creates a wrapping text layout of the given width with
+// font, a Pango font description without the family.
+func newShareCardLayout(cr *cairo.Context, font string, width int) *pango.Layout {
+	layout := pangocairo.CreateLayout(cr)
+	layout.SetFontDescription(pango.FontDescriptionFromString(shareCardFontFamily + " " + font))
+	layout.SetWidth(width * pango.SCALE)
+	layout.SetWrap(pango.WrapWordChar)
+	layout.SetSpacing(4 * pango.SCALE)
+	return layout
+}
+
+// showShareCardLayout draws a layout measured on another context at x, y.
+func showShareCardLayout(cr *cairo.Context, layout *pango.Layout, x, y float64) {
+	cr.MoveTo(x, y)
+	pangocairo.UpdateLayout(cr, layout)
+	pangocairo.ShowLayout(cr, layout)
+}
+
+// shareCardLogo renders the embedded logo at the card's logo size, or
+// returns nil if it can't be loaded.
+func shareCardLogo() *gdkpixbuf.Pixbuf {
+	if len(assets.LogoSVG) == 0 {
+		return nil
+	}
+	loader := gdkpixbuf.NewPixbufLoader()
+	loader.SetSize(shareCardLogoSize, shareCardLogoSize)
+	if err := loader.Write(assets.LogoSVG); err != nil {
+		loader.Close()
+		return nil
+	}
+	if err := loader.Close(); err != nil {
+		return nil
+	}
+	return loader.Pixbuf()
+}
+
+// roundedRectangle adds a rectangle with rounded corners to the path.
+func roundedRectangle(cr *cairo.Context, x, y, width, height, radius float64) {
+	const degrees = math.Pi / 180
+	cr.NewSubPath()
+	cr.Arc(x+width-radius, y+radius, radius, -90*degrees, 0)
+	cr.Arc(x+width-radius, y+height-radius, radius, 0, 90*degrees)
+	cr.Arc(x+radius, y+height-radius, radius, 90*degrees, 180*degrees)
+	cr.Arc(x+radius, y+radius, radius, 180*degrees, 270*degrees)
+	cr.ClosePath()
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClipShareText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{name: "short text", text: "  A short answer.\n", max: 100, want: "A short answer."},
+		{name: "cut at a word", text: "The quick brown fox jumps", max: 18, want: "The quick brown…"},
+		{name: "no nearby space", text: "Supercalifragilistic", max: 10, want: "Supercalif…"},
+		{name: "multibyte", text: "ñandú ñandú ñandú", max: 13, want: "ñandú ñandú…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clipShareText(tt.text, tt.max); got != tt.want {
+				t.Errorf("clipShareText(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShareCardFileName(t *testing.T) {
+	got := shareCardFileName(time.Date(2026, 3, 7, 9, 5, 2, 0, time.UTC))
+	if got != "guanaco-2026-03-07-090502.png" {
+		t.Errorf("shareCardFileName() = %q", got)
+	}
+	if strings.ContainsAny(got, ": ") {
+		t.Errorf("shareCardFileName() = %q, want no spaces or colons", got)
+	}
+}
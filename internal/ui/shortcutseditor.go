@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// shortcutTitle returns the name of a shortcut action shown to the user.
+func shortcutTitle(action string) string {
+	switch action {
+	case config.ShortcutNewChat:
+		return i18n.T("New chat")
+	case config.ShortcutNewWindow:
+		return i18n.T("New window")
+	case config.ShortcutSearch:
+		return i18n.T("Search chats")
+	case config.ShortcutSettings:
+		return i18n.T("Open settings")
+	case config.ShortcutCloseWindow:
+		return i18n.T("Close window")
+	case config.ShortcutStopStreaming:
+		return i18n.T("Stop response")
+	case config.ShortcutQuit:
+		return i18n.T("Quit completely")
+	default:
+		return action
+	}
+}
+
+// shortcutRow holds the widgets of one action in the shortcuts editor.
+type shortcutRow struct {
+	action string
+	label  *gtk.ShortcutLabel
+	change *gtk.Button
+}
+
+// ShortcutsEditor edits the keyboard shortcut of each action. A shortcut is
+// changed by clicking its button and pressing the new keys; Escape cancels
+// and Backspace removes the shortcut.
+type ShortcutsEditor struct {
+	*gtk.Box
+
+	rows      []*shortcutRow
+	message   *gtk.Label
+	bindings  map[string]string
+	recording *shortcutRow
+}
+
+// NewShortcutsEditor creates an editor for the given bindings, from action
+// to accelerator.
+func NewShortcutsEditor(bindings map[string]string) *ShortcutsEditor {
+	e := &ShortcutsEditor{bindings: make(map[string]string, len(bindings))}
+	for action, accel := range bindings {
+		e.bindings[action] = accel
+	}
+
+	e.Box = gtk.NewBox(gtk.OrientationVertical, 12)
+
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+	for _, action := range config.ShortcutActions {
+		list.Append(e.createRow(action))
+	}
+	e.Append(list)
+
+	e.message = gtk.NewLabel("")
+	e.message.SetXAlign(0)
+	e.message.SetWrap(true)
+	e.message.AddCSSClass("caption")
+	e.message.SetVisible(false)
+	e.Append(e.message)
+
+	resetBtn := gtk.NewButtonWithLabel(i18n.T("Reset All"))
+	resetBtn.SetHAlign(gtk.AlignStart)
+	resetBtn.ConnectClicked(func() {
+		e.stopRecording()
+		for _, row := range e.rows {
+			e.setBinding(row, config.DefaultShortcuts[row.action])
+		}
+		e.showMessage("", false)
+	})
+	e.Append(resetBtn)
+
+	return e
+}
+
+// createRow creates the row showing and changing the shortcut of action.
+func (e *ShortcutsEditor) createRow(action string) *gtk.Box {
+	row := &shortcutRow{action: action}
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	box.SetMarginTop(8)
+	box.SetMarginBottom(8)
+	box.SetMarginStart(12)
+	box.SetMarginEnd(8)
+
+	title := gtk.NewLabel(shortcutTitle(action))
+	title.SetXAlign(0)
+	title.SetHExpand(true)
+	box.Append(title)
+
+	row.label = gtk.NewShortcutLabel(e.bindings[action])
+	row.label.SetDisabledText(i18n.T("Disabled"))
+	row.label.SetVAlign(gtk.AlignCenter)
+	box.Append(row.label)
+
+	row.change = gtk.NewButtonWithLabel(i18n.T("Change"))
+	row.change.SetVAlign(gtk.AlignCenter)
+	row.change.AddCSSClass("flat")
+	row.change.ConnectClicked(func() {
+		if e.recording == row {
+			e.stopRecording()
+		} else {
+			e.startRecording(row)
+		}
+	})
+	keys := gtk.NewEventControllerKey()
+	keys.SetPropagationPhase(gtk.PhaseCapture)
+	keys.ConnectKeyPressed(func(keyval, keycode uint, state gdk.ModifierType) bool {
+		if e.recording != row {
+			return false
+		}
+		return e.record(row, keyval, state)
+	})
+	row.change.AddController(keys)
+	box.Append(row.change)
+
+	resetBtn := gtk.NewButton()
+	resetBtn.SetIconName("edit-undo-symbolic")
+	resetBtn.SetTooltipText(i18n.T("Reset to default"))
+	resetBtn.SetVAlign(gtk.AlignCenter)
+	resetBtn.AddCSSClass("flat")
+	resetBtn.ConnectClicked(func() {
+		e.stopRecording()
+		if other := config.ShortcutConflict(e.bindings, action, config.DefaultShortcuts[action]); other != "" {
+			e.showConflict(other)
+			return
+		}
+		e.setBinding(row, config.DefaultShortcuts[action])
+		e.showMessage("", false)
+	})
+	box.Append(resetBtn)
+
+	e.rows = append(e.rows, row)
+	return box
+}
+
+// startRecording waits for the keys of the new shortcut of row.
+func (e *ShortcutsEditor) startRecording(row *shortcutRow) {
+	e.stopRecording()
+	e.recording = row
+	row.change.SetLabel(i18n.T("Press keys…"))
+	row.change.AddCSSClass("suggested-action")
+	row.change.GrabFocus()
+	e.showMessage(i18n.T("Press the new shortcut, Escape to cancel or Backspace to remove it"), false)
+}
+
+// stopRecording stops waiting for a new shortcut.
+func (e *ShortcutsEditor) stopRecording() {
+	if e.recording == nil {
+		return
+	}
+	e.recording.change.SetLabel(i18n.T("Change"))
+	e.recording.change.RemoveCSSClass("suggested-action")
+	e.recording = nil
+}
+
+// record handles a key pressed while recording the shortcut of row,
+// returning whether the key was used.
+func (e *ShortcutsEditor) record(row *shortcutRow, keyval uint, state gdk.ModifierType) bool {
+	mods := state & gtk.AcceleratorGetDefaultModMask()
+	keyval = gdk.KeyvalToLower(keyval)
+
+	switch {
+	case keyval == gdk.KEY_Escape && mods == 0:
+		e.stopRecording()
+		e.showMessage("", false)
+		return true
+	case keyval == gdk.KEY_BackSpace && mods == 0:
+		e.stopRecording()
+		e.setBinding(row, "")
+		e.showMessage("", false)
+		return true
+	case !gtk.AcceleratorValid(keyval, mods):
+		// A modifier on its own: wait for the rest
+		return true
+	case mods&^gdk.ShiftMask == 0 && gdk.KeyvalToUnicode(keyval) != 0:
+		e.showMessage(i18n.T("Use Ctrl or Alt with letters, numbers and symbols"), true)
+		return true
+	}
+
+	accel := gtk.AcceleratorName(keyval, mods)
+	if other := config.ShortcutConflict(e.bindings, row.action, accel); other != "" {
+		e.showConflict(other)
+		return true
+	}
+
+	e.stopRecording()
+	e.setBinding(row, accel)
+	e.showMessage("", false)
+	return true
+}
+
+// setBinding binds accel to the action of row.
+func (e *ShortcutsEditor) setBinding(row *shortcutRow, accel string) {
+	e.bindings[row.action] = accel
+	row.label.SetAccelerator(accel)
+}
+
+// showConflict reports that a shortcut is already used by another action.
+func (e *ShortcutsEditor) showConflict(other string) {
+	e.showMessage(fmt.Sprintf(i18n.T("This shortcut is already used by “%s”"), shortcutTitle(other)), true)
+}
+
+// showMessage shows a hint or an error under the list, or hides it when
+// text is empty.
+func (e *ShortcutsEditor) showMessage(text string, isError bool) {
+	e.message.SetText(text)
+	e.message.SetVisible(text != "")
+	if isError {
+		e.message.RemoveCSSClass("dim-label")
+		e.message.AddCSSClass("error")
+	} else {
+		e.message.RemoveCSSClass("error")
+		e.message.AddCSSClass("dim-label")
+	}
+}
+
+// Bindings returns the shortcut of each action, "" for the ones removed.
+func (e *ShortcutsEditor) Bindings() map[string]string {
+	bindings := make(map[string]string, len(e.bindings))
+	for action, accel := range e.bindings {
+		bindings[action] = accel
+	}
+	return bindings
+}
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/storo/guanaco/internal/config"
+)
+
+func TestShortcutTitle(t *testing.T) {
+	seen := make(map[string]string)
+	for _, action := range config.ShortcutActions {
+		title := shortcutTitle(action)
+		if title == "" || title == action {
+			t.Errorf("shortcutTitle(%q) = %q, want a readable name", action, title)
+		}
+		if other, ok := seen[title]; ok {
+			t.Errorf("actions %q and %q have the same title %q", other, action, title)
+		}
+		seen[title] = action
+	}
+
+	if got := shortcutTitle("unknown"); got != "unknown" {
+		t.Errorf("shortcutTitle(unknown) = %q, want the action name", got)
+	}
+}
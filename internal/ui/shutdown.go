@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Closing a window while a response streams would lose the response, as it
+// is saved once the stream ends. The user is asked first (see confirmClose);
+// when they choose to close, the window is hidden, the stream is stopped and
+// the window closes when the partial response has been saved, or after
+// shutdownTimeout. When the last window closes, messages
+// kept in memory after a failed write get a last chance to be saved, the
+// window size is kept for the next start, and the database is closed and
+// the log flushed.
+
+// shutdownTimeout is how long a closing window waits for its response to
+// stop and be saved.
+const shutdownTimeout = 3 * time.Second
+
+// shutdownPoll is how often a closing window checks whether its response
+// has stopped.
+const shutdownPoll = 50 // milliseconds
+
+// setupCleanup registers cleanup handlers for window close.
+func (w *MainWindow) setupCleanup() {
+	w.ConnectCloseRequest(func() bool {
+		if w.closeReady {
+			return false
+		}
+		if w.closing || w.inBackground {
+			return true
+		}
+		if w.chatView.IsStreaming() || w.downloadingModel() != "" {
+			w.confirmClose()
+			return true // Closed once the user chooses
+		}
+		w.cleanup()
+		return false // Allow window to close
+	})
+}
+
+// stopAndClose hides the window, stops its response and download, and
+// closes the window once the response has been saved or shutdownTimeout
+// has passed.
+func (w *MainWindow) stopAndClose() {
+	if w.closing {
+		return
+	}
+	w.closing = true
+	w.SetVisible(false)
+	if w.modelDialog != nil {
+		w.modelDialog.CancelDownload()
+	}
+	w.chatView.StopStreaming()
+	logger.Info("Stopping response before closing")
+
+	deadline := time.Now().Add(shutdownTimeout)
+	glib.TimeoutAdd(shutdownPoll, func() bool {
+		if w.chatView.IsStreaming() && time.Now().Before(deadline) {
+			return true
+		}
+		if w.chatView.IsStreaming() {
+			logger.Warn("Response still streaming, closing anyway", "timeout", shutdownTimeout)
+		}
+		w.cleanup()
+		w.closeReady = true
+		w.Close()
+		return false
+	})
+}
+
+// cleanup releases all resources before window closes. The database is
+// closed with the last window.
+func (w *MainWindow) cleanup() {
+	logger.Info("Cleaning up resources")
+	w.chatView.StopReading()
+	w.chatView.Destroy()
+	if w.db != nil {
+		w.chatView.SaveDraft()
+	}
+	w.loadedModels.Stop()
+	last := w.app.removeWindow(w)
+	if w.db != nil {
+		w.sidebar.FinishDeletes()
+	}
+	if !last {
+		w.app.chatsChanged(w)
+		return
+	}
+	w.saveWindowSize()
+	if w.app.keepRunning() {
+		w.app.holdInBackground()
+		if err := logger.Sync(); err != nil {
+			logger.Error("Failed to flush log", "error", err)
+		}
+		return
+	}
+
+	w.app.cancelStorageRetry()
+	if w.db != nil {
+		w.flushPending()
+		// Close waits for the statements already running, such as a title
+		// being saved in the background
+		if err := w.db.Close(); err != nil {
+			logger.Error("Failed to close database", "error", err)
+		} else {
+			logger.Info("Database closed")
+		}
+	}
+	if err := logger.Sync(); err != nil {
+		logger.Error("Failed to flush log", "error", err)
+	}
+}
+
+// flushPending tries once more to save the messages kept in memory after a
+// failed write, which are lost otherwise.
+func (w *MainWindow) flushPending() {
+	if w.db.PendingCount() == 0 {
+		return
+	}
+	err := w.db.Reconnect()
+	if err == nil {
+		_, err = w.db.FlushPending()
+	}
+	if err != nil {
+		logger.Error("Unsaved messages lost on close", "pending", w.db.PendingCount(), "kind", store.ClassifyError(err), "error", err)
+		return
+	}
+	logger.Info("Unsaved messages saved on close")
+}
+
+// saveWindowSize keeps the size of the window in the settings, for the
+// first window of the next start.
+func (w *MainWindow) saveWindowSize() {
+	if w.appConfig == nil {
+		return
+	}
+	w.appConfig.WindowMaximized = w.IsMaximized()
+	if !w.appConfig.WindowMaximized {
+		w.appConfig.WindowWidth, w.appConfig.WindowHeight = w.DefaultSize()
+	}
+	if err := w.appConfig.Save(); err != nil {
+		logger.Error("Failed to save window size", "error", err)
+	}
+}
+
+// restoreWindowSize gives the window the size saved when the last window
+// was closed.
+func (w *MainWindow) restoreWindowSize() {
+	if w.appConfig.WindowWidth > 0 && w.appConfig.WindowHeight > 0 {
+		w.SetDefaultSize(w.appConfig.WindowWidth, w.appConfig.WindowHeight)
+	}
+	if w.appConfig.WindowMaximized {
+		w.Maximize()
+	}
+}
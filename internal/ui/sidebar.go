@@ -4,6 +4,8 @@ import (
 	"strings"
 
 	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 
 	"github.com/storo/guanaco/internal/i18n"
@@ -19,8 +21,30 @@ type Sidebar struct {
 	scrolled      *gtk.ScrolledWindow
 	emptyState    *gtk.Box
 	newChatButton *gtk.Button
+	searchBar     *gtk.SearchBar
+	searchEntry   *gtk.SearchEntry
 	chats         []*store.Chat
 
+	// Tags of the chats and the filter bar that shows them
+	tagBar    *gtk.ScrolledWindow
+	tags      []*store.Tag
+	chatTags  map[int64][]*store.Tag
+	tagFilter int64 // ID of the tag whose chats are shown, 0 for all
+
+	// Archived chats are hidden unless the button below the list shows them
+	archiveButton *gtk.Button
+	showArchived  bool
+
+	// Chats with unsent input, marked with a badge
+	drafts map[int64]bool
+
+	// Chats removed from the list whose undo toast is still shown
+	pendingDeletes map[int64]bool
+
+	// Start renaming each chat in the list, keyed by chat ID
+	renamers map[int64]func()
+	renaming bool
+
 	// Dependencies
 	db     *store.DB
 	window *gtk.Window
@@ -28,13 +52,31 @@ type Sidebar struct {
 	// Callbacks
 	onChatSelected func(*store.Chat)
 	onChatDeleted  func(int64)
+	onExportChat   func(*store.Chat)
+	onSendToNotes  func(*store.Chat)
+	onShowStats    func(*store.Chat)
+	onUsage        func()
 	onSettings     func()
+	onToast        func(*adw.Toast)
+	onChatRenamed  func(*store.Chat)
+	onOpenWindow   func(*store.Chat)
+
+	onChatLabelChanged func(*store.Chat)
+}
+
+// rowMenuItem is an entry in a context menu of the sidebar.
+type rowMenuItem struct {
+	label    string
+	activate func()
 }
 
 // NewSidebar creates a new sidebar.
 func NewSidebar(db *store.DB) *Sidebar {
 	sb := &Sidebar{
-		db: db,
+		db:             db,
+		pendingDeletes: make(map[int64]bool),
+		renamers:       make(map[int64]func()),
+		chatTags:       make(map[int64][]*store.Tag),
 	}
 
 	sb.Box = gtk.NewBox(gtk.OrientationVertical, 0)
@@ -88,12 +130,15 @@ func (sb *Sidebar) setupUI() {
 			}
 		}
 	})
+	sb.Append(sb.setupKeyboardNavigation())
+	sb.Append(sb.setupTagBar())
 
 	sb.scrolled = gtk.NewScrolledWindow()
 	sb.scrolled.SetChild(sb.listBox)
 	sb.scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
 	sb.scrolled.SetVExpand(true)
 	sb.Append(sb.scrolled)
+	sb.Append(sb.setupArchiveButton())
 
 	// Empty state (hidden by default)
 	sb.emptyState = gtk.NewBox(gtk.OrientationVertical, 8)
@@ -129,6 +174,17 @@ func (sb *Sidebar) setupUI() {
 	footer.SetMarginStart(8)
 	footer.SetMarginEnd(8)
 
+	// Token usage button
+	usageBtn := gtk.NewButton()
+	usageBtn.SetChild(sb.createFooterButtonContent("utilities-system-monitor-symbolic", i18n.T("Token Usage")))
+	usageBtn.AddCSSClass("flat")
+	usageBtn.ConnectClicked(func() {
+		if sb.onUsage != nil {
+			sb.onUsage()
+		}
+	})
+	footer.Append(usageBtn)
+
 	// Settings button
 	settingsBtn := gtk.NewButton()
 	settingsBtn.SetChild(sb.createFooterButtonContent("preferences-system-symbolic", i18n.T("Settings")))
@@ -169,7 +225,17 @@ func (sb *Sidebar) LoadChats() {
 		return
 	}
 
-	sb.setChats(chats)
+	// Hide chats waiting to be deleted
+	kept := chats[:0]
+	for _, chat := range chats {
+		if !sb.pendingDeletes[chat.ID] {
+			kept = append(kept, chat)
+		}
+	}
+
+	sb.loadTags()
+	sb.loadDrafts()
+	sb.setChats(kept)
 }
 
 func (sb *Sidebar) setChats(chats []*store.Chat) {
@@ -182,32 +248,111 @@ func (sb *Sidebar) setChats(chats []*store.Chat) {
 		sb.listBox.Remove(row)
 	}
 
+	// Branches are listed under the chat they came from
+	chats, depths := groupBranches(chats)
 	sb.chats = chats
+	sb.renamers = make(map[int64]func())
+	sb.renaming = false
 
 	// Show/hide empty state
-	hasChats := len(chats) > 0
-	sb.scrolled.SetVisible(hasChats)
-	sb.emptyState.SetVisible(!hasChats)
+	sb.updateEmptyState()
+	sb.updateArchiveButton()
 
 	// Add chat rows
-	for _, chat := range chats {
-		row := sb.createChatRow(chat)
+	for i, chat := range chats {
+		row := sb.createChatRow(chat, depths[i])
 		sb.listBox.Append(row)
 	}
 }
 
-func (sb *Sidebar) createChatRow(chat *store.Chat) *gtk.ListBoxRow {
+// branchIndent is the extra start margin of a branch row per nesting level.
+const branchIndent = 16
+
+// groupBranches orders chats so that each branch follows the chat it was
+// branched from, and returns the nesting depth of each chat in the new
+// order. Siblings keep their relative order. Chats whose parent is not in
+// the list are shown at the top level.
+func groupBranches(chats []*store.Chat) ([]*store.Chat, []int) {
+	present := make(map[int64]bool, len(chats))
+	for _, chat := range chats {
+		present[chat.ID] = true
+	}
+
+	var roots []*store.Chat
+	children := make(map[int64][]*store.Chat)
+	for _, chat := range chats {
+		if chat.ParentID != 0 && chat.ParentID != chat.ID && present[chat.ParentID] {
+			children[chat.ParentID] = append(children[chat.ParentID], chat)
+		} else {
+			roots = append(roots, chat)
+		}
+	}
+
+	ordered := make([]*store.Chat, 0, len(chats))
+	depths := make([]int, 0, len(chats))
+	visited := make(map[int64]bool, len(chats))
+
+	var visit func(chat *store.Chat, depth int)
+	visit = func(chat *store.Chat, depth int) {
+		if visited[chat.ID] {
+			return
+		}
+		visited[chat.ID] = true
+		ordered = append(ordered, chat)
+		depths = append(depths, depth)
+		for _, child := range children[chat.ID] {
+			visit(child, depth+1)
+		}
+	}
+	for _, chat := range roots {
+		visit(chat, 0)
+	}
+
+	// Chats in a parent cycle are never reached from a root
+	for _, chat := range chats {
+		visit(chat, 0)
+	}
+
+	return ordered, depths
+}
+
+func (sb *Sidebar) createChatRow(chat *store.Chat, depth int) *gtk.ListBoxRow {
 	row := gtk.NewListBoxRow()
 
 	box := gtk.NewBox(gtk.OrientationVertical, 2)
 	box.SetMarginTop(8)
 	box.SetMarginBottom(8)
-	box.SetMarginStart(12)
+	box.SetMarginStart(12 + depth*branchIndent)
 	box.SetMarginEnd(8)
 
 	// Header with title and delete button
 	headerBox := gtk.NewBox(gtk.OrientationHorizontal, 4)
 
+	// Branches are marked with an icon before the title
+	if depth > 0 {
+		branchIcon := gtk.NewImageFromIconName("mail-forward-symbolic")
+		branchIcon.AddCSSClass("dim-label")
+		branchIcon.SetTooltipText(i18n.T("Branch"))
+		headerBox.Append(branchIcon)
+	}
+
+	// Archived chats are dimmed, when shown
+	if chat.Archived {
+		archivedIcon := gtk.NewImageFromIconName("folder-symbolic")
+		archivedIcon.AddCSSClass("dim-label")
+		archivedIcon.SetTooltipText(i18n.T("Archived"))
+		headerBox.Append(archivedIcon)
+		box.SetOpacity(0.7)
+	}
+
+	// Icon and color the user marked the chat with
+	if chat.Icon != "" {
+		headerBox.Append(gtk.NewLabel(chat.Icon))
+	}
+	if class := chatColorClass(chat.Color); class != "" {
+		row.AddCSSClass(class)
+	}
+
 	// Title
 	titleLabel := gtk.NewLabel(chat.Title)
 	titleLabel.SetXAlign(0)
@@ -216,6 +361,15 @@ func (sb *Sidebar) createChatRow(chat *store.Chat) *gtk.ListBoxRow {
 	titleLabel.AddCSSClass("heading")
 	headerBox.Append(titleLabel)
 
+	// Badge of a chat with unsent input
+	if sb.drafts[chat.ID] {
+		draftLabel := gtk.NewLabel(i18n.T("Draft"))
+		draftLabel.AddCSSClass("accent")
+		draftLabel.AddCSSClass("caption")
+		draftLabel.SetTooltipText(i18n.T("Has unsent text or attachments"))
+		headerBox.Append(draftLabel)
+	}
+
 	// Delete button
 	deleteBtn := gtk.NewButton()
 	deleteBtn.SetIconName("user-trash-symbolic")
@@ -234,7 +388,7 @@ func (sb *Sidebar) createChatRow(chat *store.Chat) *gtk.ListBoxRow {
 
 	// Preview of last message
 	if sb.db != nil {
-		if messages, err := sb.db.GetMessages(chat.ID); err == nil && len(messages) > 0 {
+		if messages, err := sb.db.GetMessagesPage(chat.ID, 1, 0); err == nil && len(messages) > 0 {
 			lastMsg := messages[len(messages)-1]
 			preview := truncatePreview(lastMsg.Content, 40)
 
@@ -247,6 +401,16 @@ func (sb *Sidebar) createChatRow(chat *store.Chat) *gtk.ListBoxRow {
 		}
 	}
 
+	// Tags of the chat
+	if tags := sb.chatTags[chat.ID]; len(tags) > 0 {
+		tagsLabel := gtk.NewLabel(formatTags(tags))
+		tagsLabel.SetXAlign(0)
+		tagsLabel.SetEllipsize(3) // PANGO_ELLIPSIZE_END
+		tagsLabel.AddCSSClass("accent")
+		tagsLabel.AddCSSClass("caption")
+		box.Append(tagsLabel)
+	}
+
 	// Model subtitle (smaller, dimmer)
 	modelLabel := gtk.NewLabel(chat.Model)
 	modelLabel.SetXAlign(0)
@@ -256,9 +420,113 @@ func (sb *Sidebar) createChatRow(chat *store.Chat) *gtk.ListBoxRow {
 	box.Append(modelLabel)
 
 	row.SetChild(box)
+
+	// Double-click the title, or use the context menu, to rename the chat
+	rename := func() {
+		sb.startRename(chat, headerBox, titleLabel)
+	}
+	sb.renamers[chat.ID] = rename
+	doubleClick := gtk.NewGestureClick()
+	doubleClick.ConnectPressed(func(nPress int, x, y float64) {
+		if nPress == 2 {
+			rename()
+		}
+	})
+	titleLabel.AddController(doubleClick)
+
+	// Context menu on right click
+	rightClick := gtk.NewGestureClick()
+	rightClick.SetButton(3) // GDK_BUTTON_SECONDARY
+	rightClick.ConnectPressed(func(nPress int, x, y float64) {
+		sb.showChatMenu(row, chat, rename, x, y)
+	})
+	row.AddController(rightClick)
+
 	return row
 }
 
+// showChatMenu shows the context menu for a chat row at the given position.
+func (sb *Sidebar) showChatMenu(row *gtk.ListBoxRow, chat *store.Chat, rename func(), x, y float64) {
+	items := []rowMenuItem{
+		{i18n.T("Open in New Window"), func() {
+			if sb.onOpenWindow != nil {
+				sb.onOpenWindow(chat)
+			}
+		}},
+		{i18n.T("Rename…"), rename},
+		{i18n.T("Tags…"), func() {
+			sb.editChatTags(chat)
+		}},
+		{i18n.T("Icon and Color…"), func() {
+			sb.editChatLabel(chat)
+		}},
+		{i18n.T("Export…"), func() {
+			if sb.onExportChat != nil {
+				sb.onExportChat(chat)
+			}
+		}},
+		{i18n.T("Send to Notes"), func() {
+			if sb.onSendToNotes != nil {
+				sb.onSendToNotes(chat)
+			}
+		}},
+		{i18n.T("Statistics"), func() {
+			if sb.onShowStats != nil {
+				sb.onShowStats(chat)
+			}
+		}},
+	}
+	if chat.Archived {
+		items = append(items, rowMenuItem{i18n.T("Unarchive"), func() {
+			sb.setArchived([]*store.Chat{chat}, false)
+		}})
+	} else {
+		items = append(items, rowMenuItem{i18n.T("Archive"), func() {
+			sb.setArchived([]*store.Chat{chat}, true)
+		}})
+	}
+	items = append(items, rowMenuItem{i18n.T("Delete"), func() {
+		sb.deleteChat(chat.ID)
+	}})
+	showMenu(row, items, x, y)
+}
+
+// showMenu shows a context menu with items on parent at the given position.
+func showMenu(parent gtk.Widgetter, items []rowMenuItem, x, y float64) {
+	popover := gtk.NewPopover()
+	popover.SetHasArrow(false)
+	popover.SetHAlign(gtk.AlignStart)
+
+	menuBox := gtk.NewBox(gtk.OrientationVertical, 0)
+	for _, item := range items {
+		item := item // capture for closure
+
+		label := gtk.NewLabel(item.label)
+		label.SetXAlign(0)
+
+		btn := gtk.NewButton()
+		btn.SetChild(label)
+		btn.AddCSSClass("flat")
+		btn.ConnectClicked(func() {
+			popover.Popdown()
+			item.activate()
+		})
+		menuBox.Append(btn)
+	}
+	popover.SetChild(menuBox)
+
+	rect := gdk.NewRectangle(int(x), int(y), 1, 1)
+	popover.SetPointingTo(&rect)
+	popover.SetParent(parent)
+	popover.ConnectClosed(func() {
+		// Unparent once the close animation has been handled
+		glib.IdleAdd(func() {
+			popover.Unparent()
+		})
+	})
+	popover.Popup()
+}
+
 // truncatePreview truncates text for preview display.
 func truncatePreview(s string, maxLen int) string {
 	// Remove newlines for preview
@@ -281,7 +549,7 @@ func (sb *Sidebar) AddChat(chat *store.Chat) {
 	}
 
 	sb.chats = append([]*store.Chat{chat}, sb.chats...)
-	row := sb.createChatRow(chat)
+	row := sb.createChatRow(chat, 0)
 	sb.listBox.Prepend(row)
 }
 
@@ -359,6 +627,34 @@ func (sb *Sidebar) confirmDeleteChat(chatID int64) {
 	sb.Refresh()
 }
 
+// OnOpenInNewWindow sets the callback for when a chat is opened in a new
+// window from its context menu.
+func (sb *Sidebar) OnOpenInNewWindow(callback func(*store.Chat)) {
+	sb.onOpenWindow = callback
+}
+
+// OnExportChat sets the callback for when export is chosen from a chat's context menu.
+func (sb *Sidebar) OnExportChat(callback func(*store.Chat)) {
+	sb.onExportChat = callback
+}
+
+// OnSendToNotes sets the callback for when a chat is sent to the notes
+// folder from its context menu.
+func (sb *Sidebar) OnSendToNotes(callback func(*store.Chat)) {
+	sb.onSendToNotes = callback
+}
+
+// OnShowStats sets the callback for when the statistics of a chat are
+// requested from its context menu.
+func (sb *Sidebar) OnShowStats(callback func(*store.Chat)) {
+	sb.onShowStats = callback
+}
+
+// OnUsage sets the callback for when the token usage button is clicked.
+func (sb *Sidebar) OnUsage(callback func()) {
+	sb.onUsage = callback
+}
+
 // OnSettings sets the callback for when the settings button is clicked.
 func (sb *Sidebar) OnSettings(callback func()) {
 	sb.onSettings = callback
@@ -1,7 +1,11 @@
 package ui
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/storo/guanaco/internal/store"
 )
 
 func TestTruncatePreview(t *testing.T) {
@@ -70,3 +74,80 @@ func TestTruncatePreview(t *testing.T) {
 		})
 	}
 }
+
+func TestGroupBranches(t *testing.T) {
+	chat := func(id, parentID int64) *store.Chat {
+		return &store.Chat{ID: id, ParentID: parentID}
+	}
+
+	tests := []struct {
+		name       string
+		chats      []*store.Chat
+		wantIDs    []int64
+		wantDepths []int
+	}{
+		{
+			name:       "no branches",
+			chats:      []*store.Chat{chat(3, 0), chat(2, 0), chat(1, 0)},
+			wantIDs:    []int64{3, 2, 1},
+			wantDepths: []int{0, 0, 0},
+		},
+		{
+			name:       "branch listed under its parent",
+			chats:      []*store.Chat{chat(4, 1), chat(3, 0), chat(2, 0), chat(1, 0)},
+			wantIDs:    []int64{3, 2, 1, 4},
+			wantDepths: []int{0, 0, 0, 1},
+		},
+		{
+			name:       "nested branches keep their order",
+			chats:      []*store.Chat{chat(5, 4), chat(4, 1), chat(3, 1), chat(1, 0)},
+			wantIDs:    []int64{1, 4, 5, 3},
+			wantDepths: []int{0, 1, 2, 1},
+		},
+		{
+			name:       "missing parent shown at the top level",
+			chats:      []*store.Chat{chat(4, 9), chat(1, 0)},
+			wantIDs:    []int64{4, 1},
+			wantDepths: []int{0, 0},
+		},
+		{
+			name:       "parent cycle",
+			chats:      []*store.Chat{chat(1, 2), chat(2, 1)},
+			wantIDs:    []int64{1, 2},
+			wantDepths: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, depths := groupBranches(tt.chats)
+			ids := make([]int64, len(got))
+			for i, c := range got {
+				ids[i] = c.ID
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || !reflect.DeepEqual(depths, tt.wantDepths) {
+				t.Errorf("groupBranches() = %v %v, want %v %v", ids, depths, tt.wantIDs, tt.wantDepths)
+			}
+		})
+	}
+}
+
+func TestCleanChatTitle(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{input: "Trip to Lisbon", want: "Trip to Lisbon", ok: true},
+		{input: "  Trip\n to\tLisbon  ", want: "Trip to Lisbon", ok: true},
+		{input: " \n ", want: "", ok: false},
+		{input: strings.Repeat("é", maxChatTitleLength+5), want: strings.Repeat("é", maxChatTitleLength), ok: true},
+	}
+
+	for _, tt := range tests {
+		got, ok := cleanChatTitle(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("cleanChatTitle(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Chats can be archived to keep the list on current work, by hand from the
+// context menu or at startup once they go unused for the number of days
+// set in the settings. Archived chats stay hidden until the button below
+// the list shows them, and a chat is unarchived when a message is sent in
+// it.
+
+// setupArchiveButton creates the button that shows or hides the archived
+// chats. It is returned for the caller to place below the list.
+func (sb *Sidebar) setupArchiveButton() *gtk.Button {
+	sb.archiveButton = gtk.NewButton()
+	sb.archiveButton.AddCSSClass("flat")
+	sb.archiveButton.AddCSSClass("caption")
+	sb.archiveButton.SetMarginStart(8)
+	sb.archiveButton.SetMarginEnd(8)
+	sb.archiveButton.SetVisible(false)
+	sb.archiveButton.ConnectClicked(func() {
+		sb.showArchived = !sb.showArchived
+		sb.listBox.InvalidateFilter()
+		sb.updateArchiveButton()
+	})
+	return sb.archiveButton
+}
+
+// updateArchiveButton labels the archive button with the number of
+// archived chats, and hides it when there are none.
+func (sb *Sidebar) updateArchiveButton() {
+	archived := archivedCount(sb.chats)
+	if archived == 0 {
+		sb.showArchived = false
+	}
+	sb.archiveButton.SetVisible(archived > 0)
+
+	if sb.showArchived {
+		sb.archiveButton.SetLabel(i18n.T("Hide archived chats"))
+	} else if archived == 1 {
+		sb.archiveButton.SetLabel(i18n.T("Show 1 archived chat"))
+	} else {
+		sb.archiveButton.SetLabel(fmt.Sprintf(i18n.T("Show %d archived chats"), archived))
+	}
+}
+
+// archivedCount returns how many of chats are archived.
+func archivedCount(chats []*store.Chat) int {
+	count := 0
+	for _, chat := range chats {
+		if chat.Archived {
+			count++
+		}
+	}
+	return count
+}
+
+// archiveSummary returns the text of the toast shown after chats unused
+// for days were archived.
+func archiveSummary(chats []*store.Chat, days int) string {
+	if len(chats) == 1 {
+		return fmt.Sprintf(i18n.T("Archived \"%s\", unused for %d days"), chats[0].Title, days)
+	}
+	return fmt.Sprintf(i18n.T("Archived %d chats unused for %d days"), len(chats), days)
+}
+
+// setArchived archives or unarchives chats and refreshes the list.
+func (sb *Sidebar) setArchived(chats []*store.Chat, archived bool) {
+	if sb.db == nil {
+		return
+	}
+	ids := make([]int64, len(chats))
+	for i, chat := range chats {
+		ids[i] = chat.ID
+	}
+	if err := sb.db.SetChatsArchived(ids, archived); err != nil {
+		logger.Error("Failed to archive chats", "chatIDs", ids, "archived", archived, "error", err)
+		return
+	}
+	logger.Info("Chats archived", "chatIDs", ids, "archived", archived)
+	sb.Refresh()
+}
+
+// ArchiveInactive archives the chats that have gone unused for days, and
+// shows a toast saying which, with a button to unarchive them.
+func (sb *Sidebar) ArchiveInactive(days int) {
+	if sb.db == nil || days <= 0 {
+		return
+	}
+
+	chats, err := sb.db.InactiveChats(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.Error("Failed to find inactive chats", "error", err)
+		return
+	}
+	if len(chats) == 0 {
+		return
+	}
+	sb.setArchived(chats, true)
+
+	if sb.onToast == nil {
+		return
+	}
+	toast := adw.NewToast(archiveSummary(chats, days))
+	toast.SetTimeout(10)
+	toast.SetButtonLabel(i18n.T("Unarchive"))
+	toast.ConnectButtonClicked(func() {
+		sb.setArchived(chats, false)
+	})
+	sb.onToast(toast)
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestArchiveSummary(t *testing.T) {
+	recipes := &store.Chat{Title: "Recipes", Archived: true}
+	trip := &store.Chat{Title: "Trip", Archived: true}
+	work := &store.Chat{Title: "Work"}
+
+	if got := archivedCount([]*store.Chat{recipes, work, trip}); got != 2 {
+		t.Errorf("archivedCount() = %d, want 2", got)
+	}
+	if got, want := archiveSummary([]*store.Chat{recipes}, 30), `Archived "Recipes", unused for 30 days`; got != want {
+		t.Errorf("archiveSummary(one) = %q, want %q", got, want)
+	}
+	if got, want := archiveSummary([]*store.Chat{recipes, trip}, 7), "Archived 2 chats unused for 7 days"; got != want {
+		t.Errorf("archiveSummary(two) = %q, want %q", got, want)
+	}
+}
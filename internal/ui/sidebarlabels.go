@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Chats can be marked with an emoji, shown before their title in the chat
+// list and in the window title, and with one of the accent colors, shown
+// as a stripe along their row, so that they stand out when scanning a long
+// list. Both are set from the context menu of the chat.
+
+// maxIconLength is the longest icon, in characters. Emoji made of several
+// joined characters, such as flags and families, take a few.
+const maxIconLength = 8
+
+// cleanChatIcon returns the first word of text, cut to maxIconLength
+// characters, or "" when there is none.
+func cleanChatIcon(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	icon := []rune(fields[0])
+	if len(icon) > maxIconLength {
+		icon = icon[:maxIconLength]
+	}
+	return string(icon)
+}
+
+// chatColorName returns the name stored for an accent color.
+func chatColorName(accent accentColor) string {
+	return strings.ToLower(accent.Name)
+}
+
+// chatColorClass returns the CSS class of the rows of chats with color, or
+// "" for no color or an unknown one.
+func chatColorClass(color string) string {
+	for _, accent := range accentColors {
+		if chatColorName(accent) == color {
+			return "chat-color-" + color
+		}
+	}
+	return ""
+}
+
+// chatColorCSS returns the rules of the chat color classes: a stripe along
+// the rows of chats, and the swatches that choose a color.
+func chatColorCSS() string {
+	var b strings.Builder
+	for _, accent := range accentColors {
+		name := chatColorName(accent)
+		fmt.Fprintf(&b, "row.chat-color-%s { box-shadow: inset 3px 0 %s; }\n", name, accent.Hex)
+		fmt.Fprintf(&b, ".chat-color-swatch.chat-color-%s { background: %s; }\n", name, accent.Hex)
+	}
+	return b.String()
+}
+
+// streamingMark starts the window title while a response is streaming.
+const streamingMark = "●"
+
+// chatWindowTitle returns the title of a window showing chat, with its
+// icon, and a mark while a response is streaming. It is the app name when
+// no chat is shown.
+func chatWindowTitle(chat *store.Chat, streaming bool) string {
+	title := "Guanaco"
+	if chat != nil {
+		title = chat.Title
+		if chat.Icon != "" {
+			title = chat.Icon + " " + title
+		}
+	}
+	if streaming {
+		return streamingMark + " " + title
+	}
+	return title
+}
+
+// editChatLabel asks for the icon and the color of chat, and saves them.
+func (sb *Sidebar) editChatLabel(chat *store.Chat) {
+	if sb.db == nil {
+		return
+	}
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+
+	iconBox := gtk.NewBox(gtk.OrientationHorizontal, 6)
+	iconEntry := gtk.NewEntry()
+	iconEntry.SetText(chat.Icon)
+	iconEntry.SetPlaceholderText(i18n.T("An emoji, such as 🧪"))
+	iconEntry.SetHExpand(true)
+	iconEntry.SetActivatesDefault(true)
+	iconBox.Append(iconEntry)
+
+	emojiChooser := gtk.NewEmojiChooser()
+	emojiChooser.ConnectEmojiPicked(func(emoji string) {
+		iconEntry.SetText(emoji)
+	})
+	emojiBtn := gtk.NewMenuButton()
+	emojiBtn.SetIconName("face-smile-symbolic")
+	emojiBtn.SetTooltipText(i18n.T("Choose an emoji"))
+	emojiBtn.SetPopover(emojiChooser)
+	iconBox.Append(emojiBtn)
+	content.Append(iconBox)
+
+	// One toggle for each color, and one for none
+	swatches := gtk.NewBox(gtk.OrientationHorizontal, 6)
+	swatches.SetHAlign(gtk.AlignCenter)
+	noneBtn := gtk.NewToggleButton()
+	noneBtn.SetIconName("window-close-symbolic")
+	noneBtn.SetTooltipText(i18n.T("No color"))
+	noneBtn.AddCSSClass("circular")
+	noneBtn.SetActive(chatColorClass(chat.Color) == "")
+	swatches.Append(noneBtn)
+
+	colorBtns := make([]*gtk.ToggleButton, len(accentColors))
+	for i, accent := range accentColors {
+		colorBtns[i] = gtk.NewToggleButton()
+		colorBtns[i].SetGroup(noneBtn)
+		colorBtns[i].SetTooltipText(i18n.T(accent.Name))
+		colorBtns[i].AddCSSClass("circular")
+		colorBtns[i].AddCSSClass("chat-color-swatch")
+		colorBtns[i].AddCSSClass(chatColorClass(chatColorName(accent)))
+		colorBtns[i].SetActive(chat.Color == chatColorName(accent))
+		swatches.Append(colorBtns[i])
+	}
+	content.Append(swatches)
+
+	dialog := adw.NewMessageDialog(sb.window, i18n.T("Icon and Color"), fmt.Sprintf(i18n.T("Mark %s in the chat list"), chat.Title))
+	dialog.SetExtraChild(content)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("save", i18n.T("Save"))
+	dialog.SetResponseAppearance("save", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("save")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		if response != "save" {
+			return
+		}
+		icon := cleanChatIcon(iconEntry.Text())
+		color := ""
+		for i, accent := range accentColors {
+			if colorBtns[i].Active() {
+				color = chatColorName(accent)
+			}
+		}
+		if err := sb.db.UpdateChatLabel(chat.ID, icon, color); err != nil {
+			logger.Error("Failed to update chat label", "chatID", chat.ID, "error", err)
+			return
+		}
+		logger.Info("Chat label changed", "chatID", chat.ID, "icon", icon, "color", color)
+		chat.Icon, chat.Color = icon, color
+
+		sb.Refresh()
+		sb.SelectChat(chat)
+		if sb.onChatLabelChanged != nil {
+			sb.onChatLabelChanged(chat)
+		}
+	})
+
+	dialog.Present()
+}
+
+// OnChatLabelChanged sets the callback for when the icon or the color of
+// a chat changed.
+func (sb *Sidebar) OnChatLabelChanged(callback func(*store.Chat)) {
+	sb.onChatLabelChanged = callback
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestCleanChatIcon(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"🧪", "🧪"},
+		{" 🚀 rocket", "🚀"},
+		{"👨‍👩‍👧‍👦", "👨‍👩‍👧‍👦"},
+		{"abcdefghijkl", "abcdefgh"},
+	}
+
+	for _, tt := range tests {
+		if got := cleanChatIcon(tt.text); got != tt.want {
+			t.Errorf("cleanChatIcon(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestChatColorClass(t *testing.T) {
+	if got := chatColorClass("green"); got != "chat-color-green" {
+		t.Errorf("chatColorClass(green) = %q, want chat-color-green", got)
+	}
+	for _, color := range []string{"", "Green", "magenta", "green; background: red"} {
+		if got := chatColorClass(color); got != "" {
+			t.Errorf("chatColorClass(%q) = %q, want none", color, got)
+		}
+	}
+
+	css := chatColorCSS()
+	for _, accent := range accentColors {
+		if !strings.Contains(css, "row."+chatColorClass(chatColorName(accent))+" ") {
+			t.Errorf("chatColorCSS() has no rule for %s", accent.Name)
+		}
+	}
+}
+
+func TestChatWindowTitle(t *testing.T) {
+	if got := chatWindowTitle(nil, false); got != "Guanaco" {
+		t.Errorf("chatWindowTitle(nil, false) = %q, want Guanaco", got)
+	}
+	if got := chatWindowTitle(&store.Chat{Title: "Trip ideas"}, false); got != "Trip ideas" {
+		t.Errorf("chatWindowTitle() = %q, want the title", got)
+	}
+	if got := chatWindowTitle(&store.Chat{Title: "Trip ideas", Icon: "🏖"}, false); got != "🏖 Trip ideas" {
+		t.Errorf("chatWindowTitle() = %q, want the icon before the title", got)
+	}
+	if got := chatWindowTitle(&store.Chat{Title: "Trip ideas", Icon: "🏖"}, true); got != "● 🏖 Trip ideas" {
+		t.Errorf("streaming chatWindowTitle() = %q, want the mark first", got)
+	}
+	if got := chatWindowTitle(nil, true); got != "● Guanaco" {
+		t.Errorf("streaming chatWindowTitle(nil) = %q, want the mark before the app name", got)
+	}
+}
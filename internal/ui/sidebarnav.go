@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// The chat list can be used without a mouse. The arrow keys, Home, End and
+// Page Up/Down move the focus between chats without opening them; Enter
+// selects, and so opens, the focused chat, F2 renames it and Delete removes
+// it, with an undo toast. Typing
+// while the list has the focus opens a search that filters the chats by
+// title and model.
+
+// chatPageStep is how many rows Page Up and Page Down move.
+const chatPageStep = 10
+
+// chatMatches reports whether chat matches a search of the chat list,
+// ignoring case. An empty query matches every chat.
+func chatMatches(chat *store.Chat, query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(chat.Title), query) ||
+		strings.Contains(strings.ToLower(chat.Model), query)
+}
+
+// stepIndex moves step rows from index over the rows that are visible,
+// stopping at the first or last visible one. index may be -1, or
+// len(visible), to start before the first or after the last row. It
+// returns -1 when no row is visible.
+func stepIndex(visible []bool, index, step int) int {
+	target := -1
+	direction := 1
+	if step < 0 {
+		direction, step = -1, -step
+	}
+	for i := index + direction; i >= 0 && i < len(visible) && step > 0; i += direction {
+		if visible[i] {
+			target = i
+			step--
+		}
+	}
+	if target == -1 && index >= 0 && index < len(visible) && visible[index] {
+		return index
+	}
+	return target
+}
+
+// setupKeyboardNavigation adds the search bar and the key handling of the
+// chat list. The search bar is returned for the caller to place above the
+// list.
+func (sb *Sidebar) setupKeyboardNavigation() *gtk.SearchBar {
+	sb.searchEntry = gtk.NewSearchEntry()
+	sb.searchEntry.SetPlaceholderText(i18n.T("Search chats"))
+	sb.searchEntry.SetHExpand(true)
+	sb.searchEntry.ConnectSearchChanged(func() {
+		sb.listBox.InvalidateFilter()
+		sb.updateEmptyState()
+	})
+	sb.searchEntry.ConnectActivate(func() {
+		// Open the first match
+		if index := stepIndex(sb.visibleRows(), -1, 1); index >= 0 {
+			sb.listBox.SelectRow(sb.listBox.RowAtIndex(index))
+		}
+	})
+	searchKeys := gtk.NewEventControllerKey()
+	searchKeys.ConnectKeyPressed(func(keyval, keycode uint, state gdk.ModifierType) bool {
+		if keyval == gdk.KEY_Down {
+			sb.focusRow(stepIndex(sb.visibleRows(), -1, 1))
+			return true
+		}
+		return false
+	})
+	sb.searchEntry.AddController(searchKeys)
+
+	sb.searchBar = gtk.NewSearchBar()
+	sb.searchBar.SetChild(sb.searchEntry)
+	sb.searchBar.ConnectEntry(sb.searchEntry)
+	sb.searchBar.SetKeyCaptureWidget(sb.listBox)
+	sb.searchBar.NotifyProperty("search-mode-enabled", func() {
+		if !sb.searchBar.SearchMode() {
+			sb.searchEntry.SetText("")
+		}
+	})
+
+	sb.listBox.SetFilterFunc(func(row *gtk.ListBoxRow) bool {
+		index := row.Index()
+		if index < 0 || index >= len(sb.chats) {
+			return true
+		}
+		chat := sb.chats[index]
+		return chatMatches(chat, sb.searchEntry.Text()) && hasTag(sb.chatTags[chat.ID], sb.tagFilter) &&
+			(!chat.Archived || sb.showArchived)
+	})
+
+	// Capture the keys before the list, which would select (and so open)
+	// every chat the focus moves over
+	keys := gtk.NewEventControllerKey()
+	keys.SetPropagationPhase(gtk.PhaseCapture)
+	keys.ConnectKeyPressed(func(keyval, keycode uint, state gdk.ModifierType) bool {
+		// Keys typed in the title being renamed are the entry's
+		if state&(gdk.ControlMask|gdk.AltMask) != 0 || sb.renaming {
+			return false
+		}
+		current := sb.focusedRow()
+		visible := sb.visibleRows()
+		switch keyval {
+		case gdk.KEY_Up, gdk.KEY_KP_Up:
+			if current <= stepIndex(visible, -1, 1) && sb.searchBar.SearchMode() {
+				sb.searchEntry.GrabFocus()
+				return true
+			}
+			sb.focusRow(stepIndex(visible, current, -1))
+		case gdk.KEY_Down, gdk.KEY_KP_Down:
+			sb.focusRow(stepIndex(visible, current, 1))
+		case gdk.KEY_Page_Up, gdk.KEY_KP_Page_Up:
+			sb.focusRow(stepIndex(visible, current, -chatPageStep))
+		case gdk.KEY_Page_Down, gdk.KEY_KP_Page_Down:
+			sb.focusRow(stepIndex(visible, current, chatPageStep))
+		case gdk.KEY_Home, gdk.KEY_KP_Home:
+			sb.focusRow(stepIndex(visible, -1, 1))
+		case gdk.KEY_End, gdk.KEY_KP_End:
+			sb.focusRow(stepIndex(visible, len(visible), -1))
+		case gdk.KEY_F2:
+			return sb.renameFocused()
+		case gdk.KEY_Delete, gdk.KEY_KP_Delete:
+			if current < 0 {
+				return false
+			}
+			sb.deleteWithUndo(sb.chats[current], current)
+		default:
+			return false
+		}
+		return true
+	})
+	sb.listBox.AddController(keys)
+
+	return sb.searchBar
+}
+
+// visibleRows returns which rows of the chat list pass the search filter.
+func (sb *Sidebar) visibleRows() []bool {
+	visible := make([]bool, len(sb.chats))
+	for i := range visible {
+		if row := sb.listBox.RowAtIndex(i); row != nil {
+			visible[i] = row.ChildVisible()
+		}
+	}
+	return visible
+}
+
+// focusedRow returns the index of the row holding the keyboard focus, or
+// -1 when the focus is elsewhere.
+func (sb *Sidebar) focusedRow() int {
+	for i := range sb.chats {
+		row := sb.listBox.RowAtIndex(i)
+		if row != nil && row.StateFlags()&gtk.StateFlagFocusWithin != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// focusRow moves the keyboard focus to the row at index, if there is one.
+func (sb *Sidebar) focusRow(index int) {
+	if index < 0 {
+		return
+	}
+	if row := sb.listBox.RowAtIndex(index); row != nil {
+		row.GrabFocus()
+	}
+}
+
+// updateEmptyState shows the empty state when there are no chats, and
+// keeps the list (and so the search) when a search matches none.
+func (sb *Sidebar) updateEmptyState() {
+	hasChats := len(sb.chats) > 0
+	sb.scrolled.SetVisible(hasChats)
+	sb.emptyState.SetVisible(!hasChats)
+}
+
+// StartSearch shows the search of the chat list and focuses it.
+func (sb *Sidebar) StartSearch() {
+	sb.searchBar.SetSearchMode(true)
+	sb.searchEntry.GrabFocus()
+}
+
+// OnToast sets the callback used to show toasts, such as the one undoing
+// the deletion of a chat.
+func (sb *Sidebar) OnToast(callback func(*adw.Toast)) {
+	sb.onToast = callback
+}
+
+// deleteWithUndo removes chat from the list at once and deletes it when
+// its toast is dismissed, unless the user chose to undo. index is the row
+// of the chat, used to keep the focus in the list. Without a way to show
+// toasts it asks for confirmation instead.
+func (sb *Sidebar) deleteWithUndo(chat *store.Chat, index int) {
+	if sb.db == nil {
+		return
+	}
+	if sb.onToast == nil {
+		sb.deleteChat(chat.ID)
+		return
+	}
+
+	sb.pendingDeletes[chat.ID] = true
+	sb.Refresh()
+	sb.focusRow(min(index, len(sb.chats)-1))
+
+	undone := false
+	toast := adw.NewToast(i18n.T("Chat deleted"))
+	toast.SetButtonLabel(i18n.T("Undo"))
+	toast.ConnectButtonClicked(func() {
+		undone = true
+		delete(sb.pendingDeletes, chat.ID)
+		logger.Info("Chat deletion undone", "chatID", chat.ID)
+		sb.Refresh()
+		sb.focusChat(chat.ID)
+	})
+	toast.ConnectDismissed(func() {
+		if undone || !sb.pendingDeletes[chat.ID] {
+			return
+		}
+		delete(sb.pendingDeletes, chat.ID)
+		sb.confirmDeleteChat(chat.ID)
+	})
+	sb.onToast(toast)
+}
+
+// FinishDeletes deletes the chats whose undo toast is still shown, for
+// when the window closes before the toasts are dismissed.
+func (sb *Sidebar) FinishDeletes() {
+	for chatID := range sb.pendingDeletes {
+		if err := sb.db.DeleteChat(chatID); err != nil {
+			logger.Error("Failed to delete chat", "chatID", chatID, "error", err)
+		}
+		delete(sb.pendingDeletes, chatID)
+	}
+}
+
+// focusChat moves the keyboard focus to the row of a chat.
+func (sb *Sidebar) focusChat(chatID int64) {
+	for i, chat := range sb.chats {
+		if chat.ID == chatID {
+			sb.focusRow(i)
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestChatMatches(t *testing.T) {
+	chat := &store.Chat{Title: "Trip to Lisbon", Model: "llama3.2:3b"}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"empty query", "", true},
+		{"blank query", "   ", true},
+		{"title", "trip", true},
+		{"title ignoring case", "LISBON", true},
+		{"middle of title", "to lis", true},
+		{"model", "llama", true},
+		{"padded query", "  trip ", true},
+		{"no match", "paris", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chatMatches(chat, tt.query); got != tt.want {
+				t.Errorf("chatMatches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepIndex(t *testing.T) {
+	all := []bool{true, true, true, true, true}
+	some := []bool{false, true, false, true, false}
+
+	tests := []struct {
+		name    string
+		visible []bool
+		index   int
+		step    int
+		want    int
+	}{
+		{"down", all, 1, 1, 2},
+		{"up", all, 2, -1, 1},
+		{"down from nothing", all, -1, 1, 0},
+		{"up from past the end", all, len(all), -1, 4},
+		{"stays at the last row", all, 4, 1, 4},
+		{"stays at the first row", all, 0, -1, 0},
+		{"page down stops at the end", all, 1, 10, 4},
+		{"page up stops at the start", all, 3, -10, 0},
+		{"skips hidden rows down", some, 1, 1, 3},
+		{"skips hidden rows up", some, 3, -1, 1},
+		{"first visible", some, -1, 1, 1},
+		{"last visible", some, len(some), -1, 3},
+		{"from a hidden row", some, 2, 1, 3},
+		{"nothing visible", []bool{false, false}, -1, 1, -1},
+		{"empty list", nil, -1, 1, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stepIndex(tt.visible, tt.index, tt.step); got != tt.want {
+				t.Errorf("stepIndex(%v, %d, %d) = %d, want %d", tt.visible, tt.index, tt.step, got, tt.want)
+			}
+		})
+	}
+}
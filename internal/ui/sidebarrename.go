@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// maxChatTitleLength is the longest title, in characters, a chat can be
+// renamed to.
+const maxChatTitleLength = 100
+
+// cleanChatTitle returns title on a single line without surrounding spaces,
+// cut to maxChatTitleLength characters. It returns false when nothing is
+// left.
+func cleanChatTitle(title string) (string, bool) {
+	title = strings.Join(strings.Fields(title), " ")
+	if runes := []rune(title); len(runes) > maxChatTitleLength {
+		title = strings.TrimSpace(string(runes[:maxChatTitleLength]))
+	}
+	return title, title != ""
+}
+
+// startRename replaces the title of a chat row with an entry to rename the
+// chat. Enter saves the new title; Escape, or leaving the entry, keeps the
+// old one.
+func (sb *Sidebar) startRename(chat *store.Chat, headerBox *gtk.Box, titleLabel *gtk.Label) {
+	if sb.db == nil || sb.renaming {
+		return
+	}
+	sb.renaming = true
+
+	entry := gtk.NewEntry()
+	entry.SetText(chat.Title)
+	entry.SetHExpand(true)
+	entry.SetMaxLength(maxChatTitleLength)
+	entry.SetTooltipText(i18n.T("Press Enter to rename the chat, or Escape to cancel"))
+	headerBox.InsertChildAfter(entry, titleLabel)
+	titleLabel.SetVisible(false)
+
+	done := false
+	finish := func(save bool) {
+		if done {
+			return
+		}
+		done = true
+		sb.renaming = false
+
+		if save {
+			sb.renameChat(chat, entry.Text(), titleLabel)
+		}
+		titleLabel.SetVisible(true)
+		headerBox.Remove(entry)
+	}
+
+	entry.ConnectActivate(func() {
+		finish(true)
+	})
+
+	keys := gtk.NewEventControllerKey()
+	keys.ConnectKeyPressed(func(keyval, keycode uint, state gdk.ModifierType) bool {
+		if keyval == gdk.KEY_Escape {
+			finish(false)
+			return true
+		}
+		return false
+	})
+	entry.AddController(keys)
+
+	focus := gtk.NewEventControllerFocus()
+	focus.ConnectLeave(func() {
+		finish(false)
+	})
+	entry.AddController(focus)
+
+	entry.GrabFocus()
+	entry.SelectRegion(0, -1)
+}
+
+// renameChat saves a title chosen by the user for chat and shows it in the
+// row. Generated titles no longer replace it.
+func (sb *Sidebar) renameChat(chat *store.Chat, title string, titleLabel *gtk.Label) {
+	title, ok := cleanChatTitle(title)
+	if !ok || title == chat.Title {
+		return
+	}
+
+	if err := sb.db.RenameChat(chat.ID, title); err != nil {
+		logger.Error("Failed to rename chat", "chatID", chat.ID, "error", err)
+		return
+	}
+	logger.Info("Chat renamed", "chatID", chat.ID, "title", title)
+
+	chat.Title = title
+	chat.TitleLocked = true
+	titleLabel.SetText(title)
+
+	if sb.onChatRenamed != nil {
+		sb.onChatRenamed(chat)
+	}
+}
+
+// renameFocused starts renaming the chat whose row has the focus.
+func (sb *Sidebar) renameFocused() bool {
+	current := sb.focusedRow()
+	if current < 0 {
+		return false
+	}
+	rename := sb.renamers[sb.chats[current].ID]
+	if rename == nil {
+		return false
+	}
+	rename()
+	return true
+}
+
+// OnChatRenamed sets the callback for when the user renames a chat.
+func (sb *Sidebar) OnChatRenamed(callback func(*store.Chat)) {
+	sb.onChatRenamed = callback
+}
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Chats can be given tags, such as "work" or "personal", from their
+// context menu. A bar above the chat list, shown once there are tags,
+// filters the list to the chats with a tag. Right clicking a tag in the
+// bar renames or deletes it.
+
+// maxTagLength is the longest name, in characters, a tag can have.
+const maxTagLength = 30
+
+// hasTag reports whether tags include the tag with ID tagID. Every chat
+// has tag 0, which stands for no filter.
+func hasTag(tags []*store.Tag, tagID int64) bool {
+	if tagID == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if tag.ID == tagID {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTags returns the names of tags as shown under a chat's title.
+func formatTags(tags []*store.Tag) string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = "#" + tag.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// cleanTagName returns name on a single line without surrounding spaces or
+// a leading #, cut to maxTagLength characters. It returns false when
+// nothing is left.
+func cleanTagName(name string) (string, bool) {
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.TrimSpace(strings.TrimLeft(name, "#"))
+	if runes := []rune(name); len(runes) > maxTagLength {
+		name = strings.TrimSpace(string(runes[:maxTagLength]))
+	}
+	return name, name != ""
+}
+
+// parseTagNames splits a comma-separated list of tag names, leaving out
+// empty names and repeated ones, ignoring case.
+func parseTagNames(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(text, ",") {
+		name, ok := cleanTagName(part)
+		if !ok || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// setupTagBar creates the bar of tags that filters the chat list. It is
+// returned for the caller to place above the list, and filled in by
+// loadTags.
+func (sb *Sidebar) setupTagBar() *gtk.ScrolledWindow {
+	sb.tagBar = gtk.NewScrolledWindow()
+	sb.tagBar.SetPolicy(gtk.PolicyAutomatic, gtk.PolicyNever)
+	sb.tagBar.SetVisible(false)
+	return sb.tagBar
+}
+
+// loadTags reads the tags and the tags of each chat, and rebuilds the tag
+// bar. A filter by a tag that no longer exists is cleared.
+func (sb *Sidebar) loadTags() {
+	tags, err := sb.db.ListTags()
+	if err != nil {
+		logger.Error("Failed to load tags", "error", err)
+		return
+	}
+	chatTags, err := sb.db.ListChatTags()
+	if err != nil {
+		logger.Error("Failed to load chat tags", "error", err)
+		return
+	}
+	sb.tags = tags
+	sb.chatTags = chatTags
+
+	found := false
+	for _, tag := range tags {
+		found = found || tag.ID == sb.tagFilter
+	}
+	if !found {
+		sb.tagFilter = 0
+	}
+
+	sb.updateTagBar()
+}
+
+// updateTagBar shows a toggle button for each tag, and one for all chats,
+// with the current filter active.
+func (sb *Sidebar) updateTagBar() {
+	box := gtk.NewBox(gtk.OrientationHorizontal, 4)
+	box.SetMarginStart(8)
+	box.SetMarginEnd(8)
+	box.SetMarginBottom(6)
+
+	allBtn := gtk.NewToggleButtonWithLabel(i18n.T("All"))
+	allBtn.AddCSSClass("flat")
+	allBtn.SetActive(sb.tagFilter == 0)
+	allBtn.ConnectToggled(func() {
+		if allBtn.Active() {
+			sb.setTagFilter(0)
+		}
+	})
+	box.Append(allBtn)
+
+	for _, tag := range sb.tags {
+		tag := tag // capture for closure
+
+		btn := gtk.NewToggleButtonWithLabel("#" + tag.Name)
+		btn.AddCSSClass("flat")
+		btn.SetGroup(allBtn)
+		btn.SetActive(sb.tagFilter == tag.ID)
+		btn.SetTooltipText(i18n.T("Show the chats with this tag. Right click to rename or delete it"))
+		btn.ConnectToggled(func() {
+			if btn.Active() {
+				sb.setTagFilter(tag.ID)
+			}
+		})
+
+		rightClick := gtk.NewGestureClick()
+		rightClick.SetButton(3) // GDK_BUTTON_SECONDARY
+		rightClick.ConnectPressed(func(nPress int, x, y float64) {
+			showMenu(btn, []rowMenuItem{
+				{i18n.T("Rename…"), func() { sb.renameTag(tag) }},
+				{i18n.T("Delete"), func() { sb.deleteTag(tag) }},
+			}, x, y)
+		})
+		btn.AddController(rightClick)
+
+		box.Append(btn)
+	}
+
+	sb.tagBar.SetChild(box)
+	sb.tagBar.SetVisible(len(sb.tags) > 0)
+}
+
+// setTagFilter shows only the chats with the tag with ID tagID, or every
+// chat when it is 0.
+func (sb *Sidebar) setTagFilter(tagID int64) {
+	if sb.tagFilter == tagID {
+		return
+	}
+	sb.tagFilter = tagID
+	sb.listBox.InvalidateFilter()
+}
+
+// editChatTags shows the tags with the ones of chat checked, and an entry
+// for new tags, and saves the changes.
+func (sb *Sidebar) editChatTags(chat *store.Chat) {
+	if sb.db == nil {
+		return
+	}
+
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+
+	checks := make([]*gtk.CheckButton, len(sb.tags))
+	for i, tag := range sb.tags {
+		checks[i] = gtk.NewCheckButtonWithLabel(tag.Name)
+		checks[i].SetActive(hasTag(sb.chatTags[chat.ID], tag.ID))
+		checks[i].SetMarginTop(4)
+		checks[i].SetMarginBottom(4)
+		checks[i].SetMarginStart(8)
+		list.Append(checks[i])
+	}
+
+	newRow := adw.NewEntryRow()
+	newRow.SetTitle(i18n.T("New tags, separated by commas"))
+	list.Append(newRow)
+
+	dialog := adw.NewMessageDialog(sb.window, i18n.T("Tags"), fmt.Sprintf(i18n.T("Tags of %s"), chat.Title))
+	dialog.SetExtraChild(list)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("save", i18n.T("Save"))
+	dialog.SetResponseAppearance("save", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("save")
+	dialog.SetCloseResponse("cancel")
+
+	newRow.ConnectEntryActivated(func() {
+		dialog.Response("save")
+	})
+
+	tags := sb.tags // The list may be reloaded while the dialog is open
+	dialog.ConnectResponse(func(response string) {
+		if response != "save" {
+			return
+		}
+		for i, tag := range tags {
+			var err error
+			if checks[i].Active() {
+				err = sb.db.TagChat(chat.ID, tag.ID)
+			} else {
+				err = sb.db.UntagChat(chat.ID, tag.ID)
+			}
+			if err != nil {
+				logger.Error("Failed to change chat tags", "chatID", chat.ID, "tag", tag.Name, "error", err)
+			}
+		}
+		for _, name := range parseTagNames(newRow.Text()) {
+			tag, err := sb.db.AddTag(name)
+			if err == nil {
+				err = sb.db.TagChat(chat.ID, tag.ID)
+			}
+			if err != nil {
+				logger.Error("Failed to add chat tag", "chatID", chat.ID, "tag", name, "error", err)
+			}
+		}
+		logger.Info("Chat tags changed", "chatID", chat.ID)
+		sb.Refresh()
+		sb.SelectChat(chat)
+	})
+
+	dialog.Present()
+}
+
+// renameTag asks for a new name for tag and saves it.
+func (sb *Sidebar) renameTag(tag *store.Tag) {
+	entry := gtk.NewEntry()
+	entry.SetText(tag.Name)
+	entry.SetMaxLength(maxTagLength)
+	entry.SetActivatesDefault(true)
+
+	dialog := adw.NewMessageDialog(sb.window, i18n.T("Rename Tag"), fmt.Sprintf(i18n.T("Rename #%s to:"), tag.Name))
+	dialog.SetExtraChild(entry)
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("rename", i18n.T("Rename"))
+	dialog.SetResponseAppearance("rename", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("rename")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		name, ok := cleanTagName(entry.Text())
+		if response != "rename" || !ok || name == tag.Name {
+			return
+		}
+		if err := sb.db.RenameTag(tag.ID, name); err != nil {
+			logger.Error("Failed to rename tag", "tag", tag.Name, "error", err)
+			if sb.onToast != nil {
+				sb.onToast(adw.NewToast(fmt.Sprintf(i18n.T("There is already a tag named %s"), name)))
+			}
+			return
+		}
+		logger.Info("Tag renamed", "tag", tag.Name, "name", name)
+		sb.Refresh()
+	})
+
+	dialog.Present()
+}
+
+// deleteTag asks before removing tag from every chat and deleting it.
+func (sb *Sidebar) deleteTag(tag *store.Tag) {
+	dialog := adw.NewMessageDialog(sb.window, i18n.T("Delete Tag?"),
+		fmt.Sprintf(i18n.T("#%s will be removed from every chat. The chats are kept."), tag.Name))
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("delete", i18n.T("Delete"))
+	dialog.SetResponseAppearance("delete", adw.ResponseDestructive)
+	dialog.SetDefaultResponse("cancel")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		if response != "delete" {
+			return
+		}
+		if err := sb.db.DeleteTag(tag.ID); err != nil {
+			logger.Error("Failed to delete tag", "tag", tag.Name, "error", err)
+			return
+		}
+		logger.Info("Tag deleted", "tag", tag.Name)
+		sb.Refresh()
+	})
+
+	dialog.Present()
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestHasTag(t *testing.T) {
+	tags := []*store.Tag{{ID: 1, Name: "work"}, {ID: 3, Name: "code"}}
+
+	tests := []struct {
+		name  string
+		tags  []*store.Tag
+		tagID int64
+		want  bool
+	}{
+		{"no filter", nil, 0, true},
+		{"tagged", tags, 3, true},
+		{"other tag", tags, 2, false},
+		{"untagged chat", nil, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasTag(tt.tags, tt.tagID); got != tt.want {
+				t.Errorf("hasTag(%d) = %v, want %v", tt.tagID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	tags := []*store.Tag{{Name: "code"}, {Name: "work stuff"}}
+	if got, want := formatTags(tags), "#code #work stuff"; got != want {
+		t.Errorf("formatTags() = %q, want %q", got, want)
+	}
+	if got := formatTags(nil); got != "" {
+		t.Errorf("formatTags(nil) = %q, want empty", got)
+	}
+}
+
+func TestParseTagNames(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"work", []string{"work"}},
+		{" work , #code,, personal ", []string{"work", "code", "personal"}},
+		{"Work, work, WORK", []string{"Work"}},
+		{"side\nproject", []string{"side project"}},
+		{"#, ##", nil},
+		{"abcdefghijklmnopqrstuvwxyz0123456789", []string{"abcdefghijklmnopqrstuvwxyz0123"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := parseTagNames(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTagNames(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
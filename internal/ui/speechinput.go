@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/speech"
+)
+
+// The microphone button records the user until clicked again, then runs the
+// speech to text command of the settings on the recording and types what it
+// printed at the cursor. It is shown once a command is set.
+
+// setupMicButton creates the microphone button, hidden until a speech to
+// text command is set.
+func (ia *InputArea) setupMicButton() {
+	ia.micButton = gtk.NewButton()
+	ia.micButton.SetIconName("audio-input-microphone-symbolic")
+	ia.micButton.SetTooltipText(i18n.T("Dictate"))
+	ia.micButton.AddCSSClass("flat")
+	ia.micButton.SetVAlign(gtk.AlignEnd)
+	ia.micButton.SetVisible(false)
+	ia.micButton.ConnectClicked(func() {
+		if ia.recording == nil {
+			ia.startRecording()
+		} else {
+			ia.stopRecording()
+		}
+	})
+	ia.inputBox.Append(ia.micButton)
+}
+
+// SetSpeechCommand sets the command transcribing recordings, and shows the
+// microphone button when there is one.
+func (ia *InputArea) SetSpeechCommand(command string) {
+	ia.speechCommand = command
+	ia.micButton.SetVisible(command != "" || ia.recording != nil)
+}
+
+// OnSpeechError sets the callback for when recording or transcribing fails.
+func (ia *InputArea) OnSpeechError(callback func(error)) {
+	ia.onSpeechError = callback
+}
+
+// speechError reports a failed recording or transcription.
+func (ia *InputArea) speechError(err error) {
+	logger.Error("Speech input failed", "error", err)
+	if ia.onSpeechError != nil {
+		ia.onSpeechError(err)
+	}
+}
+
+// startRecording starts recording the microphone.
+func (ia *InputArea) startRecording() {
+	if ia.recorder == nil {
+		ia.recorder = speech.NewRecorder()
+	}
+	recording, err := ia.recorder.Start()
+	if err != nil {
+		ia.speechError(err)
+		return
+	}
+	logger.Info("Recording speech", "path", recording.Path)
+
+	ia.recording = recording
+	ia.micButton.SetIconName("media-playback-stop-symbolic")
+	ia.micButton.SetTooltipText(i18n.T("Stop dictating"))
+	ia.micButton.AddCSSClass("destructive-action")
+}
+
+// stopRecording stops recording and transcribes the recording in the
+// background.
+func (ia *InputArea) stopRecording() {
+	recording := ia.recording
+	command := ia.speechCommand
+	ia.recording = nil
+	ia.micButton.SetIconName("audio-input-microphone-symbolic")
+	ia.micButton.SetTooltipText(i18n.T("Transcribing…"))
+	ia.micButton.RemoveCSSClass("destructive-action")
+	ia.micButton.SetSensitive(false)
+
+	go func() {
+		defer os.Remove(recording.Path)
+		err := recording.Stop()
+		text := ""
+		if err == nil {
+			text, err = speech.Transcribe(context.Background(), command, recording.Path)
+		}
+		if err == nil && text == "" {
+			err = errors.New(i18n.T("No speech was recognized"))
+		}
+
+		glib.IdleAdd(func() {
+			ia.micButton.SetSensitive(true)
+			ia.micButton.SetTooltipText(i18n.T("Dictate"))
+			ia.micButton.SetVisible(ia.speechCommand != "")
+			if err != nil {
+				ia.speechError(err)
+				return
+			}
+			logger.Info("Speech transcribed", "length", len(text))
+			ia.insertTranscript(text)
+		})
+	}()
+}
+
+// insertTranscript types text at the cursor.
+func (ia *InputArea) insertTranscript(text string) {
+	buffer := ia.textView.Buffer()
+	before := buffer.Text(buffer.StartIter(), buffer.IterAtMark(buffer.GetInsert()), false)
+	buffer.InsertAtCursor(transcriptInsertion(before, text))
+	ia.textView.GrabFocus()
+}
+
+// transcriptInsertion returns text as typed after before, separated from
+// it by a space.
+func transcriptInsertion(before, text string) string {
+	last, _ := utf8.DecodeLastRuneInString(before)
+	if before == "" || unicode.IsSpace(last) {
+		return text
+	}
+	return " " + text
+}
@@ -0,0 +1,21 @@
+package ui
+
+import "testing"
+
+func TestTranscriptInsertion(t *testing.T) {
+	tests := []struct {
+		before string
+		text   string
+		want   string
+	}{
+		{"", "Hello there", "Hello there"},
+		{"Summarize this:", "the meeting notes", " the meeting notes"},
+		{"First line\n", "second line", "second line"},
+		{"¿Qué tal", "estás?", " estás?"},
+	}
+	for _, tt := range tests {
+		if got := transcriptInsertion(tt.before, tt.text); got != tt.want {
+			t.Errorf("transcriptInsertion(%q, %q) = %q, want %q", tt.before, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// Work done before a response, such as downloading its model or
+// summarizing earlier messages, is shown in a bar above the input area
+// rather than as a message, so that the message list and the history sent
+// to the model only hold the conversation.
+
+// newStatusBar creates the bar showing the work done before a response.
+func (cv *ChatView) newStatusBar() *gtk.Revealer {
+	box := gtk.NewBox(gtk.OrientationVertical, 6)
+	box.AddCSSClass("status-bar")
+	box.SetMarginStart(12)
+	box.SetMarginEnd(12)
+	box.SetMarginBottom(8)
+
+	cv.statusLabel = gtk.NewLabel("")
+	cv.statusLabel.SetXAlign(0)
+	cv.statusLabel.SetWrap(true)
+	box.Append(cv.statusLabel)
+
+	cv.statusProgress = gtk.NewProgressBar()
+	cv.statusProgress.SetVisible(false)
+	box.Append(cv.statusProgress)
+
+	revealer := gtk.NewRevealer()
+	revealer.SetTransitionType(gtk.RevealerTransitionTypeSlideUp)
+	revealer.SetChild(box)
+	return revealer
+}
+
+// showStatus shows text in the status bar, with a progress bar filled to
+// fraction, or without one when fraction is negative.
+func (cv *ChatView) showStatus(text string, fraction float64) {
+	cv.statusLabel.SetText(text)
+	cv.statusProgress.SetVisible(fraction >= 0)
+	if fraction >= 0 {
+		cv.statusProgress.SetFraction(fraction)
+	}
+	cv.statusBar.SetRevealChild(true)
+}
+
+// hideStatus hides the status bar once the work is done.
+func (cv *ChatView) hideStatus() {
+	cv.statusBar.SetRevealChild(false)
+}
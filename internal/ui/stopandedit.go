@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"context"
+	"slices"
+
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// While a response is written, the pencil next to the stop button stops it
+// and takes the prompt back: the partial response and the prompt are
+// removed from the chat and the database, and the prompt goes back into
+// the input area with its attachments, to be edited and sent again.
+//
+// Holding or right-clicking the stop button stops the response and
+// discards it instead of saving what was written so far, which would
+// otherwise be sent back to the model with the rest of the history. A
+// stopped continuation goes back to the text it continued.
+
+// sentPrompt is a prompt sent with the input area, kept until its response
+// is done so that it can be taken back.
+type sentPrompt struct {
+	text        string
+	attachments []store.Attachment
+	bubble      *MessageBubble
+}
+
+// canTakeBack reports whether the response being written answers prompt,
+// the last prompt sent, right before it. Regenerated and continued
+// responses have no prompt to take back.
+func (cv *ChatView) canTakeBack(prompt *sentPrompt) bool {
+	n := len(cv.messages)
+	return prompt != nil && cv.currentBubble != nil && cv.currentBubble.MessageID() == 0 &&
+		n >= 2 && cv.messages[n-1] == cv.currentBubble && cv.messages[n-2] == prompt.bubble
+}
+
+// StopAndEdit stops the response being written and puts its prompt back
+// into the input area. Without a prompt to take back it only stops.
+func (cv *ChatView) StopAndEdit() {
+	if !cv.isStreaming {
+		return
+	}
+	cv.editAfterStop = cv.canTakeBack(cv.lastPrompt)
+	cv.StopStreaming()
+}
+
+// takeBackAfterStop takes the prompt back when the response to it was
+// stopped with StopAndEdit, and reports whether it did.
+func (cv *ChatView) takeBackAfterStop(err error) bool {
+	edit := cv.editAfterStop
+	cv.editAfterStop = false
+	prompt := cv.lastPrompt
+	cv.lastPrompt = nil
+	if !edit || err != context.Canceled || !cv.canTakeBack(prompt) {
+		return false
+	}
+	cv.takeBackPrompt(prompt)
+	return true
+}
+
+// StopAndDiscard stops the response being written without saving it.
+func (cv *ChatView) StopAndDiscard() {
+	if !cv.isStreaming {
+		return
+	}
+	cv.dropAfterStop = true
+	cv.StopStreaming()
+}
+
+// discardAfterStop discards the response in bubble when it was stopped
+// with StopAndDiscard, and reports whether it did. partial is the saved
+// text of a continued response.
+func (cv *ChatView) discardAfterStop(err error, bubble *MessageBubble, partial string) bool {
+	drop := cv.dropAfterStop
+	cv.dropAfterStop = false
+	if !drop || err != context.Canceled {
+		return false
+	}
+
+	if bubble.MessageID() != 0 {
+		// The continuation is dropped, the stored response is unchanged
+		bubble.SetContent(partial)
+		bubble.SetTruncated(true)
+		logger.Info("Continuation discarded", "messageID", bubble.MessageID())
+		return true
+	}
+
+	cv.messagesBox.Remove(bubble)
+	if index := slices.Index(cv.messages, bubble); index >= 0 {
+		cv.messages = slices.Delete(cv.messages, index, index+1)
+	}
+	if cv.currentBubble == bubble {
+		cv.currentBubble = nil
+	}
+	logger.Info("Stopped response discarded")
+	return true
+}
+
+// takeBackPrompt removes prompt and the partial response after it, and
+// puts the prompt back into the input area.
+func (cv *ChatView) takeBackPrompt(prompt *sentPrompt) {
+	if cv.db != nil {
+		cv.deletePrompt(prompt.bubble)
+	}
+
+	index := slices.Index(cv.messages, prompt.bubble)
+	for _, bubble := range cv.messages[index:] {
+		cv.messagesBox.Remove(bubble)
+	}
+	cv.messages = cv.messages[:index]
+	cv.currentBubble = nil
+	if len(cv.messages) == 0 && cv.earlierCount == 0 {
+		cv.scrolled.SetChild(cv.welcomeView)
+		cv.showingWelcome = true
+	}
+
+	cv.inputArea.SetText(prompt.text)
+	for _, a := range prompt.attachments {
+		cv.inputArea.AddAttachment(NewAttachmentPill(a.Filename, a.Content))
+	}
+	cv.inputArea.Focus()
+	logger.Info("Prompt taken back for editing", "attachments", len(prompt.attachments))
+}
+
+// deletePrompt removes the stored prompt of bubble, or drops it from the
+// messages waiting to be saved.
+func (cv *ChatView) deletePrompt(bubble *MessageBubble) {
+	if id := bubble.MessageID(); id != 0 {
+		if err := cv.db.DeleteMessage(id); err != nil {
+			logger.Error("Failed to delete prompt", "messageID", id, "error", err)
+			cv.handleError(err)
+		}
+		return
+	}
+	for p, b := range cv.pendingBubbles {
+		if b == bubble {
+			cv.db.DiscardPending(p)
+			delete(cv.pendingBubbles, p)
+			return
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Delays between attempts to reconnect to the database after a failed write.
+// Each failed attempt doubles the delay up to the maximum.
+const (
+	storageRetryMinDelay = 2  // seconds
+	storageRetryMaxDelay = 60 // seconds
+)
+
+// saveMessage saves a message and its attachments, then associates bubble
+// (if any) with it. When the database can't be written, the message is kept
+// in memory to be saved once it recovers, so nothing typed or generated is
+// lost. Must be called from the main thread.
+func (cv *ChatView) saveMessage(bubble *MessageBubble, msg *store.Message, attachments []store.Attachment) {
+	if cv.db == nil {
+		return
+	}
+
+	// Keep the order: while earlier messages wait, new ones wait too
+	if cv.db.PendingCount() == 0 {
+		err := cv.writeMessage(msg, attachments)
+		if err == nil {
+			if bubble != nil {
+				cv.setBubbleMessage(bubble, msg.ID)
+			}
+			return
+		}
+
+		kind := store.ClassifyError(err)
+		logger.Error("Failed to save message", "chatID", msg.ChatID, "role", msg.Role, "kind", kind, "error", err)
+		if kind == store.FailureOther {
+			// Not a storage problem, retrying won't help
+			cv.handleError(err)
+			return
+		}
+		if cv.onStorageError != nil {
+			cv.onStorageError(err)
+		}
+	}
+
+	p := cv.db.BufferMessage(msg, attachments)
+	if bubble != nil {
+		cv.pendingBubbles[p] = bubble
+	}
+	logger.Info("Message kept in memory", "chatID", msg.ChatID, "pending", cv.db.PendingCount())
+}
+
+// writeMessage writes a message, its attachments, critique, truncated flag,
+// replaced response and statistics in one transaction, setting the message
+// ID.
+func (cv *ChatView) writeMessage(msg *store.Message, attachments []store.Attachment) error {
+	if err := cv.db.SaveMessage(msg, attachments); err != nil {
+		return err
+	}
+	logger.Info("Message saved", "chatID", msg.ChatID, "messageID", msg.ID, "attachments", len(attachments))
+	return nil
+}
+
+// pendingSaved associates the bubbles of buffered messages with the stored
+// messages once they have been written.
+func (cv *ChatView) pendingSaved(saved []*store.PendingMessage) {
+	for _, p := range saved {
+		if bubble, ok := cv.pendingBubbles[p]; ok {
+			delete(cv.pendingBubbles, p)
+			cv.setBubbleMessage(bubble, p.Message.ID)
+		}
+	}
+}
+
+// OnStorageError sets the callback for when a message can't be saved and
+// is kept in memory instead.
+func (cv *ChatView) OnStorageError(callback func(error)) {
+	cv.onStorageError = callback
+}
+
+// storageFailureMessage describes a database failure for the banner.
+func storageFailureMessage(kind store.FailureKind) string {
+	switch kind {
+	case store.FailureDiskFull:
+		return i18n.T("The disk is full. New messages are kept in memory until they can be saved.")
+	case store.FailureReadOnly:
+		return i18n.T("The database is read-only. New messages are kept in memory until they can be saved.")
+	case store.FailureCorrupt:
+		return i18n.T("The database is damaged. New messages are kept in memory and will be lost when Guanaco closes.")
+	default:
+		return i18n.T("Messages can't be saved right now. They are kept in memory until the database recovers.")
+	}
+}
+
+// newStorageBanner creates the banner shown while messages can't be saved,
+// revealed if they already can't when the window opens.
+func (w *MainWindow) newStorageBanner() *adw.Banner {
+	banner := adw.NewBanner(w.app.storageFailure)
+	banner.SetButtonLabel(i18n.T("Retry Now"))
+	banner.SetRevealed(w.app.storageFailure != "")
+	banner.ConnectButtonClicked(func() {
+		w.app.cancelStorageRetry()
+		w.app.retryStorage()
+	})
+	return banner
+}
+
+// onStorageError alerts the user that messages are being kept in memory.
+func (w *MainWindow) onStorageError(err error) {
+	w.app.storageFailed(err)
+}
+
+// The database and the messages kept in memory are shared by the windows,
+// so the application reconnects, and the banner of every window shows
+// while messages wait.
+
+// storageFailed shows the banner in every window and, when the failure may
+// go away, starts trying to reconnect.
+func (a *Application) storageFailed(err error) {
+	kind := store.ClassifyError(err)
+	a.showStorageFailure(storageFailureMessage(kind))
+
+	if kind.Recoverable() {
+		a.scheduleStorageRetry()
+	}
+}
+
+// showStorageFailure sets the banner of every window to message, hiding it
+// if message is "".
+func (a *Application) showStorageFailure(message string) {
+	a.storageFailure = message
+	for _, w := range a.windows {
+		if message != "" {
+			w.storageBanner.SetTitle(message)
+		}
+		w.storageBanner.SetRevealed(message != "")
+	}
+}
+
+// scheduleStorageRetry schedules the next reconnection attempt, unless one
+// is already scheduled.
+func (a *Application) scheduleStorageRetry() {
+	if a.storageRetry != 0 {
+		return
+	}
+	if a.storageRetryDelay == 0 {
+		a.storageRetryDelay = storageRetryMinDelay
+	}
+
+	logger.Info("Scheduling database reconnection", "delay", a.storageRetryDelay)
+	a.storageRetry = glib.TimeoutSecondsAdd(a.storageRetryDelay, func() bool {
+		a.storageRetry = 0
+		a.retryStorage()
+		return false
+	})
+}
+
+// cancelStorageRetry cancels the scheduled reconnection attempt, if any.
+func (a *Application) cancelStorageRetry() {
+	if a.storageRetry != 0 {
+		glib.SourceRemove(a.storageRetry)
+		a.storageRetry = 0
+	}
+}
+
+// retryStorage reconnects to the database and saves the messages kept in
+// memory, whichever window they were sent from. On failure the next attempt
+// waits twice as long.
+func (a *Application) retryStorage() {
+	if a.db.PendingCount() == 0 {
+		a.storageRecovered()
+		return
+	}
+
+	err := a.db.Reconnect()
+	if err == nil {
+		var saved []*store.PendingMessage
+		saved, err = a.db.FlushPending()
+		for _, w := range a.windows {
+			w.chatView.pendingSaved(saved)
+		}
+	}
+	if err != nil {
+		kind := store.ClassifyError(err)
+		logger.Error("Database reconnection failed", "kind", kind, "pending", a.db.PendingCount(), "error", err)
+		a.showStorageFailure(storageFailureMessage(kind))
+
+		a.storageRetryDelay = min(a.storageRetryDelay*2, storageRetryMaxDelay)
+		if kind.Recoverable() {
+			a.scheduleStorageRetry()
+		}
+		return
+	}
+
+	logger.Info("Database recovered, pending messages saved")
+	a.storageRecovered()
+	for _, w := range a.windows {
+		w.showToast(i18n.T("Unsaved messages have been saved"))
+	}
+}
+
+// storageRecovered hides the banners and resets the retry delay.
+func (a *Application) storageRecovered() {
+	a.storageRetryDelay = 0
+	a.showStorageFailure("")
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// streamStats measures the latency and rendering of one streamed response
+// for the debug overlay. It is updated from the streaming goroutine and the
+// main thread, and read by the overlay.
+type streamStats struct {
+	mu sync.Mutex
+
+	began      time.Time // User sent the message
+	sent       time.Time // Request handed to Ollama
+	firstToken time.Time
+	lastToken  time.Time
+	finished   bool
+	tokens     int
+
+	// Content flushes scheduled with glib.IdleAdd but not yet run
+	queued    int
+	maxQueued int
+
+	lastFlush        time.Time
+	flushInterval    time.Duration
+	maxFlushInterval time.Duration
+}
+
+// streamSnapshot is a copy of the measurements at one point in time.
+type streamSnapshot struct {
+	Streaming        bool
+	SendLatency      time.Duration // From sending to the request being made
+	FirstToken       time.Duration // From the request to the first token
+	Tokens           int
+	TokensPerSec     float64
+	QueueDepth       int
+	MaxQueueDepth    int
+	FlushInterval    time.Duration // Between the last two content flushes
+	MaxFlushInterval time.Duration
+}
+
+// Begin resets the measurements for a new message.
+func (s *streamStats) Begin(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.began = now
+	s.sent, s.firstToken, s.lastToken = time.Time{}, time.Time{}, time.Time{}
+	s.finished = false
+	s.tokens = 0
+	s.queued, s.maxQueued = 0, 0
+	s.lastFlush = time.Time{}
+	s.flushInterval, s.maxFlushInterval = 0, 0
+}
+
+// RequestSent records when the request was made.
+func (s *streamStats) RequestSent(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = now
+}
+
+// Token records a streamed token.
+func (s *streamStats) Token(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == 0 {
+		s.firstToken = now
+	}
+	s.lastToken = now
+	s.tokens++
+}
+
+// FlushQueued records a content flush scheduled on the main thread.
+func (s *streamStats) FlushQueued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued++
+	s.maxQueued = max(s.maxQueued, s.queued)
+}
+
+// Flushed records a content flush running on the main thread.
+func (s *streamStats) Flushed(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued = max(s.queued-1, 0)
+	if !s.lastFlush.IsZero() {
+		s.flushInterval = now.Sub(s.lastFlush)
+		s.maxFlushInterval = max(s.maxFlushInterval, s.flushInterval)
+	}
+	s.lastFlush = now
+}
+
+// Finish marks the response as complete.
+func (s *streamStats) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = true
+}
+
+// Snapshot returns the current measurements.
+func (s *streamStats) Snapshot() streamSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := streamSnapshot{
+		Streaming:        !s.began.IsZero() && !s.finished,
+		Tokens:           s.tokens,
+		QueueDepth:       s.queued,
+		MaxQueueDepth:    s.maxQueued,
+		FlushInterval:    s.flushInterval,
+		MaxFlushInterval: s.maxFlushInterval,
+	}
+	if !s.sent.IsZero() {
+		snap.SendLatency = s.sent.Sub(s.began)
+	}
+	if s.tokens > 0 && !s.sent.IsZero() {
+		snap.FirstToken = s.firstToken.Sub(s.sent)
+	}
+	if elapsed := s.lastToken.Sub(s.firstToken); s.tokens > 1 && elapsed > 0 {
+		snap.TokensPerSec = float64(s.tokens-1) / elapsed.Seconds()
+	}
+	return snap
+}
+
+// formatLatency formats a duration in milliseconds, or a dash when it
+// hasn't been measured.
+func formatLatency(d time.Duration) string {
+	if d <= 0 {
+		return "–"
+	}
+	return fmt.Sprintf("%d ms", d.Milliseconds())
+}
+
+// Lines formats the measurements for the debug overlay.
+func (s streamSnapshot) Lines() []string {
+	return []string{
+		fmt.Sprintf(i18n.T("Send latency: %s"), formatLatency(s.SendLatency)),
+		fmt.Sprintf(i18n.T("First token: %s"), formatLatency(s.FirstToken)),
+		fmt.Sprintf(i18n.T("Tokens/sec: %.1f (%d tokens)"), s.TokensPerSec, s.Tokens),
+		fmt.Sprintf(i18n.T("Idle queue: %d (max %d)"), s.QueueDepth, s.MaxQueueDepth),
+		fmt.Sprintf(i18n.T("Flush interval: %s (max %s)"), formatLatency(s.FlushInterval), formatLatency(s.MaxFlushInterval)),
+	}
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamStats(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	var stats streamStats
+	if snap := stats.Snapshot(); snap.Streaming {
+		t.Error("Snapshot().Streaming = true before Begin()")
+	}
+
+	stats.Begin(at(0))
+	stats.RequestSent(at(20))
+	stats.Token(at(320))
+	stats.FlushQueued()
+	stats.FlushQueued()
+	stats.Flushed(at(350))
+	stats.Token(at(820))
+	stats.Flushed(at(470))
+	stats.Token(at(1320))
+
+	snap := stats.Snapshot()
+	if !snap.Streaming {
+		t.Error("Snapshot().Streaming = false while streaming")
+	}
+	if snap.SendLatency != 20*time.Millisecond {
+		t.Errorf("SendLatency = %v, want 20ms", snap.SendLatency)
+	}
+	if snap.FirstToken != 300*time.Millisecond {
+		t.Errorf("FirstToken = %v, want 300ms", snap.FirstToken)
+	}
+	if snap.Tokens != 3 {
+		t.Errorf("Tokens = %d, want 3", snap.Tokens)
+	}
+	if snap.TokensPerSec != 2 {
+		t.Errorf("TokensPerSec = %v, want 2", snap.TokensPerSec)
+	}
+	if snap.QueueDepth != 0 || snap.MaxQueueDepth != 2 {
+		t.Errorf("QueueDepth = %d (max %d), want 0 (max 2)", snap.QueueDepth, snap.MaxQueueDepth)
+	}
+	if snap.FlushInterval != 120*time.Millisecond || snap.MaxFlushInterval != 120*time.Millisecond {
+		t.Errorf("FlushInterval = %v (max %v), want 120ms", snap.FlushInterval, snap.MaxFlushInterval)
+	}
+
+	stats.Finish()
+	if stats.Snapshot().Streaming {
+		t.Error("Snapshot().Streaming = true after Finish()")
+	}
+
+	// A new message starts from scratch
+	stats.Begin(at(5000))
+	snap = stats.Snapshot()
+	if snap.Tokens != 0 || snap.MaxQueueDepth != 0 || snap.SendLatency != 0 || !snap.Streaming {
+		t.Errorf("Snapshot() after Begin() = %+v, want reset", snap)
+	}
+}
+
+func TestFormatLatency(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "–"},
+		{1500 * time.Microsecond, "1 ms"},
+		{340 * time.Millisecond, "340 ms"},
+	}
+
+	for _, tt := range tests {
+		if got := formatLatency(tt.d); got != tt.want {
+			t.Errorf("formatLatency(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -14,6 +14,16 @@ type HighlightToken struct {
 	Italic bool
 }
 
+const (
+	// defaultSyntaxStyle is a dark theme that works well with Adwaita dark.
+	defaultSyntaxStyle = "dracula"
+
+	// highContrastSyntaxStyle and highContrastLightSyntaxStyle are used when
+	// the desktop asks for high contrast, in the dark and light color schemes.
+	highContrastSyntaxStyle      = "modus-vivendi"
+	highContrastLightSyntaxStyle = "modus-operandi"
+)
+
 // SyntaxHighlighter provides syntax highlighting using Chroma.
 type SyntaxHighlighter struct {
 	style *chroma.Style
@@ -21,15 +31,24 @@ type SyntaxHighlighter struct {
 
 // NewSyntaxHighlighter creates a new syntax highlighter.
 func NewSyntaxHighlighter() *SyntaxHighlighter {
-	// Use a dark theme that works well with Adwaita dark
-	style := styles.Get("dracula")
+	sh := &SyntaxHighlighter{}
+	sh.SetStyle(defaultSyntaxStyle)
+	return sh
+}
+
+// SetStyle switches to the named Chroma style, falling back to the default
+// Chroma style if it doesn't exist.
+func (sh *SyntaxHighlighter) SetStyle(name string) {
+	style := styles.Get(name)
 	if style == nil {
 		style = styles.Fallback
 	}
+	sh.style = style
+}
 
-	return &SyntaxHighlighter{
-		style: style,
-	}
+// StyleName returns the name of the current style.
+func (sh *SyntaxHighlighter) StyleName() string {
+	return sh.style.Name
 }
 
 // Highlight tokenizes the code and returns styled tokens.
@@ -83,3 +102,26 @@ func (sh *SyntaxHighlighter) GetBackgroundColor() string {
 	}
 	return "#282a36" // Dracula default background
 }
+
+// GetForegroundColor returns the style's color of plain text. Styles that
+// don't set one get white or near-black text, whichever reads on their
+// background.
+func (sh *SyntaxHighlighter) GetForegroundColor() string {
+	text := sh.style.Get(chroma.Text)
+	if text.Colour.IsSet() {
+		return text.Colour.String()
+	}
+	if r, g, b, ok := parseHexColor(sh.GetBackgroundColor()); ok && 0.299*float64(r)+0.587*float64(g)+0.114*float64(b) > 160 {
+		return "#1f2328"
+	}
+	return "#f8f8f2" // Dracula default foreground
+}
+
+// GetLineNumberColor returns the style's color of line numbers.
+func (sh *SyntaxHighlighter) GetLineNumberColor() string {
+	numbers := sh.style.Get(chroma.LineNumbers)
+	if numbers.Colour.IsSet() && numbers.Colour != sh.style.Get(chroma.Text).Colour {
+		return numbers.Colour.String()
+	}
+	return "#6272a4" // Dracula comment color
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storo/guanaco/internal/config"
+)
+
+func TestSyntaxHighlighter_SetStyle(t *testing.T) {
+	sh := NewSyntaxHighlighter()
+	if got := sh.StyleName(); got != defaultSyntaxStyle {
+		t.Errorf("default style = %q, want %q", got, defaultSyntaxStyle)
+	}
+
+	sh.SetStyle(highContrastSyntaxStyle)
+	if got := sh.StyleName(); got != highContrastSyntaxStyle {
+		t.Errorf("StyleName() = %q, want %q", got, highContrastSyntaxStyle)
+	}
+	if got := sh.GetBackgroundColor(); got != "#000000" {
+		t.Errorf("high contrast background = %q, want #000000", got)
+	}
+
+	sh.SetStyle("no-such-style")
+	if sh.StyleName() == "" {
+		t.Error("SetStyle() with unknown name left no style")
+	}
+}
+
+func TestSyntaxHighlighter_Colors(t *testing.T) {
+	sh := NewSyntaxHighlighter()
+	if got := sh.GetForegroundColor(); got != "#f8f8f2" {
+		t.Errorf("dracula foreground = %q, want #f8f8f2", got)
+	}
+
+	sh.SetStyle(highContrastLightSyntaxStyle)
+	if got := sh.GetBackgroundColor(); got != "#ffffff" {
+		t.Errorf("high contrast light background = %q, want #ffffff", got)
+	}
+	if got := sh.GetForegroundColor(); got != "#000000" {
+		t.Errorf("high contrast light foreground = %q, want #000000", got)
+	}
+	if _, _, _, ok := parseHexColor(sh.GetLineNumberColor()); !ok {
+		t.Errorf("GetLineNumberColor() = %q, want a #rrggbb color", sh.GetLineNumberColor())
+	}
+}
+
+func TestSyntaxStyleFor(t *testing.T) {
+	tests := []struct {
+		dark, highContrast bool
+		want               string
+	}{
+		{false, false, config.DefaultSyntaxStyleLight},
+		{true, false, config.DefaultSyntaxStyleDark},
+		{false, true, highContrastLightSyntaxStyle},
+		{true, true, highContrastSyntaxStyle},
+	}
+	for _, tt := range tests {
+		if got := syntaxStyleFor(tt.dark, tt.highContrast); got != tt.want {
+			t.Errorf("syntaxStyleFor(%v, %v) = %q, want %q", tt.dark, tt.highContrast, got, tt.want)
+		}
+	}
+	for _, name := range []string{config.DefaultSyntaxStyleLight, config.DefaultSyntaxStyleDark, highContrastLightSyntaxStyle} {
+		if indexOf(syntaxStyles, name, -1) < 0 {
+			t.Errorf("style %q is not offered", name)
+		}
+	}
+}
+
+func TestSyntaxCSS(t *testing.T) {
+	css := syntaxCSS("#ffffff", "#24292f", false)
+	if !strings.Contains(css, "background: #ffffff;") || !strings.Contains(css, "color: #24292f;") {
+		t.Errorf("syntaxCSS() = %q, want the background and text colors", css)
+	}
+	if strings.Contains(css, "border") {
+		t.Errorf("syntaxCSS() = %q, want no border without high contrast", css)
+	}
+	if css := syntaxCSS("#000000", "#ffffff", true); !strings.Contains(css, "border: 1px solid @borders;") {
+		t.Errorf("high contrast syntaxCSS() = %q, want a border", css)
+	}
+}
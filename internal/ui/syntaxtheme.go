@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// Code is highlighted with one Chroma style in the light color scheme and
+// another in the dark one, chosen in the appearance settings. The code
+// block colors come from the style, and code already shown is highlighted
+// again when the style changes.
+
+// Styles chosen for each color scheme, set from the settings by
+// applyAppearance.
+var (
+	syntaxStyleLight = config.DefaultSyntaxStyleLight
+	syntaxStyleDark  = config.DefaultSyntaxStyleDark
+)
+
+var (
+	syntaxStyle      string           // Style applied, "" until applied
+	syntaxContrast   bool             // Whether it was applied for high contrast
+	syntaxGeneration int              // Counts the styles applied
+	syntaxProvider   *gtk.CSSProvider // Code block colors of the style
+	syntaxFollowers  = map[int]func(){}
+	nextFollowerID   int
+)
+
+// syntaxStyleFor returns the style of code in a color scheme.
+func syntaxStyleFor(dark, highContrast bool) string {
+	switch {
+	case highContrast && dark:
+		return highContrastSyntaxStyle
+	case highContrast:
+		return highContrastLightSyntaxStyle
+	case dark:
+		return syntaxStyleDark
+	default:
+		return syntaxStyleLight
+	}
+}
+
+// setSyntaxStyles sets the styles of the light and dark color schemes and
+// applies the one of the current scheme.
+func setSyntaxStyles(light, dark string) {
+	syntaxStyleLight, syntaxStyleDark = light, dark
+	updateSyntaxStyle()
+}
+
+// setupSyntaxStyle applies the style of the current color scheme and
+// follows changes of the scheme.
+func setupSyntaxStyle() {
+	adw.StyleManagerGetDefault().NotifyProperty("dark", updateSyntaxStyle)
+	updateSyntaxStyle()
+}
+
+// updateSyntaxStyle switches to the style of the current color scheme, if
+// it isn't applied yet.
+func updateSyntaxStyle() {
+	name := syntaxStyleFor(adw.StyleManagerGetDefault().Dark(), highContrast)
+	if name == syntaxStyle && highContrast == syntaxContrast {
+		return
+	}
+	syntaxStyle, syntaxContrast = name, highContrast
+	syntaxGeneration++
+	sharedHighlighter.SetStyle(name)
+	logger.Debug("Syntax style", "style", sharedHighlighter.StyleName())
+
+	display := gdk.DisplayGetDefault()
+	if syntaxProvider != nil {
+		gtk.StyleContextRemoveProviderForDisplay(display, syntaxProvider)
+	}
+	syntaxProvider = gtk.NewCSSProvider()
+	syntaxProvider.LoadFromData(syntaxCSS(sharedHighlighter.GetBackgroundColor(), sharedHighlighter.GetForegroundColor(), highContrast))
+	// Above the app stylesheet, whose code block rules it completes
+	gtk.StyleContextAddProviderForDisplay(display, syntaxProvider, gtk.STYLE_PROVIDER_PRIORITY_APPLICATION+1)
+
+	for _, rehighlight := range syntaxFollowers {
+		rehighlight()
+	}
+}
+
+// followSyntaxStyle calls rehighlight when the style changes while widget
+// is shown, or when it is shown again after a change, to highlight its code
+// with the new style.
+func followSyntaxStyle(widget *gtk.Widget, rehighlight func()) {
+	id := nextFollowerID
+	nextFollowerID++
+	generation := syntaxGeneration
+
+	refresh := func() {
+		generation = syntaxGeneration
+		rehighlight()
+	}
+	widget.ConnectRealize(func() {
+		syntaxFollowers[id] = refresh
+		if generation != syntaxGeneration {
+			refresh()
+		}
+	})
+	widget.ConnectUnrealize(func() {
+		delete(syntaxFollowers, id)
+	})
+}
+
+// syntaxCSS returns the code block colors of a style's background and text
+// colors. High contrast adds a border around code blocks.
+func syntaxCSS(background, foreground string, highContrast bool) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, ".code-block,\n.code-window {\n  background: %s;\n}\n\n", background)
+	fmt.Fprintf(&builder, ".code-lang,\n.code-content,\n.code-output {\n  color: %s;\n}\n", foreground)
+	if highContrast {
+		builder.WriteString("\n.code-block {\n  border: 1px solid @borders;\n}\n\n.code-lang {\n  opacity: 1;\n}\n")
+	}
+	return builder.String()
+}
@@ -1,38 +1,62 @@
 package ui
 
 import (
+	"strings"
+
 	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 
 	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/store"
 )
 
-// SystemPromptDialog is a dialog for editing the system prompt.
+// SystemPromptDialog is a dialog for editing the chat settings
+// (system prompt, response language override and completion mode).
 type SystemPromptDialog struct {
 	*adw.Window
 
 	// UI components
-	textView  *gtk.TextView
-	saveBtn   *gtk.Button
-	cancelBtn *gtk.Button
+	textView         *gtk.TextView
+	languageDropdown *gtk.DropDown
+	modeDropdown     *gtk.DropDown
+	templateView     *gtk.TextView
+	templateBox      *gtk.Box
+	keepAliveEntry   *gtk.Entry
+	jsonSwitch       *gtk.Switch
+	schemaView       *gtk.TextView
+	schemaBox        *gtk.Box
+	saveBtn          *gtk.Button
+	cancelBtn        *gtk.Button
 
 	// State
-	initialPrompt string
+	initialPrompt     string
+	initialLanguage   string
+	initialCompletion completionSettings
 
 	// Callbacks
-	onSave func(string)
+	onSave func(prompt, language string, completion completionSettings)
+}
+
+// completionModes are the completion modes offered, in dropdown order.
+var completionModes = []store.CompletionMode{
+	store.CompletionChat,
+	store.CompletionGenerate,
+	store.CompletionRaw,
 }
 
 // NewSystemPromptDialog creates a new system prompt dialog.
-func NewSystemPromptDialog(parent *gtk.Window, currentPrompt string) *SystemPromptDialog {
+// currentLanguage is the chat's language override ("" uses the global setting).
+func NewSystemPromptDialog(parent *gtk.Window, currentPrompt, currentLanguage string, completion completionSettings) *SystemPromptDialog {
 	d := &SystemPromptDialog{
-		initialPrompt: currentPrompt,
+		initialPrompt:     currentPrompt,
+		initialLanguage:   currentLanguage,
+		initialCompletion: completion,
 	}
 
 	d.Window = adw.NewWindow()
 	d.SetTitle(i18n.T("System Prompt"))
 	d.SetModal(true)
-	d.SetDefaultSize(450, 380)
+	d.SetDefaultSize(450, 640)
 	d.SetResizable(true)
 	if parent != nil {
 		d.SetTransientFor(parent)
@@ -85,6 +109,18 @@ func (d *SystemPromptDialog) setupUI() {
 	scrolled.AddCSSClass("card")
 	content.Append(scrolled)
 
+	// Response language override
+	langLabel := gtk.NewLabel(i18n.T("Response Language:"))
+	langLabel.SetXAlign(0)
+	langLabel.SetMarginTop(8)
+	langLabel.AddCSSClass("heading")
+	content.Append(langLabel)
+
+	d.languageDropdown = d.createLanguageDropdown()
+	content.Append(d.languageDropdown)
+
+	d.setupCompletion(content)
+
 	// Button box
 	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
 	buttonBox.SetHAlign(gtk.AlignEnd)
@@ -109,7 +145,7 @@ func (d *SystemPromptDialog) setupUI() {
 		text := buffer.Text(start, end, false)
 
 		if d.onSave != nil {
-			d.onSave(text)
+			d.onSave(text, d.selectedLanguage(), d.completionSettings())
 		}
 		d.Close()
 	})
@@ -125,7 +161,233 @@ func (d *SystemPromptDialog) setupUI() {
 	d.SetContent(toolbarView)
 }
 
-// OnSave sets the callback for when the prompt is saved.
-func (d *SystemPromptDialog) OnSave(callback func(string)) {
+// createLanguageDropdown builds the language selector. The first entry
+// keeps the global setting; the remaining ones mirror the settings dialog.
+func (d *SystemPromptDialog) createLanguageDropdown() *gtk.DropDown {
+	langList := gtk.NewStringList(nil)
+	langList.Append(i18n.T("(Global setting)"))
+
+	selectedIdx := uint(0)
+	for i, lang := range chatLanguages() {
+		langList.Append(lang.Name)
+		if lang.Code == d.initialLanguage {
+			selectedIdx = uint(i + 1) // +1 because of "Global setting" option
+		}
+	}
+
+	dropdown := gtk.NewDropDown(langList, nil)
+	dropdown.SetSelected(selectedIdx)
+
+	return dropdown
+}
+
+// selectedLanguage returns the chosen language code, or "" for the global setting.
+func (d *SystemPromptDialog) selectedLanguage() string {
+	idx := d.languageDropdown.Selected()
+	langs := chatLanguages()
+	if idx == 0 || int(idx-1) >= len(langs) {
+		return ""
+	}
+	return langs[idx-1].Code
+}
+
+// chatLanguages returns the languages a chat can be pinned to.
+// "auto" is excluded since a chat override must name a concrete language.
+func chatLanguages() []Language {
+	var langs []Language
+	for _, lang := range availableLanguages {
+		if lang.Code != "auto" {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// setupCompletion adds the completion mode settings to content.
+func (d *SystemPromptDialog) setupCompletion(content *gtk.Box) {
+	modeLabel := gtk.NewLabel(i18n.T("Completion Mode:"))
+	modeLabel.SetXAlign(0)
+	modeLabel.SetMarginTop(8)
+	modeLabel.AddCSSClass("heading")
+	content.Append(modeLabel)
+
+	modeList := gtk.NewStringList([]string{
+		i18n.T("Chat"),
+		i18n.T("Completion"),
+		i18n.T("Raw completion"),
+	})
+	d.modeDropdown = gtk.NewDropDown(modeList, nil)
+	for i, mode := range completionModes {
+		if mode == d.initialCompletion.Mode {
+			d.modeDropdown.SetSelected(uint(i))
+		}
+	}
+	content.Append(d.modeDropdown)
+
+	modeDesc := gtk.NewLabel(i18n.T("Completion modes send the conversation as a single prompt, for base models without a chat template. Raw completion sends it without any template."))
+	modeDesc.AddCSSClass("dim-label")
+	modeDesc.AddCSSClass("caption")
+	modeDesc.SetWrap(true)
+	modeDesc.SetXAlign(0)
+	content.Append(modeDesc)
+
+	// Template override, only used by the completion mode
+	d.templateBox = gtk.NewBox(gtk.OrientationVertical, 6)
+	templateLabel := gtk.NewLabel(i18n.T("Template override (optional):"))
+	templateLabel.SetXAlign(0)
+	d.templateBox.Append(templateLabel)
+
+	d.templateView = gtk.NewTextView()
+	d.templateView.SetMonospace(true)
+	d.templateView.SetWrapMode(gtk.WrapWordChar)
+	d.templateView.SetTopMargin(8)
+	d.templateView.SetBottomMargin(8)
+	d.templateView.SetLeftMargin(8)
+	d.templateView.SetRightMargin(8)
+	d.templateView.Buffer().SetText(d.initialCompletion.Template)
+
+	templateScrolled := gtk.NewScrolledWindow()
+	templateScrolled.SetChild(d.templateView)
+	templateScrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	templateScrolled.SetMinContentHeight(60)
+	templateScrolled.AddCSSClass("card")
+	d.templateBox.Append(templateScrolled)
+	content.Append(d.templateBox)
+
+	// Keep-alive applies to every mode
+	keepAliveLabel := gtk.NewLabel(i18n.T("Keep model loaded for:"))
+	keepAliveLabel.SetXAlign(0)
+	content.Append(keepAliveLabel)
+
+	d.keepAliveEntry = gtk.NewEntry()
+	d.keepAliveEntry.SetPlaceholderText(i18n.T("Default (e.g. 10m, 1h, -1 for always)"))
+	d.keepAliveEntry.SetText(d.initialCompletion.KeepAlive)
+	d.keepAliveEntry.ConnectChanged(d.validate)
+	content.Append(d.keepAliveEntry)
+
+	d.setupOutputFormat(content)
+
+	d.modeDropdown.NotifyProperty("selected", d.updateCompletionSensitivity)
+	d.updateCompletionSensitivity()
+}
+
+// setupOutputFormat adds the JSON output switch and its optional schema to
+// content.
+func (d *SystemPromptDialog) setupOutputFormat(content *gtk.Box) {
+	jsonOutput, schema := splitOutputFormat(d.initialCompletion.Format)
+
+	jsonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	jsonBox.SetMarginTop(8)
+
+	jsonText := gtk.NewBox(gtk.OrientationVertical, 2)
+	jsonText.SetHExpand(true)
+
+	jsonLabel := gtk.NewLabel(i18n.T("JSON output"))
+	jsonLabel.SetXAlign(0)
+	jsonLabel.AddCSSClass("heading")
+	jsonText.Append(jsonLabel)
+
+	jsonHint := gtk.NewLabel(i18n.T("Responses are valid JSON, shown as a collapsible tree"))
+	jsonHint.SetXAlign(0)
+	jsonHint.SetWrap(true)
+	jsonHint.AddCSSClass("dim-label")
+	jsonHint.AddCSSClass("caption")
+	jsonText.Append(jsonHint)
+	jsonBox.Append(jsonText)
+
+	d.jsonSwitch = gtk.NewSwitch()
+	d.jsonSwitch.SetActive(jsonOutput)
+	d.jsonSwitch.SetVAlign(gtk.AlignCenter)
+	jsonBox.Append(d.jsonSwitch)
+	content.Append(jsonBox)
+
+	// Schema the responses follow, only used with JSON output
+	d.schemaBox = gtk.NewBox(gtk.OrientationVertical, 6)
+	schemaLabel := gtk.NewLabel(i18n.T("JSON schema (optional):"))
+	schemaLabel.SetXAlign(0)
+	d.schemaBox.Append(schemaLabel)
+
+	d.schemaView = gtk.NewTextView()
+	d.schemaView.SetMonospace(true)
+	d.schemaView.SetWrapMode(gtk.WrapWordChar)
+	d.schemaView.SetTopMargin(8)
+	d.schemaView.SetBottomMargin(8)
+	d.schemaView.SetLeftMargin(8)
+	d.schemaView.SetRightMargin(8)
+	d.schemaView.Buffer().SetText(schema)
+	d.schemaView.Buffer().ConnectChanged(d.validate)
+
+	schemaScrolled := gtk.NewScrolledWindow()
+	schemaScrolled.SetChild(d.schemaView)
+	schemaScrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	schemaScrolled.SetMinContentHeight(80)
+	schemaScrolled.AddCSSClass("card")
+	d.schemaBox.Append(schemaScrolled)
+	content.Append(d.schemaBox)
+
+	d.jsonSwitch.NotifyProperty("active", func() {
+		d.updateCompletionSensitivity()
+		d.validate()
+	})
+}
+
+// updateCompletionSensitivity enables the settings the selected mode and
+// output format use.
+func (d *SystemPromptDialog) updateCompletionSensitivity() {
+	mode := d.selectedMode()
+	d.templateBox.SetSensitive(mode == store.CompletionGenerate)
+	d.schemaBox.SetSensitive(d.jsonSwitch.Active())
+}
+
+// validate highlights an invalid keep-alive or schema and disables saving
+// them.
+func (d *SystemPromptDialog) validate() {
+	keepAliveValid := validKeepAlive(d.keepAliveEntry.Text())
+	if keepAliveValid {
+		d.keepAliveEntry.RemoveCSSClass("error")
+	} else {
+		d.keepAliveEntry.AddCSSClass("error")
+	}
+
+	schemaValid := !d.jsonSwitch.Active() || validSchema(d.schemaText())
+	if schemaValid {
+		d.schemaView.RemoveCSSClass("error")
+	} else {
+		d.schemaView.AddCSSClass("error")
+	}
+
+	if d.saveBtn != nil {
+		d.saveBtn.SetSensitive(keepAliveValid && schemaValid)
+	}
+}
+
+// schemaText returns the JSON schema entered in the dialog.
+func (d *SystemPromptDialog) schemaText() string {
+	buffer := d.schemaView.Buffer()
+	return strings.TrimSpace(buffer.Text(buffer.StartIter(), buffer.EndIter(), false))
+}
+
+// selectedMode returns the chosen completion mode.
+func (d *SystemPromptDialog) selectedMode() store.CompletionMode {
+	idx := int(d.modeDropdown.Selected())
+	if idx >= len(completionModes) {
+		return store.CompletionChat
+	}
+	return completionModes[idx]
+}
+
+// completionSettings returns the completion settings entered in the dialog.
+func (d *SystemPromptDialog) completionSettings() completionSettings {
+	buffer := d.templateView.Buffer()
+	return completionSettings{
+		Mode:      d.selectedMode(),
+		Template:  strings.TrimSpace(buffer.Text(buffer.StartIter(), buffer.EndIter(), false)),
+		KeepAlive: strings.TrimSpace(d.keepAliveEntry.Text()),
+		Format:    outputFormatFor(d.jsonSwitch.Active(), d.schemaText()),
+	}
+}
+
+// OnSave sets the callback for when the chat settings are saved.
+func (d *SystemPromptDialog) OnSave(callback func(prompt, language string, completion completionSettings)) {
 	d.onSave = callback
 }
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"encoding/csv"
+	"html"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	east "github.com/yuin/goldmark/extension/ast"
+
+	"github.com/storo/guanaco/internal/i18n"
+)
+
+// TableBlock is a widget that displays a Markdown table as a grid, with
+// its columns aligned and a button copying it as CSV.
+type TableBlock struct {
+	*gtk.Box
+
+	// UI components
+	copyBtn *gtk.Button
+
+	// Data
+	rows [][]string // Pango markup of the cells, header first
+}
+
+// NewTableBlock creates a table of rows, the first one being the header.
+func NewTableBlock(rows [][]string, alignments []east.Alignment) *TableBlock {
+	tb := &TableBlock{rows: rows}
+
+	tb.Box = gtk.NewBox(gtk.OrientationVertical, 0)
+	tb.AddCSSClass("table-block")
+
+	header := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	header.SetMarginStart(12)
+	header.SetMarginEnd(8)
+	header.SetMarginTop(4)
+
+	spacer := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	spacer.SetHExpand(true)
+	header.Append(spacer)
+
+	tb.copyBtn = gtk.NewButton()
+	tb.copyBtn.SetIconName("edit-copy-symbolic")
+	tb.copyBtn.SetTooltipText(i18n.T("Copy as CSV"))
+	tb.copyBtn.AddCSSClass("flat")
+	tb.copyBtn.AddCSSClass("circular")
+	tb.copyBtn.ConnectClicked(tb.copyCSV)
+	header.Append(tb.copyBtn)
+	tb.Append(header)
+
+	grid := gtk.NewGrid()
+	grid.SetRowSpacing(6)
+	grid.SetColumnSpacing(16)
+	grid.SetMarginStart(12)
+	grid.SetMarginEnd(12)
+	grid.SetMarginBottom(12)
+
+	for row, cells := range rows {
+		for column, cell := range cells {
+			label := gtk.NewLabel("")
+			label.SetMarkup(cell)
+			label.SetXAlign(cellXAlign(alignments, column))
+			label.SetWrap(true)
+			label.SetWrapMode(pango.WrapWordChar)
+			label.SetMaxWidthChars(40)
+			label.SetSelectable(true)
+			if row == 0 {
+				label.AddCSSClass("heading")
+				label.AddCSSClass("table-header")
+			}
+			grid.Attach(label, column, row, 1, 1)
+		}
+	}
+
+	// Wide tables scroll sideways rather than widening the message
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(grid)
+	scrolled.SetPolicy(gtk.PolicyAutomatic, gtk.PolicyNever)
+	scrolled.SetPropagateNaturalHeight(true)
+	tb.Append(scrolled)
+
+	return tb
+}
+
+// cellXAlign returns the horizontal alignment of the cells of a column.
+func cellXAlign(alignments []east.Alignment, column int) float32 {
+	if column >= len(alignments) {
+		return 0
+	}
+	switch alignments[column] {
+	case east.AlignCenter:
+		return 0.5
+	case east.AlignRight:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// tableCSV returns rows of Pango markup as CSV, with the text of each cell.
+func tableCSV(rows [][]string) string {
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	for _, cells := range rows {
+		record := make([]string, len(cells))
+		for i, cell := range cells {
+			record[i] = html.UnescapeString(pangoTagPattern.ReplaceAllString(cell, ""))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return builder.String()
+}
+
+func (tb *TableBlock) copyCSV() {
+	gdk.DisplayGetDefault().Clipboard().SetText(tableCSV(tb.rows))
+
+	// Visual feedback - change icon temporarily
+	tb.copyBtn.SetIconName("object-select-symbolic")
+	tb.copyBtn.SetTooltipText(i18n.T("Copied!"))
+
+	glib.TimeoutAdd(1500, func() bool {
+		tb.copyBtn.SetIconName("edit-copy-symbolic")
+		tb.copyBtn.SetTooltipText(i18n.T("Copy as CSV"))
+		return false
+	})
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"testing"
+
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+func TestTableCSV(t *testing.T) {
+	rows := [][]string{
+		{"Item", "<b>Notes</b>"},
+		{"Tea &amp; cake", `Says "hi", twice`},
+		{"<a href=\"https://example.com\">Link</a>", ""},
+	}
+	want := "Item,Notes\nTea & cake,\"Says \"\"hi\"\", twice\"\nLink,\n"
+	if got := tableCSV(rows); got != want {
+		t.Errorf("tableCSV() = %q, want %q", got, want)
+	}
+}
+
+func TestCellXAlign(t *testing.T) {
+	alignments := []east.Alignment{east.AlignLeft, east.AlignCenter, east.AlignRight, east.AlignNone}
+	for column, want := range []float32{0, 0.5, 1, 0, 0} {
+		if got := cellXAlign(alignments, column); got != want {
+			t.Errorf("cellXAlign(%d) = %v, want %v", column, got, want)
+		}
+	}
+}
+
+func TestContainsTable(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"| a | b |\n|---|---|\n| 1 | 2 |", true},
+		{"a | b\n:--- | ---:\n1 | 2", true},
+		{"| a | b |", false},
+		{"Some text\n\n---\n\nMore", false},
+		{"Use a | b in the shell", false},
+	}
+	for _, tt := range tests {
+		if got := containsTable(tt.content); got != tt.want {
+			t.Errorf("containsTable(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/rag"
+)
+
+// Limits of the preview grid, which holds a label per cell. The attachment
+// itself is not limited by them.
+const (
+	maxPreviewRows    = 200
+	maxPreviewColumns = 40
+)
+
+// tableRowsCaption describes how many rows of a table with total rows are
+// sent when it is limited to maxRows.
+func tableRowsCaption(total, maxRows int) string {
+	if maxRows <= 0 || total <= maxRows {
+		return fmt.Sprintf(i18n.N("%d row", "%d rows", uint(total)), total)
+	}
+	return fmt.Sprintf(i18n.T("%d of %d rows, the first and last ones"), maxRows, total)
+}
+
+// TablePreviewDialog shows the tables read from a spreadsheet before they
+// are attached, one page per sheet.
+type TablePreviewDialog struct {
+	*adw.Window
+
+	// Data
+	filename string
+	tables   []rag.Table
+	maxRows  int
+
+	// Callbacks
+	onAttach func()
+}
+
+// NewTablePreviewDialog creates a preview of the tables of filename, each
+// limited to maxRows rows as they will be sent.
+func NewTablePreviewDialog(parent *gtk.Window, filename string, tables []rag.Table, maxRows int) *TablePreviewDialog {
+	d := &TablePreviewDialog{
+		filename: filename,
+		tables:   tables,
+		maxRows:  maxRows,
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(filename)
+	d.SetModal(true)
+	d.SetDefaultSize(720, 520)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+
+	return d
+}
+
+func (d *TablePreviewDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(adw.NewWindowTitle(d.filename, i18n.T("Spreadsheet preview")))
+
+	content := gtk.NewBox(gtk.OrientationVertical, 12)
+	content.SetMarginTop(12)
+	content.SetMarginBottom(24)
+	content.SetMarginStart(24)
+	content.SetMarginEnd(24)
+
+	stack := gtk.NewStack()
+	stack.SetVExpand(true)
+	sheets := 0
+	for i, table := range d.tables {
+		if table.Columns() == 0 {
+			continue
+		}
+		title := table.Name
+		if title == "" {
+			title = d.filename
+		}
+		stack.AddTitled(d.createTablePage(table), fmt.Sprintf("sheet-%d", i), title)
+		sheets++
+	}
+
+	// Sheets are switched above the table
+	if sheets > 1 {
+		switcher := gtk.NewStackSwitcher()
+		switcher.SetStack(stack)
+		switcher.SetHAlign(gtk.AlignCenter)
+		content.Append(switcher)
+	}
+	content.Append(stack)
+
+	// === Buttons ===
+	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	buttonBox.SetHAlign(gtk.AlignEnd)
+	buttonBox.SetMarginTop(4)
+
+	cancelBtn := gtk.NewButton()
+	cancelBtn.SetLabel(i18n.T("Cancel"))
+	cancelBtn.ConnectClicked(func() {
+		d.Close()
+	})
+	buttonBox.Append(cancelBtn)
+
+	attachBtn := gtk.NewButton()
+	attachBtn.SetLabel(i18n.T("Attach"))
+	attachBtn.AddCSSClass("suggested-action")
+	attachBtn.ConnectClicked(func() {
+		if d.onAttach != nil {
+			d.onAttach()
+		}
+		d.Close()
+	})
+	buttonBox.Append(attachBtn)
+
+	content.Append(buttonBox)
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(content)
+
+	d.SetContent(toolbarView)
+}
+
+// createTablePage creates the grid of a table with the rows that will be
+// sent, marking the left out ones with a row of ellipses.
+func (d *TablePreviewDialog) createTablePage(table rag.Table) *gtk.Box {
+	page := gtk.NewBox(gtk.OrientationVertical, 8)
+
+	caption := tableRowsCaption(len(table.Rows), d.maxRows)
+	if table.Columns() > maxPreviewColumns {
+		caption += " · " + fmt.Sprintf(i18n.T("first %d of %d columns shown"), maxPreviewColumns, table.Columns())
+	}
+	captionLabel := gtk.NewLabel(caption)
+	captionLabel.SetXAlign(0)
+	captionLabel.AddCSSClass("dim-label")
+	captionLabel.AddCSSClass("caption")
+	page.Append(captionLabel)
+
+	grid := gtk.NewGrid()
+	grid.SetRowSpacing(4)
+	grid.SetColumnSpacing(16)
+	grid.SetMarginTop(8)
+	grid.SetMarginBottom(8)
+	grid.SetMarginStart(8)
+	grid.SetMarginEnd(8)
+
+	columns := min(table.Columns(), maxPreviewColumns)
+	row := 0
+	addRow := func(cells []string, classes ...string) {
+		for column := 0; column < columns; column++ {
+			text := ""
+			if column < len(cells) {
+				text = cells[column]
+			}
+			label := gtk.NewLabel(text)
+			label.SetXAlign(0)
+			label.SetSingleLineMode(true)
+			label.SetEllipsize(pango.EllipsizeEnd)
+			label.SetMaxWidthChars(30)
+			label.SetSelectable(true)
+			for _, class := range classes {
+				label.AddCSSClass(class)
+			}
+			grid.Attach(label, column, row, 1, 1)
+		}
+		row++
+	}
+
+	addRow(table.Header, "heading")
+
+	// The preview follows what is sent, within its own limit
+	previewRows := d.maxRows
+	if previewRows <= 0 || previewRows > maxPreviewRows {
+		previewRows = maxPreviewRows
+	}
+	head, tail := rag.SampleRows(table.Rows, previewRows)
+	for _, cells := range head {
+		addRow(cells)
+	}
+	if tail != nil {
+		ellipsis := make([]string, columns)
+		for i := range ellipsis {
+			ellipsis[i] = "…"
+		}
+		addRow(ellipsis, "dim-label")
+		for _, cells := range tail {
+			addRow(cells)
+		}
+	}
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(grid)
+	scrolled.SetPolicy(gtk.PolicyAutomatic, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+	scrolled.AddCSSClass("card")
+	page.Append(scrolled)
+
+	return page
+}
+
+// OnAttach sets the callback for when the user attaches the tables.
+func (d *TablePreviewDialog) OnAttach(callback func()) {
+	d.onAttach = callback
+}
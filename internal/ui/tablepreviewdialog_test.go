@@ -0,0 +1,25 @@
+package ui
+
+import "testing"
+
+func TestTableRowsCaption(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		maxRows int
+		want    string
+	}{
+		{"one row", 1, 200, "1 row"},
+		{"fits", 150, 200, "150 rows"},
+		{"no limit", 5000, 0, "5000 rows"},
+		{"sampled", 5000, 200, "200 of 5000 rows, the first and last ones"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tableRowsCaption(tt.total, tt.maxRows); got != tt.want {
+				t.Errorf("tableRowsCaption(%d, %d) = %q, want %q", tt.total, tt.maxRows, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Typing "/" at the start of an empty input opens a picker of the saved
+// prompt templates, filtered as the user keeps typing. The picker doesn't
+// take the focus: the arrow keys move its selection and Enter picks a
+// template while the text stays in the input.
+
+// templateQuery returns the search typed after a "/" that opens the
+// template picker. ok is false when text isn't a template search: it must
+// start with the slash and fit on one line.
+func templateQuery(text string) (query string, ok bool) {
+	if !strings.HasPrefix(text, "/") || strings.Contains(text, "\n") {
+		return "", false
+	}
+	return strings.TrimSpace(text[1:]), true
+}
+
+// filterTemplates returns the templates matching query, ignoring case:
+// those whose name starts with it, then those whose name contains it, then
+// those whose content does.
+func filterTemplates(templates []*store.PromptTemplate, query string) []*store.PromptTemplate {
+	query = strings.ToLower(query)
+	if query == "" {
+		return templates
+	}
+
+	var prefix, name, content []*store.PromptTemplate
+	for _, tmpl := range templates {
+		lowerName := strings.ToLower(tmpl.Name)
+		switch {
+		case strings.HasPrefix(lowerName, query):
+			prefix = append(prefix, tmpl)
+		case strings.Contains(lowerName, query):
+			name = append(name, tmpl)
+		case strings.Contains(strings.ToLower(tmpl.Content), query):
+			content = append(content, tmpl)
+		}
+	}
+	return append(append(prefix, name...), content...)
+}
+
+// SetPromptTemplates sets the templates offered when "/" is typed.
+func (ia *InputArea) SetPromptTemplates(templates []*store.PromptTemplate) {
+	ia.templates = templates
+}
+
+// OnTemplateChosen sets the callback for when a template is picked. The
+// "/" search has been cleared from the input by then.
+func (ia *InputArea) OnTemplateChosen(callback func(*store.PromptTemplate)) {
+	ia.onTemplateChosen = callback
+}
+
+// OnManageTemplates sets the callback for the button of the template
+// picker that opens the templates library.
+func (ia *InputArea) OnManageTemplates(callback func()) {
+	ia.onManageTemplates = callback
+}
+
+// updateTemplatePicker shows, filters or hides the template picker after
+// the text changed.
+func (ia *InputArea) updateTemplatePicker() {
+	query, ok := templateQuery(ia.GetText())
+	if !ok {
+		ia.pickerDismissed = false
+		ia.hideTemplatePicker()
+		return
+	}
+	if ia.pickerDismissed {
+		return
+	}
+
+	ia.pickerMatches = filterTemplates(ia.templates, query)
+	if len(ia.pickerMatches) == 0 && (len(ia.templates) > 0 || query != "") {
+		// Probably not meant as a template search, such as a path
+		ia.hideTemplatePicker()
+		return
+	}
+	ia.showTemplatePicker()
+}
+
+// showTemplatePicker shows the picker with the current matches, creating it
+// if needed.
+func (ia *InputArea) showTemplatePicker() {
+	if ia.picker == nil {
+		ia.picker = gtk.NewPopover()
+		ia.picker.SetAutohide(false)
+		ia.picker.SetHasArrow(false)
+		ia.picker.SetPosition(gtk.PosTop)
+		ia.picker.SetHAlign(gtk.AlignStart)
+
+		ia.pickerList = gtk.NewListBox()
+		ia.pickerList.SetSelectionMode(gtk.SelectionSingle)
+		ia.pickerList.ConnectRowActivated(func(row *gtk.ListBoxRow) {
+			ia.chooseTemplate(row.Index())
+		})
+
+		scrolled := gtk.NewScrolledWindow()
+		scrolled.SetChild(ia.pickerList)
+		scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+		scrolled.SetPropagateNaturalHeight(true)
+		scrolled.SetMaxContentHeight(260)
+		scrolled.SetSizeRequest(320, -1)
+
+		manageBtn := gtk.NewButtonWithLabel(i18n.T("Manage Templates…"))
+		manageBtn.AddCSSClass("flat")
+		manageBtn.ConnectClicked(func() {
+			ia.pickerDismissed = true
+			ia.hideTemplatePicker()
+			if ia.onManageTemplates != nil {
+				ia.onManageTemplates()
+			}
+		})
+
+		box := gtk.NewBox(gtk.OrientationVertical, 4)
+		box.Append(scrolled)
+		box.Append(manageBtn)
+		ia.picker.SetChild(box)
+
+		picker := ia.picker
+		picker.SetParent(ia.scrolled)
+		picker.ConnectClosed(func() {
+			// Unparent once the close animation has been handled
+			glib.IdleAdd(func() {
+				picker.Unparent()
+			})
+			if ia.picker == picker {
+				ia.picker = nil
+				ia.pickerList = nil
+			}
+		})
+	}
+
+	ia.pickerList.RemoveAll()
+	if len(ia.pickerMatches) == 0 {
+		hint := gtk.NewLabel(i18n.T("No templates yet. Save prompts you use often to insert them by typing /"))
+		hint.SetWrap(true)
+		hint.SetMaxWidthChars(36)
+		hint.SetMarginTop(8)
+		hint.SetMarginBottom(8)
+		hint.SetMarginStart(8)
+		hint.SetMarginEnd(8)
+		hint.AddCSSClass("dim-label")
+		row := gtk.NewListBoxRow()
+		row.SetChild(hint)
+		row.SetActivatable(false)
+		row.SetSelectable(false)
+		ia.pickerList.Append(row)
+	}
+	for _, tmpl := range ia.pickerMatches {
+		name := gtk.NewLabel(tmpl.Name)
+		name.SetXAlign(0)
+		name.SetEllipsize(pango.EllipsizeEnd)
+		name.AddCSSClass("heading")
+
+		preview := gtk.NewLabel(truncatePreview(tmpl.Content, 80))
+		preview.SetXAlign(0)
+		preview.SetEllipsize(pango.EllipsizeEnd)
+		preview.AddCSSClass("dim-label")
+		preview.AddCSSClass("caption")
+
+		box := gtk.NewBox(gtk.OrientationVertical, 2)
+		box.SetMarginTop(6)
+		box.SetMarginBottom(6)
+		box.SetMarginStart(8)
+		box.SetMarginEnd(8)
+		box.Append(name)
+		box.Append(preview)
+		ia.pickerList.Append(box)
+	}
+	if row := ia.pickerList.RowAtIndex(0); row != nil && len(ia.pickerMatches) > 0 {
+		ia.pickerList.SelectRow(row)
+	}
+
+	ia.picker.Popup()
+}
+
+// hideTemplatePicker closes the picker if it is shown.
+func (ia *InputArea) hideTemplatePicker() {
+	if ia.picker != nil {
+		ia.picker.Popdown()
+	}
+}
+
+// templatePickerKey handles the keys that control the picker while it is
+// shown, returning whether the key was used.
+func (ia *InputArea) templatePickerKey(keyval uint) bool {
+	if ia.picker == nil || !ia.picker.Visible() {
+		return false
+	}
+
+	switch keyval {
+	case gdk.KEY_Escape:
+		ia.pickerDismissed = true
+		ia.hideTemplatePicker()
+		return true
+	case gdk.KEY_Up, gdk.KEY_Down:
+		if len(ia.pickerMatches) == 0 {
+			return false
+		}
+		index := 0
+		if row := ia.pickerList.SelectedRow(); row != nil {
+			index = row.Index()
+		}
+		if keyval == gdk.KEY_Up {
+			index = (index + len(ia.pickerMatches) - 1) % len(ia.pickerMatches)
+		} else {
+			index = (index + 1) % len(ia.pickerMatches)
+		}
+		ia.pickerList.SelectRow(ia.pickerList.RowAtIndex(index))
+		return true
+	case gdk.KEY_Return, gdk.KEY_KP_Enter, gdk.KEY_Tab:
+		row := ia.pickerList.SelectedRow()
+		if row == nil {
+			return false
+		}
+		ia.chooseTemplate(row.Index())
+		return true
+	}
+	return false
+}
+
+// chooseTemplate clears the search and hands the template at index of the
+// matches to the callback.
+func (ia *InputArea) chooseTemplate(index int) {
+	if index < 0 || index >= len(ia.pickerMatches) {
+		return
+	}
+	tmpl := ia.pickerMatches[index]
+
+	ia.hideTemplatePicker()
+	ia.textView.Buffer().SetText("")
+	if ia.onTemplateChosen != nil {
+		ia.onTemplateChosen(tmpl)
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestTemplateQuery(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantQuery string
+		wantOK    bool
+	}{
+		{"/", "", true},
+		{"/tra", "tra", true},
+		{"/Summarize ", "Summarize", true},
+		{"", "", false},
+		{"hello /tra", "", false},
+		{"/tra\nmore", "", false},
+	}
+
+	for _, tt := range tests {
+		query, ok := templateQuery(tt.text)
+		if query != tt.wantQuery || ok != tt.wantOK {
+			t.Errorf("templateQuery(%q) = %q, %v, want %q, %v", tt.text, query, ok, tt.wantQuery, tt.wantOK)
+		}
+	}
+}
+
+func TestFilterTemplates(t *testing.T) {
+	templates := []*store.PromptTemplate{
+		{Name: "Explain code", Content: "Explain this code: {{text}}"},
+		{Name: "Summarize", Content: "Summarize in three bullet points:\n\n{{text}}"},
+		{Name: "Code review", Content: "Review this diff"},
+		{Name: "Translate", Content: "Translate to {{language}}: {{text}}"},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Explain code", "Summarize", "Code review", "Translate"}},
+		{"code", []string{"Code review", "Explain code"}},
+		{"TRANS", []string{"Translate"}},
+		{"bullet", []string{"Summarize"}},
+		{"language", []string{"Translate"}},
+		{"poem", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, tmpl := range filterTemplates(templates, tt.query) {
+			got = append(got, tmpl.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("filterTemplates(%q) = %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("filterTemplates(%q) = %v, want %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -30,8 +30,15 @@ func NewThinkingIndicator() *ThinkingIndicator {
 		ti.Append(dot)
 	}
 
-	// Start animation (every 200ms)
-	ti.tickerID = glib.TimeoutAdd(200, ti.animate)
+	// Start animation (every 200ms), or show static dots when the
+	// desktop asks for reduced motion
+	if motionReduced() {
+		for _, dot := range ti.dots {
+			dot.SetOpacity(0.6)
+		}
+	} else {
+		ti.tickerID = glib.TimeoutAdd(200, ti.animate)
+	}
 
 	return ti
 }
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"context"
+	"sync"
+
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/tools"
+)
+
+// maxToolRounds is how many times in a row the model may call tools before
+// its answer is taken as final.
+const maxToolRounds = 5
+
+// modelSupport remembers which models have a capability, per server. It
+// is used from the streaming goroutines, so access is locked.
+type modelSupport struct {
+	capability string                       // For logs
+	check      func(*ollama.ModelInfo) bool // Whether a model has the capability
+	fallback   bool                         // Assumed when the model can't be checked
+
+	mu     sync.Mutex
+	models map[string]bool // Keyed by server URL and model name
+}
+
+func newModelSupport(capability string, check func(*ollama.ModelInfo) bool, fallback bool) *modelSupport {
+	return &modelSupport{capability: capability, check: check, fallback: fallback, models: make(map[string]bool)}
+}
+
+// newToolSupport remembers which models can call tools. Models whose
+// information can't be loaded are treated as not supporting tools.
+func newToolSupport() *modelSupport {
+	return newModelSupport("tools", (*ollama.ModelInfo).SupportsTools, false)
+}
+
+// newVisionSupport remembers which models can see images. Models whose
+// information can't be loaded are sent the images as they are.
+func newVisionSupport() *modelSupport {
+	return newModelSupport("vision", (*ollama.ModelInfo).SupportsVision, true)
+}
+
+// supports reports whether model has the capability, asking the server the
+// first time. Models whose information can't be loaded get the fallback,
+// and are asked about again next time.
+func (s *modelSupport) supports(ctx context.Context, client ollama.API, model string) bool {
+	key := client.BaseURL() + " " + model
+	s.mu.Lock()
+	supported, known := s.models[key]
+	s.mu.Unlock()
+	if known {
+		return supported
+	}
+
+	info, err := client.ShowModel(ctx, model)
+	if err != nil {
+		logger.Error("Failed to check model support", "capability", s.capability, "model", model, "error", err)
+		return s.fallback
+	}
+	supported = s.check(info)
+
+	s.mu.Lock()
+	s.models[key] = supported
+	s.mu.Unlock()
+	logger.Info("Checked model support", "capability", s.capability, "model", model, "supported", supported)
+	return supported
+}
+
+// toolsEnabled reports whether the tools of registry are offered to model.
+func (cv *ChatView) toolsEnabled(ctx context.Context, model string, registry *tools.Registry) bool {
+	if cv.ollamaClient == nil || len(registry.Names()) == 0 {
+		return false
+	}
+	return cv.toolSupport.supports(ctx, cv.ollamaClient, model)
+}
+
+// chatWithTools streams the reply to the messages of base, offering the
+// tools of registry to the model. Tool calls are run locally and their results sent back until the
+// model answers without calling any. The statistics of all the requests
+// are summed.
+func (cv *ChatView) chatWithTools(ctx context.Context, base ollama.ChatRequest, registry *tools.Registry, onToken ollama.TokenCallback) (*ollama.ResponseStats, error) {
+	// Don't grow the caller's slice
+	messages := append([]ollama.Message(nil), base.Messages...)
+	definitions := registry.Definitions()
+
+	var stats *ollama.ResponseStats
+	for round := 0; ; round++ {
+		req := base
+		req.Messages = messages
+		if round < maxToolRounds {
+			req.Tools = definitions
+		}
+
+		result, err := cv.streamHandler.ChatWithTools(ctx, &req, onToken)
+		stats = addStats(stats, result.Stats)
+		if err != nil || len(result.ToolCalls) == 0 || req.Tools == nil {
+			return stats, err
+		}
+
+		messages = append(messages, ollama.Message{Role: "assistant", ToolCalls: result.ToolCalls})
+		for _, call := range result.ToolCalls {
+			result := registry.Run(call)
+			// Results can hold the user's files, so only their length is logged
+			logger.Info("Tool called", "tool", call.Function.Name, "resultLen", len(result))
+			logger.Debug("Tool arguments", "tool", call.Function.Name, "arguments", call.Function.Arguments)
+			messages = append(messages, ollama.Message{
+				Role:     "tool",
+				Content:  result,
+				ToolName: call.Function.Name,
+			})
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/update"
+)
+
+// Release notes longer than this are cut in the dialog, which links to the
+// full notes.
+const maxReleaseNotesLength = 1500
+
+// updateCheckTimeout bounds how long a release check may take.
+const updateCheckTimeout = 30 * time.Second
+
+// releaseNotesExcerpt returns notes cut to at most maxLength characters, at
+// a line break where possible.
+func releaseNotesExcerpt(notes string, maxLength int) string {
+	notes = strings.TrimSpace(strings.ReplaceAll(notes, "\r\n", "\n"))
+	runes := []rune(notes)
+	if len(runes) <= maxLength {
+		return notes
+	}
+	cut := string(runes[:maxLength])
+	if i := strings.LastIndex(cut, "\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "\n…"
+}
+
+// newUpdateBanner creates the banner announcing a new release, hidden until
+// one is found.
+func (w *MainWindow) newUpdateBanner() *adw.Banner {
+	banner := adw.NewBanner("")
+	banner.SetButtonLabel(i18n.T("What's New"))
+	banner.ConnectButtonClicked(func() {
+		if w.availableUpdate != nil {
+			w.showReleaseNotes(w.availableUpdate)
+		}
+	})
+	return banner
+}
+
+// checkForUpdates looks for a new release in the background when checks are
+// enabled and the last one was a week ago or more.
+func (w *MainWindow) checkForUpdates() {
+	cfg := w.appConfig
+	if !cfg.UpdateCheck || !update.Due(cfg.LastUpdateCheck, time.Now()) {
+		return
+	}
+	channel := update.Channel(cfg.UpdateChannel)
+	logger.Info("Checking for updates", "current", update.CurrentVersion, "channel", channel)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+		release, err := update.NewCheckerDefault().Check(ctx, update.CurrentVersion, channel)
+
+		glib.IdleAdd(func() {
+			if err != nil {
+				// Tried again next time the app starts
+				logger.Warn("Failed to check for updates", "error", err)
+				return
+			}
+
+			w.appConfig.LastUpdateCheck = time.Now()
+			if err := w.appConfig.Save(); err != nil {
+				logger.Error("Failed to save update check time", "error", err)
+			}
+
+			if release == nil {
+				logger.Info("Guanaco is up to date", "current", update.CurrentVersion)
+				return
+			}
+			logger.Info("New release available", "version", release.Version)
+			if release.Version == w.appConfig.SkippedUpdate {
+				return
+			}
+			w.availableUpdate = release
+			w.updateBanner.SetTitle(fmt.Sprintf(i18n.T("Guanaco %s is available"), release.Version))
+			w.updateBanner.SetRevealed(true)
+		})
+	}()
+}
+
+// showReleaseNotes shows the notes of release with a link to download it.
+func (w *MainWindow) showReleaseNotes(release *update.Release) {
+	heading := fmt.Sprintf(i18n.T("Guanaco %s"), release.Version)
+	if release.Prerelease {
+		heading = fmt.Sprintf(i18n.T("Guanaco %s (pre-release)"), release.Version)
+	}
+	notes := releaseNotesExcerpt(release.Notes, maxReleaseNotesLength)
+	if notes == "" {
+		notes = i18n.T("This release has no notes.")
+	}
+
+	dialog := adw.NewMessageDialog(&w.ApplicationWindow.Window, heading, notes)
+	dialog.AddResponse("skip", i18n.T("Skip This Version"))
+	dialog.AddResponse("later", i18n.T("Later"))
+	dialog.AddResponse("download", i18n.T("Download"))
+	dialog.SetResponseAppearance("download", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("download")
+	dialog.SetCloseResponse("later")
+
+	dialog.ConnectResponse(func(response string) {
+		switch response {
+		case "download":
+			gtk.NewURILauncher(release.URL).Launch(context.Background(), &w.ApplicationWindow.Window, nil)
+		case "skip":
+			w.appConfig.SkippedUpdate = release.Version
+			if err := w.appConfig.Save(); err != nil {
+				logger.Error("Failed to save skipped update", "error", err)
+			}
+			w.updateBanner.SetRevealed(false)
+			w.availableUpdate = nil
+		}
+	})
+
+	dialog.Present()
+}
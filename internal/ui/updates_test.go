@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReleaseNotesExcerpt(t *testing.T) {
+	long := strings.Repeat("- A fix for something\n", 20)
+
+	tests := []struct {
+		name      string
+		notes     string
+		maxLength int
+		want      string
+	}{
+		{"short", "## Fixes\r\n- Crash on start\r\n", 100, "## Fixes\n- Crash on start"},
+		{"empty", "  ", 100, ""},
+		{"cut at a line break", long, 50, "- A fix for something\n- A fix for something\n…"},
+		{"cut inside a line", "ñandú " + strings.Repeat("x", 20), 8, "ñandú xx\n…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := releaseNotesExcerpt(tt.notes, tt.maxLength); got != tt.want {
+				t.Errorf("releaseNotesExcerpt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+// heatMapWeeks is how many weeks of usage the heat map shows.
+const heatMapWeeks = 12
+
+// heatMapLevels is how many shades of non-zero usage the heat map has.
+const heatMapLevels = 4
+
+// usageTotal is the tokens spent by a model or chat.
+type usageTotal struct {
+	Label  string
+	Tokens int
+}
+
+// startOfDay returns midnight of the day of t, in t's location.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// heatMapStart returns the Monday that starts a heat map of the given
+// number of weeks, ending with the week of now.
+func heatMapStart(now time.Time, weeks int) time.Time {
+	day := startOfDay(now)
+	sinceMonday := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -sinceMonday-7*(weeks-1))
+}
+
+// daysBetween returns the number of calendar days from start to t.
+func daysBetween(start, t time.Time) int {
+	// Round, as days aren't 24 hours long around DST changes
+	return int(math.Round(startOfDay(t.In(start.Location())).Sub(start).Hours() / 24))
+}
+
+// dailyTokens sums the prompt and response tokens of usage for each of
+// the days days from start, which must be a midnight.
+func dailyTokens(usage []store.Usage, start time.Time, days int) []int {
+	totals := make([]int, days)
+	for _, u := range usage {
+		i := daysBetween(start, u.CreatedAt)
+		if i < 0 || i >= days {
+			continue
+		}
+		totals[i] += u.PromptTokens + u.EvalTokens
+	}
+	return totals
+}
+
+// heatLevel returns the shade of a day with tokens, from 0 for none to
+// heatMapLevels for the busiest day, which used most tokens.
+func heatLevel(tokens, most int) int {
+	if tokens <= 0 || most <= 0 {
+		return 0
+	}
+	level := (heatMapLevels*tokens + most - 1) / most
+	return min(level, heatMapLevels)
+}
+
+// usageTotals sums the tokens of usage grouped by key, which returns a
+// group's identity and label. The totals are sorted from the largest.
+func usageTotals(usage []store.Usage, key func(store.Usage) (id, label string)) []usageTotal {
+	index := make(map[string]int)
+	var totals []usageTotal
+	for _, u := range usage {
+		id, label := key(u)
+		i, ok := index[id]
+		if !ok {
+			i = len(totals)
+			index[id] = i
+			totals = append(totals, usageTotal{Label: label})
+		}
+		totals[i].Tokens += u.PromptTokens + u.EvalTokens
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		return totals[i].Tokens > totals[j].Tokens
+	})
+	return totals
+}
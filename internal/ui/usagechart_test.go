@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestHeatMapStart(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	tests := []struct {
+		name  string
+		now   time.Time
+		weeks int
+		want  time.Time
+	}{
+		{"wednesday", time.Date(2026, 10, 14, 15, 30, 0, 0, loc), 1, time.Date(2026, 10, 12, 0, 0, 0, 0, loc)},
+		{"monday", time.Date(2026, 10, 12, 0, 5, 0, 0, loc), 1, time.Date(2026, 10, 12, 0, 0, 0, 0, loc)},
+		{"sunday", time.Date(2026, 10, 18, 23, 0, 0, 0, loc), 1, time.Date(2026, 10, 12, 0, 0, 0, 0, loc)},
+		{"several weeks", time.Date(2026, 10, 14, 9, 0, 0, 0, loc), 3, time.Date(2026, 9, 28, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heatMapStart(tt.now, tt.weeks); !got.Equal(tt.want) {
+				t.Errorf("heatMapStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyTokens(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// Spans the end of daylight saving time on 25 October
+	start := time.Date(2026, 10, 24, 0, 0, 0, 0, loc)
+	usage := []store.Usage{
+		{CreatedAt: time.Date(2026, 10, 23, 23, 59, 0, 0, loc), EvalTokens: 1000},
+		{CreatedAt: time.Date(2026, 10, 24, 10, 0, 0, 0, loc), PromptTokens: 10, EvalTokens: 5},
+		{CreatedAt: time.Date(2026, 10, 24, 22, 30, 0, 0, time.UTC), EvalTokens: 7}, // 00:30 on the 25th in Madrid
+		{CreatedAt: time.Date(2026, 10, 26, 23, 0, 0, 0, loc), PromptTokens: 3},
+		{CreatedAt: time.Date(2026, 10, 27, 0, 0, 0, 0, loc), EvalTokens: 1000},
+	}
+
+	want := []int{15, 7, 3}
+	if got := dailyTokens(usage, start, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("dailyTokens() = %v, want %v", got, want)
+	}
+}
+
+func TestHeatLevel(t *testing.T) {
+	tests := []struct {
+		tokens, most, want int
+	}{
+		{0, 100, 0},
+		{1, 100, 1},
+		{25, 100, 1},
+		{26, 100, 2},
+		{75, 100, 3},
+		{100, 100, 4},
+		{5, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := heatLevel(tt.tokens, tt.most); got != tt.want {
+			t.Errorf("heatLevel(%d, %d) = %d, want %d", tt.tokens, tt.most, got, tt.want)
+		}
+	}
+}
+
+func TestUsageTotals(t *testing.T) {
+	usage := []store.Usage{
+		{ChatID: 1, ChatTitle: "Recipes", Model: "llama3", PromptTokens: 10, EvalTokens: 5},
+		{ChatID: 2, ChatTitle: "Recipes", Model: "qwen3", EvalTokens: 40},
+		{ChatID: 1, ChatTitle: "Recipes", Model: "llama3", PromptTokens: 20},
+		{ChatID: 3, ChatTitle: "Travel", Model: "llama3", EvalTokens: 1},
+	}
+
+	byModel := usageTotals(usage, func(u store.Usage) (string, string) {
+		return u.Model, u.Model
+	})
+	wantModels := []usageTotal{{"qwen3", 40}, {"llama3", 36}}
+	if !reflect.DeepEqual(byModel, wantModels) {
+		t.Errorf("usageTotals() by model = %v, want %v", byModel, wantModels)
+	}
+
+	// Chats with the same title stay apart
+	byChat := usageTotals(usage, func(u store.Usage) (string, string) {
+		return strconv.FormatInt(u.ChatID, 10), u.ChatTitle
+	})
+	wantChats := []usageTotal{{"Recipes", 40}, {"Recipes", 35}, {"Travel", 1}}
+	if !reflect.DeepEqual(byChat, wantChats) {
+		t.Errorf("usageTotals() by chat = %v, want %v", byChat, wantChats)
+	}
+}
@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/cairo"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+	"github.com/diamondburned/gotk4/pkg/pango"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// Heat map geometry, in pixels
+const (
+	heatCellSize = 14
+	heatCellGap  = 3
+)
+
+// usageTopCount is how many models and chats are listed by usage.
+const usageTopCount = 5
+
+// UsageDialog shows which days, models and chats used the most tokens.
+type UsageDialog struct {
+	*adw.Window
+
+	// UI components
+	stack      *gtk.Stack
+	statusPage *adw.StatusPage
+	details    *gtk.Box
+
+	// State
+	db    *store.DB
+	start time.Time // First day of the heat map
+	days  []int     // Tokens per day from start
+}
+
+// NewUsageDialog creates a dialog showing the token usage of all chats and
+// starts loading it.
+func NewUsageDialog(parent *gtk.Window, db *store.DB) *UsageDialog {
+	d := &UsageDialog{
+		db:    db,
+		start: heatMapStart(time.Now(), heatMapWeeks),
+	}
+
+	d.Window = adw.NewWindow()
+	d.SetTitle(i18n.T("Token Usage"))
+	d.SetModal(true)
+	d.SetDefaultSize(480, 620)
+	if parent != nil {
+		d.SetTransientFor(parent)
+	}
+
+	d.setupUI()
+	go d.load()
+
+	return d
+}
+
+func (d *UsageDialog) setupUI() {
+	headerBar := adw.NewHeaderBar()
+	headerBar.SetShowEndTitleButtons(true)
+	headerBar.SetShowStartTitleButtons(true)
+	headerBar.SetTitleWidget(adw.NewWindowTitle(i18n.T("Token Usage"),
+		fmt.Sprintf(i18n.N("Last %d week", "Last %d weeks", heatMapWeeks), heatMapWeeks)))
+
+	// Loading, empty and error state
+	d.statusPage = adw.NewStatusPage()
+	spinner := gtk.NewSpinner()
+	spinner.SetSizeRequest(32, 32)
+	spinner.Start()
+	d.statusPage.SetChild(spinner)
+	d.statusPage.SetTitle(i18n.T("Loading..."))
+
+	d.details = gtk.NewBox(gtk.OrientationVertical, 12)
+	d.details.SetMarginTop(16)
+	d.details.SetMarginBottom(24)
+	d.details.SetMarginStart(24)
+	d.details.SetMarginEnd(24)
+
+	scrolled := gtk.NewScrolledWindow()
+	scrolled.SetChild(d.details)
+	scrolled.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scrolled.SetVExpand(true)
+
+	d.stack = gtk.NewStack()
+	d.stack.AddNamed(d.statusPage, "status")
+	d.stack.AddNamed(scrolled, "details")
+	d.stack.SetVisibleChildName("status")
+
+	toolbarView := adw.NewToolbarView()
+	toolbarView.AddTopBar(headerBar)
+	toolbarView.SetContent(d.stack)
+
+	d.SetContent(toolbarView)
+}
+
+// load reads the usage in the background.
+func (d *UsageDialog) load() {
+	usage, err := d.db.ListUsage(d.start)
+	glib.IdleAdd(func() {
+		d.statusPage.SetChild(nil)
+		if err != nil {
+			logger.Error("Failed to load token usage", "error", err)
+			d.statusPage.SetIconName("dialog-error-symbolic")
+			d.statusPage.SetTitle(i18n.T("Could not load statistics"))
+			d.statusPage.SetDescription(err.Error())
+			return
+		}
+		if len(usage) == 0 {
+			d.statusPage.SetIconName("utilities-system-monitor-symbolic")
+			d.statusPage.SetTitle(i18n.T("No Usage Yet"))
+			d.statusPage.SetDescription(i18n.T("Token usage is recorded for each response the models generate"))
+			return
+		}
+		d.showUsage(usage)
+		d.stack.SetVisibleChildName("details")
+	})
+}
+
+// showUsage fills the dialog with usage.
+func (d *UsageDialog) showUsage(usage []store.Usage) {
+	d.days = dailyTokens(usage, d.start, daysBetween(d.start, time.Now())+1)
+	total := 0
+	for _, tokens := range d.days {
+		total += tokens
+	}
+
+	d.appendHeading(i18n.T("Tokens per Day"), fmt.Sprintf(i18n.T("%d tokens"), total))
+	d.details.Append(d.newHeatMap())
+
+	d.appendHeading(i18n.T("By Model"), "")
+	d.appendTotals(usageTotals(usage, func(u store.Usage) (string, string) {
+		return u.Model, u.Model
+	}))
+
+	d.appendHeading(i18n.T("By Chat"), "")
+	d.appendTotals(usageTotals(usage, func(u store.Usage) (string, string) {
+		return strconv.FormatInt(u.ChatID, 10), u.ChatTitle
+	}))
+}
+
+// appendHeading adds a section heading, with an optional summary at its end.
+func (d *UsageDialog) appendHeading(title, summary string) {
+	box := gtk.NewBox(gtk.OrientationHorizontal, 8)
+	box.SetMarginTop(12)
+
+	label := gtk.NewLabel(title)
+	label.AddCSSClass("heading")
+	label.SetXAlign(0)
+	label.SetHExpand(true)
+	box.Append(label)
+
+	if summary != "" {
+		value := gtk.NewLabel(summary)
+		value.AddCSSClass("dim-label")
+		box.Append(value)
+	}
+	d.details.Append(box)
+}
+
+// newHeatMap creates the grid of days, one column per week, shaded by the
+// tokens used each day.
+func (d *UsageDialog) newHeatMap() *gtk.DrawingArea {
+	most := 0
+	for _, tokens := range d.days {
+		most = max(most, tokens)
+	}
+
+	area := gtk.NewDrawingArea()
+	area.SetContentWidth(heatMapWeeks*(heatCellSize+heatCellGap) - heatCellGap)
+	area.SetContentHeight(7*(heatCellSize+heatCellGap) - heatCellGap)
+	area.SetHAlign(gtk.AlignCenter)
+	area.SetDrawFunc(func(_ *gtk.DrawingArea, cr *cairo.Context, width, height int) {
+		for i, tokens := range d.days {
+			x := float64((i / 7) * (heatCellSize + heatCellGap))
+			y := float64((i % 7) * (heatCellSize + heatCellGap))
+			roundedRectangle(cr, x, y, heatCellSize, heatCellSize, 3)
+			if level := heatLevel(tokens, most); level > 0 {
+				cr.SetSourceRGBA(0.21, 0.52, 0.89, 0.25+0.75*float64(level)/heatMapLevels)
+			} else {
+				cr.SetSourceRGBA(0.5, 0.5, 0.5, 0.15)
+			}
+			cr.Fill()
+		}
+	})
+
+	area.SetHasTooltip(true)
+	area.ConnectQueryTooltip(func(x, y int, _ bool, tooltip *gtk.Tooltip) bool {
+		step := heatCellSize + heatCellGap
+		if x%step >= heatCellSize || y%step >= heatCellSize {
+			return false
+		}
+		i := (x/step)*7 + y/step
+		if i < 0 || i >= len(d.days) {
+			return false
+		}
+		day := d.start.AddDate(0, 0, i)
+		tooltip.SetText(day.Format("2006-01-02") + ": " + fmt.Sprintf(i18n.T("%d tokens"), d.days[i]))
+		return true
+	})
+
+	return area
+}
+
+// appendTotals adds the largest totals as rows with a bar relative to the
+// first one.
+func (d *UsageDialog) appendTotals(totals []usageTotal) {
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	list.AddCSSClass("boxed-list")
+
+	most := float64(max(totals[0].Tokens, 1))
+	for _, total := range totals[:min(len(totals), usageTopCount)] {
+		box := gtk.NewBox(gtk.OrientationVertical, 6)
+		box.SetMarginTop(10)
+		box.SetMarginBottom(10)
+		box.SetMarginStart(12)
+		box.SetMarginEnd(12)
+
+		labels := gtk.NewBox(gtk.OrientationHorizontal, 8)
+		name := gtk.NewLabel(total.Label)
+		name.SetXAlign(0)
+		name.SetHExpand(true)
+		name.SetEllipsize(pango.EllipsizeEnd)
+		labels.Append(name)
+		value := gtk.NewLabel(fmt.Sprintf(i18n.T("%d tokens"), total.Tokens))
+		value.AddCSSClass("dim-label")
+		value.AddCSSClass("caption")
+		labels.Append(value)
+		box.Append(labels)
+
+		bar := gtk.NewLevelBarForInterval(0, most)
+		bar.SetValue(float64(total.Tokens))
+		box.Append(bar)
+
+		row := gtk.NewListBoxRow()
+		row.SetActivatable(false)
+		row.SetChild(box)
+		list.Append(row)
+	}
+	d.details.Append(list)
+}
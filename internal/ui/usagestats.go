@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+// messageStats converts the statistics of a response for storage.
+func messageStats(stats *ollama.ResponseStats) *store.MessageStats {
+	if stats == nil {
+		return nil
+	}
+	return &store.MessageStats{
+		PromptTokens:   stats.PromptTokens,
+		EvalTokens:     stats.EvalTokens,
+		TotalDuration:  stats.TotalDuration,
+		LoadDuration:   stats.LoadDuration,
+		PromptDuration: stats.PromptDuration,
+		EvalDuration:   stats.EvalDuration,
+	}
+}
+
+// responseStats converts stored statistics back into response statistics.
+func responseStats(stats *store.MessageStats) *ollama.ResponseStats {
+	if stats == nil {
+		return nil
+	}
+	return &ollama.ResponseStats{
+		PromptTokens:   stats.PromptTokens,
+		EvalTokens:     stats.EvalTokens,
+		TotalDuration:  stats.TotalDuration,
+		LoadDuration:   stats.LoadDuration,
+		PromptDuration: stats.PromptDuration,
+		EvalDuration:   stats.EvalDuration,
+	}
+}
+
+// addStats sums the statistics of two parts of a response, either of which
+// may be unknown.
+func addStats(a, b *ollama.ResponseStats) *ollama.ResponseStats {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	sum := a.Add(*b)
+	return &sum
+}
+
+// formatSpeed formats a generation speed, or a dash when it is unknown.
+func formatSpeed(tokensPerSecond float64) string {
+	if tokensPerSecond <= 0 {
+		return "–"
+	}
+	return fmt.Sprintf(i18n.T("%.1f tokens/s"), tokensPerSecond)
+}
+
+// formatSeconds formats a duration in seconds with one decimal.
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf(i18n.T("%.1f s"), d.Seconds())
+}
+
+// formatStatsFooter formats the footer of a response: the tokens generated
+// and how fast.
+func formatStatsFooter(stats ollama.ResponseStats) string {
+	return fmt.Sprintf(i18n.T("%d tokens"), stats.EvalTokens) + " · " + formatSpeed(stats.TokensPerSecond())
+}
+
+// formatStatsDetails formats the tooltip of a response footer.
+func formatStatsDetails(stats ollama.ResponseStats) string {
+	lines := []string{
+		fmt.Sprintf(i18n.T("Prompt: %d tokens"), stats.PromptTokens),
+		fmt.Sprintf(i18n.T("Response: %d tokens"), stats.EvalTokens),
+		fmt.Sprintf(i18n.T("Total: %d tokens"), stats.PromptTokens+stats.EvalTokens),
+	}
+	if stats.TotalDuration > 0 {
+		lines = append(lines, fmt.Sprintf(i18n.T("Time: %s"), formatSeconds(stats.TotalDuration)))
+	}
+	if stats.LoadDuration >= time.Second {
+		// Only worth mentioning when the model had to be loaded
+		lines = append(lines, fmt.Sprintf(i18n.T("Model loading: %s"), formatSeconds(stats.LoadDuration)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/storo/guanaco/internal/ollama"
+	"github.com/storo/guanaco/internal/store"
+)
+
+func TestAddStats(t *testing.T) {
+	a := &ollama.ResponseStats{PromptTokens: 10, EvalTokens: 5, EvalDuration: time.Second}
+	b := &ollama.ResponseStats{PromptTokens: 20, EvalTokens: 15, EvalDuration: time.Second}
+
+	if got := addStats(nil, nil); got != nil {
+		t.Errorf("addStats(nil, nil) = %+v, want nil", got)
+	}
+	if got := addStats(a, nil); got != a {
+		t.Errorf("addStats(a, nil) = %+v, want a", got)
+	}
+	if got := addStats(nil, b); got != b {
+		t.Errorf("addStats(nil, b) = %+v, want b", got)
+	}
+	got := addStats(a, b)
+	if got.PromptTokens != 30 || got.EvalTokens != 20 || got.EvalDuration != 2*time.Second {
+		t.Errorf("addStats(a, b) = %+v, want 30 prompt and 20 eval tokens in 2s", got)
+	}
+	if a.EvalTokens != 5 {
+		t.Error("addStats() modified its argument")
+	}
+}
+
+func TestStatsConversion(t *testing.T) {
+	stats := &ollama.ResponseStats{
+		PromptTokens:   12,
+		EvalTokens:     40,
+		TotalDuration:  3 * time.Second,
+		LoadDuration:   time.Second,
+		PromptDuration: 100 * time.Millisecond,
+		EvalDuration:   2 * time.Second,
+	}
+	if got := responseStats(messageStats(stats)); *got != *stats {
+		t.Errorf("round trip = %+v, want %+v", got, stats)
+	}
+	if messageStats(nil) != nil || responseStats((*store.MessageStats)(nil)) != nil {
+		t.Error("conversion of nil stats should be nil")
+	}
+}
+
+func TestFormatStatsFooter(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats ollama.ResponseStats
+		want  string
+	}{
+		{"measured", ollama.ResponseStats{EvalTokens: 120, EvalDuration: 4 * time.Second}, "120 tokens · 30.0 tokens/s"},
+		{"no timing", ollama.ResponseStats{EvalTokens: 7}, "7 tokens · –"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatStatsFooter(tt.stats); got != tt.want {
+				t.Errorf("formatStatsFooter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatStatsDetails(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats ollama.ResponseStats
+		want  string
+	}{
+		{
+			name:  "warm model",
+			stats: ollama.ResponseStats{PromptTokens: 30, EvalTokens: 10, TotalDuration: 1500 * time.Millisecond, LoadDuration: 20 * time.Millisecond},
+			want:  "Prompt: 30 tokens\nResponse: 10 tokens\nTotal: 40 tokens\nTime: 1.5 s",
+		},
+		{
+			name:  "model loaded",
+			stats: ollama.ResponseStats{PromptTokens: 30, EvalTokens: 10, TotalDuration: 5 * time.Second, LoadDuration: 3 * time.Second},
+			want:  "Prompt: 30 tokens\nResponse: 10 tokens\nTotal: 40 tokens\nTime: 5.0 s\nModel loading: 3.0 s",
+		},
+		{
+			name:  "no timing",
+			stats: ollama.ResponseStats{EvalTokens: 10},
+			want:  "Prompt: 0 tokens\nResponse: 10 tokens\nTotal: 10 tokens",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatStatsDetails(tt.stats); got != tt.want {
+				t.Errorf("formatStatsDetails() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
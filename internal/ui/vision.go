@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+)
+
+// visionCheckTimeout bounds how long checking the installed models for
+// vision support may take.
+const visionCheckTimeout = 15 * time.Second
+
+// suggestedVisionModels are offered for download when no installed model
+// can see images.
+const suggestedVisionModels = "gemma3, llava"
+
+// hasImageAttachments reports whether an image is attached to the message
+// being written.
+func (cv *ChatView) hasImageAttachments() bool {
+	for _, pill := range cv.inputArea.GetAttachments() {
+		if pill.IsImage() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImageSupport warns when images are attached but the current model
+// can't see them, offering to switch to an installed model that can.
+func (cv *ChatView) checkImageSupport() {
+	if cv.ollamaClient == nil || cv.currentModel == "" || !cv.hasImageAttachments() {
+		return
+	}
+
+	client := cv.ollamaClient
+	model := cv.currentModel
+	candidates := make([]string, 0, len(cv.inputArea.models))
+	for _, m := range cv.inputArea.models {
+		if m.Name != model {
+			candidates = append(candidates, m.Name)
+		}
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), visionCheckTimeout)
+		defer cancel()
+
+		if cv.visionSupport.supports(ctx, client, model) {
+			return
+		}
+		alternative := ""
+		for _, name := range candidates {
+			if cv.visionSupport.supports(ctx, client, name) {
+				alternative = name
+				break
+			}
+		}
+		logger.Info("Images attached for a model without vision", "model", model, "alternative", alternative)
+
+		glib.IdleAdd(func() {
+			// The model or the attachments changed while checking
+			if cv.currentModel != model || !cv.hasImageAttachments() {
+				return
+			}
+			cv.showVisionWarning(model, alternative)
+		})
+	}()
+}
+
+// showVisionWarning tells the user that model can't see images, with a
+// button switching to alternative when there is one.
+func (cv *ChatView) showVisionWarning(model, alternative string) {
+	action := i18n.T("Their text will be sent instead when tesseract is installed")
+	if cv.appConfig == nil || !cv.appConfig.ImageOCR {
+		action = i18n.T("They will be left out")
+	}
+
+	if alternative == "" {
+		cv.notify(fmt.Sprintf(i18n.T("%s can't see images. %s. Download a vision model such as %s to send them"), model, action, suggestedVisionModels))
+		return
+	}
+	if cv.onToast == nil {
+		cv.notify(fmt.Sprintf(i18n.T("%s can't see images. %s"), model, action))
+		return
+	}
+
+	toast := adw.NewToast(fmt.Sprintf(i18n.T("%s can't see images. %s"), model, action))
+	toast.SetTimeout(10)
+	toast.SetButtonLabel(fmt.Sprintf(i18n.T("Use %s"), alternative))
+	toast.ConnectButtonClicked(func() {
+		logger.Info("Switching to a vision model", "from", model, "to", alternative)
+		cv.inputArea.selectModel(alternative)
+	})
+	cv.onToast(toast)
+}
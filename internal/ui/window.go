@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"time"
 
 	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
@@ -11,10 +12,12 @@ import (
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 
 	"github.com/storo/guanaco/internal/config"
+	"github.com/storo/guanaco/internal/hooks"
 	"github.com/storo/guanaco/internal/i18n"
 	"github.com/storo/guanaco/internal/logger"
 	"github.com/storo/guanaco/internal/ollama"
 	"github.com/storo/guanaco/internal/store"
+	"github.com/storo/guanaco/internal/update"
 )
 
 const (
@@ -28,14 +31,24 @@ const (
 // MainWindow is the main application window.
 type MainWindow struct {
 	*adw.ApplicationWindow
+	app *Application
 
 	// UI components
-	headerBar    *HeaderBar
-	splitView    *adw.NavigationSplitView
-	toastOverlay *adw.ToastOverlay
-	statusPage   *adw.StatusPage
-	sidebar      *Sidebar
-	chatView     *ChatView
+	headerBar     *HeaderBar
+	loadedModels  *LoadedModels
+	splitView     *adw.NavigationSplitView
+	contentPage   *adw.NavigationPage
+	toastOverlay  *adw.ToastOverlay
+	storageBanner *adw.Banner
+	updateBanner  *adw.Banner
+	debugOverlay  *DebugOverlay
+	statusPage    *adw.StatusPage
+	startButton   *gtk.Button // Starts a local Ollama, on the status page
+	sidebar       *Sidebar
+	chatView      *ChatView
+	documents     *DocumentsPanel
+	docsRevealer  *gtk.Revealer
+	shortcuts     *gtk.ShortcutController // Configurable shortcuts
 
 	// State
 	ollamaClient  *ollama.Client
@@ -43,47 +56,60 @@ type MainWindow struct {
 	db            *store.DB
 	appConfig     *config.AppConfig
 	models        []ollama.Model
+
+	// Newer release found by the update check, nil if none
+	availableUpdate *update.Release
+
+	// Closing while a response streams, see stopAndClose
+	closing    bool
+	closeReady bool
+
+	// Last model download dialog opened, see confirmClose
+	modelDialog  *ModelDialog
+	inBackground bool // Hidden until its work is done
 }
 
-// NewMainWindow creates a new main window.
-func NewMainWindow(app *adw.Application) *MainWindow {
+// NewMainWindow creates a new main window. The first window of app loads
+// the settings and opens the database and the Ollama client, which the
+// windows opened after it share.
+func NewMainWindow(app *Application) *MainWindow {
 	win := &MainWindow{
-		ollamaClient: ollama.NewClientDefault(),
+		app:          app,
+		ollamaClient: app.ollamaClient,
+		db:           app.db,
+		appConfig:    app.appConfig,
 	}
 
-	win.ApplicationWindow = adw.NewApplicationWindow(&app.Application)
+	win.ApplicationWindow = adw.NewApplicationWindow(&app.Application.Application)
 	win.SetDefaultSize(DefaultWindowWidth, DefaultWindowHeight)
 	win.SetTitle("Guanaco")
 
-	win.loadConfig()
-	win.initDatabase()
+	// The shared state stays set up while running in the background
+	first := app.appConfig == nil
+	if first {
+		win.ollamaClient = ollama.NewClientDefault()
+		win.loadConfig()
+		applyInterfaceLanguage(win.appConfig)
+		win.restoreWindowSize()
+		applyAppearance(win.appConfig)
+		win.applyEndpoint()
+		win.initDatabase()
+		app.ollamaClient, app.db, app.appConfig = win.ollamaClient, win.db, win.appConfig
+	}
+	app.windows = append(app.windows, win)
+	app.releaseBackground()
+
 	win.setupUI()
 	win.checkOllamaHealth()
+	if first {
+		win.sidebar.ArchiveInactive(win.appConfig.AutoArchiveDays)
+		win.checkForUpdates()
+	}
 	win.setupCleanup()
 
 	return win
 }
 
-// setupCleanup registers cleanup handlers for window close.
-func (w *MainWindow) setupCleanup() {
-	w.ConnectCloseRequest(func() bool {
-		w.cleanup()
-		return false // Allow window to close
-	})
-}
-
-// cleanup releases all resources before window closes.
-func (w *MainWindow) cleanup() {
-	logger.Info("Cleaning up resources")
-	if w.db != nil {
-		if err := w.db.Close(); err != nil {
-			logger.Error("Failed to close database", "error", err)
-		} else {
-			logger.Info("Database closed")
-		}
-	}
-}
-
 func (w *MainWindow) loadConfig() {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -110,8 +136,30 @@ func (w *MainWindow) setupUI() {
 	// Create header bar
 	w.headerBar = NewHeaderBar()
 	w.headerBar.OnDownloadModel(w.onDownloadModel)
+	w.headerBar.OnManageModels(w.onManageModels)
 	w.headerBar.OnChatSettings(w.onChatSettings)
+	w.headerBar.OnWorkDir(func() {
+		// Ensure a chat exists to give the folder to
+		if w.chatView.GetCurrentChat() == nil {
+			w.chatView.EnsureChat(w.chatView.GetInputArea().CurrentModel())
+		}
+		w.chatView.ChooseWorkDir()
+	})
 	w.headerBar.OnToggleSidebar(w.onToggleSidebar)
+	w.headerBar.OnMultiAgent(w.onMultiAgent)
+	w.headerBar.OnCompare(w.onCompare)
+	w.headerBar.OnQuit(w.app.QuitCompletely)
+	w.headerBar.SetQuitVisible(w.appConfig.RunInBackground)
+	w.headerBar.SetMultiAgentVisible(w.appConfig.MultiAgent)
+	w.headerBar.OnMuteReadAloud(w.toggleReadAloudMuted)
+	w.headerBar.OnDocuments(func(shown bool) {
+		w.docsRevealer.SetRevealChild(shown)
+	})
+	w.loadedModels = NewLoadedModels(w.ollamaClient)
+	w.loadedModels.OnError(func(err error) {
+		w.showToast(err.Error())
+	})
+	w.headerBar.PackEnd(w.loadedModels)
 
 	// Create split view for sidebar and content
 	w.splitView = adw.NewNavigationSplitView()
@@ -124,8 +172,34 @@ func (w *MainWindow) setupUI() {
 	w.sidebar.SetWindow(&w.ApplicationWindow.Window)
 	w.sidebar.OnChatSelected(w.onChatSelected)
 	w.sidebar.OnNewChat(w.onNewChat)
-	w.sidebar.OnChatDeleted(w.onChatDeleted)
+	w.sidebar.OnChatDeleted(func(chatID int64) {
+		w.onChatDeleted(chatID)
+		w.app.chatDeleted(w, chatID)
+	})
+	w.sidebar.OnChatRenamed(func(chat *store.Chat) {
+		w.chatView.ChatRenamed(chat)
+		w.updateTitle()
+		w.app.chatRenamed(w, chat)
+	})
+	w.sidebar.OnChatLabelChanged(func(chat *store.Chat) {
+		w.chatLabelChanged(chat)
+		w.app.chatLabelChanged(w, chat)
+	})
+	w.sidebar.OnOpenInNewWindow(w.openInNewWindow)
 	w.sidebar.OnSettings(w.onSettings)
+	w.sidebar.OnExportChat(w.onExport)
+	w.sidebar.OnSendToNotes(w.onSendChatToNotes)
+	w.sidebar.OnShowStats(func(chat *store.Chat) {
+		NewChatInfoDialog(&w.ApplicationWindow.Window, w.db, chat).Present()
+	})
+	w.sidebar.OnToast(func(toast *adw.Toast) {
+		w.toastOverlay.AddToast(toast)
+	})
+	w.sidebar.OnUsage(func() {
+		if w.db != nil {
+			NewUsageDialog(&w.ApplicationWindow.Window, w.db).Present()
+		}
+	})
 
 	sidebarPage := adw.NewNavigationPage(w.sidebar, "Chats")
 	w.splitView.SetSidebar(sidebarPage)
@@ -136,42 +210,113 @@ func (w *MainWindow) setupUI() {
 	// Chat view
 	w.chatView = NewChatView(w.ollamaClient, w.db)
 	w.chatView.SetAppConfig(w.appConfig)
+	w.updateReadAloudButton()
 	w.chatView.OnError(func(err error) {
 		logger.Error("Chat error", "error", err)
 		w.showToast(err.Error())
+		w.notifyUnfocused(config.NotifyError, "error", err.Error())
+	})
+	w.chatView.OnNotice(w.showToast)
+	w.chatView.OnResponseDone(func(chat *store.Chat) {
+		w.loadedModels.Refresh()
+		w.notifyUnfocused(config.NotifyResponse, "response", fmt.Sprintf(i18n.T("Response ready in “%s”"), chat.Title))
 	})
+	w.chatView.OnToast(func(toast *adw.Toast) {
+		w.toastOverlay.AddToast(toast)
+	})
+	w.chatView.OnStorageError(w.onStorageError)
+	w.chatView.OnStreamingChanged(func(bool) {
+		w.updateTitle()
+	})
+	w.chatView.OnModelPulled(w.onChatModelPulled)
 	w.chatView.OnTitleChanged(func(title string) {
+		w.updateTitle()
 		w.sidebar.Refresh()
 		// Re-select the current chat after refresh
 		if chat := w.chatView.GetCurrentChat(); chat != nil {
 			w.sidebar.SelectChat(chat)
 		}
+		w.app.chatsChanged(w)
+	})
+	w.chatView.OnDraftChanged(func(int64) {
+		// Once the chat being opened is current, to keep it selected
+		glib.IdleAdd(func() {
+			w.refreshChats()
+			w.app.chatsChanged(w)
+		})
 	})
 	w.chatView.OnChatCreated(func(chat *store.Chat) {
+		w.updateTitle()
 		w.sidebar.AddChat(chat)
+		w.documents.SetChat(chat)
+		w.app.chatsChanged(w)
+	})
+	w.chatView.OnBranched(func(chat *store.Chat) {
+		// Selecting the branch in the sidebar opens it
+		w.sidebar.Refresh()
+		w.sidebar.SelectChat(chat)
+		w.showToast(i18n.T("Branch created"))
+		w.app.chatsChanged(w)
 	})
 	w.chatView.GetInputArea().OnModelChanged(w.onModelChanged)
+	w.chatView.GetInputArea().OnModelInfo(func(model string) {
+		NewModelInfoDialog(&w.ApplicationWindow.Window, w.ollamaClient, model).Present()
+	})
+	w.chatView.GetInputArea().OnManageTemplates(w.onManageTemplates)
+	w.loadPromptTemplates()
+
+	// Debug overlay on top of the chat, toggled with Ctrl+Shift+D
+	w.debugOverlay = NewDebugOverlay(w.chatView.stats)
+	chatOverlay := gtk.NewOverlay()
+	chatOverlay.SetChild(w.chatView)
+	chatOverlay.AddOverlay(w.debugOverlay)
+
+	// Chat documents panel on the right, toggled from the header bar
+	w.documents = NewDocumentsPanel(w.db)
+	w.documents.SetWindow(&w.ApplicationWindow.Window)
+	w.documents.OnError(func(err error) {
+		logger.Error("Documents error", "error", err)
+		w.showToast(err.Error())
+	})
+	w.documents.OnNeedChat(func() *store.Chat {
+		w.chatView.EnsureChat(w.chatView.GetInputArea().CurrentModel())
+		return w.chatView.GetCurrentChat()
+	})
+
+	w.loadPlugins()
+
+	docsBox := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	docsBox.Append(gtk.NewSeparator(gtk.OrientationVertical))
+	docsBox.Append(w.documents)
+
+	w.docsRevealer = gtk.NewRevealer()
+	w.docsRevealer.SetTransitionType(gtk.RevealerTransitionTypeSlideLeft)
+	w.docsRevealer.SetChild(docsBox)
 
-	contentPage := adw.NewNavigationPage(w.chatView, "Chat")
-	w.splitView.SetContent(contentPage)
+	chatOverlay.SetHExpand(true)
+	chatArea := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	chatArea.Append(chatOverlay)
+	chatArea.Append(w.docsRevealer)
+
+	w.contentPage = adw.NewNavigationPage(chatArea, "Guanaco")
+	w.splitView.SetContent(w.contentPage)
 
 	// Create status page for when Ollama is not running
 	w.statusPage = adw.NewStatusPage()
 	w.statusPage.SetIconName("dialog-warning-symbolic")
 	w.statusPage.SetTitle(i18n.T("Ollama Not Detected"))
-	w.statusPage.SetDescription(i18n.T("Guanaco requires Ollama to be running.\nClick the button below to start Ollama."))
 
 	// Button box for status page actions
 	buttonBox := gtk.NewBox(gtk.OrientationHorizontal, 12)
 	buttonBox.SetHAlign(gtk.AlignCenter)
 
 	// Start Ollama button
-	startButton := gtk.NewButton()
-	startButton.SetLabel(i18n.T("Start Ollama"))
-	startButton.AddCSSClass("suggested-action")
-	startButton.AddCSSClass("pill")
-	startButton.ConnectClicked(w.onStartOllama)
-	buttonBox.Append(startButton)
+	w.startButton = gtk.NewButton()
+	w.startButton.SetLabel(i18n.T("Start Ollama"))
+	w.startButton.AddCSSClass("suggested-action")
+	w.startButton.AddCSSClass("pill")
+	w.startButton.ConnectClicked(w.onStartOllama)
+	buttonBox.Append(w.startButton)
 
 	// Retry button
 	retryButton := gtk.NewButton()
@@ -182,6 +327,13 @@ func (w *MainWindow) setupUI() {
 	})
 	buttonBox.Append(retryButton)
 
+	// Settings button to switch to another server
+	settingsButton := gtk.NewButton()
+	settingsButton.SetLabel(i18n.T("Settings"))
+	settingsButton.AddCSSClass("pill")
+	settingsButton.ConnectClicked(w.onSettings)
+	buttonBox.Append(settingsButton)
+
 	w.statusPage.SetChild(buttonBox)
 
 	// Toast overlay wraps content
@@ -191,9 +343,97 @@ func (w *MainWindow) setupUI() {
 	// Main layout with toolbar view
 	toolbarView := adw.NewToolbarView()
 	toolbarView.AddTopBar(w.headerBar)
+	w.storageBanner = w.newStorageBanner()
+	toolbarView.AddTopBar(w.storageBanner)
+	w.updateBanner = w.newUpdateBanner()
+	toolbarView.AddTopBar(w.updateBanner)
 	toolbarView.SetContent(w.toastOverlay)
 
 	w.SetContent(toolbarView)
+
+	w.setupShortcuts()
+}
+
+// setupShortcuts registers the window's keyboard shortcuts.
+func (w *MainWindow) setupShortcuts() {
+	controller := gtk.NewShortcutController()
+	controller.SetScope(gtk.ShortcutScopeGlobal)
+	controller.AddShortcut(gtk.NewShortcut(
+		gtk.NewShortcutTriggerParseString("<Control><Shift>d"),
+		gtk.NewCallbackAction(func(gtk.Widgetter, *glib.Variant) bool {
+			w.debugOverlay.Toggle()
+			return true
+		}),
+	))
+	w.AddController(controller)
+
+	w.applyShortcuts()
+}
+
+// applyShortcuts (re)creates the shortcuts configured in the settings.
+func (w *MainWindow) applyShortcuts() {
+	if w.shortcuts != nil {
+		w.RemoveController(w.shortcuts)
+	}
+	w.shortcuts = gtk.NewShortcutController()
+	w.shortcuts.SetScope(gtk.ShortcutScopeGlobal)
+
+	actions := map[string]func() bool{
+		config.ShortcutNewChat: func() bool {
+			w.onNewChat()
+			return true
+		},
+		config.ShortcutNewWindow: func() bool {
+			w.app.NewWindow()
+			return true
+		},
+		config.ShortcutSearch: func() bool {
+			if w.splitView.Collapsed() {
+				w.splitView.SetShowContent(false)
+			}
+			w.sidebar.StartSearch()
+			return true
+		},
+		config.ShortcutSettings: func() bool {
+			w.onSettings()
+			return true
+		},
+		config.ShortcutCloseWindow: func() bool {
+			w.Close()
+			return true
+		},
+		config.ShortcutQuit: func() bool {
+			w.app.QuitCompletely()
+			return true
+		},
+		config.ShortcutStopStreaming: func() bool {
+			// Leave Escape to the focused widget when nothing streams
+			if !w.chatView.IsStreaming() {
+				return false
+			}
+			w.chatView.StopStreaming()
+			return true
+		},
+	}
+
+	for _, name := range config.ShortcutActions {
+		accel := w.appConfig.Shortcut(name)
+		if accel == "" {
+			continue
+		}
+		trigger := gtk.NewShortcutTriggerParseString(accel)
+		if trigger == nil {
+			logger.Warn("Invalid keyboard shortcut", "action", name, "shortcut", accel)
+			continue
+		}
+		activate := actions[name]
+		w.shortcuts.AddShortcut(gtk.NewShortcut(trigger,
+			gtk.NewCallbackAction(func(gtk.Widgetter, *glib.Variant) bool {
+				return activate()
+			}),
+		))
+	}
+	w.AddController(w.shortcuts)
 }
 
 func (w *MainWindow) checkOllamaHealth() {
@@ -201,16 +441,21 @@ func (w *MainWindow) checkOllamaHealth() {
 	defer cancel()
 
 	w.ollamaHealthy = w.ollamaClient.IsHealthy(ctx)
+	w.loadedModels.Refresh()
 
 	if !w.ollamaHealthy {
 		w.showOllamaNotRunning()
 	} else {
+		w.toastOverlay.SetChild(w.splitView)
 		w.loadModels()
 		w.sidebar.LoadChats()
 	}
 }
 
 func (w *MainWindow) showOllamaNotRunning() {
+	w.statusPage.SetDescription(fmt.Sprintf(i18n.T("Guanaco could not reach Ollama at %s.\nStart Ollama or choose another server in Settings."), w.ollamaClient.BaseURL()))
+	// Starting ollama serve here doesn't help a remote server or a socket
+	w.startButton.SetVisible(config.IsLoopbackURL(w.appConfig.ActiveEndpointURL()))
 	w.toastOverlay.SetChild(w.statusPage)
 }
 
@@ -255,6 +500,9 @@ func (w *MainWindow) loadModels() {
 
 func (w *MainWindow) onNewChat() {
 	w.chatView.NewChat()
+	w.documents.SetChat(nil)
+	w.updateReadAloudButton()
+	w.updateTitle()
 
 	// Use default model from config, or current model if none set
 	model := ""
@@ -271,51 +519,145 @@ func (w *MainWindow) onNewChat() {
 }
 
 func (w *MainWindow) onModelChanged(model string) {
-	w.chatView.SetModel(model)
+	w.chatView.SwitchModel(model)
 }
 
 func (w *MainWindow) onChatSelected(chat *store.Chat) {
 	w.chatView.SetChat(chat)
+	w.documents.SetChat(chat)
+	w.updateReadAloudButton()
+	w.updateTitle()
+}
+
+// updateTitle shows the current chat, with its icon and whether a response
+// is streaming, in the window and page titles.
+func (w *MainWindow) updateTitle() {
+	title := chatWindowTitle(w.chatView.GetCurrentChat(), w.chatView.IsStreaming())
+	w.SetTitle(title)
+	if w.contentPage != nil {
+		w.contentPage.SetTitle(title)
+	}
+}
+
+// chatLabelChanged shows the new icon of chat in the window title, when it
+// is the current chat.
+func (w *MainWindow) chatLabelChanged(chat *store.Chat) {
+	if current := w.chatView.GetCurrentChat(); current != nil && current.ID == chat.ID {
+		current.Icon, current.Color = chat.Icon, chat.Color
+		w.updateTitle()
+	}
+}
+
+// updateReadAloudButton shows the mute button while responses are read
+// aloud, for the current chat.
+func (w *MainWindow) updateReadAloudButton() {
+	w.headerBar.SetReadAloud(w.appConfig.ReadAloud, w.chatView.ReadAloudMuted())
+}
+
+// toggleReadAloudMuted mutes or unmutes reading aloud in the current chat,
+// creating the chat first if needed.
+func (w *MainWindow) toggleReadAloudMuted() {
+	if w.chatView.GetCurrentChat() == nil {
+		w.chatView.EnsureChat(w.chatView.GetInputArea().CurrentModel())
+	}
+	w.chatView.SetReadAloudMuted(!w.chatView.ReadAloudMuted())
+	w.updateReadAloudButton()
 }
 
 func (w *MainWindow) onChatDeleted(chatID int64) {
 	// If the deleted chat is the current one, start a new chat
 	if currentChat := w.chatView.GetCurrentChat(); currentChat != nil && currentChat.ID == chatID {
 		w.chatView.NewChat()
+		w.documents.SetChat(nil)
+		w.updateReadAloudButton()
+		w.updateTitle()
 	}
 }
 
 func (w *MainWindow) onDownloadModel() {
 	dialog := NewModelDialog(&w.ApplicationWindow.Window, w.ollamaClient)
+	w.modelDialog = dialog
 	dialog.OnModelDownloaded(func(model string) {
+		runHooks(w.appConfig, hooks.ModelPulled, hooks.Pull{
+			Event: hooks.ModelPulled,
+			Model: model,
+			Time:  time.Now(),
+		})
 		w.loadModels()
 		w.chatView.GetInputArea().SetModel(model)
 		w.chatView.SetModel(model)
 		w.showToast(fmt.Sprintf(i18n.T("Model %s downloaded!"), model))
+		if !dialog.IsActive() {
+			w.notifyUnfocused(config.NotifyDownload, "download", fmt.Sprintf(i18n.T("Model %s downloaded!"), model))
+		}
+	})
+	dialog.OnDownloadFailed(func(model string, err error) {
+		if !dialog.IsActive() {
+			w.notifyUnfocused(config.NotifyError, "error", fmt.Sprintf(i18n.T("Failed to download %s: %v"), model, err))
+		}
 	})
 	dialog.Present()
 }
 
+// onChatModelPulled refreshes the list of models once the missing model of
+// a chat was downloaded again.
+func (w *MainWindow) onChatModelPulled(model string) {
+	runHooks(w.appConfig, hooks.ModelPulled, hooks.Pull{
+		Event: hooks.ModelPulled,
+		Model: model,
+		Time:  time.Now(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if models, err := w.ollamaClient.ListModels(ctx); err == nil {
+		w.models = models
+		w.chatView.GetInputArea().SetModels(models)
+	} else {
+		logger.Error("Failed to load models", "error", err)
+	}
+
+	w.showToast(fmt.Sprintf(i18n.T("Model %s downloaded!"), model))
+	w.notifyUnfocused(config.NotifyDownload, "download", fmt.Sprintf(i18n.T("Model %s downloaded!"), model))
+}
+
+func (w *MainWindow) onManageModels() {
+	manager := NewModelManager(&w.ApplicationWindow.Window, w.ollamaClient)
+	manager.OnModelsChanged(w.loadModels)
+	manager.Present()
+}
+
 func (w *MainWindow) onChatSettings() {
 	// Ensure a chat exists before opening the dialog
 	if w.chatView.GetCurrentChat() == nil {
 		w.chatView.EnsureChat(w.chatView.GetInputArea().CurrentModel())
 	}
 
-	// Get current system prompt from chat
+	// Get current system prompt and language from chat
 	currentPrompt := ""
+	currentLanguage := ""
 	if chat := w.chatView.GetCurrentChat(); chat != nil {
 		currentPrompt = chat.SystemPrompt
+		currentLanguage = chat.Language
 	}
 
-	dialog := NewSystemPromptDialog(&w.ApplicationWindow.Window, currentPrompt)
-	dialog.OnSave(func(prompt string) {
+	dialog := NewSystemPromptDialog(&w.ApplicationWindow.Window, currentPrompt, currentLanguage,
+		chatCompletionSettings(w.chatView.GetCurrentChat()))
+	dialog.OnSave(func(prompt, language string, completion completionSettings) {
 		if chat := w.chatView.GetCurrentChat(); chat != nil {
 			chat.SystemPrompt = prompt
+			chat.Language = language
+			chat.CompletionMode = completion.Mode
+			chat.Template = completion.Template
+			chat.KeepAlive = completion.KeepAlive
+			chat.OutputFormat = completion.Format
 			if w.db != nil {
 				w.db.UpdateChatSystemPrompt(chat.ID, prompt)
+				w.db.UpdateChatLanguage(chat.ID, language)
+				w.db.UpdateChatCompletion(chat.ID, completion.Mode, completion.Template, completion.KeepAlive, completion.Format)
 			}
-			w.showToast(i18n.T("System prompt saved"))
+			w.chatView.UpdateOutputFormat()
+			w.showToast(i18n.T("Chat settings saved"))
 		}
 	})
 	dialog.Present()
@@ -365,26 +707,234 @@ func (w *MainWindow) onToggleSidebar() {
 	w.appConfig.Save()
 }
 
-func (w *MainWindow) onSettings() {
-	// Build model names list
-	modelNames := make([]string, len(w.models))
+// modelNames returns the names of the locally available models.
+func (w *MainWindow) modelNames() []string {
+	names := make([]string, len(w.models))
 	for i, m := range w.models {
-		modelNames[i] = m.Name
+		names[i] = m.Name
+	}
+	return names
+}
+
+func (w *MainWindow) onMultiAgent() {
+	if w.chatView.IsStreaming() {
+		return
+	}
+	if len(w.models) == 0 {
+		w.showToast(i18n.T("No models found. Use the download button to pull a model."))
+		return
+	}
+
+	dialog := NewMultiAgentDialog(&w.ApplicationWindow.Window, w.modelNames())
+	dialog.OnStart(func(cfg multiAgentConfig) {
+		w.chatView.StartMultiAgent(cfg)
+		if chat := w.chatView.GetCurrentChat(); chat != nil {
+			w.sidebar.SelectChat(chat)
+		}
+	})
+	dialog.Present()
+}
+
+// onCompare asks for a prompt and two models, and compares their answers
+// in the current chat. The text being typed is the prompt at first.
+func (w *MainWindow) onCompare() {
+	if w.chatView.IsStreaming() {
+		return
+	}
+	if len(w.models) == 0 {
+		w.showToast(i18n.T("No models found. Use the download button to pull a model."))
+		return
 	}
 
-	dialog := NewSettingsDialog(&w.ApplicationWindow.Window, w.appConfig, modelNames)
+	input := w.chatView.GetInputArea()
+	dialog := NewCompareDialog(&w.ApplicationWindow.Window, w.modelNames(), input.CurrentModel(), input.GetText())
+	dialog.OnCompare(func(prompt string, models [2]string) {
+		input.SetText("")
+		w.chatView.StartComparison(prompt, models)
+		if chat := w.chatView.GetCurrentChat(); chat != nil {
+			w.sidebar.SelectChat(chat)
+		}
+	})
+	dialog.Present()
+}
+
+func (w *MainWindow) onSettings() {
+	dialog := NewSettingsDialog(&w.ApplicationWindow.Window, w.appConfig, w.modelNames())
 	dialog.OnSave(func(cfg *config.AppConfig) {
-		w.appConfig = cfg
-		w.chatView.SetAppConfig(cfg)
+		applyAppearance(cfg)
+		languageChanged := applyInterfaceLanguage(cfg)
 
-		// Apply default model immediately if configured
-		if cfg.DefaultModel != "" {
-			w.chatView.GetInputArea().SetModel(cfg.DefaultModel)
-			w.chatView.SetModel(cfg.DefaultModel)
+		// Turning update checks off hides a release that was found
+		if cfg.UpdateCheck {
+			w.checkForUpdates()
+		} else {
+			w.updateBanner.SetRevealed(false)
+		}
+
+		// Switch server without restarting
+		endpointChanged := w.applyEndpoint()
+		for _, win := range w.app.windows {
+			win.applySettings(cfg, endpointChanged)
 		}
 
 		w.showToast(i18n.T("Settings saved"))
 		logger.Info("Settings saved", "defaultModel", cfg.DefaultModel, "language", cfg.ResponseLanguage)
+		if languageChanged {
+			// Once the settings dialog has closed
+			glib.IdleAdd(w.offerReload)
+		}
+	})
+	dialog.OnReloadStyle(func() {
+		loaded, err := reloadUserCSS()
+		switch {
+		case err != nil:
+			logger.Error("Failed to load user stylesheet", "error", err)
+			w.showToast(i18n.T("Custom style has errors, see the log for details"))
+		case loaded:
+			w.showToast(i18n.T("Custom style reloaded"))
+		default:
+			w.showToast(i18n.T("No custom style file found"))
+		}
+	})
+	dialog.OnExportAll(func() {
+		// On top of the settings, so that their changes aren't lost
+		w.showExportDialog(&dialog.Window.Window, nil)
+	})
+	dialog.OnImport(func() {
+		dialog.Close()
+		w.onImport()
+	})
+	dialog.Present()
+}
+
+// applySettings applies saved settings to the window. endpointChanged is
+// true when the Ollama server changed, so it is checked again.
+func (w *MainWindow) applySettings(cfg *config.AppConfig, endpointChanged bool) {
+	w.appConfig = cfg
+	w.chatView.SetAppConfig(cfg)
+	w.headerBar.SetQuitVisible(cfg.RunInBackground)
+	w.headerBar.SetMultiAgentVisible(cfg.MultiAgent)
+	w.chatView.applyMessageRendering()
+	w.updateReadAloudButton()
+	if !cfg.ReadAloud {
+		w.chatView.StopReading()
+	}
+	w.loadPlugins()
+	w.applyShortcuts()
+
+	if endpointChanged {
+		w.checkOllamaHealth()
+	}
+
+	// Apply default model immediately if configured
+	if cfg.DefaultModel != "" {
+		w.chatView.GetInputArea().SetModel(cfg.DefaultModel)
+		w.chatView.SetModel(cfg.DefaultModel)
+	}
+}
+
+// applyEndpoint points the Ollama client at the active endpoint from the
+// config, over its Unix socket and with its headers if it has any. Returns
+// true if the server or the way to reach it changed.
+func (w *MainWindow) applyEndpoint() bool {
+	url := ollama.DefaultBaseURL
+	var conn ollama.Connection
+	if ep, ok := w.appConfig.ActiveEndpointInfo(); ok {
+		url = ep.URL
+		conn.Headers = ep.Headers
+		if socket := ep.SocketPath(); socket != "" {
+			url = ollama.SocketBaseURL
+			conn.Socket = socket
+		}
+	}
+
+	changed := w.ollamaClient.SetConnection(conn)
+	if url != w.ollamaClient.BaseURL() {
+		w.ollamaClient.SetBaseURL(url)
+		changed = true
+	}
+	if changed {
+		logger.Info("Ollama server changed", "endpoint", w.appConfig.ActiveEndpoint, "url", url, "socket", conn.Socket)
+	}
+	return changed
+}
+
+// onImport asks for a ChatGPT or Open WebUI export and imports its chats.
+func (w *MainWindow) onImport() {
+	if w.db == nil {
+		return
+	}
+
+	chooser := gtk.NewFileChooserNative(
+		i18n.T("Import Chats"),
+		&w.ApplicationWindow.Window,
+		gtk.FileChooserActionOpen,
+		i18n.T("Open"),
+		i18n.T("Cancel"),
+	)
+	filter := gtk.NewFileFilter()
+	filter.SetName(i18n.T("Chat Exports"))
+	filter.AddPattern("*.json")
+	chooser.AddFilter(filter)
+
+	chooser.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := chooser.File(); file != nil && file.Path() != "" {
+				w.showImportDialog(file.Path())
+			}
+		}
+		chooser.Destroy()
+	})
+
+	chooser.Show()
+}
+
+// showImportDialog imports the chats of the export at path.
+func (w *MainWindow) showImportDialog(path string) {
+	model := w.appConfig.DefaultModel
+	if model == "" {
+		model = w.chatView.GetInputArea().CurrentModel()
+	}
+
+	dialog := NewImportDialog(&w.ApplicationWindow.Window, w.db, path, w.modelNames(), model)
+	dialog.OnImported(func(result store.ImportResult) {
+		w.sidebar.Refresh()
+		w.app.chatsChanged(w)
+		if result.Skipped > 0 {
+			w.showToast(fmt.Sprintf(i18n.T("Imported %d chats, skipped %d imported before"), result.Imported, result.Skipped))
+		} else {
+			w.showToast(fmt.Sprintf(i18n.T("Imported %d chats"), result.Imported))
+		}
+	})
+	dialog.OnError(func(err error) {
+		w.showToast(fmt.Sprintf(i18n.T("Import failed: %v"), err))
+	})
+	dialog.Present()
+}
+
+// onExport opens the export dialog for a chat, or for all chats when chat is nil.
+func (w *MainWindow) onExport(chat *store.Chat) {
+	w.showExportDialog(&w.ApplicationWindow.Window, chat)
+}
+
+// showExportDialog opens the export dialog on top of parent.
+func (w *MainWindow) showExportDialog(parent *gtk.Window, chat *store.Chat) {
+	dialog := NewExportDialog(parent, w.db, chat)
+	if w.appConfig != nil {
+		dialog.SetFileOptions(w.appConfig.ExportFileName, w.appConfig.ExportFrontMatter)
+	}
+	dialog.OnExported(func(path string, format store.ExportFormat, chatIDs []int64) {
+		w.showToast(fmt.Sprintf(i18n.T("Exported to %s"), filepath.Base(path)))
+		runHooks(w.appConfig, hooks.ChatExported, hooks.Export{
+			Event:   hooks.ChatExported,
+			Path:    path,
+			Format:  string(format),
+			ChatIDs: chatIDs,
+			Time:    time.Now(),
+		})
+	})
+	dialog.OnError(func(err error) {
+		w.showToast(i18n.T("Export failed"))
 	})
 	dialog.Present()
 }
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"slices"
+
+	"github.com/storo/guanaco/internal/store"
+)
+
+// The app can have several windows open at once, with Ctrl+Shift+N or
+// "Open in New Window" on a chat. They share the database, the Ollama
+// client and the settings, which the first window sets up and the last one
+// to close releases, unless Guanaco keeps running in the background.
+// Changes to the chat list made in one window are shown in the others.
+
+// NewWindow opens another window on the same chats.
+func (a *Application) NewWindow() *MainWindow {
+	win := NewMainWindow(a)
+	win.Present()
+	return win
+}
+
+// removeWindow forgets a closed window, and reports whether it was the
+// last one.
+func (a *Application) removeWindow(win *MainWindow) bool {
+	a.windows = slices.DeleteFunc(a.windows, func(w *MainWindow) bool {
+		return w == win
+	})
+	return len(a.windows) == 0
+}
+
+// otherWindows returns the open windows other than win.
+func (a *Application) otherWindows(win *MainWindow) []*MainWindow {
+	var others []*MainWindow
+	for _, w := range a.windows {
+		if w != win {
+			others = append(others, w)
+		}
+	}
+	return others
+}
+
+// chatsChanged refreshes the chat list of the windows other than from,
+// after a chat was created, renamed or imported there.
+func (a *Application) chatsChanged(from *MainWindow) {
+	for _, w := range a.otherWindows(from) {
+		w.refreshChats()
+	}
+}
+
+// chatRenamed shows the new title of chat in the windows other than from.
+func (a *Application) chatRenamed(from *MainWindow, chat *store.Chat) {
+	for _, w := range a.otherWindows(from) {
+		w.chatView.ChatRenamed(chat)
+		w.updateTitle()
+		w.refreshChats()
+	}
+}
+
+// chatLabelChanged shows the new icon and color of chat in the windows
+// other than from.
+func (a *Application) chatLabelChanged(from *MainWindow, chat *store.Chat) {
+	for _, w := range a.otherWindows(from) {
+		w.chatLabelChanged(chat)
+		w.refreshChats()
+	}
+}
+
+// chatDeleted closes a deleted chat in the windows other than from, and
+// takes it out of their chat list.
+func (a *Application) chatDeleted(from *MainWindow, chatID int64) {
+	for _, w := range a.otherWindows(from) {
+		w.onChatDeleted(chatID)
+		w.refreshChats()
+	}
+}
+
+// refreshChats reloads the chat list, keeping the current chat selected.
+func (w *MainWindow) refreshChats() {
+	w.sidebar.Refresh()
+	if chat := w.chatView.GetCurrentChat(); chat != nil {
+		w.sidebar.SelectChat(chat)
+	}
+}
+
+// openInNewWindow opens chat in a window of its own.
+func (w *MainWindow) openInNewWindow(chat *store.Chat) {
+	win := w.app.NewWindow()
+	win.onChatSelected(chat)
+	win.sidebar.SelectChat(chat)
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/diamondburned/gotk4-adwaita/pkg/adw"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/storo/guanaco/internal/i18n"
+	"github.com/storo/guanaco/internal/logger"
+	"github.com/storo/guanaco/internal/tools"
+)
+
+// A chat can have a working folder whose files models that call tools may
+// list, search and read while answering, to ask about a local project. The
+// access is read-only, is given per chat after a confirmation, and is shown
+// in a banner above the messages for as long as it lasts.
+
+// displayWorkDir returns dir as shown to the user, with the home folder
+// written as "~".
+func displayWorkDir(dir, home string) string {
+	if home == "" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(dir, home+string(filepath.Separator)); ok {
+		return filepath.Join("~", rest)
+	}
+	return dir
+}
+
+// chatTools returns the tools offered in a chat: those of the app, and the
+// file tools when the chat has a working folder.
+func (cv *ChatView) chatTools(workDir string) *tools.Registry {
+	if workDir == "" {
+		return cv.tools
+	}
+	return cv.tools.With(tools.FileTools(workDir)...)
+}
+
+// newWorkDirBanner creates the banner shown while the current chat has a
+// working folder.
+func (cv *ChatView) newWorkDirBanner() *adw.Banner {
+	banner := adw.NewBanner("")
+	banner.SetButtonLabel(i18n.T("Stop Sharing"))
+	banner.ConnectButtonClicked(func() {
+		cv.setWorkDir("")
+	})
+	return banner
+}
+
+// updateWorkDirBanner shows the working folder of the current chat, or
+// hides the banner when it has none.
+func (cv *ChatView) updateWorkDirBanner() {
+	if cv.currentChat == nil || cv.currentChat.WorkDir == "" {
+		cv.workDirBanner.SetRevealed(false)
+		return
+	}
+	home, _ := os.UserHomeDir()
+	cv.workDirBanner.SetTitle(fmt.Sprintf(i18n.T("The model can read the files in %s"), displayWorkDir(cv.currentChat.WorkDir, home)))
+	cv.workDirBanner.SetRevealed(true)
+}
+
+// ChooseWorkDir asks for the working folder of the current chat.
+func (cv *ChatView) ChooseWorkDir() {
+	if cv.currentChat == nil {
+		return
+	}
+
+	chooser := gtk.NewFileChooserNative(
+		i18n.T("Choose Working Folder"),
+		cv.parentWindow(),
+		gtk.FileChooserActionSelectFolder,
+		i18n.T("Select"),
+		i18n.T("Cancel"),
+	)
+
+	chooser.ConnectResponse(func(response int) {
+		if response == int(gtk.ResponseAccept) {
+			if file := chooser.File(); file != nil && file.Path() != "" {
+				cv.confirmWorkDir(file.Path())
+			}
+		}
+		chooser.Destroy()
+	})
+
+	chooser.Show()
+}
+
+// confirmWorkDir asks before the model is let into dir.
+func (cv *ChatView) confirmWorkDir(dir string) {
+	home, _ := os.UserHomeDir()
+	dialog := adw.NewMessageDialog(cv.parentWindow(), i18n.T("Let the Model Read This Folder?"),
+		fmt.Sprintf(i18n.T("In this chat, models that can call tools will be able to list, search and read the files in %s, and send what they read to the Ollama server.\n\nThey can't change or delete anything, or see files outside this folder. You can stop sharing it at any time."), displayWorkDir(dir, home)))
+	dialog.AddResponse("cancel", i18n.T("Cancel"))
+	dialog.AddResponse("allow", i18n.T("Allow Reading"))
+	dialog.SetResponseAppearance("allow", adw.ResponseSuggested)
+	dialog.SetDefaultResponse("allow")
+	dialog.SetCloseResponse("cancel")
+
+	dialog.ConnectResponse(func(response string) {
+		if response == "allow" {
+			cv.setWorkDir(dir)
+		}
+	})
+
+	dialog.Present()
+}
+
+// setWorkDir sets the working folder of the current chat. An empty dir
+// takes the access away.
+func (cv *ChatView) setWorkDir(dir string) {
+	chat := cv.currentChat
+	if chat == nil {
+		return
+	}
+	if cv.db != nil && chat.ID != 0 {
+		if err := cv.db.UpdateChatWorkDir(chat.ID, dir); err != nil {
+			logger.Error("Failed to save working folder", "chatID", chat.ID, "error", err)
+			cv.handleError(err)
+			return
+		}
+	}
+	chat.WorkDir = dir
+	cv.updateWorkDirBanner()
+
+	if dir == "" {
+		logger.Info("Working folder removed", "chatID", chat.ID)
+		cv.notify(i18n.T("The model can no longer read the folder"))
+	} else {
+		logger.Info("Working folder set", "chatID", chat.ID, "dir", dir)
+	}
+}
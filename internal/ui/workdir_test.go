@@ -0,0 +1,21 @@
+package ui
+
+import "testing"
+
+func TestDisplayWorkDir(t *testing.T) {
+	tests := []struct {
+		dir, home, want string
+	}{
+		{"/home/ana/src/app", "/home/ana", "~/src/app"},
+		{"/home/ana", "/home/ana", "~"},
+		{"/home/anabel/src", "/home/ana", "/home/anabel/src"},
+		{"/srv/repo", "/home/ana", "/srv/repo"},
+		{"/srv/repo", "", "/srv/repo"},
+	}
+
+	for _, tt := range tests {
+		if got := displayWorkDir(tt.dir, tt.home); got != tt.want {
+			t.Errorf("displayWorkDir(%q, %q) = %q, want %q", tt.dir, tt.home, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package update checks the GitHub releases of Guanaco for a newer version
+// than the one running. Checks are made at most once a week, and only when
+// the user hasn't turned them off.
+package update
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CurrentVersion is the version of the running build, set at build time
+// with -ldflags "-X github.com/storo/guanaco/internal/update.CurrentVersion=...".
+var CurrentVersion = "0.1.0"
+
+// ReleasesURL is the GitHub API listing the releases of Guanaco.
+const ReleasesURL = "https://api.github.com/repos/storo/guanaco/releases"
+
+// CheckInterval is how often releases are checked.
+const CheckInterval = 7 * 24 * time.Hour
+
+// maxResponseSize limits how much of the releases list is read.
+const maxResponseSize = 4 << 20
+
+// Channel selects which releases are offered.
+type Channel string
+
+const (
+	// ChannelStable offers final releases only.
+	ChannelStable Channel = "stable"
+	// ChannelPrerelease also offers betas and release candidates.
+	ChannelPrerelease Channel = "prerelease"
+)
+
+// Release is a published version of Guanaco.
+type Release struct {
+	Version     string    // Without the leading "v" of the tag
+	Name        string    // Title of the release
+	Notes       string    // Release notes, in Markdown
+	URL         string    // Page with the release notes and downloads
+	Prerelease  bool      // Beta or release candidate
+	PublishedAt time.Time // When the release was published
+}
+
+// githubRelease is a release in the GitHub API.
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Checker looks for new releases.
+type Checker struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewChecker creates a checker for the releases listed at url.
+func NewChecker(url string) *Checker {
+	return &Checker{
+		url:        url,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewCheckerDefault creates a checker for the releases of Guanaco.
+func NewCheckerDefault() *Checker {
+	return NewChecker(ReleasesURL)
+}
+
+// Due reports whether a week has passed since the last check.
+func Due(last, now time.Time) bool {
+	return now.Sub(last) >= CheckInterval
+}
+
+// Check returns the newest release of channel if it is newer than current,
+// or nil if current is up to date.
+func (c *Checker) Check(ctx context.Context, current string, channel Channel) (*Release, error) {
+	latest, err := c.Latest(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil || Compare(latest.Version, current) <= 0 {
+		return nil, nil
+	}
+	return latest, nil
+}
+
+// Latest returns the newest release of channel, or nil if there is none.
+// Drafts are never offered, and pre-releases only on ChannelPrerelease.
+func (c *Checker) Latest(ctx context.Context, channel Channel) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get releases: %s", resp.Status)
+	}
+
+	var releases []githubRelease
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse releases: %w", err)
+	}
+
+	var latest *Release
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != ChannelPrerelease) {
+			continue
+		}
+		version := strings.TrimPrefix(r.TagName, "v")
+		if _, ok := parseVersion(version); !ok {
+			continue
+		}
+		if latest != nil && Compare(version, latest.Version) <= 0 {
+			continue
+		}
+		latest = &Release{
+			Version:     version,
+			Name:        r.Name,
+			Notes:       r.Body,
+			URL:         r.HTMLURL,
+			Prerelease:  r.Prerelease,
+			PublishedAt: r.PublishedAt,
+		}
+	}
+	return latest, nil
+}
+
+// version is a parsed semantic version.
+type version struct {
+	core       [3]int
+	prerelease []string // Dot-separated identifiers after "-", nil for a release
+}
+
+// parseVersion parses a version such as "1.2.3" or "1.3.0-rc.1". A leading
+// "v" and build metadata after "+" are ignored, and missing minor or patch
+// numbers count as 0.
+func parseVersion(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		if pre == "" {
+			return v, false
+		}
+		v.prerelease = strings.Split(pre, ".")
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// Compare compares two versions, returning -1, 0 or 1 when a is older than,
+// the same as or newer than b. A pre-release is older than its release.
+// Versions that can't be parsed are older than any other.
+func Compare(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for i := range va.core {
+		if c := cmp.Compare(va.core[i], vb.core[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case va.prerelease == nil && vb.prerelease == nil:
+		return 0
+	case va.prerelease == nil:
+		return 1
+	case vb.prerelease == nil:
+		return -1
+	}
+	for i := 0; i < len(va.prerelease) && i < len(vb.prerelease); i++ {
+		if c := compareIdentifiers(va.prerelease[i], vb.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(va.prerelease), len(vb.prerelease))
+}
+
+// compareIdentifiers compares pre-release identifiers: numbers by value and
+// below words, which compare alphabetically.
+func compareIdentifiers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,170 @@
+package update
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const sampleReleases = `[
+	{"tag_name": "v0.4.0-rc.1", "name": "0.4.0 RC 1", "body": "Testing", "html_url": "https://example.com/0.4.0-rc.1", "prerelease": true, "published_at": "2026-03-01T10:00:00Z"},
+	{"tag_name": "v0.5.0", "name": "Draft", "draft": true},
+	{"tag_name": "v0.3.1", "name": "0.3.1", "body": "Fixes", "html_url": "https://example.com/0.3.1", "published_at": "2026-02-01T10:00:00Z"},
+	{"tag_name": "nightly", "name": "Nightly", "prerelease": true},
+	{"tag_name": "v0.3.0", "name": "0.3.0", "body": "Features", "html_url": "https://example.com/0.3.0"}
+]`
+
+// newReleasesServer serves body as the releases list.
+func newReleasesServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChecker_Latest(t *testing.T) {
+	server := newReleasesServer(t, http.StatusOK, sampleReleases)
+	checker := NewChecker(server.URL)
+
+	tests := []struct {
+		channel Channel
+		want    string
+	}{
+		{ChannelStable, "0.3.1"},
+		{ChannelPrerelease, "0.4.0-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.channel), func(t *testing.T) {
+			release, err := checker.Latest(context.Background(), tt.channel)
+			if err != nil {
+				t.Fatalf("Latest() error = %v", err)
+			}
+			if release == nil || release.Version != tt.want {
+				t.Fatalf("Latest() = %+v, want version %s", release, tt.want)
+			}
+		})
+	}
+
+	release, _ := checker.Latest(context.Background(), ChannelStable)
+	want := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+	if release.Notes != "Fixes" || release.URL != "https://example.com/0.3.1" || !release.PublishedAt.Equal(want) {
+		t.Errorf("Latest() = %+v, want the notes, page and date of 0.3.1", release)
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	server := newReleasesServer(t, http.StatusOK, sampleReleases)
+	checker := NewChecker(server.URL)
+
+	tests := []struct {
+		name    string
+		current string
+		channel Channel
+		want    string
+	}{
+		{"older", "0.3.0", ChannelStable, "0.3.1"},
+		{"up to date", "0.3.1", ChannelStable, ""},
+		{"newer development build", "0.4.0", ChannelPrerelease, ""},
+		{"pre-release of the running version", "0.3.1", ChannelPrerelease, "0.4.0-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release, err := checker.Check(context.Background(), tt.current, tt.channel)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			got := ""
+			if release != nil {
+				got = release.Version
+			}
+			if got != tt.want {
+				t.Errorf("Check(%q, %s) = %q, want %q", tt.current, tt.channel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"rate limited", http.StatusForbidden, `{"message": "API rate limit exceeded"}`},
+		{"invalid JSON", http.StatusOK, `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newReleasesServer(t, tt.status, tt.body)
+			if _, err := NewChecker(server.URL).Latest(context.Background(), ChannelStable); err == nil {
+				t.Error("Latest() error = nil, want an error")
+			}
+		})
+	}
+
+	t.Run("no releases", func(t *testing.T) {
+		server := newReleasesServer(t, http.StatusOK, `[]`)
+		release, err := NewChecker(server.URL).Latest(context.Background(), ChannelStable)
+		if err != nil || release != nil {
+			t.Errorf("Latest() = %v, %v, want nil and no error", release, err)
+		}
+	})
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.3+build.5", "1.2.3", 0},
+		{"1.10.0", "1.9.9", 1},
+		{"0.1.0", "0.2.0", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-rc", "1.0.0-rc.1", -1},
+		{"nightly", "0.1.0", -1},
+		{"1.0.0", "not-a-version", 1},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		last time.Time
+		want bool
+	}{
+		{"never checked", time.Time{}, true},
+		{"yesterday", now.Add(-24 * time.Hour), false},
+		{"a week ago", now.Add(-CheckInterval), true},
+		{"clock moved back", now.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Due(tt.last, now); got != tt.want {
+				t.Errorf("Due() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}